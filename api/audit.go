@@ -0,0 +1,25 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+	db "github.com/juker1141/simplebank/db/sqlc"
+	"github.com/juker1141/simplebank/requestid"
+)
+
+// auditActor returns who made the request and where it came from
+func (server *Server) auditActor(ctx *gin.Context, name string) db.AuditActor {
+	return db.AuditActor{
+		Name: name,
+		ClientIP: ctx.ClientIP(),
+		UserAgent: ctx.Request.UserAgent(),
+		RequestID: requestid.FromContext(ctx.Request.Context()),
+	}
+}
+
+// audit appends the events that aren't part of a transaction to the audit log.
+// The change is already made when it is audited, so a failure is logged rather than returned
+func (server *Server) audit(ctx *gin.Context, records ...db.AuditRecord) {
+	if err := server.store.AppendAuditEventsTx(ctx, records); err != nil {
+		requestid.Logger(ctx.Request.Context()).Error().Err(err).Msg("failed to append audit events")
+	}
+}
\ No newline at end of file
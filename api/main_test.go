@@ -17,6 +17,7 @@ func newTestServer(t *testing.T, store db.Store) *Server {
 	config := util.Config{
 		TokenSymmetricKey: 	 util.RandomString(32),
 		AccessTokenDuration: time.Minute,
+		BeneficiaryCoolDown: time.Hour,
 	}
 
 	server, err := NewServer(config, store)
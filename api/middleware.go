@@ -3,10 +3,15 @@ package api
 import (
 	"errors"
 	"fmt"
+	"math"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/juker1141/simplebank/policy"
+	"github.com/juker1141/simplebank/ratelimit"
+	"github.com/juker1141/simplebank/requestid"
 	"github.com/juker1141/simplebank/token"
 )
 
@@ -16,6 +21,18 @@ const (
 	authorizationPayloadKey = "authorization_payload"
 )
 
+// requestIDMiddleware gives each request a request ID, sent by the client or generated.
+// The ID is returned in the response header, like the gateway of the gRPC server does
+func requestIDMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id := requestid.Resolve(ctx.GetHeader(requestid.Header))
+		ctx.Header(requestid.Header, id)
+
+		ctx.Request = ctx.Request.WithContext(requestid.NewContext(ctx.Request.Context(), id))
+		ctx.Next()
+	}
+}
+
 func authMiddleware(tokenMaker token.Maker) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		authorizationHeader := ctx.GetHeader(authorizationHeaderKey)
@@ -47,6 +64,55 @@ func authMiddleware(tokenMaker token.Maker) gin.HandlerFunc {
 		}
 
 		ctx.Set(authorizationHeaderKey, payload)
+		ctx.Next()
+	}
+}
+
+// verifiedEmailMiddleware rejects the request if the email of the authenticated user must be verified first
+func verifiedEmailMiddleware(verifiedEmail *policy.VerifiedEmail) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		payload := ctx.MustGet(authorizationHeaderKey).(*token.Payload)
+
+		err := verifiedEmail.Check(ctx, payload)
+		if err != nil {
+			if errors.Is(err, policy.ErrEmailNotVerified) {
+				ctx.AbortWithStatusJSON(http.StatusForbidden, errorCodeResponse(policy.ReasonEmailNotVerified, err))
+				return
+			}
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// rateLimitMiddleware rejects the requests over the limits of the policies of the method,
+// the authenticated routes are also limited per user
+func rateLimitMiddleware(limiter *ratelimit.Limiter, method string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		identity := ratelimit.Identity{
+			IP: ctx.ClientIP(),
+		}
+		if payload, ok := ctx.Get(authorizationHeaderKey); ok {
+			identity.Username = payload.(*token.Payload).Username
+		}
+
+		result, err := limiter.Allow(ctx, method, identity)
+		if err != nil {
+			// the requests are allowed rather than all rejected while the backend is down
+			ctx.Next()
+			return
+		}
+
+		if !result.Allowed {
+			retryAfter := int(math.Ceil(result.RetryAfter.Seconds()))
+			ctx.Header("Retry-After", strconv.Itoa(retryAfter))
+			err := fmt.Errorf("too many requests, retry after %d seconds", retryAfter)
+			ctx.AbortWithStatusJSON(http.StatusTooManyRequests, errorCodeResponse(ratelimit.ReasonRateLimited, err))
+			return
+		}
+
 		ctx.Next()
 	}
 }
\ No newline at end of file
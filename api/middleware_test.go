@@ -1,6 +1,7 @@
 package api
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -8,7 +9,14 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/juker1141/simplebank/db/mock"
+	db "github.com/juker1141/simplebank/db/sqlc"
+	"github.com/juker1141/simplebank/policy"
+	"github.com/juker1141/simplebank/ratelimit"
+	"github.com/juker1141/simplebank/requestid"
 	"github.com/juker1141/simplebank/token"
+	"github.com/juker1141/simplebank/util"
 	"github.com/stretchr/testify/require"
 )
 
@@ -18,9 +26,10 @@ func addAuthorization(
 	tokenMaker token.Maker,
 	authorizationType string,
 	username string,
+	role string,
 	duration time.Duration,
 ) {
-	token, payload, err := tokenMaker.CreateToken(username, duration)
+	token, payload, err := tokenMaker.CreateToken(username, role, duration)
 	require.NoError(t, err)
 	require.NotEmpty(t, payload)
 
@@ -37,7 +46,7 @@ func TestAuthMiddlewareAPI(t *testing.T) {
 		{
 			name: "OK",
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "user", time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "user", util.DepositorRole, time.Minute)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
@@ -54,7 +63,7 @@ func TestAuthMiddlewareAPI(t *testing.T) {
 		{
 			name: "UnsupportedAuthorization",
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, "unsupported", "user", time.Minute)
+				addAuthorization(t, request, tokenMaker, "unsupported", "user", util.DepositorRole, time.Minute)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusUnauthorized, recorder.Code)
@@ -63,7 +72,7 @@ func TestAuthMiddlewareAPI(t *testing.T) {
 		{
 			name: "InvalidAuthorizationFormat",
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, "", "user", time.Minute)
+				addAuthorization(t, request, tokenMaker, "", "user", util.DepositorRole, time.Minute)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusUnauthorized, recorder.Code)
@@ -72,7 +81,7 @@ func TestAuthMiddlewareAPI(t *testing.T) {
 		{
 			name: "ExpiredToken",
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "user", -time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "user", util.DepositorRole, -time.Minute)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusUnauthorized, recorder.Code)
@@ -104,4 +113,202 @@ func TestAuthMiddlewareAPI(t *testing.T) {
 			tc.checkResponse(t, recorder)
 		})
 	}
-}
\ No newline at end of file
+}
+
+func TestVerifiedEmailMiddlewareAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	testCases := []struct{
+		name string
+		buildStubs func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				verifiedUser := user
+				verifiedUser.IsEmailVerified = true
+
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(verifiedUser, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "EmailNotVerified",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+
+				var rsp gin.H
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, policy.ReasonEmailNotVerified, rsp["code"])
+			},
+		},
+		{
+			name: "InternalError",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.User{}, db.ErrRecordNotFound)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			verifiedEmail := policy.NewVerifiedEmail(true, false, store)
+
+			path := "/verified"
+			server.router.POST(
+				path,
+				authMiddleware(server.tokenMaker),
+				verifiedEmailMiddleware(verifiedEmail),
+				func(ctx *gin.Context) {
+					ctx.JSON(http.StatusOK, gin.H{})
+				},
+			)
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodPost, path, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, user.Role, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+func TestRateLimitMiddlewareAPI(t *testing.T) {
+	server := newTestServer(t, nil)
+	limiter := ratelimit.NewLimiter([]ratelimit.Policy{
+		{Method: "LoginUser", Key: ratelimit.KeyIP, Limit: ratelimit.Limit{Rate: 1, Burst: 2}},
+	}, ratelimit.NewMemoryStore())
+
+	path := "/limited"
+	server.router.POST(
+		path,
+		rateLimitMiddleware(limiter, "LoginUser"),
+		func(ctx *gin.Context) {
+			ctx.JSON(http.StatusOK, gin.H{})
+		},
+	)
+
+	for i := 0; i < 2; i++ {
+		recorder := httptest.NewRecorder()
+		request, err := http.NewRequest(http.MethodPost, path, nil)
+		require.NoError(t, err)
+		request.RemoteAddr = "10.0.0.1:40000"
+
+		server.router.ServeHTTP(recorder, request)
+		require.Equal(t, http.StatusOK, recorder.Code)
+	}
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodPost, path, nil)
+	require.NoError(t, err)
+	request.RemoteAddr = "10.0.0.1:40001"
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	require.Equal(t, "1", recorder.Header().Get("Retry-After"))
+
+	var rsp gin.H
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+	require.Equal(t, ratelimit.ReasonRateLimited, rsp["code"])
+
+	// the client isn't a trusted proxy, so it can't pick another address with x-forwarded-for
+	recorder = httptest.NewRecorder()
+	request, err = http.NewRequest(http.MethodPost, path, nil)
+	require.NoError(t, err)
+	request.RemoteAddr = "10.0.0.1:40002"
+	request.Header.Set("X-Forwarded-For", "10.0.0.2")
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+}
+
+func TestRequestIDMiddlewareAPI(t *testing.T) {
+	testCases := []struct{
+		name string
+		requestID string
+		checkID func(t *testing.T, id string)
+	}{
+		{
+			name: "FromClient",
+			requestID: "client-request-1",
+			checkID: func(t *testing.T, id string) {
+				require.Equal(t, "client-request-1", id)
+			},
+		},
+		{
+			name: "Generated",
+			checkID: func(t *testing.T, id string) {
+				require.NotEmpty(t, id)
+			},
+		},
+		{
+			name: "Invalid",
+			requestID: "bad id\n",
+			checkID: func(t *testing.T, id string) {
+				require.NotEmpty(t, id)
+				require.NotEqual(t, "bad id\n", id)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			server := newTestServer(t, nil)
+
+			// the handler sees the ID that is sent back to the client
+			var handlerID string
+			path := "/request_id"
+			server.router.GET(
+				path,
+				func(ctx *gin.Context) {
+					handlerID = requestid.FromContext(ctx)
+					ctx.JSON(http.StatusOK, gin.H{})
+				},
+			)
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodGet, path, nil)
+			require.NoError(t, err)
+			if tc.requestID != "" {
+				request.Header.Set("X-Request-ID", tc.requestID)
+			}
+
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, http.StatusOK, recorder.Code)
+
+			id := recorder.Header().Get("X-Request-ID")
+			tc.checkID(t, id)
+			require.Equal(t, id, handlerID)
+		})
+	}
+}
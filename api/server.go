@@ -5,6 +5,8 @@ import (
 
 	"github.com/go-playground/validator/v10"
 	db "github.com/juker1141/simplebank/db/sqlc"
+	"github.com/juker1141/simplebank/policy"
+	"github.com/juker1141/simplebank/ratelimit"
 	"github.com/juker1141/simplebank/token"
 	"github.com/juker1141/simplebank/util"
 
@@ -17,6 +19,8 @@ type Server struct {
 	config util.Config
 	store db.Store
 	tokenMaker token.Maker
+	verifiedEmail *policy.VerifiedEmail
+	limiter *ratelimit.Limiter
 	router *gin.Engine
 }
 
@@ -27,36 +31,60 @@ func NewServer(config util.Config, store db.Store) (*Server, error) {
 		return nil, fmt.Errorf("cannot create token maker: %w", err)
 	}
 
+	limiter, err := ratelimit.New(config)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create rate limiter: %w", err)
+	}
+
 	server := &Server{
 		config: config,
 		store: store,
 		tokenMaker: tokenMaker,
+		verifiedEmail: policy.NewVerifiedEmail(config.RequireVerifiedEmail, config.TrustTokenEmailVerified, store),
+		limiter: limiter,
 	}
 
 	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
 		v.RegisterValidation("currency", validCurrency)
 	}
 
-	server.setupRouter()
+	if err := server.setupRouter(); err != nil {
+		return nil, err
+	}
 	return server, nil
 }
 
-func (server *Server) setupRouter() {
+func (server *Server) setupRouter() error {
 	router := gin.Default()
+
+	// gin trusts the x-forwarded-for header of every client by default,
+	// it is only read from the proxies in front of the server
+	if err := router.SetTrustedProxies(server.config.TrustedProxies); err != nil {
+		return fmt.Errorf("cannot set trusted proxies: %w", err)
+	}
+
+	// the handlers pass the gin context to the store and the logger,
+	// its values fall back to the request context so they see the request ID
+	router.ContextWithFallback = true
+	router.Use(requestIDMiddleware())
 	
-	router.POST("/users", server.createUser)
-	router.POST("/users/login", server.loginUser)
-	router.POST("/tokens/renew_access", server.renewAccessToken)
+	// the routes are limited by the policies of the RPCs of the gRPC server doing the same
+	router.POST("/users", rateLimitMiddleware(server.limiter, "CreateUser"), server.createUser)
+	router.POST("/users/login", rateLimitMiddleware(server.limiter, "LoginUser"), server.loginUser)
+	router.POST("/tokens/renew_access", rateLimitMiddleware(server.limiter, "RenewAccessToken"), server.renewAccessToken)
 
 	authRoutes := router.Group("/").Use(authMiddleware(server.tokenMaker))
 
-	authRoutes.POST("/accounts", server.createAccount)
-	authRoutes.GET("/accounts/:id", server.getAccount)
-	authRoutes.GET("/accounts", server.listAccounts)
+	// money can only be moved once the email of the user is verified
+	authRoutes.POST("/accounts", rateLimitMiddleware(server.limiter, "CreateAccount"), verifiedEmailMiddleware(server.verifiedEmail), server.createAccount)
+	authRoutes.GET("/accounts/:id", rateLimitMiddleware(server.limiter, "GetAccount"), server.getAccount)
+	authRoutes.GET("/accounts", rateLimitMiddleware(server.limiter, "ListAccounts"), server.listAccounts)
 
-	authRoutes.POST("/transfers", server.createTransfer)
+	authRoutes.POST("/transfers", rateLimitMiddleware(server.limiter, "CreateTransfer"), verifiedEmailMiddleware(server.verifiedEmail), server.createTransfer)
+	authRoutes.POST("/transfers/quote", rateLimitMiddleware(server.limiter, "QuoteTransfer"), server.quoteTransfer)
 	
 	server.router = router
+	return nil
 }
 
 // Start runs the HTTP server on a specific address.
@@ -66,4 +94,9 @@ func (server *Server) Start(address string) error {
 
 func errorResponse(err error) gin.H {
 	return gin.H{"error": err.Error()}
+}
+
+// errorCodeResponse also returns a stable code that clients can handle
+func errorCodeResponse(code string, err error) gin.H {
+	return gin.H{"error": err.Error(), "code": code}
 }
\ No newline at end of file
@@ -8,6 +8,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	db "github.com/juker1141/simplebank/db/sqlc"
+	"github.com/juker1141/simplebank/token"
 )
 
 type renewAccessTokenRequest struct {
@@ -68,7 +69,9 @@ func (server *Server) renewAccessToken(ctx *gin.Context) {
 
 	accessToken, accessPayload, err := server.tokenMaker.CreateToken(
 		refreshPayload.Username,
+		refreshPayload.Role,
 		server.config.AccessTokenDuration,
+		token.WithEmailVerified(refreshPayload.EmailVerified),
 	)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
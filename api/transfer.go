@@ -4,15 +4,19 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/juker1141/simplebank/audit"
 	db "github.com/juker1141/simplebank/db/sqlc"
 	"github.com/juker1141/simplebank/token"
+	"github.com/juker1141/simplebank/worker"
 )
 
 type transferRequest struct {
 	FromAccountID     int64  `json:"from_account_id" binding:"required,min=1"`
-	ToAccountID    		int64  `json:"to_account_id" binding:"required,min=1"`
+	ToAccountID    		int64  `json:"to_account_id" binding:"required_without=BeneficiaryID,omitempty,min=1"`
+	BeneficiaryID     int64  `json:"beneficiary_id" binding:"required_without=ToAccountID,omitempty,min=1"`
 	Amount						int64  `json:"amount" binding:"required,gt=0"`
 	Currency 					string `json:"currency" binding:"required,currency"`
 }
@@ -24,27 +28,19 @@ func (server *Server) createTransfer(ctx *gin.Context) {
 		return
 	}
 	
-	fromAccount, valid := server.validAccount(ctx, req.FromAccountID, req.Currency)
-	if !valid {
+	if !server.validTransfer(ctx, &req) {
 		return
 	}
 
 	authPayload := ctx.MustGet(authorizationHeaderKey).(*token.Payload)
-	if fromAccount.Owner != authPayload.Username {
-		err := errors.New("from account doesn't belong to the authenticated user")
-		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
-		return
-	}
-
-	_, valid = server.validAccount(ctx, req.ToAccountID, req.Currency)
-	if !valid {
-		return
-	}
+	actor := server.auditActor(ctx, audit.UserActor(authPayload.Username))
 
 	arg := db.TransferTxParams{
 		FromAccountID: req.FromAccountID,
 		ToAccountID: 	 req.ToAccountID,
 		Amount: 			 req.Amount,
+		Tasks: 				 worker.TransferTasks,
+		Audit: 				 audit.TransferRecords(actor),
 	}
 
 	result, err := server.store.TransferTx(ctx, arg)
@@ -56,6 +52,76 @@ func (server *Server) createTransfer(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, result)
 }
 
+type transferQuoteResponse struct {
+	Amount     int64         `json:"amount"`
+	Currency   string        `json:"currency"`
+	Fees       []db.FeeQuote `json:"fees"`
+	TotalFee   int64         `json:"total_fee"`
+	TotalDebit int64         `json:"total_debit"`
+}
+
+// quoteTransfer returns the fees that will be charged if the transfer is submitted
+func (server *Server) quoteTransfer(ctx *gin.Context) {
+	var req transferRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if !server.validTransfer(ctx, &req) {
+		return
+	}
+
+	fees, err := server.store.QuoteTransferFees(ctx, db.TransferTxParams{
+		FromAccountID: req.FromAccountID,
+		ToAccountID:   req.ToAccountID,
+		Amount:        req.Amount,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := transferQuoteResponse{
+		Amount:   req.Amount,
+		Currency: req.Currency,
+		Fees:     fees,
+	}
+	for _, fee := range fees {
+		rsp.TotalFee += fee.Amount
+	}
+	rsp.TotalDebit = rsp.Amount + rsp.TotalFee
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// validTransfer checks that both accounts exist with the requested currency
+// and that the authenticated user is allowed to transfer from the from account.
+// If the transfer targets a beneficiary, req.ToAccountID is set to its account
+func (server *Server) validTransfer(ctx *gin.Context, req *transferRequest) bool {
+	fromAccount, valid := server.validAccount(ctx, req.FromAccountID, req.Currency)
+	if !valid {
+		return false
+	}
+
+	if !server.authorizeAccount(ctx, fromAccount.ID, db.AccountMember.CanTransfer) {
+		return false
+	}
+
+	authPayload := ctx.MustGet(authorizationHeaderKey).(*token.Payload)
+
+	if req.BeneficiaryID > 0 {
+		beneficiary, valid := server.validBeneficiary(ctx, req.BeneficiaryID, authPayload.Username)
+		if !valid {
+			return false
+		}
+		req.ToAccountID = beneficiary.AccountID
+	}
+
+	_, valid = server.validAccount(ctx, req.ToAccountID, req.Currency)
+	return valid
+}
+
 func (server *Server) validAccount(ctx *gin.Context, accountId int64, currency string) (db.Account, bool) {
 	account, err := server.store.GetAccount(ctx, accountId)
 	if err != nil {
@@ -75,4 +141,33 @@ func (server *Server) validAccount(ctx *gin.Context, accountId int64, currency s
 	}
 
 	return account, true
+}
+
+// validBeneficiary checks that the beneficiary belongs to the user
+// and that its cool-down period is over
+func (server *Server) validBeneficiary(ctx *gin.Context, beneficiaryID int64, username string) (db.Beneficiary, bool) {
+	beneficiary, err := server.store.GetBeneficiary(ctx, beneficiaryID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return beneficiary, false
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return beneficiary, false
+	}
+
+	if beneficiary.Owner != username {
+		err := errors.New("beneficiary doesn't belong to the authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return beneficiary, false
+	}
+
+	if time.Since(beneficiary.CreatedAt) < server.config.BeneficiaryCoolDown {
+		err := fmt.Errorf("beneficiary [%d] cannot receive transfers until %s", beneficiary.ID, beneficiary.CreatedAt.Add(server.config.BeneficiaryCoolDown).Format(time.RFC3339))
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+		return beneficiary, false
+	}
+
+	return beneficiary, true
 }
\ No newline at end of file
@@ -4,8 +4,10 @@ import (
 	"bytes"
 	"database/sql"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"testing"
 	"time"
 
@@ -15,9 +17,70 @@ import (
 	db "github.com/juker1141/simplebank/db/sqlc"
 	"github.com/juker1141/simplebank/token"
 	"github.com/juker1141/simplebank/util"
+	"github.com/juker1141/simplebank/worker"
 	"github.com/stretchr/testify/require"
 )
 
+type eqTransferTxParamsMatcher struct {
+	arg db.TransferTxParams
+}
+
+func (expected eqTransferTxParamsMatcher) Matches(x interface{}) bool {
+	actualArg, ok := x.(db.TransferTxParams)
+	if !ok {
+		return false
+	}
+
+	if actualArg.FromAccountID != expected.arg.FromAccountID ||
+		actualArg.ToAccountID != expected.arg.ToAccountID ||
+		actualArg.Amount != expected.arg.Amount {
+		return false
+	}
+
+	// both sides of the transfer and their webhook endpoints are notified once it is committed
+	result := db.TransferTxResult{
+		Transfer: db.Transfer{ID: util.RandomInt(1, 1000)},
+		FromAccount: db.Account{ID: expected.arg.FromAccountID, Owner: util.RandomOwner()},
+		ToAccount: db.Account{ID: expected.arg.ToAccountID, Owner: util.RandomOwner()},
+	}
+	tasks := actualArg.Tasks(result)
+	if len(tasks) != 5 {
+		return false
+	}
+
+	// the transfer is audited within its transaction
+	if actualArg.Audit == nil {
+		return false
+	}
+	records := actualArg.Audit(result)
+	if len(records) != 1 || records[0].EventType != db.AuditEventTransfer || records[0].TargetType != db.AuditTargetTransfer {
+		return false
+	}
+
+	for i, eventType := range []string{util.WebhookEventTransferCreated, util.WebhookEventAccountUpdated, util.WebhookEventAccountUpdated} {
+		webhookPayload, ok := tasks[2+i].Payload.(*worker.PayloadDispatchWebhookEvent)
+		if !ok || tasks[2+i].TaskType != worker.TaskDispatchWebhookEvent || webhookPayload.Event.Type != eventType {
+			return false
+		}
+	}
+
+	payload := &worker.PayloadSendTransferNotification{
+		TransferID: result.Transfer.ID,
+	}
+	return tasks[0].TaskType == worker.TaskSendDebitNotification &&
+		tasks[1].TaskType == worker.TaskSendCreditNotification &&
+		reflect.DeepEqual(tasks[0].Payload, payload) &&
+		reflect.DeepEqual(tasks[1].Payload, payload)
+}
+
+func (e eqTransferTxParamsMatcher) String() string {
+	return fmt.Sprintf("matches arg %v", e.arg)
+}
+
+func eqTransferTxParams(arg db.TransferTxParams) gomock.Matcher {
+	return eqTransferTxParamsMatcher{arg}
+}
+
 func TestCreateTransferAPI(t *testing.T) {
 	amount := int64(10)
 
@@ -33,6 +96,18 @@ func TestCreateTransferAPI(t *testing.T) {
 	account2.Currency = util.USD
 	account3.Currency = util.EUR
 
+	beneficiary := db.Beneficiary{
+		ID: util.RandomInt(1, 1000),
+		Owner: user1.Username,
+		Nickname: util.RandomOwner(),
+		AccountID: account2.ID,
+		Currency: account2.Currency,
+		CreatedAt: time.Now().Add(-2 * time.Hour),
+	}
+
+	newBeneficiary := beneficiary
+	newBeneficiary.CreatedAt = time.Now()
+
 	testCases := []struct{
 		name string
 		body gin.H
@@ -49,13 +124,17 @@ func TestCreateTransferAPI(t *testing.T) {
 				"currency": util.USD,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
 					GetAccount(gomock.Any(), gomock.Eq(account1.ID)).
 					Times(1).
 					Return(account1, nil)
+				store.EXPECT().
+					GetAccountMember(gomock.Any(), gomock.Eq(db.GetAccountMemberParams{AccountID: account1.ID, Username: user1.Username})).
+					Times(1).
+					Return(randomAccountMember(account1, user1.Username, util.AccountOwnerRole), nil)
 				store.EXPECT().
 					GetAccount(gomock.Any(), gomock.Eq(account2.ID)).
 					Times(1).
@@ -67,7 +146,7 @@ func TestCreateTransferAPI(t *testing.T) {
 					Amount: amount,
 				}
 				store.EXPECT().
-					TransferTx(gomock.Any(), gomock.Eq(arg)).
+					TransferTx(gomock.Any(), eqTransferTxParams(arg)).
 					Times(1)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
@@ -83,13 +162,17 @@ func TestCreateTransferAPI(t *testing.T) {
 				"currency": util.USD,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user2.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user2.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
 					GetAccount(gomock.Any(), gomock.Eq(account1.ID)).
 					Times(1).
 					Return(account1, nil)
+				store.EXPECT().
+					GetAccountMember(gomock.Any(), gomock.Eq(db.GetAccountMemberParams{AccountID: account1.ID, Username: user2.Username})).
+					Times(1).
+					Return(db.AccountMember{}, db.ErrRecordNotFound)
 				store.EXPECT().
 					GetAccount(gomock.Any(), gomock.Eq(account2.ID)).
 					Times(0)
@@ -102,6 +185,73 @@ func TestCreateTransferAPI(t *testing.T) {
 				require.Equal(t, http.StatusUnauthorized, recorder.Code)
 			},
 		},
+		{
+			name: "ViewOnlyMember",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id": account2.ID,
+				"amount": amount,
+				"currency": util.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user2.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account1.ID)).
+					Times(1).
+					Return(account1, nil)
+				store.EXPECT().
+					GetAccountMember(gomock.Any(), gomock.Eq(db.GetAccountMemberParams{AccountID: account1.ID, Username: user2.Username})).
+					Times(1).
+					Return(randomAccountMember(account1, user2.Username, util.AccountViewOnlyRole), nil)
+
+				store.EXPECT().
+					TransferTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "CanTransferMember",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id": account2.ID,
+				"amount": amount,
+				"currency": util.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user2.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account1.ID)).
+					Times(1).
+					Return(account1, nil)
+				store.EXPECT().
+					GetAccountMember(gomock.Any(), gomock.Eq(db.GetAccountMemberParams{AccountID: account1.ID, Username: user2.Username})).
+					Times(1).
+					Return(randomAccountMember(account1, user2.Username, util.AccountCanTransferRole), nil)
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account2.ID)).
+					Times(1).
+					Return(account2, nil)
+
+				arg := db.TransferTxParams{
+					FromAccountID: account1.ID,
+					ToAccountID: account2.ID,
+					Amount: amount,
+				}
+				store.EXPECT().
+					TransferTx(gomock.Any(), eqTransferTxParams(arg)).
+					Times(1)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
 		{
 			name: "NoAuthorization",
 			body: gin.H{
@@ -133,7 +283,7 @@ func TestCreateTransferAPI(t *testing.T) {
 				"currency": util.USD,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
@@ -161,13 +311,17 @@ func TestCreateTransferAPI(t *testing.T) {
 				"currency": util.USD,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
 					GetAccount(gomock.Any(), gomock.Eq(account1.ID)).
 					Times(1).
 					Return(account1, nil)
+				store.EXPECT().
+					GetAccountMember(gomock.Any(), gomock.Eq(db.GetAccountMemberParams{AccountID: account1.ID, Username: user1.Username})).
+					Times(1).
+					Return(randomAccountMember(account1, user1.Username, util.AccountOwnerRole), nil)
 				store.EXPECT().
 					GetAccount(gomock.Any(), gomock.Eq(account2.ID)).
 					Times(1).
@@ -190,7 +344,7 @@ func TestCreateTransferAPI(t *testing.T) {
 				"currency": util.USD,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user3.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user3.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
@@ -218,13 +372,17 @@ func TestCreateTransferAPI(t *testing.T) {
 				"currency": util.USD,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
 					GetAccount(gomock.Any(), gomock.Eq(account1.ID)).
 					Times(1).
 					Return(account1, nil)
+				store.EXPECT().
+					GetAccountMember(gomock.Any(), gomock.Eq(db.GetAccountMemberParams{AccountID: account1.ID, Username: user1.Username})).
+					Times(1).
+					Return(randomAccountMember(account1, user1.Username, util.AccountOwnerRole), nil)
 				store.EXPECT().
 					GetAccount(gomock.Any(), gomock.Eq(account3.ID)).
 					Times(1).
@@ -247,7 +405,7 @@ func TestCreateTransferAPI(t *testing.T) {
 				"currency": "XYZ",
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
@@ -271,7 +429,7 @@ func TestCreateTransferAPI(t *testing.T) {
 				"currency": util.USD,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
@@ -295,7 +453,7 @@ func TestCreateTransferAPI(t *testing.T) {
 				"currency": util.USD,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
@@ -320,13 +478,17 @@ func TestCreateTransferAPI(t *testing.T) {
 				"currency": util.USD,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
 					GetAccount(gomock.Any(), gomock.Eq(account1.ID)).
 					Times(1).
 					Return(account1, nil)
+				store.EXPECT().
+					GetAccountMember(gomock.Any(), gomock.Eq(db.GetAccountMemberParams{AccountID: account1.ID, Username: user1.Username})).
+					Times(1).
+					Return(randomAccountMember(account1, user1.Username, util.AccountOwnerRole), nil)
 				store.EXPECT().
 					GetAccount(gomock.Any(), gomock.Eq(account2.ID)).
 					Times(1).
@@ -341,6 +503,137 @@ func TestCreateTransferAPI(t *testing.T) {
 				require.Equal(t, http.StatusInternalServerError, recorder.Code)
 			},
 		},
+		{
+			name: "OKBeneficiary",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"beneficiary_id": beneficiary.ID,
+				"amount": amount,
+				"currency": util.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account1.ID)).
+					Times(1).
+					Return(account1, nil)
+				store.EXPECT().
+					GetAccountMember(gomock.Any(), gomock.Eq(db.GetAccountMemberParams{AccountID: account1.ID, Username: user1.Username})).
+					Times(1).
+					Return(randomAccountMember(account1, user1.Username, util.AccountOwnerRole), nil)
+				store.EXPECT().
+					GetBeneficiary(gomock.Any(), gomock.Eq(beneficiary.ID)).
+					Times(1).
+					Return(beneficiary, nil)
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account2.ID)).
+					Times(1).
+					Return(account2, nil)
+
+				arg := db.TransferTxParams{
+					FromAccountID: account1.ID,
+					ToAccountID: account2.ID,
+					Amount: amount,
+				}
+				store.EXPECT().
+					TransferTx(gomock.Any(), eqTransferTxParams(arg)).
+					Times(1)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "BeneficiaryCoolDown",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"beneficiary_id": newBeneficiary.ID,
+				"amount": amount,
+				"currency": util.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account1.ID)).
+					Times(1).
+					Return(account1, nil)
+				store.EXPECT().
+					GetAccountMember(gomock.Any(), gomock.Eq(db.GetAccountMemberParams{AccountID: account1.ID, Username: user1.Username})).
+					Times(1).
+					Return(randomAccountMember(account1, user1.Username, util.AccountOwnerRole), nil)
+				store.EXPECT().
+					GetBeneficiary(gomock.Any(), gomock.Eq(newBeneficiary.ID)).
+					Times(1).
+					Return(newBeneficiary, nil)
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account2.ID)).
+					Times(0)
+				store.EXPECT().
+					TransferTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "BeneficiaryOfOtherUser",
+			body: gin.H{
+				"from_account_id": account3.ID,
+				"beneficiary_id": beneficiary.ID,
+				"amount": amount,
+				"currency": util.EUR,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user3.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account3.ID)).
+					Times(1).
+					Return(account3, nil)
+				store.EXPECT().
+					GetAccountMember(gomock.Any(), gomock.Eq(db.GetAccountMemberParams{AccountID: account3.ID, Username: user3.Username})).
+					Times(1).
+					Return(randomAccountMember(account3, user3.Username, util.AccountOwnerRole), nil)
+				store.EXPECT().
+					GetBeneficiary(gomock.Any(), gomock.Eq(beneficiary.ID)).
+					Times(1).
+					Return(beneficiary, nil)
+				store.EXPECT().
+					TransferTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "NoRecipient",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"amount": amount,
+				"currency": util.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().
+					TransferTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
 	}
 
 	for i := range testCases {
@@ -367,4 +660,172 @@ func TestCreateTransferAPI(t *testing.T) {
 		server.router.ServeHTTP(recorder, request)
 		tc.checkResponse(recorder)
 	}
-}
\ No newline at end of file
+}
+
+func TestQuoteTransferAPI(t *testing.T) {
+	amount := int64(10)
+
+	user1, _ := randomUser(t)
+	user2, _ := randomUser(t)
+
+	account1 := randomAccount(user1.Username)
+	account2 := randomAccount(user2.Username)
+
+	account1.Currency = util.USD
+	account2.Currency = util.USD
+
+	fees := []db.FeeQuote{
+		{
+			FeeRuleID:        util.RandomInt(1, 1000),
+			Name:             util.RandomString(8),
+			Amount:           2,
+			Currency:         util.USD,
+			RevenueAccountID: util.RandomInt(1, 1000),
+		},
+	}
+
+	testCases := []struct{
+		name string
+		body gin.H
+		setupAuth func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id": account2.ID,
+				"amount": amount,
+				"currency": util.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account1.ID)).
+					Times(1).
+					Return(account1, nil)
+				store.EXPECT().
+					GetAccountMember(gomock.Any(), gomock.Eq(db.GetAccountMemberParams{AccountID: account1.ID, Username: user1.Username})).
+					Times(1).
+					Return(randomAccountMember(account1, user1.Username, util.AccountOwnerRole), nil)
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account2.ID)).
+					Times(1).
+					Return(account2, nil)
+
+				arg := db.TransferTxParams{
+					FromAccountID: account1.ID,
+					ToAccountID: account2.ID,
+					Amount: amount,
+				}
+				store.EXPECT().
+					QuoteTransferFees(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(fees, nil)
+				store.EXPECT().
+					TransferTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp transferQuoteResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.Equal(t, amount, rsp.Amount)
+				require.Equal(t, fees, rsp.Fees)
+				require.Equal(t, int64(2), rsp.TotalFee)
+				require.Equal(t, amount + 2, rsp.TotalDebit)
+			},
+		},
+		{
+			name: "UnauthorizedUser",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id": account2.ID,
+				"amount": amount,
+				"currency": util.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user2.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account1.ID)).
+					Times(1).
+					Return(account1, nil)
+				store.EXPECT().
+					GetAccountMember(gomock.Any(), gomock.Eq(db.GetAccountMemberParams{AccountID: account1.ID, Username: user2.Username})).
+					Times(1).
+					Return(db.AccountMember{}, db.ErrRecordNotFound)
+				store.EXPECT().
+					QuoteTransferFees(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id": account2.ID,
+				"amount": amount,
+				"currency": util.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Any()).
+					Times(2).
+					DoAndReturn(func(_ interface{}, id int64) (db.Account, error) {
+						if id == account1.ID {
+							return account1, nil
+						}
+						return account2, nil
+					})
+				store.EXPECT().
+					GetAccountMember(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(randomAccountMember(account1, user1.Username, util.AccountOwnerRole), nil)
+				store.EXPECT().
+					QuoteTransferFees(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		store := mockdb.NewMockStore(ctrl)
+		tc.buildStubs(store)
+
+		server := newTestServer(t, store)
+		recorder := httptest.NewRecorder()
+
+		jsonData, err := json.Marshal(tc.body)
+		require.NoError(t, err)
+
+		url := "/transfers/quote"
+		request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(jsonData))
+		require.NoError(t, err)
+
+		tc.setupAuth(t, request, server.tokenMaker)
+		server.router.ServeHTTP(recorder, request)
+		tc.checkResponse(recorder)
+	}
+}
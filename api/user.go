@@ -7,7 +7,9 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/juker1141/simplebank/audit"
 	db "github.com/juker1141/simplebank/db/sqlc"
+	"github.com/juker1141/simplebank/token"
 	"github.com/juker1141/simplebank/util"
 )
 
@@ -96,6 +98,7 @@ func (server *Server) loginUser(ctx *gin.Context) {
 	user, err := server.store.GetUser(ctx, req.Username)
 	if err != nil {
 		if errors.Is(err, db.ErrRecordNotFound) {
+			server.audit(ctx, audit.LoginFailedRecord(server.auditActor(ctx, db.AuditActorAnonymous), req.Username, "user not found"))
 			ctx.JSON(http.StatusNotFound, errorResponse(err))
 			return
 		}
@@ -105,13 +108,23 @@ func (server *Server) loginUser(ctx *gin.Context) {
 
 	err = util.CheckPassword(req.Password, user.HashedPassword)
 	if err != nil {
+		server.audit(ctx, audit.LoginFailedRecord(server.auditActor(ctx, db.AuditActorAnonymous), user.Username, "incorrect password"))
 		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
 		return
 	}
 
+	if user.IsLocked {
+		server.audit(ctx, audit.LoginFailedRecord(server.auditActor(ctx, db.AuditActorAnonymous), user.Username, "user is locked"))
+		err := errors.New("user is locked")
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+		return
+	}
+
 	accessToken, accessPayload, err := server.tokenMaker.CreateToken(
 		user.Username,
+		user.Role,
 		server.config.AccessTokenDuration,
+		token.WithEmailVerified(user.IsEmailVerified),
 	)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
@@ -120,7 +133,9 @@ func (server *Server) loginUser(ctx *gin.Context) {
 
 	refreshToken, refreshPayload, err := server.tokenMaker.CreateToken(
 		user.Username,
+		user.Role,
 		server.config.RefreshTokenDuration,
+		token.WithEmailVerified(user.IsEmailVerified),
 	)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
@@ -141,6 +156,8 @@ func (server *Server) loginUser(ctx *gin.Context) {
 		return
 	}
 
+	server.audit(ctx, audit.LoginRecord(server.auditActor(ctx, audit.UserActor(user.Username)), session))
+
 	rsp := loginUserResponse{
 		SessionId: session.ID,
 		AccessToken: accessToken,
@@ -2,6 +2,7 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
@@ -13,6 +14,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang/mock/gomock"
+	"github.com/juker1141/simplebank/audit"
 	mockdb "github.com/juker1141/simplebank/db/mock"
 	db "github.com/juker1141/simplebank/db/sqlc"
 	"github.com/juker1141/simplebank/util"
@@ -220,6 +222,16 @@ func TestLoginUserAPI(t *testing.T) {
 				store.EXPECT().
 					CreateSession(gomock.Any(), gomock.Any()).
 					Times(1)
+				store.EXPECT().
+					AppendAuditEventsTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, records []db.AuditRecord) error {
+						require.Len(t, records, 1)
+						require.Equal(t, db.AuditEventLogin, records[0].EventType)
+						require.Equal(t, audit.UserActor(user.Username), records[0].Actor.Name)
+						require.Equal(t, db.AuditTargetSession, records[0].TargetType)
+						return nil
+					})
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
@@ -236,6 +248,17 @@ func TestLoginUserAPI(t *testing.T) {
 					GetUser(gomock.Any(), gomock.Any()).
 					Times(1).
 					Return(db.User{}, db.ErrRecordNotFound)
+				store.EXPECT().
+					AppendAuditEventsTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, records []db.AuditRecord) error {
+						require.Len(t, records, 1)
+						require.Equal(t, db.AuditEventLoginFailed, records[0].EventType)
+						require.Equal(t, db.AuditActorAnonymous, records[0].Actor.Name)
+						require.Equal(t, "NotFound", records[0].TargetID)
+						require.Equal(t, map[string]string{"reason": "user not found"}, records[0].After)
+						return nil
+					})
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusNotFound, recorder.Code)
@@ -252,11 +275,54 @@ func TestLoginUserAPI(t *testing.T) {
 					GetUser(gomock.Any(), gomock.Eq(user.Username)).
 					Times(1).
 					Return(user, nil)
+				store.EXPECT().
+					AppendAuditEventsTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, records []db.AuditRecord) error {
+						require.Len(t, records, 1)
+						require.Equal(t, db.AuditEventLoginFailed, records[0].EventType)
+						require.Equal(t, db.AuditActorAnonymous, records[0].Actor.Name)
+						require.Equal(t, user.Username, records[0].TargetID)
+						require.Equal(t, map[string]string{"reason": "incorrect password"}, records[0].After)
+						return nil
+					})
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusUnauthorized, recorder.Code)
 			},
 		},
+		{
+			name: "UserLocked",
+			body: gin.H{
+				"username": user.Username,
+				"password": password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				lockedUser := user
+				lockedUser.IsLocked = true
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(lockedUser, nil)
+				store.EXPECT().
+					CreateSession(gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().
+					AppendAuditEventsTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, records []db.AuditRecord) error {
+						require.Len(t, records, 1)
+						require.Equal(t, db.AuditEventLoginFailed, records[0].EventType)
+						require.Equal(t, db.AuditActorAnonymous, records[0].Actor.Name)
+						require.Equal(t, user.Username, records[0].TargetID)
+						require.Equal(t, map[string]string{"reason": "user is locked"}, records[0].After)
+						return nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
 		{
 			name: "InternalError",
 			body: gin.H{
@@ -268,6 +334,9 @@ func TestLoginUserAPI(t *testing.T) {
 					GetUser(gomock.Any(), gomock.Any()).
 					Times(1).
 					Return(db.User{}, sql.ErrConnDone)
+				store.EXPECT().
+					AppendAuditEventsTx(gomock.Any(), gomock.Any()).
+					Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusInternalServerError, recorder.Code)
@@ -326,6 +395,7 @@ func randomUser(t *testing.T) (user db.User, password string) {
 		HashedPassword: hashedPassword,
 		FullName: util.RandomOwner(),
 		Email: util.RandomEmail(),
+		Role: util.DepositorRole,
 	}
 	return
 } 
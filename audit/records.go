@@ -0,0 +1,288 @@
+// Package audit builds the records of the audit log, with the snapshots of the targets before and after the changes
+package audit
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	db "github.com/juker1141/simplebank/db/sqlc"
+)
+
+// User is the snapshot of a user in the audit events, without the password hash
+type User struct {
+	Username          string    `json:"username"`
+	FullName          string    `json:"full_name"`
+	Email             string    `json:"email"`
+	PendingEmail      string    `json:"pending_email"`
+	IsEmailVerified   bool      `json:"is_email_verified"`
+	Role              string    `json:"role"`
+	IsLocked          bool      `json:"is_locked"`
+	PasswordChangedAt time.Time `json:"password_changed_at"`
+}
+
+// Session is the snapshot of a session in the audit events, without the refresh token
+type Session struct {
+	ID        string    `json:"id"`
+	Username  string    `json:"username"`
+	UserAgent string    `json:"user_agent"`
+	ClientIP  string    `json:"client_ip"`
+	IsBlocked bool      `json:"is_blocked"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Balance is the balance of an account in the snapshots of the transfers
+type Balance struct {
+	AccountID int64 `json:"account_id"`
+	Balance   int64 `json:"balance"`
+}
+
+type Transfer struct {
+	ID       int64     `json:"id,omitempty"`
+	Amount   int64     `json:"amount,omitempty"`
+	Fees     int64     `json:"fees,omitempty"`
+	Balances []Balance `json:"balances"`
+}
+
+// UserActor is the name of a user in the audit events, in the format of the operators
+func UserActor(username string) string {
+	return fmt.Sprintf("user:%s", username)
+}
+
+func convertUser(user db.User) User {
+	return User{
+		Username:          user.Username,
+		FullName:          user.FullName,
+		Email:             user.Email,
+		PendingEmail:      user.PendingEmail,
+		IsEmailVerified:   user.IsEmailVerified,
+		Role:              user.Role,
+		IsLocked:          user.IsLocked,
+		PasswordChangedAt: user.PasswordChangedAt,
+	}
+}
+
+func convertSession(session db.Session) Session {
+	return Session{
+		ID:        session.ID.String(),
+		Username:  session.Username,
+		UserAgent: session.UserAgent,
+		ClientIP:  session.ClientIp,
+		IsBlocked: session.IsBlocked,
+		ExpiresAt: session.ExpiresAt,
+	}
+}
+
+// sessionRevokedRecords audits the revoked sessions, the sessions before the change are the same but not blocked
+func sessionRevokedRecords(actor db.AuditActor, sessions []db.Session) []db.AuditRecord {
+	records := make([]db.AuditRecord, 0, len(sessions))
+	for _, session := range sessions {
+		before := convertSession(session)
+		before.IsBlocked = false
+
+		records = append(records, db.AuditRecord{
+			EventType:  db.AuditEventSessionRevoked,
+			Actor:      actor,
+			TargetType: db.AuditTargetSession,
+			TargetID:   session.ID.String(),
+			Before:     before,
+			After:      convertSession(session),
+		})
+	}
+	return records
+}
+
+// SetUserLockedRecords audits the lock or the unlock of a user, and the sessions revoked with the lock
+func SetUserLockedRecords(actor db.AuditActor) func(result db.SetUserLockedTxResult) []db.AuditRecord {
+	return func(result db.SetUserLockedTxResult) []db.AuditRecord {
+		var records []db.AuditRecord
+
+		if result.User.IsLocked != result.PreviousUser.IsLocked {
+			records = append(records, db.AuditRecord{
+				EventType:  db.AuditEventUserStatusChanged,
+				Actor:      actor,
+				TargetType: db.AuditTargetUser,
+				TargetID:   result.User.Username,
+				Before:     convertUser(result.PreviousUser),
+				After:      convertUser(result.User),
+			})
+		}
+
+		return append(records, sessionRevokedRecords(actor, result.RevokedSessions)...)
+	}
+}
+
+// RevokeSessionsRecords audits the sessions revoked by an operator
+func RevokeSessionsRecords(actor db.AuditActor) func(result db.RevokeSessionsTxResult) []db.AuditRecord {
+	return func(result db.RevokeSessionsTxResult) []db.AuditRecord {
+		return sessionRevokedRecords(actor, result.Sessions)
+	}
+}
+
+// UpdateUserRecords audits the changes of the password and the email of the user
+func UpdateUserRecords(actor db.AuditActor) func(result db.UpdateUserTxResult) []db.AuditRecord {
+	return func(result db.UpdateUserTxResult) []db.AuditRecord {
+		var records []db.AuditRecord
+
+		before := convertUser(result.PreviousUser)
+		after := convertUser(result.User)
+
+		if !result.User.PasswordChangedAt.Equal(result.PreviousUser.PasswordChangedAt) {
+			records = append(records, db.AuditRecord{
+				EventType:  db.AuditEventPasswordChanged,
+				Actor:      actor,
+				TargetType: db.AuditTargetUser,
+				TargetID:   result.User.Username,
+				Before:     before,
+				After:      after,
+			})
+		}
+
+		if result.User.PendingEmail != result.PreviousUser.PendingEmail && result.User.PendingEmail != "" {
+			records = append(records, db.AuditRecord{
+				EventType:  db.AuditEventEmailChangeRequested,
+				Actor:      actor,
+				TargetType: db.AuditTargetUser,
+				TargetID:   result.User.Username,
+				Before:     before,
+				After:      after,
+			})
+		}
+
+		return records
+	}
+}
+
+// VerifyEmailRecords audits the verification of a pending email, which replaces the email of the user.
+// The secret code proves that the user made the request, so the user is the actor
+func VerifyEmailRecords(actor db.AuditActor) func(result db.VerifyEmailTxResult) []db.AuditRecord {
+	return func(result db.VerifyEmailTxResult) []db.AuditRecord {
+		if result.User.Email == result.PreviousUser.Email {
+			return nil
+		}
+		actor.Name = UserActor(result.User.Username)
+
+		return []db.AuditRecord{
+			{
+				EventType:  db.AuditEventEmailChanged,
+				Actor:      actor,
+				TargetType: db.AuditTargetUser,
+				TargetID:   result.User.Username,
+				Before:     convertUser(result.PreviousUser),
+				After:      convertUser(result.User),
+			},
+		}
+	}
+}
+
+// TransferRecords audits a transfer with the balances of both accounts before and after it
+func TransferRecords(actor db.AuditActor) func(result db.TransferTxResult) []db.AuditRecord {
+	return func(result db.TransferTxResult) []db.AuditRecord {
+		fees := int64(0)
+		for _, fee := range result.Fees {
+			fees += fee.Amount
+		}
+
+		return []db.AuditRecord{
+			{
+				EventType:  db.AuditEventTransfer,
+				Actor:      actor,
+				TargetType: db.AuditTargetTransfer,
+				TargetID:   strconv.FormatInt(result.Transfer.ID, 10),
+				Before: Transfer{
+					Balances: []Balance{
+						{AccountID: result.FromAccount.ID, Balance: result.FromAccount.Balance + result.Transfer.Amount + fees},
+						{AccountID: result.ToAccount.ID, Balance: result.ToAccount.Balance - result.Transfer.Amount},
+					},
+				},
+				After: Transfer{
+					ID:     result.Transfer.ID,
+					Amount: result.Transfer.Amount,
+					Fees:   fees,
+					Balances: []Balance{
+						{AccountID: result.FromAccount.ID, Balance: result.FromAccount.Balance},
+						{AccountID: result.ToAccount.ID, Balance: result.ToAccount.Balance},
+					},
+				},
+			},
+		}
+	}
+}
+
+// ExternalTransferRecords audits a deposit or a withdrawal with the balance of the account before and after it
+func ExternalTransferRecords(actor db.AuditActor) func(result db.ExternalTransferTxResult) []db.AuditRecord {
+	return func(result db.ExternalTransferTxResult) []db.AuditRecord {
+		eventType := db.AuditEventDeposit
+		amount := result.ExternalTransfer.Amount
+		if result.ExternalTransfer.Type == db.ExternalTransferTypeWithdrawal {
+			eventType = db.AuditEventWithdrawal
+			amount = -amount
+		}
+
+		return []db.AuditRecord{
+			{
+				EventType:  eventType,
+				Actor:      actor,
+				TargetType: db.AuditTargetExternalTransfer,
+				TargetID:   strconv.FormatInt(result.ExternalTransfer.ID, 10),
+				Before: Transfer{
+					Balances: []Balance{
+						{AccountID: result.Account.ID, Balance: result.Account.Balance - amount},
+					},
+				},
+				After: Transfer{
+					ID:     result.ExternalTransfer.ID,
+					Amount: result.ExternalTransfer.Amount,
+					Balances: []Balance{
+						{AccountID: result.Account.ID, Balance: result.Account.Balance},
+					},
+				},
+			},
+		}
+	}
+}
+
+// LoginRecord audits the session created by a successful login
+func LoginRecord(actor db.AuditActor, session db.Session) db.AuditRecord {
+	return db.AuditRecord{
+		EventType:  db.AuditEventLogin,
+		Actor:      actor,
+		TargetType: db.AuditTargetSession,
+		TargetID:   session.ID.String(),
+		After: map[string]any{
+			"username":   session.Username,
+			"expires_at": session.ExpiresAt,
+		},
+	}
+}
+
+// LoginFailedRecord audits a failed login of the user. The user might not exist
+func LoginFailedRecord(actor db.AuditActor, username string, reason string) db.AuditRecord {
+	return db.AuditRecord{
+		EventType:  db.AuditEventLoginFailed,
+		Actor:      actor,
+		TargetType: db.AuditTargetUser,
+		TargetID:   username,
+		After: map[string]string{
+			"reason": reason,
+		},
+	}
+}
+
+// TaskTarget is the ID of a task in the audit events, task IDs are only unique within their queue
+func TaskTarget(queue string, id string) string {
+	return fmt.Sprintf("%s/%s", queue, id)
+}
+
+// AdminRecord audits an operation of an operator on the worker or another part of the system
+func AdminRecord(actor db.AuditActor, targetType string, targetID string, action string) db.AuditRecord {
+	return db.AuditRecord{
+		EventType:  db.AuditEventAdminAction,
+		Actor:      actor,
+		TargetType: targetType,
+		TargetID:   targetID,
+		After: map[string]string{
+			"action": action,
+		},
+	}
+}
@@ -0,0 +1,55 @@
+package audit
+
+import (
+	"testing"
+	"time"
+
+	db "github.com/juker1141/simplebank/db/sqlc"
+	"github.com/juker1141/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransferRecords(t *testing.T) {
+	actor := db.AuditActor{Name: UserActor("alice")}
+	records := TransferRecords(actor)(db.TransferTxResult{
+		Transfer:    db.Transfer{ID: 7, FromAccountID: 1, ToAccountID: 2, Amount: 100},
+		FromAccount: db.Account{ID: 1, Balance: 895},
+		ToAccount:   db.Account{ID: 2, Balance: 100},
+		Fees:        []db.TransferFee{{Amount: 5}},
+	})
+
+	require.Len(t, records, 1)
+	require.Equal(t, db.AuditEventTransfer, records[0].EventType)
+	require.Equal(t, "7", records[0].TargetID)
+	require.Equal(t, []Balance{{AccountID: 1, Balance: 1000}, {AccountID: 2, Balance: 0}}, records[0].Before.(Transfer).Balances)
+	require.Equal(t, []Balance{{AccountID: 1, Balance: 895}, {AccountID: 2, Balance: 100}}, records[0].After.(Transfer).Balances)
+	require.Equal(t, int64(5), records[0].After.(Transfer).Fees)
+}
+
+func TestUpdateUserRecords(t *testing.T) {
+	user := db.User{
+		Username:          util.RandomOwner(),
+		Email:             util.RandomEmail(),
+		PasswordChangedAt: time.Now().Add(-time.Hour),
+	}
+	actor := db.AuditActor{Name: UserActor(user.Username)}
+
+	updated := user
+	updated.PasswordChangedAt = time.Now()
+	updated.PendingEmail = util.RandomEmail()
+
+	records := UpdateUserRecords(actor)(db.UpdateUserTxResult{
+		User:         updated,
+		PreviousUser: user,
+	})
+	require.Len(t, records, 2)
+	require.Equal(t, db.AuditEventPasswordChanged, records[0].EventType)
+	require.Equal(t, db.AuditEventEmailChangeRequested, records[1].EventType)
+	require.Equal(t, updated.PendingEmail, records[1].After.(User).PendingEmail)
+
+	records = UpdateUserRecords(actor)(db.UpdateUserTxResult{
+		User:         user,
+		PreviousUser: user,
+	})
+	require.Empty(t, records)
+}
@@ -0,0 +1,135 @@
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Authority is a self-signed CA that issues the certificates of the servers
+// and of the clients for the local development
+type Authority struct {
+	Certificate *x509.Certificate
+	key         *ecdsa.PrivateKey
+}
+
+// NewAuthority generates the key and the self-signed certificate of a CA
+func NewAuthority(commonName string, validFor time.Duration) (*Authority, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("cannot generate key: %w", err)
+	}
+
+	template, err := newTemplate(commonName, validFor)
+	if err != nil {
+		return nil, err
+	}
+	template.IsCA = true
+	template.BasicConstraintsValid = true
+	template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create CA certificate: %w", err)
+	}
+
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse CA certificate: %w", err)
+	}
+
+	return &Authority{
+		Certificate: certificate,
+		key:         key,
+	}, nil
+}
+
+// PEM encodes the certificate and the key of the CA
+func (authority *Authority) PEM() (certPEM []byte, keyPEM []byte, err error) {
+	return encodePEM(authority.Certificate.Raw, authority.key)
+}
+
+// IssueServer issues a certificate for a server reached with the hosts,
+// which are DNS names or IP addresses
+func (authority *Authority) IssueServer(commonName string, hosts []string, validFor time.Duration) (certPEM []byte, keyPEM []byte, err error) {
+	return authority.issue(commonName, hosts, x509.ExtKeyUsageServerAuth, validFor)
+}
+
+// IssueClient issues a certificate for a client, its identity is the common name
+// unless a URI such as a SPIFFE ID is given in the names
+func (authority *Authority) IssueClient(commonName string, names []string, validFor time.Duration) (certPEM []byte, keyPEM []byte, err error) {
+	return authority.issue(commonName, names, x509.ExtKeyUsageClientAuth, validFor)
+}
+
+func (authority *Authority) issue(commonName string, names []string, usage x509.ExtKeyUsage, validFor time.Duration) ([]byte, []byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot generate key: %w", err)
+	}
+
+	template, err := newTemplate(commonName, validFor)
+	if err != nil {
+		return nil, nil, err
+	}
+	template.KeyUsage = x509.KeyUsageDigitalSignature
+	template.ExtKeyUsage = []x509.ExtKeyUsage{usage}
+
+	for _, name := range names {
+		if ip := net.ParseIP(name); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else if strings.Contains(name, "://") {
+			uri, err := url.Parse(name)
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid URI %s: %w", name, err)
+			}
+			template.URIs = append(template.URIs, uri)
+		} else {
+			template.DNSNames = append(template.DNSNames, name)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, authority.Certificate, &key.PublicKey, authority.key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot create certificate: %w", err)
+	}
+
+	return encodePEM(der, key)
+}
+
+func newTemplate(commonName string, validFor time.Duration) (*x509.Certificate, error) {
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, fmt.Errorf("cannot generate serial number: %w", err)
+	}
+
+	now := time.Now()
+	return &x509.Certificate{
+		SerialNumber: serialNumber,
+		Subject: pkix.Name{
+			Organization: []string{"Simple Bank"},
+			CommonName:   commonName,
+		},
+		NotBefore: now.Add(-time.Minute),
+		NotAfter:  now.Add(validFor),
+	}, nil
+}
+
+func encodePEM(der []byte, key *ecdsa.PrivateKey) ([]byte, []byte, error) {
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot marshal key: %w", err)
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+	return certPEM, keyPEM, nil
+}
@@ -0,0 +1,21 @@
+package certs
+
+import (
+	"crypto/tls"
+)
+
+// ServiceIdentity returns the identity of the client that authenticated with a certificate,
+// or an empty string for the other clients. It is the first URI of the certificate,
+// such as a SPIFFE ID, and its common name if it has no URI
+func ServiceIdentity(state tls.ConnectionState) string {
+	// the peer certificates are only kept once they are verified against the client CA
+	if !state.HandshakeComplete || len(state.PeerCertificates) == 0 {
+		return ""
+	}
+
+	certificate := state.PeerCertificates[0]
+	if len(certificate.URIs) > 0 {
+		return certificate.URIs[0].String()
+	}
+	return certificate.Subject.CommonName
+}
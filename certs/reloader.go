@@ -0,0 +1,221 @@
+package certs
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Client authentications that can be selected with TLS_CLIENT_AUTH
+const (
+	// ClientAuthNone doesn't ask the clients for a certificate
+	ClientAuthNone = "none"
+	// ClientAuthOptional verifies the certificate of the clients that send one,
+	// the other clients authenticate with a token or an API key
+	ClientAuthOptional = "optional"
+	// ClientAuthRequire rejects the connections of the clients without a valid certificate
+	ClientAuthRequire = "require"
+)
+
+// Reloader serves the certificate of the server and verifies the certificates of the clients,
+// the files are read again when they change so that the certificates can be rotated without a restart
+type Reloader struct {
+	certFile     string
+	keyFile      string
+	clientCAFile string
+	clientAuth   string
+
+	mutex       sync.RWMutex
+	certificate *tls.Certificate
+	clientCAs   *x509.CertPool
+	modTimes    map[string]time.Time
+}
+
+// NewReloader loads the certificate and the key of the server,
+// and the CA of the clients unless the client authentication is none
+func NewReloader(certFile string, keyFile string, clientCAFile string, clientAuth string) (*Reloader, error) {
+	switch clientAuth {
+	case ClientAuthNone, "":
+		clientAuth = ClientAuthNone
+		clientCAFile = ""
+	case ClientAuthOptional, ClientAuthRequire:
+		if clientCAFile == "" {
+			return nil, fmt.Errorf("client authentication %s needs a client CA file", clientAuth)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported client authentication: %s", clientAuth)
+	}
+
+	reloader := &Reloader{
+		certFile:     certFile,
+		keyFile:      keyFile,
+		clientCAFile: clientCAFile,
+		clientAuth:   clientAuth,
+	}
+
+	if _, err := reloader.Reload(); err != nil {
+		return nil, err
+	}
+	return reloader, nil
+}
+
+func (reloader *Reloader) files() []string {
+	files := []string{reloader.certFile, reloader.keyFile}
+	if reloader.clientCAFile != "" {
+		files = append(files, reloader.clientCAFile)
+	}
+	return files
+}
+
+// Reload reads the files again if one of them changed since they were loaded,
+// and reports whether they were reloaded. The previous certificates are kept if the new files are invalid
+func (reloader *Reloader) Reload() (bool, error) {
+	modTimes := make(map[string]time.Time)
+	for _, file := range reloader.files() {
+		info, err := os.Stat(file)
+		if err != nil {
+			return false, fmt.Errorf("cannot read %s: %w", file, err)
+		}
+		modTimes[file] = info.ModTime()
+	}
+
+	reloader.mutex.RLock()
+	changed := false
+	for file, modTime := range modTimes {
+		if !reloader.modTimes[file].Equal(modTime) {
+			changed = true
+		}
+	}
+	reloader.mutex.RUnlock()
+	if !changed {
+		return false, nil
+	}
+
+	certificate, err := tls.LoadX509KeyPair(reloader.certFile, reloader.keyFile)
+	if err != nil {
+		return false, fmt.Errorf("cannot load certificate: %w", err)
+	}
+
+	var clientCAs *x509.CertPool
+	if reloader.clientCAFile != "" {
+		clientCAs, err = LoadCertPool(reloader.clientCAFile)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	reloader.mutex.Lock()
+	defer reloader.mutex.Unlock()
+
+	reloader.certificate = &certificate
+	reloader.clientCAs = clientCAs
+	reloader.modTimes = modTimes
+	return true, nil
+}
+
+// LoadCertPool reads the PEM certificates of the CAs in file
+func LoadCertPool(file string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read CA file: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificate found in %s", file)
+	}
+	return pool, nil
+}
+
+// Run checks the files at every interval until the context is canceled
+func (reloader *Reloader) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		reloaded, err := reloader.Reload()
+		if err != nil {
+			log.Error().Err(err).Msg("failed to reload certificates, the previous ones are still used")
+			continue
+		}
+		if reloaded {
+			log.Info().Msg("certificates are reloaded")
+		}
+	}
+}
+
+// TLSConfig returns the config of the listeners, which always uses the last loaded certificates
+func (reloader *Reloader) TLSConfig() *tls.Config {
+	config := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetCertificate: func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+			reloader.mutex.RLock()
+			defer reloader.mutex.RUnlock()
+			return reloader.certificate, nil
+		},
+	}
+
+	// the certificates of the clients are verified by VerifyPeerCertificate instead of ClientCAs,
+	// which can't be changed once the listener is started
+	switch reloader.clientAuth {
+	case ClientAuthOptional:
+		config.ClientAuth = tls.RequestClientCert
+		config.VerifyPeerCertificate = reloader.verifyClientCertificate
+	case ClientAuthRequire:
+		config.ClientAuth = tls.RequireAnyClientCert
+		config.VerifyPeerCertificate = reloader.verifyClientCertificate
+	}
+
+	return config
+}
+
+func (reloader *Reloader) verifyClientCertificate(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+	if len(rawCerts) == 0 {
+		// only reached with the optional client authentication
+		return nil
+	}
+
+	certificates := make([]*x509.Certificate, 0, len(rawCerts))
+	for _, rawCert := range rawCerts {
+		certificate, err := x509.ParseCertificate(rawCert)
+		if err != nil {
+			return fmt.Errorf("invalid client certificate: %w", err)
+		}
+		certificates = append(certificates, certificate)
+	}
+
+	reloader.mutex.RLock()
+	roots := reloader.clientCAs
+	reloader.mutex.RUnlock()
+	if roots == nil {
+		return errors.New("no client CA is loaded")
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, certificate := range certificates[1:] {
+		intermediates.AddCert(certificate)
+	}
+
+	_, err := certificates[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	if err != nil {
+		return fmt.Errorf("invalid client certificate: %w", err)
+	}
+	return nil
+}
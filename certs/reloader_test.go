@@ -0,0 +1,237 @@
+package certs
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testFiles struct {
+	dir       string
+	authority *Authority
+}
+
+func newTestFiles(t *testing.T) *testFiles {
+	authority, err := NewAuthority("test CA", time.Hour)
+	require.NoError(t, err)
+
+	files := &testFiles{dir: t.TempDir(), authority: authority}
+
+	certPEM, _, err := authority.PEM()
+	require.NoError(t, err)
+	files.write(t, "ca.pem", certPEM)
+	files.issueServer(t, "server")
+	return files
+}
+
+func (files *testFiles) path(name string) string {
+	return filepath.Join(files.dir, name)
+}
+
+func (files *testFiles) write(t *testing.T, name string, data []byte) {
+	require.NoError(t, os.WriteFile(files.path(name), data, 0o600))
+}
+
+func (files *testFiles) issueServer(t *testing.T, commonName string) {
+	certPEM, keyPEM, err := files.authority.IssueServer(commonName, []string{"localhost", "127.0.0.1"}, time.Hour)
+	require.NoError(t, err)
+	files.write(t, "server.pem", certPEM)
+	files.write(t, "server-key.pem", keyPEM)
+}
+
+func (files *testFiles) newReloader(t *testing.T, clientAuth string) *Reloader {
+	reloader, err := NewReloader(files.path("server.pem"), files.path("server-key.pem"), files.path("ca.pem"), clientAuth)
+	require.NoError(t, err)
+	return reloader
+}
+
+func (files *testFiles) rootCAs(t *testing.T) *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(files.authority.Certificate)
+	return pool
+}
+
+func clientCertificate(t *testing.T, authority *Authority, identity string, names []string) tls.Certificate {
+	certPEM, keyPEM, err := authority.IssueClient(identity, names, time.Hour)
+	require.NoError(t, err)
+
+	certificate, err := tls.X509KeyPair(certPEM, keyPEM)
+	require.NoError(t, err)
+	return certificate
+}
+
+// handshake connects a client to a server using the TLS config,
+// and returns the connection state of the server
+func handshake(t *testing.T, serverConfig *tls.Config, clientConfig *tls.Config) (tls.ConnectionState, error) {
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", serverConfig)
+	require.NoError(t, err)
+	defer listener.Close()
+
+	type result struct {
+		state tls.ConnectionState
+		err   error
+	}
+	results := make(chan result, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			results <- result{err: err}
+			return
+		}
+		defer conn.Close()
+
+		tlsConn := conn.(*tls.Conn)
+		err = tlsConn.Handshake()
+		results <- result{state: tlsConn.ConnectionState(), err: err}
+	}()
+
+	conn, err := tls.Dial("tcp", listener.Addr().String(), clientConfig)
+	if err == nil {
+		// TLS 1.3 clients only learn that their certificate is rejected on the first read
+		conn.Read(make([]byte, 1))
+		conn.Close()
+	}
+
+	serverResult := <-results
+	return serverResult.state, serverResult.err
+}
+
+func TestReloaderServerCertificate(t *testing.T) {
+	files := newTestFiles(t)
+	reloader := files.newReloader(t, ClientAuthNone)
+
+	state, err := handshake(t, reloader.TLSConfig(), &tls.Config{RootCAs: files.rootCAs(t), ServerName: "localhost"})
+	require.NoError(t, err)
+	require.Empty(t, ServiceIdentity(state))
+
+	reloaded, err := reloader.Reload()
+	require.NoError(t, err)
+	require.False(t, reloaded)
+
+	files.issueServer(t, "rotated")
+	// the modification time has a limited resolution on some file systems
+	later := time.Now().Add(time.Second)
+	require.NoError(t, os.Chtimes(files.path("server.pem"), later, later))
+
+	reloaded, err = reloader.Reload()
+	require.NoError(t, err)
+	require.True(t, reloaded)
+
+	var served string
+	_, err = handshake(t, reloader.TLSConfig(), &tls.Config{
+		RootCAs:    files.rootCAs(t),
+		ServerName: "localhost",
+		VerifyConnection: func(state tls.ConnectionState) error {
+			served = state.PeerCertificates[0].Subject.CommonName
+			return nil
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "rotated", served)
+}
+
+func TestReloaderKeepsCertificatesOnError(t *testing.T) {
+	files := newTestFiles(t)
+	reloader := files.newReloader(t, ClientAuthNone)
+
+	files.write(t, "server.pem", []byte("not a certificate"))
+	later := time.Now().Add(time.Second)
+	require.NoError(t, os.Chtimes(files.path("server.pem"), later, later))
+
+	reloaded, err := reloader.Reload()
+	require.Error(t, err)
+	require.False(t, reloaded)
+
+	_, err = handshake(t, reloader.TLSConfig(), &tls.Config{RootCAs: files.rootCAs(t), ServerName: "localhost"})
+	require.NoError(t, err)
+}
+
+func TestReloaderClientAuth(t *testing.T) {
+	files := newTestFiles(t)
+
+	otherAuthority, err := NewAuthority("other CA", time.Hour)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name        string
+		clientAuth  string
+		certificate func(t *testing.T) []tls.Certificate
+		identity    string
+		rejected    bool
+	}{
+		{
+			name:       "CommonName",
+			clientAuth: ClientAuthRequire,
+			certificate: func(t *testing.T) []tls.Certificate {
+				return []tls.Certificate{clientCertificate(t, files.authority, "worker", nil)}
+			},
+			identity: "worker",
+		},
+		{
+			name:       "URI",
+			clientAuth: ClientAuthRequire,
+			certificate: func(t *testing.T) []tls.Certificate {
+				return []tls.Certificate{clientCertificate(t, files.authority, "worker", []string{"spiffe://simplebank/worker"})}
+			},
+			identity: "spiffe://simplebank/worker",
+		},
+		{
+			name:       "OptionalWithoutCertificate",
+			clientAuth: ClientAuthOptional,
+			certificate: func(t *testing.T) []tls.Certificate {
+				return nil
+			},
+		},
+		{
+			name:       "RequireWithoutCertificate",
+			clientAuth: ClientAuthRequire,
+			certificate: func(t *testing.T) []tls.Certificate {
+				return nil
+			},
+			rejected: true,
+		},
+		{
+			name:       "UnknownAuthority",
+			clientAuth: ClientAuthOptional,
+			certificate: func(t *testing.T) []tls.Certificate {
+				return []tls.Certificate{clientCertificate(t, otherAuthority, "intruder", nil)}
+			},
+			rejected: true,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			reloader := files.newReloader(t, tc.clientAuth)
+
+			state, err := handshake(t, reloader.TLSConfig(), &tls.Config{
+				RootCAs:      files.rootCAs(t),
+				ServerName:   "localhost",
+				Certificates: tc.certificate(t),
+			})
+			if tc.rejected {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.identity, ServiceIdentity(state))
+		})
+	}
+}
+
+func TestNewReloaderInvalidClientAuth(t *testing.T) {
+	files := newTestFiles(t)
+
+	_, err := NewReloader(files.path("server.pem"), files.path("server-key.pem"), "", ClientAuthRequire)
+	require.Error(t, err)
+
+	_, err = NewReloader(files.path("server.pem"), files.path("server-key.pem"), files.path("ca.pem"), "always")
+	require.Error(t, err)
+}
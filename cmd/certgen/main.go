@@ -0,0 +1,91 @@
+// Command certgen generates a self-signed CA with the certificates signed by it,
+// to run the servers with TLS and mutual TLS in the local development.
+//
+// It writes <out>/ca.pem, <out>/server.pem and <out>/<client>.pem files,
+// each certificate with its key in the -key.pem file
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/juker1141/simplebank/certs"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+func main() {
+	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
+
+	out := flag.String("out", "tmp/certs", "directory to write the certificates to")
+	hosts := flag.String("hosts", "localhost,127.0.0.1,::1", "comma-separated DNS names and IP addresses of the server")
+	clients := flag.String("clients", "", "comma-separated identities of the clients to issue certificates for")
+	validFor := flag.Duration("valid-for", 365*24*time.Hour, "validity of the certificates")
+	flag.Parse()
+
+	if err := os.MkdirAll(*out, 0o755); err != nil {
+		log.Fatal().Err(err).Msg("cannot create output directory")
+	}
+
+	authority, err := certs.NewAuthority("Simple Bank Development CA", *validFor)
+	if err != nil {
+		log.Fatal().Err(err).Msg("cannot create CA")
+	}
+
+	certPEM, keyPEM, err := authority.PEM()
+	if err != nil {
+		log.Fatal().Err(err).Msg("cannot encode CA")
+	}
+	write(*out, "ca", certPEM, keyPEM)
+
+	certPEM, keyPEM, err = authority.IssueServer("simplebank", split(*hosts), *validFor)
+	if err != nil {
+		log.Fatal().Err(err).Msg("cannot issue server certificate")
+	}
+	write(*out, "server", certPEM, keyPEM)
+
+	for _, client := range split(*clients) {
+		var names []string
+		if strings.Contains(client, "://") {
+			names = []string{client}
+		}
+
+		certPEM, keyPEM, err = authority.IssueClient(client, names, *validFor)
+		if err != nil {
+			log.Fatal().Err(err).Str("client", client).Msg("cannot issue client certificate")
+		}
+		write(*out, fileName(client), certPEM, keyPEM)
+	}
+}
+
+func split(list string) []string {
+	var values []string
+	for _, value := range strings.Split(list, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// fileName turns a client identity such as spiffe://simplebank/worker into a file name
+func fileName(identity string) string {
+	if index := strings.Index(identity, "://"); index >= 0 {
+		identity = identity[index+3:]
+	}
+	return strings.NewReplacer("/", "-", ":", "-").Replace(identity)
+}
+
+func write(dir string, name string, certPEM []byte, keyPEM []byte) {
+	if err := os.WriteFile(filepath.Join(dir, name+".pem"), certPEM, 0o644); err != nil {
+		log.Fatal().Err(err).Msg("cannot write certificate")
+	}
+	if err := os.WriteFile(filepath.Join(dir, name+"-key.pem"), keyPEM, 0o600); err != nil {
+		log.Fatal().Err(err).Msg("cannot write key")
+	}
+
+	log.Info().Str("file", filepath.Join(dir, name+".pem")).Msg("wrote certificate")
+}
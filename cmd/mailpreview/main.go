@@ -0,0 +1,62 @@
+// Command mailpreview renders every email template with sample data,
+// so that they can be checked in a browser or a text editor.
+//
+// It writes <out>/<language>/<template>.html and .txt files,
+// with the subject of the email in the first line of the text file
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+
+	"github.com/juker1141/simplebank/mail"
+	"github.com/juker1141/simplebank/util"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+func main() {
+	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
+
+	out := flag.String("out", "tmp/mail-preview", "directory to write the rendered emails to")
+	baseURL := flag.String("base-url", "", "base URL of the links, defaults to BASE_URL of the config")
+	flag.Parse()
+
+	if *baseURL == "" {
+		config, err := util.LoadConfig(".")
+		if err != nil {
+			log.Fatal().Err(err).Msg("cannot load config")
+		}
+		*baseURL = config.BaseURL
+	}
+
+	renderer, err := mail.NewRenderer(*baseURL)
+	if err != nil {
+		log.Fatal().Err(err).Msg("cannot load email templates")
+	}
+
+	for _, name := range renderer.Templates() {
+		for _, language := range renderer.Languages(name) {
+			message, err := renderer.Render(name, language, mail.SampleData(name))
+			if err != nil {
+				log.Fatal().Err(err).Str("template", name).Str("language", language).Msg("cannot render email")
+			}
+
+			dir := filepath.Join(*out, language)
+			if err := os.MkdirAll(dir, 0o755); err != nil {
+				log.Fatal().Err(err).Msg("cannot create output directory")
+			}
+
+			text := "Subject: " + message.Subject + "\n\n" + message.Text
+			if err := os.WriteFile(filepath.Join(dir, name+".txt"), []byte(text), 0o644); err != nil {
+				log.Fatal().Err(err).Msg("cannot write preview")
+			}
+			if err := os.WriteFile(filepath.Join(dir, name+".html"), []byte(message.HTML), 0o644); err != nil {
+				log.Fatal().Err(err).Msg("cannot write preview")
+			}
+
+			log.Info().Str("template", name).Str("language", language).Msg("rendered email")
+		}
+	}
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"strings"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/juker1141/simplebank/db/migration"
+	db "github.com/juker1141/simplebank/db/sqlc"
+	"github.com/juker1141/simplebank/util"
+)
+
+const commandUsage = `Usage: simplebank [-config file] [-set KEY=VALUE]... [command] [arguments]
+
+Flags:
+  -config file               read the config from a .env or .yaml file, over app.env and app.yaml
+  -set KEY=VALUE             set a config value over all the other sources, can be repeated
+
+Commands:
+  serve                      run the servers, the default without a command
+  migrate up|down|version    migrate the database with the embedded migrations
+  users create-admin         create a banker user
+  users lock|unlock          lock a user and revoke its sessions, or unlock it
+  sessions revoke            revoke a session, or all the sessions of a user
+  reconcile                  check that the balances of the accounts match their entries
+  outbox replay              publish the tasks of outbox events again
+  seed                       create demo users with funded accounts
+  config                     print the effective config, with the secrets redacted
+
+Run "simplebank <command> -h" for the arguments of a command.
+`
+
+// configOverrides collects the values of the -set flags by key
+type configOverrides map[string]string
+
+func (overrides configOverrides) String() string {
+	return ""
+}
+
+func (overrides configOverrides) Set(value string) error {
+	key, value, ok := strings.Cut(value, "=")
+	if !ok || key == "" {
+		return errors.New("must be KEY=VALUE")
+	}
+
+	overrides[strings.ToUpper(key)] = value
+	return nil
+}
+
+// errUsage is returned when the arguments of a command are invalid, after its usage is printed
+var errUsage = errors.New("invalid arguments")
+
+// runCommand runs an administrative command with the same config as the servers.
+// The output of the command is written to out, and the logs to the logger
+func runCommand(ctx context.Context, config util.Config, args []string, out io.Writer) error {
+	command, args := args[0], args[1:]
+
+	switch command {
+	case "migrate":
+		return runMigrateCommand(config, args, out)
+	case "users":
+		return runUsersCommand(ctx, config, args, out)
+	case "sessions":
+		return runSessionsCommand(ctx, config, args, out)
+	case "reconcile":
+		return runReconcileCommand(ctx, config, args, out)
+	case "outbox":
+		return runOutboxCommand(ctx, config, args, out)
+	case "seed":
+		return runSeedCommand(ctx, config, args, out)
+	case "config":
+		return runConfigCommand(config, args, out)
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(out, commandUsage)
+		return nil
+	}
+
+	fmt.Fprint(os.Stderr, commandUsage)
+	return fmt.Errorf("unknown command %q: %w", command, errUsage)
+}
+
+// exitCommand prints the error of a command and exits with a non-zero status
+func exitCommand(err error) {
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+
+	fmt.Fprintf(os.Stderr, "error: %s\n", err)
+	if errors.Is(err, errUsage) {
+		os.Exit(2)
+	}
+	os.Exit(1)
+}
+
+// commandAction splits the action of a command with several actions, such as "users lock", from its arguments
+func commandAction(command string, args []string, actions ...string) (string, []string, error) {
+	if len(args) > 0 {
+		for _, action := range actions {
+			if args[0] == action {
+				return action, args[1:], nil
+			}
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "Usage: simplebank %s %s [arguments]\n", command, strings.Join(actions, "|"))
+	if len(args) == 0 {
+		return "", nil, fmt.Errorf("missing action of %s: %w", command, errUsage)
+	}
+	return "", nil, fmt.Errorf("unknown action %s of %s: %w", args[0], command, errUsage)
+}
+
+// newFlagSet creates the flags of a command, which report the errors instead of exiting
+func newFlagSet(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet("simplebank "+name, flag.ContinueOnError)
+	flags.SetOutput(os.Stderr)
+	return flags
+}
+
+// parseFlags parses the arguments of a command, which takes no positional arguments
+func parseFlags(flags *flag.FlagSet, args []string) error {
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return fmt.Errorf("%s: %w", err, errUsage)
+	}
+
+	if flags.NArg() > 0 {
+		flags.Usage()
+		return fmt.Errorf("unexpected arguments %v: %w", flags.Args(), errUsage)
+	}
+	return nil
+}
+
+// requireFlag returns an error if a required flag isn't set
+func requireFlag(flags *flag.FlagSet, name string, value string) error {
+	if value == "" {
+		flags.Usage()
+		return fmt.Errorf("-%s is required: %w", name, errUsage)
+	}
+	return nil
+}
+
+// connectStore connects the commands to the database, the pool is closed when they are done.
+// The tests replace it to run the commands with a mock store
+var connectStore = func(ctx context.Context, config util.Config) (db.Store, func(), error) {
+	connPool, err := pgxpool.New(ctx, config.DBSource)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot connect to db: %w", err)
+	}
+
+	if err := connPool.Ping(ctx); err != nil {
+		connPool.Close()
+		return nil, nil, fmt.Errorf("cannot connect to db: %w", err)
+	}
+
+	return db.NewStore(connPool), connPool.Close, nil
+}
+
+// commandActor is the actor of the changes made by the commands in the audit log,
+// which is the operator running them
+func commandActor() db.AuditActor {
+	name := "unknown"
+	if current, err := user.Current(); err == nil {
+		name = current.Username
+	}
+
+	return db.AuditActor{
+		Name: fmt.Sprintf("cli:%s", name),
+	}
+}
+
+func runMigrateCommand(config util.Config, args []string, out io.Writer) error {
+	action, args, err := commandAction("migrate", args, "up", "down", "version")
+	if err != nil {
+		return err
+	}
+
+	flags := newFlagSet("migrate " + action)
+	steps := 0
+	all := false
+	switch action {
+	case "up":
+		flags.IntVar(&steps, "steps", 0, "number of migrations to apply, all of them if 0")
+	case "down":
+		flags.IntVar(&steps, "steps", 1, "number of migrations to roll back")
+		flags.BoolVar(&all, "all", false, "roll back all the migrations")
+	}
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if steps < 0 {
+		return fmt.Errorf("-steps must be positive: %w", errUsage)
+	}
+
+	migrator, err := migration.New(config.DBSource)
+	if err != nil {
+		return err
+	}
+	defer migrator.Close()
+
+	switch {
+	case action == "up" && steps == 0:
+		err = migrator.Up()
+	case action == "up":
+		err = migrator.Steps(steps)
+	case action == "down" && all:
+		err = migrator.Down()
+	case action == "down":
+		err = migrator.Steps(-steps)
+	}
+	if err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("failed to migrate %s: %w", action, err)
+	}
+
+	return printMigrationVersion(migrator, out)
+}
+
+func printMigrationVersion(migrator *migrate.Migrate, out io.Writer) error {
+	last, err := migration.LastVersion()
+	if err != nil {
+		return err
+	}
+
+	version, dirty, err := migrator.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		fmt.Fprintf(out, "no migration is applied, the last migration is %d\n", last)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get migration version: %w", err)
+	}
+
+	state := ""
+	if dirty {
+		state = " (dirty)"
+	}
+	fmt.Fprintf(out, "database is at migration %d%s, the last migration is %d\n", version, state, last)
+	return nil
+}
+
+func runConfigCommand(config util.Config, args []string, out io.Writer) error {
+	flags := newFlagSet("config")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	for _, line := range config.Redacted() {
+		fmt.Fprintln(out, line)
+	}
+	return nil
+}
@@ -0,0 +1,333 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/juker1141/simplebank/audit"
+	db "github.com/juker1141/simplebank/db/sqlc"
+	"github.com/juker1141/simplebank/util"
+	"github.com/juker1141/simplebank/val"
+	"github.com/juker1141/simplebank/worker"
+)
+
+// generatedPasswordLength is the length of the passwords generated for the admin users
+const generatedPasswordLength = 24
+
+// outboxReplayHold is how long the outbox relay waits before it publishes the replayed events,
+// which leaves the time to remove their previous tasks from the queue
+const outboxReplayHold = time.Minute
+
+func runUsersCommand(ctx context.Context, config util.Config, args []string, out io.Writer) error {
+	action, args, err := commandAction("users", args, "create-admin", "lock", "unlock")
+	if err != nil {
+		return err
+	}
+
+	if action == "create-admin" {
+		return createAdminUser(ctx, config, args, out)
+	}
+	return setUserLocked(ctx, config, action == "lock", args, out)
+}
+
+func createAdminUser(ctx context.Context, config util.Config, args []string, out io.Writer) error {
+	flags := newFlagSet("users create-admin")
+	username := flags.String("username", "", "username of the admin")
+	fullName := flags.String("full-name", "", "full name of the admin")
+	email := flags.String("email", "", "email of the admin, it is considered verified")
+	password := flags.String("password", "", "password of the admin, a random one is generated and printed if empty")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if err := requireFlag(flags, "username", *username); err != nil {
+		return err
+	}
+	if err := requireFlag(flags, "full-name", *fullName); err != nil {
+		return err
+	}
+	if err := requireFlag(flags, "email", *email); err != nil {
+		return err
+	}
+
+	generated := *password == ""
+	if generated {
+		key, err := util.NewAPIKey()
+		if err != nil {
+			return err
+		}
+		*password = key[:generatedPasswordLength]
+	}
+
+	if err := val.ValidateUsername(*username); err != nil {
+		return fmt.Errorf("invalid username: %w", err)
+	}
+	if err := val.ValidateFullName(*fullName); err != nil {
+		return fmt.Errorf("invalid full name: %w", err)
+	}
+	if err := val.ValidateEmail(*email); err != nil {
+		return fmt.Errorf("invalid email: %w", err)
+	}
+	if err := val.ValidatePassword(*password); err != nil {
+		return fmt.Errorf("invalid password: %w", err)
+	}
+
+	hashedPassword, err := util.HashPassword(*password)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	store, closeStore, err := connectStore(ctx, config)
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+
+	// the user is only created with its audit event
+	result, err := store.CreateUserWithRoleTx(ctx, db.CreateUserWithRoleTxParams{
+		CreateUserWithRoleParams: db.CreateUserWithRoleParams{
+			Username: *username,
+			HashedPassword: hashedPassword,
+			FullName: *fullName,
+			Email: *email,
+			Role: util.BankerRole,
+			IsEmailVerified: true,
+		},
+		Audit: func(result db.CreateUserWithRoleTxResult) []db.AuditRecord {
+			return []db.AuditRecord{
+				audit.AdminRecord(commandActor(), db.AuditTargetUser, result.User.Username, "create_admin"),
+			}
+		},
+	})
+	if err != nil {
+		if db.ErrorCode(err) == db.UniqueViolation {
+			return fmt.Errorf("user %s already exists", *username)
+		}
+		return fmt.Errorf("failed to create user: %w", err)
+	}
+
+	fmt.Fprintf(out, "created admin user %s\n", result.User.Username)
+	if generated {
+		fmt.Fprintf(out, "password: %s\n", *password)
+	}
+	return nil
+}
+
+func setUserLocked(ctx context.Context, config util.Config, locked bool, args []string, out io.Writer) error {
+	action := "unlock"
+	if locked {
+		action = "lock"
+	}
+
+	flags := newFlagSet("users " + action)
+	username := flags.String("username", "", "username of the user to "+action)
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if err := requireFlag(flags, "username", *username); err != nil {
+		return err
+	}
+
+	store, closeStore, err := connectStore(ctx, config)
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+
+	result, err := store.SetUserLockedTx(ctx, db.SetUserLockedTxParams{
+		SetUserLockedParams: db.SetUserLockedParams{
+			Username: *username,
+			IsLocked: locked,
+		},
+		Audit: audit.SetUserLockedRecords(commandActor()),
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return fmt.Errorf("user %s not found", *username)
+		}
+		return fmt.Errorf("failed to %s user: %w", action, err)
+	}
+
+	if result.PreviousUser.IsLocked == locked {
+		fmt.Fprintf(out, "user %s is already %sed\n", *username, action)
+	} else {
+		fmt.Fprintf(out, "%sed user %s\n", action, *username)
+	}
+	if locked {
+		fmt.Fprintf(out, "revoked %d sessions\n", len(result.RevokedSessions))
+	}
+	return nil
+}
+
+func runSessionsCommand(ctx context.Context, config util.Config, args []string, out io.Writer) error {
+	_, args, err := commandAction("sessions", args, "revoke")
+	if err != nil {
+		return err
+	}
+
+	flags := newFlagSet("sessions revoke")
+	username := flags.String("username", "", "revoke all the active sessions of the user")
+	id := flags.String("id", "", "revoke the session with the ID")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if (*username == "") == (*id == "") {
+		flags.Usage()
+		return fmt.Errorf("exactly one of -username and -id is required: %w", errUsage)
+	}
+
+	arg := db.RevokeSessionsTxParams{
+		Username: *username,
+		Audit: audit.RevokeSessionsRecords(commandActor()),
+	}
+	if *id != "" {
+		arg.SessionID, err = uuid.Parse(*id)
+		if err != nil {
+			return fmt.Errorf("invalid session id: %w", err)
+		}
+	}
+
+	store, closeStore, err := connectStore(ctx, config)
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+
+	result, err := store.RevokeSessionsTx(ctx, arg)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return fmt.Errorf("session %s not found or already revoked", *id)
+		}
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	fmt.Fprintf(out, "revoked %d sessions\n", len(result.Sessions))
+	return nil
+}
+
+// runReconcileCommand runs the reconciliation of the scheduler now,
+// it fails if some accounts are unbalanced
+func runReconcileCommand(ctx context.Context, config util.Config, args []string, out io.Writer) error {
+	flags := newFlagSet("reconcile")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	store, closeStore, err := connectStore(ctx, config)
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+
+	for _, job := range worker.MaintenanceJobs(config, store) {
+		if job.Name != worker.JobReconcileAccounts {
+			continue
+		}
+
+		result, err := job.Run(ctx)
+		if result != "" {
+			fmt.Fprintln(out, result)
+		}
+		return err
+	}
+	return fmt.Errorf("job %s isn't registered", worker.JobReconcileAccounts)
+}
+
+// runOutboxCommand replays outbox events, published or dead-lettered: the events are marked unpublished
+// and their tasks are removed from the queue, so the outbox relay of the servers publishes them again
+func runOutboxCommand(ctx context.Context, config util.Config, args []string, out io.Writer) error {
+	_, args, err := commandAction("outbox", args, "replay")
+	if err != nil {
+		return err
+	}
+
+	flags := newFlagSet("outbox replay")
+	id := flags.Int64("id", 0, "replay the outbox event with the ID, such as a dead-lettered event")
+	since := flags.Duration("since", 0, "replay the outbox events published within the duration, such as 2h")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if *id == 0 && *since == 0 {
+		flags.Usage()
+		return fmt.Errorf("-id or -since is required: %w", errUsage)
+	}
+
+	inspector, closeInspector, err := connectTaskInspector(config)
+	if err != nil {
+		return err
+	}
+	defer closeInspector()
+
+	arg := db.ReplayOutboxTxParams{
+		ReplayOutboxEventsParams: db.ReplayOutboxEventsParams{
+			ClaimedUntil: time.Now().Add(outboxReplayHold),
+		},
+		Audit: func(result db.ReplayOutboxTxResult) []db.AuditRecord {
+			actor := commandActor()
+			records := make([]db.AuditRecord, 0, len(result.Events))
+			for _, event := range result.Events {
+				records = append(records, db.AuditRecord{
+					EventType: db.AuditEventAdminAction,
+					Actor: actor,
+					TargetType: db.AuditTargetTask,
+					TargetID: fmt.Sprintf("%s/%s", event.Queue, worker.OutboxTaskID(event.ID)),
+					After: map[string]string{
+						"action": "replay_outbox_event",
+					},
+				})
+			}
+			return records
+		},
+	}
+	if *id != 0 {
+		arg.ID = pgtype.Int8{Int64: *id, Valid: true}
+	}
+	if *since != 0 {
+		arg.PublishedAfter = pgtype.Timestamptz{Time: time.Now().Add(-*since), Valid: true}
+	}
+
+	store, closeStore, err := connectStore(ctx, config)
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+
+	result, err := store.ReplayOutboxTx(ctx, arg)
+	if err != nil {
+		return fmt.Errorf("failed to replay outbox: %w", err)
+	}
+
+	// the queue isn't called while the transaction is open, the events are held back from the relay instead
+	for _, event := range result.Events {
+		err := inspector.DeleteTask(ctx, event.Queue, worker.OutboxTaskID(event.ID))
+		// the task is already gone once its retention is over
+		if err != nil && !errors.Is(err, worker.ErrTaskNotFound) && !errors.Is(err, worker.ErrQueueNotFound) {
+			return fmt.Errorf("replayed %d outbox events, but failed to remove the task of outbox event %d, "+
+				"run the replay again once the relay marks it published: %w", len(result.Events), event.ID, err)
+		}
+	}
+
+	fmt.Fprintf(out, "replayed %d outbox events\n", len(result.Events))
+	return nil
+}
+
+// connectTaskInspector connects the commands to the task queue of the servers, it is closed when they are done.
+// The tests replace it to run the commands with a mock inspector
+var connectTaskInspector = func(config util.Config) (worker.TaskInspector, func(), error) {
+	taskQueue, err := worker.NewTaskQueue(config)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// the tasks of the memory queue only live in the process of the servers
+	if taskQueue.Backend() == worker.BackendMemory {
+		taskQueue.Close()
+		return nil, nil, fmt.Errorf("outbox replay requires the %s task queue backend", worker.BackendRedis)
+	}
+
+	return taskQueue.NewInspector(), func() { taskQueue.Close() }, nil
+}
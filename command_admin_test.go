@@ -0,0 +1,396 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/juker1141/simplebank/db/mock"
+	db "github.com/juker1141/simplebank/db/sqlc"
+	"github.com/juker1141/simplebank/util"
+	"github.com/juker1141/simplebank/worker"
+	mockwk "github.com/juker1141/simplebank/worker/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// setupCommandInspector runs the commands of the test with the inspector instead of connecting to the task queue
+func setupCommandInspector(t *testing.T, inspector worker.TaskInspector) {
+	connect := connectTaskInspector
+	connectTaskInspector = func(config util.Config) (worker.TaskInspector, func(), error) {
+		return inspector, func() {}, nil
+	}
+	t.Cleanup(func() {
+		connectTaskInspector = connect
+	})
+}
+
+func TestCreateAdminUserCommand(t *testing.T) {
+	username := util.RandomOwner()
+	fullName := util.RandomOwner()
+	email := util.RandomEmail()
+	password := util.RandomString(6)
+
+	testCases := []struct{
+		name string
+		args []string
+		buildStubs func(store *mockdb.MockStore)
+		checkResult func(t *testing.T, out string, err error)
+	}{
+		{
+			name: "OK",
+			args: []string{"-username", username, "-full-name", fullName, "-email", email, "-password", password},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateUserWithRoleTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, arg db.CreateUserWithRoleTxParams) (db.CreateUserWithRoleTxResult, error) {
+						require.Equal(t, username, arg.Username)
+						require.Equal(t, fullName, arg.FullName)
+						require.Equal(t, email, arg.Email)
+						require.Equal(t, util.BankerRole, arg.Role)
+						require.True(t, arg.IsEmailVerified)
+						require.NoError(t, util.CheckPassword(password, arg.HashedPassword))
+
+						result := db.CreateUserWithRoleTxResult{
+							User: db.User{Username: arg.Username},
+						}
+						records := arg.Audit(result)
+						require.Len(t, records, 1)
+						require.Equal(t, db.AuditEventAdminAction, records[0].EventType)
+						require.Equal(t, db.AuditTargetUser, records[0].TargetType)
+						require.Equal(t, username, records[0].TargetID)
+						require.Equal(t, map[string]string{"action": "create_admin"}, records[0].After)
+						return result, nil
+					})
+			},
+			checkResult: func(t *testing.T, out string, err error) {
+				require.NoError(t, err)
+				require.Equal(t, fmt.Sprintf("created admin user %s\n", username), out)
+			},
+		},
+		{
+			name: "GeneratedPassword",
+			args: []string{"-username", username, "-full-name", fullName, "-email", email},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateUserWithRoleTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, arg db.CreateUserWithRoleTxParams) (db.CreateUserWithRoleTxResult, error) {
+						return db.CreateUserWithRoleTxResult{User: db.User{Username: arg.Username}}, nil
+					})
+			},
+			checkResult: func(t *testing.T, out string, err error) {
+				require.NoError(t, err)
+
+				lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+				require.Len(t, lines, 2)
+				require.True(t, strings.HasPrefix(lines[1], "password: "))
+				require.Len(t, strings.TrimPrefix(lines[1], "password: "), generatedPasswordLength)
+			},
+		},
+		{
+			name: "MissingEmail",
+			args: []string{"-username", username, "-full-name", fullName},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateUserWithRoleTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResult: func(t *testing.T, out string, err error) {
+				require.ErrorIs(t, err, errUsage)
+			},
+		},
+		{
+			name: "InvalidEmail",
+			args: []string{"-username", username, "-full-name", fullName, "-email", "invalid-email"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateUserWithRoleTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResult: func(t *testing.T, out string, err error) {
+				require.ErrorContains(t, err, "invalid email")
+			},
+		},
+		{
+			name: "TooShortPassword",
+			args: []string{"-username", username, "-full-name", fullName, "-email", email, "-password", "123"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateUserWithRoleTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResult: func(t *testing.T, out string, err error) {
+				require.ErrorContains(t, err, "invalid password")
+			},
+		},
+		{
+			name: "DuplicateUsername",
+			args: []string{"-username", username, "-full-name", fullName, "-email", email, "-password", password},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateUserWithRoleTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.CreateUserWithRoleTxResult{}, db.ErrUniqueViolation)
+			},
+			checkResult: func(t *testing.T, out string, err error) {
+				require.EqualError(t, err, fmt.Sprintf("user %s already exists", username))
+				require.Empty(t, out)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			setupCommandStore(t, store)
+
+			var out bytes.Buffer
+			args := append([]string{"users", "create-admin"}, tc.args...)
+			err := runCommand(context.Background(), util.Config{}, args, &out)
+			tc.checkResult(t, out.String(), err)
+		})
+	}
+}
+
+func TestSetUserLockedCommand(t *testing.T) {
+	username := util.RandomOwner()
+
+	testCases := []struct{
+		name string
+		args []string
+		buildStubs func(store *mockdb.MockStore)
+		checkResult func(t *testing.T, out string, err error)
+	}{
+		{
+			name: "Lock",
+			args: []string{"users", "lock", "-username", username},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					SetUserLockedTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, arg db.SetUserLockedTxParams) (db.SetUserLockedTxResult, error) {
+						require.Equal(t, username, arg.Username)
+						require.True(t, arg.IsLocked)
+						require.NotNil(t, arg.Audit)
+						return db.SetUserLockedTxResult{RevokedSessions: []db.Session{{}}}, nil
+					})
+			},
+			checkResult: func(t *testing.T, out string, err error) {
+				require.NoError(t, err)
+				require.Equal(t, fmt.Sprintf("locked user %s\nrevoked 1 sessions\n", username), out)
+			},
+		},
+		{
+			name: "AlreadyUnlocked",
+			args: []string{"users", "unlock", "-username", username},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					SetUserLockedTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, arg db.SetUserLockedTxParams) (db.SetUserLockedTxResult, error) {
+						require.False(t, arg.IsLocked)
+						return db.SetUserLockedTxResult{}, nil
+					})
+			},
+			checkResult: func(t *testing.T, out string, err error) {
+				require.NoError(t, err)
+				require.Equal(t, fmt.Sprintf("user %s is already unlocked\n", username), out)
+			},
+		},
+		{
+			name: "MissingUsername",
+			args: []string{"users", "lock"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					SetUserLockedTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResult: func(t *testing.T, out string, err error) {
+				require.ErrorIs(t, err, errUsage)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			setupCommandStore(t, store)
+
+			var out bytes.Buffer
+			err := runCommand(context.Background(), util.Config{}, tc.args, &out)
+			tc.checkResult(t, out.String(), err)
+		})
+	}
+}
+
+func TestOutboxReplayCommand(t *testing.T) {
+	events := []db.OutboxEvent{
+		{ID: util.RandomInt(1, 1000), Queue: worker.QueueDefault},
+		{ID: util.RandomInt(1001, 2000), Queue: worker.QueueCritical},
+	}
+
+	testCases := []struct{
+		name string
+		args []string
+		buildStubs func(store *mockdb.MockStore, inspector *mockwk.MockTaskInspector)
+		checkResult func(t *testing.T, out string, err error)
+	}{
+		{
+			name: "ReplayID",
+			args: []string{"-id", fmt.Sprint(events[0].ID)},
+			buildStubs: func(store *mockdb.MockStore, inspector *mockwk.MockTaskInspector) {
+				store.EXPECT().
+					ReplayOutboxTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, arg db.ReplayOutboxTxParams) (db.ReplayOutboxTxResult, error) {
+						require.True(t, arg.ID.Valid)
+						require.Equal(t, events[0].ID, arg.ID.Int64)
+						require.False(t, arg.PublishedAfter.Valid)
+						require.WithinDuration(t, time.Now().Add(outboxReplayHold), arg.ClaimedUntil, time.Second)
+
+						result := db.ReplayOutboxTxResult{Events: events[:1]}
+						records := arg.Audit(result)
+						require.Len(t, records, 1)
+						require.Equal(t, db.AuditTargetTask, records[0].TargetType)
+						require.Equal(t, fmt.Sprintf("%s/%s", events[0].Queue, worker.OutboxTaskID(events[0].ID)), records[0].TargetID)
+						return result, nil
+					})
+
+				inspector.EXPECT().
+					DeleteTask(gomock.Any(), gomock.Eq(events[0].Queue), gomock.Eq(worker.OutboxTaskID(events[0].ID))).
+					Times(1).
+					Return(nil)
+			},
+			checkResult: func(t *testing.T, out string, err error) {
+				require.NoError(t, err)
+				require.Equal(t, "replayed 1 outbox events\n", out)
+			},
+		},
+		{
+			name: "ReplaySince",
+			args: []string{"-since", "2h"},
+			buildStubs: func(store *mockdb.MockStore, inspector *mockwk.MockTaskInspector) {
+				store.EXPECT().
+					ReplayOutboxTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, arg db.ReplayOutboxTxParams) (db.ReplayOutboxTxResult, error) {
+						require.False(t, arg.ID.Valid)
+						require.True(t, arg.PublishedAfter.Valid)
+						require.WithinDuration(t, time.Now().Add(-2*time.Hour), arg.PublishedAfter.Time, time.Second)
+						return db.ReplayOutboxTxResult{Events: events}, nil
+					})
+
+				// the expired tasks are already gone
+				inspector.EXPECT().
+					DeleteTask(gomock.Any(), gomock.Eq(events[0].Queue), gomock.Eq(worker.OutboxTaskID(events[0].ID))).
+					Times(1).
+					Return(worker.ErrTaskNotFound)
+				inspector.EXPECT().
+					DeleteTask(gomock.Any(), gomock.Eq(events[1].Queue), gomock.Eq(worker.OutboxTaskID(events[1].ID))).
+					Times(1).
+					Return(worker.ErrQueueNotFound)
+			},
+			checkResult: func(t *testing.T, out string, err error) {
+				require.NoError(t, err)
+				require.Equal(t, "replayed 2 outbox events\n", out)
+			},
+		},
+		{
+			name: "MissingFilter",
+			args: []string{},
+			buildStubs: func(store *mockdb.MockStore, inspector *mockwk.MockTaskInspector) {
+				store.EXPECT().
+					ReplayOutboxTx(gomock.Any(), gomock.Any()).
+					Times(0)
+				inspector.EXPECT().
+					DeleteTask(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResult: func(t *testing.T, out string, err error) {
+				require.ErrorIs(t, err, errUsage)
+			},
+		},
+		{
+			name: "ReplayError",
+			args: []string{"-since", "2h"},
+			buildStubs: func(store *mockdb.MockStore, inspector *mockwk.MockTaskInspector) {
+				store.EXPECT().
+					ReplayOutboxTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.ReplayOutboxTxResult{}, sql.ErrConnDone)
+				inspector.EXPECT().
+					DeleteTask(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResult: func(t *testing.T, out string, err error) {
+				require.ErrorIs(t, err, sql.ErrConnDone)
+				require.Empty(t, out)
+			},
+		},
+		{
+			name: "DeleteTaskError",
+			args: []string{"-since", "2h"},
+			buildStubs: func(store *mockdb.MockStore, inspector *mockwk.MockTaskInspector) {
+				store.EXPECT().
+					ReplayOutboxTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.ReplayOutboxTxResult{Events: events}, nil)
+
+				inspector.EXPECT().
+					DeleteTask(gomock.Any(), gomock.Eq(events[0].Queue), gomock.Eq(worker.OutboxTaskID(events[0].ID))).
+					Times(1).
+					Return(errTaskActive)
+				inspector.EXPECT().
+					DeleteTask(gomock.Any(), gomock.Eq(events[1].Queue), gomock.Any()).
+					Times(0)
+			},
+			checkResult: func(t *testing.T, out string, err error) {
+				require.ErrorIs(t, err, errTaskActive)
+				require.ErrorContains(t, err, fmt.Sprintf("task of outbox event %d", events[0].ID))
+				require.Empty(t, out)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			inspector := mockwk.NewMockTaskInspector(ctrl)
+			tc.buildStubs(store, inspector)
+			setupCommandStore(t, store)
+			setupCommandInspector(t, inspector)
+
+			var out bytes.Buffer
+			args := append([]string{"outbox", "replay"}, tc.args...)
+			err := runCommand(context.Background(), util.Config{}, args, &out)
+			tc.checkResult(t, out.String(), err)
+		})
+	}
+}
+
+// errTaskActive fails the removal of a task that is being processed
+var errTaskActive = errors.New("task is active")
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	db "github.com/juker1141/simplebank/db/sqlc"
+	"github.com/juker1141/simplebank/util"
+	"github.com/juker1141/simplebank/val"
+)
+
+// settlementUsername owns the settlement accounts created by the seed
+const settlementUsername = "settlement"
+
+// seedUser is a demo user created by the seed, with a funded account in each of its currencies
+type seedUser struct {
+	Username   string
+	FullName   string
+	Currencies []string
+}
+
+var seedUsers = []seedUser{
+	{Username: "alice", FullName: "Alice Liddell", Currencies: []string{util.USD, util.EUR}},
+	{Username: "bob", FullName: "Bob Builder", Currencies: []string{util.USD, util.CAD}},
+	{Username: "carol", FullName: "Carol Danvers", Currencies: []string{util.EUR}},
+}
+
+// runSeedCommand creates the demo users with funded accounts, and the settlement accounts they are funded from.
+// The users that already exist are skipped, so the seed can be run again
+func runSeedCommand(ctx context.Context, config util.Config, args []string, out io.Writer) error {
+	flags := newFlagSet("seed")
+	password := flags.String("password", "secret", "password of the demo users")
+	balance := flags.Int64("balance", 100000, "amount deposited in each account of the demo users")
+	force := flags.Bool("force", false, "seed the database even if the environment isn't development")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if config.Environment != "development" && !*force {
+		return fmt.Errorf("refusing to seed the %s environment without -force", config.Environment)
+	}
+	if err := val.ValidatePassword(*password); err != nil {
+		return fmt.Errorf("invalid password: %w", err)
+	}
+	if err := val.ValidateAmount(*balance); err != nil {
+		return fmt.Errorf("invalid balance: %w", err)
+	}
+
+	hashedPassword, err := util.HashPassword(*password)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	store, closeStore, err := connectStore(ctx, config)
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+
+	if err := seedSettlementAccounts(ctx, store, out); err != nil {
+		return err
+	}
+
+	for _, user := range seedUsers {
+		if err := seedDemoUser(ctx, store, user, hashedPassword, *balance, out); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// seedSettlementAccounts creates the settlement accounts of the currencies that don't have one.
+// Nobody logs in as their owner, so it gets a random password that isn't printed
+func seedSettlementAccounts(ctx context.Context, store db.Store, out io.Writer) error {
+	for _, currency := range []string{util.USD, util.EUR, util.CAD} {
+		_, err := store.GetSettlementAccount(ctx, currency)
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, db.ErrRecordNotFound) {
+			return fmt.Errorf("failed to get settlement account: %w", err)
+		}
+
+		_, err = store.GetUser(ctx, settlementUsername)
+		if errors.Is(err, db.ErrRecordNotFound) {
+			var hashedPassword string
+			hashedPassword, err = randomHashedPassword()
+			if err != nil {
+				return err
+			}
+
+			_, err = store.CreateUserWithRole(ctx, db.CreateUserWithRoleParams{
+				Username: settlementUsername,
+				HashedPassword: hashedPassword,
+				FullName: "Settlement",
+				Email: fmt.Sprintf("%s@example.com", settlementUsername),
+				Role: util.DepositorRole,
+				IsEmailVerified: true,
+			})
+		}
+		if err != nil {
+			return fmt.Errorf("failed to create settlement user: %w", err)
+		}
+
+		result, err := store.CreateAccountTx(ctx, db.CreateAccountParams{
+			Owner: settlementUsername,
+			Balance: 0,
+			Currency: currency,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create settlement account: %w", err)
+		}
+
+		_, err = store.CreateSettlementAccount(ctx, db.CreateSettlementAccountParams{
+			Currency: currency,
+			AccountID: result.Account.ID,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create settlement account: %w", err)
+		}
+
+		fmt.Fprintf(out, "created %s settlement account %d\n", currency, result.Account.ID)
+	}
+	return nil
+}
+
+func seedDemoUser(ctx context.Context, store db.Store, user seedUser, hashedPassword string, balance int64, out io.Writer) error {
+	_, err := store.CreateUserWithRole(ctx, db.CreateUserWithRoleParams{
+		Username: user.Username,
+		HashedPassword: hashedPassword,
+		FullName: user.FullName,
+		Email: fmt.Sprintf("%s@example.com", user.Username),
+		Role: util.DepositorRole,
+		IsEmailVerified: true,
+	})
+	if err != nil {
+		if db.ErrorCode(err) == db.UniqueViolation {
+			fmt.Fprintf(out, "user %s already exists, skipped\n", user.Username)
+			return nil
+		}
+		return fmt.Errorf("failed to create user %s: %w", user.Username, err)
+	}
+
+	for _, currency := range user.Currencies {
+		result, err := store.CreateAccountTx(ctx, db.CreateAccountParams{
+			Owner: user.Username,
+			Balance: 0,
+			Currency: currency,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create account of %s: %w", user.Username, err)
+		}
+
+		_, err = store.DepositTx(ctx, db.ExternalTransferTxParams{
+			AccountID: result.Account.ID,
+			Amount: balance,
+			ExternalReference: fmt.Sprintf("seed-%d", result.Account.ID),
+			CreatedBy: commandActor().Name,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to fund account of %s: %w", user.Username, err)
+		}
+	}
+
+	fmt.Fprintf(out, "created user %s with %d accounts\n", user.Username, len(user.Currencies))
+	return nil
+}
+
+func randomHashedPassword() (string, error) {
+	password, err := util.NewAPIKey()
+	if err != nil {
+		return "", err
+	}
+
+	hashedPassword, err := util.HashPassword(password)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	return hashedPassword, nil
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/juker1141/simplebank/db/mock"
+	db "github.com/juker1141/simplebank/db/sqlc"
+	"github.com/juker1141/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+// seedAccounts is the number of accounts of the demo users
+func seedAccounts() int {
+	n := 0
+	for _, user := range seedUsers {
+		n += len(user.Currencies)
+	}
+	return n
+}
+
+func TestSeedCommand(t *testing.T) {
+	testCases := []struct{
+		name string
+		environment string
+		args []string
+		buildStubs func(store *mockdb.MockStore)
+		checkResult func(t *testing.T, out string, err error)
+	}{
+		{
+			name: "OK",
+			environment: "development",
+			args: []string{"-balance", "100"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetSettlementAccount(gomock.Any(), gomock.Any()).
+					Times(3).
+					Return(db.SettlementAccount{}, nil)
+				store.EXPECT().
+					CreateUserWithRole(gomock.Any(), gomock.Any()).
+					Times(len(seedUsers)).
+					DoAndReturn(func(ctx context.Context, arg db.CreateUserWithRoleParams) (db.User, error) {
+						require.Equal(t, util.DepositorRole, arg.Role)
+						require.True(t, arg.IsEmailVerified)
+						require.NoError(t, util.CheckPassword("secret", arg.HashedPassword))
+						return db.User{Username: arg.Username}, nil
+					})
+				store.EXPECT().
+					CreateAccountTx(gomock.Any(), gomock.Any()).
+					Times(seedAccounts()).
+					DoAndReturn(func(ctx context.Context, arg db.CreateAccountParams) (db.CreateAccountTxResult, error) {
+						require.Zero(t, arg.Balance)
+						return db.CreateAccountTxResult{Account: db.Account{ID: util.RandomInt(1, 1000), Owner: arg.Owner}}, nil
+					})
+				store.EXPECT().
+					DepositTx(gomock.Any(), gomock.Any()).
+					Times(seedAccounts()).
+					DoAndReturn(func(ctx context.Context, arg db.ExternalTransferTxParams) (db.ExternalTransferTxResult, error) {
+						require.Equal(t, int64(100), arg.Amount)
+						require.NotEmpty(t, arg.ExternalReference)
+						return db.ExternalTransferTxResult{}, nil
+					})
+			},
+			checkResult: func(t *testing.T, out string, err error) {
+				require.NoError(t, err)
+				require.Contains(t, out, "created user alice with 2 accounts\n")
+			},
+		},
+		{
+			name: "UserExists",
+			environment: "development",
+			args: []string{},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetSettlementAccount(gomock.Any(), gomock.Any()).
+					Times(3).
+					Return(db.SettlementAccount{}, nil)
+				store.EXPECT().
+					CreateUserWithRole(gomock.Any(), gomock.Any()).
+					Times(len(seedUsers)).
+					Return(db.User{}, db.ErrUniqueViolation)
+				store.EXPECT().
+					CreateAccountTx(gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().
+					DepositTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResult: func(t *testing.T, out string, err error) {
+				require.NoError(t, err)
+				require.Contains(t, out, "user alice already exists, skipped\n")
+			},
+		},
+		{
+			name: "MissingSettlementAccount",
+			environment: "development",
+			args: []string{"-balance", "100"},
+			buildStubs: func(store *mockdb.MockStore) {
+				accountID := util.RandomInt(1, 1000)
+
+				store.EXPECT().
+					GetSettlementAccount(gomock.Any(), gomock.Eq(util.USD)).
+					Times(1).
+					Return(db.SettlementAccount{}, db.ErrRecordNotFound)
+				store.EXPECT().
+					GetSettlementAccount(gomock.Any(), gomock.Not(gomock.Eq(util.USD))).
+					Times(2).
+					Return(db.SettlementAccount{}, nil)
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(settlementUsername)).
+					Times(1).
+					Return(db.User{}, db.ErrRecordNotFound)
+				store.EXPECT().
+					CreateUserWithRole(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, arg db.CreateUserWithRoleParams) (db.User, error) {
+						require.Equal(t, settlementUsername, arg.Username)
+						return db.User{Username: arg.Username}, nil
+					})
+				store.EXPECT().
+					CreateAccountTx(gomock.Any(), gomock.Eq(db.CreateAccountParams{Owner: settlementUsername, Currency: util.USD})).
+					Times(1).
+					Return(db.CreateAccountTxResult{Account: db.Account{ID: accountID}}, nil)
+				store.EXPECT().
+					CreateSettlementAccount(gomock.Any(), gomock.Eq(db.CreateSettlementAccountParams{Currency: util.USD, AccountID: accountID})).
+					Times(1).
+					Return(db.SettlementAccount{}, nil)
+
+				// stops the seed after the settlement accounts
+				store.EXPECT().
+					CreateUserWithRole(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.User{}, sql.ErrConnDone)
+			},
+			checkResult: func(t *testing.T, out string, err error) {
+				require.ErrorIs(t, err, sql.ErrConnDone)
+				require.Contains(t, out, "created USD settlement account")
+			},
+		},
+		{
+			name: "Production",
+			environment: "production",
+			args: []string{},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetSettlementAccount(gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().
+					CreateUserWithRole(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResult: func(t *testing.T, out string, err error) {
+				require.EqualError(t, err, "refusing to seed the production environment without -force")
+			},
+		},
+		{
+			name: "InvalidBalance",
+			environment: "development",
+			args: []string{"-balance", "0"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetSettlementAccount(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResult: func(t *testing.T, out string, err error) {
+				require.ErrorContains(t, err, "invalid balance")
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			setupCommandStore(t, store)
+
+			var out bytes.Buffer
+			args := append([]string{"seed"}, tc.args...)
+			err := runCommand(context.Background(), util.Config{Environment: tc.environment}, args, &out)
+			tc.checkResult(t, out.String(), err)
+		})
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/juker1141/simplebank/db/mock"
+	db "github.com/juker1141/simplebank/db/sqlc"
+	"github.com/juker1141/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+// setupCommandStore runs the commands of the test with the store instead of connecting to the database
+func setupCommandStore(t *testing.T, store db.Store) {
+	connect := connectStore
+	connectStore = func(ctx context.Context, config util.Config) (db.Store, func(), error) {
+		return store, func() {}, nil
+	}
+	t.Cleanup(func() {
+		connectStore = connect
+	})
+}
+
+func TestRunCommand(t *testing.T) {
+	testCases := []struct{
+		name string
+		args []string
+		buildStubs func(store *mockdb.MockStore)
+		checkResult func(t *testing.T, out string, err error)
+	}{
+		{
+			name: "Help",
+			args: []string{"help"},
+			buildStubs: func(store *mockdb.MockStore) {
+			},
+			checkResult: func(t *testing.T, out string, err error) {
+				require.NoError(t, err)
+				require.Equal(t, commandUsage, out)
+			},
+		},
+		{
+			name: "UnknownCommand",
+			args: []string{"unknown"},
+			buildStubs: func(store *mockdb.MockStore) {
+			},
+			checkResult: func(t *testing.T, out string, err error) {
+				require.ErrorIs(t, err, errUsage)
+				require.Empty(t, out)
+			},
+		},
+		{
+			name: "MissingAction",
+			args: []string{"users"},
+			buildStubs: func(store *mockdb.MockStore) {
+			},
+			checkResult: func(t *testing.T, out string, err error) {
+				require.ErrorIs(t, err, errUsage)
+			},
+		},
+		{
+			name: "UnknownAction",
+			args: []string{"users", "delete", "-username", "alice"},
+			buildStubs: func(store *mockdb.MockStore) {
+			},
+			checkResult: func(t *testing.T, out string, err error) {
+				require.ErrorIs(t, err, errUsage)
+			},
+		},
+		{
+			name: "UnknownFlag",
+			args: []string{"sessions", "revoke", "-user", "alice"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					RevokeSessionsTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResult: func(t *testing.T, out string, err error) {
+				require.ErrorIs(t, err, errUsage)
+			},
+		},
+		{
+			name: "UnexpectedArguments",
+			args: []string{"sessions", "revoke", "-username", "alice", "bob"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					RevokeSessionsTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResult: func(t *testing.T, out string, err error) {
+				require.ErrorIs(t, err, errUsage)
+			},
+		},
+		{
+			name: "FlagHelp",
+			args: []string{"sessions", "revoke", "-h"},
+			buildStubs: func(store *mockdb.MockStore) {
+			},
+			checkResult: func(t *testing.T, out string, err error) {
+				require.ErrorIs(t, err, flag.ErrHelp)
+			},
+		},
+		{
+			name: "ExclusiveFlags",
+			args: []string{"sessions", "revoke", "-username", "alice", "-id", "e3c8a1c2-3b0e-4a8e-9a3b-1f6e1c1c1c1c"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					RevokeSessionsTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResult: func(t *testing.T, out string, err error) {
+				require.ErrorIs(t, err, errUsage)
+			},
+		},
+		{
+			name: "RevokeSessions",
+			args: []string{"sessions", "revoke", "-username", "alice"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					RevokeSessionsTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, arg db.RevokeSessionsTxParams) (db.RevokeSessionsTxResult, error) {
+						require.Equal(t, "alice", arg.Username)
+						require.NotNil(t, arg.Audit)
+						return db.RevokeSessionsTxResult{Sessions: []db.Session{{}, {}}}, nil
+					})
+			},
+			checkResult: func(t *testing.T, out string, err error) {
+				require.NoError(t, err)
+				require.Equal(t, "revoked 2 sessions\n", out)
+			},
+		},
+		{
+			name: "LockUserNotFound",
+			args: []string{"users", "lock", "-username", "alice"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					SetUserLockedTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.SetUserLockedTxResult{}, db.ErrRecordNotFound)
+			},
+			checkResult: func(t *testing.T, out string, err error) {
+				require.EqualError(t, err, "user alice not found")
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			setupCommandStore(t, store)
+
+			var out bytes.Buffer
+			err := runCommand(context.Background(), util.Config{}, tc.args, &out)
+			tc.checkResult(t, out.String(), err)
+		})
+	}
+}
+
+func TestConfigOverrides(t *testing.T) {
+	overrides := make(configOverrides)
+
+	require.NoError(t, overrides.Set("db_source=postgresql://localhost/simple_bank?sslmode=disable"))
+	require.NoError(t, overrides.Set("ENVIRONMENT="))
+	require.Equal(t, configOverrides{
+		"DB_SOURCE": "postgresql://localhost/simple_bank?sslmode=disable",
+		"ENVIRONMENT": "",
+	}, overrides)
+
+	for _, value := range []string{"ENVIRONMENT", "=production"} {
+		err := overrides.Set(value)
+		require.Error(t, err)
+		require.False(t, errors.Is(err, errUsage))
+	}
+}
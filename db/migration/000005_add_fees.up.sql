@@ -0,0 +1,50 @@
+CREATE TABLE "fee_rules" (
+  "id" bigserial PRIMARY KEY,
+  "name" varchar NOT NULL,
+  "fee_type" varchar NOT NULL,
+  "currency" varchar NOT NULL,
+  "flat_amount" bigint NOT NULL DEFAULT 0,
+  "basis_points" bigint NOT NULL DEFAULT 0,
+  "min_transfer_amount" bigint NOT NULL DEFAULT 0,
+  "free_transfers_per_month" bigint NOT NULL DEFAULT 0,
+  "cross_currency_only" boolean NOT NULL DEFAULT false,
+  "revenue_account_id" bigint NOT NULL,
+  "is_active" boolean NOT NULL DEFAULT true,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "transfer_fees" (
+  "id" bigserial PRIMARY KEY,
+  "transfer_id" bigint NOT NULL,
+  "fee_rule_id" bigint NOT NULL,
+  "account_id" bigint NOT NULL,
+  "revenue_account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "from_entry_id" bigint NOT NULL,
+  "to_entry_id" bigint NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "fee_rules" ("currency");
+
+CREATE INDEX ON "transfer_fees" ("transfer_id");
+
+COMMENT ON COLUMN "fee_rules"."fee_type" IS 'flat or percentage';
+
+COMMENT ON COLUMN "fee_rules"."basis_points" IS '1 basis point is 0.01%';
+
+COMMENT ON COLUMN "fee_rules"."free_transfers_per_month" IS 'the rule only applies after this many outgoing transfers in the month';
+
+ALTER TABLE "fee_rules" ADD FOREIGN KEY ("revenue_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "transfer_fees" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "transfer_fees" ADD FOREIGN KEY ("fee_rule_id") REFERENCES "fee_rules" ("id");
+
+ALTER TABLE "transfer_fees" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "transfer_fees" ADD FOREIGN KEY ("revenue_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "transfer_fees" ADD FOREIGN KEY ("from_entry_id") REFERENCES "entries" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "transfer_fees" ADD FOREIGN KEY ("to_entry_id") REFERENCES "entries" ("id") ON DELETE CASCADE;
@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS "external_transfers";
+
+DROP TABLE IF EXISTS "settlement_accounts";
+
+DROP TABLE IF EXISTS "api_keys";
+
+ALTER TABLE "entries" DROP COLUMN "type";
+
+ALTER TABLE "users" DROP COLUMN "role";
@@ -0,0 +1,57 @@
+ALTER TABLE "users" ADD COLUMN "role" varchar NOT NULL DEFAULT 'depositor';
+
+ALTER TABLE "entries" ADD COLUMN "type" varchar NOT NULL DEFAULT 'transfer';
+
+UPDATE "entries" SET "type" = 'fee'
+WHERE "id" IN (SELECT "from_entry_id" FROM "transfer_fees")
+  OR "id" IN (SELECT "to_entry_id" FROM "transfer_fees");
+
+CREATE TABLE "api_keys" (
+  "id" bigserial PRIMARY KEY,
+  "name" varchar NOT NULL,
+  "hashed_key" varchar UNIQUE NOT NULL,
+  "is_revoked" boolean NOT NULL DEFAULT false,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "settlement_accounts" (
+  "currency" varchar PRIMARY KEY,
+  "account_id" bigint UNIQUE NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "external_transfers" (
+  "id" bigserial PRIMARY KEY,
+  "type" varchar NOT NULL,
+  "account_id" bigint NOT NULL,
+  "settlement_account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "currency" varchar NOT NULL,
+  "external_reference" varchar NOT NULL,
+  "entry_id" bigint NOT NULL,
+  "settlement_entry_id" bigint NOT NULL,
+  "created_by" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "external_transfers" ("account_id");
+
+CREATE UNIQUE INDEX ON "external_transfers" ("type", "external_reference");
+
+COMMENT ON COLUMN "entries"."type" IS 'transfer, fee, deposit or withdrawal';
+
+COMMENT ON COLUMN "external_transfers"."type" IS 'deposit or withdrawal';
+
+COMMENT ON COLUMN "external_transfers"."amount" IS 'must be positive';
+
+COMMENT ON COLUMN "external_transfers"."external_reference" IS 'reference on the bank statement';
+
+ALTER TABLE "settlement_accounts" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "external_transfers" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "external_transfers" ADD FOREIGN KEY ("settlement_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "external_transfers" ADD FOREIGN KEY ("entry_id") REFERENCES "entries" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "external_transfers" ADD FOREIGN KEY ("settlement_entry_id") REFERENCES "entries" ("id") ON DELETE CASCADE;
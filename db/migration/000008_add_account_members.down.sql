@@ -0,0 +1,21 @@
+-- the up migration allows several accounts of an owner in the same currency,
+-- they have to be merged or deleted by hand before the unique constraint can be restored
+DO $$
+DECLARE
+  duplicates text;
+BEGIN
+  SELECT string_agg(format('%s/%s (%s accounts)', "owner", "currency", "count"), ', ') INTO duplicates
+  FROM (
+    SELECT "owner", "currency", count(*) AS "count" FROM "accounts"
+    GROUP BY "owner", "currency"
+    HAVING count(*) > 1
+  ) AS "duplicate_accounts";
+
+  IF duplicates IS NOT NULL THEN
+    RAISE EXCEPTION 'cannot restore the unique owner and currency of the accounts, these owners have several accounts in a currency: %', duplicates;
+  END IF;
+END $$;
+
+ALTER TABLE "accounts" ADD CONSTRAINT "owner_currency_key" UNIQUE ("owner", "currency");
+
+DROP TABLE IF EXISTS "account_members";
\ No newline at end of file
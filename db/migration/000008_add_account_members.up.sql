@@ -0,0 +1,24 @@
+CREATE TABLE "account_members" (
+  "account_id" bigint NOT NULL,
+  "username" varchar NOT NULL,
+  "role" varchar NOT NULL,
+  "invited_by" varchar NOT NULL,
+  "is_accepted" bool NOT NULL DEFAULT false,
+  "accepted_at" timestamptz NOT NULL DEFAULT '0001-01-01 00:00:00Z',
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("account_id", "username")
+);
+
+CREATE INDEX ON "account_members" ("username");
+
+ALTER TABLE "account_members" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "account_members" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "account_members" ADD FOREIGN KEY ("invited_by") REFERENCES "users" ("username");
+
+-- every existing account is owned by its creator
+INSERT INTO "account_members" ("account_id", "username", "role", "invited_by", "is_accepted", "accepted_at")
+SELECT "id", "owner", 'owner', "owner", true, "created_at" FROM "accounts";
+
+ALTER TABLE "accounts" DROP CONSTRAINT IF EXISTS "owner_currency_key";
@@ -0,0 +1 @@
+ALTER TABLE "users" ADD COLUMN "language" varchar NOT NULL DEFAULT 'en';
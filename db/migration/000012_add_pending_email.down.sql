@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS "verify_emails_username_created_at_idx";
+
+ALTER TABLE "users" DROP COLUMN IF EXISTS "pending_email";
\ No newline at end of file
@@ -0,0 +1,5 @@
+ALTER TABLE "users" ADD COLUMN "pending_email" varchar NOT NULL DEFAULT '';
+
+CREATE INDEX ON "verify_emails" ("username", "created_at");
+
+COMMENT ON COLUMN "users"."pending_email" IS 'new email address that is not verified yet';
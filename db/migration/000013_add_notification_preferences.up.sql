@@ -0,0 +1,17 @@
+CREATE TABLE "notification_preferences" (
+  "username" varchar NOT NULL,
+  "event_type" varchar NOT NULL,
+  "channel" varchar NOT NULL,
+  "is_enabled" bool NOT NULL DEFAULT true,
+  "min_amount" bigint NOT NULL DEFAULT 0,
+  "updated_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("username", "event_type", "channel")
+);
+
+COMMENT ON COLUMN "notification_preferences"."event_type" IS 'debit or credit';
+
+COMMENT ON COLUMN "notification_preferences"."channel" IS 'email';
+
+COMMENT ON COLUMN "notification_preferences"."min_amount" IS 'no notification is sent for smaller amounts';
+
+ALTER TABLE "notification_preferences" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
@@ -0,0 +1,9 @@
+COMMENT ON COLUMN "payment_requests"."status" IS 'pending, accepted or declined';
+
+DROP INDEX IF EXISTS "payment_requests_status_expired_at_idx";
+
+DROP INDEX IF EXISTS "verify_emails_expired_at_idx";
+
+DROP INDEX IF EXISTS "sessions_expires_at_idx";
+
+DROP TABLE IF EXISTS "scheduled_jobs";
\ No newline at end of file
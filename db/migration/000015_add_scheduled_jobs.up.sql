@@ -0,0 +1,27 @@
+CREATE TABLE "scheduled_jobs" (
+  "name" varchar PRIMARY KEY,
+  "schedule" varchar NOT NULL,
+  "next_run_at" timestamptz NOT NULL DEFAULT (now()),
+  "locked_by" varchar NOT NULL DEFAULT '',
+  "locked_until" timestamptz NOT NULL DEFAULT '0001-01-01 00:00:00Z',
+  "last_started_at" timestamptz NOT NULL DEFAULT '0001-01-01 00:00:00Z',
+  "last_finished_at" timestamptz NOT NULL DEFAULT '0001-01-01 00:00:00Z',
+  "last_status" varchar NOT NULL DEFAULT '',
+  "last_result" varchar NOT NULL DEFAULT '',
+  "last_error" varchar NOT NULL DEFAULT '',
+  "last_duration_ms" bigint NOT NULL DEFAULT 0
+);
+
+CREATE INDEX ON "sessions" ("expires_at");
+
+CREATE INDEX ON "verify_emails" ("expired_at");
+
+CREATE INDEX ON "payment_requests" ("status", "expired_at");
+
+COMMENT ON COLUMN "scheduled_jobs"."schedule" IS 'cron expression';
+
+COMMENT ON COLUMN "scheduled_jobs"."locked_by" IS 'worker running the job, empty when the job is idle';
+
+COMMENT ON COLUMN "scheduled_jobs"."last_status" IS 'succeeded or failed, empty if the job never ran';
+
+COMMENT ON COLUMN "payment_requests"."status" IS 'pending, accepted, declined or expired';
@@ -0,0 +1,3 @@
+ALTER TABLE "users" ADD COLUMN "is_locked" bool NOT NULL DEFAULT false;
+
+COMMENT ON COLUMN "users"."is_locked" IS 'locked users cannot log in and their sessions are revoked';
@@ -0,0 +1 @@
+ALTER TABLE "fee_rules" ADD COLUMN "cross_currency_only" boolean NOT NULL DEFAULT false;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE "fee_rules" DROP COLUMN IF EXISTS "cross_currency_only";
\ No newline at end of file
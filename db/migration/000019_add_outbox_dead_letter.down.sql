@@ -0,0 +1,3 @@
+ALTER TABLE "outbox_events" DROP COLUMN IF EXISTS "is_dead_lettered";
+
+ALTER TABLE "outbox_events" DROP COLUMN IF EXISTS "claimed_until";
\ No newline at end of file
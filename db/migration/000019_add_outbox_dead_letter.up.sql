@@ -0,0 +1,7 @@
+ALTER TABLE "outbox_events" ADD COLUMN "claimed_until" timestamptz NOT NULL DEFAULT '0001-01-01 00:00:00Z';
+
+ALTER TABLE "outbox_events" ADD COLUMN "is_dead_lettered" bool NOT NULL DEFAULT false;
+
+COMMENT ON COLUMN "outbox_events"."claimed_until" IS 'the event is being published by a relay until then, or retried after a failure';
+
+COMMENT ON COLUMN "outbox_events"."is_dead_lettered" IS 'the event failed too many times and is only published again when it is replayed';
\ No newline at end of file
@@ -0,0 +1,7 @@
+COMMENT ON COLUMN "webhook_endpoints"."owner" IS 'receives the events of every user when null';
+
+ALTER TABLE "webhook_endpoints" DROP CONSTRAINT IF EXISTS "webhook_endpoints_single_owner";
+
+ALTER TABLE "webhook_endpoints" DROP COLUMN IF EXISTS "api_key_id";
+
+ALTER TABLE "api_keys" DROP COLUMN IF EXISTS "scopes";
\ No newline at end of file
@@ -0,0 +1,34 @@
+ALTER TABLE "api_keys" ADD COLUMN "scopes" varchar[] NOT NULL DEFAULT '{}';
+
+ALTER TABLE "webhook_endpoints" ADD COLUMN "api_key_id" bigint;
+
+ALTER TABLE "webhook_endpoints" ADD FOREIGN KEY ("api_key_id") REFERENCES "api_keys" ("id");
+
+CREATE INDEX ON "webhook_endpoints" ("api_key_id");
+
+ALTER TABLE "webhook_endpoints" ADD CONSTRAINT "webhook_endpoints_single_owner" CHECK ("owner" IS NULL OR "api_key_id" IS NULL);
+
+-- the endpoints created with an API key were shared by every key,
+-- they are given to the key that created them when its name is unique
+UPDATE "webhook_endpoints" AS e
+SET "api_key_id" = k."id"
+FROM "api_keys" AS k
+WHERE
+  e."owner" IS NULL
+  AND e."created_by" = 'api_key:' || k."name"
+  AND (SELECT count(*) FROM "api_keys" WHERE "name" = k."name") = 1;
+
+-- the keys keep receiving the events of their endpoints
+UPDATE "api_keys"
+SET "scopes" = array_append("scopes", 'webhooks')
+WHERE "id" IN (SELECT "api_key_id" FROM "webhook_endpoints" WHERE "api_key_id" IS NOT NULL);
+
+UPDATE "webhook_endpoints"
+SET "is_active" = false
+WHERE "owner" IS NULL AND "api_key_id" IS NULL;
+
+COMMENT ON COLUMN "api_keys"."scopes" IS 'webhooks: the endpoints of the key receive the events of every user';
+
+COMMENT ON COLUMN "webhook_endpoints"."owner" IS 'the user whose events are received, null for the endpoints of an API key';
+
+COMMENT ON COLUMN "webhook_endpoints"."api_key_id" IS 'the API key that manages the endpoint, null for the endpoints of a user';
\ No newline at end of file
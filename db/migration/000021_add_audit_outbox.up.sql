@@ -0,0 +1,20 @@
+CREATE TABLE "audit_outbox" (
+  "id" bigserial PRIMARY KEY,
+  "event_type" varchar NOT NULL,
+  "actor" varchar NOT NULL,
+  "target_type" varchar NOT NULL,
+  "target_id" varchar NOT NULL,
+  "client_ip" varchar NOT NULL DEFAULT '',
+  "user_agent" varchar NOT NULL DEFAULT '',
+  "request_id" varchar NOT NULL DEFAULT '',
+  "before" json,
+  "after" json,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+COMMENT ON COLUMN "audit_outbox"."created_at" IS 'time of the change, kept by the audit event once the record is chained';
+
+-- the records are only deleted by the audit chainer, once they are copied to audit_events
+CREATE TRIGGER "audit_outbox_append_only"
+BEFORE UPDATE ON "audit_outbox"
+FOR EACH ROW EXECUTE FUNCTION "reject_audit_event_change"();
\ No newline at end of file
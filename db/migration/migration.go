@@ -0,0 +1,79 @@
+// Package migration embeds the migrations of the database,
+// so the binaries can migrate it without the files of db/migration
+package migration
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+)
+
+//go:embed *.sql
+var files embed.FS
+
+func newSource() (source.Driver, error) {
+	driver, err := iofs.New(files, ".")
+	if err != nil {
+		return nil, fmt.Errorf("cannot open embedded migrations: %w", err)
+	}
+	return driver, nil
+}
+
+// New creates a migrate instance that applies the embedded migrations to the database
+func New(dbSource string) (*migrate.Migrate, error) {
+	driver, err := newSource()
+	if err != nil {
+		return nil, err
+	}
+
+	migration, err := migrate.NewWithSourceInstance("iofs", driver, dbSource)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create migrate instance: %w", err)
+	}
+	return migration, nil
+}
+
+// Up applies all the migrations that aren't applied yet, it's not an error if there are none
+func Up(dbSource string) error {
+	migration, err := New(dbSource)
+	if err != nil {
+		return err
+	}
+	defer migration.Close()
+
+	if err := migration.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("failed to run migrate up: %w", err)
+	}
+	return nil
+}
+
+// LastVersion returns the version of the last embedded migration
+func LastVersion() (uint, error) {
+	driver, err := newSource()
+	if err != nil {
+		return 0, err
+	}
+	defer driver.Close()
+
+	version, err := driver.First()
+	if err != nil {
+		return 0, fmt.Errorf("cannot read first migration: %w", err)
+	}
+
+	for {
+		next, err := driver.Next(version)
+		if errors.Is(err, fs.ErrNotExist) {
+			return version, nil
+		}
+		if err != nil {
+			return 0, fmt.Errorf("cannot read migration after %d: %w", version, err)
+		}
+		version = next
+	}
+}
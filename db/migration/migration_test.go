@@ -0,0 +1,29 @@
+package migration
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLastVersion(t *testing.T) {
+	files, err := filepath.Glob("*.up.sql")
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+
+	expected := uint64(0)
+	for _, file := range files {
+		version, err := strconv.ParseUint(strings.SplitN(file, "_", 2)[0], 10, 64)
+		require.NoError(t, err)
+		if version > expected {
+			expected = version
+		}
+	}
+
+	version, err := LastVersion()
+	require.NoError(t, err)
+	require.Equal(t, uint(expected), version)
+}
@@ -17,11 +17,14 @@ WHERE id = $1 LIMIT 1
 FOR NO KEY UPDATE;
 
 -- name: ListAccounts :many
-SELECT * FROM accounts
-WHERE owner = $1
-ORDER BY id
-LIMIT $2
-OFFSET $3;
+SELECT accounts.* FROM accounts
+JOIN account_members ON account_members.account_id = accounts.id
+WHERE
+  account_members.username = sqlc.arg(username)
+  AND account_members.is_accepted = TRUE
+ORDER BY accounts.id
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
 
 -- name: UpdateAccount :one
 UPDATE accounts
@@ -37,3 +40,12 @@ RETURNING *;
 
 -- name: DeleteAccount :exec
 DELETE FROM accounts WHERE id = $1;
+
+-- name: ListAccountTotals :many
+SELECT
+  currency,
+  count(*) AS accounts,
+  COALESCE(sum(balance), 0)::bigint AS balance
+FROM accounts
+GROUP BY currency
+ORDER BY currency;
@@ -0,0 +1,18 @@
+-- name: CreateAPIKey :one
+INSERT INTO api_keys (
+  name,
+  hashed_key,
+  scopes
+) VALUES (
+  $1, $2, $3
+) RETURNING *;
+
+-- name: GetAPIKeyByHashedKey :one
+SELECT * FROM api_keys
+WHERE hashed_key = $1 LIMIT 1;
+
+-- name: RevokeAPIKey :one
+UPDATE api_keys
+SET is_revoked = TRUE
+WHERE id = $1
+RETURNING *;
@@ -0,0 +1,68 @@
+-- name: TryLockAuditEvents :one
+SELECT pg_try_advisory_xact_lock(hashtext('audit_events'))::bool AS locked;
+
+-- name: CreateAuditOutboxEvent :exec
+INSERT INTO audit_outbox (
+  event_type,
+  actor,
+  target_type,
+  target_id,
+  client_ip,
+  user_agent,
+  request_id,
+  before,
+  after
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9
+);
+
+-- name: ListAuditOutboxEvents :many
+SELECT * FROM audit_outbox
+ORDER BY id
+LIMIT $1;
+
+-- name: DeleteAuditOutboxEvents :exec
+DELETE FROM audit_outbox
+WHERE id = ANY(sqlc.arg(ids)::bigint[]);
+
+-- name: GetLastAuditEvent :one
+SELECT * FROM audit_events
+ORDER BY id DESC
+LIMIT 1;
+
+-- name: CreateAuditEvent :one
+INSERT INTO audit_events (
+  event_type,
+  actor,
+  target_type,
+  target_id,
+  client_ip,
+  user_agent,
+  request_id,
+  before,
+  after,
+  prev_hash,
+  hash,
+  created_at
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
+) RETURNING *;
+
+-- name: ListAuditEvents :many
+SELECT * FROM audit_events
+WHERE
+  (sqlc.narg(actor)::varchar IS NULL OR actor = sqlc.narg(actor)) AND
+  (sqlc.narg(event_type)::varchar IS NULL OR event_type = sqlc.narg(event_type)) AND
+  (sqlc.narg(target_type)::varchar IS NULL OR target_type = sqlc.narg(target_type)) AND
+  (sqlc.narg(target_id)::varchar IS NULL OR target_id = sqlc.narg(target_id)) AND
+  (sqlc.narg(start_time)::timestamptz IS NULL OR created_at >= sqlc.narg(start_time)) AND
+  (sqlc.narg(end_time)::timestamptz IS NULL OR created_at < sqlc.narg(end_time))
+ORDER BY id DESC
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
+
+-- name: ListAuditEventsAfter :many
+SELECT * FROM audit_events
+WHERE id > $1
+ORDER BY id
+LIMIT $2;
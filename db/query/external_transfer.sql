@@ -0,0 +1,41 @@
+-- name: CreateExternalTransfer :one
+INSERT INTO external_transfers (
+  type,
+  account_id,
+  settlement_account_id,
+  amount,
+  currency,
+  external_reference,
+  entry_id,
+  settlement_entry_id,
+  created_by
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9
+) RETURNING *;
+
+-- name: GetExternalTransfer :one
+SELECT * FROM external_transfers
+WHERE id = $1 LIMIT 1;
+
+-- name: GetExternalTransferByReference :one
+SELECT * FROM external_transfers
+WHERE type = $1 AND external_reference = $2 LIMIT 1;
+
+-- name: ListExternalTransfers :many
+SELECT * FROM external_transfers
+WHERE account_id = $1
+ORDER BY id
+LIMIT $2
+OFFSET $3;
+
+-- name: CreateSettlementAccount :one
+INSERT INTO settlement_accounts (
+  currency,
+  account_id
+) VALUES (
+  $1, $2
+) RETURNING *;
+
+-- name: GetSettlementAccount :one
+SELECT * FROM settlement_accounts
+WHERE currency = $1 LIMIT 1;
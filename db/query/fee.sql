@@ -0,0 +1,46 @@
+-- name: CreateFeeRule :one
+INSERT INTO fee_rules (
+  name,
+  fee_type,
+  currency,
+  flat_amount,
+  basis_points,
+  min_transfer_amount,
+  free_transfers_per_month,
+  revenue_account_id
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8
+) RETURNING *;
+
+-- name: GetFeeRule :one
+SELECT * FROM fee_rules
+WHERE id = $1 LIMIT 1;
+
+-- name: ListActiveFeeRules :many
+SELECT * FROM fee_rules
+WHERE currency = $1 AND is_active = TRUE
+ORDER BY id;
+
+-- name: UpdateFeeRuleActive :one
+UPDATE fee_rules
+SET is_active = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: CreateTransferFee :one
+INSERT INTO transfer_fees (
+  transfer_id,
+  fee_rule_id,
+  account_id,
+  revenue_account_id,
+  amount,
+  from_entry_id,
+  to_entry_id
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7
+) RETURNING *;
+
+-- name: ListTransferFees :many
+SELECT * FROM transfer_fees
+WHERE transfer_id = $1
+ORDER BY id;
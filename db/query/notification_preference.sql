@@ -0,0 +1,25 @@
+-- name: UpsertNotificationPreference :one
+INSERT INTO notification_preferences (
+  username,
+  event_type,
+  channel,
+  is_enabled,
+  min_amount
+) VALUES (
+  $1, $2, $3, $4, $5
+) ON CONFLICT (username, event_type, channel) DO UPDATE
+SET
+  is_enabled = EXCLUDED.is_enabled,
+  min_amount = EXCLUDED.min_amount,
+  updated_at = now()
+RETURNING *;
+
+-- name: GetNotificationPreference :one
+SELECT * FROM notification_preferences
+WHERE username = $1 AND event_type = $2 AND channel = $3
+LIMIT 1;
+
+-- name: ListNotificationPreferences :many
+SELECT * FROM notification_preferences
+WHERE username = $1
+ORDER BY event_type, channel;
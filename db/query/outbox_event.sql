@@ -0,0 +1,65 @@
+-- name: CreateOutboxEvent :one
+INSERT INTO outbox_events (
+  task_type,
+  payload,
+  queue,
+  max_retry,
+  process_at
+) VALUES (
+  $1, $2, $3, $4, $5
+) RETURNING *;
+
+-- name: GetOutboxEvent :one
+SELECT * FROM outbox_events
+WHERE id = $1 LIMIT 1;
+
+-- name: ClaimOutboxEvents :many
+UPDATE outbox_events
+SET claimed_until = sqlc.arg(claimed_until)
+WHERE id IN (
+  SELECT id FROM outbox_events
+  WHERE is_published = false AND is_dead_lettered = false AND claimed_until < now()
+  ORDER BY id
+  LIMIT sqlc.arg(batch_size)
+  FOR NO KEY UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: MarkOutboxEventPublished :one
+UPDATE outbox_events
+SET
+  is_published = true,
+  published_at = now()
+WHERE
+  id = $1
+RETURNING *;
+
+-- name: RecordOutboxEventError :one
+UPDATE outbox_events
+SET
+  attempts = attempts + 1,
+  last_error = sqlc.arg(last_error),
+  claimed_until = sqlc.arg(retry_at),
+  is_dead_lettered = attempts + 1 >= sqlc.arg(max_attempts)::int
+WHERE
+  id = sqlc.arg(id)
+RETURNING *;
+
+-- name: DeletePublishedOutboxEvents :execrows
+DELETE FROM outbox_events
+WHERE is_published = true AND published_at < sqlc.arg(published_before);
+
+-- name: ReplayOutboxEvents :many
+UPDATE outbox_events
+SET
+  is_published = false,
+  published_at = '0001-01-01 00:00:00Z',
+  attempts = 0,
+  last_error = '',
+  claimed_until = sqlc.arg(claimed_until),
+  is_dead_lettered = false
+WHERE
+  (is_published = true OR is_dead_lettered = true)
+  AND (sqlc.narg(id)::bigint IS NULL OR id = sqlc.narg(id))
+  AND (sqlc.narg(published_after)::timestamptz IS NULL OR published_at >= sqlc.narg(published_after))
+RETURNING *;
@@ -0,0 +1,57 @@
+-- name: CreatePaymentRequest :one
+INSERT INTO payment_requests (
+  payee,
+  payer,
+  to_account_id,
+  amount,
+  currency,
+  memo,
+  expired_at
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7
+) RETURNING *;
+
+-- name: GetPaymentRequest :one
+SELECT * FROM payment_requests
+WHERE id = $1 LIMIT 1;
+
+-- name: GetPaymentRequestForUpdate :one
+SELECT * FROM payment_requests
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: ListPaymentRequests :many
+SELECT * FROM payment_requests
+WHERE
+  (payee = sqlc.arg(username) OR payer = sqlc.arg(username))
+  AND status = sqlc.arg(status)
+ORDER BY id DESC
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
+
+-- name: AcceptPaymentRequest :one
+UPDATE payment_requests
+SET
+  status = 'accepted',
+  from_account_id = sqlc.arg(from_account_id),
+  transfer_id = sqlc.arg(transfer_id),
+  responded_at = now()
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: DeclinePaymentRequest :one
+UPDATE payment_requests
+SET
+  status = 'declined',
+  responded_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- name: ExpirePaymentRequests :execrows
+UPDATE payment_requests
+SET
+  status = 'expired',
+  responded_at = now()
+WHERE
+  status = 'pending'
+  AND expired_at < now();
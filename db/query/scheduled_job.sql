@@ -0,0 +1,51 @@
+-- name: UpsertScheduledJob :one
+INSERT INTO scheduled_jobs (
+  name,
+  schedule,
+  next_run_at
+) VALUES (
+  $1, $2, $3
+) ON CONFLICT (name) DO UPDATE
+SET
+  schedule = EXCLUDED.schedule,
+  next_run_at = CASE
+    WHEN scheduled_jobs.schedule = EXCLUDED.schedule THEN scheduled_jobs.next_run_at
+    ELSE EXCLUDED.next_run_at
+  END
+RETURNING *;
+
+-- name: GetScheduledJob :one
+SELECT * FROM scheduled_jobs
+WHERE name = $1 LIMIT 1;
+
+-- name: ListScheduledJobs :many
+SELECT * FROM scheduled_jobs
+ORDER BY name;
+
+-- name: AcquireScheduledJob :one
+UPDATE scheduled_jobs
+SET
+  locked_by = @locked_by,
+  locked_until = @locked_until,
+  last_started_at = now()
+WHERE
+  name = @name
+  AND next_run_at <= now()
+  AND locked_until < now()
+RETURNING *;
+
+-- name: FinishScheduledJob :one
+UPDATE scheduled_jobs
+SET
+  locked_by = '',
+  locked_until = '0001-01-01 00:00:00Z',
+  next_run_at = @next_run_at,
+  last_finished_at = now(),
+  last_status = @last_status,
+  last_result = @last_result,
+  last_error = @last_error,
+  last_duration_ms = @last_duration_ms
+WHERE
+  name = @name
+  AND locked_by = @locked_by
+RETURNING *;
@@ -13,4 +13,20 @@ INSERT INTO sessions (
 
 -- name: GetSession :one
 SELECT * FROM sessions
-WHERE id = $1 LIMIT 1;
\ No newline at end of file
+WHERE id = $1 LIMIT 1;
+
+-- name: DeleteExpiredSessions :execrows
+DELETE FROM sessions
+WHERE expires_at < @before;
+
+-- name: RevokeSession :one
+UPDATE sessions
+SET is_blocked = true
+WHERE id = $1 AND is_blocked = false
+RETURNING *;
+
+-- name: RevokeUserSessions :many
+UPDATE sessions
+SET is_blocked = true
+WHERE username = $1 AND is_blocked = false AND expires_at > now()
+RETURNING *;
@@ -24,4 +24,18 @@ WHERE id = $1
 RETURNING *;
 
 -- name: DeleteTransfer :exec
-DELETE FROM transfers WHERE id = $1;
\ No newline at end of file
+DELETE FROM transfers WHERE id = $1;
+
+-- name: CountTransfersFromAccountSince :one
+SELECT count(*) FROM transfers
+WHERE from_account_id = $1 AND created_at >= sqlc.arg(since);
+
+-- name: ListTransferVolumes :many
+SELECT
+  a.currency,
+  count(*) AS transfers,
+  COALESCE(sum(t.amount), 0)::bigint AS amount
+FROM transfers t
+JOIN accounts a ON a.id = t.from_account_id
+GROUP BY a.currency
+ORDER BY a.currency;
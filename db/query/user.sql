@@ -8,10 +8,27 @@ INSERT INTO users (
   $1, $2, $3, $4
 ) RETURNING *;
 
+-- name: CreateUserWithRole :one
+INSERT INTO users (
+  username,
+  hashed_password,
+  full_name,
+  email,
+  role,
+  is_email_verified
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+) RETURNING *;
+
 -- name: GetUser :one
 SELECT * FROM users
 WHERE username = $1 LIMIT 1;
 
+-- name: GetUserForUpdate :one
+SELECT * FROM users
+WHERE username = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
 -- name: UpdateUser :one
 UPDATE users
 SET
@@ -19,7 +36,15 @@ SET
   password_changed_at = COALESCE(sqlc.narg(password_changed_at), password_changed_at),
   full_name = COALESCE(sqlc.narg(full_name), full_name),
   email = COALESCE(sqlc.narg(email), email),
-  is_email_verified = COALESCE(sqlc.narg(is_email_verified), is_email_verified)
+  is_email_verified = COALESCE(sqlc.narg(is_email_verified), is_email_verified),
+  language = COALESCE(sqlc.narg(language), language),
+  pending_email = COALESCE(sqlc.narg(pending_email), pending_email)
 WHERE
   username = sqlc.arg(username)
+RETURNING *;
+
+-- name: SetUserLocked :one
+UPDATE users
+SET is_locked = @is_locked
+WHERE username = @username
 RETURNING *;
\ No newline at end of file
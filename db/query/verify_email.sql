@@ -16,4 +16,33 @@ WHERE
   AND secret_code = @secret_code
   AND is_used = FALSE
   AND expired_at > now()
-RETURNING *;
\ No newline at end of file
+RETURNING *;
+
+-- name: GetVerifyEmail :one
+SELECT * FROM verify_emails
+WHERE id = $1 LIMIT 1;
+
+-- name: GetLastVerifyEmail :one
+SELECT * FROM verify_emails
+WHERE username = $1
+ORDER BY created_at DESC
+LIMIT 1;
+
+-- name: CountVerifyEmailsSince :one
+SELECT count(*) FROM verify_emails
+WHERE username = @username AND created_at >= @since;
+
+-- name: InvalidateVerifyEmails :execrows
+UPDATE verify_emails
+SET
+  expired_at = now()
+WHERE
+  username = $1
+  AND is_used = FALSE
+  AND expired_at > now();
+
+-- name: DeleteStaleVerifyEmails :execrows
+DELETE FROM verify_emails
+WHERE
+  (is_used = TRUE OR expired_at < now())
+  AND created_at < @before;
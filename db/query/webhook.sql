@@ -0,0 +1,90 @@
+-- name: CreateWebhookEndpoint :one
+INSERT INTO webhook_endpoints (
+  owner,
+  api_key_id,
+  url,
+  secret,
+  events,
+  created_by
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+) RETURNING *;
+
+-- name: GetWebhookEndpoint :one
+SELECT * FROM webhook_endpoints
+WHERE id = $1 LIMIT 1;
+
+-- name: ListWebhookEndpoints :many
+SELECT * FROM webhook_endpoints
+WHERE
+  owner IS NOT DISTINCT FROM sqlc.narg(owner)::varchar
+  AND api_key_id IS NOT DISTINCT FROM sqlc.narg(api_key_id)::bigint
+  AND is_active = TRUE
+ORDER BY id;
+
+-- name: ListWebhookEndpointsForEvent :many
+SELECT * FROM webhook_endpoints
+WHERE
+  is_active = TRUE
+  AND @event_type::varchar = ANY(events)
+  AND (
+    owner = ANY(@usernames::varchar[])
+    -- the endpoints of an API key receive every event while the key can see them
+    OR api_key_id IN (
+      SELECT id FROM api_keys
+      WHERE is_revoked = FALSE AND @api_key_scope::varchar = ANY(scopes)
+    )
+  )
+ORDER BY id;
+
+-- name: DeactivateWebhookEndpoint :one
+UPDATE webhook_endpoints
+SET is_active = FALSE
+WHERE id = $1
+RETURNING *;
+
+-- name: CreateWebhookDelivery :one
+INSERT INTO webhook_deliveries (
+  endpoint_id,
+  event_id,
+  event_type,
+  payload
+) VALUES (
+  $1, $2, $3, $4
+) ON CONFLICT (endpoint_id, event_id) DO NOTHING
+RETURNING *;
+
+-- name: GetWebhookDelivery :one
+SELECT * FROM webhook_deliveries
+WHERE id = $1 LIMIT 1;
+
+-- name: ListWebhookDeliveries :many
+SELECT * FROM webhook_deliveries
+WHERE endpoint_id = $1
+ORDER BY id DESC
+LIMIT $2
+OFFSET $3;
+
+-- name: UpdateWebhookDeliveryStatus :one
+UPDATE webhook_deliveries
+SET
+  status = @status,
+  attempts = attempts + sqlc.arg(attempted)::int,
+  delivered_at = COALESCE(sqlc.narg(delivered_at), delivered_at)
+WHERE id = @id
+RETURNING *;
+
+-- name: CreateWebhookDeliveryAttempt :one
+INSERT INTO webhook_delivery_attempts (
+  delivery_id,
+  response_status,
+  error,
+  duration_ms
+) VALUES (
+  $1, $2, $3, $4
+) RETURNING *;
+
+-- name: ListWebhookDeliveryAttempts :many
+SELECT * FROM webhook_delivery_attempts
+WHERE delivery_id = $1
+ORDER BY id;
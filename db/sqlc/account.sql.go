@@ -109,22 +109,61 @@ func (q *Queries) GetAccountForUpdate(ctx context.Context, id int64) (Account, e
 	return i, err
 }
 
+const listAccountTotals = `-- name: ListAccountTotals :many
+SELECT
+  currency,
+  count(*) AS accounts,
+  COALESCE(sum(balance), 0)::bigint AS balance
+FROM accounts
+GROUP BY currency
+ORDER BY currency
+`
+
+type ListAccountTotalsRow struct {
+	Currency string `json:"currency"`
+	Accounts int64  `json:"accounts"`
+	Balance  int64  `json:"balance"`
+}
+
+func (q *Queries) ListAccountTotals(ctx context.Context) ([]ListAccountTotalsRow, error) {
+	rows, err := q.db.Query(ctx, listAccountTotals)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListAccountTotalsRow{}
+	for rows.Next() {
+		var i ListAccountTotalsRow
+		if err := rows.Scan(&i.Currency, &i.Accounts, &i.Balance); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listAccounts = `-- name: ListAccounts :many
-SELECT id, owner, balance, currency, created_at FROM accounts
-WHERE owner = $1
-ORDER BY id
-LIMIT $2
-OFFSET $3
+SELECT accounts.id, accounts.owner, accounts.balance, accounts.currency, accounts.created_at FROM accounts
+JOIN account_members ON account_members.account_id = accounts.id
+WHERE
+  account_members.username = $1
+  AND account_members.is_accepted = TRUE
+ORDER BY accounts.id
+LIMIT $3
+OFFSET $2
 `
 
 type ListAccountsParams struct {
-	Owner  string `json:"owner"`
-	Limit  int32  `json:"limit"`
-	Offset int32  `json:"offset"`
+	Username string `json:"username"`
+	Offset   int32  `json:"offset"`
+	Limit    int32  `json:"limit"`
 }
 
 func (q *Queries) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]Account, error) {
-	rows, err := q.db.Query(ctx, listAccounts, arg.Owner, arg.Limit, arg.Offset)
+	rows, err := q.db.Query(ctx, listAccounts, arg.Username, arg.Offset, arg.Limit)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,21 @@
+package db
+
+import "github.com/juker1141/simplebank/util"
+
+// CanView returns true if the member can see the account and its history
+func (member AccountMember) CanView() bool {
+	return member.IsAccepted
+}
+
+// CanTransfer returns true if the member can move money out of the account
+func (member AccountMember) CanTransfer() bool {
+	if !member.IsAccepted {
+		return false
+	}
+	return member.Role == util.AccountOwnerRole || member.Role == util.AccountCanTransferRole
+}
+
+// CanManage returns true if the member can invite other members to the account
+func (member AccountMember) CanManage() bool {
+	return member.IsAccepted && member.Role == util.AccountOwnerRole
+}
@@ -0,0 +1,142 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.18.0
+// source: account_member.sql
+
+package db
+
+import (
+	"context"
+)
+
+const acceptAccountMember = `-- name: AcceptAccountMember :one
+UPDATE account_members
+SET
+  is_accepted = TRUE,
+  accepted_at = now()
+WHERE
+  account_id = $1
+  AND username = $2
+  AND is_accepted = FALSE
+RETURNING account_id, username, role, invited_by, is_accepted, accepted_at, created_at
+`
+
+type AcceptAccountMemberParams struct {
+	AccountID int64  `json:"account_id"`
+	Username  string `json:"username"`
+}
+
+func (q *Queries) AcceptAccountMember(ctx context.Context, arg AcceptAccountMemberParams) (AccountMember, error) {
+	row := q.db.QueryRow(ctx, acceptAccountMember, arg.AccountID, arg.Username)
+	var i AccountMember
+	err := row.Scan(
+		&i.AccountID,
+		&i.Username,
+		&i.Role,
+		&i.InvitedBy,
+		&i.IsAccepted,
+		&i.AcceptedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createAccountMember = `-- name: CreateAccountMember :one
+INSERT INTO account_members (
+  account_id,
+  username,
+  role,
+  invited_by,
+  is_accepted
+) VALUES (
+  $1, $2, $3, $4, $5
+) RETURNING account_id, username, role, invited_by, is_accepted, accepted_at, created_at
+`
+
+type CreateAccountMemberParams struct {
+	AccountID  int64  `json:"account_id"`
+	Username   string `json:"username"`
+	Role       string `json:"role"`
+	InvitedBy  string `json:"invited_by"`
+	IsAccepted bool   `json:"is_accepted"`
+}
+
+func (q *Queries) CreateAccountMember(ctx context.Context, arg CreateAccountMemberParams) (AccountMember, error) {
+	row := q.db.QueryRow(ctx, createAccountMember,
+		arg.AccountID,
+		arg.Username,
+		arg.Role,
+		arg.InvitedBy,
+		arg.IsAccepted,
+	)
+	var i AccountMember
+	err := row.Scan(
+		&i.AccountID,
+		&i.Username,
+		&i.Role,
+		&i.InvitedBy,
+		&i.IsAccepted,
+		&i.AcceptedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getAccountMember = `-- name: GetAccountMember :one
+SELECT account_id, username, role, invited_by, is_accepted, accepted_at, created_at FROM account_members
+WHERE account_id = $1 AND username = $2 LIMIT 1
+`
+
+type GetAccountMemberParams struct {
+	AccountID int64  `json:"account_id"`
+	Username  string `json:"username"`
+}
+
+func (q *Queries) GetAccountMember(ctx context.Context, arg GetAccountMemberParams) (AccountMember, error) {
+	row := q.db.QueryRow(ctx, getAccountMember, arg.AccountID, arg.Username)
+	var i AccountMember
+	err := row.Scan(
+		&i.AccountID,
+		&i.Username,
+		&i.Role,
+		&i.InvitedBy,
+		&i.IsAccepted,
+		&i.AcceptedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listAccountMembers = `-- name: ListAccountMembers :many
+SELECT account_id, username, role, invited_by, is_accepted, accepted_at, created_at FROM account_members
+WHERE account_id = $1
+ORDER BY created_at
+`
+
+func (q *Queries) ListAccountMembers(ctx context.Context, accountID int64) ([]AccountMember, error) {
+	rows, err := q.db.Query(ctx, listAccountMembers, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []AccountMember{}
+	for rows.Next() {
+		var i AccountMember
+		if err := rows.Scan(
+			&i.AccountID,
+			&i.Username,
+			&i.Role,
+			&i.InvitedBy,
+			&i.IsAccepted,
+			&i.AcceptedAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
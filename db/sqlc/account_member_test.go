@@ -0,0 +1,90 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/juker1141/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccountMemberPermissions(t *testing.T) {
+	testCases := []struct {
+		role        string
+		isAccepted  bool
+		canView     bool
+		canTransfer bool
+		canManage   bool
+	}{
+		{role: util.AccountOwnerRole, isAccepted: true, canView: true, canTransfer: true, canManage: true},
+		{role: util.AccountCanTransferRole, isAccepted: true, canView: true, canTransfer: true},
+		{role: util.AccountViewOnlyRole, isAccepted: true, canView: true},
+		{role: util.AccountOwnerRole, isAccepted: false},
+	}
+
+	for _, tc := range testCases {
+		member := AccountMember{Role: tc.role, IsAccepted: tc.isAccepted}
+		require.Equal(t, tc.canView, member.CanView())
+		require.Equal(t, tc.canTransfer, member.CanTransfer())
+		require.Equal(t, tc.canManage, member.CanManage())
+	}
+}
+
+func TestInviteAndAcceptAccountMember(t *testing.T) {
+	account := createRandomAccount(t)
+	user := createRandomUser(t)
+
+	invited, err := testStore.CreateAccountMember(context.Background(), CreateAccountMemberParams{
+		AccountID: account.ID,
+		Username: user.Username,
+		Role: util.AccountCanTransferRole,
+		InvitedBy: account.Owner,
+	})
+	require.NoError(t, err)
+	require.False(t, invited.IsAccepted)
+	require.False(t, invited.CanView())
+
+	// pending invitations don't grant access to the account
+	accounts, err := testStore.ListAccounts(context.Background(), ListAccountsParams{
+		Username: user.Username,
+		Limit: 5,
+	})
+	require.NoError(t, err)
+	require.Empty(t, accounts)
+
+	accepted, err := testStore.AcceptAccountMember(context.Background(), AcceptAccountMemberParams{
+		AccountID: account.ID,
+		Username: user.Username,
+	})
+	require.NoError(t, err)
+	require.True(t, accepted.IsAccepted)
+	require.True(t, accepted.CanTransfer())
+	require.NotZero(t, accepted.AcceptedAt)
+
+	accounts, err = testStore.ListAccounts(context.Background(), ListAccountsParams{
+		Username: user.Username,
+		Limit: 5,
+	})
+	require.NoError(t, err)
+	require.Len(t, accounts, 1)
+	require.Equal(t, account.ID, accounts[0].ID)
+
+	// an invitation can only be accepted once
+	_, err = testStore.AcceptAccountMember(context.Background(), AcceptAccountMemberParams{
+		AccountID: account.ID,
+		Username: user.Username,
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
+
+func TestCreateAccountsWithSameCurrency(t *testing.T) {
+	account1 := createRandomAccount(t)
+
+	result, err := testStore.CreateAccountTx(context.Background(), CreateAccountParams{
+		Owner: account1.Owner,
+		Currency: account1.Currency,
+	})
+	require.NoError(t, err)
+	require.NotEqual(t, account1.ID, result.Account.ID)
+	require.Equal(t, account1.Currency, result.Account.Currency)
+}
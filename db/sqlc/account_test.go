@@ -18,10 +18,16 @@ func createRandomAccount(t *testing.T) Account {
 		Currency: util.RandomCurrency(),
 	}
 
-	account, err := testStore.CreateAccount(context.Background(), arg)
+	result, err := testStore.CreateAccountTx(context.Background(), arg)
 
 	require.NoError(t, err)
-	require.NotEmpty(t, account)
+	require.NotEmpty(t, result)
+
+	account := result.Account
+	require.Equal(t, account.ID, result.Member.AccountID)
+	require.Equal(t, arg.Owner, result.Member.Username)
+	require.Equal(t, util.AccountOwnerRole, result.Member.Role)
+	require.True(t, result.Member.IsAccepted)
 
 	require.Equal(t, arg.Owner, account.Owner)
 	require.Equal(t, arg.Balance, account.Balance)
@@ -89,7 +95,7 @@ func TestListAccounts(t *testing.T) {
 	}
 
 	arg := ListAccountsParams{
-		Owner: lastAccount.Owner,
+		Username: lastAccount.Owner,
 		Limit: 5,
 		Offset: 0,
 	}
@@ -0,0 +1,11 @@
+package db
+
+// HasScope returns true if the API key was granted the scope
+func (apiKey ApiKey) HasScope(scope string) bool {
+	for _, value := range apiKey.Scopes {
+		if value == scope {
+			return true
+		}
+	}
+	return false
+}
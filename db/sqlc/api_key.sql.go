@@ -0,0 +1,80 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.18.0
+// source: api_key.sql
+
+package db
+
+import (
+	"context"
+)
+
+const createAPIKey = `-- name: CreateAPIKey :one
+INSERT INTO api_keys (
+  name,
+  hashed_key,
+  scopes
+) VALUES (
+  $1, $2, $3
+) RETURNING id, name, hashed_key, is_revoked, created_at, scopes
+`
+
+type CreateAPIKeyParams struct {
+	Name      string   `json:"name"`
+	HashedKey string   `json:"hashed_key"`
+	Scopes    []string `json:"scopes"`
+}
+
+func (q *Queries) CreateAPIKey(ctx context.Context, arg CreateAPIKeyParams) (ApiKey, error) {
+	row := q.db.QueryRow(ctx, createAPIKey, arg.Name, arg.HashedKey, arg.Scopes)
+	var i ApiKey
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.HashedKey,
+		&i.IsRevoked,
+		&i.CreatedAt,
+		&i.Scopes,
+	)
+	return i, err
+}
+
+const getAPIKeyByHashedKey = `-- name: GetAPIKeyByHashedKey :one
+SELECT id, name, hashed_key, is_revoked, created_at, scopes FROM api_keys
+WHERE hashed_key = $1 LIMIT 1
+`
+
+func (q *Queries) GetAPIKeyByHashedKey(ctx context.Context, hashedKey string) (ApiKey, error) {
+	row := q.db.QueryRow(ctx, getAPIKeyByHashedKey, hashedKey)
+	var i ApiKey
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.HashedKey,
+		&i.IsRevoked,
+		&i.CreatedAt,
+		&i.Scopes,
+	)
+	return i, err
+}
+
+const revokeAPIKey = `-- name: RevokeAPIKey :one
+UPDATE api_keys
+SET is_revoked = TRUE
+WHERE id = $1
+RETURNING id, name, hashed_key, is_revoked, created_at, scopes
+`
+
+func (q *Queries) RevokeAPIKey(ctx context.Context, id int64) (ApiKey, error) {
+	row := q.db.QueryRow(ctx, revokeAPIKey, id)
+	var i ApiKey
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.HashedKey,
+		&i.IsRevoked,
+		&i.CreatedAt,
+		&i.Scopes,
+	)
+	return i, err
+}
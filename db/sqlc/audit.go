@@ -0,0 +1,275 @@
+package db
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Types of the audit events
+const (
+	AuditEventLogin                = "login"
+	AuditEventLoginFailed          = "login_failed"
+	AuditEventPasswordChanged      = "password_changed"
+	AuditEventEmailChangeRequested = "email_change_requested"
+	AuditEventEmailChanged         = "email_changed"
+	AuditEventSessionRevoked       = "session_revoked"
+	AuditEventUserStatusChanged    = "user_status_changed"
+	AuditEventTransfer             = "transfer"
+	AuditEventDeposit              = "deposit"
+	AuditEventWithdrawal           = "withdrawal"
+	AuditEventAdminAction          = "admin_action"
+)
+
+// Types of the targets of the audit events
+const (
+	AuditTargetUser             = "user"
+	AuditTargetSession          = "session"
+	AuditTargetTransfer         = "transfer"
+	AuditTargetExternalTransfer = "external_transfer"
+	AuditTargetTask             = "task"
+	AuditTargetTaskQueue        = "task_queue"
+	AuditTargetWebhookDelivery  = "webhook_delivery"
+)
+
+const (
+	// AuditActorSystem is the actor of the changes that aren't made by a user, an API key or a service
+	AuditActorSystem = "system"
+	// AuditActorAnonymous is the actor of the requests that aren't authenticated, such as failed logins
+	AuditActorAnonymous = "anonymous"
+)
+
+var ErrAuditChainBroken = errors.New("audit chain is broken")
+
+// AuditActor is who made a change, and where the change came from
+type AuditActor struct {
+	Name      string
+	ClientIP  string
+	UserAgent string
+	RequestID string
+}
+
+// AuditRecord is an event to append to the audit log.
+// Before and After are snapshots of the target, encoded to JSON. They are nil if the target didn't exist
+type AuditRecord struct {
+	EventType  string
+	Actor      AuditActor
+	TargetType string
+	TargetID   string
+	Before     any
+	After      any
+}
+
+// AppendAuditEventsTx writes the records to the audit outbox, for the events that aren't part of another transaction
+func (store *SQLStore) AppendAuditEventsTx(ctx context.Context, records []AuditRecord) error {
+	return store.execTx(ctx, "AppendAuditEventsTx", func(q *Queries) error {
+		return createAuditOutboxEvents(ctx, q, records)
+	})
+}
+
+// ChainAuditEventsTx moves a batch of records from the audit outbox to the audit log, chaining every event
+// to the one before it, and returns the number of chained events.
+// The audit log is locked by the chainer rather than by the transactions that write the records,
+// so the money movements never wait for each other to be audited. The events are chained by a single
+// instance at a time, the others chain nothing while the lock is held
+func (store *SQLStore) ChainAuditEventsTx(ctx context.Context, limit int32) (int, error) {
+	chained := 0
+
+	err := store.execTx(ctx, "ChainAuditEventsTx", func(q *Queries) error {
+		locked, err := q.TryLockAuditEvents(ctx)
+		if err != nil || !locked {
+			return err
+		}
+
+		records, err := q.ListAuditOutboxEvents(ctx, limit)
+		if err != nil || len(records) == 0 {
+			return err
+		}
+
+		prevHash := ""
+		last, err := q.GetLastAuditEvent(ctx)
+		if err == nil {
+			prevHash = last.Hash
+		} else if !errors.Is(err, ErrRecordNotFound) {
+			return err
+		}
+
+		ids := make([]int64, 0, len(records))
+		for _, record := range records {
+			arg := CreateAuditEventParams{
+				EventType:  record.EventType,
+				Actor:      record.Actor,
+				TargetType: record.TargetType,
+				TargetID:   record.TargetID,
+				ClientIp:   record.ClientIp,
+				UserAgent:  record.UserAgent,
+				RequestID:  record.RequestID,
+				Before:     record.Before,
+				After:      record.After,
+				PrevHash:   prevHash,
+				CreatedAt:  record.CreatedAt,
+			}
+			arg.Hash = HashAuditEvent(AuditEvent{
+				EventType:  arg.EventType,
+				Actor:      arg.Actor,
+				TargetType: arg.TargetType,
+				TargetID:   arg.TargetID,
+				ClientIp:   arg.ClientIp,
+				UserAgent:  arg.UserAgent,
+				RequestID:  arg.RequestID,
+				Before:     arg.Before,
+				After:      arg.After,
+				PrevHash:   arg.PrevHash,
+				CreatedAt:  arg.CreatedAt,
+			})
+
+			event, err := q.CreateAuditEvent(ctx, arg)
+			if err != nil {
+				return err
+			}
+
+			prevHash = event.Hash
+			ids = append(ids, record.ID)
+		}
+
+		if err := q.DeleteAuditOutboxEvents(ctx, ids); err != nil {
+			return err
+		}
+
+		chained = len(records)
+		return nil
+	})
+	return chained, err
+}
+
+// auditVerifyBatchSize is the number of events read at once to verify the audit log
+const auditVerifyBatchSize = 1000
+
+// VerifyAuditLog checks the hash chain of the whole audit log and returns the number of verified events.
+// It returns ErrAuditChainBroken if an event was changed, deleted or inserted out of the chain
+func (store *SQLStore) VerifyAuditLog(ctx context.Context) (int, error) {
+	verified := 0
+	prevHash := ""
+	lastID := int64(0)
+
+	for {
+		events, err := store.ListAuditEventsAfter(ctx, ListAuditEventsAfterParams{
+			ID:    lastID,
+			Limit: auditVerifyBatchSize,
+		})
+		if err != nil {
+			return verified, err
+		}
+
+		if err := VerifyAuditEvents(prevHash, events); err != nil {
+			return verified, err
+		}
+
+		verified += len(events)
+		if len(events) < auditVerifyBatchSize {
+			return verified, nil
+		}
+
+		last := events[len(events)-1]
+		prevHash = last.Hash
+		lastID = last.ID
+	}
+}
+
+// createAuditEvents writes the records of the value to the audit outbox.
+// The records are chained to the audit log once the transaction commits, so they are never audited for rolled-back changes
+func createAuditEvents[T any](ctx context.Context, q *Queries, records func(T) []AuditRecord, value T) error {
+	if records == nil {
+		return nil
+	}
+
+	return createAuditOutboxEvents(ctx, q, records(value))
+}
+
+// createAuditOutboxEvents writes the records to the audit outbox. Unlike the audit log, the outbox isn't locked,
+// so the concurrent transactions write their records without waiting for each other
+func createAuditOutboxEvents(ctx context.Context, q *Queries, records []AuditRecord) error {
+	for _, record := range records {
+		before, err := marshalAuditSnapshot(record.Before)
+		if err != nil {
+			return err
+		}
+		after, err := marshalAuditSnapshot(record.After)
+		if err != nil {
+			return err
+		}
+
+		err = q.CreateAuditOutboxEvent(ctx, CreateAuditOutboxEventParams{
+			EventType:  record.EventType,
+			Actor:      record.Actor.Name,
+			TargetType: record.TargetType,
+			TargetID:   record.TargetID,
+			ClientIp:   record.Actor.ClientIP,
+			UserAgent:  record.Actor.UserAgent,
+			RequestID:  record.Actor.RequestID,
+			Before:     before,
+			After:      after,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func marshalAuditSnapshot(snapshot any) ([]byte, error) {
+	if snapshot == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal audit snapshot: %w", err)
+	}
+	return data, nil
+}
+
+// HashAuditEvent returns the hash of the event, which covers all of its fields but the ID and the hash itself.
+// The snapshots are hashed as they are stored, which is why they are kept in json rather than jsonb columns
+func HashAuditEvent(event AuditEvent) string {
+	fields := []string{
+		event.PrevHash,
+		event.EventType,
+		event.Actor,
+		event.TargetType,
+		event.TargetID,
+		event.ClientIp,
+		event.UserAgent,
+		event.RequestID,
+		string(event.Before),
+		string(event.After),
+		event.CreatedAt.UTC().Format(time.RFC3339Nano),
+	}
+
+	hash := sha256.New()
+	for _, field := range fields {
+		// the length prefix keeps the boundaries of the fields in the hash
+		fmt.Fprintf(hash, "%d:%s", len(field), field)
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// VerifyAuditEvents checks that the events are chained to each other, starting from the hash of the event before them.
+// It returns ErrAuditChainBroken for the first event that was changed, or that doesn't follow the previous one
+func VerifyAuditEvents(prevHash string, events []AuditEvent) error {
+	for _, event := range events {
+		if event.PrevHash != prevHash {
+			return fmt.Errorf("%w: event %d doesn't follow the previous event", ErrAuditChainBroken, event.ID)
+		}
+		if HashAuditEvent(event) != event.Hash {
+			return fmt.Errorf("%w: event %d was changed", ErrAuditChainBroken, event.ID)
+		}
+		prevHash = event.Hash
+	}
+	return nil
+}
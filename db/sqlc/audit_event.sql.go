@@ -0,0 +1,324 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.18.0
+// source: audit_event.sql
+
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const createAuditEvent = `-- name: CreateAuditEvent :one
+INSERT INTO audit_events (
+  event_type,
+  actor,
+  target_type,
+  target_id,
+  client_ip,
+  user_agent,
+  request_id,
+  before,
+  after,
+  prev_hash,
+  hash,
+  created_at
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
+) RETURNING id, event_type, actor, target_type, target_id, client_ip, user_agent, request_id, before, after, prev_hash, hash, created_at
+`
+
+type CreateAuditEventParams struct {
+	EventType  string    `json:"event_type"`
+	Actor      string    `json:"actor"`
+	TargetType string    `json:"target_type"`
+	TargetID   string    `json:"target_id"`
+	ClientIp   string    `json:"client_ip"`
+	UserAgent  string    `json:"user_agent"`
+	RequestID  string    `json:"request_id"`
+	Before     []byte    `json:"before"`
+	After      []byte    `json:"after"`
+	PrevHash   string    `json:"prev_hash"`
+	Hash       string    `json:"hash"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func (q *Queries) CreateAuditEvent(ctx context.Context, arg CreateAuditEventParams) (AuditEvent, error) {
+	row := q.db.QueryRow(ctx, createAuditEvent,
+		arg.EventType,
+		arg.Actor,
+		arg.TargetType,
+		arg.TargetID,
+		arg.ClientIp,
+		arg.UserAgent,
+		arg.RequestID,
+		arg.Before,
+		arg.After,
+		arg.PrevHash,
+		arg.Hash,
+		arg.CreatedAt,
+	)
+	var i AuditEvent
+	err := row.Scan(
+		&i.ID,
+		&i.EventType,
+		&i.Actor,
+		&i.TargetType,
+		&i.TargetID,
+		&i.ClientIp,
+		&i.UserAgent,
+		&i.RequestID,
+		&i.Before,
+		&i.After,
+		&i.PrevHash,
+		&i.Hash,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createAuditOutboxEvent = `-- name: CreateAuditOutboxEvent :exec
+INSERT INTO audit_outbox (
+  event_type,
+  actor,
+  target_type,
+  target_id,
+  client_ip,
+  user_agent,
+  request_id,
+  before,
+  after
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9
+)
+`
+
+type CreateAuditOutboxEventParams struct {
+	EventType  string `json:"event_type"`
+	Actor      string `json:"actor"`
+	TargetType string `json:"target_type"`
+	TargetID   string `json:"target_id"`
+	ClientIp   string `json:"client_ip"`
+	UserAgent  string `json:"user_agent"`
+	RequestID  string `json:"request_id"`
+	Before     []byte `json:"before"`
+	After      []byte `json:"after"`
+}
+
+func (q *Queries) CreateAuditOutboxEvent(ctx context.Context, arg CreateAuditOutboxEventParams) error {
+	_, err := q.db.Exec(ctx, createAuditOutboxEvent,
+		arg.EventType,
+		arg.Actor,
+		arg.TargetType,
+		arg.TargetID,
+		arg.ClientIp,
+		arg.UserAgent,
+		arg.RequestID,
+		arg.Before,
+		arg.After,
+	)
+	return err
+}
+
+const deleteAuditOutboxEvents = `-- name: DeleteAuditOutboxEvents :exec
+DELETE FROM audit_outbox
+WHERE id = ANY($1::bigint[])
+`
+
+func (q *Queries) DeleteAuditOutboxEvents(ctx context.Context, ids []int64) error {
+	_, err := q.db.Exec(ctx, deleteAuditOutboxEvents, ids)
+	return err
+}
+
+const getLastAuditEvent = `-- name: GetLastAuditEvent :one
+SELECT id, event_type, actor, target_type, target_id, client_ip, user_agent, request_id, before, after, prev_hash, hash, created_at FROM audit_events
+ORDER BY id DESC
+LIMIT 1
+`
+
+func (q *Queries) GetLastAuditEvent(ctx context.Context) (AuditEvent, error) {
+	row := q.db.QueryRow(ctx, getLastAuditEvent)
+	var i AuditEvent
+	err := row.Scan(
+		&i.ID,
+		&i.EventType,
+		&i.Actor,
+		&i.TargetType,
+		&i.TargetID,
+		&i.ClientIp,
+		&i.UserAgent,
+		&i.RequestID,
+		&i.Before,
+		&i.After,
+		&i.PrevHash,
+		&i.Hash,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listAuditEvents = `-- name: ListAuditEvents :many
+SELECT id, event_type, actor, target_type, target_id, client_ip, user_agent, request_id, before, after, prev_hash, hash, created_at FROM audit_events
+WHERE
+  ($1::varchar IS NULL OR actor = $1) AND
+  ($2::varchar IS NULL OR event_type = $2) AND
+  ($3::varchar IS NULL OR target_type = $3) AND
+  ($4::varchar IS NULL OR target_id = $4) AND
+  ($5::timestamptz IS NULL OR created_at >= $5) AND
+  ($6::timestamptz IS NULL OR created_at < $6)
+ORDER BY id DESC
+LIMIT $8
+OFFSET $7
+`
+
+type ListAuditEventsParams struct {
+	Actor      pgtype.Text        `json:"actor"`
+	EventType  pgtype.Text        `json:"event_type"`
+	TargetType pgtype.Text        `json:"target_type"`
+	TargetID   pgtype.Text        `json:"target_id"`
+	StartTime  pgtype.Timestamptz `json:"start_time"`
+	EndTime    pgtype.Timestamptz `json:"end_time"`
+	Offset     int32              `json:"offset"`
+	Limit      int32              `json:"limit"`
+}
+
+func (q *Queries) ListAuditEvents(ctx context.Context, arg ListAuditEventsParams) ([]AuditEvent, error) {
+	rows, err := q.db.Query(ctx, listAuditEvents,
+		arg.Actor,
+		arg.EventType,
+		arg.TargetType,
+		arg.TargetID,
+		arg.StartTime,
+		arg.EndTime,
+		arg.Offset,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []AuditEvent{}
+	for rows.Next() {
+		var i AuditEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.EventType,
+			&i.Actor,
+			&i.TargetType,
+			&i.TargetID,
+			&i.ClientIp,
+			&i.UserAgent,
+			&i.RequestID,
+			&i.Before,
+			&i.After,
+			&i.PrevHash,
+			&i.Hash,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAuditEventsAfter = `-- name: ListAuditEventsAfter :many
+SELECT id, event_type, actor, target_type, target_id, client_ip, user_agent, request_id, before, after, prev_hash, hash, created_at FROM audit_events
+WHERE id > $1
+ORDER BY id
+LIMIT $2
+`
+
+type ListAuditEventsAfterParams struct {
+	ID    int64 `json:"id"`
+	Limit int32 `json:"limit"`
+}
+
+func (q *Queries) ListAuditEventsAfter(ctx context.Context, arg ListAuditEventsAfterParams) ([]AuditEvent, error) {
+	rows, err := q.db.Query(ctx, listAuditEventsAfter, arg.ID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []AuditEvent{}
+	for rows.Next() {
+		var i AuditEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.EventType,
+			&i.Actor,
+			&i.TargetType,
+			&i.TargetID,
+			&i.ClientIp,
+			&i.UserAgent,
+			&i.RequestID,
+			&i.Before,
+			&i.After,
+			&i.PrevHash,
+			&i.Hash,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAuditOutboxEvents = `-- name: ListAuditOutboxEvents :many
+SELECT id, event_type, actor, target_type, target_id, client_ip, user_agent, request_id, before, after, created_at FROM audit_outbox
+ORDER BY id
+LIMIT $1
+`
+
+func (q *Queries) ListAuditOutboxEvents(ctx context.Context, limit int32) ([]AuditOutbox, error) {
+	rows, err := q.db.Query(ctx, listAuditOutboxEvents, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []AuditOutbox{}
+	for rows.Next() {
+		var i AuditOutbox
+		if err := rows.Scan(
+			&i.ID,
+			&i.EventType,
+			&i.Actor,
+			&i.TargetType,
+			&i.TargetID,
+			&i.ClientIp,
+			&i.UserAgent,
+			&i.RequestID,
+			&i.Before,
+			&i.After,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const tryLockAuditEvents = `-- name: TryLockAuditEvents :one
+SELECT pg_try_advisory_xact_lock(hashtext('audit_events'))::bool AS locked
+`
+
+func (q *Queries) TryLockAuditEvents(ctx context.Context) (bool, error) {
+	row := q.db.QueryRow(ctx, tryLockAuditEvents)
+	var locked bool
+	err := row.Scan(&locked)
+	return locked, err
+}
@@ -0,0 +1,234 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/juker1141/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func appendRandomAuditEvents(t *testing.T, n int) []AuditEvent {
+	actor := AuditActor{
+		Name: "user:" + util.RandomOwner(),
+		ClientIP: "127.0.0.1",
+		UserAgent: "test",
+		RequestID: util.RandomString(12),
+	}
+
+	records := make([]AuditRecord, n)
+	for i := range records {
+		records[i] = AuditRecord{
+			EventType: AuditEventPasswordChanged,
+			Actor: actor,
+			TargetType: AuditTargetUser,
+			TargetID: util.RandomOwner(),
+			Before: map[string]string{"email": util.RandomEmail()},
+			After: map[string]string{"email": util.RandomEmail()},
+		}
+	}
+
+	err := testStore.AppendAuditEventsTx(context.Background(), records)
+	require.NoError(t, err)
+
+	// the records are only in the audit log once they are chained
+	listed, err := testStore.ListAuditEvents(context.Background(), ListAuditEventsParams{
+		Actor: pgtype.Text{String: actor.Name, Valid: true},
+		Limit: int32(n),
+	})
+	require.NoError(t, err)
+	require.Empty(t, listed)
+
+	chainAuditEvents(t)
+
+	listed, err = testStore.ListAuditEvents(context.Background(), ListAuditEventsParams{
+		Actor: pgtype.Text{String: actor.Name, Valid: true},
+		Limit: int32(n),
+	})
+	require.NoError(t, err)
+	require.Len(t, listed, n)
+
+	// the newest events are listed first
+	events := make([]AuditEvent, n)
+	for i, event := range listed {
+		events[n-1-i] = event
+	}
+
+	for i, event := range events {
+		require.Equal(t, records[i].EventType, event.EventType)
+		require.Equal(t, actor.Name, event.Actor)
+		require.Equal(t, actor.ClientIP, event.ClientIp)
+		require.Equal(t, actor.RequestID, event.RequestID)
+		require.Equal(t, records[i].TargetID, event.TargetID)
+		require.Equal(t, HashAuditEvent(event), event.Hash)
+		if i > 0 {
+			require.Equal(t, events[i-1].Hash, event.PrevHash)
+		}
+	}
+
+	return events
+}
+
+// chainAuditEvents chains all the records of the audit outbox
+func chainAuditEvents(t *testing.T) {
+	for {
+		chained, err := testStore.ChainAuditEventsTx(context.Background(), 100)
+		require.NoError(t, err)
+		if chained < 100 {
+			return
+		}
+	}
+}
+
+func TestAppendAuditEventsTx(t *testing.T) {
+	first := appendRandomAuditEvents(t, 1)
+	next := appendRandomAuditEvents(t, 2)
+
+	// the events of successive transactions are chained one after the other
+	require.Equal(t, first[0].Hash, next[0].PrevHash)
+	require.NoError(t, VerifyAuditEvents(first[0].PrevHash, append(first, next...)))
+
+	verified, err := testStore.VerifyAuditLog(context.Background())
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, verified, 3)
+}
+
+func TestAuditEventsAreAppendOnly(t *testing.T) {
+	event := appendRandomAuditEvents(t, 1)[0]
+
+	_, err := testStore.(*SQLStore).connPool.Exec(context.Background(),
+		"UPDATE audit_events SET actor = 'system' WHERE id = $1", event.ID)
+	require.ErrorContains(t, err, "append-only")
+
+	_, err = testStore.(*SQLStore).connPool.Exec(context.Background(),
+		"DELETE FROM audit_events WHERE id = $1", event.ID)
+	require.ErrorContains(t, err, "append-only")
+}
+
+func TestVerifyAuditEvents(t *testing.T) {
+	events := appendRandomAuditEvents(t, 3)
+	prevHash := events[0].PrevHash
+	require.NoError(t, VerifyAuditEvents(prevHash, events))
+
+	changed := append([]AuditEvent{}, events...)
+	changed[1].Actor = AuditActorSystem
+	require.ErrorIs(t, VerifyAuditEvents(prevHash, changed), ErrAuditChainBroken)
+
+	deleted := []AuditEvent{events[0], events[2]}
+	require.ErrorIs(t, VerifyAuditEvents(prevHash, deleted), ErrAuditChainBroken)
+}
+
+func TestListAuditEvents(t *testing.T) {
+	events := appendRandomAuditEvents(t, 2)
+
+	listed, err := testStore.ListAuditEvents(context.Background(), ListAuditEventsParams{
+		Actor: pgtype.Text{String: events[0].Actor, Valid: true},
+		EventType: pgtype.Text{String: AuditEventPasswordChanged, Valid: true},
+		Limit: 5,
+		Offset: 0,
+	})
+	require.NoError(t, err)
+	require.Len(t, listed, 2)
+
+	// the newest events come first
+	require.Equal(t, events[1].ID, listed[0].ID)
+	require.Equal(t, events[0].ID, listed[1].ID)
+
+	listed, err = testStore.ListAuditEvents(context.Background(), ListAuditEventsParams{
+		TargetType: pgtype.Text{String: AuditTargetUser, Valid: true},
+		TargetID: pgtype.Text{String: events[0].TargetID, Valid: true},
+		Limit: 5,
+		Offset: 0,
+	})
+	require.NoError(t, err)
+	require.Len(t, listed, 1)
+	require.Equal(t, events[0].ID, listed[0].ID)
+}
+
+func TestTransferTxAuditEvents(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+	actor := AuditActor{Name: "user:" + account1.Owner}
+
+	// the concurrent transfers write their records without waiting for the audit log
+	n := 5
+	errs := make(chan error)
+	for i := 0; i < n; i++ {
+		go func() {
+			_, err := testStore.TransferTx(context.Background(), TransferTxParams{
+				FromAccountID: account1.ID,
+				ToAccountID: account2.ID,
+				Amount: 10,
+				Audit: func(result TransferTxResult) []AuditRecord {
+					return []AuditRecord{
+						{
+							EventType: AuditEventTransfer,
+							Actor: actor,
+							TargetType: AuditTargetTransfer,
+							TargetID: util.RandomString(12),
+						},
+					}
+				},
+			})
+			errs <- err
+		}()
+	}
+	for i := 0; i < n; i++ {
+		require.NoError(t, <-errs)
+	}
+
+	chainAuditEvents(t)
+
+	events, err := testStore.ListAuditEvents(context.Background(), ListAuditEventsParams{
+		Actor: pgtype.Text{String: actor.Name, Valid: true},
+		Limit: int32(n),
+	})
+	require.NoError(t, err)
+	require.Len(t, events, n)
+
+	verified, err := testStore.VerifyAuditLog(context.Background())
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, verified, n)
+}
+
+func TestCreateUserWithRoleTxAuditEvents(t *testing.T) {
+	arg := CreateUserWithRoleTxParams{
+		CreateUserWithRoleParams: CreateUserWithRoleParams{
+			Username: util.RandomOwner(),
+			HashedPassword: util.RandomString(32),
+			FullName: util.RandomOwner(),
+			Email: util.RandomEmail(),
+			Role: util.BankerRole,
+			IsEmailVerified: true,
+		},
+		Audit: func(result CreateUserWithRoleTxResult) []AuditRecord {
+			return []AuditRecord{
+				{
+					EventType: AuditEventAdminAction,
+					Actor: AuditActor{Name: "cli:test"},
+					TargetType: AuditTargetUser,
+					TargetID: result.User.Username,
+				},
+			}
+		},
+	}
+
+	result, err := testStore.CreateUserWithRoleTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, util.BankerRole, result.User.Role)
+
+	// a user that isn't created isn't audited either
+	_, err = testStore.CreateUserWithRoleTx(context.Background(), arg)
+	require.Equal(t, UniqueViolation, ErrorCode(err))
+
+	chainAuditEvents(t)
+
+	events, err := testStore.ListAuditEvents(context.Background(), ListAuditEventsParams{
+		TargetType: pgtype.Text{String: AuditTargetUser, Valid: true},
+		TargetID: pgtype.Text{String: result.User.Username, Valid: true},
+		Limit: 5,
+	})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+}
@@ -0,0 +1,116 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.18.0
+// source: beneficiary.sql
+
+package db
+
+import (
+	"context"
+)
+
+const createBeneficiary = `-- name: CreateBeneficiary :one
+INSERT INTO beneficiaries (
+  owner,
+  nickname,
+  account_id,
+  currency
+) VALUES (
+  $1, $2, $3, $4
+) RETURNING id, owner, nickname, account_id, currency, created_at
+`
+
+type CreateBeneficiaryParams struct {
+	Owner     string `json:"owner"`
+	Nickname  string `json:"nickname"`
+	AccountID int64  `json:"account_id"`
+	Currency  string `json:"currency"`
+}
+
+func (q *Queries) CreateBeneficiary(ctx context.Context, arg CreateBeneficiaryParams) (Beneficiary, error) {
+	row := q.db.QueryRow(ctx, createBeneficiary,
+		arg.Owner,
+		arg.Nickname,
+		arg.AccountID,
+		arg.Currency,
+	)
+	var i Beneficiary
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Nickname,
+		&i.AccountID,
+		&i.Currency,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deleteBeneficiary = `-- name: DeleteBeneficiary :exec
+DELETE FROM beneficiaries WHERE id = $1
+`
+
+func (q *Queries) DeleteBeneficiary(ctx context.Context, id int64) error {
+	_, err := q.db.Exec(ctx, deleteBeneficiary, id)
+	return err
+}
+
+const getBeneficiary = `-- name: GetBeneficiary :one
+SELECT id, owner, nickname, account_id, currency, created_at FROM beneficiaries
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetBeneficiary(ctx context.Context, id int64) (Beneficiary, error) {
+	row := q.db.QueryRow(ctx, getBeneficiary, id)
+	var i Beneficiary
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Nickname,
+		&i.AccountID,
+		&i.Currency,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listBeneficiaries = `-- name: ListBeneficiaries :many
+SELECT id, owner, nickname, account_id, currency, created_at FROM beneficiaries
+WHERE owner = $1
+ORDER BY id
+LIMIT $2
+OFFSET $3
+`
+
+type ListBeneficiariesParams struct {
+	Owner  string `json:"owner"`
+	Limit  int32  `json:"limit"`
+	Offset int32  `json:"offset"`
+}
+
+func (q *Queries) ListBeneficiaries(ctx context.Context, arg ListBeneficiariesParams) ([]Beneficiary, error) {
+	rows, err := q.db.Query(ctx, listBeneficiaries, arg.Owner, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Beneficiary{}
+	for rows.Next() {
+		var i Beneficiary
+		if err := rows.Scan(
+			&i.ID,
+			&i.Owner,
+			&i.Nickname,
+			&i.AccountID,
+			&i.Currency,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
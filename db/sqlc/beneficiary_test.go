@@ -0,0 +1,119 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/juker1141/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomBeneficiary(t *testing.T, owner string) Beneficiary {
+	account := createRandomAccount(t)
+	arg := CreateBeneficiaryParams{
+		Owner: owner,
+		Nickname: util.RandomOwner(),
+		AccountID: account.ID,
+		Currency: account.Currency,
+	}
+
+	beneficiary, err := testStore.CreateBeneficiary(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, beneficiary)
+
+	require.Equal(t, arg.Owner, beneficiary.Owner)
+	require.Equal(t, arg.Nickname, beneficiary.Nickname)
+	require.Equal(t, arg.AccountID, beneficiary.AccountID)
+	require.Equal(t, arg.Currency, beneficiary.Currency)
+
+	require.NotZero(t, beneficiary.ID)
+	require.NotZero(t, beneficiary.CreatedAt)
+
+	return beneficiary
+}
+
+func TestCreateBeneficiary(t *testing.T) {
+	user := createRandomUser(t)
+	createRandomBeneficiary(t, user.Username)
+}
+
+func TestCreateBeneficiaryTx(t *testing.T) {
+	user := createRandomUser(t)
+	account := createRandomAccount(t)
+
+	var created Beneficiary
+	result, err := testStore.CreateBeneficiaryTx(context.Background(), CreateBeneficiaryTxParams{
+		CreateBeneficiaryParams: CreateBeneficiaryParams{
+			Owner: user.Username,
+			Nickname: util.RandomOwner(),
+			AccountID: account.ID,
+			Currency: account.Currency,
+		},
+		Tasks: func(beneficiary Beneficiary) []OutboxTask {
+			created = beneficiary
+			return nil
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, created, result.Beneficiary)
+
+	// the same account cannot be added twice
+	_, err = testStore.CreateBeneficiaryTx(context.Background(), CreateBeneficiaryTxParams{
+		CreateBeneficiaryParams: CreateBeneficiaryParams{
+			Owner: user.Username,
+			Nickname: util.RandomOwner(),
+			AccountID: account.ID,
+			Currency: account.Currency,
+		},
+	})
+	require.Error(t, err)
+	require.Equal(t, UniqueViolation, ErrorCode(err))
+}
+
+func TestGetBeneficiary(t *testing.T) {
+	user := createRandomUser(t)
+	beneficiary1 := createRandomBeneficiary(t, user.Username)
+
+	beneficiary2, err := testStore.GetBeneficiary(context.Background(), beneficiary1.ID)
+	require.NoError(t, err)
+	require.NotEmpty(t, beneficiary2)
+
+	require.Equal(t, beneficiary1.ID, beneficiary2.ID)
+	require.Equal(t, beneficiary1.Owner, beneficiary2.Owner)
+	require.Equal(t, beneficiary1.Nickname, beneficiary2.Nickname)
+	require.Equal(t, beneficiary1.AccountID, beneficiary2.AccountID)
+	require.WithinDuration(t, beneficiary1.CreatedAt, beneficiary2.CreatedAt, time.Second)
+}
+
+func TestListBeneficiaries(t *testing.T) {
+	user := createRandomUser(t)
+	for i := 0; i < 5; i++ {
+		createRandomBeneficiary(t, user.Username)
+	}
+
+	beneficiaries, err := testStore.ListBeneficiaries(context.Background(), ListBeneficiariesParams{
+		Owner: user.Username,
+		Limit: 5,
+		Offset: 0,
+	})
+	require.NoError(t, err)
+	require.Len(t, beneficiaries, 5)
+
+	for _, beneficiary := range beneficiaries {
+		require.Equal(t, user.Username, beneficiary.Owner)
+	}
+}
+
+func TestDeleteBeneficiary(t *testing.T) {
+	user := createRandomUser(t)
+	beneficiary1 := createRandomBeneficiary(t, user.Username)
+
+	err := testStore.DeleteBeneficiary(context.Background(), beneficiary1.ID)
+	require.NoError(t, err)
+
+	beneficiary2, err := testStore.GetBeneficiary(context.Background(), beneficiary1.ID)
+	require.Error(t, err)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+	require.Empty(t, beneficiary2)
+}
@@ -16,6 +16,7 @@ func createRandomEntry(t *testing.T) Entry  {
 	arg := CreateEntryParams{
 		AccountID: account.ID,
 		Amount: util.RandomEntryAmount(),
+		Type: EntryTypeTransfer,
 	}
 
 	entry, err := testStore.CreateEntry(context.Background(), arg)
@@ -24,6 +25,7 @@ func createRandomEntry(t *testing.T) Entry  {
 
 	require.Equal(t, arg.AccountID, entry.AccountID)
 	require.Equal(t, arg.Amount, entry.Amount)
+	require.Equal(t, arg.Type, entry.Type)
 
 	require.NotZero(t, entry.ID)
 	require.NotZero(t, entry.CreatedAt)
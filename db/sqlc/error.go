@@ -10,6 +10,7 @@ import (
 const (
 	ForeignKeyViolation = "23503"
 	UniqueViolation     = "23505"
+	DeadlockDetected    = "40P01"
 )
 
 var ErrRecordNotFound = pgx.ErrNoRows
@@ -3,10 +3,18 @@ package db
 import (
 	"context"
 	"fmt"
+
+	"github.com/juker1141/simplebank/tracing"
 )
 
-// execTx executes a function within a database transaction
-func (store *SQLStore) execTx(ctx context.Context, fn func(*Queries) error) error {
+// execTx executes a function within a database transaction.
+// The transaction is traced as a span named after the name of the transaction
+func (store *SQLStore) execTx(ctx context.Context, name string, fn func(*Queries) error) (err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "db."+name)
+	defer func() {
+		tracing.End(span, err)
+	}()
+
 	tx, err := store.connPool.Begin(ctx)
 	if err != nil {
 		return err
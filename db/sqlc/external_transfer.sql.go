@@ -0,0 +1,199 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.18.0
+// source: external_transfer.sql
+
+package db
+
+import (
+	"context"
+)
+
+const createExternalTransfer = `-- name: CreateExternalTransfer :one
+INSERT INTO external_transfers (
+  type,
+  account_id,
+  settlement_account_id,
+  amount,
+  currency,
+  external_reference,
+  entry_id,
+  settlement_entry_id,
+  created_by
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9
+) RETURNING id, type, account_id, settlement_account_id, amount, currency, external_reference, entry_id, settlement_entry_id, created_by, created_at
+`
+
+type CreateExternalTransferParams struct {
+	Type                string `json:"type"`
+	AccountID           int64  `json:"account_id"`
+	SettlementAccountID int64  `json:"settlement_account_id"`
+	Amount              int64  `json:"amount"`
+	Currency            string `json:"currency"`
+	ExternalReference   string `json:"external_reference"`
+	EntryID             int64  `json:"entry_id"`
+	SettlementEntryID   int64  `json:"settlement_entry_id"`
+	CreatedBy           string `json:"created_by"`
+}
+
+func (q *Queries) CreateExternalTransfer(ctx context.Context, arg CreateExternalTransferParams) (ExternalTransfer, error) {
+	row := q.db.QueryRow(ctx, createExternalTransfer,
+		arg.Type,
+		arg.AccountID,
+		arg.SettlementAccountID,
+		arg.Amount,
+		arg.Currency,
+		arg.ExternalReference,
+		arg.EntryID,
+		arg.SettlementEntryID,
+		arg.CreatedBy,
+	)
+	var i ExternalTransfer
+	err := row.Scan(
+		&i.ID,
+		&i.Type,
+		&i.AccountID,
+		&i.SettlementAccountID,
+		&i.Amount,
+		&i.Currency,
+		&i.ExternalReference,
+		&i.EntryID,
+		&i.SettlementEntryID,
+		&i.CreatedBy,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createSettlementAccount = `-- name: CreateSettlementAccount :one
+INSERT INTO settlement_accounts (
+  currency,
+  account_id
+) VALUES (
+  $1, $2
+) RETURNING currency, account_id, created_at
+`
+
+type CreateSettlementAccountParams struct {
+	Currency  string `json:"currency"`
+	AccountID int64  `json:"account_id"`
+}
+
+func (q *Queries) CreateSettlementAccount(ctx context.Context, arg CreateSettlementAccountParams) (SettlementAccount, error) {
+	row := q.db.QueryRow(ctx, createSettlementAccount, arg.Currency, arg.AccountID)
+	var i SettlementAccount
+	err := row.Scan(&i.Currency, &i.AccountID, &i.CreatedAt)
+	return i, err
+}
+
+const getExternalTransfer = `-- name: GetExternalTransfer :one
+SELECT id, type, account_id, settlement_account_id, amount, currency, external_reference, entry_id, settlement_entry_id, created_by, created_at FROM external_transfers
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetExternalTransfer(ctx context.Context, id int64) (ExternalTransfer, error) {
+	row := q.db.QueryRow(ctx, getExternalTransfer, id)
+	var i ExternalTransfer
+	err := row.Scan(
+		&i.ID,
+		&i.Type,
+		&i.AccountID,
+		&i.SettlementAccountID,
+		&i.Amount,
+		&i.Currency,
+		&i.ExternalReference,
+		&i.EntryID,
+		&i.SettlementEntryID,
+		&i.CreatedBy,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getExternalTransferByReference = `-- name: GetExternalTransferByReference :one
+SELECT id, type, account_id, settlement_account_id, amount, currency, external_reference, entry_id, settlement_entry_id, created_by, created_at FROM external_transfers
+WHERE type = $1 AND external_reference = $2 LIMIT 1
+`
+
+type GetExternalTransferByReferenceParams struct {
+	Type              string `json:"type"`
+	ExternalReference string `json:"external_reference"`
+}
+
+func (q *Queries) GetExternalTransferByReference(ctx context.Context, arg GetExternalTransferByReferenceParams) (ExternalTransfer, error) {
+	row := q.db.QueryRow(ctx, getExternalTransferByReference, arg.Type, arg.ExternalReference)
+	var i ExternalTransfer
+	err := row.Scan(
+		&i.ID,
+		&i.Type,
+		&i.AccountID,
+		&i.SettlementAccountID,
+		&i.Amount,
+		&i.Currency,
+		&i.ExternalReference,
+		&i.EntryID,
+		&i.SettlementEntryID,
+		&i.CreatedBy,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getSettlementAccount = `-- name: GetSettlementAccount :one
+SELECT currency, account_id, created_at FROM settlement_accounts
+WHERE currency = $1 LIMIT 1
+`
+
+func (q *Queries) GetSettlementAccount(ctx context.Context, currency string) (SettlementAccount, error) {
+	row := q.db.QueryRow(ctx, getSettlementAccount, currency)
+	var i SettlementAccount
+	err := row.Scan(&i.Currency, &i.AccountID, &i.CreatedAt)
+	return i, err
+}
+
+const listExternalTransfers = `-- name: ListExternalTransfers :many
+SELECT id, type, account_id, settlement_account_id, amount, currency, external_reference, entry_id, settlement_entry_id, created_by, created_at FROM external_transfers
+WHERE account_id = $1
+ORDER BY id
+LIMIT $2
+OFFSET $3
+`
+
+type ListExternalTransfersParams struct {
+	AccountID int64 `json:"account_id"`
+	Limit     int32 `json:"limit"`
+	Offset    int32 `json:"offset"`
+}
+
+func (q *Queries) ListExternalTransfers(ctx context.Context, arg ListExternalTransfersParams) ([]ExternalTransfer, error) {
+	rows, err := q.db.Query(ctx, listExternalTransfers, arg.AccountID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ExternalTransfer{}
+	for rows.Next() {
+		var i ExternalTransfer
+		if err := rows.Scan(
+			&i.ID,
+			&i.Type,
+			&i.AccountID,
+			&i.SettlementAccountID,
+			&i.Amount,
+			&i.Currency,
+			&i.ExternalReference,
+			&i.EntryID,
+			&i.SettlementEntryID,
+			&i.CreatedBy,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
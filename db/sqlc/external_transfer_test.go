@@ -0,0 +1,108 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/juker1141/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+// settlementAccountFor returns the settlement account of the currency,
+// and creates one if the test database doesn't have it yet
+func settlementAccountFor(t *testing.T, currency string) Account {
+	settlement, err := testStore.GetSettlementAccount(context.Background(), currency)
+	if err == ErrRecordNotFound {
+		user := createRandomUser(t)
+		account, err := testStore.CreateAccount(context.Background(), CreateAccountParams{
+			Owner: user.Username,
+			Balance: 0,
+			Currency: currency,
+		})
+		require.NoError(t, err)
+
+		settlement, err = testStore.CreateSettlementAccount(context.Background(), CreateSettlementAccountParams{
+			Currency: currency,
+			AccountID: account.ID,
+		})
+		require.NoError(t, err)
+	}
+	require.NoError(t, err)
+
+	account, err := testStore.GetAccount(context.Background(), settlement.AccountID)
+	require.NoError(t, err)
+	return account
+}
+
+func TestDepositTx(t *testing.T) {
+	account := createRandomAccount(t)
+	settlement := settlementAccountFor(t, account.Currency)
+	amount := int64(50)
+
+	arg := ExternalTransferTxParams{
+		AccountID: account.ID,
+		Amount: amount,
+		ExternalReference: util.RandomString(16),
+		CreatedBy: "user:" + account.Owner,
+	}
+
+	result, err := testStore.DepositTx(context.Background(), arg)
+	require.NoError(t, err)
+
+	transfer := result.ExternalTransfer
+	require.NotZero(t, transfer.ID)
+	require.Equal(t, ExternalTransferTypeDeposit, transfer.Type)
+	require.Equal(t, account.ID, transfer.AccountID)
+	require.Equal(t, settlement.ID, transfer.SettlementAccountID)
+	require.Equal(t, amount, transfer.Amount)
+	require.Equal(t, account.Currency, transfer.Currency)
+	require.Equal(t, arg.ExternalReference, transfer.ExternalReference)
+	require.Equal(t, arg.CreatedBy, transfer.CreatedBy)
+
+	require.Equal(t, EntryTypeDeposit, result.Entry.Type)
+	require.Equal(t, amount, result.Entry.Amount)
+	require.Equal(t, EntryTypeDeposit, result.SettlementEntry.Type)
+	require.Equal(t, -amount, result.SettlementEntry.Amount)
+
+	require.Equal(t, account.Balance + amount, result.Account.Balance)
+	require.Equal(t, settlement.Balance - amount, result.SettlementAccount.Balance)
+
+	// the same statement line can't be booked twice
+	_, err = testStore.DepositTx(context.Background(), arg)
+	require.Error(t, err)
+	require.Equal(t, UniqueViolation, ErrorCode(err))
+}
+
+func TestWithdrawTx(t *testing.T) {
+	account := createRandomAccount(t)
+	settlement := settlementAccountFor(t, account.Currency)
+
+	arg := ExternalTransferTxParams{
+		AccountID: account.ID,
+		Amount: account.Balance + 1,
+		ExternalReference: util.RandomString(16),
+		CreatedBy: "user:" + account.Owner,
+	}
+
+	_, err := testStore.WithdrawTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrInsufficientBalance)
+
+	// the failed withdrawal must not change any balance
+	updatedAccount, err := testStore.GetAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, account.Balance, updatedAccount.Balance)
+
+	arg.Amount = account.Balance
+	result, err := testStore.WithdrawTx(context.Background(), arg)
+	require.NoError(t, err)
+
+	require.Equal(t, ExternalTransferTypeWithdrawal, result.ExternalTransfer.Type)
+	require.Equal(t, EntryTypeWithdrawal, result.Entry.Type)
+	require.Equal(t, -arg.Amount, result.Entry.Amount)
+	require.Equal(t, arg.Amount, result.SettlementEntry.Amount)
+	require.Zero(t, result.Account.Balance)
+
+	updatedSettlement, err := testStore.GetAccount(context.Background(), settlement.ID)
+	require.NoError(t, err)
+	require.Equal(t, result.SettlementAccount.Balance, updatedSettlement.Balance)
+}
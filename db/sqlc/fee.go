@@ -0,0 +1,112 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+// Types of fee rules
+const (
+	FeeTypeFlat       = "flat"
+	FeeTypePercentage = "percentage"
+)
+
+// FeeQuote is a fee that will be charged for a transfer
+type FeeQuote struct {
+	FeeRuleID        int64  `json:"fee_rule_id"`
+	Name             string `json:"name"`
+	Amount           int64  `json:"amount"`
+	Currency         string `json:"currency"`
+	RevenueAccountID int64  `json:"revenue_account_id"`
+}
+
+// FeeContext describes the transfer that fee rules are evaluated against.
+// Both accounts of a transfer have the same currency
+type FeeContext struct {
+	Amount       int64
+	FromCurrency string
+	// MonthlyTransferCount is the number of outgoing transfers of the month, including this one
+	MonthlyTransferCount int64
+}
+
+// CalculateFee returns the fee charged by the rule, and false if the rule doesn't apply
+func CalculateFee(rule FeeRule, fc FeeContext) (int64, bool) {
+	if !rule.IsActive || rule.Currency != fc.FromCurrency {
+		return 0, false
+	}
+
+	if fc.Amount < rule.MinTransferAmount {
+		return 0, false
+	}
+
+	if fc.MonthlyTransferCount <= rule.FreeTransfersPerMonth {
+		return 0, false
+	}
+
+	var fee int64
+	switch rule.FeeType {
+	case FeeTypeFlat:
+		fee = rule.FlatAmount
+	case FeeTypePercentage:
+		fee = fc.Amount * rule.BasisPoints / 10000
+	}
+
+	return fee, fee > 0
+}
+
+// QuoteTransferFees returns the fees that would be charged for a transfer
+func (store *SQLStore) QuoteTransferFees(ctx context.Context, arg TransferTxParams) ([]FeeQuote, error) {
+	return quoteTransferFees(ctx, store.Queries, arg)
+}
+
+// quoteTransferFees counts the transfers of the month of the sender for the free transfers,
+// the count is only exact within a transfer, which locks the sender's account first
+func quoteTransferFees(ctx context.Context, q *Queries, arg TransferTxParams) ([]FeeQuote, error) {
+	fromAccount, err := q.GetAccount(ctx, arg.FromAccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	quotes := []FeeQuote{}
+
+	rules, err := q.ListActiveFeeRules(ctx, fromAccount.Currency)
+	if err != nil || len(rules) == 0 {
+		return quotes, err
+	}
+
+	count, err := q.CountTransfersFromAccountSince(ctx, CountTransfersFromAccountSinceParams{
+		FromAccountID: fromAccount.ID,
+		Since:         startOfMonth(time.Now()),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	fc := FeeContext{
+		Amount:               arg.Amount,
+		FromCurrency:         fromAccount.Currency,
+		MonthlyTransferCount: count + 1,
+	}
+
+	for _, rule := range rules {
+		fee, ok := CalculateFee(rule, fc)
+		if !ok {
+			continue
+		}
+
+		quotes = append(quotes, FeeQuote{
+			FeeRuleID:        rule.ID,
+			Name:             rule.Name,
+			Amount:           fee,
+			Currency:         rule.Currency,
+			RevenueAccountID: rule.RevenueAccountID,
+		})
+	}
+
+	return quotes, nil
+}
+
+func startOfMonth(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
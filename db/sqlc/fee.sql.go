@@ -0,0 +1,242 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.18.0
+// source: fee.sql
+
+package db
+
+import (
+	"context"
+)
+
+const createFeeRule = `-- name: CreateFeeRule :one
+INSERT INTO fee_rules (
+  name,
+  fee_type,
+  currency,
+  flat_amount,
+  basis_points,
+  min_transfer_amount,
+  free_transfers_per_month,
+  revenue_account_id
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8
+) RETURNING id, name, fee_type, currency, flat_amount, basis_points, min_transfer_amount, free_transfers_per_month, revenue_account_id, is_active, created_at
+`
+
+type CreateFeeRuleParams struct {
+	Name                  string `json:"name"`
+	FeeType               string `json:"fee_type"`
+	Currency              string `json:"currency"`
+	FlatAmount            int64  `json:"flat_amount"`
+	BasisPoints           int64  `json:"basis_points"`
+	MinTransferAmount     int64  `json:"min_transfer_amount"`
+	FreeTransfersPerMonth int64  `json:"free_transfers_per_month"`
+	RevenueAccountID      int64  `json:"revenue_account_id"`
+}
+
+func (q *Queries) CreateFeeRule(ctx context.Context, arg CreateFeeRuleParams) (FeeRule, error) {
+	row := q.db.QueryRow(ctx, createFeeRule,
+		arg.Name,
+		arg.FeeType,
+		arg.Currency,
+		arg.FlatAmount,
+		arg.BasisPoints,
+		arg.MinTransferAmount,
+		arg.FreeTransfersPerMonth,
+		arg.RevenueAccountID,
+	)
+	var i FeeRule
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.FeeType,
+		&i.Currency,
+		&i.FlatAmount,
+		&i.BasisPoints,
+		&i.MinTransferAmount,
+		&i.FreeTransfersPerMonth,
+		&i.RevenueAccountID,
+		&i.IsActive,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createTransferFee = `-- name: CreateTransferFee :one
+INSERT INTO transfer_fees (
+  transfer_id,
+  fee_rule_id,
+  account_id,
+  revenue_account_id,
+  amount,
+  from_entry_id,
+  to_entry_id
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7
+) RETURNING id, transfer_id, fee_rule_id, account_id, revenue_account_id, amount, from_entry_id, to_entry_id, created_at
+`
+
+type CreateTransferFeeParams struct {
+	TransferID       int64 `json:"transfer_id"`
+	FeeRuleID        int64 `json:"fee_rule_id"`
+	AccountID        int64 `json:"account_id"`
+	RevenueAccountID int64 `json:"revenue_account_id"`
+	Amount           int64 `json:"amount"`
+	FromEntryID      int64 `json:"from_entry_id"`
+	ToEntryID        int64 `json:"to_entry_id"`
+}
+
+func (q *Queries) CreateTransferFee(ctx context.Context, arg CreateTransferFeeParams) (TransferFee, error) {
+	row := q.db.QueryRow(ctx, createTransferFee,
+		arg.TransferID,
+		arg.FeeRuleID,
+		arg.AccountID,
+		arg.RevenueAccountID,
+		arg.Amount,
+		arg.FromEntryID,
+		arg.ToEntryID,
+	)
+	var i TransferFee
+	err := row.Scan(
+		&i.ID,
+		&i.TransferID,
+		&i.FeeRuleID,
+		&i.AccountID,
+		&i.RevenueAccountID,
+		&i.Amount,
+		&i.FromEntryID,
+		&i.ToEntryID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getFeeRule = `-- name: GetFeeRule :one
+SELECT id, name, fee_type, currency, flat_amount, basis_points, min_transfer_amount, free_transfers_per_month, revenue_account_id, is_active, created_at FROM fee_rules
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetFeeRule(ctx context.Context, id int64) (FeeRule, error) {
+	row := q.db.QueryRow(ctx, getFeeRule, id)
+	var i FeeRule
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.FeeType,
+		&i.Currency,
+		&i.FlatAmount,
+		&i.BasisPoints,
+		&i.MinTransferAmount,
+		&i.FreeTransfersPerMonth,
+		&i.RevenueAccountID,
+		&i.IsActive,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listActiveFeeRules = `-- name: ListActiveFeeRules :many
+SELECT id, name, fee_type, currency, flat_amount, basis_points, min_transfer_amount, free_transfers_per_month, revenue_account_id, is_active, created_at FROM fee_rules
+WHERE currency = $1 AND is_active = TRUE
+ORDER BY id
+`
+
+func (q *Queries) ListActiveFeeRules(ctx context.Context, currency string) ([]FeeRule, error) {
+	rows, err := q.db.Query(ctx, listActiveFeeRules, currency)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []FeeRule{}
+	for rows.Next() {
+		var i FeeRule
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.FeeType,
+			&i.Currency,
+			&i.FlatAmount,
+			&i.BasisPoints,
+			&i.MinTransferAmount,
+			&i.FreeTransfersPerMonth,
+			&i.RevenueAccountID,
+			&i.IsActive,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTransferFees = `-- name: ListTransferFees :many
+SELECT id, transfer_id, fee_rule_id, account_id, revenue_account_id, amount, from_entry_id, to_entry_id, created_at FROM transfer_fees
+WHERE transfer_id = $1
+ORDER BY id
+`
+
+func (q *Queries) ListTransferFees(ctx context.Context, transferID int64) ([]TransferFee, error) {
+	rows, err := q.db.Query(ctx, listTransferFees, transferID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []TransferFee{}
+	for rows.Next() {
+		var i TransferFee
+		if err := rows.Scan(
+			&i.ID,
+			&i.TransferID,
+			&i.FeeRuleID,
+			&i.AccountID,
+			&i.RevenueAccountID,
+			&i.Amount,
+			&i.FromEntryID,
+			&i.ToEntryID,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateFeeRuleActive = `-- name: UpdateFeeRuleActive :one
+UPDATE fee_rules
+SET is_active = $2
+WHERE id = $1
+RETURNING id, name, fee_type, currency, flat_amount, basis_points, min_transfer_amount, free_transfers_per_month, revenue_account_id, is_active, created_at
+`
+
+type UpdateFeeRuleActiveParams struct {
+	ID       int64 `json:"id"`
+	IsActive bool  `json:"is_active"`
+}
+
+func (q *Queries) UpdateFeeRuleActive(ctx context.Context, arg UpdateFeeRuleActiveParams) (FeeRule, error) {
+	row := q.db.QueryRow(ctx, updateFeeRuleActive, arg.ID, arg.IsActive)
+	var i FeeRule
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.FeeType,
+		&i.Currency,
+		&i.FlatAmount,
+		&i.BasisPoints,
+		&i.MinTransferAmount,
+		&i.FreeTransfersPerMonth,
+		&i.RevenueAccountID,
+		&i.IsActive,
+		&i.CreatedAt,
+	)
+	return i, err
+}
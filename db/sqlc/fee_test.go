@@ -0,0 +1,197 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/juker1141/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomFeeRule(t *testing.T, arg CreateFeeRuleParams) FeeRule {
+	rule, err := testStore.CreateFeeRule(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, rule)
+
+	require.Equal(t, arg.Name, rule.Name)
+	require.Equal(t, arg.FeeType, rule.FeeType)
+	require.Equal(t, arg.Currency, rule.Currency)
+	require.Equal(t, arg.RevenueAccountID, rule.RevenueAccountID)
+	require.True(t, rule.IsActive)
+	require.NotZero(t, rule.ID)
+
+	// deactivate the rule so that it won't charge the other tests' transfers
+	t.Cleanup(func() {
+		_, err := testStore.UpdateFeeRuleActive(context.Background(), UpdateFeeRuleActiveParams{
+			ID:       rule.ID,
+			IsActive: false,
+		})
+		require.NoError(t, err)
+	})
+
+	return rule
+}
+
+func TestCalculateFee(t *testing.T) {
+	flat := FeeRule{
+		FeeType:               FeeTypeFlat,
+		Currency:              util.USD,
+		FlatAmount:            5,
+		FreeTransfersPerMonth: 3,
+		IsActive:              true,
+	}
+	percentage := FeeRule{
+		FeeType:     FeeTypePercentage,
+		Currency:    util.USD,
+		BasisPoints: 150,
+		IsActive:    true,
+	}
+
+	testCases := []struct {
+		name    string
+		rule    FeeRule
+		fc      FeeContext
+		fee     int64
+		applies bool
+	}{
+		{
+			name:    "FlatWithinFreeTransfers",
+			rule:    flat,
+			fc:      FeeContext{Amount: 100, FromCurrency: util.USD, MonthlyTransferCount: 3},
+			applies: false,
+		},
+		{
+			name:    "FlatAboveFreeTransfers",
+			rule:    flat,
+			fc:      FeeContext{Amount: 100, FromCurrency: util.USD, MonthlyTransferCount: 4},
+			fee:     5,
+			applies: true,
+		},
+		{
+			name:    "OtherCurrency",
+			rule:    flat,
+			fc:      FeeContext{Amount: 100, FromCurrency: util.EUR, MonthlyTransferCount: 4},
+			applies: false,
+		},
+		{
+			name:    "Percentage",
+			rule:    percentage,
+			fc:      FeeContext{Amount: 1000, FromCurrency: util.USD, MonthlyTransferCount: 1},
+			fee:     15,
+			applies: true,
+		},
+		{
+			name:    "PercentageRoundedToZero",
+			rule:    percentage,
+			fc:      FeeContext{Amount: 10, FromCurrency: util.USD, MonthlyTransferCount: 1},
+			applies: false,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			fee, applies := CalculateFee(tc.rule, tc.fc)
+			require.Equal(t, tc.applies, applies)
+			require.Equal(t, tc.fee, fee)
+		})
+	}
+}
+
+func TestTransferTxWithFees(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+	revenueAccount := createRandomAccount(t)
+
+	rule := createRandomFeeRule(t, CreateFeeRuleParams{
+		Name:             util.RandomString(8),
+		FeeType:          FeeTypeFlat,
+		Currency:         account1.Currency,
+		FlatAmount:       3,
+		RevenueAccountID: revenueAccount.ID,
+	})
+
+	amount := int64(10)
+	arg := TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        amount,
+	}
+
+	quotes, err := testStore.QuoteTransferFees(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, quotes, 1)
+	require.Equal(t, rule.ID, quotes[0].FeeRuleID)
+	require.Equal(t, rule.FlatAmount, quotes[0].Amount)
+
+	result, err := testStore.TransferTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, result.Fees, 1)
+
+	fee := result.Fees[0]
+	require.Equal(t, result.Transfer.ID, fee.TransferID)
+	require.Equal(t, rule.ID, fee.FeeRuleID)
+	require.Equal(t, account1.ID, fee.AccountID)
+	require.Equal(t, revenueAccount.ID, fee.RevenueAccountID)
+	require.Equal(t, rule.FlatAmount, fee.Amount)
+
+	fromEntry, err := testStore.GetEntry(context.Background(), fee.FromEntryID)
+	require.NoError(t, err)
+	require.Equal(t, account1.ID, fromEntry.AccountID)
+	require.Equal(t, -rule.FlatAmount, fromEntry.Amount)
+
+	toEntry, err := testStore.GetEntry(context.Background(), fee.ToEntryID)
+	require.NoError(t, err)
+	require.Equal(t, revenueAccount.ID, toEntry.AccountID)
+	require.Equal(t, rule.FlatAmount, toEntry.Amount)
+
+	require.Equal(t, account1.Balance-amount-rule.FlatAmount, result.FromAccount.Balance)
+	require.Equal(t, account2.Balance+amount, result.ToAccount.Balance)
+
+	updatedRevenueAccount, err := testStore.GetAccount(context.Background(), revenueAccount.ID)
+	require.NoError(t, err)
+	require.Equal(t, revenueAccount.Balance+rule.FlatAmount, updatedRevenueAccount.Balance)
+
+	fees, err := testStore.ListTransferFees(context.Background(), result.Transfer.ID)
+	require.NoError(t, err)
+	require.Len(t, fees, 1)
+	require.Equal(t, fee.ID, fees[0].ID)
+}
+
+func TestTransferTxFreeTransfersConcurrent(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+	revenueAccount := createRandomAccount(t)
+
+	rule := createRandomFeeRule(t, CreateFeeRuleParams{
+		Name:                  util.RandomString(8),
+		FeeType:               FeeTypeFlat,
+		Currency:              account1.Currency,
+		FlatAmount:            3,
+		FreeTransfersPerMonth: 1,
+		RevenueAccountID:      revenueAccount.ID,
+	})
+
+	// the concurrent transfers count each other, so only the first one is free
+	n := 5
+	errs := make(chan error)
+	for i := 0; i < n; i++ {
+		go func() {
+			_, err := testStore.TransferTx(context.Background(), TransferTxParams{
+				FromAccountID: account1.ID,
+				ToAccountID:   account2.ID,
+				Amount:        10,
+			})
+			errs <- err
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		require.NoError(t, <-errs)
+	}
+
+	updatedRevenueAccount, err := testStore.GetAccount(context.Background(), revenueAccount.ID)
+	require.NoError(t, err)
+	require.Equal(t, revenueAccount.Balance+int64(n-1)*rule.FlatAmount, updatedRevenueAccount.Balance)
+}
@@ -0,0 +1,41 @@
+package db
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// the metrics package depends on db, so its namespace is repeated here
+const metricsNamespace = "simplebank"
+
+var (
+	transferTxDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "db",
+		Name: "transfer_tx_duration_seconds",
+		Help: "Duration of the transfer transactions, by outcome.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"outcome"})
+
+	transferTxDeadlocks = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "db",
+		Name: "transfer_tx_deadlocks_total",
+		Help: "Number of transfer transactions aborted by a deadlock.",
+	})
+)
+
+func observeTransferTx(duration time.Duration, err error) {
+	outcome := "committed"
+	if err != nil {
+		outcome = "failed"
+	}
+	transferTxDuration.WithLabelValues(outcome).Observe(duration.Seconds())
+
+	// the accounts are locked in the order of their IDs, so a deadlock points at a query that breaks that order
+	if ErrorCode(err) == DeadlockDetected {
+		transferTxDeadlocks.Inc()
+	}
+}
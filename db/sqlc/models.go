@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type Account struct {
@@ -18,12 +19,179 @@ type Account struct {
 	CreatedAt time.Time `json:"created_at"`
 }
 
+type AccountMember struct {
+	AccountID  int64     `json:"account_id"`
+	Username   string    `json:"username"`
+	Role       string    `json:"role"`
+	InvitedBy  string    `json:"invited_by"`
+	IsAccepted bool      `json:"is_accepted"`
+	AcceptedAt time.Time `json:"accepted_at"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type ApiKey struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	HashedKey string    `json:"hashed_key"`
+	IsRevoked bool      `json:"is_revoked"`
+	CreatedAt time.Time `json:"created_at"`
+	// webhooks: the endpoints of the key receive the events of every user
+	Scopes []string `json:"scopes"`
+}
+
+type AuditEvent struct {
+	ID        int64  `json:"id"`
+	EventType string `json:"event_type"`
+	// user:<username>, api_key:<name>, anonymous or system
+	Actor      string `json:"actor"`
+	TargetType string `json:"target_type"`
+	TargetID   string `json:"target_id"`
+	ClientIp   string `json:"client_ip"`
+	UserAgent  string `json:"user_agent"`
+	RequestID  string `json:"request_id"`
+	// snapshot of the target before the change
+	Before []byte `json:"before"`
+	// snapshot of the target after the change
+	After []byte `json:"after"`
+	// hash of the previous event, empty for the first event
+	PrevHash string `json:"prev_hash"`
+	// sha256 of the event and the hash of the previous event
+	Hash      string    `json:"hash"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type AuditOutbox struct {
+	ID         int64  `json:"id"`
+	EventType  string `json:"event_type"`
+	Actor      string `json:"actor"`
+	TargetType string `json:"target_type"`
+	TargetID   string `json:"target_id"`
+	ClientIp   string `json:"client_ip"`
+	UserAgent  string `json:"user_agent"`
+	RequestID  string `json:"request_id"`
+	Before     []byte `json:"before"`
+	After      []byte `json:"after"`
+	// time of the change, kept by the audit event once the record is chained
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Beneficiary struct {
+	ID        int64     `json:"id"`
+	Owner     string    `json:"owner"`
+	Nickname  string    `json:"nickname"`
+	AccountID int64     `json:"account_id"`
+	Currency  string    `json:"currency"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
 type Entry struct {
 	ID        int64 `json:"id"`
 	AccountID int64 `json:"account_id"`
 	// can be negative or positive
 	Amount    int64     `json:"amount"`
 	CreatedAt time.Time `json:"created_at"`
+	// transfer, fee, deposit or withdrawal
+	Type string `json:"type"`
+}
+
+type ExternalTransfer struct {
+	ID int64 `json:"id"`
+	// deposit or withdrawal
+	Type                string `json:"type"`
+	AccountID           int64  `json:"account_id"`
+	SettlementAccountID int64  `json:"settlement_account_id"`
+	// must be positive
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+	// reference on the bank statement
+	ExternalReference string    `json:"external_reference"`
+	EntryID           int64     `json:"entry_id"`
+	SettlementEntryID int64     `json:"settlement_entry_id"`
+	CreatedBy         string    `json:"created_by"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+type FeeRule struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+	// flat or percentage
+	FeeType    string `json:"fee_type"`
+	Currency   string `json:"currency"`
+	FlatAmount int64  `json:"flat_amount"`
+	// 1 basis point is 0.01%
+	BasisPoints       int64 `json:"basis_points"`
+	MinTransferAmount int64 `json:"min_transfer_amount"`
+	// the rule only applies after this many outgoing transfers in the month
+	FreeTransfersPerMonth int64     `json:"free_transfers_per_month"`
+	RevenueAccountID      int64     `json:"revenue_account_id"`
+	IsActive              bool      `json:"is_active"`
+	CreatedAt             time.Time `json:"created_at"`
+}
+
+type NotificationPreference struct {
+	Username string `json:"username"`
+	// debit or credit
+	EventType string `json:"event_type"`
+	// email
+	Channel   string `json:"channel"`
+	IsEnabled bool   `json:"is_enabled"`
+	// no notification is sent for smaller amounts
+	MinAmount int64     `json:"min_amount"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type OutboxEvent struct {
+	ID        int64     `json:"id"`
+	TaskType  string    `json:"task_type"`
+	Payload   []byte    `json:"payload"`
+	Queue     string    `json:"queue"`
+	MaxRetry  int32     `json:"max_retry"`
+	ProcessAt time.Time `json:"process_at"`
+	// number of failed attempts to publish the task
+	Attempts    int32     `json:"attempts"`
+	LastError   string    `json:"last_error"`
+	IsPublished bool      `json:"is_published"`
+	PublishedAt time.Time `json:"published_at"`
+	CreatedAt   time.Time `json:"created_at"`
+	// the event is being published by a relay until then, or retried after a failure
+	ClaimedUntil time.Time `json:"claimed_until"`
+	// the event failed too many times and is only published again when it is replayed
+	IsDeadLettered bool `json:"is_dead_lettered"`
+}
+
+type PaymentRequest struct {
+	ID          int64  `json:"id"`
+	Payee       string `json:"payee"`
+	Payer       string `json:"payer"`
+	ToAccountID int64  `json:"to_account_id"`
+	// must be positive
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+	Memo     string `json:"memo"`
+	// pending, accepted, declined or expired
+	Status        string      `json:"status"`
+	FromAccountID pgtype.Int8 `json:"from_account_id"`
+	TransferID    pgtype.Int8 `json:"transfer_id"`
+	ExpiredAt     time.Time   `json:"expired_at"`
+	RespondedAt   time.Time   `json:"responded_at"`
+	CreatedAt     time.Time   `json:"created_at"`
+}
+
+type ScheduledJob struct {
+	Name string `json:"name"`
+	// cron expression
+	Schedule  string    `json:"schedule"`
+	NextRunAt time.Time `json:"next_run_at"`
+	// worker running the job, empty when the job is idle
+	LockedBy       string    `json:"locked_by"`
+	LockedUntil    time.Time `json:"locked_until"`
+	LastStartedAt  time.Time `json:"last_started_at"`
+	LastFinishedAt time.Time `json:"last_finished_at"`
+	// succeeded or failed, empty if the job never ran
+	LastStatus     string `json:"last_status"`
+	LastResult     string `json:"last_result"`
+	LastError      string `json:"last_error"`
+	LastDurationMs int64  `json:"last_duration_ms"`
 }
 
 type Session struct {
@@ -37,6 +205,12 @@ type Session struct {
 	CreatedAt    time.Time `json:"created_at"`
 }
 
+type SettlementAccount struct {
+	Currency  string    `json:"currency"`
+	AccountID int64     `json:"account_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
 type Transfer struct {
 	ID            int64 `json:"id"`
 	FromAccountID int64 `json:"from_account_id"`
@@ -46,6 +220,18 @@ type Transfer struct {
 	CreatedAt time.Time `json:"created_at"`
 }
 
+type TransferFee struct {
+	ID               int64     `json:"id"`
+	TransferID       int64     `json:"transfer_id"`
+	FeeRuleID        int64     `json:"fee_rule_id"`
+	AccountID        int64     `json:"account_id"`
+	RevenueAccountID int64     `json:"revenue_account_id"`
+	Amount           int64     `json:"amount"`
+	FromEntryID      int64     `json:"from_entry_id"`
+	ToEntryID        int64     `json:"to_entry_id"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
 type User struct {
 	Username          string    `json:"username"`
 	HashedPassword    string    `json:"hashed_password"`
@@ -54,6 +240,12 @@ type User struct {
 	PasswordChangedAt time.Time `json:"password_changed_at"`
 	CreatedAt         time.Time `json:"created_at"`
 	IsEmailVerified   bool      `json:"is_email_verified"`
+	Role              string    `json:"role"`
+	Language          string    `json:"language"`
+	// new email address that is not verified yet
+	PendingEmail string `json:"pending_email"`
+	// locked users cannot log in and their sessions are revoked
+	IsLocked bool `json:"is_locked"`
 }
 
 type VerifyEmail struct {
@@ -65,3 +257,41 @@ type VerifyEmail struct {
 	CreatedAt  time.Time `json:"created_at"`
 	ExpiredAt  time.Time `json:"expired_at"`
 }
+
+type WebhookDelivery struct {
+	ID         int64  `json:"id"`
+	EndpointID int64  `json:"endpoint_id"`
+	EventID    string `json:"event_id"`
+	EventType  string `json:"event_type"`
+	Payload    []byte `json:"payload"`
+	// pending, succeeded or failed
+	Status      string    `json:"status"`
+	Attempts    int32     `json:"attempts"`
+	DeliveredAt time.Time `json:"delivered_at"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type WebhookDeliveryAttempt struct {
+	ID         int64 `json:"id"`
+	DeliveryID int64 `json:"delivery_id"`
+	// 0 if no response was received
+	ResponseStatus int32     `json:"response_status"`
+	Error          string    `json:"error"`
+	DurationMs     int64     `json:"duration_ms"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type WebhookEndpoint struct {
+	ID int64 `json:"id"`
+	// the user whose events are received, null for the endpoints of an API key
+	Owner pgtype.Text `json:"owner"`
+	Url   string      `json:"url"`
+	// signs the payloads with HMAC-SHA256
+	Secret    string    `json:"secret"`
+	Events    []string  `json:"events"`
+	IsActive  bool      `json:"is_active"`
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+	// the API key that manages the endpoint, null for the endpoints of a user
+	ApiKeyID pgtype.Int8 `json:"api_key_id"`
+}
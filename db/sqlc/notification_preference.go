@@ -0,0 +1,36 @@
+package db
+
+import (
+	"context"
+	"errors"
+)
+
+// DefaultNotificationPreference is used for the events and channels the user hasn't set a preference for.
+// Users are notified of every transfer by default
+func DefaultNotificationPreference(username string, eventType string, channel string) NotificationPreference {
+	return NotificationPreference{
+		Username: username,
+		EventType: eventType,
+		Channel: channel,
+		IsEnabled: true,
+		MinAmount: 0,
+	}
+}
+
+// Allows returns true if a notification should be sent for the amount
+func (preference NotificationPreference) Allows(amount int64) bool {
+	return preference.IsEnabled && amount >= preference.MinAmount
+}
+
+// GetEffectiveNotificationPreference returns the preference of the user, or the default one if it isn't set
+func GetEffectiveNotificationPreference(ctx context.Context, q Querier, username string, eventType string, channel string) (NotificationPreference, error) {
+	preference, err := q.GetNotificationPreference(ctx, GetNotificationPreferenceParams{
+		Username: username,
+		EventType: eventType,
+		Channel: channel,
+	})
+	if errors.Is(err, ErrRecordNotFound) {
+		return DefaultNotificationPreference(username, eventType, channel), nil
+	}
+	return preference, err
+}
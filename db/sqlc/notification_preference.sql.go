@@ -0,0 +1,114 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.18.0
+// source: notification_preference.sql
+
+package db
+
+import (
+	"context"
+)
+
+const getNotificationPreference = `-- name: GetNotificationPreference :one
+SELECT username, event_type, channel, is_enabled, min_amount, updated_at FROM notification_preferences
+WHERE username = $1 AND event_type = $2 AND channel = $3
+LIMIT 1
+`
+
+type GetNotificationPreferenceParams struct {
+	Username  string `json:"username"`
+	EventType string `json:"event_type"`
+	Channel   string `json:"channel"`
+}
+
+func (q *Queries) GetNotificationPreference(ctx context.Context, arg GetNotificationPreferenceParams) (NotificationPreference, error) {
+	row := q.db.QueryRow(ctx, getNotificationPreference, arg.Username, arg.EventType, arg.Channel)
+	var i NotificationPreference
+	err := row.Scan(
+		&i.Username,
+		&i.EventType,
+		&i.Channel,
+		&i.IsEnabled,
+		&i.MinAmount,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const listNotificationPreferences = `-- name: ListNotificationPreferences :many
+SELECT username, event_type, channel, is_enabled, min_amount, updated_at FROM notification_preferences
+WHERE username = $1
+ORDER BY event_type, channel
+`
+
+func (q *Queries) ListNotificationPreferences(ctx context.Context, username string) ([]NotificationPreference, error) {
+	rows, err := q.db.Query(ctx, listNotificationPreferences, username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []NotificationPreference{}
+	for rows.Next() {
+		var i NotificationPreference
+		if err := rows.Scan(
+			&i.Username,
+			&i.EventType,
+			&i.Channel,
+			&i.IsEnabled,
+			&i.MinAmount,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const upsertNotificationPreference = `-- name: UpsertNotificationPreference :one
+INSERT INTO notification_preferences (
+  username,
+  event_type,
+  channel,
+  is_enabled,
+  min_amount
+) VALUES (
+  $1, $2, $3, $4, $5
+) ON CONFLICT (username, event_type, channel) DO UPDATE
+SET
+  is_enabled = EXCLUDED.is_enabled,
+  min_amount = EXCLUDED.min_amount,
+  updated_at = now()
+RETURNING username, event_type, channel, is_enabled, min_amount, updated_at
+`
+
+type UpsertNotificationPreferenceParams struct {
+	Username  string `json:"username"`
+	EventType string `json:"event_type"`
+	Channel   string `json:"channel"`
+	IsEnabled bool   `json:"is_enabled"`
+	MinAmount int64  `json:"min_amount"`
+}
+
+func (q *Queries) UpsertNotificationPreference(ctx context.Context, arg UpsertNotificationPreferenceParams) (NotificationPreference, error) {
+	row := q.db.QueryRow(ctx, upsertNotificationPreference,
+		arg.Username,
+		arg.EventType,
+		arg.Channel,
+		arg.IsEnabled,
+		arg.MinAmount,
+	)
+	var i NotificationPreference
+	err := row.Scan(
+		&i.Username,
+		&i.EventType,
+		&i.Channel,
+		&i.IsEnabled,
+		&i.MinAmount,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,60 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/juker1141/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpsertNotificationPreference(t *testing.T) {
+	user := createRandomUser(t)
+
+	arg := UpsertNotificationPreferenceParams{
+		Username: user.Username,
+		EventType: util.NotificationEventCredit,
+		Channel: util.NotificationChannelEmail,
+		IsEnabled: true,
+		MinAmount: 100,
+	}
+
+	preference1, err := testStore.UpsertNotificationPreference(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.MinAmount, preference1.MinAmount)
+	require.True(t, preference1.IsEnabled)
+
+	arg.IsEnabled = false
+	preference2, err := testStore.UpsertNotificationPreference(context.Background(), arg)
+	require.NoError(t, err)
+	require.False(t, preference2.IsEnabled)
+	require.False(t, preference2.UpdatedAt.Before(preference1.UpdatedAt))
+
+	preferences, err := testStore.ListNotificationPreferences(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Len(t, preferences, 1)
+	require.Equal(t, preference2, preferences[0])
+}
+
+func TestGetEffectiveNotificationPreference(t *testing.T) {
+	user := createRandomUser(t)
+
+	// users are notified of every transfer until they change their preference
+	preference, err := GetEffectiveNotificationPreference(context.Background(), testStore, user.Username, util.NotificationEventDebit, util.NotificationChannelEmail)
+	require.NoError(t, err)
+	require.True(t, preference.Allows(1))
+
+	_, err = testStore.UpsertNotificationPreference(context.Background(), UpsertNotificationPreferenceParams{
+		Username: user.Username,
+		EventType: util.NotificationEventDebit,
+		Channel: util.NotificationChannelEmail,
+		IsEnabled: true,
+		MinAmount: 50,
+	})
+	require.NoError(t, err)
+
+	preference, err = GetEffectiveNotificationPreference(context.Background(), testStore, user.Username, util.NotificationEventDebit, util.NotificationChannelEmail)
+	require.NoError(t, err)
+	require.False(t, preference.Allows(49))
+	require.True(t, preference.Allows(50))
+}
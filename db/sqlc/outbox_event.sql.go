@@ -0,0 +1,283 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.18.0
+// source: outbox_event.sql
+
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const claimOutboxEvents = `-- name: ClaimOutboxEvents :many
+UPDATE outbox_events
+SET claimed_until = $1
+WHERE id IN (
+  SELECT id FROM outbox_events
+  WHERE is_published = false AND is_dead_lettered = false AND claimed_until < now()
+  ORDER BY id
+  LIMIT $2
+  FOR NO KEY UPDATE SKIP LOCKED
+)
+RETURNING id, task_type, payload, queue, max_retry, process_at, attempts, last_error, is_published, published_at, created_at, claimed_until, is_dead_lettered
+`
+
+type ClaimOutboxEventsParams struct {
+	ClaimedUntil time.Time `json:"claimed_until"`
+	BatchSize    int32     `json:"batch_size"`
+}
+
+func (q *Queries) ClaimOutboxEvents(ctx context.Context, arg ClaimOutboxEventsParams) ([]OutboxEvent, error) {
+	rows, err := q.db.Query(ctx, claimOutboxEvents, arg.ClaimedUntil, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []OutboxEvent{}
+	for rows.Next() {
+		var i OutboxEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.TaskType,
+			&i.Payload,
+			&i.Queue,
+			&i.MaxRetry,
+			&i.ProcessAt,
+			&i.Attempts,
+			&i.LastError,
+			&i.IsPublished,
+			&i.PublishedAt,
+			&i.CreatedAt,
+			&i.ClaimedUntil,
+			&i.IsDeadLettered,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const createOutboxEvent = `-- name: CreateOutboxEvent :one
+INSERT INTO outbox_events (
+  task_type,
+  payload,
+  queue,
+  max_retry,
+  process_at
+) VALUES (
+  $1, $2, $3, $4, $5
+) RETURNING id, task_type, payload, queue, max_retry, process_at, attempts, last_error, is_published, published_at, created_at, claimed_until, is_dead_lettered
+`
+
+type CreateOutboxEventParams struct {
+	TaskType  string    `json:"task_type"`
+	Payload   []byte    `json:"payload"`
+	Queue     string    `json:"queue"`
+	MaxRetry  int32     `json:"max_retry"`
+	ProcessAt time.Time `json:"process_at"`
+}
+
+func (q *Queries) CreateOutboxEvent(ctx context.Context, arg CreateOutboxEventParams) (OutboxEvent, error) {
+	row := q.db.QueryRow(ctx, createOutboxEvent,
+		arg.TaskType,
+		arg.Payload,
+		arg.Queue,
+		arg.MaxRetry,
+		arg.ProcessAt,
+	)
+	var i OutboxEvent
+	err := row.Scan(
+		&i.ID,
+		&i.TaskType,
+		&i.Payload,
+		&i.Queue,
+		&i.MaxRetry,
+		&i.ProcessAt,
+		&i.Attempts,
+		&i.LastError,
+		&i.IsPublished,
+		&i.PublishedAt,
+		&i.CreatedAt,
+		&i.ClaimedUntil,
+		&i.IsDeadLettered,
+	)
+	return i, err
+}
+
+const deletePublishedOutboxEvents = `-- name: DeletePublishedOutboxEvents :execrows
+DELETE FROM outbox_events
+WHERE is_published = true AND published_at < $1
+`
+
+func (q *Queries) DeletePublishedOutboxEvents(ctx context.Context, publishedBefore time.Time) (int64, error) {
+	result, err := q.db.Exec(ctx, deletePublishedOutboxEvents, publishedBefore)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const getOutboxEvent = `-- name: GetOutboxEvent :one
+SELECT id, task_type, payload, queue, max_retry, process_at, attempts, last_error, is_published, published_at, created_at, claimed_until, is_dead_lettered FROM outbox_events
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetOutboxEvent(ctx context.Context, id int64) (OutboxEvent, error) {
+	row := q.db.QueryRow(ctx, getOutboxEvent, id)
+	var i OutboxEvent
+	err := row.Scan(
+		&i.ID,
+		&i.TaskType,
+		&i.Payload,
+		&i.Queue,
+		&i.MaxRetry,
+		&i.ProcessAt,
+		&i.Attempts,
+		&i.LastError,
+		&i.IsPublished,
+		&i.PublishedAt,
+		&i.CreatedAt,
+		&i.ClaimedUntil,
+		&i.IsDeadLettered,
+	)
+	return i, err
+}
+
+const markOutboxEventPublished = `-- name: MarkOutboxEventPublished :one
+UPDATE outbox_events
+SET
+  is_published = true,
+  published_at = now()
+WHERE
+  id = $1
+RETURNING id, task_type, payload, queue, max_retry, process_at, attempts, last_error, is_published, published_at, created_at, claimed_until, is_dead_lettered
+`
+
+func (q *Queries) MarkOutboxEventPublished(ctx context.Context, id int64) (OutboxEvent, error) {
+	row := q.db.QueryRow(ctx, markOutboxEventPublished, id)
+	var i OutboxEvent
+	err := row.Scan(
+		&i.ID,
+		&i.TaskType,
+		&i.Payload,
+		&i.Queue,
+		&i.MaxRetry,
+		&i.ProcessAt,
+		&i.Attempts,
+		&i.LastError,
+		&i.IsPublished,
+		&i.PublishedAt,
+		&i.CreatedAt,
+		&i.ClaimedUntil,
+		&i.IsDeadLettered,
+	)
+	return i, err
+}
+
+const recordOutboxEventError = `-- name: RecordOutboxEventError :one
+UPDATE outbox_events
+SET
+  attempts = attempts + 1,
+  last_error = $1,
+  claimed_until = $2,
+  is_dead_lettered = attempts + 1 >= $3::int
+WHERE
+  id = $4
+RETURNING id, task_type, payload, queue, max_retry, process_at, attempts, last_error, is_published, published_at, created_at, claimed_until, is_dead_lettered
+`
+
+type RecordOutboxEventErrorParams struct {
+	LastError   string    `json:"last_error"`
+	RetryAt     time.Time `json:"retry_at"`
+	MaxAttempts int32     `json:"max_attempts"`
+	ID          int64     `json:"id"`
+}
+
+func (q *Queries) RecordOutboxEventError(ctx context.Context, arg RecordOutboxEventErrorParams) (OutboxEvent, error) {
+	row := q.db.QueryRow(ctx, recordOutboxEventError,
+		arg.LastError,
+		arg.RetryAt,
+		arg.MaxAttempts,
+		arg.ID,
+	)
+	var i OutboxEvent
+	err := row.Scan(
+		&i.ID,
+		&i.TaskType,
+		&i.Payload,
+		&i.Queue,
+		&i.MaxRetry,
+		&i.ProcessAt,
+		&i.Attempts,
+		&i.LastError,
+		&i.IsPublished,
+		&i.PublishedAt,
+		&i.CreatedAt,
+		&i.ClaimedUntil,
+		&i.IsDeadLettered,
+	)
+	return i, err
+}
+
+const replayOutboxEvents = `-- name: ReplayOutboxEvents :many
+UPDATE outbox_events
+SET
+  is_published = false,
+  published_at = '0001-01-01 00:00:00Z',
+  attempts = 0,
+  last_error = '',
+  claimed_until = $1,
+  is_dead_lettered = false
+WHERE
+  (is_published = true OR is_dead_lettered = true)
+  AND ($2::bigint IS NULL OR id = $2)
+  AND ($3::timestamptz IS NULL OR published_at >= $3)
+RETURNING id, task_type, payload, queue, max_retry, process_at, attempts, last_error, is_published, published_at, created_at, claimed_until, is_dead_lettered
+`
+
+type ReplayOutboxEventsParams struct {
+	ClaimedUntil   time.Time          `json:"claimed_until"`
+	ID             pgtype.Int8        `json:"id"`
+	PublishedAfter pgtype.Timestamptz `json:"published_after"`
+}
+
+func (q *Queries) ReplayOutboxEvents(ctx context.Context, arg ReplayOutboxEventsParams) ([]OutboxEvent, error) {
+	rows, err := q.db.Query(ctx, replayOutboxEvents, arg.ClaimedUntil, arg.ID, arg.PublishedAfter)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []OutboxEvent{}
+	for rows.Next() {
+		var i OutboxEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.TaskType,
+			&i.Payload,
+			&i.Queue,
+			&i.MaxRetry,
+			&i.ProcessAt,
+			&i.Attempts,
+			&i.LastError,
+			&i.IsPublished,
+			&i.PublishedAt,
+			&i.CreatedAt,
+			&i.ClaimedUntil,
+			&i.IsDeadLettered,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,215 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/juker1141/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+type testTaskPayload struct {
+	Username string `json:"username"`
+}
+
+func TestCreateUserTxWritesOutbox(t *testing.T) {
+	hashedPassword, err := util.HashPassword(util.RandomString(6))
+	require.NoError(t, err)
+
+	arg := CreateUserTxParams{
+		CreateUserParams: CreateUserParams{
+			Username: util.RandomOwner(),
+			HashedPassword: hashedPassword,
+			FullName: util.RandomOwner(),
+			Email: util.RandomEmail(),
+		},
+		Tasks: func(result CreateUserTxResult) []OutboxTask {
+			return []OutboxTask{
+				{
+					TaskType: "task:test",
+					Payload: &testTaskPayload{Username: result.User.Username},
+					Queue: "critical",
+					MaxRetry: 3,
+					ProcessIn: time.Minute,
+				},
+			}
+		},
+	}
+
+	result, err := testStore.CreateUserTx(context.Background(), arg)
+	require.NoError(t, err)
+
+	var failed []OutboxEvent
+	publishErr := errors.New("queue is down")
+
+	_, err = testStore.PublishOutbox(context.Background(), PublishOutboxParams{
+		Limit: 1000,
+		Lease: time.Minute,
+		MaxAttempts: 2,
+		Publish: func(event OutboxEvent) error {
+			if event.TaskType == "task:test" {
+				failed = append(failed, event)
+				return publishErr
+			}
+			return nil
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, failed, 1)
+
+	event := failed[0]
+	require.Equal(t, "critical", event.Queue)
+	require.Equal(t, int32(3), event.MaxRetry)
+	require.WithinDuration(t, time.Now().Add(time.Minute), event.ProcessAt, 10*time.Second)
+
+	var payload testTaskPayload
+	require.NoError(t, json.Unmarshal(event.Payload, &payload))
+	require.Equal(t, result.User.Username, payload.Username)
+
+	// the failed event stays in the outbox with its error
+	event, err = testStore.GetOutboxEvent(context.Background(), event.ID)
+	require.NoError(t, err)
+	require.False(t, event.IsPublished)
+	require.Equal(t, int32(1), event.Attempts)
+	require.Equal(t, publishErr.Error(), event.LastError)
+	require.False(t, event.IsDeadLettered)
+
+	// and is published by the next relay
+	var published []int64
+	_, err = testStore.PublishOutbox(context.Background(), PublishOutboxParams{
+		Limit: 1000,
+		Lease: time.Minute,
+		MaxAttempts: 2,
+		Publish: func(event OutboxEvent) error {
+			published = append(published, event.ID)
+			return nil
+		},
+	})
+	require.NoError(t, err)
+	require.Contains(t, published, event.ID)
+
+	event, err = testStore.GetOutboxEvent(context.Background(), event.ID)
+	require.NoError(t, err)
+	require.True(t, event.IsPublished)
+	require.NotZero(t, event.PublishedAt)
+}
+
+func TestPublishOutboxDeadLetter(t *testing.T) {
+	user := createRandomUser(t)
+
+	arg := CreateUserTxParams{
+		CreateUserParams: CreateUserParams{
+			Username: util.RandomOwner(),
+			HashedPassword: user.HashedPassword,
+			FullName: util.RandomOwner(),
+			Email: util.RandomEmail(),
+		},
+		Tasks: func(result CreateUserTxResult) []OutboxTask {
+			return []OutboxTask{
+				{
+					TaskType: "task:dead",
+					Payload: &testTaskPayload{Username: result.User.Username},
+					Queue: "default",
+				},
+			}
+		},
+	}
+	_, err := testStore.CreateUserTx(context.Background(), arg)
+	require.NoError(t, err)
+
+	// the event is dead-lettered by its second failure
+	var event OutboxEvent
+	for i := 0; i < 3; i++ {
+		_, err = testStore.PublishOutbox(context.Background(), PublishOutboxParams{
+			Limit: 1000,
+			Lease: time.Minute,
+			MaxAttempts: 2,
+			Publish: func(claimed OutboxEvent) error {
+				if claimed.TaskType == "task:dead" {
+					require.Less(t, i, 2)
+					event = claimed
+					return errors.New("invalid task")
+				}
+				return nil
+			},
+		})
+		require.NoError(t, err)
+	}
+
+	event, err = testStore.GetOutboxEvent(context.Background(), event.ID)
+	require.NoError(t, err)
+	require.False(t, event.IsPublished)
+	require.True(t, event.IsDeadLettered)
+	require.Equal(t, int32(2), event.Attempts)
+
+	// until it is replayed
+	result, err := testStore.ReplayOutboxTx(context.Background(), ReplayOutboxTxParams{
+		ReplayOutboxEventsParams: ReplayOutboxEventsParams{
+			ClaimedUntil: time.Now().Add(time.Minute),
+			ID: pgtype.Int8{Int64: event.ID, Valid: true},
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, result.Events, 1)
+	require.False(t, result.Events[0].IsDeadLettered)
+	require.Zero(t, result.Events[0].Attempts)
+
+	// the relay waits for the claim of the replay, while the task is removed from the queue
+	_, err = testStore.PublishOutbox(context.Background(), PublishOutboxParams{
+		Limit: 1000,
+		Lease: time.Minute,
+		MaxAttempts: 2,
+		Publish: func(claimed OutboxEvent) error {
+			require.NotEqual(t, event.ID, claimed.ID)
+			return nil
+		},
+	})
+	require.NoError(t, err)
+}
+
+func TestCreateUserTxRollbackOutbox(t *testing.T) {
+	user := createRandomUser(t)
+
+	called := false
+	_, err := testStore.CreateUserTx(context.Background(), CreateUserTxParams{
+		CreateUserParams: CreateUserParams{
+			Username: user.Username,
+			HashedPassword: user.HashedPassword,
+			FullName: user.FullName,
+			Email: util.RandomEmail(),
+		},
+		Tasks: func(result CreateUserTxResult) []OutboxTask {
+			called = true
+			return nil
+		},
+	})
+	require.Error(t, err)
+	require.Equal(t, UniqueViolation, ErrorCode(err))
+	require.False(t, called)
+}
+
+func TestTransferTxRollbackOutbox(t *testing.T) {
+	account := createRandomAccount(t)
+
+	// the transfer fails because the receiving account doesn't exist
+	called := false
+	_, err := testStore.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account.ID,
+		ToAccountID: -1,
+		Amount: 10,
+		Tasks: func(result TransferTxResult) []OutboxTask {
+			called = true
+			return nil
+		},
+	})
+	require.Error(t, err)
+	require.False(t, called)
+
+	account2, err := testStore.GetAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, account.Balance, account2.Balance)
+}
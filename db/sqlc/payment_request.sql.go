@@ -0,0 +1,261 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.18.0
+// source: payment_request.sql
+
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const acceptPaymentRequest = `-- name: AcceptPaymentRequest :one
+UPDATE payment_requests
+SET
+  status = 'accepted',
+  from_account_id = $1,
+  transfer_id = $2,
+  responded_at = now()
+WHERE id = $3
+RETURNING id, payee, payer, to_account_id, amount, currency, memo, status, from_account_id, transfer_id, expired_at, responded_at, created_at
+`
+
+type AcceptPaymentRequestParams struct {
+	FromAccountID pgtype.Int8 `json:"from_account_id"`
+	TransferID    pgtype.Int8 `json:"transfer_id"`
+	ID            int64       `json:"id"`
+}
+
+func (q *Queries) AcceptPaymentRequest(ctx context.Context, arg AcceptPaymentRequestParams) (PaymentRequest, error) {
+	row := q.db.QueryRow(ctx, acceptPaymentRequest, arg.FromAccountID, arg.TransferID, arg.ID)
+	var i PaymentRequest
+	err := row.Scan(
+		&i.ID,
+		&i.Payee,
+		&i.Payer,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Currency,
+		&i.Memo,
+		&i.Status,
+		&i.FromAccountID,
+		&i.TransferID,
+		&i.ExpiredAt,
+		&i.RespondedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createPaymentRequest = `-- name: CreatePaymentRequest :one
+INSERT INTO payment_requests (
+  payee,
+  payer,
+  to_account_id,
+  amount,
+  currency,
+  memo,
+  expired_at
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7
+) RETURNING id, payee, payer, to_account_id, amount, currency, memo, status, from_account_id, transfer_id, expired_at, responded_at, created_at
+`
+
+type CreatePaymentRequestParams struct {
+	Payee       string    `json:"payee"`
+	Payer       string    `json:"payer"`
+	ToAccountID int64     `json:"to_account_id"`
+	Amount      int64     `json:"amount"`
+	Currency    string    `json:"currency"`
+	Memo        string    `json:"memo"`
+	ExpiredAt   time.Time `json:"expired_at"`
+}
+
+func (q *Queries) CreatePaymentRequest(ctx context.Context, arg CreatePaymentRequestParams) (PaymentRequest, error) {
+	row := q.db.QueryRow(ctx, createPaymentRequest,
+		arg.Payee,
+		arg.Payer,
+		arg.ToAccountID,
+		arg.Amount,
+		arg.Currency,
+		arg.Memo,
+		arg.ExpiredAt,
+	)
+	var i PaymentRequest
+	err := row.Scan(
+		&i.ID,
+		&i.Payee,
+		&i.Payer,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Currency,
+		&i.Memo,
+		&i.Status,
+		&i.FromAccountID,
+		&i.TransferID,
+		&i.ExpiredAt,
+		&i.RespondedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const declinePaymentRequest = `-- name: DeclinePaymentRequest :one
+UPDATE payment_requests
+SET
+  status = 'declined',
+  responded_at = now()
+WHERE id = $1
+RETURNING id, payee, payer, to_account_id, amount, currency, memo, status, from_account_id, transfer_id, expired_at, responded_at, created_at
+`
+
+func (q *Queries) DeclinePaymentRequest(ctx context.Context, id int64) (PaymentRequest, error) {
+	row := q.db.QueryRow(ctx, declinePaymentRequest, id)
+	var i PaymentRequest
+	err := row.Scan(
+		&i.ID,
+		&i.Payee,
+		&i.Payer,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Currency,
+		&i.Memo,
+		&i.Status,
+		&i.FromAccountID,
+		&i.TransferID,
+		&i.ExpiredAt,
+		&i.RespondedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const expirePaymentRequests = `-- name: ExpirePaymentRequests :execrows
+UPDATE payment_requests
+SET
+  status = 'expired',
+  responded_at = now()
+WHERE
+  status = 'pending'
+  AND expired_at < now()
+`
+
+func (q *Queries) ExpirePaymentRequests(ctx context.Context) (int64, error) {
+	result, err := q.db.Exec(ctx, expirePaymentRequests)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const getPaymentRequest = `-- name: GetPaymentRequest :one
+SELECT id, payee, payer, to_account_id, amount, currency, memo, status, from_account_id, transfer_id, expired_at, responded_at, created_at FROM payment_requests
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetPaymentRequest(ctx context.Context, id int64) (PaymentRequest, error) {
+	row := q.db.QueryRow(ctx, getPaymentRequest, id)
+	var i PaymentRequest
+	err := row.Scan(
+		&i.ID,
+		&i.Payee,
+		&i.Payer,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Currency,
+		&i.Memo,
+		&i.Status,
+		&i.FromAccountID,
+		&i.TransferID,
+		&i.ExpiredAt,
+		&i.RespondedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getPaymentRequestForUpdate = `-- name: GetPaymentRequestForUpdate :one
+SELECT id, payee, payer, to_account_id, amount, currency, memo, status, from_account_id, transfer_id, expired_at, responded_at, created_at FROM payment_requests
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE
+`
+
+func (q *Queries) GetPaymentRequestForUpdate(ctx context.Context, id int64) (PaymentRequest, error) {
+	row := q.db.QueryRow(ctx, getPaymentRequestForUpdate, id)
+	var i PaymentRequest
+	err := row.Scan(
+		&i.ID,
+		&i.Payee,
+		&i.Payer,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Currency,
+		&i.Memo,
+		&i.Status,
+		&i.FromAccountID,
+		&i.TransferID,
+		&i.ExpiredAt,
+		&i.RespondedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listPaymentRequests = `-- name: ListPaymentRequests :many
+SELECT id, payee, payer, to_account_id, amount, currency, memo, status, from_account_id, transfer_id, expired_at, responded_at, created_at FROM payment_requests
+WHERE
+  (payee = $1 OR payer = $1)
+  AND status = $2
+ORDER BY id DESC
+LIMIT $4
+OFFSET $3
+`
+
+type ListPaymentRequestsParams struct {
+	Username string `json:"username"`
+	Status   string `json:"status"`
+	Offset   int32  `json:"offset"`
+	Limit    int32  `json:"limit"`
+}
+
+func (q *Queries) ListPaymentRequests(ctx context.Context, arg ListPaymentRequestsParams) ([]PaymentRequest, error) {
+	rows, err := q.db.Query(ctx, listPaymentRequests,
+		arg.Username,
+		arg.Status,
+		arg.Offset,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []PaymentRequest{}
+	for rows.Next() {
+		var i PaymentRequest
+		if err := rows.Scan(
+			&i.ID,
+			&i.Payee,
+			&i.Payer,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.Currency,
+			&i.Memo,
+			&i.Status,
+			&i.FromAccountID,
+			&i.TransferID,
+			&i.ExpiredAt,
+			&i.RespondedAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,130 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/juker1141/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomPaymentRequest(t *testing.T, toAccount Account, payer string, expiredAt time.Time) PaymentRequest {
+	arg := CreatePaymentRequestTxParams{
+		CreatePaymentRequestParams: CreatePaymentRequestParams{
+			Payee: toAccount.Owner,
+			Payer: payer,
+			ToAccountID: toAccount.ID,
+			Amount: util.RandomInt(1, 10),
+			Currency: toAccount.Currency,
+			Memo: util.RandomString(10),
+			ExpiredAt: expiredAt,
+		},
+	}
+
+	result, err := testStore.CreatePaymentRequestTx(context.Background(), arg)
+	require.NoError(t, err)
+
+	paymentRequest := result.PaymentRequest
+	require.NotZero(t, paymentRequest.ID)
+	require.Equal(t, arg.Payee, paymentRequest.Payee)
+	require.Equal(t, arg.Payer, paymentRequest.Payer)
+	require.Equal(t, arg.Amount, paymentRequest.Amount)
+	require.Equal(t, arg.Memo, paymentRequest.Memo)
+	require.Equal(t, PaymentRequestStatusPending, paymentRequest.Status)
+	require.False(t, paymentRequest.TransferID.Valid)
+
+	return paymentRequest
+}
+
+func TestAcceptPaymentRequestTx(t *testing.T) {
+	toAccount := createRandomAccount(t)
+	fromAccount := createRandomAccount(t)
+	paymentRequest := createRandomPaymentRequest(t, toAccount, fromAccount.Owner, time.Now().Add(time.Hour))
+
+	var notified PaymentRequest
+	arg := AcceptPaymentRequestTxParams{
+		ID: paymentRequest.ID,
+		FromAccountID: fromAccount.ID,
+		Tasks: func(paymentRequest PaymentRequest) []OutboxTask {
+			notified = paymentRequest
+			return nil
+		},
+	}
+
+	result, err := testStore.AcceptPaymentRequestTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, notified, result.PaymentRequest)
+
+	accepted := result.PaymentRequest
+	require.Equal(t, PaymentRequestStatusAccepted, accepted.Status)
+	require.Equal(t, fromAccount.ID, accepted.FromAccountID.Int64)
+	require.Equal(t, result.Transfer.ID, accepted.TransferID.Int64)
+	require.NotZero(t, accepted.RespondedAt)
+
+	require.Equal(t, fromAccount.ID, result.Transfer.FromAccountID)
+	require.Equal(t, toAccount.ID, result.Transfer.ToAccountID)
+	require.Equal(t, paymentRequest.Amount, result.Transfer.Amount)
+	require.Equal(t, toAccount.Balance+paymentRequest.Amount, result.ToAccount.Balance)
+
+	// a request can only be paid once
+	_, err = testStore.AcceptPaymentRequestTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrPaymentRequestNotPending)
+}
+
+func TestDeclinePaymentRequestTx(t *testing.T) {
+	toAccount := createRandomAccount(t)
+	payer := createRandomUser(t)
+	paymentRequest := createRandomPaymentRequest(t, toAccount, payer.Username, time.Now().Add(time.Hour))
+
+	result, err := testStore.DeclinePaymentRequestTx(context.Background(), DeclinePaymentRequestTxParams{
+		ID: paymentRequest.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, PaymentRequestStatusDeclined, result.PaymentRequest.Status)
+
+	declined, err := testStore.ListPaymentRequests(context.Background(), ListPaymentRequestsParams{
+		Username: payer.Username,
+		Status: PaymentRequestStatusDeclined,
+		Limit: 5,
+	})
+	require.NoError(t, err)
+	require.Len(t, declined, 1)
+	require.Equal(t, paymentRequest.ID, declined[0].ID)
+}
+
+func TestAcceptExpiredPaymentRequestTx(t *testing.T) {
+	toAccount := createRandomAccount(t)
+	fromAccount := createRandomAccount(t)
+	paymentRequest := createRandomPaymentRequest(t, toAccount, fromAccount.Owner, time.Now().Add(-time.Minute))
+
+	_, err := testStore.AcceptPaymentRequestTx(context.Background(), AcceptPaymentRequestTxParams{
+		ID: paymentRequest.ID,
+		FromAccountID: fromAccount.ID,
+	})
+	require.ErrorIs(t, err, ErrPaymentRequestExpired)
+
+	unchanged, err := testStore.GetPaymentRequest(context.Background(), paymentRequest.ID)
+	require.NoError(t, err)
+	require.Equal(t, PaymentRequestStatusPending, unchanged.Status)
+}
+
+func TestExpirePaymentRequests(t *testing.T) {
+	toAccount := createRandomAccount(t)
+	fromAccount := createRandomAccount(t)
+	expired := createRandomPaymentRequest(t, toAccount, fromAccount.Owner, time.Now().Add(-time.Minute))
+	pending := createRandomPaymentRequest(t, toAccount, fromAccount.Owner, time.Now().Add(time.Hour))
+
+	count, err := testStore.ExpirePaymentRequests(context.Background())
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, count, int64(1))
+
+	paymentRequest, err := testStore.GetPaymentRequest(context.Background(), expired.ID)
+	require.NoError(t, err)
+	require.Equal(t, PaymentRequestStatusExpired, paymentRequest.Status)
+	require.WithinDuration(t, time.Now(), paymentRequest.RespondedAt, time.Second)
+
+	paymentRequest, err = testStore.GetPaymentRequest(context.Background(), pending.ID)
+	require.NoError(t, err)
+	require.Equal(t, PaymentRequestStatusPending, paymentRequest.Status)
+}
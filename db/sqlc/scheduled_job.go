@@ -0,0 +1,7 @@
+package db
+
+// Statuses of the last run of a scheduled job
+const (
+	ScheduledJobStatusSucceeded = "succeeded"
+	ScheduledJobStatusFailed    = "failed"
+)
@@ -0,0 +1,206 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.18.0
+// source: scheduled_job.sql
+
+package db
+
+import (
+	"context"
+	"time"
+)
+
+const acquireScheduledJob = `-- name: AcquireScheduledJob :one
+UPDATE scheduled_jobs
+SET
+  locked_by = $1,
+  locked_until = $2,
+  last_started_at = now()
+WHERE
+  name = $3
+  AND next_run_at <= now()
+  AND locked_until < now()
+RETURNING name, schedule, next_run_at, locked_by, locked_until, last_started_at, last_finished_at, last_status, last_result, last_error, last_duration_ms
+`
+
+type AcquireScheduledJobParams struct {
+	LockedBy    string    `json:"locked_by"`
+	LockedUntil time.Time `json:"locked_until"`
+	Name        string    `json:"name"`
+}
+
+func (q *Queries) AcquireScheduledJob(ctx context.Context, arg AcquireScheduledJobParams) (ScheduledJob, error) {
+	row := q.db.QueryRow(ctx, acquireScheduledJob, arg.LockedBy, arg.LockedUntil, arg.Name)
+	var i ScheduledJob
+	err := row.Scan(
+		&i.Name,
+		&i.Schedule,
+		&i.NextRunAt,
+		&i.LockedBy,
+		&i.LockedUntil,
+		&i.LastStartedAt,
+		&i.LastFinishedAt,
+		&i.LastStatus,
+		&i.LastResult,
+		&i.LastError,
+		&i.LastDurationMs,
+	)
+	return i, err
+}
+
+const finishScheduledJob = `-- name: FinishScheduledJob :one
+UPDATE scheduled_jobs
+SET
+  locked_by = '',
+  locked_until = '0001-01-01 00:00:00Z',
+  next_run_at = $1,
+  last_finished_at = now(),
+  last_status = $2,
+  last_result = $3,
+  last_error = $4,
+  last_duration_ms = $5
+WHERE
+  name = $6
+  AND locked_by = $7
+RETURNING name, schedule, next_run_at, locked_by, locked_until, last_started_at, last_finished_at, last_status, last_result, last_error, last_duration_ms
+`
+
+type FinishScheduledJobParams struct {
+	NextRunAt      time.Time `json:"next_run_at"`
+	LastStatus     string    `json:"last_status"`
+	LastResult     string    `json:"last_result"`
+	LastError      string    `json:"last_error"`
+	LastDurationMs int64     `json:"last_duration_ms"`
+	Name           string    `json:"name"`
+	LockedBy       string    `json:"locked_by"`
+}
+
+func (q *Queries) FinishScheduledJob(ctx context.Context, arg FinishScheduledJobParams) (ScheduledJob, error) {
+	row := q.db.QueryRow(ctx, finishScheduledJob,
+		arg.NextRunAt,
+		arg.LastStatus,
+		arg.LastResult,
+		arg.LastError,
+		arg.LastDurationMs,
+		arg.Name,
+		arg.LockedBy,
+	)
+	var i ScheduledJob
+	err := row.Scan(
+		&i.Name,
+		&i.Schedule,
+		&i.NextRunAt,
+		&i.LockedBy,
+		&i.LockedUntil,
+		&i.LastStartedAt,
+		&i.LastFinishedAt,
+		&i.LastStatus,
+		&i.LastResult,
+		&i.LastError,
+		&i.LastDurationMs,
+	)
+	return i, err
+}
+
+const getScheduledJob = `-- name: GetScheduledJob :one
+SELECT name, schedule, next_run_at, locked_by, locked_until, last_started_at, last_finished_at, last_status, last_result, last_error, last_duration_ms FROM scheduled_jobs
+WHERE name = $1 LIMIT 1
+`
+
+func (q *Queries) GetScheduledJob(ctx context.Context, name string) (ScheduledJob, error) {
+	row := q.db.QueryRow(ctx, getScheduledJob, name)
+	var i ScheduledJob
+	err := row.Scan(
+		&i.Name,
+		&i.Schedule,
+		&i.NextRunAt,
+		&i.LockedBy,
+		&i.LockedUntil,
+		&i.LastStartedAt,
+		&i.LastFinishedAt,
+		&i.LastStatus,
+		&i.LastResult,
+		&i.LastError,
+		&i.LastDurationMs,
+	)
+	return i, err
+}
+
+const listScheduledJobs = `-- name: ListScheduledJobs :many
+SELECT name, schedule, next_run_at, locked_by, locked_until, last_started_at, last_finished_at, last_status, last_result, last_error, last_duration_ms FROM scheduled_jobs
+ORDER BY name
+`
+
+func (q *Queries) ListScheduledJobs(ctx context.Context) ([]ScheduledJob, error) {
+	rows, err := q.db.Query(ctx, listScheduledJobs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ScheduledJob{}
+	for rows.Next() {
+		var i ScheduledJob
+		if err := rows.Scan(
+			&i.Name,
+			&i.Schedule,
+			&i.NextRunAt,
+			&i.LockedBy,
+			&i.LockedUntil,
+			&i.LastStartedAt,
+			&i.LastFinishedAt,
+			&i.LastStatus,
+			&i.LastResult,
+			&i.LastError,
+			&i.LastDurationMs,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const upsertScheduledJob = `-- name: UpsertScheduledJob :one
+INSERT INTO scheduled_jobs (
+  name,
+  schedule,
+  next_run_at
+) VALUES (
+  $1, $2, $3
+) ON CONFLICT (name) DO UPDATE
+SET
+  schedule = EXCLUDED.schedule,
+  next_run_at = CASE
+    WHEN scheduled_jobs.schedule = EXCLUDED.schedule THEN scheduled_jobs.next_run_at
+    ELSE EXCLUDED.next_run_at
+  END
+RETURNING name, schedule, next_run_at, locked_by, locked_until, last_started_at, last_finished_at, last_status, last_result, last_error, last_duration_ms
+`
+
+type UpsertScheduledJobParams struct {
+	Name      string    `json:"name"`
+	Schedule  string    `json:"schedule"`
+	NextRunAt time.Time `json:"next_run_at"`
+}
+
+func (q *Queries) UpsertScheduledJob(ctx context.Context, arg UpsertScheduledJobParams) (ScheduledJob, error) {
+	row := q.db.QueryRow(ctx, upsertScheduledJob, arg.Name, arg.Schedule, arg.NextRunAt)
+	var i ScheduledJob
+	err := row.Scan(
+		&i.Name,
+		&i.Schedule,
+		&i.NextRunAt,
+		&i.LockedBy,
+		&i.LockedUntil,
+		&i.LastStartedAt,
+		&i.LastFinishedAt,
+		&i.LastStatus,
+		&i.LastResult,
+		&i.LastError,
+		&i.LastDurationMs,
+	)
+	return i, err
+}
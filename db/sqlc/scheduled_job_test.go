@@ -0,0 +1,126 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/juker1141/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomScheduledJob(t *testing.T, nextRunAt time.Time) ScheduledJob {
+	arg := UpsertScheduledJobParams{
+		Name: util.RandomString(12),
+		Schedule: "@hourly",
+		NextRunAt: nextRunAt,
+	}
+
+	job, err := testStore.UpsertScheduledJob(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Name, job.Name)
+	require.Equal(t, arg.Schedule, job.Schedule)
+	require.WithinDuration(t, arg.NextRunAt, job.NextRunAt, time.Second)
+	require.Empty(t, job.LockedBy)
+	require.Empty(t, job.LastStatus)
+
+	return job
+}
+
+func TestUpsertScheduledJob(t *testing.T) {
+	job := createRandomScheduledJob(t, time.Now().Add(time.Hour))
+
+	// the next run is kept while the schedule doesn't change
+	unchanged, err := testStore.UpsertScheduledJob(context.Background(), UpsertScheduledJobParams{
+		Name: job.Name,
+		Schedule: job.Schedule,
+		NextRunAt: time.Now().Add(2 * time.Hour),
+	})
+	require.NoError(t, err)
+	require.WithinDuration(t, job.NextRunAt, unchanged.NextRunAt, time.Second)
+
+	nextRunAt := time.Now().Add(5 * time.Minute)
+	rescheduled, err := testStore.UpsertScheduledJob(context.Background(), UpsertScheduledJobParams{
+		Name: job.Name,
+		Schedule: "@every 5m",
+		NextRunAt: nextRunAt,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "@every 5m", rescheduled.Schedule)
+	require.WithinDuration(t, nextRunAt, rescheduled.NextRunAt, time.Second)
+}
+
+func TestAcquireScheduledJob(t *testing.T) {
+	job := createRandomScheduledJob(t, time.Now().Add(-time.Minute))
+
+	acquired, err := testStore.AcquireScheduledJob(context.Background(), AcquireScheduledJobParams{
+		Name: job.Name,
+		LockedBy: "worker-1",
+		LockedUntil: time.Now().Add(time.Minute),
+	})
+	require.NoError(t, err)
+	require.Equal(t, "worker-1", acquired.LockedBy)
+	require.WithinDuration(t, time.Now(), acquired.LastStartedAt, time.Second)
+
+	// another worker can't run the job at the same time
+	_, err = testStore.AcquireScheduledJob(context.Background(), AcquireScheduledJobParams{
+		Name: job.Name,
+		LockedBy: "worker-2",
+		LockedUntil: time.Now().Add(time.Minute),
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	nextRunAt := time.Now().Add(time.Hour)
+	finished, err := testStore.FinishScheduledJob(context.Background(), FinishScheduledJobParams{
+		Name: job.Name,
+		LockedBy: "worker-1",
+		NextRunAt: nextRunAt,
+		LastStatus: ScheduledJobStatusSucceeded,
+		LastResult: "done",
+		LastDurationMs: 12,
+	})
+	require.NoError(t, err)
+	require.Empty(t, finished.LockedBy)
+	require.True(t, finished.LockedUntil.Before(time.Now()))
+	require.WithinDuration(t, nextRunAt, finished.NextRunAt, time.Second)
+	require.Equal(t, ScheduledJobStatusSucceeded, finished.LastStatus)
+	require.Equal(t, "done", finished.LastResult)
+	require.Equal(t, int64(12), finished.LastDurationMs)
+
+	// the job isn't due until its next run
+	_, err = testStore.AcquireScheduledJob(context.Background(), AcquireScheduledJobParams{
+		Name: job.Name,
+		LockedBy: "worker-2",
+		LockedUntil: time.Now().Add(time.Minute),
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
+
+func TestAcquireExpiredScheduledJobLock(t *testing.T) {
+	job := createRandomScheduledJob(t, time.Now().Add(-time.Minute))
+
+	_, err := testStore.AcquireScheduledJob(context.Background(), AcquireScheduledJobParams{
+		Name: job.Name,
+		LockedBy: "worker-1",
+		LockedUntil: time.Now().Add(-time.Second),
+	})
+	require.NoError(t, err)
+
+	// the lock of a worker that didn't finish in time is taken over
+	acquired, err := testStore.AcquireScheduledJob(context.Background(), AcquireScheduledJobParams{
+		Name: job.Name,
+		LockedBy: "worker-2",
+		LockedUntil: time.Now().Add(time.Minute),
+	})
+	require.NoError(t, err)
+	require.Equal(t, "worker-2", acquired.LockedBy)
+
+	// the first worker can't record its result anymore
+	_, err = testStore.FinishScheduledJob(context.Background(), FinishScheduledJobParams{
+		Name: job.Name,
+		LockedBy: "worker-1",
+		NextRunAt: time.Now().Add(time.Hour),
+		LastStatus: ScheduledJobStatusSucceeded,
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
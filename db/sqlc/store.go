@@ -10,8 +10,29 @@ import (
 type Store interface {
 	Querier
 	TransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error)
+	QuoteTransferFees(ctx context.Context, arg TransferTxParams) ([]FeeQuote, error)
 	CreateUserTx(ctx context.Context, arg CreateUserTxParams) (CreateUserTxResult, error)
+	CreateUserWithRoleTx(ctx context.Context, arg CreateUserWithRoleTxParams) (CreateUserWithRoleTxResult, error)
+	UpdateUserTx(ctx context.Context, arg UpdateUserTxParams) (UpdateUserTxResult, error)
 	VerifyEmailTx(ctx context.Context, arg VerifyEmailTxParams) (VerifyEmailTxResult, error)
+	CreateVerifyEmailTx(ctx context.Context, arg CreateVerifyEmailTxParams) (CreateVerifyEmailTxResult, error)
+	DepositTx(ctx context.Context, arg ExternalTransferTxParams) (ExternalTransferTxResult, error)
+	WithdrawTx(ctx context.Context, arg ExternalTransferTxParams) (ExternalTransferTxResult, error)
+	CreateBeneficiaryTx(ctx context.Context, arg CreateBeneficiaryTxParams) (CreateBeneficiaryTxResult, error)
+	CreateAccountTx(ctx context.Context, arg CreateAccountParams) (CreateAccountTxResult, error)
+	CreatePaymentRequestTx(ctx context.Context, arg CreatePaymentRequestTxParams) (CreatePaymentRequestTxResult, error)
+	AcceptPaymentRequestTx(ctx context.Context, arg AcceptPaymentRequestTxParams) (AcceptPaymentRequestTxResult, error)
+	DeclinePaymentRequestTx(ctx context.Context, arg DeclinePaymentRequestTxParams) (DeclinePaymentRequestTxResult, error)
+	CreateOutboxEventsTx(ctx context.Context, tasks []OutboxTask) error
+	PublishOutbox(ctx context.Context, arg PublishOutboxParams) (PublishOutboxResult, error)
+	CreateWebhookDeliveriesTx(ctx context.Context, arg CreateWebhookDeliveriesTxParams) (CreateWebhookDeliveriesTxResult, error)
+	ReplayWebhookDeliveryTx(ctx context.Context, arg ReplayWebhookDeliveryTxParams) (ReplayWebhookDeliveryTxResult, error)
+	SetUserLockedTx(ctx context.Context, arg SetUserLockedTxParams) (SetUserLockedTxResult, error)
+	RevokeSessionsTx(ctx context.Context, arg RevokeSessionsTxParams) (RevokeSessionsTxResult, error)
+	ReplayOutboxTx(ctx context.Context, arg ReplayOutboxTxParams) (ReplayOutboxTxResult, error)
+	AppendAuditEventsTx(ctx context.Context, records []AuditRecord) error
+	ChainAuditEventsTx(ctx context.Context, limit int32) (int, error)
+	VerifyAuditLog(ctx context.Context) (int, error)
 }
 
 // SQLStore provides all functions to execute SQL queries and transactions
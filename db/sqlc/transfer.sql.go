@@ -7,8 +7,26 @@ package db
 
 import (
 	"context"
+	"time"
 )
 
+const countTransfersFromAccountSince = `-- name: CountTransfersFromAccountSince :one
+SELECT count(*) FROM transfers
+WHERE from_account_id = $1 AND created_at >= $2
+`
+
+type CountTransfersFromAccountSinceParams struct {
+	FromAccountID int64     `json:"from_account_id"`
+	Since         time.Time `json:"since"`
+}
+
+func (q *Queries) CountTransfersFromAccountSince(ctx context.Context, arg CountTransfersFromAccountSinceParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countTransfersFromAccountSince, arg.FromAccountID, arg.Since)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createTransfer = `-- name: CreateTransfer :one
 INSERT INTO transfers (
   from_account_id,
@@ -65,6 +83,43 @@ func (q *Queries) GetTransfer(ctx context.Context, id int64) (Transfer, error) {
 	return i, err
 }
 
+const listTransferVolumes = `-- name: ListTransferVolumes :many
+SELECT
+  a.currency,
+  count(*) AS transfers,
+  COALESCE(sum(t.amount), 0)::bigint AS amount
+FROM transfers t
+JOIN accounts a ON a.id = t.from_account_id
+GROUP BY a.currency
+ORDER BY a.currency
+`
+
+type ListTransferVolumesRow struct {
+	Currency  string `json:"currency"`
+	Transfers int64  `json:"transfers"`
+	Amount    int64  `json:"amount"`
+}
+
+func (q *Queries) ListTransferVolumes(ctx context.Context) ([]ListTransferVolumesRow, error) {
+	rows, err := q.db.Query(ctx, listTransferVolumes)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListTransferVolumesRow{}
+	for rows.Next() {
+		var i ListTransferVolumesRow
+		if err := rows.Scan(&i.Currency, &i.Transfers, &i.Amount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listTransfers = `-- name: ListTransfers :many
 SELECT id, from_account_id, to_account_id, amount, created_at FROM transfers
 ORDER BY id
@@ -0,0 +1,36 @@
+package db
+
+import (
+	"context"
+
+	"github.com/juker1141/simplebank/util"
+)
+
+type CreateAccountTxResult struct {
+	Account Account
+	Member  AccountMember
+}
+
+// CreateAccountTx creates a new account and makes its creator the owner member
+func (store *SQLStore) CreateAccountTx(ctx context.Context, arg CreateAccountParams) (CreateAccountTxResult, error) {
+	var result CreateAccountTxResult
+
+	err := store.execTx(ctx, "CreateAccountTx", func(q *Queries) error {
+		var err error
+
+		result.Account, err = q.CreateAccount(ctx, arg)
+		if err != nil {
+			return err
+		}
+
+		result.Member, err = q.CreateAccountMember(ctx, CreateAccountMemberParams{
+			AccountID:  result.Account.ID,
+			Username:   arg.Owner,
+			Role:       util.AccountOwnerRole,
+			InvitedBy:  arg.Owner,
+			IsAccepted: true,
+		})
+		return err
+	})
+	return result, err
+}
@@ -0,0 +1,28 @@
+package db
+
+import "context"
+
+type CreateBeneficiaryTxParams struct {
+	CreateBeneficiaryParams
+	Tasks func(beneficiary Beneficiary) []OutboxTask
+}
+
+type CreateBeneficiaryTxResult struct {
+	Beneficiary Beneficiary
+}
+
+func (store *SQLStore) CreateBeneficiaryTx(ctx context.Context, arg CreateBeneficiaryTxParams) (CreateBeneficiaryTxResult, error) {
+	var result CreateBeneficiaryTxResult
+
+	err := store.execTx(ctx, "CreateBeneficiaryTx", func(q *Queries) error {
+		var err error
+
+		result.Beneficiary, err = q.CreateBeneficiary(ctx, arg.CreateBeneficiaryParams)
+		if err != nil {
+			return err
+		}
+
+		return createOutboxEvents(ctx, q, arg.Tasks, result.Beneficiary)
+	})
+	return result, err
+}
@@ -4,17 +4,20 @@ import "context"
 
 type CreateUserTxParams struct {
 	CreateUserParams
-	AfterCreate func(user User) error
+	// VerifySecretCode is the code of the verification of the email, it is created with the user when it is set
+	VerifySecretCode string
+	Tasks            func(result CreateUserTxResult) []OutboxTask
 }
 
 type CreateUserTxResult struct {
-	User User
+	User        User
+	VerifyEmail VerifyEmail
 }
 
 func (store *SQLStore) CreateUserTx(ctx context.Context, arg CreateUserTxParams) (CreateUserTxResult, error) {
 	var result CreateUserTxResult
 
-	err := store.execTx(ctx, func(q *Queries) error {
+	err := store.execTx(ctx, "CreateUserTx", func(q *Queries) error {
 		var err error
 
 		result.User, err = q.CreateUser(ctx, arg.CreateUserParams)
@@ -22,7 +25,45 @@ func (store *SQLStore) CreateUserTx(ctx context.Context, arg CreateUserTxParams)
 			return err
 		}
 
-		return arg.AfterCreate(result.User)
+		if arg.VerifySecretCode != "" {
+			result.VerifyEmail, err = q.CreateVerifyEmail(ctx, CreateVerifyEmailParams{
+				Username:   result.User.Username,
+				Email:      result.User.Email,
+				SecretCode: arg.VerifySecretCode,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		return createOutboxEvents(ctx, q, arg.Tasks, result)
+	})
+	return result, err
+}
+
+type CreateUserWithRoleTxParams struct {
+	CreateUserWithRoleParams
+	// Audit returns the audit events of the creation, they are appended within the transaction
+	Audit func(result CreateUserWithRoleTxResult) []AuditRecord
+}
+
+type CreateUserWithRoleTxResult struct {
+	User User
+}
+
+// CreateUserWithRoleTx creates a user with the role, such as the bankers created by the operators
+func (store *SQLStore) CreateUserWithRoleTx(ctx context.Context, arg CreateUserWithRoleTxParams) (CreateUserWithRoleTxResult, error) {
+	var result CreateUserWithRoleTxResult
+
+	err := store.execTx(ctx, "CreateUserWithRoleTx", func(q *Queries) error {
+		var err error
+
+		result.User, err = q.CreateUserWithRole(ctx, arg.CreateUserWithRoleParams)
+		if err != nil {
+			return err
+		}
+
+		return createAuditEvents(ctx, q, arg.Audit, result)
 	})
 	return result, err
 }
@@ -0,0 +1,132 @@
+package db
+
+import (
+	"context"
+	"errors"
+)
+
+// Types of account entries
+const (
+	EntryTypeTransfer   = "transfer"
+	EntryTypeFee        = "fee"
+	EntryTypeDeposit    = "deposit"
+	EntryTypeWithdrawal = "withdrawal"
+)
+
+// Types of external transfers
+const (
+	ExternalTransferTypeDeposit    = "deposit"
+	ExternalTransferTypeWithdrawal = "withdrawal"
+)
+
+var ErrInsufficientBalance = errors.New("insufficient balance")
+
+// ExternalTransferTxParams contains the input parameters of the deposit and withdraw transactions
+type ExternalTransferTxParams struct {
+	AccountID         int64  `json:"account_id"`
+	Amount            int64  `json:"amount"`
+	ExternalReference string `json:"external_reference"`
+	CreatedBy         string `json:"created_by"`
+	// Tasks returns the tasks to publish once the transfer is committed
+	Tasks func(result ExternalTransferTxResult) []OutboxTask `json:"-"`
+	// Audit returns the audit events of the transfer, they are appended within the transaction
+	Audit func(result ExternalTransferTxResult) []AuditRecord `json:"-"`
+}
+
+// ExternalTransferTxResult is the result of the deposit and withdraw transactions
+type ExternalTransferTxResult struct {
+	ExternalTransfer  ExternalTransfer `json:"external_transfer"`
+	Account           Account          `json:"account"`
+	SettlementAccount Account          `json:"settlement_account"`
+	Entry             Entry            `json:"entry"`
+	SettlementEntry   Entry            `json:"settlement_entry"`
+}
+
+// DepositTx moves money from the settlement account of the currency into a customer account
+func (store *SQLStore) DepositTx(ctx context.Context, arg ExternalTransferTxParams) (ExternalTransferTxResult, error) {
+	return store.externalTransferTx(ctx, "DepositTx", ExternalTransferTypeDeposit, arg)
+}
+
+// WithdrawTx moves money from a customer account to the settlement account of the currency.
+// It fails with ErrInsufficientBalance if the account doesn't have enough money
+func (store *SQLStore) WithdrawTx(ctx context.Context, arg ExternalTransferTxParams) (ExternalTransferTxResult, error) {
+	return store.externalTransferTx(ctx, "WithdrawTx", ExternalTransferTypeWithdrawal, arg)
+}
+
+func (store *SQLStore) externalTransferTx(ctx context.Context, name string, transferType string, arg ExternalTransferTxParams) (ExternalTransferTxResult, error) {
+	var result ExternalTransferTxResult
+
+	err := store.execTx(ctx, name, func(q *Queries) error {
+		account, err := q.GetAccount(ctx, arg.AccountID)
+		if err != nil {
+			return err
+		}
+
+		settlement, err := q.GetSettlementAccount(ctx, account.Currency)
+		if err != nil {
+			return err
+		}
+
+		amount := arg.Amount
+		entryType := EntryTypeDeposit
+		if transferType == ExternalTransferTypeWithdrawal {
+			amount = -arg.Amount
+			entryType = EntryTypeWithdrawal
+		}
+
+		result.Entry, err = q.CreateEntry(ctx, CreateEntryParams{
+			AccountID: account.ID,
+			Amount:    amount,
+			Type:      entryType,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.SettlementEntry, err = q.CreateEntry(ctx, CreateEntryParams{
+			AccountID: settlement.AccountID,
+			Amount:    -amount,
+			Type:      entryType,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.ExternalTransfer, err = q.CreateExternalTransfer(ctx, CreateExternalTransferParams{
+			Type:                transferType,
+			AccountID:           account.ID,
+			SettlementAccountID: settlement.AccountID,
+			Amount:              arg.Amount,
+			Currency:            account.Currency,
+			ExternalReference:   arg.ExternalReference,
+			EntryID:             result.Entry.ID,
+			SettlementEntryID:   result.SettlementEntry.ID,
+			CreatedBy:           arg.CreatedBy,
+		})
+		if err != nil {
+			return err
+		}
+
+		// update both accounts in the same order as TransferTx to avoid deadlock
+		if account.ID < settlement.AccountID {
+			result.Account, result.SettlementAccount, err = addMoney(ctx, q, account.ID, amount, settlement.AccountID, -amount)
+		} else {
+			result.SettlementAccount, result.Account, err = addMoney(ctx, q, settlement.AccountID, -amount, account.ID, amount)
+		}
+		if err != nil {
+			return err
+		}
+
+		if result.Account.Balance < 0 {
+			return ErrInsufficientBalance
+		}
+
+		err = createOutboxEvents(ctx, q, arg.Tasks, result)
+		if err != nil {
+			return err
+		}
+
+		return createAuditEvents(ctx, q, arg.Audit, result)
+	})
+	return result, err
+}
@@ -0,0 +1,47 @@
+package db
+
+import "context"
+
+type SetUserLockedTxParams struct {
+	SetUserLockedParams
+	// Audit returns the audit events of the change, they are appended within the transaction
+	Audit func(result SetUserLockedTxResult) []AuditRecord
+}
+
+type SetUserLockedTxResult struct {
+	User User
+	// PreviousUser is the user before the change
+	PreviousUser User
+	// RevokedSessions are the sessions revoked when the user is locked
+	RevokedSessions []Session
+}
+
+// SetUserLockedTx locks or unlocks the user. Locking the user also revokes all its sessions
+func (store *SQLStore) SetUserLockedTx(ctx context.Context, arg SetUserLockedTxParams) (SetUserLockedTxResult, error) {
+	var result SetUserLockedTxResult
+
+	err := store.execTx(ctx, "SetUserLockedTx", func(q *Queries) error {
+		var err error
+
+		result.PreviousUser, err = q.GetUserForUpdate(ctx, arg.Username)
+		if err != nil {
+			return err
+		}
+
+		result.User, err = q.SetUserLocked(ctx, arg.SetUserLockedParams)
+		if err != nil {
+			return err
+		}
+
+		result.RevokedSessions = []Session{}
+		if arg.IsLocked {
+			result.RevokedSessions, err = q.RevokeUserSessions(ctx, arg.Username)
+			if err != nil {
+				return err
+			}
+		}
+
+		return createAuditEvents(ctx, q, arg.Audit, result)
+	})
+	return result, err
+}
@@ -0,0 +1,153 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/juker1141/simplebank/requestid"
+	"github.com/juker1141/simplebank/tracing"
+)
+
+// OutboxTask is a worker task that is written to the outbox within a transaction.
+// It is only published after the transaction commits, so it is never sent for rolled back changes
+type OutboxTask struct {
+	TaskType  string
+	Payload   any
+	Queue     string
+	MaxRetry  int32
+	ProcessIn time.Duration
+}
+
+// createOutboxEvents writes the tasks emitted for the value to the outbox.
+// The payloads carry the request ID and the trace context of ctx, so the tasks can be tied to the request
+func createOutboxEvents[T any](ctx context.Context, q *Queries, tasks func(T) []OutboxTask, value T) error {
+	if tasks == nil {
+		return nil
+	}
+
+	for _, task := range tasks(value) {
+		payload, err := json.Marshal(task.Payload)
+		if err != nil {
+			return fmt.Errorf("failed to marshal task payload: %w", err)
+		}
+		payload = requestid.InjectPayload(ctx, payload)
+
+		_, err = q.CreateOutboxEvent(ctx, CreateOutboxEventParams{
+			TaskType:  task.TaskType,
+			Payload:   tracing.InjectPayload(ctx, payload),
+			Queue:     task.Queue,
+			MaxRetry:  task.MaxRetry,
+			ProcessAt: time.Now().Add(task.ProcessIn),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CreateOutboxEventsTx writes the tasks to the outbox, for the tasks that are emitted by another task
+func (store *SQLStore) CreateOutboxEventsTx(ctx context.Context, tasks []OutboxTask) error {
+	return store.execTx(ctx, "CreateOutboxEventsTx", func(q *Queries) error {
+		return createOutboxEvents(ctx, q, func(tasks []OutboxTask) []OutboxTask {
+			return tasks
+		}, tasks)
+	})
+}
+
+type PublishOutboxParams struct {
+	Limit int32
+	// Lease is how long the claimed events are reserved for the relay, other relays publish them once it is over
+	Lease time.Duration
+	// RetryIn is how long an event that fails to publish waits before it is published again
+	RetryIn time.Duration
+	// MaxAttempts is the number of failures after which an event is dead-lettered
+	MaxAttempts int32
+	Publish     func(event OutboxEvent) error
+}
+
+type PublishOutboxResult struct {
+	Published    int
+	Failed       int
+	DeadLettered int
+}
+
+// PublishOutbox claims a batch of unpublished outbox events and publishes them in order.
+// The events are claimed for the lease, so concurrent relays never publish the same event at the same time,
+// and they are published outside of any transaction, so a slow queue doesn't hold the rows locked.
+// An event that fails to publish is kept in the outbox with its error and retried later,
+// until it fails MaxAttempts times and is dead-lettered
+func (store *SQLStore) PublishOutbox(ctx context.Context, arg PublishOutboxParams) (PublishOutboxResult, error) {
+	var result PublishOutboxResult
+
+	events, err := store.ClaimOutboxEvents(ctx, ClaimOutboxEventsParams{
+		ClaimedUntil: time.Now().Add(arg.Lease),
+		BatchSize:    arg.Limit,
+	})
+	if err != nil {
+		return result, err
+	}
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].ID < events[j].ID
+	})
+
+	for _, event := range events {
+		if err := arg.Publish(event); err != nil {
+			result.Failed++
+			event, err = store.RecordOutboxEventError(ctx, RecordOutboxEventErrorParams{
+				ID:          event.ID,
+				LastError:   err.Error(),
+				RetryAt:     time.Now().Add(arg.RetryIn),
+				MaxAttempts: arg.MaxAttempts,
+			})
+			if err != nil {
+				return result, err
+			}
+			if event.IsDeadLettered {
+				result.DeadLettered++
+			}
+			continue
+		}
+
+		result.Published++
+		if _, err := store.MarkOutboxEventPublished(ctx, event.ID); err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+// ReplayOutboxTxParams selects the events to replay. ClaimedUntil holds the events back from the relay,
+// so the tasks published for them can be removed from the queue once the transaction is committed.
+// Otherwise the queue drops the tasks published again as duplicates
+type ReplayOutboxTxParams struct {
+	ReplayOutboxEventsParams
+	// Audit returns the audit events of the change, they are appended within the transaction
+	Audit func(result ReplayOutboxTxResult) []AuditRecord
+}
+
+type ReplayOutboxTxResult struct {
+	Events []OutboxEvent
+}
+
+// ReplayOutboxTx marks published or dead-lettered outbox events as unpublished, so the relay publishes them again
+// once their claim is over. It returns the replayed events, whose tasks must be removed from the queue before then
+func (store *SQLStore) ReplayOutboxTx(ctx context.Context, arg ReplayOutboxTxParams) (ReplayOutboxTxResult, error) {
+	var result ReplayOutboxTxResult
+
+	err := store.execTx(ctx, "ReplayOutboxTx", func(q *Queries) error {
+		var err error
+		result.Events, err = q.ReplayOutboxEvents(ctx, arg.ReplayOutboxEventsParams)
+		if err != nil {
+			return err
+		}
+
+		return createAuditEvents(ctx, q, arg.Audit, result)
+	})
+	return result, err
+}
@@ -0,0 +1,169 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Statuses of payment requests
+const (
+	PaymentRequestStatusPending  = "pending"
+	PaymentRequestStatusAccepted = "accepted"
+	PaymentRequestStatusDeclined = "declined"
+	// PaymentRequestStatusExpired is set by the scheduler on the pending requests past their expiry
+	PaymentRequestStatusExpired = "expired"
+)
+
+var (
+	ErrPaymentRequestNotPending = errors.New("payment request is not pending")
+	ErrPaymentRequestExpired    = errors.New("payment request is expired")
+)
+
+type CreatePaymentRequestTxParams struct {
+	CreatePaymentRequestParams
+	Tasks func(paymentRequest PaymentRequest) []OutboxTask
+}
+
+type CreatePaymentRequestTxResult struct {
+	PaymentRequest PaymentRequest
+}
+
+// CreatePaymentRequestTx creates a payment request and notifies the payer
+func (store *SQLStore) CreatePaymentRequestTx(ctx context.Context, arg CreatePaymentRequestTxParams) (CreatePaymentRequestTxResult, error) {
+	var result CreatePaymentRequestTxResult
+
+	err := store.execTx(ctx, "CreatePaymentRequestTx", func(q *Queries) error {
+		var err error
+
+		result.PaymentRequest, err = q.CreatePaymentRequest(ctx, arg.CreatePaymentRequestParams)
+		if err != nil {
+			return err
+		}
+
+		return createOutboxEvents(ctx, q, arg.Tasks, result.PaymentRequest)
+	})
+	return result, err
+}
+
+type AcceptPaymentRequestTxParams struct {
+	ID            int64
+	FromAccountID int64
+	Tasks         func(paymentRequest PaymentRequest) []OutboxTask
+	// TransferTasks returns the tasks to publish for the transfer that pays the request
+	TransferTasks func(result TransferTxResult) []OutboxTask
+	// Audit returns the audit events of the payment, they are appended within the transaction
+	Audit func(result AcceptPaymentRequestTxResult) []AuditRecord
+}
+
+type AcceptPaymentRequestTxResult struct {
+	PaymentRequest PaymentRequest
+	TransferTxResult
+}
+
+// AcceptPaymentRequestTx pays a pending payment request from the payer's account.
+// The transfer and the status change are made within a single database transaction,
+// so a request can never be paid twice
+func (store *SQLStore) AcceptPaymentRequestTx(ctx context.Context, arg AcceptPaymentRequestTxParams) (AcceptPaymentRequestTxResult, error) {
+	var result AcceptPaymentRequestTxResult
+
+	// the transfer is observed like the ones of TransferTx, unless the request can't be accepted
+	transferred := false
+	startTime := time.Now()
+	err := store.execTx(ctx, "AcceptPaymentRequestTx", func(q *Queries) error {
+		paymentRequest, err := lockPendingPaymentRequest(ctx, q, arg.ID)
+		if err != nil {
+			return err
+		}
+
+		transferred = true
+		result.TransferTxResult, err = transferTx(ctx, q, TransferTxParams{
+			FromAccountID: arg.FromAccountID,
+			ToAccountID:   paymentRequest.ToAccountID,
+			Amount:        paymentRequest.Amount,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = createOutboxEvents(ctx, q, arg.TransferTasks, result.TransferTxResult)
+		if err != nil {
+			return err
+		}
+
+		result.PaymentRequest, err = q.AcceptPaymentRequest(ctx, AcceptPaymentRequestParams{
+			ID: paymentRequest.ID,
+			FromAccountID: pgtype.Int8{
+				Int64: arg.FromAccountID,
+				Valid: true,
+			},
+			TransferID: pgtype.Int8{
+				Int64: result.Transfer.ID,
+				Valid: true,
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		err = createOutboxEvents(ctx, q, arg.Tasks, result.PaymentRequest)
+		if err != nil {
+			return err
+		}
+
+		return createAuditEvents(ctx, q, arg.Audit, result)
+	})
+
+	if transferred {
+		observeTransferTx(time.Since(startTime), err)
+	}
+	return result, err
+}
+
+type DeclinePaymentRequestTxParams struct {
+	ID    int64
+	Tasks func(paymentRequest PaymentRequest) []OutboxTask
+}
+
+type DeclinePaymentRequestTxResult struct {
+	PaymentRequest PaymentRequest
+}
+
+// DeclinePaymentRequestTx declines a pending payment request and notifies the payee
+func (store *SQLStore) DeclinePaymentRequestTx(ctx context.Context, arg DeclinePaymentRequestTxParams) (DeclinePaymentRequestTxResult, error) {
+	var result DeclinePaymentRequestTxResult
+
+	err := store.execTx(ctx, "DeclinePaymentRequestTx", func(q *Queries) error {
+		paymentRequest, err := lockPendingPaymentRequest(ctx, q, arg.ID)
+		if err != nil {
+			return err
+		}
+
+		result.PaymentRequest, err = q.DeclinePaymentRequest(ctx, paymentRequest.ID)
+		if err != nil {
+			return err
+		}
+
+		return createOutboxEvents(ctx, q, arg.Tasks, result.PaymentRequest)
+	})
+	return result, err
+}
+
+func lockPendingPaymentRequest(ctx context.Context, q *Queries, id int64) (PaymentRequest, error) {
+	paymentRequest, err := q.GetPaymentRequestForUpdate(ctx, id)
+	if err != nil {
+		return paymentRequest, err
+	}
+
+	if paymentRequest.Status != PaymentRequestStatusPending {
+		return paymentRequest, ErrPaymentRequestNotPending
+	}
+
+	if time.Now().After(paymentRequest.ExpiredAt) {
+		return paymentRequest, ErrPaymentRequestExpired
+	}
+
+	return paymentRequest, nil
+}
@@ -0,0 +1,43 @@
+package db
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+type RevokeSessionsTxParams struct {
+	// Username revokes all the active sessions of the user, when SessionID isn't set
+	Username string
+	SessionID uuid.UUID
+	// Audit returns the audit events of the change, they are appended within the transaction
+	Audit func(result RevokeSessionsTxResult) []AuditRecord
+}
+
+type RevokeSessionsTxResult struct {
+	Sessions []Session
+}
+
+// RevokeSessionsTx blocks a session or all the sessions of a user, their refresh tokens can't be used anymore
+func (store *SQLStore) RevokeSessionsTx(ctx context.Context, arg RevokeSessionsTxParams) (RevokeSessionsTxResult, error) {
+	var result RevokeSessionsTxResult
+
+	err := store.execTx(ctx, "RevokeSessionsTx", func(q *Queries) error {
+		if arg.SessionID != uuid.Nil {
+			session, err := q.RevokeSession(ctx, arg.SessionID)
+			if err != nil {
+				return err
+			}
+			result.Sessions = []Session{session}
+		} else {
+			var err error
+			result.Sessions, err = q.RevokeUserSessions(ctx, arg.Username)
+			if err != nil {
+				return err
+			}
+		}
+
+		return createAuditEvents(ctx, q, arg.Audit, result)
+	})
+	return result, err
+}
@@ -1,12 +1,19 @@
 package db
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // TransferTxParams contains the input parameters of the transfer transaction
 type TransferTxParams struct {
 	FromAccountID int64 `json:"from_account_id"`
 	ToAccountID 	int64 `json:"to_account_id"`
 	Amount 				int64 `json:"amount"`
+	// Tasks returns the tasks to publish once the transfer is committed
+	Tasks func(result TransferTxResult) []OutboxTask `json:"-"`
+	// Audit returns the audit events of the transfer, they are appended within the transaction
+	Audit func(result TransferTxResult) []AuditRecord `json:"-"`
 }
 
 // TransferTxResult is the result of the transfer transaction
@@ -16,55 +23,170 @@ type TransferTxResult struct {
 	ToAccount 	Account  `json:"to_account"`
 	FromEntry 	Entry    `json:"from_entry"`
 	ToEntry 		Entry    `json:"to_entry"`
+	Fees 				[]TransferFee `json:"fees"`
 }
 
 var txKey = struct{}{}
 
 // TransferTx performs a money transfer from one account to the other.
 // It creates a transfer record, add account entries,
-// and update accounts' balance within a single database transaction
+// update accounts' balance and charge the transfer fees within a single database transaction.
+// The tasks are written to the outbox in the same transaction, so they are never published for a rolled-back transfer
 func (store *SQLStore) TransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error) {
 	var result TransferTxResult
 
-	err := store.execTx(ctx, func(q *Queries) error {
+	startTime := time.Now()
+	err := store.execTx(ctx, "TransferTx", func(q *Queries) error {
 		var err error
-		
-		// create transfer
-		result.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams{
-			FromAccountID: arg.FromAccountID,
-			ToAccountID:   arg.ToAccountID,
-			Amount:        arg.Amount,
-		})
+
+		result, err = transferTx(ctx, q, arg)
 		if err != nil {
 			return err
 		}
 
-		// add account entries
-		result.FromEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-			AccountID: arg.FromAccountID,
-			Amount:    -arg.Amount,
-		})
+		err = createOutboxEvents(ctx, q, arg.Tasks, result)
 		if err != nil {
 			return err
 		}
 
-		result.ToEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-			AccountID: arg.ToAccountID,
-			Amount:    arg.Amount,
-		})
+		return createAuditEvents(ctx, q, arg.Audit, result)
+	})
+
+	observeTransferTx(time.Since(startTime), err)
+	return result, err
+}
+
+// transferTx moves the money and charges the fees using the queries of an open transaction
+func transferTx(ctx context.Context, q *Queries, arg TransferTxParams) (TransferTxResult, error) {
+	var result TransferTxResult
+
+	// the accounts are locked before the fees are quoted, so that the concurrent transfers
+	// from the same account wait for each other and count each other in the free transfers of the month
+	err := lockAccounts(ctx, q, arg.FromAccountID, arg.ToAccountID)
+	if err != nil {
+		return result, err
+	}
+
+	fees, err := quoteTransferFees(ctx, q, arg)
+	if err != nil {
+		return result, err
+	}
+	
+	// create transfer
+	result.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams{
+		FromAccountID: arg.FromAccountID,
+		ToAccountID:   arg.ToAccountID,
+		Amount:        arg.Amount,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	// add account entries
+	result.FromEntry, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID: arg.FromAccountID,
+		Amount:    -arg.Amount,
+		Type:      EntryTypeTransfer,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	result.ToEntry, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID: arg.ToAccountID,
+		Amount:    arg.Amount,
+		Type:      EntryTypeTransfer,
+	})
+	if err != nil {
+		return result, err
+	}
+	
+	if arg.FromAccountID < arg.ToAccountID {
+		result.FromAccount, result.ToAccount, err = addMoney(ctx, q, arg.FromAccountID, -arg.Amount, arg.ToAccountID, arg.Amount)
+	} else {
+		result.ToAccount, result.FromAccount, err = addMoney(ctx, q, arg.ToAccountID, arg.Amount, arg.FromAccountID, -arg.Amount)
+	}
+	if err != nil {
+		return result, err
+	}
+
+	result.Fees = []TransferFee{}
+	for _, fee := range fees {
+		transferFee, fromAccount, err := chargeFee(ctx, q, result.Transfer, fee)
 		if err != nil {
-			return err
-		}
-		
-		if arg.FromAccountID < arg.ToAccountID {
-			result.FromAccount, result.ToAccount, err = addMoney(ctx, q, arg.FromAccountID, -arg.Amount, arg.ToAccountID, arg.Amount)
-		} else {
-			result.ToAccount, result.FromAccount, err = addMoney(ctx, q, arg.ToAccountID, arg.Amount, arg.FromAccountID, -arg.Amount)
+			return result, err
 		}
 
-		return err
+		result.Fees = append(result.Fees, transferFee)
+		result.FromAccount = fromAccount
+	}
+
+	return result, nil
+}
+
+// chargeFee moves the fee from the sender's account to the bank revenue account
+func chargeFee(ctx context.Context, q *Queries, transfer Transfer, fee FeeQuote) (transferFee TransferFee, fromAccount Account, err error) {
+	fromEntry, err := q.CreateEntry(ctx, CreateEntryParams{
+		AccountID: transfer.FromAccountID,
+		Amount:    -fee.Amount,
+		Type:      EntryTypeFee,
 	})
-	return result, err
+	if err != nil {
+		return
+	}
+
+	toEntry, err := q.CreateEntry(ctx, CreateEntryParams{
+		AccountID: fee.RevenueAccountID,
+		Amount:    fee.Amount,
+		Type:      EntryTypeFee,
+	})
+	if err != nil {
+		return
+	}
+
+	transferFee, err = q.CreateTransferFee(ctx, CreateTransferFeeParams{
+		TransferID:       transfer.ID,
+		FeeRuleID:        fee.FeeRuleID,
+		AccountID:        transfer.FromAccountID,
+		RevenueAccountID: fee.RevenueAccountID,
+		Amount:           fee.Amount,
+		FromEntryID:      fromEntry.ID,
+		ToEntryID:        toEntry.ID,
+	})
+	if err != nil {
+		return
+	}
+
+	// the sender's account is already locked by the transfer,
+	// so the revenue account is always updated last
+	fromAccount, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
+		ID:     transfer.FromAccountID,
+		Amount: -fee.Amount,
+	})
+	if err != nil {
+		return
+	}
+
+	_, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
+		ID:     fee.RevenueAccountID,
+		Amount: fee.Amount,
+	})
+	return
+}
+
+// lockAccounts locks the accounts in the order of their IDs, like addMoney updates them, so that two transfers
+// between the same accounts in opposite directions don't deadlock
+func lockAccounts(ctx context.Context, q *Queries, accountID1 int64, accountID2 int64) error {
+	if accountID1 > accountID2 {
+		accountID1, accountID2 = accountID2, accountID1
+	}
+
+	for _, accountID := range []int64{accountID1, accountID2} {
+		if _, err := q.GetAccountForUpdate(ctx, accountID); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func addMoney(
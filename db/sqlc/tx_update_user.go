@@ -0,0 +1,76 @@
+package db
+
+import "context"
+
+type UpdateUserTxParams struct {
+	UpdateUserParams
+	// VerifySecretCode is the code of the verification of the new pending email,
+	// it is created with the change when it is set
+	VerifySecretCode string
+	// VerifyEmailLimit limits the verification emails sent for a new pending email
+	VerifyEmailLimit VerifyEmailLimit
+	Tasks            func(result UpdateUserTxResult) []OutboxTask
+	// Audit returns the audit events of the change, they are appended within the transaction
+	Audit func(result UpdateUserTxResult) []AuditRecord
+}
+
+type UpdateUserTxResult struct {
+	User User
+	// PreviousUser is the user before the update
+	PreviousUser User
+	// VerifyEmail is the verification of the new pending email
+	VerifyEmail VerifyEmail
+}
+
+// UpdateUserTx updates the user. A new pending email invalidates the verification codes sent before.
+// It returns a VerifyEmailLimitError if the verification email of the new pending email is over the limit
+func (store *SQLStore) UpdateUserTx(ctx context.Context, arg UpdateUserTxParams) (UpdateUserTxResult, error) {
+	var result UpdateUserTxResult
+
+	err := store.execTx(ctx, "UpdateUserTx", func(q *Queries) error {
+		var err error
+
+		result.PreviousUser, err = q.GetUserForUpdate(ctx, arg.Username)
+		if err != nil {
+			return err
+		}
+
+		if arg.PendingEmail.Valid && arg.VerifySecretCode != "" {
+			err = checkVerifyEmailLimit(ctx, q, arg.Username, arg.VerifyEmailLimit)
+			if err != nil {
+				return err
+			}
+		}
+
+		if arg.PendingEmail.Valid {
+			_, err = q.InvalidateVerifyEmails(ctx, arg.Username)
+			if err != nil {
+				return err
+			}
+		}
+
+		result.User, err = q.UpdateUser(ctx, arg.UpdateUserParams)
+		if err != nil {
+			return err
+		}
+
+		if arg.PendingEmail.Valid && arg.VerifySecretCode != "" {
+			result.VerifyEmail, err = q.CreateVerifyEmail(ctx, CreateVerifyEmailParams{
+				Username:   result.User.Username,
+				Email:      result.User.PendingEmail,
+				SecretCode: arg.VerifySecretCode,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		err = createOutboxEvents(ctx, q, arg.Tasks, result)
+		if err != nil {
+			return err
+		}
+
+		return createAuditEvents(ctx, q, arg.Audit, result)
+	})
+	return result, err
+}
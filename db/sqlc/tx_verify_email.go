@@ -2,24 +2,92 @@ package db
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+// ErrVerifyEmailOutdated is returned when the verified address is neither the email nor the pending email of the user
+var ErrVerifyEmailOutdated = errors.New("verify email is outdated")
+
+// VerifyEmailLimit limits the verification emails sent to a user. It is checked in the transaction
+// that creates the code once the user is locked, so concurrent requests can't all pass it
+type VerifyEmailLimit struct {
+	// ResendInterval is how long the user waits between two verification emails
+	ResendInterval time.Duration
+	// HourlyLimit is the number of verification emails per hour, zero disables it
+	HourlyLimit int
+}
+
+// VerifyEmailLimitError is returned when a verification email is requested over the limit
+type VerifyEmailLimitError struct {
+	// Wait is how long the user must wait before the next email, zero when the hourly limit is reached
+	Wait time.Duration
+}
+
+func (err *VerifyEmailLimitError) Error() string {
+	if err.Wait > 0 {
+		return fmt.Sprintf("please wait %s before requesting another verification email", err.Wait.Round(time.Second))
+	}
+	return "too many verification emails were requested, please try again later"
+}
+
+// checkVerifyEmailLimit returns a VerifyEmailLimitError if the user can't receive another verification email yet.
+// The user must be locked by the transaction
+func checkVerifyEmailLimit(ctx context.Context, q *Queries, username string, limit VerifyEmailLimit) error {
+	lastVerifyEmail, err := q.GetLastVerifyEmail(ctx, username)
+	if err != nil && !errors.Is(err, ErrRecordNotFound) {
+		return err
+	}
+
+	if err == nil {
+		wait := limit.ResendInterval - time.Since(lastVerifyEmail.CreatedAt)
+		if wait > 0 {
+			return &VerifyEmailLimitError{Wait: wait}
+		}
+	}
+
+	if limit.HourlyLimit <= 0 {
+		return nil
+	}
+
+	count, err := q.CountVerifyEmailsSince(ctx, CountVerifyEmailsSinceParams{
+		Username: username,
+		Since:    time.Now().Add(-time.Hour),
+	})
+	if err != nil {
+		return err
+	}
+
+	if count >= int64(limit.HourlyLimit) {
+		return &VerifyEmailLimitError{}
+	}
+	return nil
+}
+
 type VerifyEmailTxParams struct {
 	EmailID 	 int64
 	SecretCode string
+	Tasks      func(user User) []OutboxTask
+	// Audit returns the audit events of the change, they are appended within the transaction
+	Audit func(result VerifyEmailTxResult) []AuditRecord
 }
 
 type VerifyEmailTxResult struct {
 	User 				User
 	VerifyEmail VerifyEmail
+	// PreviousUser is the user before the email was verified
+	PreviousUser User
 }
 
+// VerifyEmailTx marks the email of the user as verified.
+// If the code was sent to the pending email, the pending email replaces the current one
 func (store *SQLStore) VerifyEmailTx(ctx context.Context, arg VerifyEmailTxParams) (VerifyEmailTxResult, error) {
 	var result VerifyEmailTxResult
 
-	err := store.execTx(ctx, func(q *Queries) error {
+	err := store.execTx(ctx, "VerifyEmailTx", func(q *Queries) error {
 		var err error
 
 		result.VerifyEmail, err = q.UpdateVerifyEmail(ctx, UpdateVerifyEmailParams{
@@ -30,15 +98,88 @@ func (store *SQLStore) VerifyEmailTx(ctx context.Context, arg VerifyEmailTxParam
 			return err
 		}
 
-		result.User, err = q.UpdateUser(ctx, UpdateUserParams{
-			Username: result.VerifyEmail.Username,
+		user, err := q.GetUser(ctx, result.VerifyEmail.Username)
+		if err != nil {
+			return err
+		}
+		result.PreviousUser = user
+
+		updateArg := UpdateUserParams{
+			Username: user.Username,
 			IsEmailVerified: pgtype.Bool{
 				Bool: true,
 				Valid: true,
 			},
-		})
+		}
 
-		return err
+		switch result.VerifyEmail.Email {
+		case user.PendingEmail:
+			updateArg.Email = pgtype.Text{
+				String: user.PendingEmail,
+				Valid: true,
+			}
+			updateArg.PendingEmail = pgtype.Text{
+				String: "",
+				Valid: true,
+			}
+		case user.Email:
+		default:
+			return ErrVerifyEmailOutdated
+		}
+
+		result.User, err = q.UpdateUser(ctx, updateArg)
+		if err != nil {
+			return err
+		}
+
+		err = createOutboxEvents(ctx, q, arg.Tasks, result.User)
+		if err != nil {
+			return err
+		}
+
+		return createAuditEvents(ctx, q, arg.Audit, result)
+	})
+	return result, err
+}
+
+type CreateVerifyEmailTxParams struct {
+	CreateVerifyEmailParams
+	Limit VerifyEmailLimit
+	Tasks func(verifyEmail VerifyEmail) []OutboxTask
+}
+
+type CreateVerifyEmailTxResult struct {
+	VerifyEmail VerifyEmail
+}
+
+// CreateVerifyEmailTx creates a new verification code for the user,
+// and invalidates the codes that were sent before and are not used yet.
+// It returns a VerifyEmailLimitError if the user is over the limit
+func (store *SQLStore) CreateVerifyEmailTx(ctx context.Context, arg CreateVerifyEmailTxParams) (CreateVerifyEmailTxResult, error) {
+	var result CreateVerifyEmailTxResult
+
+	err := store.execTx(ctx, "CreateVerifyEmailTx", func(q *Queries) error {
+		_, err := q.GetUserForUpdate(ctx, arg.Username)
+		if err != nil {
+			return err
+		}
+
+		err = checkVerifyEmailLimit(ctx, q, arg.Username, arg.Limit)
+		if err != nil {
+			return err
+		}
+
+		_, err = q.InvalidateVerifyEmails(ctx, arg.Username)
+		if err != nil {
+			return err
+		}
+
+		result.VerifyEmail, err = q.CreateVerifyEmail(ctx, arg.CreateVerifyEmailParams)
+		if err != nil {
+			return err
+		}
+
+		return createOutboxEvents(ctx, q, arg.Tasks, result.VerifyEmail)
 	})
 	return result, err
 }
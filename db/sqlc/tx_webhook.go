@@ -0,0 +1,78 @@
+package db
+
+import (
+	"context"
+	"errors"
+)
+
+// Statuses of webhook deliveries, the status is the result of the last attempt
+const (
+	WebhookDeliveryStatusPending   = "pending"
+	WebhookDeliveryStatusSucceeded = "succeeded"
+	WebhookDeliveryStatusFailed    = "failed"
+)
+
+type CreateWebhookDeliveriesTxParams struct {
+	Deliveries []CreateWebhookDeliveryParams
+	Tasks      func(delivery WebhookDelivery) []OutboxTask
+}
+
+type CreateWebhookDeliveriesTxResult struct {
+	Deliveries []WebhookDelivery
+}
+
+// CreateWebhookDeliveriesTx records the deliveries of an event to the subscribed endpoints,
+// and writes the tasks that deliver them to the outbox.
+// A delivery of the same event to the same endpoint is only created once, so the event can be dispatched again safely
+func (store *SQLStore) CreateWebhookDeliveriesTx(ctx context.Context, arg CreateWebhookDeliveriesTxParams) (CreateWebhookDeliveriesTxResult, error) {
+	var result CreateWebhookDeliveriesTxResult
+
+	err := store.execTx(ctx, "CreateWebhookDeliveriesTx", func(q *Queries) error {
+		for _, deliveryArg := range arg.Deliveries {
+			delivery, err := q.CreateWebhookDelivery(ctx, deliveryArg)
+			if err != nil {
+				if errors.Is(err, ErrRecordNotFound) {
+					continue
+				}
+				return err
+			}
+
+			result.Deliveries = append(result.Deliveries, delivery)
+
+			if err := createOutboxEvents(ctx, q, arg.Tasks, delivery); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return result, err
+}
+
+type ReplayWebhookDeliveryTxParams struct {
+	ID    int64
+	Tasks func(delivery WebhookDelivery) []OutboxTask
+}
+
+type ReplayWebhookDeliveryTxResult struct {
+	Delivery WebhookDelivery
+}
+
+// ReplayWebhookDeliveryTx sets the delivery back to pending and writes the task that delivers it again to the outbox
+func (store *SQLStore) ReplayWebhookDeliveryTx(ctx context.Context, arg ReplayWebhookDeliveryTxParams) (ReplayWebhookDeliveryTxResult, error) {
+	var result ReplayWebhookDeliveryTxResult
+
+	err := store.execTx(ctx, "ReplayWebhookDeliveryTx", func(q *Queries) error {
+		var err error
+
+		result.Delivery, err = q.UpdateWebhookDeliveryStatus(ctx, UpdateWebhookDeliveryStatusParams{
+			ID: arg.ID,
+			Status: WebhookDeliveryStatusPending,
+		})
+		if err != nil {
+			return err
+		}
+
+		return createOutboxEvents(ctx, q, arg.Tasks, result.Delivery)
+	})
+	return result, err
+}
@@ -19,7 +19,7 @@ INSERT INTO users (
   email
 ) VALUES (
   $1, $2, $3, $4
-) RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, is_email_verified
+) RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, is_email_verified, role, language, pending_email, is_locked
 `
 
 type CreateUserParams struct {
@@ -45,12 +45,64 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.PasswordChangedAt,
 		&i.CreatedAt,
 		&i.IsEmailVerified,
+		&i.Role,
+		&i.Language,
+		&i.PendingEmail,
+		&i.IsLocked,
+	)
+	return i, err
+}
+
+const createUserWithRole = `-- name: CreateUserWithRole :one
+INSERT INTO users (
+  username,
+  hashed_password,
+  full_name,
+  email,
+  role,
+  is_email_verified
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+) RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, is_email_verified, role, language, pending_email, is_locked
+`
+
+type CreateUserWithRoleParams struct {
+	Username        string `json:"username"`
+	HashedPassword  string `json:"hashed_password"`
+	FullName        string `json:"full_name"`
+	Email           string `json:"email"`
+	Role            string `json:"role"`
+	IsEmailVerified bool   `json:"is_email_verified"`
+}
+
+func (q *Queries) CreateUserWithRole(ctx context.Context, arg CreateUserWithRoleParams) (User, error) {
+	row := q.db.QueryRow(ctx, createUserWithRole,
+		arg.Username,
+		arg.HashedPassword,
+		arg.FullName,
+		arg.Email,
+		arg.Role,
+		arg.IsEmailVerified,
+	)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.IsEmailVerified,
+		&i.Role,
+		&i.Language,
+		&i.PendingEmail,
+		&i.IsLocked,
 	)
 	return i, err
 }
 
 const getUser = `-- name: GetUser :one
-SELECT username, hashed_password, full_name, email, password_changed_at, created_at, is_email_verified FROM users
+SELECT username, hashed_password, full_name, email, password_changed_at, created_at, is_email_verified, role, language, pending_email, is_locked FROM users
 WHERE username = $1 LIMIT 1
 `
 
@@ -65,6 +117,66 @@ func (q *Queries) GetUser(ctx context.Context, username string) (User, error) {
 		&i.PasswordChangedAt,
 		&i.CreatedAt,
 		&i.IsEmailVerified,
+		&i.Role,
+		&i.Language,
+		&i.PendingEmail,
+		&i.IsLocked,
+	)
+	return i, err
+}
+
+const getUserForUpdate = `-- name: GetUserForUpdate :one
+SELECT username, hashed_password, full_name, email, password_changed_at, created_at, is_email_verified, role, language, pending_email, is_locked FROM users
+WHERE username = $1 LIMIT 1
+FOR NO KEY UPDATE
+`
+
+func (q *Queries) GetUserForUpdate(ctx context.Context, username string) (User, error) {
+	row := q.db.QueryRow(ctx, getUserForUpdate, username)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.IsEmailVerified,
+		&i.Role,
+		&i.Language,
+		&i.PendingEmail,
+		&i.IsLocked,
+	)
+	return i, err
+}
+
+const setUserLocked = `-- name: SetUserLocked :one
+UPDATE users
+SET is_locked = $1
+WHERE username = $2
+RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, is_email_verified, role, language, pending_email, is_locked
+`
+
+type SetUserLockedParams struct {
+	IsLocked bool   `json:"is_locked"`
+	Username string `json:"username"`
+}
+
+func (q *Queries) SetUserLocked(ctx context.Context, arg SetUserLockedParams) (User, error) {
+	row := q.db.QueryRow(ctx, setUserLocked, arg.IsLocked, arg.Username)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.IsEmailVerified,
+		&i.Role,
+		&i.Language,
+		&i.PendingEmail,
+		&i.IsLocked,
 	)
 	return i, err
 }
@@ -76,10 +188,12 @@ SET
   password_changed_at = COALESCE($2, password_changed_at),
   full_name = COALESCE($3, full_name),
   email = COALESCE($4, email),
-  is_email_verified = COALESCE($5, is_email_verified)
+  is_email_verified = COALESCE($5, is_email_verified),
+  language = COALESCE($6, language),
+  pending_email = COALESCE($7, pending_email)
 WHERE
-  username = $6
-RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, is_email_verified
+  username = $8
+RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, is_email_verified, role, language, pending_email, is_locked
 `
 
 type UpdateUserParams struct {
@@ -88,6 +202,8 @@ type UpdateUserParams struct {
 	FullName          pgtype.Text        `json:"full_name"`
 	Email             pgtype.Text        `json:"email"`
 	IsEmailVerified   pgtype.Bool        `json:"is_email_verified"`
+	Language          pgtype.Text        `json:"language"`
+	PendingEmail      pgtype.Text        `json:"pending_email"`
 	Username          string             `json:"username"`
 }
 
@@ -98,6 +214,8 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		arg.FullName,
 		arg.Email,
 		arg.IsEmailVerified,
+		arg.Language,
+		arg.PendingEmail,
 		arg.Username,
 	)
 	var i User
@@ -109,6 +227,10 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		&i.PasswordChangedAt,
 		&i.CreatedAt,
 		&i.IsEmailVerified,
+		&i.Role,
+		&i.Language,
+		&i.PendingEmail,
+		&i.IsLocked,
 	)
 	return i, err
 }
@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/juker1141/simplebank/util"
 	"github.com/stretchr/testify/require"
@@ -94,6 +95,24 @@ func TestUpdateUserOnlyEmail(t *testing.T) {
 	require.Equal(t, oldUser.HashedPassword, updateUser.HashedPassword)
 }
 
+func TestUpdateUserOnlyLanguage(t *testing.T) {
+	oldUser := createRandomUser(t)
+	require.Equal(t, util.English, oldUser.Language)
+
+	updateUser, err := testStore.UpdateUser(context.Background(), UpdateUserParams{
+		Username: oldUser.Username,
+		Language: pgtype.Text{
+			String: util.TraditionalChinese,
+			Valid: true,
+		},
+	})
+
+	require.NoError(t, err)
+	require.Equal(t, util.TraditionalChinese, updateUser.Language)
+	require.Equal(t, oldUser.Email, updateUser.Email)
+	require.Equal(t, oldUser.FullName, updateUser.FullName)
+}
+
 func TestUpdateUserOnlyPassword(t *testing.T) {
 	oldUser := createRandomUser(t)
 
@@ -146,4 +165,57 @@ func TestUpdateUserAllFields(t *testing.T) {
 	require.Equal(t, newFullName, updateUser.FullName)
 	require.Equal(t, newEmail, updateUser.Email)
 	require.Equal(t, newHashedPassword, updateUser.HashedPassword)
+}
+
+func createRandomSession(t *testing.T, username string, expiresAt time.Time) Session {
+	session, err := testStore.CreateSession(context.Background(), CreateSessionParams{
+		ID: uuid.New(),
+		Username: username,
+		RefreshToken: util.RandomString(32),
+		UserAgent: "test",
+		ClientIp: "127.0.0.1",
+		ExpiresAt: expiresAt,
+	})
+	require.NoError(t, err)
+	return session
+}
+
+func TestSetUserLockedTx(t *testing.T) {
+	user := createRandomUser(t)
+	active1 := createRandomSession(t, user.Username, time.Now().Add(time.Hour))
+	active2 := createRandomSession(t, user.Username, time.Now().Add(time.Hour))
+	createRandomSession(t, user.Username, time.Now().Add(-time.Hour))
+
+	result, err := testStore.SetUserLockedTx(context.Background(), SetUserLockedTxParams{
+		SetUserLockedParams: SetUserLockedParams{
+			Username: user.Username,
+			IsLocked: true,
+		},
+	})
+	require.NoError(t, err)
+	require.False(t, result.PreviousUser.IsLocked)
+	require.True(t, result.User.IsLocked)
+
+	// only the active sessions are revoked
+	require.Len(t, result.RevokedSessions, 2)
+	revoked := []uuid.UUID{result.RevokedSessions[0].ID, result.RevokedSessions[1].ID}
+	require.ElementsMatch(t, []uuid.UUID{active1.ID, active2.ID}, revoked)
+	for _, session := range result.RevokedSessions {
+		require.True(t, session.IsBlocked)
+	}
+
+	result, err = testStore.SetUserLockedTx(context.Background(), SetUserLockedTxParams{
+		SetUserLockedParams: SetUserLockedParams{
+			Username: user.Username,
+			IsLocked: false,
+		},
+	})
+	require.NoError(t, err)
+	require.True(t, result.PreviousUser.IsLocked)
+	require.False(t, result.User.IsLocked)
+	require.Empty(t, result.RevokedSessions)
+
+	// a revoked session stays revoked when the user is unlocked
+	_, err = testStore.RevokeSessionsTx(context.Background(), RevokeSessionsTxParams{SessionID: active1.ID})
+	require.ErrorIs(t, err, ErrRecordNotFound)
 }
\ No newline at end of file
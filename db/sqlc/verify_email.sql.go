@@ -7,8 +7,26 @@ package db
 
 import (
 	"context"
+	"time"
 )
 
+const countVerifyEmailsSince = `-- name: CountVerifyEmailsSince :one
+SELECT count(*) FROM verify_emails
+WHERE username = $1 AND created_at >= $2
+`
+
+type CountVerifyEmailsSinceParams struct {
+	Username string    `json:"username"`
+	Since    time.Time `json:"since"`
+}
+
+func (q *Queries) CountVerifyEmailsSince(ctx context.Context, arg CountVerifyEmailsSinceParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countVerifyEmailsSince, arg.Username, arg.Since)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createVerifyEmail = `-- name: CreateVerifyEmail :one
 INSERT INTO verify_emails (
   username,
@@ -40,6 +58,81 @@ func (q *Queries) CreateVerifyEmail(ctx context.Context, arg CreateVerifyEmailPa
 	return i, err
 }
 
+const deleteStaleVerifyEmails = `-- name: DeleteStaleVerifyEmails :execrows
+DELETE FROM verify_emails
+WHERE
+  (is_used = TRUE OR expired_at < now())
+  AND created_at < $1
+`
+
+func (q *Queries) DeleteStaleVerifyEmails(ctx context.Context, before time.Time) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteStaleVerifyEmails, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const getLastVerifyEmail = `-- name: GetLastVerifyEmail :one
+SELECT id, username, email, secret_code, is_used, created_at, expired_at FROM verify_emails
+WHERE username = $1
+ORDER BY created_at DESC
+LIMIT 1
+`
+
+func (q *Queries) GetLastVerifyEmail(ctx context.Context, username string) (VerifyEmail, error) {
+	row := q.db.QueryRow(ctx, getLastVerifyEmail, username)
+	var i VerifyEmail
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Email,
+		&i.SecretCode,
+		&i.IsUsed,
+		&i.CreatedAt,
+		&i.ExpiredAt,
+	)
+	return i, err
+}
+
+const getVerifyEmail = `-- name: GetVerifyEmail :one
+SELECT id, username, email, secret_code, is_used, created_at, expired_at FROM verify_emails
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetVerifyEmail(ctx context.Context, id int64) (VerifyEmail, error) {
+	row := q.db.QueryRow(ctx, getVerifyEmail, id)
+	var i VerifyEmail
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Email,
+		&i.SecretCode,
+		&i.IsUsed,
+		&i.CreatedAt,
+		&i.ExpiredAt,
+	)
+	return i, err
+}
+
+const invalidateVerifyEmails = `-- name: InvalidateVerifyEmails :execrows
+UPDATE verify_emails
+SET
+  expired_at = now()
+WHERE
+  username = $1
+  AND is_used = FALSE
+  AND expired_at > now()
+`
+
+func (q *Queries) InvalidateVerifyEmails(ctx context.Context, username string) (int64, error) {
+	result, err := q.db.Exec(ctx, invalidateVerifyEmails, username)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const updateVerifyEmail = `-- name: UpdateVerifyEmail :one
 UPDATE verify_emails
 SET
@@ -0,0 +1,171 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/juker1141/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomVerifyEmail(t *testing.T, user User, email string) VerifyEmail {
+	result, err := testStore.CreateVerifyEmailTx(context.Background(), CreateVerifyEmailTxParams{
+		CreateVerifyEmailParams: CreateVerifyEmailParams{
+			Username: user.Username,
+			Email: email,
+			SecretCode: util.RandomString(32),
+		},
+	})
+	require.NoError(t, err)
+
+	verifyEmail := result.VerifyEmail
+	require.NotZero(t, verifyEmail.ID)
+	require.Equal(t, user.Username, verifyEmail.Username)
+	require.Equal(t, email, verifyEmail.Email)
+	require.False(t, verifyEmail.IsUsed)
+
+	return verifyEmail
+}
+
+func TestCreateVerifyEmailTxInvalidatesOldCodes(t *testing.T) {
+	user := createRandomUser(t)
+
+	oldVerifyEmail := createRandomVerifyEmail(t, user, user.Email)
+	newVerifyEmail := createRandomVerifyEmail(t, user, user.Email)
+
+	// the old code can't be used anymore
+	_, err := testStore.VerifyEmailTx(context.Background(), VerifyEmailTxParams{
+		EmailID: oldVerifyEmail.ID,
+		SecretCode: oldVerifyEmail.SecretCode,
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	lastVerifyEmail, err := testStore.GetLastVerifyEmail(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Equal(t, newVerifyEmail.ID, lastVerifyEmail.ID)
+
+	count, err := testStore.CountVerifyEmailsSince(context.Background(), CountVerifyEmailsSinceParams{
+		Username: user.Username,
+		Since: oldVerifyEmail.CreatedAt,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(2), count)
+
+	result, err := testStore.VerifyEmailTx(context.Background(), VerifyEmailTxParams{
+		EmailID: newVerifyEmail.ID,
+		SecretCode: newVerifyEmail.SecretCode,
+	})
+	require.NoError(t, err)
+	require.True(t, result.User.IsEmailVerified)
+	require.Equal(t, user.Email, result.User.Email)
+}
+
+func TestVerifyEmailTxPendingEmail(t *testing.T) {
+	user := createRandomUser(t)
+	newEmail := util.RandomEmail()
+
+	txResult, err := testStore.UpdateUserTx(context.Background(), UpdateUserTxParams{
+		UpdateUserParams: UpdateUserParams{
+			Username: user.Username,
+			PendingEmail: pgtype.Text{
+				String: newEmail,
+				Valid: true,
+			},
+		},
+		VerifySecretCode: util.RandomString(32),
+	})
+	require.NoError(t, err)
+	require.Equal(t, user.Email, txResult.User.Email)
+	require.Equal(t, newEmail, txResult.User.PendingEmail)
+
+	// the code of the new email is created with the change
+	verifyEmail := txResult.VerifyEmail
+	require.NotZero(t, verifyEmail.ID)
+	require.Equal(t, newEmail, verifyEmail.Email)
+
+	result, err := testStore.VerifyEmailTx(context.Background(), VerifyEmailTxParams{
+		EmailID: verifyEmail.ID,
+		SecretCode: verifyEmail.SecretCode,
+	})
+	require.NoError(t, err)
+	require.True(t, result.User.IsEmailVerified)
+	require.Equal(t, newEmail, result.User.Email)
+	require.Empty(t, result.User.PendingEmail)
+}
+
+func TestVerifyEmailTxOutdated(t *testing.T) {
+	user := createRandomUser(t)
+
+	// a code sent to an address the user doesn't use anymore
+	verifyEmail := createRandomVerifyEmail(t, user, util.RandomEmail())
+
+	_, err := testStore.VerifyEmailTx(context.Background(), VerifyEmailTxParams{
+		EmailID: verifyEmail.ID,
+		SecretCode: verifyEmail.SecretCode,
+	})
+	require.ErrorIs(t, err, ErrVerifyEmailOutdated)
+
+	user2, err := testStore.GetUser(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.False(t, user2.IsEmailVerified)
+	require.Equal(t, user.Email, user2.Email)
+}
+
+func TestCreateVerifyEmailTxLimit(t *testing.T) {
+	user := createRandomUser(t)
+	limit := VerifyEmailLimit{
+		ResendInterval: time.Minute,
+		HourlyLimit: 5,
+	}
+
+	// the requests are sent at the same time, only the first one that locks the user sends a code
+	n := 5
+	errs := make(chan error)
+	for i := 0; i < n; i++ {
+		go func() {
+			_, err := testStore.CreateVerifyEmailTx(context.Background(), CreateVerifyEmailTxParams{
+				CreateVerifyEmailParams: CreateVerifyEmailParams{
+					Username: user.Username,
+					Email: user.Email,
+					SecretCode: util.RandomString(32),
+				},
+				Limit: limit,
+			})
+			errs <- err
+		}()
+	}
+
+	sent := 0
+	for i := 0; i < n; i++ {
+		err := <-errs
+		if err == nil {
+			sent++
+			continue
+		}
+
+		var limitErr *VerifyEmailLimitError
+		require.ErrorAs(t, err, &limitErr)
+		require.Positive(t, limitErr.Wait)
+	}
+	require.Equal(t, 1, sent)
+
+	// without the resend interval, the hourly limit stops the requests
+	limit.ResendInterval = 0
+	for i := 1; i < limit.HourlyLimit; i++ {
+		createRandomVerifyEmail(t, user, user.Email)
+	}
+
+	_, err := testStore.CreateVerifyEmailTx(context.Background(), CreateVerifyEmailTxParams{
+		CreateVerifyEmailParams: CreateVerifyEmailParams{
+			Username: user.Username,
+			Email: user.Email,
+			SecretCode: util.RandomString(32),
+		},
+		Limit: limit,
+	})
+	var limitErr *VerifyEmailLimitError
+	require.ErrorAs(t, err, &limitErr)
+	require.Zero(t, limitErr.Wait)
+}
\ No newline at end of file
@@ -0,0 +1,416 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.18.0
+// source: webhook.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const createWebhookDelivery = `-- name: CreateWebhookDelivery :one
+INSERT INTO webhook_deliveries (
+  endpoint_id,
+  event_id,
+  event_type,
+  payload
+) VALUES (
+  $1, $2, $3, $4
+) ON CONFLICT (endpoint_id, event_id) DO NOTHING
+RETURNING id, endpoint_id, event_id, event_type, payload, status, attempts, delivered_at, created_at
+`
+
+type CreateWebhookDeliveryParams struct {
+	EndpointID int64  `json:"endpoint_id"`
+	EventID    string `json:"event_id"`
+	EventType  string `json:"event_type"`
+	Payload    []byte `json:"payload"`
+}
+
+func (q *Queries) CreateWebhookDelivery(ctx context.Context, arg CreateWebhookDeliveryParams) (WebhookDelivery, error) {
+	row := q.db.QueryRow(ctx, createWebhookDelivery,
+		arg.EndpointID,
+		arg.EventID,
+		arg.EventType,
+		arg.Payload,
+	)
+	var i WebhookDelivery
+	err := row.Scan(
+		&i.ID,
+		&i.EndpointID,
+		&i.EventID,
+		&i.EventType,
+		&i.Payload,
+		&i.Status,
+		&i.Attempts,
+		&i.DeliveredAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createWebhookDeliveryAttempt = `-- name: CreateWebhookDeliveryAttempt :one
+INSERT INTO webhook_delivery_attempts (
+  delivery_id,
+  response_status,
+  error,
+  duration_ms
+) VALUES (
+  $1, $2, $3, $4
+) RETURNING id, delivery_id, response_status, error, duration_ms, created_at
+`
+
+type CreateWebhookDeliveryAttemptParams struct {
+	DeliveryID     int64  `json:"delivery_id"`
+	ResponseStatus int32  `json:"response_status"`
+	Error          string `json:"error"`
+	DurationMs     int64  `json:"duration_ms"`
+}
+
+func (q *Queries) CreateWebhookDeliveryAttempt(ctx context.Context, arg CreateWebhookDeliveryAttemptParams) (WebhookDeliveryAttempt, error) {
+	row := q.db.QueryRow(ctx, createWebhookDeliveryAttempt,
+		arg.DeliveryID,
+		arg.ResponseStatus,
+		arg.Error,
+		arg.DurationMs,
+	)
+	var i WebhookDeliveryAttempt
+	err := row.Scan(
+		&i.ID,
+		&i.DeliveryID,
+		&i.ResponseStatus,
+		&i.Error,
+		&i.DurationMs,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createWebhookEndpoint = `-- name: CreateWebhookEndpoint :one
+INSERT INTO webhook_endpoints (
+  owner,
+  api_key_id,
+  url,
+  secret,
+  events,
+  created_by
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+) RETURNING id, owner, url, secret, events, is_active, created_by, created_at, api_key_id
+`
+
+type CreateWebhookEndpointParams struct {
+	Owner     pgtype.Text `json:"owner"`
+	ApiKeyID  pgtype.Int8 `json:"api_key_id"`
+	Url       string      `json:"url"`
+	Secret    string      `json:"secret"`
+	Events    []string    `json:"events"`
+	CreatedBy string      `json:"created_by"`
+}
+
+func (q *Queries) CreateWebhookEndpoint(ctx context.Context, arg CreateWebhookEndpointParams) (WebhookEndpoint, error) {
+	row := q.db.QueryRow(ctx, createWebhookEndpoint,
+		arg.Owner,
+		arg.ApiKeyID,
+		arg.Url,
+		arg.Secret,
+		arg.Events,
+		arg.CreatedBy,
+	)
+	var i WebhookEndpoint
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Url,
+		&i.Secret,
+		&i.Events,
+		&i.IsActive,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.ApiKeyID,
+	)
+	return i, err
+}
+
+const deactivateWebhookEndpoint = `-- name: DeactivateWebhookEndpoint :one
+UPDATE webhook_endpoints
+SET is_active = FALSE
+WHERE id = $1
+RETURNING id, owner, url, secret, events, is_active, created_by, created_at, api_key_id
+`
+
+func (q *Queries) DeactivateWebhookEndpoint(ctx context.Context, id int64) (WebhookEndpoint, error) {
+	row := q.db.QueryRow(ctx, deactivateWebhookEndpoint, id)
+	var i WebhookEndpoint
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Url,
+		&i.Secret,
+		&i.Events,
+		&i.IsActive,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.ApiKeyID,
+	)
+	return i, err
+}
+
+const getWebhookDelivery = `-- name: GetWebhookDelivery :one
+SELECT id, endpoint_id, event_id, event_type, payload, status, attempts, delivered_at, created_at FROM webhook_deliveries
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetWebhookDelivery(ctx context.Context, id int64) (WebhookDelivery, error) {
+	row := q.db.QueryRow(ctx, getWebhookDelivery, id)
+	var i WebhookDelivery
+	err := row.Scan(
+		&i.ID,
+		&i.EndpointID,
+		&i.EventID,
+		&i.EventType,
+		&i.Payload,
+		&i.Status,
+		&i.Attempts,
+		&i.DeliveredAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getWebhookEndpoint = `-- name: GetWebhookEndpoint :one
+SELECT id, owner, url, secret, events, is_active, created_by, created_at, api_key_id FROM webhook_endpoints
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetWebhookEndpoint(ctx context.Context, id int64) (WebhookEndpoint, error) {
+	row := q.db.QueryRow(ctx, getWebhookEndpoint, id)
+	var i WebhookEndpoint
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Url,
+		&i.Secret,
+		&i.Events,
+		&i.IsActive,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.ApiKeyID,
+	)
+	return i, err
+}
+
+const listWebhookDeliveries = `-- name: ListWebhookDeliveries :many
+SELECT id, endpoint_id, event_id, event_type, payload, status, attempts, delivered_at, created_at FROM webhook_deliveries
+WHERE endpoint_id = $1
+ORDER BY id DESC
+LIMIT $2
+OFFSET $3
+`
+
+type ListWebhookDeliveriesParams struct {
+	EndpointID int64 `json:"endpoint_id"`
+	Limit      int32 `json:"limit"`
+	Offset     int32 `json:"offset"`
+}
+
+func (q *Queries) ListWebhookDeliveries(ctx context.Context, arg ListWebhookDeliveriesParams) ([]WebhookDelivery, error) {
+	rows, err := q.db.Query(ctx, listWebhookDeliveries, arg.EndpointID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []WebhookDelivery{}
+	for rows.Next() {
+		var i WebhookDelivery
+		if err := rows.Scan(
+			&i.ID,
+			&i.EndpointID,
+			&i.EventID,
+			&i.EventType,
+			&i.Payload,
+			&i.Status,
+			&i.Attempts,
+			&i.DeliveredAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWebhookDeliveryAttempts = `-- name: ListWebhookDeliveryAttempts :many
+SELECT id, delivery_id, response_status, error, duration_ms, created_at FROM webhook_delivery_attempts
+WHERE delivery_id = $1
+ORDER BY id
+`
+
+func (q *Queries) ListWebhookDeliveryAttempts(ctx context.Context, deliveryID int64) ([]WebhookDeliveryAttempt, error) {
+	rows, err := q.db.Query(ctx, listWebhookDeliveryAttempts, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []WebhookDeliveryAttempt{}
+	for rows.Next() {
+		var i WebhookDeliveryAttempt
+		if err := rows.Scan(
+			&i.ID,
+			&i.DeliveryID,
+			&i.ResponseStatus,
+			&i.Error,
+			&i.DurationMs,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWebhookEndpoints = `-- name: ListWebhookEndpoints :many
+SELECT id, owner, url, secret, events, is_active, created_by, created_at, api_key_id FROM webhook_endpoints
+WHERE
+  owner IS NOT DISTINCT FROM $1::varchar
+  AND api_key_id IS NOT DISTINCT FROM $2::bigint
+  AND is_active = TRUE
+ORDER BY id
+`
+
+type ListWebhookEndpointsParams struct {
+	Owner    pgtype.Text `json:"owner"`
+	ApiKeyID pgtype.Int8 `json:"api_key_id"`
+}
+
+func (q *Queries) ListWebhookEndpoints(ctx context.Context, arg ListWebhookEndpointsParams) ([]WebhookEndpoint, error) {
+	rows, err := q.db.Query(ctx, listWebhookEndpoints, arg.Owner, arg.ApiKeyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []WebhookEndpoint{}
+	for rows.Next() {
+		var i WebhookEndpoint
+		if err := rows.Scan(
+			&i.ID,
+			&i.Owner,
+			&i.Url,
+			&i.Secret,
+			&i.Events,
+			&i.IsActive,
+			&i.CreatedBy,
+			&i.CreatedAt,
+			&i.ApiKeyID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWebhookEndpointsForEvent = `-- name: ListWebhookEndpointsForEvent :many
+SELECT id, owner, url, secret, events, is_active, created_by, created_at, api_key_id FROM webhook_endpoints
+WHERE
+  is_active = TRUE
+  AND $1::varchar = ANY(events)
+  AND (
+    owner = ANY($2::varchar[])
+    -- the endpoints of an API key receive every event while the key can see them
+    OR api_key_id IN (
+      SELECT id FROM api_keys
+      WHERE is_revoked = FALSE AND $3::varchar = ANY(scopes)
+    )
+  )
+ORDER BY id
+`
+
+type ListWebhookEndpointsForEventParams struct {
+	EventType   string   `json:"event_type"`
+	Usernames   []string `json:"usernames"`
+	ApiKeyScope string   `json:"api_key_scope"`
+}
+
+func (q *Queries) ListWebhookEndpointsForEvent(ctx context.Context, arg ListWebhookEndpointsForEventParams) ([]WebhookEndpoint, error) {
+	rows, err := q.db.Query(ctx, listWebhookEndpointsForEvent, arg.EventType, arg.Usernames, arg.ApiKeyScope)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []WebhookEndpoint{}
+	for rows.Next() {
+		var i WebhookEndpoint
+		if err := rows.Scan(
+			&i.ID,
+			&i.Owner,
+			&i.Url,
+			&i.Secret,
+			&i.Events,
+			&i.IsActive,
+			&i.CreatedBy,
+			&i.CreatedAt,
+			&i.ApiKeyID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateWebhookDeliveryStatus = `-- name: UpdateWebhookDeliveryStatus :one
+UPDATE webhook_deliveries
+SET
+  status = $1,
+  attempts = attempts + $2::int,
+  delivered_at = COALESCE($3, delivered_at)
+WHERE id = $4
+RETURNING id, endpoint_id, event_id, event_type, payload, status, attempts, delivered_at, created_at
+`
+
+type UpdateWebhookDeliveryStatusParams struct {
+	Status      string             `json:"status"`
+	Attempted   int32              `json:"attempted"`
+	DeliveredAt pgtype.Timestamptz `json:"delivered_at"`
+	ID          int64              `json:"id"`
+}
+
+func (q *Queries) UpdateWebhookDeliveryStatus(ctx context.Context, arg UpdateWebhookDeliveryStatusParams) (WebhookDelivery, error) {
+	row := q.db.QueryRow(ctx, updateWebhookDeliveryStatus,
+		arg.Status,
+		arg.Attempted,
+		arg.DeliveredAt,
+		arg.ID,
+	)
+	var i WebhookDelivery
+	err := row.Scan(
+		&i.ID,
+		&i.EndpointID,
+		&i.EventID,
+		&i.EventType,
+		&i.Payload,
+		&i.Status,
+		&i.Attempts,
+		&i.DeliveredAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
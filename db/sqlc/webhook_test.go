@@ -0,0 +1,211 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/juker1141/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomWebhookEndpoint(t *testing.T, owner pgtype.Text, events ...string) WebhookEndpoint {
+	return createTestWebhookEndpoint(t, CreateWebhookEndpointParams{Owner: owner, Events: events})
+}
+
+func createRandomAPIKey(t *testing.T, scopes ...string) ApiKey {
+	apiKey, err := testStore.CreateAPIKey(context.Background(), CreateAPIKeyParams{
+		Name: util.RandomOwner(),
+		HashedKey: util.HashAPIKey(util.RandomString(32)),
+		Scopes: scopes,
+	})
+	require.NoError(t, err)
+	return apiKey
+}
+
+func createTestWebhookEndpoint(t *testing.T, arg CreateWebhookEndpointParams) WebhookEndpoint {
+	arg.Url = "https://example.com/" + util.RandomString(6)
+	arg.Secret = util.RandomString(32)
+	arg.CreatedBy = "test"
+
+	endpoint, err := testStore.CreateWebhookEndpoint(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, endpoint.ID)
+	require.Equal(t, arg.Owner, endpoint.Owner)
+	require.Equal(t, arg.ApiKeyID, endpoint.ApiKeyID)
+	require.Equal(t, arg.Url, endpoint.Url)
+	require.Equal(t, arg.Events, endpoint.Events)
+	require.True(t, endpoint.IsActive)
+
+	return endpoint
+}
+
+func TestListWebhookEndpointsForEvent(t *testing.T) {
+	user1 := createRandomUser(t)
+	user2 := createRandomUser(t)
+
+	owner1 := pgtype.Text{String: user1.Username, Valid: true}
+	owner2 := pgtype.Text{String: user2.Username, Valid: true}
+
+	endpoint1 := createRandomWebhookEndpoint(t, owner1, util.WebhookEventTransferCreated)
+	endpoint2 := createRandomWebhookEndpoint(t, owner2, util.WebhookEventTransferCreated)
+	endpoint3 := createRandomWebhookEndpoint(t, owner1, util.WebhookEventUserVerified)
+
+	// the endpoints of an API key only receive the events of every user with the webhooks scope
+	apiKey := createRandomAPIKey(t, util.APIKeyScopeWebhooks)
+	endpoint4 := createTestWebhookEndpoint(t, CreateWebhookEndpointParams{
+		ApiKeyID: pgtype.Int8{Int64: apiKey.ID, Valid: true},
+		Events: []string{util.WebhookEventTransferCreated},
+	})
+	unscopedKey := createRandomAPIKey(t)
+	endpoint5 := createTestWebhookEndpoint(t, CreateWebhookEndpointParams{
+		ApiKeyID: pgtype.Int8{Int64: unscopedKey.ID, Valid: true},
+		Events: []string{util.WebhookEventTransferCreated},
+	})
+
+	endpoints, err := testStore.ListWebhookEndpointsForEvent(context.Background(), ListWebhookEndpointsForEventParams{
+		EventType: util.WebhookEventTransferCreated,
+		Usernames: []string{user1.Username},
+		ApiKeyScope: util.APIKeyScopeWebhooks,
+	})
+	require.NoError(t, err)
+
+	ids := make([]int64, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		ids = append(ids, endpoint.ID)
+	}
+	require.Contains(t, ids, endpoint1.ID)
+	require.Contains(t, ids, endpoint4.ID)
+	require.NotContains(t, ids, endpoint2.ID)
+	require.NotContains(t, ids, endpoint3.ID)
+	require.NotContains(t, ids, endpoint5.ID)
+
+	// deactivated endpoints don't receive events anymore
+	_, err = testStore.DeactivateWebhookEndpoint(context.Background(), endpoint1.ID)
+	require.NoError(t, err)
+
+	endpoints, err = testStore.ListWebhookEndpoints(context.Background(), ListWebhookEndpointsParams{Owner: owner1})
+	require.NoError(t, err)
+	require.Len(t, endpoints, 1)
+	require.Equal(t, endpoint3.ID, endpoints[0].ID)
+
+	// each API key only lists its own endpoints
+	endpoints, err = testStore.ListWebhookEndpoints(context.Background(), ListWebhookEndpointsParams{ApiKeyID: endpoint4.ApiKeyID})
+	require.NoError(t, err)
+	require.Len(t, endpoints, 1)
+	require.Equal(t, endpoint4.ID, endpoints[0].ID)
+}
+
+func TestCreateWebhookDeliveriesTx(t *testing.T) {
+	user := createRandomUser(t)
+	owner := pgtype.Text{String: user.Username, Valid: true}
+
+	endpoint1 := createRandomWebhookEndpoint(t, owner, util.WebhookEventAccountUpdated)
+	endpoint2 := createRandomWebhookEndpoint(t, owner, util.WebhookEventAccountUpdated)
+
+	eventID := uuid.NewString()
+	arg := CreateWebhookDeliveriesTxParams{
+		Tasks: func(delivery WebhookDelivery) []OutboxTask {
+			return []OutboxTask{{TaskType: "task:test", Payload: delivery.ID}}
+		},
+	}
+	for _, endpoint := range []WebhookEndpoint{endpoint1, endpoint2} {
+		arg.Deliveries = append(arg.Deliveries, CreateWebhookDeliveryParams{
+			EndpointID: endpoint.ID,
+			EventID: eventID,
+			EventType: util.WebhookEventAccountUpdated,
+			Payload: []byte(`{"id":"` + eventID + `"}`),
+		})
+	}
+
+	result, err := testStore.CreateWebhookDeliveriesTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, result.Deliveries, 2)
+	for _, delivery := range result.Deliveries {
+		require.Equal(t, WebhookDeliveryStatusPending, delivery.Status)
+		require.Zero(t, delivery.Attempts)
+		require.True(t, delivery.DeliveredAt.IsZero())
+	}
+
+	// dispatching the same event again doesn't create the deliveries twice
+	result, err = testStore.CreateWebhookDeliveriesTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Empty(t, result.Deliveries)
+
+	deliveries, err := testStore.ListWebhookDeliveries(context.Background(), ListWebhookDeliveriesParams{
+		EndpointID: endpoint1.ID,
+		Limit: 5,
+		Offset: 0,
+	})
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	require.Equal(t, eventID, deliveries[0].EventID)
+}
+
+func TestWebhookDeliveryAttempts(t *testing.T) {
+	endpoint := createRandomWebhookEndpoint(t, pgtype.Text{}, util.WebhookEventUserVerified)
+
+	result, err := testStore.CreateWebhookDeliveriesTx(context.Background(), CreateWebhookDeliveriesTxParams{
+		Deliveries: []CreateWebhookDeliveryParams{
+			{
+				EndpointID: endpoint.ID,
+				EventID: uuid.NewString(),
+				EventType: util.WebhookEventUserVerified,
+				Payload: []byte(`{}`),
+			},
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, result.Deliveries, 1)
+	delivery := result.Deliveries[0]
+
+	_, err = testStore.CreateWebhookDeliveryAttempt(context.Background(), CreateWebhookDeliveryAttemptParams{
+		DeliveryID: delivery.ID,
+		Error: "connection refused",
+		DurationMs: 3,
+	})
+	require.NoError(t, err)
+
+	delivery, err = testStore.UpdateWebhookDeliveryStatus(context.Background(), UpdateWebhookDeliveryStatusParams{
+		ID: delivery.ID,
+		Status: WebhookDeliveryStatusFailed,
+		Attempted: 1,
+	})
+	require.NoError(t, err)
+	require.Equal(t, WebhookDeliveryStatusFailed, delivery.Status)
+	require.Equal(t, int32(1), delivery.Attempts)
+
+	// a replay sets the delivery back to pending, without counting an attempt
+	replayed, err := testStore.ReplayWebhookDeliveryTx(context.Background(), ReplayWebhookDeliveryTxParams{
+		ID: delivery.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, WebhookDeliveryStatusPending, replayed.Delivery.Status)
+	require.Equal(t, int32(1), replayed.Delivery.Attempts)
+
+	_, err = testStore.CreateWebhookDeliveryAttempt(context.Background(), CreateWebhookDeliveryAttemptParams{
+		DeliveryID: delivery.ID,
+		ResponseStatus: 200,
+		DurationMs: 12,
+	})
+	require.NoError(t, err)
+
+	delivery, err = testStore.UpdateWebhookDeliveryStatus(context.Background(), UpdateWebhookDeliveryStatusParams{
+		ID: delivery.ID,
+		Status: WebhookDeliveryStatusSucceeded,
+		Attempted: 1,
+		DeliveredAt: pgtype.Timestamptz{Time: time.Now(), Valid: true},
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(2), delivery.Attempts)
+	require.WithinDuration(t, time.Now(), delivery.DeliveredAt, time.Second)
+
+	attempts, err := testStore.ListWebhookDeliveryAttempts(context.Background(), delivery.ID)
+	require.NoError(t, err)
+	require.Len(t, attempts, 2)
+	require.Equal(t, "connection refused", attempts[0].Error)
+	require.Zero(t, attempts[0].ResponseStatus)
+	require.Equal(t, int32(200), attempts[1].ResponseStatus)
+}
@@ -7,7 +7,11 @@ CREATE TABLE "users" (
   "hashed_password" varchar NOT NULL,
   "full_name" varchar NOT NULL,
   "email" varchar UNIQUE NOT NULL,
+  "pending_email" varchar NOT NULL DEFAULT '',
   "is_email_verified" bool NOT NULL DEFAULT false,
+  "role" varchar NOT NULL DEFAULT 'depositor',
+  "language" varchar NOT NULL DEFAULT 'en',
+  "is_locked" bool NOT NULL DEFAULT false,
   "password_changed_at" timestamptz NOT NULL DEFAULT '0001-01-01 00:00:00Z',
   "created_at" timestamptz NOT NULL DEFAULT (now())
 );
@@ -34,6 +38,7 @@ CREATE TABLE "entries" (
   "id" bigserial PRIMARY KEY,
   "account_id" bigint NOT NULL,
   "amount" bigint NOT NULL,
+  "type" varchar NOT NULL DEFAULT 'transfer',
   "created_at" timestamptz NOT NULL DEFAULT (now())
 );
 
@@ -56,9 +61,201 @@ CREATE TABLE "sessions" (
   "created_at" timestamptz NOT NULL DEFAULT (now())
 );
 
-CREATE INDEX ON "accounts" ("owner");
+CREATE TABLE "fee_rules" (
+  "id" bigserial PRIMARY KEY,
+  "name" varchar NOT NULL,
+  "fee_type" varchar NOT NULL,
+  "currency" varchar NOT NULL,
+  "flat_amount" bigint NOT NULL DEFAULT 0,
+  "basis_points" bigint NOT NULL DEFAULT 0,
+  "min_transfer_amount" bigint NOT NULL DEFAULT 0,
+  "free_transfers_per_month" bigint NOT NULL DEFAULT 0,
+  "revenue_account_id" bigint NOT NULL,
+  "is_active" boolean NOT NULL DEFAULT true,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "transfer_fees" (
+  "id" bigserial PRIMARY KEY,
+  "transfer_id" bigint NOT NULL,
+  "fee_rule_id" bigint NOT NULL,
+  "account_id" bigint NOT NULL,
+  "revenue_account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "from_entry_id" bigint NOT NULL,
+  "to_entry_id" bigint NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "api_keys" (
+  "id" bigserial PRIMARY KEY,
+  "name" varchar NOT NULL,
+  "hashed_key" varchar UNIQUE NOT NULL,
+  "is_revoked" boolean NOT NULL DEFAULT false,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "scopes" varchar[] NOT NULL DEFAULT '{}'
+);
+
+CREATE TABLE "settlement_accounts" (
+  "currency" varchar PRIMARY KEY,
+  "account_id" bigint UNIQUE NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "external_transfers" (
+  "id" bigserial PRIMARY KEY,
+  "type" varchar NOT NULL,
+  "account_id" bigint NOT NULL,
+  "settlement_account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "currency" varchar NOT NULL,
+  "external_reference" varchar NOT NULL,
+  "entry_id" bigint NOT NULL,
+  "settlement_entry_id" bigint NOT NULL,
+  "created_by" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "beneficiaries" (
+  "id" bigserial PRIMARY KEY,
+  "owner" varchar NOT NULL,
+  "nickname" varchar NOT NULL,
+  "account_id" bigint NOT NULL,
+  "currency" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "account_members" (
+  "account_id" bigint NOT NULL,
+  "username" varchar NOT NULL,
+  "role" varchar NOT NULL,
+  "invited_by" varchar NOT NULL,
+  "is_accepted" bool NOT NULL DEFAULT false,
+  "accepted_at" timestamptz NOT NULL DEFAULT '0001-01-01 00:00:00Z',
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("account_id", "username")
+);
+
+CREATE TABLE "payment_requests" (
+  "id" bigserial PRIMARY KEY,
+  "payee" varchar NOT NULL,
+  "payer" varchar NOT NULL,
+  "to_account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "currency" varchar NOT NULL,
+  "memo" varchar NOT NULL DEFAULT '',
+  "status" varchar NOT NULL DEFAULT 'pending',
+  "from_account_id" bigint,
+  "transfer_id" bigint,
+  "expired_at" timestamptz NOT NULL,
+  "responded_at" timestamptz NOT NULL DEFAULT '0001-01-01 00:00:00Z',
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "outbox_events" (
+  "id" bigserial PRIMARY KEY,
+  "task_type" varchar NOT NULL,
+  "payload" jsonb NOT NULL,
+  "queue" varchar NOT NULL,
+  "max_retry" int NOT NULL,
+  "process_at" timestamptz NOT NULL DEFAULT (now()),
+  "attempts" int NOT NULL DEFAULT 0,
+  "last_error" varchar NOT NULL DEFAULT '',
+  "is_published" bool NOT NULL DEFAULT false,
+  "published_at" timestamptz NOT NULL DEFAULT '0001-01-01 00:00:00Z',
+  "claimed_until" timestamptz NOT NULL DEFAULT '0001-01-01 00:00:00Z',
+  "is_dead_lettered" bool NOT NULL DEFAULT false,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "notification_preferences" (
+  "username" varchar NOT NULL,
+  "event_type" varchar NOT NULL,
+  "channel" varchar NOT NULL,
+  "is_enabled" bool NOT NULL DEFAULT true,
+  "min_amount" bigint NOT NULL DEFAULT 0,
+  "updated_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("username", "event_type", "channel")
+);
+
+CREATE TABLE "webhook_endpoints" (
+  "id" bigserial PRIMARY KEY,
+  "owner" varchar,
+  "url" varchar NOT NULL,
+  "secret" varchar NOT NULL,
+  "events" varchar[] NOT NULL,
+  "is_active" bool NOT NULL DEFAULT true,
+  "created_by" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "api_key_id" bigint
+);
 
-CREATE UNIQUE INDEX ON "accounts" ("owner", "currency");
+CREATE TABLE "webhook_deliveries" (
+  "id" bigserial PRIMARY KEY,
+  "endpoint_id" bigint NOT NULL,
+  "event_id" varchar NOT NULL,
+  "event_type" varchar NOT NULL,
+  "payload" jsonb NOT NULL,
+  "status" varchar NOT NULL DEFAULT 'pending',
+  "attempts" int NOT NULL DEFAULT 0,
+  "delivered_at" timestamptz NOT NULL DEFAULT '0001-01-01 00:00:00Z',
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "webhook_delivery_attempts" (
+  "id" bigserial PRIMARY KEY,
+  "delivery_id" bigint NOT NULL,
+  "response_status" int NOT NULL DEFAULT 0,
+  "error" varchar NOT NULL DEFAULT '',
+  "duration_ms" bigint NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "scheduled_jobs" (
+  "name" varchar PRIMARY KEY,
+  "schedule" varchar NOT NULL,
+  "next_run_at" timestamptz NOT NULL DEFAULT (now()),
+  "locked_by" varchar NOT NULL DEFAULT '',
+  "locked_until" timestamptz NOT NULL DEFAULT '0001-01-01 00:00:00Z',
+  "last_started_at" timestamptz NOT NULL DEFAULT '0001-01-01 00:00:00Z',
+  "last_finished_at" timestamptz NOT NULL DEFAULT '0001-01-01 00:00:00Z',
+  "last_status" varchar NOT NULL DEFAULT '',
+  "last_result" varchar NOT NULL DEFAULT '',
+  "last_error" varchar NOT NULL DEFAULT '',
+  "last_duration_ms" bigint NOT NULL DEFAULT 0
+);
+
+CREATE TABLE "audit_events" (
+  "id" bigserial PRIMARY KEY,
+  "event_type" varchar NOT NULL,
+  "actor" varchar NOT NULL,
+  "target_type" varchar NOT NULL,
+  "target_id" varchar NOT NULL,
+  "client_ip" varchar NOT NULL DEFAULT '',
+  "user_agent" varchar NOT NULL DEFAULT '',
+  "request_id" varchar NOT NULL DEFAULT '',
+  "before" json,
+  "after" json,
+  "prev_hash" varchar NOT NULL,
+  "hash" varchar UNIQUE NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "audit_outbox" (
+  "id" bigserial PRIMARY KEY,
+  "event_type" varchar NOT NULL,
+  "actor" varchar NOT NULL,
+  "target_type" varchar NOT NULL,
+  "target_id" varchar NOT NULL,
+  "client_ip" varchar NOT NULL DEFAULT '',
+  "user_agent" varchar NOT NULL DEFAULT '',
+  "request_id" varchar NOT NULL DEFAULT '',
+  "before" json,
+  "after" json,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "accounts" ("owner");
 
 CREATE INDEX ON "entries" ("account_id");
 
@@ -68,10 +265,120 @@ CREATE INDEX ON "transfers" ("to_account_id");
 
 CREATE INDEX ON "transfers" ("from_account_id", "to_account_id");
 
+CREATE INDEX ON "sessions" ("expires_at");
+
+CREATE INDEX ON "fee_rules" ("currency");
+
+CREATE INDEX ON "transfer_fees" ("transfer_id");
+
+CREATE INDEX ON "external_transfers" ("account_id");
+
+CREATE UNIQUE INDEX ON "external_transfers" ("type", "external_reference");
+
+CREATE INDEX ON "beneficiaries" ("owner");
+
+CREATE UNIQUE INDEX ON "beneficiaries" ("owner", "nickname");
+
+CREATE UNIQUE INDEX ON "beneficiaries" ("owner", "account_id");
+
+CREATE INDEX ON "account_members" ("username");
+
+CREATE INDEX ON "payment_requests" ("payee", "status");
+
+CREATE INDEX ON "payment_requests" ("payer", "status");
+
+CREATE INDEX ON "payment_requests" ("status", "expired_at");
+
+CREATE INDEX ON "outbox_events" ("is_published", "id");
+
+CREATE INDEX ON "verify_emails" ("username", "created_at");
+
+CREATE INDEX ON "verify_emails" ("expired_at");
+
+CREATE INDEX ON "webhook_endpoints" ("owner");
+
+CREATE INDEX ON "webhook_endpoints" ("api_key_id");
+
+CREATE UNIQUE INDEX ON "webhook_deliveries" ("endpoint_id", "event_id");
+
+CREATE INDEX ON "webhook_delivery_attempts" ("delivery_id");
+
+CREATE INDEX ON "audit_events" ("actor", "created_at");
+
+CREATE INDEX ON "audit_events" ("target_type", "target_id", "created_at");
+
+CREATE INDEX ON "audit_events" ("event_type", "created_at");
+
 COMMENT ON COLUMN "entries"."amount" IS 'can be negative or positive';
 
 COMMENT ON COLUMN "transfers"."amount" IS 'must be positive';
 
+COMMENT ON COLUMN "fee_rules"."fee_type" IS 'flat or percentage';
+
+COMMENT ON COLUMN "fee_rules"."basis_points" IS '1 basis point is 0.01%';
+
+COMMENT ON COLUMN "fee_rules"."free_transfers_per_month" IS 'the rule only applies after this many outgoing transfers in the month';
+
+COMMENT ON COLUMN "entries"."type" IS 'transfer, fee, deposit or withdrawal';
+
+COMMENT ON COLUMN "external_transfers"."type" IS 'deposit or withdrawal';
+
+COMMENT ON COLUMN "external_transfers"."amount" IS 'must be positive';
+
+COMMENT ON COLUMN "external_transfers"."external_reference" IS 'reference on the bank statement';
+
+COMMENT ON COLUMN "account_members"."role" IS 'owner, can_transfer or view_only';
+
+COMMENT ON COLUMN "payment_requests"."amount" IS 'must be positive';
+
+COMMENT ON COLUMN "payment_requests"."status" IS 'pending, accepted, declined or expired';
+
+COMMENT ON COLUMN "outbox_events"."attempts" IS 'number of failed attempts to publish the task';
+
+COMMENT ON COLUMN "outbox_events"."claimed_until" IS 'the event is being published by a relay until then, or retried after a failure';
+
+COMMENT ON COLUMN "outbox_events"."is_dead_lettered" IS 'the event failed too many times and is only published again when it is replayed';
+
+COMMENT ON COLUMN "users"."pending_email" IS 'new email address that is not verified yet';
+
+COMMENT ON COLUMN "users"."is_locked" IS 'locked users cannot log in and their sessions are revoked';
+
+COMMENT ON COLUMN "notification_preferences"."event_type" IS 'debit or credit';
+
+COMMENT ON COLUMN "notification_preferences"."channel" IS 'email';
+
+COMMENT ON COLUMN "notification_preferences"."min_amount" IS 'no notification is sent for smaller amounts';
+
+COMMENT ON COLUMN "api_keys"."scopes" IS 'webhooks: the endpoints of the key receive the events of every user';
+
+COMMENT ON COLUMN "webhook_endpoints"."owner" IS 'the user whose events are received, null for the endpoints of an API key';
+
+COMMENT ON COLUMN "webhook_endpoints"."api_key_id" IS 'the API key that manages the endpoint, null for the endpoints of a user';
+
+COMMENT ON COLUMN "webhook_endpoints"."secret" IS 'signs the payloads with HMAC-SHA256';
+
+COMMENT ON COLUMN "webhook_deliveries"."status" IS 'pending, succeeded or failed';
+
+COMMENT ON COLUMN "webhook_delivery_attempts"."response_status" IS '0 if no response was received';
+
+COMMENT ON COLUMN "scheduled_jobs"."schedule" IS 'cron expression';
+
+COMMENT ON COLUMN "scheduled_jobs"."locked_by" IS 'worker running the job, empty when the job is idle';
+
+COMMENT ON COLUMN "scheduled_jobs"."last_status" IS 'succeeded or failed, empty if the job never ran';
+
+COMMENT ON COLUMN "audit_events"."actor" IS 'user:<username>, api_key:<name>, anonymous or system';
+
+COMMENT ON COLUMN "audit_events"."before" IS 'snapshot of the target before the change';
+
+COMMENT ON COLUMN "audit_events"."after" IS 'snapshot of the target after the change';
+
+COMMENT ON COLUMN "audit_events"."prev_hash" IS 'hash of the previous event, empty for the first event';
+
+COMMENT ON COLUMN "audit_events"."hash" IS 'sha256 of the event and the hash of the previous event';
+
+COMMENT ON COLUMN "audit_outbox"."created_at" IS 'time of the change, kept by the audit event once the record is chained';
+
 ALTER TABLE "verify_emails" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
 
 ALTER TABLE "accounts" ADD FOREIGN KEY ("owner") REFERENCES "users" ("username");
@@ -83,3 +390,57 @@ ALTER TABLE "transfers" ADD FOREIGN KEY ("from_account_id") REFERENCES "accounts
 ALTER TABLE "transfers" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
 
 ALTER TABLE "sessions" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "fee_rules" ADD FOREIGN KEY ("revenue_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "transfer_fees" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+ALTER TABLE "transfer_fees" ADD FOREIGN KEY ("fee_rule_id") REFERENCES "fee_rules" ("id");
+
+ALTER TABLE "transfer_fees" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "transfer_fees" ADD FOREIGN KEY ("revenue_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "transfer_fees" ADD FOREIGN KEY ("from_entry_id") REFERENCES "entries" ("id");
+
+ALTER TABLE "transfer_fees" ADD FOREIGN KEY ("to_entry_id") REFERENCES "entries" ("id");
+
+ALTER TABLE "settlement_accounts" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "external_transfers" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "external_transfers" ADD FOREIGN KEY ("settlement_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "external_transfers" ADD FOREIGN KEY ("entry_id") REFERENCES "entries" ("id");
+
+ALTER TABLE "external_transfers" ADD FOREIGN KEY ("settlement_entry_id") REFERENCES "entries" ("id");
+
+ALTER TABLE "beneficiaries" ADD FOREIGN KEY ("owner") REFERENCES "users" ("username");
+
+ALTER TABLE "beneficiaries" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "account_members" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "account_members" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "account_members" ADD FOREIGN KEY ("invited_by") REFERENCES "users" ("username");
+
+ALTER TABLE "payment_requests" ADD FOREIGN KEY ("payee") REFERENCES "users" ("username");
+
+ALTER TABLE "payment_requests" ADD FOREIGN KEY ("payer") REFERENCES "users" ("username");
+
+ALTER TABLE "payment_requests" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "payment_requests" ADD FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "payment_requests" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+ALTER TABLE "notification_preferences" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "webhook_endpoints" ADD FOREIGN KEY ("owner") REFERENCES "users" ("username");
+
+ALTER TABLE "webhook_endpoints" ADD FOREIGN KEY ("api_key_id") REFERENCES "api_keys" ("id");
+
+ALTER TABLE "webhook_deliveries" ADD FOREIGN KEY ("endpoint_id") REFERENCES "webhook_endpoints" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "webhook_delivery_attempts" ADD FOREIGN KEY ("delivery_id") REFERENCES "webhook_deliveries" ("id") ON DELETE CASCADE;
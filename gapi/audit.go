@@ -0,0 +1,27 @@
+package gapi
+
+import (
+	"context"
+
+	db "github.com/juker1141/simplebank/db/sqlc"
+	"github.com/juker1141/simplebank/requestid"
+)
+
+// auditActor returns who made the request and where it came from
+func (server *Server) auditActor(ctx context.Context, name string) db.AuditActor {
+	mtdt := server.extractMetadata(ctx)
+	return db.AuditActor{
+		Name: name,
+		ClientIP: mtdt.ClientIP,
+		UserAgent: mtdt.UserAgent,
+		RequestID: requestid.FromContext(ctx),
+	}
+}
+
+// audit appends the events that aren't part of a transaction to the audit log.
+// The change is already made when it is audited, so a failure is logged rather than returned
+func (server *Server) audit(ctx context.Context, records ...db.AuditRecord) {
+	if err := server.store.AppendAuditEventsTx(ctx, records); err != nil {
+		requestid.Logger(ctx).Error().Err(err).Msg("failed to append audit events")
+	}
+}
\ No newline at end of file
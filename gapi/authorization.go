@@ -2,19 +2,27 @@ package gapi
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 
+	db "github.com/juker1141/simplebank/db/sqlc"
+	"github.com/juker1141/simplebank/policy"
 	"github.com/juker1141/simplebank/token"
+	"github.com/juker1141/simplebank/util"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 const (
 	authorizationHeader = "authorization"
 	authorizationBearer = "bearer"
+	apiKeyHeader        = "x-api-key"
 )
 
-func (server *Server) authorizeUser(ctx context.Context) (*token.Payload, error) {
+func (server *Server) authorizeUser(ctx context.Context, accessibleRoles []string) (*token.Payload, error) {
 	md, ok := metadata.FromIncomingContext(ctx)
 	if !ok {
 		return nil, fmt.Errorf("missing metadata")
@@ -43,6 +51,138 @@ func (server *Server) authorizeUser(ctx context.Context) (*token.Payload, error)
 	if err != nil {
 		return nil, fmt.Errorf("invalid access token: %s", err)
 	}
+
+	if !hasPermission(payload.Role, accessibleRoles) {
+		return nil, fmt.Errorf("permission denied")
+	}
 	
 	return payload, nil
+}
+
+func hasPermission(userRole string, accessibleRoles []string) bool {
+	for _, role := range accessibleRoles {
+		if userRole == role {
+			return true
+		}
+	}
+	return false
+}
+
+// authorizeAPIKey checks the API key sent in the metadata
+func (server *Server) authorizeAPIKey(ctx context.Context) (db.ApiKey, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return db.ApiKey{}, fmt.Errorf("missing metadata")
+	}
+
+	values := md.Get(apiKeyHeader)
+	if len(values) == 0 {
+		return db.ApiKey{}, fmt.Errorf("missing api key header")
+	}
+
+	apiKey, err := server.store.GetAPIKeyByHashedKey(ctx, util.HashAPIKey(values[0]))
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return db.ApiKey{}, fmt.Errorf("invalid api key")
+		}
+		return db.ApiKey{}, fmt.Errorf("failed to get api key: %s", err)
+	}
+
+	if apiKey.IsRevoked {
+		return db.ApiKey{}, fmt.Errorf("revoked api key")
+	}
+
+	return apiKey, nil
+}
+
+// authorizeOperator accepts an internal service trusted as an operator, the API key of a partner or the access token of a banker,
+// for the money operations of the settlement accounts. It returns the name of the caller to record who performed the operation
+func (server *Server) authorizeOperator(ctx context.Context) (string, error) {
+	if identity, ok := serviceIdentity(ctx); ok && server.isOperatorService(identity) {
+		return fmt.Sprintf("service:%s", identity), nil
+	}
+
+	if md, ok := metadata.FromIncomingContext(ctx); ok && len(md.Get(apiKeyHeader)) > 0 {
+		apiKey, err := server.authorizeAPIKey(ctx)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("api_key:%s", apiKey.Name), nil
+	}
+
+	payload, err := server.authorizeUser(ctx, []string{util.BankerRole})
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("user:%s", payload.Username), nil
+}
+
+// authorizeAdmin accepts an internal service trusted as an operator or the access token of a banker,
+// for the RPCs that inspect or change the bank itself. The API keys of the partners aren't accepted.
+// It returns the name of the caller to record who performed the operation
+func (server *Server) authorizeAdmin(ctx context.Context) (string, error) {
+	if identity, ok := serviceIdentity(ctx); ok && server.isOperatorService(identity) {
+		return fmt.Sprintf("service:%s", identity), nil
+	}
+
+	payload, err := server.authorizeUser(ctx, []string{util.BankerRole})
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("user:%s", payload.Username), nil
+}
+
+// isOperatorService checks whether the service authenticated with a client certificate
+// is allowed to call the operator RPCs
+func (server *Server) isOperatorService(identity string) bool {
+	for _, service := range server.config.Get().OperatorServices {
+		if service == identity {
+			return true
+		}
+	}
+	return false
+}
+
+// authorizeAccount checks that the user is a member of the account
+// whose permissions are accepted by allowed, and returns a gRPC status error otherwise
+func (server *Server) authorizeAccount(ctx context.Context, accountID int64, username string, allowed func(db.AccountMember) bool) (db.AccountMember, error) {
+	member, err := server.store.GetAccountMember(ctx, db.GetAccountMemberParams{
+		AccountID: accountID,
+		Username: username,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return member, status.Errorf(codes.PermissionDenied, "account doesn't belong to the authenticated user")
+		}
+		return member, status.Errorf(codes.Internal, "failed to get account member: %s", err)
+	}
+
+	if !allowed(member) {
+		return member, status.Errorf(codes.PermissionDenied, "account member role %s doesn't allow this operation", member.Role)
+	}
+
+	return member, nil
+}
+
+// requireVerifiedEmail checks the verified email policy for the authenticated user,
+// and returns a gRPC status error with the reason of the rejection otherwise
+func (server *Server) requireVerifiedEmail(ctx context.Context, payload *token.Payload) error {
+	err := server.verifiedEmail.Check(ctx, payload)
+	if err == nil {
+		return nil
+	}
+
+	if !errors.Is(err, policy.ErrEmailNotVerified) {
+		return status.Errorf(codes.Internal, "failed to check email verification: %s", err)
+	}
+
+	statusNotVerified := status.New(codes.FailedPrecondition, err.Error())
+	statusDetails, detailsErr := statusNotVerified.WithDetails(&errdetails.ErrorInfo{
+		Reason: policy.ReasonEmailNotVerified,
+	})
+	if detailsErr != nil {
+		return statusNotVerified.Err()
+	}
+
+	return statusDetails.Err()
 }
\ No newline at end of file
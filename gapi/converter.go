@@ -1,8 +1,11 @@
 package gapi
 
 import (
+	"time"
+
 	db "github.com/juker1141/simplebank/db/sqlc"
 	"github.com/juker1141/simplebank/pb"
+	"github.com/juker1141/simplebank/worker"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
@@ -13,5 +16,185 @@ func convertUser(user db.User) *pb.User{
 		Email: user.Email,
 		PasswordChangedAt: timestamppb.New(user.PasswordChangedAt),
 		CreatedAt: timestamppb.New(user.CreatedAt),
+		Language: user.Language,
+		PendingEmail: user.PendingEmail,
+	}
+}
+
+func convertAccount(account db.Account) *pb.Account {
+	return &pb.Account{
+		Id: account.ID,
+		Owner: account.Owner,
+		Balance: account.Balance,
+		Currency: account.Currency,
+		CreatedAt: timestamppb.New(account.CreatedAt),
+	}
+}
+
+func convertExternalTransfer(transfer db.ExternalTransfer) *pb.ExternalTransfer {
+	return &pb.ExternalTransfer{
+		Id: transfer.ID,
+		Type: transfer.Type,
+		AccountId: transfer.AccountID,
+		Amount: transfer.Amount,
+		Currency: transfer.Currency,
+		ExternalReference: transfer.ExternalReference,
+		CreatedBy: transfer.CreatedBy,
+		CreatedAt: timestamppb.New(transfer.CreatedAt),
+	}
+}
+
+func convertBeneficiary(beneficiary db.Beneficiary) *pb.Beneficiary {
+	return &pb.Beneficiary{
+		Id: beneficiary.ID,
+		Nickname: beneficiary.Nickname,
+		AccountId: beneficiary.AccountID,
+		Currency: beneficiary.Currency,
+		CreatedAt: timestamppb.New(beneficiary.CreatedAt),
+	}
+}
+
+func convertAccountMember(member db.AccountMember) *pb.AccountMember {
+	return &pb.AccountMember{
+		AccountId: member.AccountID,
+		Username: member.Username,
+		Role: member.Role,
+		InvitedBy: member.InvitedBy,
+		IsAccepted: member.IsAccepted,
+		AcceptedAt: timestamppb.New(member.AcceptedAt),
+		CreatedAt: timestamppb.New(member.CreatedAt),
+	}
+}
+
+func convertPaymentRequest(paymentRequest db.PaymentRequest) *pb.PaymentRequest {
+	return &pb.PaymentRequest{
+		Id: paymentRequest.ID,
+		Payee: paymentRequest.Payee,
+		Payer: paymentRequest.Payer,
+		ToAccountId: paymentRequest.ToAccountID,
+		Amount: paymentRequest.Amount,
+		Currency: paymentRequest.Currency,
+		Memo: paymentRequest.Memo,
+		Status: paymentRequest.Status,
+		FromAccountId: paymentRequest.FromAccountID.Int64,
+		TransferId: paymentRequest.TransferID.Int64,
+		ExpiredAt: timestamppb.New(paymentRequest.ExpiredAt),
+		RespondedAt: timestamppb.New(paymentRequest.RespondedAt),
+		CreatedAt: timestamppb.New(paymentRequest.CreatedAt),
+	}
+}
+
+func convertNotificationPreference(preference db.NotificationPreference) *pb.NotificationPreference {
+	return &pb.NotificationPreference{
+		EventType: preference.EventType,
+		Channel: preference.Channel,
+		IsEnabled: preference.IsEnabled,
+		MinAmount: preference.MinAmount,
+		UpdatedAt: timestamppb.New(preference.UpdatedAt),
+	}
+}
+
+func convertWebhookEndpoint(endpoint db.WebhookEndpoint) *pb.WebhookEndpoint {
+	return &pb.WebhookEndpoint{
+		Id: endpoint.ID,
+		Url: endpoint.Url,
+		Events: endpoint.Events,
+		IsActive: endpoint.IsActive,
+		CreatedBy: endpoint.CreatedBy,
+		CreatedAt: timestamppb.New(endpoint.CreatedAt),
+	}
+}
+
+func convertWebhookDelivery(delivery db.WebhookDelivery) *pb.WebhookDelivery {
+	return &pb.WebhookDelivery{
+		Id: delivery.ID,
+		EndpointId: delivery.EndpointID,
+		EventId: delivery.EventID,
+		EventType: delivery.EventType,
+		Payload: string(delivery.Payload),
+		Status: delivery.Status,
+		Attempts: delivery.Attempts,
+		DeliveredAt: timestamppb.New(delivery.DeliveredAt),
+		CreatedAt: timestamppb.New(delivery.CreatedAt),
+	}
+}
+
+func convertWebhookDeliveryAttempt(attempt db.WebhookDeliveryAttempt) *pb.WebhookDeliveryAttempt {
+	return &pb.WebhookDeliveryAttempt{
+		Id: attempt.ID,
+		ResponseStatus: attempt.ResponseStatus,
+		Error: attempt.Error,
+		DurationMs: attempt.DurationMs,
+		CreatedAt: timestamppb.New(attempt.CreatedAt),
+	}
+}
+
+// convertScheduledJob reports the job as running until its lock expires,
+// after which another worker may take it over
+func convertScheduledJob(job db.ScheduledJob) *pb.ScheduledJob {
+	rsp := &pb.ScheduledJob{
+		Name: job.Name,
+		Schedule: job.Schedule,
+		NextRunAt: timestamppb.New(job.NextRunAt),
+		LastStartedAt: timestamppb.New(job.LastStartedAt),
+		LastFinishedAt: timestamppb.New(job.LastFinishedAt),
+		LastStatus: job.LastStatus,
+		LastResult: job.LastResult,
+		LastError: job.LastError,
+		LastDurationMs: job.LastDurationMs,
+	}
+
+	if job.LockedBy != "" && job.LockedUntil.After(time.Now()) {
+		rsp.IsRunning = true
+		rsp.RunningOn = job.LockedBy
+	}
+
+	return rsp
+}
+
+func convertTaskQueue(queue worker.QueueInfo) *pb.TaskQueue {
+	return &pb.TaskQueue{
+		Name: queue.Queue,
+		IsPaused: queue.Paused,
+		Size: int32(queue.Size),
+		Pending: int32(queue.Pending),
+		Active: int32(queue.Active),
+		Scheduled: int32(queue.Scheduled),
+		Retry: int32(queue.Retry),
+		Archived: int32(queue.Archived),
+	}
+}
+
+// convertTask returns the payload as text, the tasks are enqueued with JSON payloads
+func convertTask(task worker.TaskInfo) *pb.Task {
+	return &pb.Task{
+		Id: task.ID,
+		Queue: task.Queue,
+		Type: task.Type,
+		Payload: string(task.Payload),
+		State: task.State,
+		MaxRetry: int32(task.MaxRetry),
+		Retried: int32(task.Retried),
+		LastError: task.LastError,
+		LastFailedAt: timestamppb.New(task.LastFailedAt),
+		NextProcessAt: timestamppb.New(task.NextProcessAt),
+	}
+}
+
+func convertAuditEvent(event db.AuditEvent) *pb.AuditEvent {
+	return &pb.AuditEvent{
+		Id: event.ID,
+		EventType: event.EventType,
+		Actor: event.Actor,
+		TargetType: event.TargetType,
+		TargetId: event.TargetID,
+		ClientIp: event.ClientIp,
+		UserAgent: event.UserAgent,
+		RequestId: event.RequestID,
+		Before: string(event.Before),
+		After: string(event.After),
+		PrevHash: event.PrevHash,
+		Hash: event.Hash,
+		CreatedAt: timestamppb.New(event.CreatedAt),
 	}
 }
\ No newline at end of file
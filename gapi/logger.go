@@ -5,7 +5,9 @@ import (
 	"net/http"
 	"time"
 
-	"github.com/rs/zerolog/log"
+	"github.com/juker1141/simplebank/requestid"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
@@ -26,10 +28,11 @@ func GrpcLogger(
 	if st, ok := status.FromError(err); ok {
 		statusCode = st.Code()
 	}
+	observeGrpcRequest(info.FullMethod, statusCode, duration)
 
-	logger := log.Info()
+	logger := requestid.Logger(ctx).Info()
 	if err != nil {
-		logger = log.Error().Err(err)
+		logger = requestid.Logger(ctx).Error().Err(err)
 	}
 
 	logger.Str("protocol", "grpc").
@@ -64,12 +67,21 @@ func HttpLogger(handler http.Handler) http.Handler {
 			ResponseWriter: res,
 			StatusCode: http.StatusOK,
 		}
+		route := &httpRoute{}
+		req = req.WithContext(context.WithValue(req.Context(), httpRouteKey{}, route))
 		handler.ServeHTTP(rec, req)
 		duration := time.Since(startTime)
+		pattern := routeOf(handler, req, route)
+		observeHttpRequest(req.Method, pattern, rec.StatusCode, duration)
 
-		logger := log.Info()
+		// the span of the request is named after its route, which is only known once it is served
+		span := trace.SpanFromContext(req.Context())
+		span.SetName(req.Method + " " + pattern)
+		span.SetAttributes(semconv.HTTPRoute(pattern))
+
+		logger := requestid.Logger(req.Context()).Info()
 		if rec.StatusCode != http.StatusOK {
-			logger = log.Error().Bytes("body", rec.Body)
+			logger = requestid.Logger(req.Context()).Error().Bytes("body", rec.Body)
 		}
 
 		logger.Str("protocol", "http").
@@ -3,6 +3,7 @@ package gapi
 import (
 	"context"
 	"fmt"
+	"reflect"
 	"testing"
 	"time"
 
@@ -18,16 +19,18 @@ func newTestServer(t *testing.T, store db.Store, taskDistributor worker.TaskDist
 	config := util.Config{
 		TokenSymmetricKey: 	 util.RandomString(32),
 		AccessTokenDuration: time.Minute,
+		VerifyEmailResendInterval: time.Minute,
+		VerifyEmailHourlyLimit: 5,
 	}
 
-	server, err := NewServer(config, store, taskDistributor)
+	server, err := NewServer(util.NewLiveConfig(config), store, taskDistributor, nil)
 	require.NoError(t, err)
 
 	return server
 }
 
-func newContextWithBearerToken(t *testing.T, tokenMaker token.Maker, username string, duration time.Duration) context.Context {
-	accessToken, _, err := tokenMaker.CreateToken(username, duration)
+func newContextWithBearerToken(t *testing.T, tokenMaker token.Maker, username string, role string, duration time.Duration) context.Context {
+	accessToken, _, err := tokenMaker.CreateToken(username, role, duration)
 	require.NoError(t, err)
 	
 	bearerToken := fmt.Sprintf("%s %s", authorizationBearer, accessToken)
@@ -38,4 +41,27 @@ func newContextWithBearerToken(t *testing.T, tokenMaker token.Maker, username st
 	}
 
 	return metadata.NewIncomingContext(context.Background(), md)
+}
+
+// isOutboxTask returns true if the tasks are a single task of the type with the payload
+func isOutboxTask(tasks []db.OutboxTask, taskType string, payload any) bool {
+	if len(tasks) != 1 {
+		return false
+	}
+
+	return tasks[0].TaskType == taskType && reflect.DeepEqual(tasks[0].Payload, payload)
+}
+
+// isWebhookEventTask returns true if the task dispatches a webhook event of the type about the users and the accounts
+func isWebhookEventTask(task db.OutboxTask, eventType string, usernames []string, accountIDs []int64, data any) bool {
+	payload, ok := task.Payload.(*worker.PayloadDispatchWebhookEvent)
+	if !ok || task.TaskType != worker.TaskDispatchWebhookEvent {
+		return false
+	}
+
+	return payload.Event.ID != "" &&
+		payload.Event.Type == eventType &&
+		reflect.DeepEqual(payload.Usernames, usernames) &&
+		reflect.DeepEqual(payload.AccountIDs, accountIDs) &&
+		reflect.DeepEqual(payload.Event.Data, data)
 }
\ No newline at end of file
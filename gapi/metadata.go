@@ -2,6 +2,10 @@ package gapi
 
 import (
 	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
 
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/peer"
@@ -19,7 +23,12 @@ type Metadata struct {
 }
 
 func (server *Server) extractMetadata(ctx context.Context) *Metadata {
+	return extractMetadata(ctx, server.trustedProxies)
+}
+
+func extractMetadata(ctx context.Context, trustedProxies []netip.Prefix) *Metadata {
 	mtdt := &Metadata{}
+	var hops []string
 
 	if md, ok := metadata.FromIncomingContext(ctx); ok {
 		if userAgents := md.Get(grpcGatewayUserAgentHeader); len(userAgents) > 0 {
@@ -30,14 +39,77 @@ func (server *Server) extractMetadata(ctx context.Context) *Metadata {
 			mtdt.UserAgent = userAgents[0]
 		}
 
-		if clientIPs := md.Get(xForwardedForHeader); len(clientIPs) > 0 {
-			mtdt.ClientIP = clientIPs[0]
-		}
+		// the gateway appends the address of the HTTP client to the header
+		hops = forwardedHops(md.Get(xForwardedForHeader))
 	}
 
 	if p, ok := peer.FromContext(ctx); ok {
-		mtdt.ClientIP = p.Addr.String()
+		hops = append(hops, p.Addr.String())
 	}
 
+	mtdt.ClientIP = clientIP(hops, trustedProxies)
 	return mtdt
+}
+
+// forwardedHops splits the values of the x-forwarded-for header into the addresses they list
+func forwardedHops(values []string) []string {
+	var hops []string
+	for _, value := range values {
+		for _, hop := range strings.Split(value, ",") {
+			if hop = strings.TrimSpace(hop); hop != "" {
+				hops = append(hops, hop)
+			}
+		}
+	}
+	return hops
+}
+
+// clientIP returns the IP of the client from the addresses the request went through,
+// from the client to the peer of the server. Each proxy appends the address of its own peer,
+// so the addresses are read from the right and the first one that isn't a trusted proxy is the client.
+// The addresses on its left are sent by the client, which can write anything in them
+func clientIP(hops []string, trustedProxies []netip.Prefix) string {
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := hops[i]
+		if host, _, err := net.SplitHostPort(hop); err == nil {
+			hop = host
+		}
+
+		addr, err := netip.ParseAddr(hop)
+		if i == 0 || err != nil || !isTrustedProxy(addr.Unmap(), trustedProxies) {
+			return hop
+		}
+	}
+	return ""
+}
+
+func isTrustedProxy(addr netip.Addr, trustedProxies []netip.Prefix) bool {
+	for _, prefix := range trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseTrustedProxies parses the IPs and the CIDR ranges of the proxies that are trusted to set x-forwarded-for
+func parseTrustedProxies(proxies []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(proxies))
+	for _, proxy := range proxies {
+		if strings.Contains(proxy, "/") {
+			prefix, err := netip.ParsePrefix(proxy)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trusted proxy %s: %w", proxy, err)
+			}
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+
+		addr, err := netip.ParseAddr(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %s: %w", proxy, err)
+		}
+		prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+	}
+	return prefixes, nil
 }
\ No newline at end of file
@@ -0,0 +1,89 @@
+package gapi
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/juker1141/simplebank/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+)
+
+var (
+	grpcRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "grpc",
+		Name: "requests_total",
+		Help: "Number of gRPC requests, by method and status code.",
+	}, []string{"method", "code"})
+
+	grpcRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "grpc",
+		Name: "request_duration_seconds",
+		Help: "Duration of the gRPC requests, by method.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method"})
+
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "http",
+		Name: "requests_total",
+		Help: "Number of HTTP requests, by method, route and status code.",
+	}, []string{"method", "route", "code"})
+
+	httpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "http",
+		Name: "request_duration_seconds",
+		Help: "Duration of the HTTP requests, by method and route.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route"})
+)
+
+func observeGrpcRequest(method string, code codes.Code, duration time.Duration) {
+	grpcRequests.WithLabelValues(method, code.String()).Inc()
+	grpcRequestDuration.WithLabelValues(method).Observe(duration.Seconds())
+}
+
+func observeHttpRequest(method string, route string, statusCode int, duration time.Duration) {
+	httpRequests.WithLabelValues(method, route, strconv.Itoa(statusCode)).Inc()
+	httpRequestDuration.WithLabelValues(method, route).Observe(duration.Seconds())
+}
+
+type httpRouteKey struct{}
+
+// httpRoute receives the path pattern of the gateway route that serves a request.
+// The metrics are labeled with the pattern rather than the path, which contains IDs
+type httpRoute struct {
+	pattern string
+}
+
+// HttpRouteAnnotator records the path pattern of the gateway route for HttpLogger.
+// It must be registered on the gateway with runtime.WithMetadata, and doesn't add any metadata
+func HttpRouteAnnotator(ctx context.Context, req *http.Request) metadata.MD {
+	if route, ok := req.Context().Value(httpRouteKey{}).(*httpRoute); ok {
+		route.pattern, _ = runtime.HTTPPathPattern(ctx)
+	}
+	return nil
+}
+
+// routeOf returns the path pattern that served the request: the gateway route,
+// or the pattern of the ServeMux for the other handlers
+func routeOf(handler http.Handler, req *http.Request, route *httpRoute) string {
+	if route.pattern != "" {
+		return route.pattern
+	}
+
+	if mux, ok := handler.(*http.ServeMux); ok {
+		if _, pattern := mux.Handler(req); pattern != "" {
+			return pattern
+		}
+	}
+	return "unknown"
+}
@@ -0,0 +1,50 @@
+package gapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	mockdb "github.com/juker1141/simplebank/db/mock"
+	"github.com/juker1141/simplebank/pb"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHttpLoggerMetrics(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	server := newTestServer(t, mockdb.NewMockStore(ctrl), nil)
+
+	grpcMux := runtime.NewServeMux(runtime.WithMetadata(HttpRouteAnnotator))
+	require.NoError(t, pb.RegisterSimpleBankHandlerServer(context.Background(), grpcMux, server))
+
+	mux := http.NewServeMux()
+	mux.Handle("/", grpcMux)
+	mux.Handle("/swagger/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	handler := HttpLogger(mux)
+
+	testCases := []struct {
+		path   string
+		route  string
+		status string
+	}{
+		{path: "/v1/webhook_deliveries/42", route: "/v1/webhook_deliveries/{id}", status: "401"},
+		{path: "/swagger/index.html", route: "/swagger/", status: "200"},
+		{path: "/v1/unknown", route: "/", status: "404"},
+	}
+
+	for _, tc := range testCases {
+		requests := httpRequests.WithLabelValues(http.MethodGet, tc.route, tc.status)
+		before := testutil.ToFloat64(requests)
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+		require.Equal(t, before+1, testutil.ToFloat64(requests), tc.path)
+	}
+}
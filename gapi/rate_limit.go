@@ -0,0 +1,262 @@
+package gapi
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"net/netip"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/juker1141/simplebank/pb"
+	"github.com/juker1141/simplebank/ratelimit"
+	"github.com/juker1141/simplebank/requestid"
+	"github.com/juker1141/simplebank/token"
+	"github.com/juker1141/simplebank/util"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+const retryAfterHeader = "retry-after"
+
+// gatewayRoute is the HTTP route of a RPC, the segments in braces are the variables of the path
+type gatewayRoute struct {
+	verb string
+	segments []string
+	method string
+}
+
+// RateLimiter applies the rate limiting policies to the gRPC calls, and to the requests of the gateway
+// which call the server in-process without the gRPC interceptors
+type RateLimiter struct {
+	limiter *ratelimit.Limiter
+	tokenMaker token.Maker
+	routes []gatewayRoute
+	trustedProxies []netip.Prefix
+}
+
+func NewRateLimiter(config util.Config, limiter *ratelimit.Limiter) (*RateLimiter, error) {
+	tokenMaker, err := token.NewPasetoMaker(config.TokenSymmetricKey)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create token maker: %w", err)
+	}
+
+	routes, err := gatewayRoutes()
+	if err != nil {
+		return nil, err
+	}
+
+	trustedProxies, err := parseTrustedProxies(config.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RateLimiter{
+		limiter: limiter,
+		tokenMaker: tokenMaker,
+		routes: routes,
+		trustedProxies: trustedProxies,
+	}, nil
+}
+
+// gatewayRoutes reads the HTTP routes of the RPCs from their google.api.http options
+func gatewayRoutes() ([]gatewayRoute, error) {
+	descriptor, err := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(pb.SimpleBank_ServiceDesc.ServiceName))
+	if err != nil {
+		return nil, fmt.Errorf("cannot find service descriptor: %w", err)
+	}
+	methods := descriptor.(protoreflect.ServiceDescriptor).Methods()
+
+	var routes []gatewayRoute
+	for i := 0; i < methods.Len(); i++ {
+		method := methods.Get(i)
+		rule, ok := proto.GetExtension(method.Options(), annotations.E_Http).(*annotations.HttpRule)
+		if !ok || rule == nil {
+			continue
+		}
+
+		var verb, pattern string
+		switch {
+		case rule.GetGet() != "":
+			verb, pattern = http.MethodGet, rule.GetGet()
+		case rule.GetPost() != "":
+			verb, pattern = http.MethodPost, rule.GetPost()
+		case rule.GetPut() != "":
+			verb, pattern = http.MethodPut, rule.GetPut()
+		case rule.GetPatch() != "":
+			verb, pattern = http.MethodPatch, rule.GetPatch()
+		case rule.GetDelete() != "":
+			verb, pattern = http.MethodDelete, rule.GetDelete()
+		default:
+			continue
+		}
+
+		routes = append(routes, gatewayRoute{
+			verb: verb,
+			segments: strings.Split(strings.Trim(pattern, "/"), "/"),
+			method: string(method.Name()),
+		})
+	}
+
+	return routes, nil
+}
+
+// gatewayMethod returns the name of the RPC served by the HTTP request, or an empty string
+// for the requests that aren't served by the gateway
+func (rateLimiter *RateLimiter) gatewayMethod(req *http.Request) string {
+	segments := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+
+	for _, route := range rateLimiter.routes {
+		if route.verb != req.Method || len(route.segments) != len(segments) {
+			continue
+		}
+
+		matched := true
+		for i, segment := range route.segments {
+			if strings.HasPrefix(segment, "{") {
+				matched = segments[i] != ""
+			} else {
+				matched = segment == segments[i]
+			}
+			if !matched {
+				break
+			}
+		}
+		if matched {
+			return route.method
+		}
+	}
+	return ""
+}
+
+// GrpcRateLimit is an interceptor that rejects the calls over the limits of the policies of their method
+func (rateLimiter *RateLimiter) GrpcRateLimit(
+	ctx context.Context,
+	req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+	// the health and reflection services aren't limited
+	service, method := path.Split(info.FullMethod)
+	if service != "/"+pb.SimpleBank_ServiceDesc.ServiceName+"/" {
+		return handler(ctx, req)
+	}
+
+	identity := ratelimit.Identity{
+		IP: extractMetadata(ctx, rateLimiter.trustedProxies).ClientIP,
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(authorizationHeader); len(values) > 0 {
+			identity.Username = rateLimiter.username(values[0])
+		}
+		if values := md.Get(apiKeyHeader); len(values) > 0 {
+			identity.APIKey = util.HashAPIKey(values[0])
+		}
+	}
+
+	result, ok := rateLimiter.allow(ctx, method, identity)
+	if !ok {
+		grpc.SetHeader(ctx, metadata.Pairs(retryAfterHeader, retryAfterSeconds(result)))
+		return nil, rateLimitError(result)
+	}
+
+	return handler(ctx, req)
+}
+
+// HttpRateLimit serves the gateway, rejecting the requests over the limits of the policies of their RPC.
+// The errors are written like the errors of the gateway
+func (rateLimiter *RateLimiter) HttpRateLimit(mux *runtime.ServeMux) http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		method := rateLimiter.gatewayMethod(req)
+		if method == "" {
+			mux.ServeHTTP(res, req)
+			return
+		}
+
+		identity := ratelimit.Identity{
+			IP: clientIP(append(forwardedHops(req.Header.Values(xForwardedForHeader)), req.RemoteAddr), rateLimiter.trustedProxies),
+			Username: rateLimiter.username(req.Header.Get(authorizationHeader)),
+		}
+		if apiKey := req.Header.Get(apiKeyHeader); apiKey != "" {
+			identity.APIKey = util.HashAPIKey(apiKey)
+		}
+
+		result, ok := rateLimiter.allow(req.Context(), method, identity)
+		if !ok {
+			res.Header().Set(retryAfterHeader, retryAfterSeconds(result))
+			_, outboundMarshaler := runtime.MarshalerForRequest(mux, req)
+			HttpErrorHandler(req.Context(), mux, outboundMarshaler, res, req, rateLimitError(result))
+			return
+		}
+
+		mux.ServeHTTP(res, req)
+	})
+}
+
+// allow checks the limits of the method, the requests are allowed if the limits can't be checked
+// rather than rejecting all of them while the backend is down
+func (rateLimiter *RateLimiter) allow(ctx context.Context, method string, identity ratelimit.Identity) (ratelimit.Result, bool) {
+	result, err := rateLimiter.limiter.Allow(ctx, method, identity)
+	if err != nil {
+		requestid.Logger(ctx).Error().Err(err).Str("method", method).Msg("failed to check rate limit")
+		return result, true
+	}
+	return result, result.Allowed
+}
+
+// username returns the user of a valid access token in the authorization header,
+// the calls with an invalid token are only limited by the other policies before they are rejected
+func (rateLimiter *RateLimiter) username(authHeader string) string {
+	fields := strings.Fields(authHeader)
+	if len(fields) < 2 || strings.ToLower(fields[0]) != authorizationBearer {
+		return ""
+	}
+
+	payload, err := rateLimiter.tokenMaker.VerifyToken(fields[1])
+	if err != nil {
+		return ""
+	}
+	return payload.Username
+}
+
+func retryAfterSeconds(result ratelimit.Result) string {
+	return strconv.Itoa(int(math.Ceil(result.RetryAfter.Seconds())))
+}
+
+// rateLimitError returns a ResourceExhausted error with the delay before the next call in its details,
+// the gateway responds with 429 Too Many Requests
+func rateLimitError(result ratelimit.Result) error {
+	statusLimited := status.Newf(codes.ResourceExhausted, "too many requests, retry after %s seconds", retryAfterSeconds(result))
+	statusDetails, err := statusLimited.WithDetails(
+		&errdetails.ErrorInfo{
+			Reason: ratelimit.ReasonRateLimited,
+		},
+		&errdetails.RetryInfo{
+			RetryDelay: durationpb.New(result.RetryAfter),
+		},
+		&errdetails.QuotaFailure{
+			Violations: []*errdetails.QuotaFailure_Violation{
+				{
+					Subject: result.Policy.Key,
+					Description: fmt.Sprintf("limited to %g requests per second per %s", result.Policy.Limit.Rate, result.Policy.Key),
+				},
+			},
+		},
+	)
+	if err != nil {
+		return statusLimited.Err()
+	}
+
+	return statusDetails.Err()
+}
\ No newline at end of file
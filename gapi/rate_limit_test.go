@@ -0,0 +1,193 @@
+package gapi
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/juker1141/simplebank/ratelimit"
+	"github.com/juker1141/simplebank/util"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func newTestRateLimiter(t *testing.T, policies ...ratelimit.Policy) *RateLimiter {
+	config := util.Config{
+		TokenSymmetricKey: util.RandomString(32),
+		TrustedProxies: []string{"10.0.0.1"},
+	}
+
+	rateLimiter, err := NewRateLimiter(config, ratelimit.NewLimiter(policies, ratelimit.NewMemoryStore()))
+	require.NoError(t, err)
+	return rateLimiter
+}
+
+func TestGatewayMethod(t *testing.T) {
+	rateLimiter := newTestRateLimiter(t)
+
+	testCases := []struct {
+		method string
+		path string
+		rpc string
+	}{
+		{http.MethodPost, "/v1/login_user", "LoginUser"},
+		{http.MethodGet, "/v1/verify_email", "VerifyEmail"},
+		{http.MethodPost, "/v1/task_queues/critical/tasks/abc/retry", "RetryTask"},
+		{http.MethodDelete, "/v1/task_queues/critical/tasks/abc", "DeleteTask"},
+		{http.MethodPost, "/v1/accounts/1/members/accept", "AcceptAccountInvitation"},
+		{http.MethodGet, "/v1/login_user", ""},
+		{http.MethodGet, "/swagger/index.html", ""},
+		{http.MethodGet, "/healthz", ""},
+	}
+
+	for _, tc := range testCases {
+		req := httptest.NewRequest(tc.method, tc.path, nil)
+		require.Equal(t, tc.rpc, rateLimiter.gatewayMethod(req), "%s %s", tc.method, tc.path)
+	}
+}
+
+func TestGrpcRateLimit(t *testing.T) {
+	rateLimiter := newTestRateLimiter(t, ratelimit.Policy{
+		Method: "LoginUser",
+		Key: ratelimit.KeyIP,
+		Limit: ratelimit.Limit{Rate: 1, Burst: 1},
+	})
+
+	calls := 0
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		calls++
+		return nil, nil
+	}
+	info := &grpc.UnaryServerInfo{FullMethod: "/pb.SimpleBank/LoginUser"}
+
+	newContext := func(port int) context.Context {
+		return peer.NewContext(context.Background(), &peer.Peer{
+			Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: port},
+		})
+	}
+
+	_, err := rateLimiter.GrpcRateLimit(newContext(40000), nil, info, handler)
+	require.NoError(t, err)
+
+	// the port of each connection of the client doesn't matter
+	_, err = rateLimiter.GrpcRateLimit(newContext(40001), nil, info, handler)
+	require.Error(t, err)
+	require.Equal(t, 1, calls)
+
+	st, ok := status.FromError(err)
+	require.True(t, ok)
+	require.Equal(t, codes.ResourceExhausted, st.Code())
+
+	var retryInfo *errdetails.RetryInfo
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.RetryInfo); ok {
+			retryInfo = info
+		}
+	}
+	require.NotNil(t, retryInfo)
+	require.InDelta(t, time.Second, retryInfo.GetRetryDelay().AsDuration(), float64(10*time.Millisecond))
+
+	// the other services aren't limited
+	_, err = rateLimiter.GrpcRateLimit(newContext(40002), nil, &grpc.UnaryServerInfo{FullMethod: "/grpc.health.v1.Health/Check"}, handler)
+	require.NoError(t, err)
+	require.Equal(t, 2, calls)
+}
+
+func TestHttpRateLimit(t *testing.T) {
+	rateLimiter := newTestRateLimiter(t, ratelimit.Policy{
+		Method: "CreateUser",
+		Key: ratelimit.KeyIP,
+		Limit: ratelimit.Limit{Rate: 0.5, Burst: 1},
+	})
+
+	mux := runtime.NewServeMux()
+	err := mux.HandlePath(http.MethodPost, "/v1/create_user", func(res http.ResponseWriter, req *http.Request, params map[string]string) {
+		res.WriteHeader(http.StatusOK)
+	})
+	require.NoError(t, err)
+	handler := rateLimiter.HttpRateLimit(mux)
+
+	newRequest := func() *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/v1/create_user", strings.NewReader("{}"))
+		req.RemoteAddr = "10.0.0.1:40000"
+		return req
+	}
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, newRequest())
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, newRequest())
+	require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	require.Equal(t, "2", recorder.Header().Get(retryAfterHeader))
+
+	var body map[string]any
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+	require.Equal(t, float64(codes.ResourceExhausted), body["code"])
+
+	// the requests of another client forwarded by the trusted proxy have their own bucket,
+	// the client is the right-most address that isn't a trusted proxy
+	req := newRequest()
+	req.Header.Set("X-Forwarded-For", "10.0.0.9, 10.0.0.2, 10.0.0.1")
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	req = newRequest()
+	req.Header.Set("X-Forwarded-For", "10.0.0.8, 10.0.0.2")
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+
+	// the header of a client that isn't a trusted proxy is ignored
+	for i, forwardedFor := range []string{"10.0.0.4", "10.0.0.5"} {
+		req = newRequest()
+		req.RemoteAddr = "10.0.0.3:40000"
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+		recorder = httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+		if i == 0 {
+			require.Equal(t, http.StatusOK, recorder.Code)
+		} else {
+			require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+		}
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	trustedProxies, err := parseTrustedProxies([]string{"10.0.0.1", "192.168.0.0/16"})
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name string
+		hops []string
+		ip string
+	}{
+		{"Peer", []string{"10.0.0.3:40000"}, "10.0.0.3"},
+		{"UntrustedPeer", []string{"1.2.3.4", "10.0.0.3:40000"}, "10.0.0.3"},
+		{"TrustedProxy", []string{"1.2.3.4", "10.0.0.1:40000"}, "1.2.3.4"},
+		{"TrustedProxies", []string{"6.6.6.6", "1.2.3.4", "192.168.1.1", "10.0.0.1:40000"}, "1.2.3.4"},
+		{"OnlyTrustedProxies", []string{"192.168.1.1", "10.0.0.1:40000"}, "192.168.1.1"},
+		{"InvalidHop", []string{"1.2.3.4", "unknown", "10.0.0.1:40000"}, "unknown"},
+		{"IPv6", []string{"2001:db8::1", "[::ffff:10.0.0.1]:40000"}, "2001:db8::1"},
+		{"NoHop", nil, ""},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.ip, clientIP(tc.hops, trustedProxies), tc.name)
+	}
+
+	_, err = parseTrustedProxies([]string{"10.0.0.0/33"})
+	require.Error(t, err)
+}
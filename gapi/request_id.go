@@ -0,0 +1,87 @@
+package gapi
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/juker1141/simplebank/requestid"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// GrpcRequestID is an interceptor that gives each call a request ID, sent by the client or generated.
+// The ID is returned in the response header and in the details of the errors
+func GrpcRequestID(
+	ctx context.Context,
+	req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+	var id string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if ids := md.Get(requestid.Header); len(ids) > 0 {
+			id = ids[0]
+		}
+	}
+	id = requestid.Resolve(id)
+
+	ctx = requestid.NewContext(ctx, id)
+	grpc.SetHeader(ctx, metadata.Pairs(requestid.Header, id))
+
+	result, err := handler(ctx, req)
+	if err != nil {
+		return result, withRequestInfo(err, id)
+	}
+	return result, nil
+}
+
+// HttpRequestID is a middleware that gives each HTTP request a request ID, sent by the client or generated.
+// The ID is returned in the response header
+func HttpRequestID(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		id := requestid.Resolve(req.Header.Get(requestid.Header))
+		res.Header().Set(requestid.Header, id)
+
+		req = req.WithContext(requestid.NewContext(req.Context(), id))
+		handler.ServeHTTP(res, req)
+	})
+}
+
+// HttpRequestIDAnnotator forwards the request ID of the HTTP request to the gRPC metadata of the gateway
+func HttpRequestIDAnnotator(ctx context.Context, req *http.Request) metadata.MD {
+	if id := requestid.FromContext(req.Context()); id != "" {
+		return metadata.Pairs(requestid.Header, id)
+	}
+	return nil
+}
+
+// HttpErrorHandler writes the errors of the gateway with the request ID in their details
+func HttpErrorHandler(ctx context.Context, mux *runtime.ServeMux, marshaler runtime.Marshaler, res http.ResponseWriter, req *http.Request, err error) {
+	if id := requestid.FromContext(req.Context()); id != "" {
+		err = withRequestInfo(err, id)
+	}
+	runtime.DefaultHTTPErrorHandler(ctx, mux, marshaler, res, req, err)
+}
+
+// withRequestInfo adds the request ID to the details of a status error
+func withRequestInfo(err error, id string) error {
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+
+	for _, detail := range st.Details() {
+		if _, ok := detail.(*errdetails.RequestInfo); ok {
+			return err
+		}
+	}
+
+	statusDetails, detailsErr := st.WithDetails(&errdetails.RequestInfo{RequestId: id})
+	if detailsErr != nil {
+		return err
+	}
+	return statusDetails.Err()
+}
\ No newline at end of file
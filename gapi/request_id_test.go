@@ -0,0 +1,122 @@
+package gapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	mockdb "github.com/juker1141/simplebank/db/mock"
+	"github.com/juker1141/simplebank/pb"
+	"github.com/juker1141/simplebank/requestid"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestGrpcRequestID(t *testing.T) {
+	testCases := []struct {
+		name          string
+		buildContext  func() context.Context
+		handlerErr    error
+		checkResponse func(t *testing.T, id string, err error)
+	}{
+		{
+			name: "FromMetadata",
+			buildContext: func() context.Context {
+				md := metadata.Pairs(requestid.Header, "req-1")
+				return metadata.NewIncomingContext(context.Background(), md)
+			},
+			handlerErr: status.Error(codes.NotFound, "account not found"),
+			checkResponse: func(t *testing.T, id string, err error) {
+				require.Equal(t, "req-1", id)
+
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.NotFound, st.Code())
+				require.Len(t, st.Details(), 1)
+				requestInfo, ok := st.Details()[0].(*errdetails.RequestInfo)
+				require.True(t, ok)
+				require.Equal(t, "req-1", requestInfo.RequestId)
+			},
+		},
+		{
+			name: "Generated",
+			buildContext: func() context.Context {
+				return context.Background()
+			},
+			checkResponse: func(t *testing.T, id string, err error) {
+				require.NoError(t, err)
+				require.NotEmpty(t, id)
+			},
+		},
+		{
+			name: "InvalidID",
+			buildContext: func() context.Context {
+				md := metadata.Pairs(requestid.Header, "not a valid id")
+				return metadata.NewIncomingContext(context.Background(), md)
+			},
+			checkResponse: func(t *testing.T, id string, err error) {
+				require.NoError(t, err)
+				require.NotEmpty(t, id)
+				require.NotEqual(t, "not a valid id", id)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			var id string
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				id = requestid.FromContext(ctx)
+				return nil, tc.handlerErr
+			}
+
+			info := &grpc.UnaryServerInfo{FullMethod: "/pb.SimpleBank/GetAccount"}
+			_, err := GrpcRequestID(tc.buildContext(), nil, info, handler)
+			tc.checkResponse(t, id, err)
+		})
+	}
+}
+
+func TestHttpRequestID(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	server := newTestServer(t, mockdb.NewMockStore(ctrl), nil)
+
+	grpcMux := runtime.NewServeMux(
+		runtime.WithMetadata(HttpRequestIDAnnotator),
+		runtime.WithErrorHandler(HttpErrorHandler),
+	)
+	require.NoError(t, pb.RegisterSimpleBankHandlerServer(context.Background(), grpcMux, server))
+	handler := HttpRequestID(grpcMux)
+
+	request := httptest.NewRequest(http.MethodGet, "/v1/webhook_deliveries/42", nil)
+	request.Header.Set(requestid.Header, "req-1")
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+	require.Equal(t, "req-1", recorder.Header().Get(requestid.Header))
+
+	var body struct {
+		Details []map[string]any `json:"details"`
+	}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+	require.Len(t, body.Details, 1)
+	require.Equal(t, "type.googleapis.com/google.rpc.RequestInfo", body.Details[0]["@type"])
+	require.Equal(t, "req-1", body.Details[0]["requestId"])
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/v1/webhook_deliveries/42", nil))
+	require.NotEmpty(t, recorder.Header().Get(requestid.Header))
+}
@@ -0,0 +1,54 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+
+	db "github.com/juker1141/simplebank/db/sqlc"
+	"github.com/juker1141/simplebank/pb"
+	"github.com/juker1141/simplebank/util"
+	"github.com/juker1141/simplebank/val"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) AcceptAccountInvitation(ctx context.Context, req *pb.AcceptAccountInvitationRequest) (*pb.AcceptAccountInvitationResponse, error) {
+	authPayload, err := server.authorizeUser(ctx, []string{util.BankerRole, util.DepositorRole})
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	violations := validateAcceptAccountInvitationRequest(req)
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	arg := db.AcceptAccountMemberParams{
+		AccountID: req.GetAccountId(),
+		Username: authPayload.Username,
+	}
+
+	// only pending invitations can be accepted
+	member, err := server.store.AcceptAccountMember(ctx, arg)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.NotFound, "no pending invitation for this account")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to accept invitation: %s", err)
+	}
+
+	rsp := &pb.AcceptAccountInvitationResponse{
+		Member: convertAccountMember(member),
+	}
+
+	return rsp, nil
+}
+
+func validateAcceptAccountInvitationRequest(req *pb.AcceptAccountInvitationRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := val.ValidateAccountId(req.GetAccountId()); err != nil {
+		violations = append(violations, fieldViolation("account_id", err))
+	}
+
+	return violations
+}
@@ -0,0 +1,112 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+
+	"github.com/juker1141/simplebank/audit"
+	db "github.com/juker1141/simplebank/db/sqlc"
+	"github.com/juker1141/simplebank/pb"
+	"github.com/juker1141/simplebank/util"
+	"github.com/juker1141/simplebank/val"
+	"github.com/juker1141/simplebank/worker"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) AcceptPaymentRequest(ctx context.Context, req *pb.AcceptPaymentRequestRequest) (*pb.AcceptPaymentRequestResponse, error) {
+	authPayload, err := server.authorizeUser(ctx, []string{util.BankerRole, util.DepositorRole})
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	violations := validateAcceptPaymentRequestRequest(req)
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	if err := server.requireVerifiedEmail(ctx, authPayload); err != nil {
+		return nil, err
+	}
+
+	paymentRequest, err := server.getReceivedPaymentRequest(ctx, req.GetId(), authPayload.Username)
+	if err != nil {
+		return nil, err
+	}
+
+	account, err := server.store.GetAccount(ctx, req.GetFromAccountId())
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.NotFound, "account not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get account: %s", err)
+	}
+
+	if account.Currency != paymentRequest.Currency {
+		return nil, status.Errorf(codes.InvalidArgument, "account [%d] currency mismatch: %s vs %s", account.ID, account.Currency, paymentRequest.Currency)
+	}
+
+	_, err = server.authorizeAccount(ctx, account.ID, authPayload.Username, db.AccountMember.CanTransfer)
+	if err != nil {
+		return nil, err
+	}
+
+	actor := server.auditActor(ctx, audit.UserActor(authPayload.Username))
+	arg := db.AcceptPaymentRequestTxParams{
+		ID: paymentRequest.ID,
+		FromAccountID: account.ID,
+		Tasks: paymentRequestEmailTasks,
+		TransferTasks: worker.TransferTasks,
+		Audit: func(result db.AcceptPaymentRequestTxResult) []db.AuditRecord {
+			return audit.TransferRecords(actor)(result.TransferTxResult)
+		},
+	}
+
+	txResult, err := server.store.AcceptPaymentRequestTx(ctx, arg)
+	if err != nil {
+		return nil, paymentRequestTxError(err)
+	}
+
+	rsp := &pb.AcceptPaymentRequestResponse{
+		PaymentRequest: convertPaymentRequest(txResult.PaymentRequest),
+	}
+
+	return rsp, nil
+}
+
+// getReceivedPaymentRequest returns the payment request if the user is its payer
+func (server *Server) getReceivedPaymentRequest(ctx context.Context, id int64, username string) (db.PaymentRequest, error) {
+	paymentRequest, err := server.store.GetPaymentRequest(ctx, id)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return paymentRequest, status.Errorf(codes.NotFound, "payment request not found")
+		}
+		return paymentRequest, status.Errorf(codes.Internal, "failed to get payment request: %s", err)
+	}
+
+	if paymentRequest.Payer != username {
+		return paymentRequest, status.Errorf(codes.PermissionDenied, "payment request wasn't sent to the authenticated user")
+	}
+
+	return paymentRequest, nil
+}
+
+func paymentRequestTxError(err error) error {
+	if errors.Is(err, db.ErrPaymentRequestNotPending) || errors.Is(err, db.ErrPaymentRequestExpired) {
+		return status.Errorf(codes.FailedPrecondition, "%s", err)
+	}
+	return status.Errorf(codes.Internal, "failed to respond to payment request: %s", err)
+}
+
+func validateAcceptPaymentRequestRequest(req *pb.AcceptPaymentRequestRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := val.ValidateId(req.GetId()); err != nil {
+		violations = append(violations, fieldViolation("id", err))
+	}
+
+	if err := val.ValidateAccountId(req.GetFromAccountId()); err != nil {
+		violations = append(violations, fieldViolation("from_account_id", err))
+	}
+
+	return violations
+}
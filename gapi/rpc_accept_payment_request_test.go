@@ -0,0 +1,399 @@
+package gapi
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/jackc/pgx/v5/pgtype"
+	mockdb "github.com/juker1141/simplebank/db/mock"
+	db "github.com/juker1141/simplebank/db/sqlc"
+	"github.com/juker1141/simplebank/pb"
+	"github.com/juker1141/simplebank/policy"
+	"github.com/juker1141/simplebank/token"
+	"github.com/juker1141/simplebank/util"
+	"github.com/juker1141/simplebank/worker"
+	mockwk "github.com/juker1141/simplebank/worker/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type eqAcceptPaymentRequestTxParamsMatcher struct {
+	arg db.AcceptPaymentRequestTxParams
+	paymentRequest db.PaymentRequest
+}
+
+func (expected eqAcceptPaymentRequestTxParamsMatcher) Matches(x interface{}) bool {
+	actualArg, ok := x.(db.AcceptPaymentRequestTxParams)
+	if !ok {
+		return false
+	}
+
+	if expected.arg.ID != actualArg.ID || expected.arg.FromAccountID != actualArg.FromAccountID || actualArg.TransferTasks == nil {
+		return false
+	}
+
+	// both sides and their webhook endpoints are told about the transfer, like for the other transfers
+	transferResult := db.TransferTxResult{
+		Transfer: db.Transfer{ID: util.RandomInt(1, 1000)},
+		FromAccount: db.Account{ID: expected.arg.FromAccountID, Owner: expected.paymentRequest.Payer},
+		ToAccount: db.Account{ID: expected.paymentRequest.ToAccountID, Owner: expected.paymentRequest.Payee},
+	}
+	notificationPayload := &worker.PayloadSendTransferNotification{
+		TransferID: transferResult.Transfer.ID,
+	}
+	transferTasks := actualArg.TransferTasks(transferResult)
+	if len(transferTasks) != 5 ||
+		!isOutboxTask(transferTasks[0:1], worker.TaskSendDebitNotification, notificationPayload) ||
+		!isOutboxTask(transferTasks[1:2], worker.TaskSendCreditNotification, notificationPayload) ||
+		!isWebhookEventTask(transferTasks[2], util.WebhookEventTransferCreated, []string{expected.paymentRequest.Payer, expected.paymentRequest.Payee}, []int64{expected.arg.FromAccountID, expected.paymentRequest.ToAccountID}, transferResult.Transfer) {
+		return false
+	}
+
+	// check the tasks written to the outbox with the payment request
+	taskPayload := &worker.PayloadSendPaymentRequestEmail{
+		PaymentRequestID: expected.paymentRequest.ID,
+	}
+
+	return isOutboxTask(actualArg.Tasks(expected.paymentRequest), worker.TaskSendPaymentRequestEmail, taskPayload)
+}
+
+func (e eqAcceptPaymentRequestTxParamsMatcher) String() string {
+	return fmt.Sprintf("matches arg %v", e.arg)
+}
+
+func eqAcceptPaymentRequestTxParams(arg db.AcceptPaymentRequestTxParams, paymentRequest db.PaymentRequest) gomock.Matcher {
+	return eqAcceptPaymentRequestTxParamsMatcher{arg, paymentRequest}
+}
+
+func randomPaymentRequest(payee string, payer string, toAccount db.Account) db.PaymentRequest {
+	return db.PaymentRequest{
+		ID: util.RandomInt(1, 1000),
+		Payee: payee,
+		Payer: payer,
+		ToAccountID: toAccount.ID,
+		Amount: util.RandomInt(1, 100),
+		Currency: toAccount.Currency,
+		Memo: util.RandomString(10),
+		Status: db.PaymentRequestStatusPending,
+		ExpiredAt: time.Now().Add(time.Hour),
+		CreatedAt: time.Now(),
+	}
+}
+
+func TestAcceptPaymentRequestAPI(t *testing.T) {
+	payee, _ := randomUser(t)
+	payer, _ := randomUser(t)
+
+	toAccount := randomAccount(payee.Username)
+	toAccount.Currency = util.USD
+	fromAccount := randomAccount(payer.Username)
+	fromAccount.Currency = util.USD
+	otherAccount := randomAccount(payer.Username)
+	otherAccount.Currency = util.EUR
+
+	paymentRequest := randomPaymentRequest(payee.Username, payer.Username, toAccount)
+
+	transferID := util.RandomInt(1, 1000)
+	acceptedRequest := paymentRequest
+	acceptedRequest.Status = db.PaymentRequestStatusAccepted
+	acceptedRequest.FromAccountID = pgtype.Int8{Int64: fromAccount.ID, Valid: true}
+	acceptedRequest.TransferID = pgtype.Int8{Int64: transferID, Valid: true}
+
+	payerMember := db.AccountMember{
+		AccountID: fromAccount.ID,
+		Username: payer.Username,
+		Role: util.AccountOwnerRole,
+		InvitedBy: payer.Username,
+		IsAccepted: true,
+	}
+
+	testCases := []struct{
+		name string
+		req *pb.AcceptPaymentRequestRequest
+		buildStubs func(store *mockdb.MockStore, taskDistributor *mockwk.MockTaskDistribtor)
+		buildContext func(t *testing.T, tokenMaker token.Maker) context.Context
+		checkResponse func(t *testing.T, res *pb.AcceptPaymentRequestResponse, err error)
+	}{
+		{
+			name: "OK",
+			req: &pb.AcceptPaymentRequestRequest{
+				Id: paymentRequest.ID,
+				FromAccountId: fromAccount.ID,
+			},
+			buildStubs: func(store *mockdb.MockStore, taskDistributor *mockwk.MockTaskDistribtor) {
+				store.EXPECT().
+					GetPaymentRequest(gomock.Any(), gomock.Eq(paymentRequest.ID)).
+					Times(1).
+					Return(paymentRequest, nil)
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(fromAccount.ID)).
+					Times(1).
+					Return(fromAccount, nil)
+				store.EXPECT().
+					GetAccountMember(gomock.Any(), gomock.Eq(db.GetAccountMemberParams{AccountID: fromAccount.ID, Username: payer.Username})).
+					Times(1).
+					Return(payerMember, nil)
+
+				arg := db.AcceptPaymentRequestTxParams{
+					ID: paymentRequest.ID,
+					FromAccountID: fromAccount.ID,
+				}
+				store.EXPECT().
+					AcceptPaymentRequestTx(gomock.Any(), eqAcceptPaymentRequestTxParams(arg, acceptedRequest)).
+					Times(1).
+					Return(db.AcceptPaymentRequestTxResult{PaymentRequest: acceptedRequest}, nil)
+
+				taskDistributor.EXPECT().
+					DistributeTaskSendPaymentRequestEmail(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, payer.Username, payer.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.AcceptPaymentRequestResponse, err error) {
+				require.NoError(t, err)
+				require.NotNil(t, res)
+				accepted := res.GetPaymentRequest()
+				require.Equal(t, db.PaymentRequestStatusAccepted, accepted.Status)
+				require.Equal(t, fromAccount.ID, accepted.FromAccountId)
+				require.Equal(t, transferID, accepted.TransferId)
+			},
+		},
+		{
+			name: "NotPayer",
+			req: &pb.AcceptPaymentRequestRequest{
+				Id: paymentRequest.ID,
+				FromAccountId: toAccount.ID,
+			},
+			buildStubs: func(store *mockdb.MockStore, taskDistributor *mockwk.MockTaskDistribtor) {
+				store.EXPECT().
+					GetPaymentRequest(gomock.Any(), gomock.Eq(paymentRequest.ID)).
+					Times(1).
+					Return(paymentRequest, nil)
+				store.EXPECT().
+					AcceptPaymentRequestTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, payee.Username, payee.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.AcceptPaymentRequestResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.PermissionDenied, st.Code())
+			},
+		},
+		{
+			name: "NotFound",
+			req: &pb.AcceptPaymentRequestRequest{
+				Id: paymentRequest.ID,
+				FromAccountId: fromAccount.ID,
+			},
+			buildStubs: func(store *mockdb.MockStore, taskDistributor *mockwk.MockTaskDistribtor) {
+				store.EXPECT().
+					GetPaymentRequest(gomock.Any(), gomock.Eq(paymentRequest.ID)).
+					Times(1).
+					Return(db.PaymentRequest{}, db.ErrRecordNotFound)
+				store.EXPECT().
+					AcceptPaymentRequestTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, payer.Username, payer.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.AcceptPaymentRequestResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.NotFound, st.Code())
+			},
+		},
+		{
+			name: "CurrencyMismatch",
+			req: &pb.AcceptPaymentRequestRequest{
+				Id: paymentRequest.ID,
+				FromAccountId: otherAccount.ID,
+			},
+			buildStubs: func(store *mockdb.MockStore, taskDistributor *mockwk.MockTaskDistribtor) {
+				store.EXPECT().
+					GetPaymentRequest(gomock.Any(), gomock.Eq(paymentRequest.ID)).
+					Times(1).
+					Return(paymentRequest, nil)
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(otherAccount.ID)).
+					Times(1).
+					Return(otherAccount, nil)
+				store.EXPECT().
+					AcceptPaymentRequestTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, payer.Username, payer.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.AcceptPaymentRequestResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.InvalidArgument, st.Code())
+			},
+		},
+		{
+			name: "ViewOnlyMember",
+			req: &pb.AcceptPaymentRequestRequest{
+				Id: paymentRequest.ID,
+				FromAccountId: fromAccount.ID,
+			},
+			buildStubs: func(store *mockdb.MockStore, taskDistributor *mockwk.MockTaskDistribtor) {
+				member := payerMember
+				member.Role = util.AccountViewOnlyRole
+
+				store.EXPECT().
+					GetPaymentRequest(gomock.Any(), gomock.Eq(paymentRequest.ID)).
+					Times(1).
+					Return(paymentRequest, nil)
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(fromAccount.ID)).
+					Times(1).
+					Return(fromAccount, nil)
+				store.EXPECT().
+					GetAccountMember(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(member, nil)
+				store.EXPECT().
+					AcceptPaymentRequestTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, payer.Username, payer.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.AcceptPaymentRequestResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.PermissionDenied, st.Code())
+			},
+		},
+		{
+			name: "NotPending",
+			req: &pb.AcceptPaymentRequestRequest{
+				Id: paymentRequest.ID,
+				FromAccountId: fromAccount.ID,
+			},
+			buildStubs: func(store *mockdb.MockStore, taskDistributor *mockwk.MockTaskDistribtor) {
+				store.EXPECT().
+					GetPaymentRequest(gomock.Any(), gomock.Eq(paymentRequest.ID)).
+					Times(1).
+					Return(paymentRequest, nil)
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(fromAccount.ID)).
+					Times(1).
+					Return(fromAccount, nil)
+				store.EXPECT().
+					GetAccountMember(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(payerMember, nil)
+				store.EXPECT().
+					AcceptPaymentRequestTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.AcceptPaymentRequestTxResult{}, db.ErrPaymentRequestNotPending)
+				taskDistributor.EXPECT().
+					DistributeTaskSendPaymentRequestEmail(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, payer.Username, payer.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.AcceptPaymentRequestResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.FailedPrecondition, st.Code())
+			},
+		},
+		{
+			name: "InvalidID",
+			req: &pb.AcceptPaymentRequestRequest{
+				Id: 0,
+				FromAccountId: fromAccount.ID,
+			},
+			buildStubs: func(store *mockdb.MockStore, taskDistributor *mockwk.MockTaskDistribtor) {
+				store.EXPECT().
+					GetPaymentRequest(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, payer.Username, payer.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.AcceptPaymentRequestResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.InvalidArgument, st.Code())
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T){
+			storeCtrl := gomock.NewController(t)
+			defer storeCtrl.Finish()
+			store := mockdb.NewMockStore(storeCtrl)
+
+			taskCtrl := gomock.NewController(t)
+			defer taskCtrl.Finish()
+			taskDistributor := mockwk.NewMockTaskDistribtor(taskCtrl)
+
+			tc.buildStubs(store, taskDistributor)
+
+			server := newTestServer(t, store, taskDistributor)
+
+			ctx := tc.buildContext(t, server.tokenMaker)
+			res, err := server.AcceptPaymentRequest(ctx, tc.req)
+
+			tc.checkResponse(t, res, err)
+		})
+	}
+}
+
+func TestAcceptPaymentRequestEmailNotVerified(t *testing.T) {
+	payer, _ := randomUser(t)
+
+	storeCtrl := gomock.NewController(t)
+	defer storeCtrl.Finish()
+	store := mockdb.NewMockStore(storeCtrl)
+
+	store.EXPECT().
+		GetUser(gomock.Any(), gomock.Eq(payer.Username)).
+		Times(1).
+		Return(payer, nil)
+	store.EXPECT().
+		AcceptPaymentRequestTx(gomock.Any(), gomock.Any()).
+		Times(0)
+
+	server := newTestServer(t, store, nil)
+	server.verifiedEmail = policy.NewVerifiedEmail(true, true, store)
+
+	ctx := newContextWithBearerToken(t, server.tokenMaker, payer.Username, payer.Role, time.Minute)
+	_, err := server.AcceptPaymentRequest(ctx, &pb.AcceptPaymentRequestRequest{
+		Id: util.RandomInt(1, 1000),
+		FromAccountId: util.RandomInt(1, 1000),
+	})
+	require.Error(t, err)
+
+	st, ok := status.FromError(err)
+	require.True(t, ok)
+	require.Equal(t, codes.FailedPrecondition, st.Code())
+	require.Len(t, st.Details(), 1)
+
+	errorInfo, ok := st.Details()[0].(*errdetails.ErrorInfo)
+	require.True(t, ok)
+	require.Equal(t, policy.ReasonEmailNotVerified, errorInfo.Reason)
+}
\ No newline at end of file
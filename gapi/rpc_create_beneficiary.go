@@ -0,0 +1,92 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+
+	db "github.com/juker1141/simplebank/db/sqlc"
+	"github.com/juker1141/simplebank/pb"
+	"github.com/juker1141/simplebank/util"
+	"github.com/juker1141/simplebank/val"
+	"github.com/juker1141/simplebank/worker"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) CreateBeneficiary(ctx context.Context, req *pb.CreateBeneficiaryRequest) (*pb.CreateBeneficiaryResponse, error) {
+	authPayload, err := server.authorizeUser(ctx, []string{util.BankerRole, util.DepositorRole})
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	violations := validateCreateBeneficiaryRequest(req)
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	account, err := server.store.GetAccount(ctx, req.GetAccountId())
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.NotFound, "account not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get account: %s", err)
+	}
+
+	if account.Currency != req.GetCurrency() {
+		return nil, status.Errorf(codes.InvalidArgument, "account [%d] currency mismatch: %s vs %s", account.ID, account.Currency, req.GetCurrency())
+	}
+
+	arg := db.CreateBeneficiaryTxParams{
+		CreateBeneficiaryParams: db.CreateBeneficiaryParams{
+			Owner: authPayload.Username,
+			Nickname: req.GetNickname(),
+			AccountID: account.ID,
+			Currency: account.Currency,
+		},
+		Tasks: func(beneficiary db.Beneficiary) []db.OutboxTask {
+			taskPayload := &worker.PayloadSendBeneficiaryAddedEmail{
+				BeneficiaryID: beneficiary.ID,
+			}
+
+			return []db.OutboxTask{
+				{
+					TaskType: worker.TaskSendBeneficiaryAddedEmail,
+					Payload: taskPayload,
+					Queue: worker.QueueCritical,
+					MaxRetry: 10,
+				},
+			}
+		},
+	}
+
+	txResult, err := server.store.CreateBeneficiaryTx(ctx, arg)
+	if err != nil {
+		if db.ErrorCode(err) == db.UniqueViolation {
+			return nil, status.Errorf(codes.AlreadyExists, "beneficiary already exists")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to create beneficiary: %s", err)
+	}
+
+	rsp := &pb.CreateBeneficiaryResponse{
+		Beneficiary: convertBeneficiary(txResult.Beneficiary),
+	}
+
+	return rsp, nil
+}
+
+func validateCreateBeneficiaryRequest(req *pb.CreateBeneficiaryRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := val.ValidateNickname(req.GetNickname()); err != nil {
+		violations = append(violations, fieldViolation("nickname", err))
+	}
+
+	if err := val.ValidateAccountId(req.GetAccountId()); err != nil {
+		violations = append(violations, fieldViolation("account_id", err))
+	}
+
+	if err := val.ValidateCurrency(req.GetCurrency()); err != nil {
+		violations = append(violations, fieldViolation("currency", err))
+	}
+
+	return violations
+}
@@ -0,0 +1,297 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/juker1141/simplebank/db/mock"
+	db "github.com/juker1141/simplebank/db/sqlc"
+	"github.com/juker1141/simplebank/pb"
+	"github.com/juker1141/simplebank/token"
+	"github.com/juker1141/simplebank/util"
+	"github.com/juker1141/simplebank/worker"
+	mockwk "github.com/juker1141/simplebank/worker/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type eqCreateBeneficiaryTxParamsMatcher struct {
+	arg db.CreateBeneficiaryTxParams
+	beneficiary db.Beneficiary
+}
+
+func (expected eqCreateBeneficiaryTxParamsMatcher) Matches(x interface{}) bool {
+	actualArg, ok := x.(db.CreateBeneficiaryTxParams)
+	if !ok {
+		return false
+	}
+
+	if !reflect.DeepEqual(expected.arg.CreateBeneficiaryParams, actualArg.CreateBeneficiaryParams) {
+		return false
+	}
+
+	// check the tasks written to the outbox with the beneficiary
+	taskPayload := &worker.PayloadSendBeneficiaryAddedEmail{
+		BeneficiaryID: expected.beneficiary.ID,
+	}
+
+	return isOutboxTask(actualArg.Tasks(expected.beneficiary), worker.TaskSendBeneficiaryAddedEmail, taskPayload)
+}
+
+func (e eqCreateBeneficiaryTxParamsMatcher) String() string {
+	return fmt.Sprintf("matches arg %v", e.arg)
+}
+
+func eqCreateBeneficiaryTxParams(arg db.CreateBeneficiaryTxParams, beneficiary db.Beneficiary) gomock.Matcher {
+	return eqCreateBeneficiaryTxParamsMatcher{arg, beneficiary}
+}
+
+func TestCreateBeneficiaryAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	payee, _ := randomUser(t)
+	account := randomAccount(payee.Username)
+	account.Currency = util.USD
+
+	beneficiary := db.Beneficiary{
+		ID: util.RandomInt(1, 1000),
+		Owner: user.Username,
+		Nickname: util.RandomOwner(),
+		AccountID: account.ID,
+		Currency: account.Currency,
+		CreatedAt: time.Now(),
+	}
+
+	testCases := []struct{
+		name string
+		req *pb.CreateBeneficiaryRequest
+		buildStubs func(store *mockdb.MockStore, taskDistributor *mockwk.MockTaskDistribtor)
+		buildContext func(t *testing.T, tokenMaker token.Maker) context.Context
+		checkResponse func(t *testing.T, res *pb.CreateBeneficiaryResponse, err error)
+	}{
+		{
+			name: "OK",
+			req: &pb.CreateBeneficiaryRequest{
+				Nickname: beneficiary.Nickname,
+				AccountId: account.ID,
+				Currency: account.Currency,
+			},
+			buildStubs: func(store *mockdb.MockStore, taskDistributor *mockwk.MockTaskDistribtor) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+
+				arg := db.CreateBeneficiaryTxParams{
+					CreateBeneficiaryParams: db.CreateBeneficiaryParams{
+						Owner: user.Username,
+						Nickname: beneficiary.Nickname,
+						AccountID: account.ID,
+						Currency: account.Currency,
+					},
+				}
+				store.EXPECT().
+					CreateBeneficiaryTx(gomock.Any(), eqCreateBeneficiaryTxParams(arg, beneficiary)).
+					Times(1).
+					Return(db.CreateBeneficiaryTxResult{Beneficiary: beneficiary}, nil)
+
+				taskDistributor.EXPECT().
+					DistributeTaskSendBeneficiaryAddedEmail(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateBeneficiaryResponse, err error) {
+				require.NoError(t, err)
+				require.NotNil(t, res)
+				createdBeneficiary := res.GetBeneficiary()
+				require.Equal(t, beneficiary.ID, createdBeneficiary.Id)
+				require.Equal(t, beneficiary.Nickname, createdBeneficiary.Nickname)
+				require.Equal(t, beneficiary.AccountID, createdBeneficiary.AccountId)
+				require.Equal(t, beneficiary.Currency, createdBeneficiary.Currency)
+			},
+		},
+		{
+			name: "AccountNotFound",
+			req: &pb.CreateBeneficiaryRequest{
+				Nickname: beneficiary.Nickname,
+				AccountId: account.ID,
+				Currency: account.Currency,
+			},
+			buildStubs: func(store *mockdb.MockStore, taskDistributor *mockwk.MockTaskDistribtor) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(db.Account{}, db.ErrRecordNotFound)
+				store.EXPECT().
+					CreateBeneficiaryTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateBeneficiaryResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.NotFound, st.Code())
+			},
+		},
+		{
+			name: "CurrencyMismatch",
+			req: &pb.CreateBeneficiaryRequest{
+				Nickname: beneficiary.Nickname,
+				AccountId: account.ID,
+				Currency: util.EUR,
+			},
+			buildStubs: func(store *mockdb.MockStore, taskDistributor *mockwk.MockTaskDistribtor) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+				store.EXPECT().
+					CreateBeneficiaryTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateBeneficiaryResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.InvalidArgument, st.Code())
+			},
+		},
+		{
+			name: "AlreadyExists",
+			req: &pb.CreateBeneficiaryRequest{
+				Nickname: beneficiary.Nickname,
+				AccountId: account.ID,
+				Currency: account.Currency,
+			},
+			buildStubs: func(store *mockdb.MockStore, taskDistributor *mockwk.MockTaskDistribtor) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+				store.EXPECT().
+					CreateBeneficiaryTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.CreateBeneficiaryTxResult{}, db.ErrUniqueViolation)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateBeneficiaryResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.AlreadyExists, st.Code())
+			},
+		},
+		{
+			name: "InternalError",
+			req: &pb.CreateBeneficiaryRequest{
+				Nickname: beneficiary.Nickname,
+				AccountId: account.ID,
+				Currency: account.Currency,
+			},
+			buildStubs: func(store *mockdb.MockStore, taskDistributor *mockwk.MockTaskDistribtor) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+				store.EXPECT().
+					CreateBeneficiaryTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.CreateBeneficiaryTxResult{}, sql.ErrConnDone)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateBeneficiaryResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.Internal, st.Code())
+			},
+		},
+		{
+			name: "InvalidNickname",
+			req: &pb.CreateBeneficiaryRequest{
+				Nickname: "invalid#nickname",
+				AccountId: account.ID,
+				Currency: account.Currency,
+			},
+			buildStubs: func(store *mockdb.MockStore, taskDistributor *mockwk.MockTaskDistribtor) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().
+					CreateBeneficiaryTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateBeneficiaryResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.InvalidArgument, st.Code())
+			},
+		},
+		{
+			name: "NoAuthorization",
+			req: &pb.CreateBeneficiaryRequest{
+				Nickname: beneficiary.Nickname,
+				AccountId: account.ID,
+				Currency: account.Currency,
+			},
+			buildStubs: func(store *mockdb.MockStore, taskDistributor *mockwk.MockTaskDistribtor) {
+				store.EXPECT().
+					CreateBeneficiaryTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return context.Background()
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateBeneficiaryResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.Unauthenticated, st.Code())
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T){
+			storeCtrl := gomock.NewController(t)
+			defer storeCtrl.Finish()
+			store := mockdb.NewMockStore(storeCtrl)
+
+			taskCtrl := gomock.NewController(t)
+			defer taskCtrl.Finish()
+			taskDistributor := mockwk.NewMockTaskDistribtor(taskCtrl)
+
+			tc.buildStubs(store, taskDistributor)
+
+			server := newTestServer(t, store, taskDistributor)
+
+			ctx := tc.buildContext(t, server.tokenMaker)
+			res, err := server.CreateBeneficiary(ctx, tc.req)
+
+			tc.checkResponse(t, res, err)
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	db "github.com/juker1141/simplebank/db/sqlc"
+	"github.com/juker1141/simplebank/pb"
+	"github.com/juker1141/simplebank/util"
+	"github.com/juker1141/simplebank/val"
+	"github.com/juker1141/simplebank/worker"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// defaultPaymentRequestDuration is used when the payee doesn't set an expiry
+const defaultPaymentRequestDuration = 7 * 24 * time.Hour
+
+func (server *Server) CreatePaymentRequest(ctx context.Context, req *pb.CreatePaymentRequestRequest) (*pb.CreatePaymentRequestResponse, error) {
+	authPayload, err := server.authorizeUser(ctx, []string{util.BankerRole, util.DepositorRole})
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	violations := validateCreatePaymentRequestRequest(req, authPayload.Username)
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	account, err := server.store.GetAccount(ctx, req.GetToAccountId())
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.NotFound, "account not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get account: %s", err)
+	}
+
+	if account.Currency != req.GetCurrency() {
+		return nil, status.Errorf(codes.InvalidArgument, "account [%d] currency mismatch: %s vs %s", account.ID, account.Currency, req.GetCurrency())
+	}
+
+	// the view-only members of a joint account can't request money into it
+	_, err = server.authorizeAccount(ctx, account.ID, authPayload.Username, db.AccountMember.CanTransfer)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = server.store.GetUser(ctx, req.GetPayer())
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.NotFound, "payer not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get payer: %s", err)
+	}
+
+	expiredAt := time.Now().Add(defaultPaymentRequestDuration)
+	if req.ExpiredAt != nil {
+		expiredAt = req.GetExpiredAt().AsTime()
+	}
+
+	arg := db.CreatePaymentRequestTxParams{
+		CreatePaymentRequestParams: db.CreatePaymentRequestParams{
+			Payee: authPayload.Username,
+			Payer: req.GetPayer(),
+			ToAccountID: account.ID,
+			Amount: req.GetAmount(),
+			Currency: account.Currency,
+			Memo: req.GetMemo(),
+			ExpiredAt: expiredAt,
+		},
+		Tasks: paymentRequestEmailTasks,
+	}
+
+	txResult, err := server.store.CreatePaymentRequestTx(ctx, arg)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to create payment request: %s", err)
+	}
+
+	rsp := &pb.CreatePaymentRequestResponse{
+		PaymentRequest: convertPaymentRequest(txResult.PaymentRequest),
+	}
+
+	return rsp, nil
+}
+
+// paymentRequestEmailTasks notifies the other side of the payment request about its status
+func paymentRequestEmailTasks(paymentRequest db.PaymentRequest) []db.OutboxTask {
+	taskPayload := &worker.PayloadSendPaymentRequestEmail{
+		PaymentRequestID: paymentRequest.ID,
+	}
+
+	return []db.OutboxTask{
+		{
+			TaskType: worker.TaskSendPaymentRequestEmail,
+			Payload: taskPayload,
+			Queue: worker.QueueCritical,
+			MaxRetry: 10,
+			ProcessIn: 10 * time.Second,
+		},
+	}
+}
+
+func validateCreatePaymentRequestRequest(req *pb.CreatePaymentRequestRequest, payee string) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := val.ValidateUsername(req.GetPayer()); err != nil {
+		violations = append(violations, fieldViolation("payer", err))
+	} else if req.GetPayer() == payee {
+		violations = append(violations, fieldViolation("payer", fmt.Errorf("cannot request money from yourself")))
+	}
+
+	if err := val.ValidateAccountId(req.GetToAccountId()); err != nil {
+		violations = append(violations, fieldViolation("to_account_id", err))
+	}
+
+	if err := val.ValidateAmount(req.GetAmount()); err != nil {
+		violations = append(violations, fieldViolation("amount", err))
+	}
+
+	if err := val.ValidateCurrency(req.GetCurrency()); err != nil {
+		violations = append(violations, fieldViolation("currency", err))
+	}
+
+	if err := val.ValidateMemo(req.GetMemo()); err != nil {
+		violations = append(violations, fieldViolation("memo", err))
+	}
+
+	if req.ExpiredAt != nil && !req.GetExpiredAt().AsTime().After(time.Now()) {
+		violations = append(violations, fieldViolation("expired_at", fmt.Errorf("must be in the future")))
+	}
+
+	return violations
+}
@@ -0,0 +1,112 @@
+package gapi
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/juker1141/simplebank/db/mock"
+	db "github.com/juker1141/simplebank/db/sqlc"
+	"github.com/juker1141/simplebank/pb"
+	"github.com/juker1141/simplebank/util"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestCreatePaymentRequestAPI(t *testing.T) {
+	payee, _ := randomUser(t)
+	payer, _ := randomUser(t)
+
+	toAccount := randomAccount(payee.Username)
+	paymentRequest := randomPaymentRequest(payee.Username, payer.Username, toAccount)
+
+	req := &pb.CreatePaymentRequestRequest{
+		Payer: payer.Username,
+		ToAccountId: toAccount.ID,
+		Amount: paymentRequest.Amount,
+		Currency: toAccount.Currency,
+		Memo: paymentRequest.Memo,
+	}
+
+	member := func(role string) db.AccountMember {
+		return db.AccountMember{
+			AccountID: toAccount.ID,
+			Username: payee.Username,
+			Role: role,
+			InvitedBy: payee.Username,
+			IsAccepted: true,
+		}
+	}
+
+	testCases := []struct{
+		name string
+		buildStubs func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, res *pb.CreatePaymentRequestResponse, err error)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(toAccount.ID)).
+					Times(1).
+					Return(toAccount, nil)
+				store.EXPECT().
+					GetAccountMember(gomock.Any(), gomock.Eq(db.GetAccountMemberParams{AccountID: toAccount.ID, Username: payee.Username})).
+					Times(1).
+					Return(member(util.AccountCanTransferRole), nil)
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(payer.Username)).
+					Times(1).
+					Return(payer, nil)
+				store.EXPECT().
+					CreatePaymentRequestTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.CreatePaymentRequestTxResult{PaymentRequest: paymentRequest}, nil)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreatePaymentRequestResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, paymentRequest.ID, res.GetPaymentRequest().GetId())
+			},
+		},
+		{
+			name: "ViewOnlyMember",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(toAccount.ID)).
+					Times(1).
+					Return(toAccount, nil)
+				store.EXPECT().
+					GetAccountMember(gomock.Any(), gomock.Eq(db.GetAccountMemberParams{AccountID: toAccount.ID, Username: payee.Username})).
+					Times(1).
+					Return(member(util.AccountViewOnlyRole), nil)
+				store.EXPECT().
+					CreatePaymentRequestTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreatePaymentRequestResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.PermissionDenied, st.Code())
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store, nil)
+			ctx := newContextWithBearerToken(t, server.tokenMaker, payee.Username, payee.Role, time.Minute)
+			res, err := server.CreatePaymentRequest(ctx, req)
+			tc.checkResponse(t, res, err)
+		})
+	}
+}
\ No newline at end of file
@@ -4,7 +4,6 @@ import (
 	"context"
 	"time"
 
-	"github.com/hibiken/asynq"
 	db "github.com/juker1141/simplebank/db/sqlc"
 	"github.com/juker1141/simplebank/pb"
 	"github.com/juker1141/simplebank/util"
@@ -33,17 +32,23 @@ func (server *Server) CreateUser(ctx context.Context, req  *pb.CreateUserRequest
 			FullName: req.GetFullName(),
 			Email: req.GetEmail(),
 		},
-		AfterCreate: func(user db.User) error {
+		// the code is created with the user, so that the retries of the task send the same one
+		VerifySecretCode: util.RandomString(32),
+		Tasks: func(result db.CreateUserTxResult) []db.OutboxTask {
 			taskPayload := &worker.PayloadSendVerifyEmail{
-				Username: user.Username,
-			}
-			opt := []asynq.Option{
-				asynq.MaxRetry(10),
-				asynq.ProcessIn(10 * time.Second),
-				asynq.Queue(worker.QueueCritical),
+				Username: result.User.Username,
+				VerifyEmailID: result.VerifyEmail.ID,
 			}
 
-			return server.taskDistributor.DistributeTaskSendVerifyEmail(ctx, taskPayload, opt...)
+			return []db.OutboxTask{
+				{
+					TaskType: worker.TaskSendVerifyEmail,
+					Payload: taskPayload,
+					Queue: worker.QueueCritical,
+					MaxRetry: 10,
+					ProcessIn: 10 * time.Second,
+				},
+			}
 		},
 	}
 
@@ -42,10 +42,25 @@ func (expected eqCreateUserTxParamsMatcher) Matches(x interface{}) bool {
 		return false
 	}
 
-	// call the AfterCreate function here
-	err = actualArg.AfterCreate(expected.user)
+	if len(actualArg.VerifySecretCode) != 32 {
+		return false
+	}
 
-	return err == nil
+	// check the tasks written to the outbox with the user, which send the code created with it
+	result := db.CreateUserTxResult{
+		User: expected.user,
+		VerifyEmail: db.VerifyEmail{
+			ID: util.RandomInt(1, 1000),
+			Username: expected.user.Username,
+			Email: expected.user.Email,
+		},
+	}
+	taskPayload := &worker.PayloadSendVerifyEmail{
+		Username: expected.user.Username,
+		VerifyEmailID: result.VerifyEmail.ID,
+	}
+
+	return isOutboxTask(actualArg.Tasks(result), worker.TaskSendVerifyEmail, taskPayload)
 }
 
 func (e eqCreateUserTxParamsMatcher) String() string {
@@ -66,6 +81,7 @@ func randomUser(t *testing.T) (user db.User, password string) {
 		HashedPassword: hashedPassword,
 		FullName: util.RandomOwner(),
 		Email: util.RandomEmail(),
+		Role: util.DepositorRole,
 	}
 	return
 } 
@@ -100,15 +116,10 @@ func TestCreateUserAPI(t *testing.T) {
 					CreateUserTx(gomock.Any(), eqCreateUserTxParams(arg, password, user)).
 					Times(1).
 					Return(db.CreateUserTxResult{User: user}, nil)
-					
-				taskPayload := &worker.PayloadSendVerifyEmail{
-					Username: user.Username,
-				}
-				
+
 				taskDistributor.EXPECT().
-					DistributeTaskSendVerifyEmail(gomock.Any(), taskPayload, gomock.Any()).
-					Times(1).
-					Return(nil)
+					DistributeTaskSendVerifyEmail(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
 			},
 			checkResponse: func(t *testing.T, res *pb.CreateUserResponse,err error) {
 				require.NoError(t, err)
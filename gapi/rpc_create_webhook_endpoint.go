@@ -0,0 +1,171 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/juker1141/simplebank/db/sqlc"
+	"github.com/juker1141/simplebank/pb"
+	"github.com/juker1141/simplebank/util"
+	"github.com/juker1141/simplebank/val"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) CreateWebhookEndpoint(ctx context.Context, req *pb.CreateWebhookEndpointRequest) (*pb.CreateWebhookEndpointResponse, error) {
+	owner, err := server.authorizeWebhookOwner(ctx)
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	// the endpoints of an API key without the scope would never receive an event
+	if owner.APIKey.Valid && !owner.receivesEvents {
+		return nil, status.Errorf(codes.PermissionDenied, "api key doesn't have the %s scope", util.APIKeyScopeWebhooks)
+	}
+
+	violations := validateCreateWebhookEndpointRequest(req)
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	secret, err := util.NewWebhookSecret()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "%s", err)
+	}
+
+	endpoint, err := server.store.CreateWebhookEndpoint(ctx, db.CreateWebhookEndpointParams{
+		Owner: owner.Username,
+		ApiKeyID: owner.APIKey,
+		Url: req.GetUrl(),
+		Secret: secret,
+		Events: uniqueStrings(req.GetEvents()),
+		CreatedBy: owner.name,
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to create webhook endpoint: %s", err)
+	}
+
+	rsp := &pb.CreateWebhookEndpointResponse{
+		Endpoint: convertWebhookEndpoint(endpoint),
+		Secret: secret,
+	}
+
+	return rsp, nil
+}
+
+func validateCreateWebhookEndpointRequest(req *pb.CreateWebhookEndpointRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := val.ValidateWebhookURL(req.GetUrl()); err != nil {
+		violations = append(violations, fieldViolation("url", err))
+	}
+
+	if len(req.GetEvents()) == 0 {
+		violations = append(violations, fieldViolation("events", fmt.Errorf("must contain at least one event")))
+	}
+
+	for _, event := range req.GetEvents() {
+		if err := val.ValidateWebhookEvent(event); err != nil {
+			violations = append(violations, fieldViolation("events", err))
+		}
+	}
+
+	return violations
+}
+
+// webhookOwner owns the webhook endpoints of the caller, which is either a user or an API key
+type webhookOwner struct {
+	Username pgtype.Text
+	APIKey   pgtype.Int8
+	// name is the name of the caller
+	name string
+	// receivesEvents is false for an API key without the webhooks scope
+	receivesEvents bool
+}
+
+// owns returns true if the endpoint belongs to the owner
+func (owner webhookOwner) owns(endpoint db.WebhookEndpoint) bool {
+	return endpoint.Owner == owner.Username && endpoint.ApiKeyID == owner.APIKey
+}
+
+// authorizeWebhookOwner accepts either an API key, whose endpoints receive the events of every user
+// while the key has the webhooks scope, or the access token of a user, whose endpoints only receive the user's events.
+// Each API key only manages its own endpoints
+func (server *Server) authorizeWebhookOwner(ctx context.Context) (webhookOwner, error) {
+	if md, ok := metadata.FromIncomingContext(ctx); ok && len(md.Get(apiKeyHeader)) > 0 {
+		apiKey, err := server.authorizeAPIKey(ctx)
+		if err != nil {
+			return webhookOwner{}, err
+		}
+
+		owner := webhookOwner{
+			APIKey: pgtype.Int8{
+				Int64: apiKey.ID,
+				Valid: true,
+			},
+			name: fmt.Sprintf("api_key:%s", apiKey.Name),
+			receivesEvents: apiKey.HasScope(util.APIKeyScopeWebhooks),
+		}
+		return owner, nil
+	}
+
+	payload, err := server.authorizeUser(ctx, []string{util.BankerRole, util.DepositorRole})
+	if err != nil {
+		return webhookOwner{}, err
+	}
+
+	owner := webhookOwner{
+		Username: pgtype.Text{
+			String: payload.Username,
+			Valid: true,
+		},
+		name: fmt.Sprintf("user:%s", payload.Username),
+		receivesEvents: true,
+	}
+	return owner, nil
+}
+
+// getWebhookEndpoint returns the webhook endpoint if it belongs to the owner
+func (server *Server) getWebhookEndpoint(ctx context.Context, id int64, owner webhookOwner) (db.WebhookEndpoint, error) {
+	endpoint, err := server.store.GetWebhookEndpoint(ctx, id)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return endpoint, status.Errorf(codes.NotFound, "webhook endpoint not found")
+		}
+		return endpoint, status.Errorf(codes.Internal, "failed to get webhook endpoint: %s", err)
+	}
+
+	if !owner.owns(endpoint) {
+		return endpoint, status.Errorf(codes.PermissionDenied, "webhook endpoint doesn't belong to the caller")
+	}
+
+	return endpoint, nil
+}
+
+// getWebhookDelivery returns the webhook delivery and its endpoint if the endpoint belongs to the owner
+func (server *Server) getWebhookDelivery(ctx context.Context, id int64, owner webhookOwner) (db.WebhookDelivery, db.WebhookEndpoint, error) {
+	delivery, err := server.store.GetWebhookDelivery(ctx, id)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return delivery, db.WebhookEndpoint{}, status.Errorf(codes.NotFound, "webhook delivery not found")
+		}
+		return delivery, db.WebhookEndpoint{}, status.Errorf(codes.Internal, "failed to get webhook delivery: %s", err)
+	}
+
+	endpoint, err := server.getWebhookEndpoint(ctx, delivery.EndpointID, owner)
+	return delivery, endpoint, err
+}
+
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	unique := make([]string, 0, len(values))
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			unique = append(unique, value)
+		}
+	}
+	return unique
+}
\ No newline at end of file
@@ -0,0 +1,273 @@
+package gapi
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/jackc/pgx/v5/pgtype"
+	mockdb "github.com/juker1141/simplebank/db/mock"
+	db "github.com/juker1141/simplebank/db/sqlc"
+	"github.com/juker1141/simplebank/pb"
+	"github.com/juker1141/simplebank/token"
+	"github.com/juker1141/simplebank/util"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type eqCreateWebhookEndpointParamsMatcher struct {
+	arg db.CreateWebhookEndpointParams
+}
+
+func (expected eqCreateWebhookEndpointParamsMatcher) Matches(x interface{}) bool {
+	actualArg, ok := x.(db.CreateWebhookEndpointParams)
+	if !ok {
+		return false
+	}
+
+	// the secret is random, so only its format is checked
+	if !strings.HasPrefix(actualArg.Secret, "whsec_") {
+		return false
+	}
+
+	expected.arg.Secret = actualArg.Secret
+	return reflect.DeepEqual(expected.arg, actualArg)
+}
+
+func (e eqCreateWebhookEndpointParamsMatcher) String() string {
+	return fmt.Sprintf("matches arg %v", e.arg)
+}
+
+func eqCreateWebhookEndpointParams(arg db.CreateWebhookEndpointParams) gomock.Matcher {
+	return eqCreateWebhookEndpointParamsMatcher{arg}
+}
+
+func TestCreateWebhookEndpointAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	url := "https://example.com/webhooks"
+	events := []string{util.WebhookEventTransferCreated, util.WebhookEventAccountUpdated}
+
+	apiKey := util.RandomString(32)
+	apiKeyRecord := db.ApiKey{
+		ID: 1,
+		Name: "partner",
+		HashedKey: util.HashAPIKey(apiKey),
+		Scopes: []string{util.APIKeyScopeWebhooks},
+	}
+
+	testCases := []struct{
+		name string
+		req *pb.CreateWebhookEndpointRequest
+		buildStubs func(store *mockdb.MockStore)
+		buildContext func(t *testing.T, tokenMaker token.Maker) context.Context
+		checkResponse func(t *testing.T, res *pb.CreateWebhookEndpointResponse, err error)
+	}{
+		{
+			name: "OKUser",
+			req: &pb.CreateWebhookEndpointRequest{
+				Url: url,
+				Events: append(events, util.WebhookEventTransferCreated),
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.CreateWebhookEndpointParams{
+					Owner: pgtype.Text{String: user.Username, Valid: true},
+					Url: url,
+					Events: events,
+					CreatedBy: "user:" + user.Username,
+				}
+				store.EXPECT().
+					CreateWebhookEndpoint(gomock.Any(), eqCreateWebhookEndpointParams(arg)).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, arg db.CreateWebhookEndpointParams) (db.WebhookEndpoint, error) {
+						return db.WebhookEndpoint{
+							ID: 1,
+							Owner: arg.Owner,
+							Url: arg.Url,
+							Secret: arg.Secret,
+							Events: arg.Events,
+							IsActive: true,
+							CreatedBy: arg.CreatedBy,
+						}, nil
+					})
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateWebhookEndpointResponse, err error) {
+				require.NoError(t, err)
+				require.NotEmpty(t, res.GetSecret())
+				require.Equal(t, url, res.GetEndpoint().GetUrl())
+				require.Equal(t, events, res.GetEndpoint().GetEvents())
+			},
+		},
+		{
+			name: "OKAPIKey",
+			req: &pb.CreateWebhookEndpointRequest{
+				Url: url,
+				Events: []string{util.WebhookEventUserVerified},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAPIKeyByHashedKey(gomock.Any(), gomock.Eq(util.HashAPIKey(apiKey))).
+					Times(1).
+					Return(apiKeyRecord, nil)
+
+				arg := db.CreateWebhookEndpointParams{
+					ApiKeyID: pgtype.Int8{
+						Int64: apiKeyRecord.ID,
+						Valid: true,
+					},
+					Url: url,
+					Events: []string{util.WebhookEventUserVerified},
+					CreatedBy: "api_key:" + apiKeyRecord.Name,
+				}
+				store.EXPECT().
+					CreateWebhookEndpoint(gomock.Any(), eqCreateWebhookEndpointParams(arg)).
+					Times(1).
+					Return(db.WebhookEndpoint{ID: 1, Url: url, IsActive: true}, nil)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithAPIKey(apiKey)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateWebhookEndpointResponse, err error) {
+				require.NoError(t, err)
+				require.NotEmpty(t, res.GetSecret())
+			},
+		},
+		{
+			name: "APIKeyWithoutScope",
+			req: &pb.CreateWebhookEndpointRequest{
+				Url: url,
+				Events: []string{util.WebhookEventUserVerified},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				unscoped := apiKeyRecord
+				unscoped.Scopes = nil
+				store.EXPECT().
+					GetAPIKeyByHashedKey(gomock.Any(), gomock.Eq(util.HashAPIKey(apiKey))).
+					Times(1).
+					Return(unscoped, nil)
+				store.EXPECT().
+					CreateWebhookEndpoint(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithAPIKey(apiKey)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateWebhookEndpointResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.PermissionDenied, st.Code())
+			},
+		},
+		{
+			name: "InvalidURL",
+			req: &pb.CreateWebhookEndpointRequest{
+				Url: "ftp://example.com",
+				Events: events,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateWebhookEndpoint(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateWebhookEndpointResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.InvalidArgument, st.Code())
+			},
+		},
+		{
+			name: "PrivateURL",
+			req: &pb.CreateWebhookEndpointRequest{
+				Url: "http://169.254.169.254/latest/meta-data",
+				Events: events,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateWebhookEndpoint(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateWebhookEndpointResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.InvalidArgument, st.Code())
+			},
+		},
+		{
+			name: "InvalidEvent",
+			req: &pb.CreateWebhookEndpointRequest{
+				Url: url,
+				Events: []string{"transfer.deleted"},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateWebhookEndpoint(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateWebhookEndpointResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.InvalidArgument, st.Code())
+			},
+		},
+		{
+			name: "NoAuthorization",
+			req: &pb.CreateWebhookEndpointRequest{
+				Url: url,
+				Events: events,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateWebhookEndpoint(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return context.Background()
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateWebhookEndpointResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.Unauthenticated, st.Code())
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T){
+			storeCtrl := gomock.NewController(t)
+			defer storeCtrl.Finish()
+			store := mockdb.NewMockStore(storeCtrl)
+
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store, nil)
+
+			ctx := tc.buildContext(t, server.tokenMaker)
+			res, err := server.CreateWebhookEndpoint(ctx, tc.req)
+
+			tc.checkResponse(t, res, err)
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+
+	db "github.com/juker1141/simplebank/db/sqlc"
+	"github.com/juker1141/simplebank/pb"
+	"github.com/juker1141/simplebank/util"
+	"github.com/juker1141/simplebank/val"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) DeleteBeneficiary(ctx context.Context, req *pb.DeleteBeneficiaryRequest) (*pb.DeleteBeneficiaryResponse, error) {
+	authPayload, err := server.authorizeUser(ctx, []string{util.BankerRole, util.DepositorRole})
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	violations := validateDeleteBeneficiaryRequest(req)
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	beneficiary, err := server.store.GetBeneficiary(ctx, req.GetId())
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.NotFound, "beneficiary not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get beneficiary: %s", err)
+	}
+
+	if beneficiary.Owner != authPayload.Username {
+		return nil, status.Errorf(codes.PermissionDenied, "cannot delete other user's beneficiary")
+	}
+
+	err = server.store.DeleteBeneficiary(ctx, beneficiary.ID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to delete beneficiary: %s", err)
+	}
+
+	return &pb.DeleteBeneficiaryResponse{}, nil
+}
+
+func validateDeleteBeneficiaryRequest(req *pb.DeleteBeneficiaryRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := val.ValidateId(req.GetId()); err != nil {
+		violations = append(violations, fieldViolation("id", err))
+	}
+
+	return violations
+}
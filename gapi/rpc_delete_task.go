@@ -0,0 +1,43 @@
+package gapi
+
+import (
+	"context"
+
+	"github.com/juker1141/simplebank/audit"
+	db "github.com/juker1141/simplebank/db/sqlc"
+	"github.com/juker1141/simplebank/pb"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+)
+
+func (server *Server) DeleteTask(ctx context.Context, req *pb.DeleteTaskRequest) (*pb.DeleteTaskResponse, error) {
+	operator, err := server.authorizeAdmin(ctx)
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	violations := validateDeleteTaskRequest(req)
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	err = server.taskInspector.DeleteTask(ctx, req.GetQueue(), req.GetId())
+	if err != nil {
+		return nil, taskInspectorError(err, "failed to delete task")
+	}
+
+	server.audit(ctx, audit.AdminRecord(server.auditActor(ctx, operator), db.AuditTargetTask, audit.TaskTarget(req.GetQueue(), req.GetId()), "delete_task"))
+
+	return &pb.DeleteTaskResponse{}, nil
+}
+
+func validateDeleteTaskRequest(req *pb.DeleteTaskRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := validateTaskQueue(req.GetQueue()); err != nil {
+		violations = append(violations, fieldViolation("queue", err))
+	}
+
+	if err := validateTaskId(req.GetId()); err != nil {
+		violations = append(violations, fieldViolation("id", err))
+	}
+
+	return violations
+}
\ No newline at end of file
@@ -0,0 +1,48 @@
+package gapi
+
+import (
+	"context"
+
+	"github.com/juker1141/simplebank/pb"
+	"github.com/juker1141/simplebank/val"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// DeleteWebhookEndpoint deactivates the endpoint, its delivery log is kept
+func (server *Server) DeleteWebhookEndpoint(ctx context.Context, req *pb.DeleteWebhookEndpointRequest) (*pb.DeleteWebhookEndpointResponse, error) {
+	owner, err := server.authorizeWebhookOwner(ctx)
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	violations := validateDeleteWebhookEndpointRequest(req)
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	endpoint, err := server.getWebhookEndpoint(ctx, req.GetId(), owner)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint, err = server.store.DeactivateWebhookEndpoint(ctx, endpoint.ID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to delete webhook endpoint: %s", err)
+	}
+
+	rsp := &pb.DeleteWebhookEndpointResponse{
+		Endpoint: convertWebhookEndpoint(endpoint),
+	}
+
+	return rsp, nil
+}
+
+func validateDeleteWebhookEndpointRequest(req *pb.DeleteWebhookEndpointRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := val.ValidateId(req.GetId()); err != nil {
+		violations = append(violations, fieldViolation("id", err))
+	}
+
+	return violations
+}
\ No newline at end of file
@@ -0,0 +1,107 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+
+	"github.com/juker1141/simplebank/audit"
+	db "github.com/juker1141/simplebank/db/sqlc"
+	"github.com/juker1141/simplebank/pb"
+	"github.com/juker1141/simplebank/val"
+	"github.com/juker1141/simplebank/worker"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) Deposit(ctx context.Context, req *pb.DepositRequest) (*pb.DepositResponse, error) {
+	createdBy, err := server.authorizeOperator(ctx)
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	violations := validateExternalTransferRequest(req.GetAccountId(), req.GetAmount(), req.GetCurrency(), req.GetExternalReference())
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	err = server.checkAccountCurrency(ctx, req.GetAccountId(), req.GetCurrency())
+	if err != nil {
+		return nil, err
+	}
+
+	txResult, err := server.store.DepositTx(ctx, db.ExternalTransferTxParams{
+		AccountID: req.GetAccountId(),
+		Amount: req.GetAmount(),
+		ExternalReference: req.GetExternalReference(),
+		CreatedBy: createdBy,
+		Tasks: externalTransferTasks,
+		Audit: audit.ExternalTransferRecords(server.auditActor(ctx, createdBy)),
+	})
+	if err != nil {
+		return nil, externalTransferError(err)
+	}
+
+	rsp := &pb.DepositResponse{
+		Account: convertAccount(txResult.Account),
+		ExternalTransfer: convertExternalTransfer(txResult.ExternalTransfer),
+	}
+
+	return rsp, nil
+}
+
+func validateExternalTransferRequest(accountID int64, amount int64, currency string, externalReference string) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := val.ValidateAccountId(accountID); err != nil {
+		violations = append(violations, fieldViolation("account_id", err))
+	}
+
+	if err := val.ValidateAmount(amount); err != nil {
+		violations = append(violations, fieldViolation("amount", err))
+	}
+
+	if err := val.ValidateCurrency(currency); err != nil {
+		violations = append(violations, fieldViolation("currency", err))
+	}
+
+	if err := val.ValidateExternalReference(externalReference); err != nil {
+		violations = append(violations, fieldViolation("external_reference", err))
+	}
+
+	return violations
+}
+
+func (server *Server) checkAccountCurrency(ctx context.Context, accountID int64, currency string) error {
+	account, err := server.store.GetAccount(ctx, accountID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return status.Errorf(codes.NotFound, "account not found")
+		}
+		return status.Errorf(codes.Internal, "failed to get account: %s", err)
+	}
+
+	if account.Currency != currency {
+		return status.Errorf(codes.InvalidArgument, "account [%d] currency mismatch: %s vs %s", account.ID, account.Currency, currency)
+	}
+
+	return nil
+}
+
+// externalTransferTasks tells the webhook endpoints of the account owner about the new balance
+func externalTransferTasks(result db.ExternalTransferTxResult) []db.OutboxTask {
+	return []db.OutboxTask{
+		worker.AccountUpdatedWebhookTask(result.Account),
+	}
+}
+
+func externalTransferError(err error) error {
+	if errors.Is(err, db.ErrInsufficientBalance) {
+		return status.Errorf(codes.FailedPrecondition, "%s", err)
+	}
+	if errors.Is(err, db.ErrRecordNotFound) {
+		return status.Errorf(codes.FailedPrecondition, "no settlement account for the currency")
+	}
+	if db.ErrorCode(err) == db.UniqueViolation {
+		return status.Errorf(codes.AlreadyExists, "external reference has already been used")
+	}
+	return status.Errorf(codes.Internal, "failed to move money: %s", err)
+}
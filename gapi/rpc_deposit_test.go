@@ -0,0 +1,333 @@
+package gapi
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/juker1141/simplebank/db/mock"
+	db "github.com/juker1141/simplebank/db/sqlc"
+	"github.com/juker1141/simplebank/pb"
+	"github.com/juker1141/simplebank/token"
+	"github.com/juker1141/simplebank/util"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type eqExternalTransferTxParamsMatcher struct {
+	arg db.ExternalTransferTxParams
+	account db.Account
+}
+
+func (expected eqExternalTransferTxParamsMatcher) Matches(x interface{}) bool {
+	actualArg, ok := x.(db.ExternalTransferTxParams)
+	if !ok || actualArg.Tasks == nil {
+		return false
+	}
+
+	if actualArg.AccountID != expected.arg.AccountID ||
+		actualArg.Amount != expected.arg.Amount ||
+		actualArg.ExternalReference != expected.arg.ExternalReference ||
+		actualArg.CreatedBy != expected.arg.CreatedBy {
+		return false
+	}
+
+	// the owner's webhook endpoints are told about the new balance
+	tasks := actualArg.Tasks(db.ExternalTransferTxResult{Account: expected.account})
+	return len(tasks) == 1 &&
+		isWebhookEventTask(tasks[0], util.WebhookEventAccountUpdated, []string{expected.account.Owner}, []int64{expected.account.ID}, expected.account)
+}
+
+func (e eqExternalTransferTxParamsMatcher) String() string {
+	return fmt.Sprintf("matches arg %v", e.arg)
+}
+
+func eqExternalTransferTxParams(arg db.ExternalTransferTxParams, account db.Account) gomock.Matcher {
+	return eqExternalTransferTxParamsMatcher{arg, account}
+}
+
+func randomAccount(owner string) db.Account {
+	return db.Account{
+		ID: util.RandomInt(1, 1000),
+		Owner: owner,
+		Balance: util.RandomMoney(),
+		Currency: util.RandomCurrency(),
+	}
+}
+
+func newContextWithAPIKey(key string) context.Context {
+	md := metadata.MD{
+		apiKeyHeader: []string{
+			key,
+		},
+	}
+
+	return metadata.NewIncomingContext(context.Background(), md)
+}
+
+func TestDepositAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	banker, _ := randomUser(t)
+	banker.Role = util.BankerRole
+
+	account := randomAccount(user.Username)
+	amount := int64(100)
+	reference := util.RandomString(12)
+
+	apiKey := util.RandomString(32)
+	apiKeyRecord := db.ApiKey{
+		ID: 1,
+		Name: "statement-importer",
+		HashedKey: util.HashAPIKey(apiKey),
+	}
+
+	txResult := db.ExternalTransferTxResult{
+		Account: db.Account{
+			ID: account.ID,
+			Owner: account.Owner,
+			Balance: account.Balance + amount,
+			Currency: account.Currency,
+		},
+		ExternalTransfer: db.ExternalTransfer{
+			ID: 1,
+			Type: db.ExternalTransferTypeDeposit,
+			AccountID: account.ID,
+			Amount: amount,
+			Currency: account.Currency,
+			ExternalReference: reference,
+		},
+	}
+
+	testCases := []struct{
+		name string
+		req *pb.DepositRequest
+		buildStubs func(store *mockdb.MockStore)
+		buildContext func(t *testing.T, tokenMaker token.Maker) context.Context
+		checkResponse func(t *testing.T, res *pb.DepositResponse, err error)
+	}{
+		{
+			name: "OKBanker",
+			req: &pb.DepositRequest{
+				AccountId: account.ID,
+				Amount: amount,
+				Currency: account.Currency,
+				ExternalReference: reference,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+
+				arg := db.ExternalTransferTxParams{
+					AccountID: account.ID,
+					Amount: amount,
+					ExternalReference: reference,
+					CreatedBy: "user:" + banker.Username,
+				}
+				store.EXPECT().
+					DepositTx(gomock.Any(), eqExternalTransferTxParams(arg, txResult.Account)).
+					Times(1).
+					Return(txResult, nil)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, banker.Username, banker.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.DepositResponse, err error) {
+				require.NoError(t, err)
+				require.NotNil(t, res)
+				require.Equal(t, account.Balance + amount, res.GetAccount().GetBalance())
+				require.Equal(t, reference, res.GetExternalTransfer().GetExternalReference())
+			},
+		},
+		{
+			name: "OKAPIKey",
+			req: &pb.DepositRequest{
+				AccountId: account.ID,
+				Amount: amount,
+				Currency: account.Currency,
+				ExternalReference: reference,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAPIKeyByHashedKey(gomock.Any(), gomock.Eq(util.HashAPIKey(apiKey))).
+					Times(1).
+					Return(apiKeyRecord, nil)
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+
+				arg := db.ExternalTransferTxParams{
+					AccountID: account.ID,
+					Amount: amount,
+					ExternalReference: reference,
+					CreatedBy: "api_key:" + apiKeyRecord.Name,
+				}
+				store.EXPECT().
+					DepositTx(gomock.Any(), eqExternalTransferTxParams(arg, txResult.Account)).
+					Times(1).
+					Return(txResult, nil)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithAPIKey(apiKey)
+			},
+			checkResponse: func(t *testing.T, res *pb.DepositResponse, err error) {
+				require.NoError(t, err)
+				require.NotNil(t, res)
+			},
+		},
+		{
+			name: "RevokedAPIKey",
+			req: &pb.DepositRequest{
+				AccountId: account.ID,
+				Amount: amount,
+				Currency: account.Currency,
+				ExternalReference: reference,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				revoked := apiKeyRecord
+				revoked.IsRevoked = true
+				store.EXPECT().
+					GetAPIKeyByHashedKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(revoked, nil)
+				store.EXPECT().
+					DepositTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithAPIKey(apiKey)
+			},
+			checkResponse: func(t *testing.T, res *pb.DepositResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.Unauthenticated, st.Code())
+			},
+		},
+		{
+			name: "DepositorNotAllowed",
+			req: &pb.DepositRequest{
+				AccountId: account.ID,
+				Amount: amount,
+				Currency: account.Currency,
+				ExternalReference: reference,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					DepositTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.DepositResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.Unauthenticated, st.Code())
+			},
+		},
+		{
+			name: "InvalidAmount",
+			req: &pb.DepositRequest{
+				AccountId: account.ID,
+				Amount: -amount,
+				Currency: account.Currency,
+				ExternalReference: reference,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					DepositTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, banker.Username, banker.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.DepositResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.InvalidArgument, st.Code())
+			},
+		},
+		{
+			name: "CurrencyMismatch",
+			req: &pb.DepositRequest{
+				AccountId: account.ID,
+				Amount: amount,
+				Currency: "XYZ",
+				ExternalReference: reference,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().
+					DepositTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, banker.Username, banker.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.DepositResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.InvalidArgument, st.Code())
+			},
+		},
+		{
+			name: "DuplicateReference",
+			req: &pb.DepositRequest{
+				AccountId: account.ID,
+				Amount: amount,
+				Currency: account.Currency,
+				ExternalReference: reference,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+				store.EXPECT().
+					DepositTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.ExternalTransferTxResult{}, db.ErrUniqueViolation)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, banker.Username, banker.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.DepositResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.AlreadyExists, st.Code())
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T){
+			storeCtrl := gomock.NewController(t)
+			defer storeCtrl.Finish()
+			store := mockdb.NewMockStore(storeCtrl)
+
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store, nil)
+
+			ctx := tc.buildContext(t, server.tokenMaker)
+			res, err := server.Deposit(ctx, tc.req)
+
+			tc.checkResponse(t, res, err)
+		})
+	}
+}
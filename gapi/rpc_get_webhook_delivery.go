@@ -0,0 +1,50 @@
+package gapi
+
+import (
+	"context"
+
+	"github.com/juker1141/simplebank/pb"
+	"github.com/juker1141/simplebank/val"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) GetWebhookDelivery(ctx context.Context, req *pb.GetWebhookDeliveryRequest) (*pb.GetWebhookDeliveryResponse, error) {
+	owner, err := server.authorizeWebhookOwner(ctx)
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	violations := validateWebhookDeliveryId(req.GetId())
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	delivery, _, err := server.getWebhookDelivery(ctx, req.GetId(), owner)
+	if err != nil {
+		return nil, err
+	}
+
+	attempts, err := server.store.ListWebhookDeliveryAttempts(ctx, delivery.ID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list webhook delivery attempts: %s", err)
+	}
+
+	rsp := &pb.GetWebhookDeliveryResponse{
+		Delivery: convertWebhookDelivery(delivery),
+	}
+	for _, attempt := range attempts {
+		rsp.Attempts = append(rsp.Attempts, convertWebhookDeliveryAttempt(attempt))
+	}
+
+	return rsp, nil
+}
+
+func validateWebhookDeliveryId(id int64) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := val.ValidateId(id); err != nil {
+		violations = append(violations, fieldViolation("id", err))
+	}
+
+	return violations
+}
\ No newline at end of file
@@ -0,0 +1,272 @@
+package gapi
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/juker1141/simplebank/db/mock"
+	db "github.com/juker1141/simplebank/db/sqlc"
+	"github.com/juker1141/simplebank/pb"
+	"github.com/juker1141/simplebank/token"
+	"github.com/juker1141/simplebank/util"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestInviteAccountMemberAPI(t *testing.T) {
+	owner, _ := randomUser(t)
+	invitee, _ := randomUser(t)
+	account := randomAccount(owner.Username)
+
+	ownerMember := db.AccountMember{
+		AccountID: account.ID,
+		Username: owner.Username,
+		Role: util.AccountOwnerRole,
+		InvitedBy: owner.Username,
+		IsAccepted: true,
+	}
+
+	invitedMember := db.AccountMember{
+		AccountID: account.ID,
+		Username: invitee.Username,
+		Role: util.AccountCanTransferRole,
+		InvitedBy: owner.Username,
+		IsAccepted: false,
+	}
+
+	testCases := []struct{
+		name string
+		req *pb.InviteAccountMemberRequest
+		buildStubs func(store *mockdb.MockStore)
+		buildContext func(t *testing.T, tokenMaker token.Maker) context.Context
+		checkResponse func(t *testing.T, res *pb.InviteAccountMemberResponse, err error)
+	}{
+		{
+			name: "OK",
+			req: &pb.InviteAccountMemberRequest{
+				AccountId: account.ID,
+				Username: invitee.Username,
+				Role: util.AccountCanTransferRole,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccountMember(gomock.Any(), gomock.Eq(db.GetAccountMemberParams{AccountID: account.ID, Username: owner.Username})).
+					Times(1).
+					Return(ownerMember, nil)
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(invitee.Username)).
+					Times(1).
+					Return(invitee, nil)
+
+				arg := db.CreateAccountMemberParams{
+					AccountID: account.ID,
+					Username: invitee.Username,
+					Role: util.AccountCanTransferRole,
+					InvitedBy: owner.Username,
+					IsAccepted: false,
+				}
+				store.EXPECT().
+					CreateAccountMember(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(invitedMember, nil)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, owner.Username, owner.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.InviteAccountMemberResponse, err error) {
+				require.NoError(t, err)
+				require.NotNil(t, res)
+				member := res.GetMember()
+				require.Equal(t, account.ID, member.AccountId)
+				require.Equal(t, invitee.Username, member.Username)
+				require.Equal(t, util.AccountCanTransferRole, member.Role)
+				require.False(t, member.IsAccepted)
+			},
+		},
+		{
+			name: "NotOwner",
+			req: &pb.InviteAccountMemberRequest{
+				AccountId: account.ID,
+				Username: owner.Username,
+				Role: util.AccountViewOnlyRole,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				member := invitedMember
+				member.IsAccepted = true
+
+				store.EXPECT().
+					GetAccountMember(gomock.Any(), gomock.Eq(db.GetAccountMemberParams{AccountID: account.ID, Username: invitee.Username})).
+					Times(1).
+					Return(member, nil)
+				store.EXPECT().
+					CreateAccountMember(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, invitee.Username, invitee.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.InviteAccountMemberResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.PermissionDenied, st.Code())
+			},
+		},
+		{
+			name: "NotMember",
+			req: &pb.InviteAccountMemberRequest{
+				AccountId: account.ID,
+				Username: owner.Username,
+				Role: util.AccountOwnerRole,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccountMember(gomock.Any(), gomock.Eq(db.GetAccountMemberParams{AccountID: account.ID, Username: invitee.Username})).
+					Times(1).
+					Return(db.AccountMember{}, db.ErrRecordNotFound)
+				store.EXPECT().
+					CreateAccountMember(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, invitee.Username, invitee.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.InviteAccountMemberResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.PermissionDenied, st.Code())
+			},
+		},
+		{
+			name: "UserNotFound",
+			req: &pb.InviteAccountMemberRequest{
+				AccountId: account.ID,
+				Username: invitee.Username,
+				Role: util.AccountCanTransferRole,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccountMember(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(ownerMember, nil)
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(invitee.Username)).
+					Times(1).
+					Return(db.User{}, db.ErrRecordNotFound)
+				store.EXPECT().
+					CreateAccountMember(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, owner.Username, owner.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.InviteAccountMemberResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.NotFound, st.Code())
+			},
+		},
+		{
+			name: "AlreadyMember",
+			req: &pb.InviteAccountMemberRequest{
+				AccountId: account.ID,
+				Username: invitee.Username,
+				Role: util.AccountCanTransferRole,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccountMember(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(ownerMember, nil)
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(invitee.Username)).
+					Times(1).
+					Return(invitee, nil)
+				store.EXPECT().
+					CreateAccountMember(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.AccountMember{}, db.ErrUniqueViolation)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, owner.Username, owner.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.InviteAccountMemberResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.AlreadyExists, st.Code())
+			},
+		},
+		{
+			name: "InvalidRole",
+			req: &pb.InviteAccountMemberRequest{
+				AccountId: account.ID,
+				Username: invitee.Username,
+				Role: "admin",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccountMember(gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().
+					CreateAccountMember(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, owner.Username, owner.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.InviteAccountMemberResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.InvalidArgument, st.Code())
+			},
+		},
+		{
+			name: "NoAuthorization",
+			req: &pb.InviteAccountMemberRequest{
+				AccountId: account.ID,
+				Username: invitee.Username,
+				Role: util.AccountCanTransferRole,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateAccountMember(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return context.Background()
+			},
+			checkResponse: func(t *testing.T, res *pb.InviteAccountMemberResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.Unauthenticated, st.Code())
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T){
+			storeCtrl := gomock.NewController(t)
+			defer storeCtrl.Finish()
+			store := mockdb.NewMockStore(storeCtrl)
+
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store, nil)
+
+			ctx := tc.buildContext(t, server.tokenMaker)
+			res, err := server.InviteAccountMember(ctx, tc.req)
+
+			tc.checkResponse(t, res, err)
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package gapi
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/juker1141/simplebank/db/sqlc"
+	"github.com/juker1141/simplebank/pb"
+	"github.com/juker1141/simplebank/val"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ListAuditEvents searches the audit log, the newest events first. The filters that are not set match all events
+func (server *Server) ListAuditEvents(ctx context.Context, req *pb.ListAuditEventsRequest) (*pb.ListAuditEventsResponse, error) {
+	_, err := server.authorizeAdmin(ctx)
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	violations := validateListAuditEventsRequest(req)
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	arg := db.ListAuditEventsParams{
+		Actor: pgtype.Text{
+			String: req.GetActor(),
+			Valid: req.Actor != nil,
+		},
+		EventType: pgtype.Text{
+			String: req.GetEventType(),
+			Valid: req.EventType != nil,
+		},
+		TargetType: pgtype.Text{
+			String: req.GetTargetType(),
+			Valid: req.TargetType != nil,
+		},
+		TargetID: pgtype.Text{
+			String: req.GetTargetId(),
+			Valid: req.TargetId != nil,
+		},
+		StartTime: pgtype.Timestamptz{
+			Time: req.GetStartTime().AsTime(),
+			Valid: req.StartTime != nil,
+		},
+		EndTime: pgtype.Timestamptz{
+			Time: req.GetEndTime().AsTime(),
+			Valid: req.EndTime != nil,
+		},
+		Limit: req.GetPageSize(),
+		Offset: (req.GetPageId() - 1) * req.GetPageSize(),
+	}
+
+	events, err := server.store.ListAuditEvents(ctx, arg)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list audit events: %s", err)
+	}
+
+	rsp := &pb.ListAuditEventsResponse{}
+	for _, event := range events {
+		rsp.Events = append(rsp.Events, convertAuditEvent(event))
+	}
+
+	return rsp, nil
+}
+
+func validateListAuditEventsRequest(req *pb.ListAuditEventsRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if req.EventType != nil {
+		if err := validateAuditEventType(req.GetEventType()); err != nil {
+			violations = append(violations, fieldViolation("event_type", err))
+		}
+	}
+
+	if req.StartTime != nil && req.EndTime != nil && !req.GetStartTime().AsTime().Before(req.GetEndTime().AsTime()) {
+		violations = append(violations, fieldViolation("end_time", fmt.Errorf("must be after the start time")))
+	}
+
+	if err := val.ValidatePageId(req.GetPageId()); err != nil {
+		violations = append(violations, fieldViolation("page_id", err))
+	}
+
+	if err := val.ValidatePageSize(req.GetPageSize()); err != nil {
+		violations = append(violations, fieldViolation("page_size", err))
+	}
+
+	return violations
+}
+
+func validateAuditEventType(eventType string) error {
+	switch eventType {
+	case db.AuditEventLogin,
+		db.AuditEventLoginFailed,
+		db.AuditEventPasswordChanged,
+		db.AuditEventEmailChangeRequested,
+		db.AuditEventEmailChanged,
+		db.AuditEventSessionRevoked,
+		db.AuditEventUserStatusChanged,
+		db.AuditEventTransfer,
+		db.AuditEventDeposit,
+		db.AuditEventWithdrawal,
+		db.AuditEventAdminAction:
+		return nil
+	}
+	return fmt.Errorf("unsupported audit event type: %s", eventType)
+}
\ No newline at end of file
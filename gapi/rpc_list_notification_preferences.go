@@ -0,0 +1,42 @@
+package gapi
+
+import (
+	"context"
+
+	db "github.com/juker1141/simplebank/db/sqlc"
+	"github.com/juker1141/simplebank/pb"
+	"github.com/juker1141/simplebank/util"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) ListNotificationPreferences(ctx context.Context, req *pb.ListNotificationPreferencesRequest) (*pb.ListNotificationPreferencesResponse, error) {
+	authPayload, err := server.authorizeUser(ctx, []string{util.BankerRole, util.DepositorRole})
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	preferences, err := server.store.ListNotificationPreferences(ctx, authPayload.Username)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list notification preferences: %s", err)
+	}
+
+	saved := make(map[[2]string]db.NotificationPreference)
+	for _, preference := range preferences {
+		saved[[2]string{preference.EventType, preference.Channel}] = preference
+	}
+
+	// every event and channel is listed, with the default preference if the user hasn't set one
+	rsp := &pb.ListNotificationPreferencesResponse{}
+	for _, event := range util.NotificationEvents() {
+		for _, channel := range util.NotificationChannels() {
+			preference, ok := saved[[2]string{event, channel}]
+			if !ok {
+				preference = db.DefaultNotificationPreference(authPayload.Username, event, channel)
+			}
+			rsp.Preferences = append(rsp.Preferences, convertNotificationPreference(preference))
+		}
+	}
+
+	return rsp, nil
+}
\ No newline at end of file
@@ -0,0 +1,61 @@
+package gapi
+
+import (
+	"context"
+	"fmt"
+
+	db "github.com/juker1141/simplebank/db/sqlc"
+	"github.com/juker1141/simplebank/pb"
+	"github.com/juker1141/simplebank/util"
+	"github.com/juker1141/simplebank/val"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) ListPaymentRequests(ctx context.Context, req *pb.ListPaymentRequestsRequest) (*pb.ListPaymentRequestsResponse, error) {
+	authPayload, err := server.authorizeUser(ctx, []string{util.BankerRole, util.DepositorRole})
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	violations := validateListPaymentRequestsRequest(req)
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	paymentRequests, err := server.store.ListPaymentRequests(ctx, db.ListPaymentRequestsParams{
+		Username: authPayload.Username,
+		Status: req.GetStatus(),
+		Limit: req.GetPageSize(),
+		Offset: (req.GetPageId() - 1) * req.GetPageSize(),
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list payment requests: %s", err)
+	}
+
+	rsp := &pb.ListPaymentRequestsResponse{}
+	for _, paymentRequest := range paymentRequests {
+		rsp.PaymentRequests = append(rsp.PaymentRequests, convertPaymentRequest(paymentRequest))
+	}
+
+	return rsp, nil
+}
+
+func validateListPaymentRequestsRequest(req *pb.ListPaymentRequestsRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	switch req.GetStatus() {
+	case db.PaymentRequestStatusPending, db.PaymentRequestStatusAccepted, db.PaymentRequestStatusDeclined, db.PaymentRequestStatusExpired:
+	default:
+		violations = append(violations, fieldViolation("status", fmt.Errorf("is not a supported payment request status")))
+	}
+
+	if err := val.ValidatePageId(req.GetPageId()); err != nil {
+		violations = append(violations, fieldViolation("page_id", err))
+	}
+
+	if err := val.ValidatePageSize(req.GetPageSize()); err != nil {
+		violations = append(violations, fieldViolation("page_size", err))
+	}
+
+	return violations
+}
@@ -0,0 +1,28 @@
+package gapi
+
+import (
+	"context"
+
+	"github.com/juker1141/simplebank/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) ListScheduledJobs(ctx context.Context, req *pb.ListScheduledJobsRequest) (*pb.ListScheduledJobsResponse, error) {
+	_, err := server.authorizeAdmin(ctx)
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	jobs, err := server.store.ListScheduledJobs(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list scheduled jobs: %s", err)
+	}
+
+	rsp := &pb.ListScheduledJobsResponse{}
+	for _, job := range jobs {
+		rsp.Jobs = append(rsp.Jobs, convertScheduledJob(job))
+	}
+
+	return rsp, nil
+}
\ No newline at end of file
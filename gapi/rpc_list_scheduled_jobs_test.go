@@ -0,0 +1,132 @@
+package gapi
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/juker1141/simplebank/db/mock"
+	db "github.com/juker1141/simplebank/db/sqlc"
+	"github.com/juker1141/simplebank/pb"
+	"github.com/juker1141/simplebank/token"
+	"github.com/juker1141/simplebank/util"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestListScheduledJobsAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	banker, _ := randomUser(t)
+	banker.Role = util.BankerRole
+
+	jobs := []db.ScheduledJob{
+		{
+			Name: "cleanup_sessions",
+			Schedule: "0 * * * *",
+			NextRunAt: time.Now().Add(time.Hour),
+			LockedUntil: time.Now().Add(-time.Minute),
+			LastStatus: db.ScheduledJobStatusSucceeded,
+			LastResult: "deleted 3 sessions",
+		},
+		{
+			Name: "reconcile_accounts",
+			Schedule: "0 2 * * *",
+			NextRunAt: time.Now(),
+			LockedBy: "worker-1",
+			LockedUntil: time.Now().Add(time.Minute),
+		},
+	}
+
+	testCases := []struct{
+		name string
+		buildStubs func(store *mockdb.MockStore)
+		buildContext func(t *testing.T, tokenMaker token.Maker) context.Context
+		checkResponse func(t *testing.T, res *pb.ListScheduledJobsResponse, err error)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListScheduledJobs(gomock.Any()).
+					Times(1).
+					Return(jobs, nil)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, banker.Username, banker.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.ListScheduledJobsResponse, err error) {
+				require.NoError(t, err)
+				require.Len(t, res.GetJobs(), len(jobs))
+
+				idle := res.GetJobs()[0]
+				require.Equal(t, jobs[0].Name, idle.GetName())
+				require.Equal(t, jobs[0].LastResult, idle.GetLastResult())
+				require.False(t, idle.GetIsRunning())
+				require.Empty(t, idle.GetRunningOn())
+
+				running := res.GetJobs()[1]
+				require.True(t, running.GetIsRunning())
+				require.Equal(t, jobs[1].LockedBy, running.GetRunningOn())
+			},
+		},
+		{
+			name: "Depositor",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListScheduledJobs(gomock.Any()).
+					Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.ListScheduledJobsResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.Unauthenticated, st.Code())
+			},
+		},
+		{
+			name: "APIKey",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAPIKeyByHashedKey(gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().
+					ListScheduledJobs(gomock.Any()).
+					Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				// the API keys of the partners can't administer the bank
+				return newContextWithAPIKey(util.RandomString(32))
+			},
+			checkResponse: func(t *testing.T, res *pb.ListScheduledJobsResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.Unauthenticated, st.Code())
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T){
+			storeCtrl := gomock.NewController(t)
+			defer storeCtrl.Finish()
+			store := mockdb.NewMockStore(storeCtrl)
+
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store, nil)
+
+			ctx := tc.buildContext(t, server.tokenMaker)
+			res, err := server.ListScheduledJobs(ctx, &pb.ListScheduledJobsRequest{})
+
+			tc.checkResponse(t, res, err)
+		})
+	}
+}
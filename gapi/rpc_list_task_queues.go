@@ -0,0 +1,28 @@
+package gapi
+
+import (
+	"context"
+
+	"github.com/juker1141/simplebank/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) ListTaskQueues(ctx context.Context, req *pb.ListTaskQueuesRequest) (*pb.ListTaskQueuesResponse, error) {
+	_, err := server.authorizeAdmin(ctx)
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	queues, err := server.taskInspector.ListQueues(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list task queues: %s", err)
+	}
+
+	rsp := &pb.ListTaskQueuesResponse{}
+	for _, queue := range queues {
+		rsp.Queues = append(rsp.Queues, convertTaskQueue(queue))
+	}
+
+	return rsp, nil
+}
\ No newline at end of file
@@ -0,0 +1,81 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/juker1141/simplebank/pb"
+	"github.com/juker1141/simplebank/val"
+	"github.com/juker1141/simplebank/worker"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) ListTasks(ctx context.Context, req *pb.ListTasksRequest) (*pb.ListTasksResponse, error) {
+	_, err := server.authorizeAdmin(ctx)
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	violations := validateListTasksRequest(req)
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	tasks, err := server.taskInspector.ListTasks(ctx, req.GetQueue(), req.GetState(), int(req.GetPageId()), int(req.GetPageSize()))
+	if err != nil {
+		return nil, taskInspectorError(err, "failed to list tasks")
+	}
+
+	rsp := &pb.ListTasksResponse{}
+	for _, task := range tasks {
+		rsp.Tasks = append(rsp.Tasks, convertTask(task))
+	}
+
+	return rsp, nil
+}
+
+func validateListTasksRequest(req *pb.ListTasksRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := validateTaskQueue(req.GetQueue()); err != nil {
+		violations = append(violations, fieldViolation("queue", err))
+	}
+
+	switch req.GetState() {
+	case worker.TaskStatePending, worker.TaskStateScheduled, worker.TaskStateRetry, worker.TaskStateArchived:
+	default:
+		violations = append(violations, fieldViolation("state", fmt.Errorf("must be pending, scheduled, retry or archived")))
+	}
+
+	if err := val.ValidatePageId(req.GetPageId()); err != nil {
+		violations = append(violations, fieldViolation("page_id", err))
+	}
+
+	if err := val.ValidatePageSize(req.GetPageSize()); err != nil {
+		violations = append(violations, fieldViolation("page_size", err))
+	}
+
+	return violations
+}
+
+func validateTaskQueue(value string) error {
+	return val.ValidateString(value, 1, 100)
+}
+
+func validateTaskId(value string) error {
+	return val.ValidateString(value, 1, 100)
+}
+
+// taskInspectorError converts an error of the task inspector to a gRPC status error
+func taskInspectorError(err error, message string) error {
+	switch {
+	case errors.Is(err, worker.ErrQueueNotFound):
+		return status.Errorf(codes.NotFound, "queue not found")
+	case errors.Is(err, worker.ErrTaskNotFound):
+		return status.Errorf(codes.NotFound, "task not found")
+	case errors.Is(err, worker.ErrInvalidTaskState):
+		return status.Errorf(codes.FailedPrecondition, "%s", err)
+	}
+	return status.Errorf(codes.Internal, "%s: %s", message, err)
+}
\ No newline at end of file
@@ -0,0 +1,32 @@
+package gapi
+
+import (
+	"context"
+
+	db "github.com/juker1141/simplebank/db/sqlc"
+	"github.com/juker1141/simplebank/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) ListWebhookEndpoints(ctx context.Context, req *pb.ListWebhookEndpointsRequest) (*pb.ListWebhookEndpointsResponse, error) {
+	owner, err := server.authorizeWebhookOwner(ctx)
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	endpoints, err := server.store.ListWebhookEndpoints(ctx, db.ListWebhookEndpointsParams{
+		Owner: owner.Username,
+		ApiKeyID: owner.APIKey,
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list webhook endpoints: %s", err)
+	}
+
+	rsp := &pb.ListWebhookEndpointsResponse{}
+	for _, endpoint := range endpoints {
+		rsp.Endpoints = append(rsp.Endpoints, convertWebhookEndpoint(endpoint))
+	}
+
+	return rsp, nil
+}
\ No newline at end of file
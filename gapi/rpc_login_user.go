@@ -4,8 +4,10 @@ import (
 	"context"
 	"errors"
 
+	"github.com/juker1141/simplebank/audit"
 	db "github.com/juker1141/simplebank/db/sqlc"
 	"github.com/juker1141/simplebank/pb"
+	"github.com/juker1141/simplebank/token"
 	"github.com/juker1141/simplebank/util"
 	"github.com/juker1141/simplebank/val"
 	"google.golang.org/genproto/googleapis/rpc/errdetails"
@@ -23,6 +25,7 @@ func (server *Server) LoginUser(ctx context.Context, req *pb.LoginUserRequest) (
 	user, err := server.store.GetUser(ctx, req.Username)
 	if err != nil {
 		if errors.Is(err, db.ErrRecordNotFound) {
+			server.audit(ctx, audit.LoginFailedRecord(server.auditActor(ctx, db.AuditActorAnonymous), req.GetUsername(), "user not found"))
 			return nil, status.Errorf(codes.NotFound, "user not found: %s", err)
 		}
 		return nil, status.Errorf(codes.Internal, "failed to find user: %s", err)
@@ -30,12 +33,21 @@ func (server *Server) LoginUser(ctx context.Context, req *pb.LoginUserRequest) (
 
 	err = util.CheckPassword(req.Password, user.HashedPassword)
 	if err != nil {
+		server.audit(ctx, audit.LoginFailedRecord(server.auditActor(ctx, db.AuditActorAnonymous), user.Username, "incorrect password"))
 		return nil, status.Errorf(codes.NotFound, "incorrect password: %s", err)
 	}
 
+	if user.IsLocked {
+		server.audit(ctx, audit.LoginFailedRecord(server.auditActor(ctx, db.AuditActorAnonymous), user.Username, "user is locked"))
+		return nil, status.Errorf(codes.PermissionDenied, "user is locked")
+	}
+
+	config := server.config.Get()
 	accessToken, accessPayload, err := server.tokenMaker.CreateToken(
 		user.Username,
-		server.config.AccessTokenDuration,
+		user.Role,
+		config.AccessTokenDuration,
+		token.WithEmailVerified(user.IsEmailVerified),
 	)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to create access token: %s", err)
@@ -43,7 +55,9 @@ func (server *Server) LoginUser(ctx context.Context, req *pb.LoginUserRequest) (
 
 	refreshToken, refreshPayload, err := server.tokenMaker.CreateToken(
 		user.Username,
-		server.config.RefreshTokenDuration,
+		user.Role,
+		config.RefreshTokenDuration,
+		token.WithEmailVerified(user.IsEmailVerified),
 	)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to create refresh token: %s", err)
@@ -63,6 +77,8 @@ func (server *Server) LoginUser(ctx context.Context, req *pb.LoginUserRequest) (
 		return nil, status.Errorf(codes.Internal, "failed to create session: %s", err)
 	}
 
+	server.audit(ctx, audit.LoginRecord(server.auditActor(ctx, audit.UserActor(user.Username)), session))
+
 	rsp := &pb.LoginUserResponse{
 		User: convertUser(user),
 		SessionId: session.ID.String(),
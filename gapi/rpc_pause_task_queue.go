@@ -0,0 +1,49 @@
+package gapi
+
+import (
+	"context"
+
+	"github.com/juker1141/simplebank/audit"
+	db "github.com/juker1141/simplebank/db/sqlc"
+	"github.com/juker1141/simplebank/pb"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+)
+
+// PauseTaskQueue pauses or resumes a queue. The tasks of a paused queue are still enqueued,
+// they are processed when the queue is resumed
+func (server *Server) PauseTaskQueue(ctx context.Context, req *pb.PauseTaskQueueRequest) (*pb.PauseTaskQueueResponse, error) {
+	operator, err := server.authorizeAdmin(ctx)
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	violations := validatePauseTaskQueueRequest(req)
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	if req.GetIsPaused() {
+		err = server.taskInspector.PauseQueue(ctx, req.GetQueue())
+	} else {
+		err = server.taskInspector.UnpauseQueue(ctx, req.GetQueue())
+	}
+	if err != nil {
+		return nil, taskInspectorError(err, "failed to pause task queue")
+	}
+
+	action := "unpause_task_queue"
+	if req.GetIsPaused() {
+		action = "pause_task_queue"
+	}
+	server.audit(ctx, audit.AdminRecord(server.auditActor(ctx, operator), db.AuditTargetTaskQueue, req.GetQueue(), action))
+
+	return &pb.PauseTaskQueueResponse{}, nil
+}
+
+func validatePauseTaskQueueRequest(req *pb.PauseTaskQueueRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := validateTaskQueue(req.GetQueue()); err != nil {
+		violations = append(violations, fieldViolation("queue", err))
+	}
+
+	return violations
+}
\ No newline at end of file
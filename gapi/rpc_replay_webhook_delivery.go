@@ -0,0 +1,48 @@
+package gapi
+
+import (
+	"context"
+
+	db "github.com/juker1141/simplebank/db/sqlc"
+	"github.com/juker1141/simplebank/pb"
+	"github.com/juker1141/simplebank/worker"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) ReplayWebhookDelivery(ctx context.Context, req *pb.ReplayWebhookDeliveryRequest) (*pb.ReplayWebhookDeliveryResponse, error) {
+	owner, err := server.authorizeWebhookOwner(ctx)
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	violations := validateWebhookDeliveryId(req.GetId())
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	delivery, endpoint, err := server.getWebhookDelivery(ctx, req.GetId(), owner)
+	if err != nil {
+		return nil, err
+	}
+
+	if !endpoint.IsActive {
+		return nil, status.Errorf(codes.FailedPrecondition, "webhook endpoint is deleted")
+	}
+
+	txResult, err := server.store.ReplayWebhookDeliveryTx(ctx, db.ReplayWebhookDeliveryTxParams{
+		ID: delivery.ID,
+		Tasks: func(delivery db.WebhookDelivery) []db.OutboxTask {
+			return []db.OutboxTask{worker.WebhookDeliveryTask(delivery)}
+		},
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to replay webhook delivery: %s", err)
+	}
+
+	rsp := &pb.ReplayWebhookDeliveryResponse{
+		Delivery: convertWebhookDelivery(txResult.Delivery),
+	}
+
+	return rsp, nil
+}
\ No newline at end of file
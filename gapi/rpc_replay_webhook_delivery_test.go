@@ -0,0 +1,228 @@
+package gapi
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/jackc/pgx/v5/pgtype"
+	mockdb "github.com/juker1141/simplebank/db/mock"
+	db "github.com/juker1141/simplebank/db/sqlc"
+	"github.com/juker1141/simplebank/pb"
+	"github.com/juker1141/simplebank/token"
+	"github.com/juker1141/simplebank/util"
+	"github.com/juker1141/simplebank/worker"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type eqReplayWebhookDeliveryTxParamsMatcher struct {
+	delivery db.WebhookDelivery
+}
+
+func (expected eqReplayWebhookDeliveryTxParamsMatcher) Matches(x interface{}) bool {
+	actualArg, ok := x.(db.ReplayWebhookDeliveryTxParams)
+	if !ok || actualArg.ID != expected.delivery.ID {
+		return false
+	}
+
+	// check the task written to the outbox with the delivery
+	taskPayload := &worker.PayloadDeliverWebhook{
+		DeliveryID: expected.delivery.ID,
+	}
+
+	return isOutboxTask(actualArg.Tasks(expected.delivery), worker.TaskDeliverWebhook, taskPayload)
+}
+
+func (e eqReplayWebhookDeliveryTxParamsMatcher) String() string {
+	return fmt.Sprintf("matches delivery %d", e.delivery.ID)
+}
+
+func eqReplayWebhookDeliveryTxParams(delivery db.WebhookDelivery) gomock.Matcher {
+	return eqReplayWebhookDeliveryTxParamsMatcher{delivery}
+}
+
+func TestReplayWebhookDeliveryAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	other, _ := randomUser(t)
+
+	endpoint := db.WebhookEndpoint{
+		ID: 7,
+		Owner: pgtype.Text{String: user.Username, Valid: true},
+		Url: "https://example.com/webhooks",
+		IsActive: true,
+	}
+	deletedEndpoint := endpoint
+	deletedEndpoint.IsActive = false
+
+	// an endpoint of another API key
+	apiKey := util.RandomString(32)
+	apiKeyEndpoint := endpoint
+	apiKeyEndpoint.Owner = pgtype.Text{}
+	apiKeyEndpoint.ApiKeyID = pgtype.Int8{Int64: 2, Valid: true}
+
+	delivery := db.WebhookDelivery{
+		ID: 42,
+		EndpointID: endpoint.ID,
+		Status: db.WebhookDeliveryStatusFailed,
+		Attempts: 3,
+	}
+
+	testCases := []struct{
+		name string
+		buildStubs func(store *mockdb.MockStore)
+		buildContext func(t *testing.T, tokenMaker token.Maker) context.Context
+		checkResponse func(t *testing.T, res *pb.ReplayWebhookDeliveryResponse, err error)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetWebhookDelivery(gomock.Any(), gomock.Eq(delivery.ID)).
+					Times(1).
+					Return(delivery, nil)
+				store.EXPECT().
+					GetWebhookEndpoint(gomock.Any(), gomock.Eq(endpoint.ID)).
+					Times(1).
+					Return(endpoint, nil)
+
+				replayed := delivery
+				replayed.Status = db.WebhookDeliveryStatusPending
+				store.EXPECT().
+					ReplayWebhookDeliveryTx(gomock.Any(), eqReplayWebhookDeliveryTxParams(delivery)).
+					Times(1).
+					Return(db.ReplayWebhookDeliveryTxResult{Delivery: replayed}, nil)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.ReplayWebhookDeliveryResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, delivery.ID, res.GetDelivery().GetId())
+				require.Equal(t, db.WebhookDeliveryStatusPending, res.GetDelivery().GetStatus())
+			},
+		},
+		{
+			name: "OtherOwner",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetWebhookDelivery(gomock.Any(), gomock.Eq(delivery.ID)).
+					Times(1).
+					Return(delivery, nil)
+				store.EXPECT().
+					GetWebhookEndpoint(gomock.Any(), gomock.Eq(endpoint.ID)).
+					Times(1).
+					Return(endpoint, nil)
+				store.EXPECT().
+					ReplayWebhookDeliveryTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, other.Username, other.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.ReplayWebhookDeliveryResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.PermissionDenied, st.Code())
+			},
+		},
+		{
+			name: "OtherAPIKey",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAPIKeyByHashedKey(gomock.Any(), gomock.Eq(util.HashAPIKey(apiKey))).
+					Times(1).
+					Return(db.ApiKey{ID: 1, Name: "partner", Scopes: []string{util.APIKeyScopeWebhooks}}, nil)
+				store.EXPECT().
+					GetWebhookDelivery(gomock.Any(), gomock.Eq(delivery.ID)).
+					Times(1).
+					Return(delivery, nil)
+				store.EXPECT().
+					GetWebhookEndpoint(gomock.Any(), gomock.Eq(endpoint.ID)).
+					Times(1).
+					Return(apiKeyEndpoint, nil)
+				store.EXPECT().
+					ReplayWebhookDeliveryTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithAPIKey(apiKey)
+			},
+			checkResponse: func(t *testing.T, res *pb.ReplayWebhookDeliveryResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.PermissionDenied, st.Code())
+			},
+		},
+		{
+			name: "DeletedEndpoint",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetWebhookDelivery(gomock.Any(), gomock.Eq(delivery.ID)).
+					Times(1).
+					Return(delivery, nil)
+				store.EXPECT().
+					GetWebhookEndpoint(gomock.Any(), gomock.Eq(endpoint.ID)).
+					Times(1).
+					Return(deletedEndpoint, nil)
+				store.EXPECT().
+					ReplayWebhookDeliveryTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.ReplayWebhookDeliveryResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.FailedPrecondition, st.Code())
+			},
+		},
+		{
+			name: "NotFound",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetWebhookDelivery(gomock.Any(), gomock.Eq(delivery.ID)).
+					Times(1).
+					Return(db.WebhookDelivery{}, db.ErrRecordNotFound)
+				store.EXPECT().
+					ReplayWebhookDeliveryTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.ReplayWebhookDeliveryResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.NotFound, st.Code())
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T){
+			storeCtrl := gomock.NewController(t)
+			defer storeCtrl.Finish()
+			store := mockdb.NewMockStore(storeCtrl)
+
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store, nil)
+
+			ctx := tc.buildContext(t, server.tokenMaker)
+			res, err := server.ReplayWebhookDelivery(ctx, &pb.ReplayWebhookDeliveryRequest{Id: delivery.ID})
+
+			tc.checkResponse(t, res, err)
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+
+	db "github.com/juker1141/simplebank/db/sqlc"
+	"github.com/juker1141/simplebank/pb"
+	"github.com/juker1141/simplebank/util"
+	"github.com/juker1141/simplebank/worker"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) ResendVerifyEmail(ctx context.Context, req  *pb.ResendVerifyEmailRequest) (*pb.ResendVerifyEmailResponse, error) {
+	authPayload, err := server.authorizeUser(ctx, []string{util.BankerRole, util.DepositorRole})
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	user, err := server.store.GetUser(ctx, authPayload.Username)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.NotFound, "user not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get user: %s", err)
+	}
+
+	// a pending email is verified before it replaces the current one
+	email := user.PendingEmail
+	if email == "" {
+		if user.IsEmailVerified {
+			return nil, status.Errorf(codes.FailedPrecondition, "email is already verified")
+		}
+		email = user.Email
+	}
+
+	_, err = server.store.CreateVerifyEmailTx(ctx, db.CreateVerifyEmailTxParams{
+		CreateVerifyEmailParams: db.CreateVerifyEmailParams{
+			Username: user.Username,
+			Email: email,
+			SecretCode: util.RandomString(32),
+		},
+		Limit: server.verifyEmailLimit(),
+		Tasks: func(verifyEmail db.VerifyEmail) []db.OutboxTask {
+			return []db.OutboxTask{
+				{
+					TaskType: worker.TaskSendVerifyEmail,
+					Payload: &worker.PayloadSendVerifyEmail{
+						Username: user.Username,
+						VerifyEmailID: verifyEmail.ID,
+					},
+					Queue: worker.QueueCritical,
+					MaxRetry: 10,
+				},
+			}
+		},
+	})
+	if err != nil {
+		var limitErr *db.VerifyEmailLimitError
+		if errors.As(err, &limitErr) {
+			return nil, status.Errorf(codes.ResourceExhausted, "%s", limitErr)
+		}
+		return nil, status.Errorf(codes.Internal, "failed to create verify email: %s", err)
+	}
+
+	rsp := &pb.ResendVerifyEmailResponse{
+		Email: email,
+	}
+
+	return rsp, nil
+}
+
+// verifyEmailLimit makes sure the user waits between two verification emails,
+// and doesn't request more of them per hour than the configured limit. A zero limit disables the hourly check
+func (server *Server) verifyEmailLimit() db.VerifyEmailLimit {
+	config := server.config.Get()
+
+	return db.VerifyEmailLimit{
+		ResendInterval: config.VerifyEmailResendInterval,
+		HourlyLimit: config.VerifyEmailHourlyLimit,
+	}
+}
\ No newline at end of file
@@ -0,0 +1,223 @@
+package gapi
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/juker1141/simplebank/db/mock"
+	db "github.com/juker1141/simplebank/db/sqlc"
+	"github.com/juker1141/simplebank/pb"
+	"github.com/juker1141/simplebank/token"
+	"github.com/juker1141/simplebank/util"
+	"github.com/juker1141/simplebank/worker"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type eqCreateVerifyEmailTxParamsMatcher struct {
+	username string
+	email string
+}
+
+func (expected eqCreateVerifyEmailTxParamsMatcher) Matches(x interface{}) bool {
+	actualArg, ok := x.(db.CreateVerifyEmailTxParams)
+	if !ok {
+		return false
+	}
+
+	if actualArg.Username != expected.username || actualArg.Email != expected.email || len(actualArg.SecretCode) != 32 {
+		return false
+	}
+
+	// the limit is checked by the transaction once the user is locked
+	if actualArg.Limit != (db.VerifyEmailLimit{ResendInterval: time.Minute, HourlyLimit: 5}) {
+		return false
+	}
+
+	// the task sends the code that was just created
+	verifyEmail := db.VerifyEmail{
+		ID: util.RandomInt(1, 1000),
+		Username: expected.username,
+		Email: expected.email,
+	}
+	taskPayload := &worker.PayloadSendVerifyEmail{
+		Username: expected.username,
+		VerifyEmailID: verifyEmail.ID,
+	}
+
+	tasks := actualArg.Tasks(verifyEmail)
+	return isOutboxTask(tasks, worker.TaskSendVerifyEmail, taskPayload) &&
+		tasks[0].Queue == worker.QueueCritical
+}
+
+func (e eqCreateVerifyEmailTxParamsMatcher) String() string {
+	return fmt.Sprintf("matches username %s and email %s", e.username, e.email)
+}
+
+func eqCreateVerifyEmailTxParams(username string, email string) gomock.Matcher {
+	return eqCreateVerifyEmailTxParamsMatcher{username, email}
+}
+
+func TestResendVerifyEmailAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	verifiedUser := user
+	verifiedUser.IsEmailVerified = true
+
+	changingUser := verifiedUser
+	changingUser.PendingEmail = util.RandomEmail()
+
+	testCases := []struct{
+		name string
+		buildStubs func(store *mockdb.MockStore)
+		buildContext func(t *testing.T, tokenMaker token.Maker) context.Context
+		checkResponse func(t *testing.T, res *pb.ResendVerifyEmailResponse, err error)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					CreateVerifyEmailTx(gomock.Any(), eqCreateVerifyEmailTxParams(user.Username, user.Email)).
+					Times(1).
+					Return(db.CreateVerifyEmailTxResult{}, nil)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.ResendVerifyEmailResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, user.Email, res.GetEmail())
+			},
+		},
+		{
+			name: "PendingEmail",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(changingUser, nil)
+				store.EXPECT().
+					CreateVerifyEmailTx(gomock.Any(), eqCreateVerifyEmailTxParams(user.Username, changingUser.PendingEmail)).
+					Times(1).
+					Return(db.CreateVerifyEmailTxResult{}, nil)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.ResendVerifyEmailResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, changingUser.PendingEmail, res.GetEmail())
+			},
+		},
+		{
+			name: "AlreadyVerified",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(verifiedUser, nil)
+				store.EXPECT().
+					CreateVerifyEmailTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.ResendVerifyEmailResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.FailedPrecondition, st.Code())
+			},
+		},
+		{
+			name: "TooSoon",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					CreateVerifyEmailTx(gomock.Any(), eqCreateVerifyEmailTxParams(user.Username, user.Email)).
+					Times(1).
+					Return(db.CreateVerifyEmailTxResult{}, &db.VerifyEmailLimitError{Wait: 50 * time.Second})
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.ResendVerifyEmailResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.ResourceExhausted, st.Code())
+				require.Contains(t, st.Message(), "50s")
+			},
+		},
+		{
+			name: "HourlyLimit",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					CreateVerifyEmailTx(gomock.Any(), eqCreateVerifyEmailTxParams(user.Username, user.Email)).
+					Times(1).
+					Return(db.CreateVerifyEmailTxResult{}, &db.VerifyEmailLimitError{})
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.ResendVerifyEmailResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.ResourceExhausted, st.Code())
+			},
+		},
+		{
+			name: "NoAuthorization",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return context.Background()
+			},
+			checkResponse: func(t *testing.T, res *pb.ResendVerifyEmailResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.Unauthenticated, st.Code())
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T){
+			storeCtrl := gomock.NewController(t)
+			defer storeCtrl.Finish()
+			store := mockdb.NewMockStore(storeCtrl)
+
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store, nil)
+
+			ctx := tc.buildContext(t, server.tokenMaker)
+			res, err := server.ResendVerifyEmail(ctx, &pb.ResendVerifyEmailRequest{})
+
+			tc.checkResponse(t, res, err)
+		})
+	}
+}
\ No newline at end of file
@@ -0,0 +1,45 @@
+package gapi
+
+import (
+	"context"
+
+	"github.com/juker1141/simplebank/audit"
+	db "github.com/juker1141/simplebank/db/sqlc"
+	"github.com/juker1141/simplebank/pb"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+)
+
+// RetryTask processes a scheduled, retry or archived task now.
+// An archived task that fails again goes back to the archive, its retries are exhausted
+func (server *Server) RetryTask(ctx context.Context, req *pb.RetryTaskRequest) (*pb.RetryTaskResponse, error) {
+	operator, err := server.authorizeAdmin(ctx)
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	violations := validateRetryTaskRequest(req)
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	err = server.taskInspector.RunTask(ctx, req.GetQueue(), req.GetId())
+	if err != nil {
+		return nil, taskInspectorError(err, "failed to retry task")
+	}
+
+	server.audit(ctx, audit.AdminRecord(server.auditActor(ctx, operator), db.AuditTargetTask, audit.TaskTarget(req.GetQueue(), req.GetId()), "retry_task"))
+
+	return &pb.RetryTaskResponse{}, nil
+}
+
+func validateRetryTaskRequest(req *pb.RetryTaskRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := validateTaskQueue(req.GetQueue()); err != nil {
+		violations = append(violations, fieldViolation("queue", err))
+	}
+
+	if err := validateTaskId(req.GetId()); err != nil {
+		violations = append(violations, fieldViolation("id", err))
+	}
+
+	return violations
+}
\ No newline at end of file
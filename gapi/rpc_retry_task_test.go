@@ -0,0 +1,265 @@
+package gapi
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/juker1141/simplebank/db/mock"
+	db "github.com/juker1141/simplebank/db/sqlc"
+	"github.com/juker1141/simplebank/pb"
+	"github.com/juker1141/simplebank/token"
+	"github.com/juker1141/simplebank/util"
+	"github.com/juker1141/simplebank/worker"
+	mockwk "github.com/juker1141/simplebank/worker/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRetryTaskAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	banker, _ := randomUser(t)
+	banker.Role = util.BankerRole
+
+	taskID := util.RandomString(12)
+
+	testCases := []struct{
+		name string
+		req *pb.RetryTaskRequest
+		buildStubs func(store *mockdb.MockStore, inspector *mockwk.MockTaskInspector)
+		buildContext func(t *testing.T, tokenMaker token.Maker) context.Context
+		checkResponse func(t *testing.T, res *pb.RetryTaskResponse, err error)
+	}{
+		{
+			name: "OK",
+			req: &pb.RetryTaskRequest{
+				Queue: worker.QueueCritical,
+				Id: taskID,
+			},
+			buildStubs: func(store *mockdb.MockStore, inspector *mockwk.MockTaskInspector) {
+				inspector.EXPECT().
+					RunTask(gomock.Any(), gomock.Eq(worker.QueueCritical), gomock.Eq(taskID)).
+					Times(1).
+					Return(nil)
+				store.EXPECT().
+					AppendAuditEventsTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, records []db.AuditRecord) error {
+						require.Len(t, records, 1)
+						require.Equal(t, db.AuditEventAdminAction, records[0].EventType)
+						require.Equal(t, "user:"+banker.Username, records[0].Actor.Name)
+						require.Equal(t, db.AuditTargetTask, records[0].TargetType)
+						require.Equal(t, worker.QueueCritical+"/"+taskID, records[0].TargetID)
+						return nil
+					})
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, banker.Username, banker.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.RetryTaskResponse, err error) {
+				require.NoError(t, err)
+				require.NotNil(t, res)
+			},
+		},
+		{
+			name: "Depositor",
+			req: &pb.RetryTaskRequest{
+				Queue: worker.QueueCritical,
+				Id: taskID,
+			},
+			buildStubs: func(store *mockdb.MockStore, inspector *mockwk.MockTaskInspector) {
+				inspector.EXPECT().
+					RunTask(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().
+					AppendAuditEventsTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.RetryTaskResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.Unauthenticated, st.Code())
+			},
+		},
+		{
+			name: "APIKey",
+			req: &pb.RetryTaskRequest{
+				Queue: worker.QueueCritical,
+				Id: taskID,
+			},
+			buildStubs: func(store *mockdb.MockStore, inspector *mockwk.MockTaskInspector) {
+				store.EXPECT().
+					GetAPIKeyByHashedKey(gomock.Any(), gomock.Any()).
+					Times(0)
+				inspector.EXPECT().
+					RunTask(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().
+					AppendAuditEventsTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				// the API keys of the partners can't administer the bank
+				return newContextWithAPIKey(util.RandomString(32))
+			},
+			checkResponse: func(t *testing.T, res *pb.RetryTaskResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.Unauthenticated, st.Code())
+			},
+		},
+		{
+			name: "NotFound",
+			req: &pb.RetryTaskRequest{
+				Queue: worker.QueueCritical,
+				Id: taskID,
+			},
+			buildStubs: func(store *mockdb.MockStore, inspector *mockwk.MockTaskInspector) {
+				inspector.EXPECT().
+					RunTask(gomock.Any(), gomock.Eq(worker.QueueCritical), gomock.Eq(taskID)).
+					Times(1).
+					Return(fmt.Errorf("failed to run task: %w", worker.ErrTaskNotFound))
+				store.EXPECT().
+					AppendAuditEventsTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, banker.Username, banker.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.RetryTaskResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.NotFound, st.Code())
+			},
+		},
+		{
+			name: "PendingTask",
+			req: &pb.RetryTaskRequest{
+				Queue: worker.QueueCritical,
+				Id: taskID,
+			},
+			buildStubs: func(store *mockdb.MockStore, inspector *mockwk.MockTaskInspector) {
+				inspector.EXPECT().
+					RunTask(gomock.Any(), gomock.Eq(worker.QueueCritical), gomock.Eq(taskID)).
+					Times(1).
+					Return(fmt.Errorf("failed to run task: %w: task is pending", worker.ErrInvalidTaskState))
+				store.EXPECT().
+					AppendAuditEventsTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, banker.Username, banker.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.RetryTaskResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.FailedPrecondition, st.Code())
+			},
+		},
+		{
+			name: "InvalidId",
+			req: &pb.RetryTaskRequest{
+				Queue: worker.QueueCritical,
+			},
+			buildStubs: func(store *mockdb.MockStore, inspector *mockwk.MockTaskInspector) {
+				inspector.EXPECT().
+					RunTask(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().
+					AppendAuditEventsTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, banker.Username, banker.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.RetryTaskResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.InvalidArgument, st.Code())
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T){
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			inspector := mockwk.NewMockTaskInspector(ctrl)
+
+			tc.buildStubs(store, inspector)
+
+			server := newTestServer(t, store, nil)
+			server.taskInspector = inspector
+
+			ctx := tc.buildContext(t, server.tokenMaker)
+			res, err := server.RetryTask(ctx, tc.req)
+
+			tc.checkResponse(t, res, err)
+		})
+	}
+}
+
+func TestListTasksAPI(t *testing.T) {
+	banker, _ := randomUser(t)
+	banker.Role = util.BankerRole
+
+	task := worker.TaskInfo{
+		ID: util.RandomString(12),
+		Queue: worker.QueueCritical,
+		Type: worker.TaskSendVerifyEmail,
+		Payload: []byte(`{"username":"alice","verify_email_id":1}`),
+		State: worker.TaskStateArchived,
+		MaxRetry: 10,
+		Retried: 10,
+		LastError: "smtp server is down",
+		LastFailedAt: time.Now(),
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	inspector := mockwk.NewMockTaskInspector(ctrl)
+	inspector.EXPECT().
+		ListTasks(gomock.Any(), gomock.Eq(worker.QueueCritical), gomock.Eq(worker.TaskStateArchived), gomock.Eq(1), gomock.Eq(5)).
+		Times(1).
+		Return([]worker.TaskInfo{task}, nil)
+
+	server := newTestServer(t, mockdb.NewMockStore(ctrl), nil)
+	server.taskInspector = inspector
+
+	ctx := newContextWithBearerToken(t, server.tokenMaker, banker.Username, banker.Role, time.Minute)
+	res, err := server.ListTasks(ctx, &pb.ListTasksRequest{
+		Queue: worker.QueueCritical,
+		State: worker.TaskStateArchived,
+		PageId: 1,
+		PageSize: 5,
+	})
+	require.NoError(t, err)
+	require.Len(t, res.GetTasks(), 1)
+	require.Equal(t, task.ID, res.GetTasks()[0].GetId())
+	require.Equal(t, string(task.Payload), res.GetTasks()[0].GetPayload())
+	require.Equal(t, task.LastError, res.GetTasks()[0].GetLastError())
+
+	_, err = server.ListTasks(ctx, &pb.ListTasksRequest{
+		Queue: worker.QueueCritical,
+		State: "completed",
+		PageId: 1,
+		PageSize: 5,
+	})
+	st, ok := status.FromError(err)
+	require.True(t, ok)
+	require.Equal(t, codes.InvalidArgument, st.Code())
+}
@@ -0,0 +1,73 @@
+package gapi
+
+import (
+	"context"
+
+	db "github.com/juker1141/simplebank/db/sqlc"
+	"github.com/juker1141/simplebank/pb"
+	"github.com/juker1141/simplebank/util"
+	"github.com/juker1141/simplebank/val"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) UpdateNotificationPreference(ctx context.Context, req *pb.UpdateNotificationPreferenceRequest) (*pb.UpdateNotificationPreferenceResponse, error) {
+	authPayload, err := server.authorizeUser(ctx, []string{util.BankerRole, util.DepositorRole})
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	violations := validateUpdateNotificationPreferenceRequest(req)
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	preference, err := db.GetEffectiveNotificationPreference(ctx, server.store, authPayload.Username, req.GetEventType(), req.GetChannel())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get notification preference: %s", err)
+	}
+
+	arg := db.UpsertNotificationPreferenceParams{
+		Username: authPayload.Username,
+		EventType: req.GetEventType(),
+		Channel: req.GetChannel(),
+		IsEnabled: preference.IsEnabled,
+		MinAmount: preference.MinAmount,
+	}
+	if req.IsEnabled != nil {
+		arg.IsEnabled = req.GetIsEnabled()
+	}
+	if req.MinAmount != nil {
+		arg.MinAmount = req.GetMinAmount()
+	}
+
+	preference, err = server.store.UpsertNotificationPreference(ctx, arg)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to update notification preference: %s", err)
+	}
+
+	rsp := &pb.UpdateNotificationPreferenceResponse{
+		Preference: convertNotificationPreference(preference),
+	}
+
+	return rsp, nil
+}
+
+func validateUpdateNotificationPreferenceRequest(req *pb.UpdateNotificationPreferenceRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := val.ValidateNotificationEvent(req.GetEventType()); err != nil {
+		violations = append(violations, fieldViolation("event_type", err))
+	}
+
+	if err := val.ValidateNotificationChannel(req.GetChannel()); err != nil {
+		violations = append(violations, fieldViolation("channel", err))
+	}
+
+	if req.MinAmount != nil {
+		if err := val.ValidateMinAmount(req.GetMinAmount()); err != nil {
+			violations = append(violations, fieldViolation("min_amount", err))
+		}
+	}
+
+	return violations
+}
\ No newline at end of file
@@ -0,0 +1,232 @@
+package gapi
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/juker1141/simplebank/db/mock"
+	db "github.com/juker1141/simplebank/db/sqlc"
+	"github.com/juker1141/simplebank/pb"
+	"github.com/juker1141/simplebank/token"
+	"github.com/juker1141/simplebank/util"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestUpdateNotificationPreferenceAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	disabled := false
+	minAmount := int64(100)
+	negativeAmount := int64(-1)
+
+	getArg := db.GetNotificationPreferenceParams{
+		Username: user.Username,
+		EventType: util.NotificationEventCredit,
+		Channel: util.NotificationChannelEmail,
+	}
+
+	testCases := []struct{
+		name string
+		req *pb.UpdateNotificationPreferenceRequest
+		buildStubs func(store *mockdb.MockStore)
+		buildContext func(t *testing.T, tokenMaker token.Maker) context.Context
+		checkResponse func(t *testing.T, res *pb.UpdateNotificationPreferenceResponse, err error)
+	}{
+		{
+			name: "OK",
+			req: &pb.UpdateNotificationPreferenceRequest{
+				EventType: util.NotificationEventCredit,
+				Channel: util.NotificationChannelEmail,
+				MinAmount: &minAmount,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetNotificationPreference(gomock.Any(), gomock.Eq(getArg)).
+					Times(1).
+					Return(db.NotificationPreference{}, db.ErrRecordNotFound)
+
+				// the fields that aren't set keep the default preference
+				arg := db.UpsertNotificationPreferenceParams{
+					Username: user.Username,
+					EventType: util.NotificationEventCredit,
+					Channel: util.NotificationChannelEmail,
+					IsEnabled: true,
+					MinAmount: minAmount,
+				}
+				store.EXPECT().
+					UpsertNotificationPreference(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(db.NotificationPreference{
+						Username: arg.Username,
+						EventType: arg.EventType,
+						Channel: arg.Channel,
+						IsEnabled: arg.IsEnabled,
+						MinAmount: arg.MinAmount,
+						UpdatedAt: time.Now(),
+					}, nil)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.UpdateNotificationPreferenceResponse, err error) {
+				require.NoError(t, err)
+				preference := res.GetPreference()
+				require.Equal(t, util.NotificationEventCredit, preference.EventType)
+				require.True(t, preference.IsEnabled)
+				require.Equal(t, minAmount, preference.MinAmount)
+			},
+		},
+		{
+			name: "KeepSavedFields",
+			req: &pb.UpdateNotificationPreferenceRequest{
+				EventType: util.NotificationEventCredit,
+				Channel: util.NotificationChannelEmail,
+				IsEnabled: &disabled,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetNotificationPreference(gomock.Any(), gomock.Eq(getArg)).
+					Times(1).
+					Return(db.NotificationPreference{
+						Username: user.Username,
+						EventType: util.NotificationEventCredit,
+						Channel: util.NotificationChannelEmail,
+						IsEnabled: true,
+						MinAmount: minAmount,
+					}, nil)
+
+				arg := db.UpsertNotificationPreferenceParams{
+					Username: user.Username,
+					EventType: util.NotificationEventCredit,
+					Channel: util.NotificationChannelEmail,
+					IsEnabled: false,
+					MinAmount: minAmount,
+				}
+				store.EXPECT().
+					UpsertNotificationPreference(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(db.NotificationPreference{
+						Username: arg.Username,
+						EventType: arg.EventType,
+						Channel: arg.Channel,
+						IsEnabled: arg.IsEnabled,
+						MinAmount: arg.MinAmount,
+					}, nil)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.UpdateNotificationPreferenceResponse, err error) {
+				require.NoError(t, err)
+				require.False(t, res.GetPreference().IsEnabled)
+				require.Equal(t, minAmount, res.GetPreference().MinAmount)
+			},
+		},
+		{
+			name: "InvalidArguments",
+			req: &pb.UpdateNotificationPreferenceRequest{
+				EventType: "withdrawal",
+				Channel: "sms",
+				MinAmount: &negativeAmount,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpsertNotificationPreference(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.UpdateNotificationPreferenceResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.InvalidArgument, st.Code())
+			},
+		},
+		{
+			name: "NoAuthorization",
+			req: &pb.UpdateNotificationPreferenceRequest{
+				EventType: util.NotificationEventCredit,
+				Channel: util.NotificationChannelEmail,
+				IsEnabled: &disabled,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpsertNotificationPreference(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return context.Background()
+			},
+			checkResponse: func(t *testing.T, res *pb.UpdateNotificationPreferenceResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.Unauthenticated, st.Code())
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T){
+			storeCtrl := gomock.NewController(t)
+			defer storeCtrl.Finish()
+			store := mockdb.NewMockStore(storeCtrl)
+
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store, nil)
+
+			ctx := tc.buildContext(t, server.tokenMaker)
+			res, err := server.UpdateNotificationPreference(ctx, tc.req)
+
+			tc.checkResponse(t, res, err)
+		})
+	}
+}
+
+func TestListNotificationPreferencesAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	storeCtrl := gomock.NewController(t)
+	defer storeCtrl.Finish()
+	store := mockdb.NewMockStore(storeCtrl)
+
+	saved := db.NotificationPreference{
+		Username: user.Username,
+		EventType: util.NotificationEventDebit,
+		Channel: util.NotificationChannelEmail,
+		IsEnabled: false,
+		MinAmount: 50,
+	}
+	store.EXPECT().
+		ListNotificationPreferences(gomock.Any(), gomock.Eq(user.Username)).
+		Times(1).
+		Return([]db.NotificationPreference{saved}, nil)
+
+	server := newTestServer(t, store, nil)
+	ctx := newContextWithBearerToken(t, server.tokenMaker, user.Username, user.Role, time.Minute)
+
+	res, err := server.ListNotificationPreferences(ctx, &pb.ListNotificationPreferencesRequest{})
+	require.NoError(t, err)
+
+	// the saved preference is listed with the defaults of the other events
+	preferences := res.GetPreferences()
+	require.Len(t, preferences, len(util.NotificationEvents())*len(util.NotificationChannels()))
+	for _, preference := range preferences {
+		if preference.EventType == util.NotificationEventDebit {
+			require.False(t, preference.IsEnabled)
+			require.Equal(t, saved.MinAmount, preference.MinAmount)
+		} else {
+			require.True(t, preference.IsEnabled)
+			require.Zero(t, preference.MinAmount)
+		}
+	}
+}
\ No newline at end of file
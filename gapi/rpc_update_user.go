@@ -6,17 +6,19 @@ import (
 	"time"
 
 	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/juker1141/simplebank/audit"
 	db "github.com/juker1141/simplebank/db/sqlc"
 	"github.com/juker1141/simplebank/pb"
 	"github.com/juker1141/simplebank/util"
 	"github.com/juker1141/simplebank/val"
+	"github.com/juker1141/simplebank/worker"
 	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
 
 func (server *Server) UpdateUser(ctx context.Context, req  *pb.UpdateUserRequest) (*pb.UpdateUserResponse, error) {
-	authPayload, err := server.authorizeUser(ctx)
+	authPayload, err := server.authorizeUser(ctx, []string{util.BankerRole, util.DepositorRole})
 	if err != nil {
 		return nil, unauthenticatedError(err)
 	}
@@ -36,10 +38,15 @@ func (server *Server) UpdateUser(ctx context.Context, req  *pb.UpdateUserRequest
 			String: req.GetFullName(),
 			Valid: req.FullName != nil,
 		},
-		Email: pgtype.Text{
+		// the new email is only used once it is verified
+		PendingEmail: pgtype.Text{
 			String: req.GetEmail(),
 			Valid: req.Email != nil,
 		},
+		Language: pgtype.Text{
+			String: req.GetLanguage(),
+			Valid: req.Language != nil,
+		},
 	}
 
 	if req.Password != nil {
@@ -58,20 +65,60 @@ func (server *Server) UpdateUser(ctx context.Context, req  *pb.UpdateUserRequest
 		}
 	}
 
-	user, err := server.store.UpdateUser(ctx, arg)
+	txArg := db.UpdateUserTxParams{
+		UpdateUserParams: arg,
+		Audit: audit.UpdateUserRecords(server.auditActor(ctx, audit.UserActor(authPayload.Username))),
+	}
+	if req.Email != nil {
+		// a new email sends a verification email, which is limited like the resent ones
+		txArg.VerifySecretCode = util.RandomString(32)
+		txArg.VerifyEmailLimit = server.verifyEmailLimit()
+		txArg.Tasks = emailChangeTasks
+	}
+
+	txResult, err := server.store.UpdateUserTx(ctx, txArg)
 	if err != nil {
 		if errors.Is(err, db.ErrRecordNotFound) {
 			return nil, status.Errorf(codes.NotFound, "user not found")
 		}
+		var limitErr *db.VerifyEmailLimitError
+		if errors.As(err, &limitErr) {
+			return nil, status.Errorf(codes.ResourceExhausted, "%s", limitErr)
+		}
 		return nil, status.Errorf(codes.Internal, "failed to update user: %s", err)
 	}
 	rsp := &pb.UpdateUserResponse{
-		User: convertUser(user),
+		User: convertUser(txResult.User),
 	}
 
 	return rsp, nil
 }
 
+// emailChangeTasks verifies the pending email of the user, and warns the current address about the change
+func emailChangeTasks(result db.UpdateUserTxResult) []db.OutboxTask {
+	user := result.User
+	return []db.OutboxTask{
+		{
+			TaskType: worker.TaskSendVerifyEmail,
+			Payload: &worker.PayloadSendVerifyEmail{
+				Username: user.Username,
+				VerifyEmailID: result.VerifyEmail.ID,
+			},
+			Queue: worker.QueueCritical,
+			MaxRetry: 10,
+		},
+		{
+			TaskType: worker.TaskSendEmailChangeNotice,
+			Payload: &worker.PayloadSendEmailChangeNotice{
+				Username: user.Username,
+				NewEmail: user.PendingEmail,
+			},
+			Queue: worker.QueueCritical,
+			MaxRetry: 10,
+		},
+	}
+}
+
 func validateUpdateUserRequest(req *pb.UpdateUserRequest) (violations []*errdetails.BadRequest_FieldViolation) {
 	if err := val.ValidateUsername(req.GetUsername()); err != nil {
 		violations = append(violations, fieldViolation("username", err))
@@ -95,5 +142,11 @@ func validateUpdateUserRequest(req *pb.UpdateUserRequest) (violations []*errdeta
 		}
 	}
 
+	if req.Language != nil {
+		if err := val.ValidateLanguage(req.GetLanguage()); err != nil {
+			violations = append(violations, fieldViolation("language", err))
+		}
+	}
+
 	return violations
 }
\ No newline at end of file
@@ -2,6 +2,8 @@ package gapi
 
 import (
 	"context"
+	"fmt"
+	"reflect"
 	"testing"
 	"time"
 
@@ -12,11 +14,71 @@ import (
 	"github.com/juker1141/simplebank/pb"
 	"github.com/juker1141/simplebank/token"
 	"github.com/juker1141/simplebank/util"
+	"github.com/juker1141/simplebank/worker"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
 
+type eqUpdateUserTxParamsMatcher struct {
+	arg db.UpdateUserTxParams
+	user db.User
+}
+
+func (expected eqUpdateUserTxParamsMatcher) Matches(x interface{}) bool {
+	actualArg, ok := x.(db.UpdateUserTxParams)
+	if !ok {
+		return false
+	}
+
+	if !reflect.DeepEqual(expected.arg.UpdateUserParams, actualArg.UpdateUserParams) {
+		return false
+	}
+
+	if !expected.arg.UpdateUserParams.PendingEmail.Valid {
+		return actualArg.Tasks == nil && actualArg.VerifySecretCode == ""
+	}
+
+	if len(actualArg.VerifySecretCode) != 32 {
+		return false
+	}
+
+	// the limit is checked by the transaction once the user is locked
+	if actualArg.VerifyEmailLimit != (db.VerifyEmailLimit{ResendInterval: time.Minute, HourlyLimit: 5}) {
+		return false
+	}
+
+	// the pending email is verified with the code created with the change, and the current address is notified
+	result := db.UpdateUserTxResult{
+		User: expected.user,
+		VerifyEmail: db.VerifyEmail{
+			ID: util.RandomInt(1, 1000),
+			Username: expected.user.Username,
+			Email: expected.user.PendingEmail,
+		},
+	}
+	tasks := actualArg.Tasks(result)
+	if len(tasks) != 2 {
+		return false
+	}
+
+	return isOutboxTask(tasks[:1], worker.TaskSendVerifyEmail, &worker.PayloadSendVerifyEmail{
+		Username: expected.user.Username,
+		VerifyEmailID: result.VerifyEmail.ID,
+	}) && isOutboxTask(tasks[1:], worker.TaskSendEmailChangeNotice, &worker.PayloadSendEmailChangeNotice{
+		Username: expected.user.Username,
+		NewEmail: expected.user.PendingEmail,
+	})
+}
+
+func (e eqUpdateUserTxParamsMatcher) String() string {
+	return fmt.Sprintf("matches arg %v", e.arg)
+}
+
+func eqUpdateUserTxParams(arg db.UpdateUserTxParams, user db.User) gomock.Matcher {
+	return eqUpdateUserTxParamsMatcher{arg, user}
+}
+
 func TestUpdateUserAPI(t *testing.T) {
 	user, _ := randomUser(t)
 
@@ -39,34 +101,37 @@ func TestUpdateUserAPI(t *testing.T) {
 				Email: &newEmail,
 			},
 			buildStubs: func(store *mockdb.MockStore) {
-				arg := db.UpdateUserParams{
-					Username: user.Username,
-					FullName: pgtype.Text{
-						String: newName,
-						Valid: true,
-					},
-					Email: pgtype.Text{
-						String: newEmail,
-						Valid: true,
+				arg := db.UpdateUserTxParams{
+					UpdateUserParams: db.UpdateUserParams{
+						Username: user.Username,
+						FullName: pgtype.Text{
+							String: newName,
+							Valid: true,
+						},
+						PendingEmail: pgtype.Text{
+							String: newEmail,
+							Valid: true,
+						},
 					},
 				}
 				updateUser := db.User{
 					Username: user.Username,
 					HashedPassword: user.HashedPassword,
 					FullName: newName,
-					Email: newEmail,
+					Email: user.Email,
+					PendingEmail: newEmail,
 					PasswordChangedAt: user.PasswordChangedAt,
 					CreatedAt: user.CreatedAt,
 					IsEmailVerified: user.IsEmailVerified,
 				}
 
 				store.EXPECT().
-					UpdateUser(gomock.Any(), gomock.Eq(arg)).
+					UpdateUserTx(gomock.Any(), eqUpdateUserTxParams(arg, updateUser)).
 					Times(1).
-					Return(updateUser, nil)
+					Return(db.UpdateUserTxResult{User: updateUser}, nil)
 			},
 			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
-				return newContextWithBearerToken(t, tokenMaker, user.Username, time.Minute)
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
 			},
 			checkResponse: func(t *testing.T, res *pb.UpdateUserResponse,err error) {
 				require.NoError(t, err)
@@ -74,7 +139,43 @@ func TestUpdateUserAPI(t *testing.T) {
 				updateUser := res.GetUser()
 				require.Equal(t, user.Username, updateUser.Username)
 				require.Equal(t, newName, updateUser.FullName)
-				require.Equal(t, newEmail, updateUser.Email)
+				// the email only changes once the new address is verified
+				require.Equal(t, user.Email, updateUser.Email)
+				require.Equal(t, newEmail, updateUser.PendingEmail)
+			},
+		},
+		{
+			name: "OnlyFullName",
+			req: &pb.UpdateUserRequest{
+				Username: user.Username,
+				FullName: &newName,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.UpdateUserTxParams{
+					UpdateUserParams: db.UpdateUserParams{
+						Username: user.Username,
+						FullName: pgtype.Text{
+							String: newName,
+							Valid: true,
+						},
+					},
+				}
+				updateUser := user
+				updateUser.FullName = newName
+
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), eqUpdateUserTxParams(arg, updateUser)).
+					Times(1).
+					Return(db.UpdateUserTxResult{User: updateUser}, nil)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.UpdateUserResponse,err error) {
+				require.NoError(t, err)
+				require.NotNil(t, res)
+				require.Equal(t, newName, res.GetUser().FullName)
+				require.Empty(t, res.GetUser().PendingEmail)
 			},
 		},
 		{
@@ -86,12 +187,12 @@ func TestUpdateUserAPI(t *testing.T) {
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					UpdateUser(gomock.Any(), gomock.Any()).
+					UpdateUserTx(gomock.Any(), gomock.Any()).
 					Times(1).
-					Return(db.User{}, db.ErrRecordNotFound)
+					Return(db.UpdateUserTxResult{}, db.ErrRecordNotFound)
 			},
 			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
-				return newContextWithBearerToken(t, tokenMaker, user.Username, time.Minute)
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
 			},
 			checkResponse: func(t *testing.T, res *pb.UpdateUserResponse,err error) {
 				require.Error(t, err)
@@ -100,6 +201,50 @@ func TestUpdateUserAPI(t *testing.T) {
 				require.Equal(t, codes.NotFound, st.Code())
 			},
 		},
+		{
+			name: "EmailTooSoon",
+			req: &pb.UpdateUserRequest{
+				Username: user.Username,
+				Email: &newEmail,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.UpdateUserTxResult{}, &db.VerifyEmailLimitError{Wait: 50 * time.Second})
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.UpdateUserResponse,err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.ResourceExhausted, st.Code())
+			},
+		},
+		{
+			name: "EmailHourlyLimit",
+			req: &pb.UpdateUserRequest{
+				Username: user.Username,
+				Email: &newEmail,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.UpdateUserTxResult{}, &db.VerifyEmailLimitError{})
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.UpdateUserResponse,err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.ResourceExhausted, st.Code())
+			},
+		},
 		{
 			name: "ExpiredToken",
 			req: &pb.UpdateUserRequest{
@@ -109,11 +254,11 @@ func TestUpdateUserAPI(t *testing.T) {
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					UpdateUser(gomock.Any(), gomock.Any()).
+					UpdateUserTx(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
-				return newContextWithBearerToken(t, tokenMaker, user.Username, -time.Minute)
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, -time.Minute)
 			},
 			checkResponse: func(t *testing.T, res *pb.UpdateUserResponse,err error) {
 				require.Error(t, err)
@@ -131,7 +276,7 @@ func TestUpdateUserAPI(t *testing.T) {
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					UpdateUser(gomock.Any(), gomock.Any()).
+					UpdateUserTx(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
@@ -153,11 +298,11 @@ func TestUpdateUserAPI(t *testing.T) {
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					UpdateUser(gomock.Any(), gomock.Any()).
+					UpdateUserTx(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
-				return newContextWithBearerToken(t, tokenMaker, user.Username, time.Minute)
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
 			},
 			checkResponse: func(t *testing.T, res *pb.UpdateUserResponse,err error) {
 				require.Error(t, err)
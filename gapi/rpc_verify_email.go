@@ -2,10 +2,15 @@ package gapi
 
 import (
 	"context"
+	"errors"
 
+	"github.com/juker1141/simplebank/audit"
 	db "github.com/juker1141/simplebank/db/sqlc"
 	"github.com/juker1141/simplebank/pb"
+	"github.com/juker1141/simplebank/util"
 	"github.com/juker1141/simplebank/val"
+	"github.com/juker1141/simplebank/webhook"
+	"github.com/juker1141/simplebank/worker"
 	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -20,8 +25,23 @@ func (server *Server) VerifyEmail(ctx context.Context, req  *pb.VerifyEmailReque
 	txResult, err := server.store.VerifyEmailTx(ctx, db.VerifyEmailTxParams{
 		EmailID: req.GetEmailId(),
 		SecretCode: req.GetSecretCode(),
+		Tasks: func(user db.User) []db.OutboxTask {
+			return []db.OutboxTask{
+				worker.WebhookEventTask(util.WebhookEventUserVerified, []string{user.Username}, webhook.UserData{
+					Username: user.Username,
+					Email: user.Email,
+				}),
+			}
+		},
+		Audit: audit.VerifyEmailRecords(server.auditActor(ctx, "")),
 	})
 	if err != nil {
+		if errors.Is(err, db.ErrVerifyEmailOutdated) {
+			return nil, status.Errorf(codes.FailedPrecondition, "the email address was changed, please verify the new one")
+		}
+		if db.ErrorCode(err) == db.UniqueViolation {
+			return nil, status.Errorf(codes.AlreadyExists, "the email address is used by another user")
+		}
 		return nil, status.Errorf(codes.Internal, "failed to verify email")
 	}
 
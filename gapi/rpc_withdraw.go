@@ -0,0 +1,45 @@
+package gapi
+
+import (
+	"context"
+
+	"github.com/juker1141/simplebank/audit"
+	db "github.com/juker1141/simplebank/db/sqlc"
+	"github.com/juker1141/simplebank/pb"
+)
+
+func (server *Server) Withdraw(ctx context.Context, req *pb.WithdrawRequest) (*pb.WithdrawResponse, error) {
+	createdBy, err := server.authorizeOperator(ctx)
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	violations := validateExternalTransferRequest(req.GetAccountId(), req.GetAmount(), req.GetCurrency(), req.GetExternalReference())
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	err = server.checkAccountCurrency(ctx, req.GetAccountId(), req.GetCurrency())
+	if err != nil {
+		return nil, err
+	}
+
+	txResult, err := server.store.WithdrawTx(ctx, db.ExternalTransferTxParams{
+		AccountID: req.GetAccountId(),
+		Amount: req.GetAmount(),
+		ExternalReference: req.GetExternalReference(),
+		CreatedBy: createdBy,
+		Tasks: externalTransferTasks,
+		Audit: audit.ExternalTransferRecords(server.auditActor(ctx, createdBy)),
+	})
+	if err != nil {
+		return nil, externalTransferError(err)
+	}
+
+	rsp := &pb.WithdrawResponse{
+		Account: convertAccount(txResult.Account),
+		ExternalTransfer: convertExternalTransfer(txResult.ExternalTransfer),
+	}
+
+	return rsp, nil
+}
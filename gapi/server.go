@@ -2,9 +2,11 @@ package gapi
 
 import (
 	"fmt"
+	"net/netip"
 
 	db "github.com/juker1141/simplebank/db/sqlc"
 	"github.com/juker1141/simplebank/pb"
+	"github.com/juker1141/simplebank/policy"
 	"github.com/juker1141/simplebank/token"
 	"github.com/juker1141/simplebank/util"
 	"github.com/juker1141/simplebank/worker"
@@ -12,24 +14,37 @@ import (
 
 type Server struct {
 	pb.UnimplementedSimpleBankServer
-	config util.Config
+	config *util.LiveConfig
 	store db.Store
 	tokenMaker token.Maker
+	verifiedEmail *policy.VerifiedEmail
 	taskDistributor worker.TaskDistribtor
+	taskInspector worker.TaskInspector
+	trustedProxies []netip.Prefix
 }
 
 // NewServer creates a new gRPC server.
-func NewServer(config util.Config, store db.Store, taskDistributor worker.TaskDistribtor) (*Server, error) {
-	tokenMaker, err := token.NewPasetoMaker(config.TokenSymmetricKey)
+// The values of the config that can be reloaded are read from the live config for each request
+func NewServer(config *util.LiveConfig, store db.Store, taskDistributor worker.TaskDistribtor, taskInspector worker.TaskInspector) (*Server, error) {
+	startConfig := config.Get()
+	tokenMaker, err := token.NewPasetoMaker(startConfig.TokenSymmetricKey)
 	if err != nil {
 		return nil, fmt.Errorf("cannot create token maker: %w", err)
 	}
 
+	trustedProxies, err := parseTrustedProxies(startConfig.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+
 	server := &Server{
 		config: config,
 		store: store,
 		tokenMaker: tokenMaker,
+		verifiedEmail: policy.NewVerifiedEmail(startConfig.RequireVerifiedEmail, startConfig.TrustTokenEmailVerified, store),
 		taskDistributor: taskDistributor,
+		taskInspector: taskInspector,
+		trustedProxies: trustedProxies,
 	}
 
 	return server, nil
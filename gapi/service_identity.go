@@ -0,0 +1,56 @@
+package gapi
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/juker1141/simplebank/certs"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+type serviceIdentityKey struct{}
+
+// GrpcServiceIdentity is an interceptor that keeps the identity of the internal services
+// authenticated with a client certificate, for the authorization of the calls
+func GrpcServiceIdentity(
+	ctx context.Context,
+	req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+	if p, ok := peer.FromContext(ctx); ok {
+		if tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+			ctx = withServiceIdentity(ctx, certs.ServiceIdentity(tlsInfo.State))
+		}
+	}
+
+	return handler(ctx, req)
+}
+
+// HttpServiceIdentity is a middleware that keeps the identity of the internal services
+// authenticated with a client certificate. The gateway calls the gRPC server in-process,
+// so the identity reaches the RPCs through the context of the request
+func HttpServiceIdentity(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		if req.TLS != nil {
+			req = req.WithContext(withServiceIdentity(req.Context(), certs.ServiceIdentity(*req.TLS)))
+		}
+
+		handler.ServeHTTP(res, req)
+	})
+}
+
+func withServiceIdentity(ctx context.Context, identity string) context.Context {
+	if identity == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, serviceIdentityKey{}, identity)
+}
+
+// serviceIdentity returns the identity of the service that made the call, if it has one
+func serviceIdentity(ctx context.Context) (string, bool) {
+	identity, ok := ctx.Value(serviceIdentityKey{}).(string)
+	return identity, ok
+}
\ No newline at end of file
@@ -0,0 +1,55 @@
+package gapi
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/juker1141/simplebank/db/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHttpServiceIdentity(t *testing.T) {
+	var identity string
+	var ok bool
+	handler := HttpServiceIdentity(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		identity, ok = serviceIdentity(req.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/task_queues", nil)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	require.False(t, ok)
+
+	req.TLS = &tls.ConnectionState{
+		HandshakeComplete: true,
+		PeerCertificates: []*x509.Certificate{
+			{Subject: pkix.Name{CommonName: "admin"}},
+		},
+	}
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	require.True(t, ok)
+	require.Equal(t, "admin", identity)
+}
+
+func TestAuthorizeOperatorService(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	server := newTestServer(t, mockdb.NewMockStore(ctrl), nil)
+	config := server.config.Get()
+	config.OperatorServices = []string{"spiffe://simplebank/admin"}
+	server.config.Update(config)
+
+	operator, err := server.authorizeOperator(withServiceIdentity(context.Background(), "spiffe://simplebank/admin"))
+	require.NoError(t, err)
+	require.Equal(t, "service:spiffe://simplebank/admin", operator)
+
+	// the other services have to authenticate like the other callers
+	_, err = server.authorizeOperator(withServiceIdentity(context.Background(), "spiffe://simplebank/worker"))
+	require.Error(t, err)
+}
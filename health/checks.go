@@ -0,0 +1,52 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Names of the checks of the database
+const (
+	CheckPostgres   = "postgres"
+	CheckMigrations = "migrations"
+)
+
+// Postgres checks that a connection of the pool can reach the database
+func Postgres(pool *pgxpool.Pool) Check {
+	return Check{
+		Name: CheckPostgres,
+		Run:  pool.Ping,
+	}
+}
+
+// Migrations checks that the database is at least at the version of the last migration,
+// and that no migration failed halfway. A newer version is healthy: during a rolling update,
+// the instances of the new release migrate the database while the old ones still serve requests
+func Migrations(pool *pgxpool.Pool, version uint) Check {
+	return Check{
+		Name: CheckMigrations,
+		Run: func(ctx context.Context) error {
+			var current int64
+			var dirty bool
+			err := pool.QueryRow(ctx, "SELECT version, dirty FROM schema_migrations").Scan(&current, &dirty)
+			if err != nil {
+				if errors.Is(err, pgx.ErrNoRows) {
+					return errors.New("no migration is applied")
+				}
+				return fmt.Errorf("failed to get migration version: %w", err)
+			}
+
+			if dirty {
+				return fmt.Errorf("migration %d is dirty", current)
+			}
+			if current < int64(version) {
+				return fmt.Errorf("database is at migration %d, expected at least %d", current, version)
+			}
+			return nil
+		},
+	}
+}
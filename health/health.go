@@ -0,0 +1,210 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// Statuses of the server and of its dependencies
+const (
+	StatusUp       = "up"
+	StatusDown     = "down"
+	StatusDraining = "draining"
+)
+
+// Check tests that a dependency of the server can be used
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// CheckResult is the status of a dependency. The error is only logged,
+// it is never sent to the probes as it may describe the internals of the server
+type CheckResult struct {
+	Status string `json:"status"`
+	Error  string `json:"-"`
+}
+
+// Report is the status of the server, it is up only if all its dependencies are up
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+// Checker runs the checks of the dependencies in the background, and publishes their last results
+// to the readiness probes and the grpc.health.v1 service. The probes never run the checks themselves,
+// so they can't be used to load the dependencies
+type Checker struct {
+	checks     []Check
+	timeout    time.Duration
+	services   []string
+	draining   atomic.Bool
+	report     atomic.Pointer[Report]
+	grpcHealth *health.Server
+}
+
+// NewChecker creates a checker of the dependencies, each check has to finish before the timeout.
+// The services are reported serving over gRPC when all the checks pass
+func NewChecker(timeout time.Duration, services []string, checks ...Check) *Checker {
+	checker := &Checker{
+		checks:     checks,
+		timeout:    timeout,
+		services:   append([]string{""}, services...),
+		grpcHealth: health.NewServer(),
+	}
+
+	// nothing is serving until the dependencies are checked
+	checker.report.Store(&Report{Status: StatusDown})
+	checker.setServingStatus(Report{Status: StatusDown})
+	return checker
+}
+
+// GrpcServer returns the grpc.health.v1 service, the status of each dependency
+// can also be queried with its check name as the service
+func (checker *Checker) GrpcServer() healthpb.HealthServer {
+	return checker.grpcHealth
+}
+
+// Check runs all the checks concurrently
+func (checker *Checker) Check(ctx context.Context) Report {
+	if checker.draining.Load() {
+		return Report{Status: StatusDraining}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, checker.timeout)
+	defer cancel()
+
+	report := Report{
+		Status: StatusUp,
+		Checks: make(map[string]CheckResult, len(checker.checks)),
+	}
+
+	var mutex sync.Mutex
+	var waitGroup sync.WaitGroup
+	for _, check := range checker.checks {
+		waitGroup.Add(1)
+		go func(check Check) {
+			defer waitGroup.Done()
+
+			result := CheckResult{Status: StatusUp}
+			if err := check.Run(ctx); err != nil {
+				result = CheckResult{Status: StatusDown, Error: err.Error()}
+			}
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			report.Checks[check.Name] = result
+			if result.Status != StatusUp {
+				report.Status = StatusDown
+			}
+		}(check)
+	}
+	waitGroup.Wait()
+
+	return report
+}
+
+// Run checks the dependencies at every interval to update the statuses of the gRPC health service,
+// until the context is canceled
+func (checker *Checker) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		checker.update(checker.Check(ctx))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// update publishes the report, and logs the dependencies whose status changed
+func (checker *Checker) update(report Report) {
+	previous := checker.report.Swap(&report)
+
+	for name, result := range report.Checks {
+		if previous.Checks[name].Status == result.Status {
+			continue
+		}
+
+		if result.Status == StatusUp {
+			log.Info().Str("check", name).Msg("dependency is up")
+		} else {
+			log.Error().Str("check", name).Str("error", result.Error).Msg("dependency is down")
+		}
+	}
+
+	checker.setServingStatus(report)
+}
+
+// Report returns the result of the last checks
+func (checker *Checker) Report() Report {
+	if checker.draining.Load() {
+		return Report{Status: StatusDraining}
+	}
+	return *checker.report.Load()
+}
+
+func (checker *Checker) setServingStatus(report Report) {
+	status := healthpb.HealthCheckResponse_NOT_SERVING
+	if report.Status == StatusUp {
+		status = healthpb.HealthCheckResponse_SERVING
+	}
+	for _, service := range checker.services {
+		checker.grpcHealth.SetServingStatus(service, status)
+	}
+
+	for _, check := range checker.checks {
+		status := healthpb.HealthCheckResponse_NOT_SERVING
+		if result, ok := report.Checks[check.Name]; ok && result.Status == StatusUp {
+			status = healthpb.HealthCheckResponse_SERVING
+		}
+		checker.grpcHealth.SetServingStatus(check.Name, status)
+	}
+}
+
+// Drain reports the server as not ready from now on, so that the load balancers
+// stop sending it requests before it shuts down
+func (checker *Checker) Drain() {
+	checker.draining.Store(true)
+	checker.grpcHealth.Shutdown()
+}
+
+// LivenessHandler reports that the server is running, whatever the status of its dependencies
+func (checker *Checker) LivenessHandler() http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		writeReport(res, http.StatusOK, Report{Status: StatusUp})
+	})
+}
+
+// ReadinessHandler reports the status of the last checks, the status of each dependency
+// is only published by the gRPC health service. It responds 503 if one of them is down or if the server is draining
+func (checker *Checker) ReadinessHandler() http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		report := checker.Report()
+
+		statusCode := http.StatusOK
+		if report.Status != StatusUp {
+			statusCode = http.StatusServiceUnavailable
+		}
+		writeReport(res, statusCode, Report{Status: report.Status})
+	})
+}
+
+func writeReport(res http.ResponseWriter, statusCode int, report Report) {
+	res.Header().Set("Content-Type", "application/json")
+	res.Header().Set("Cache-Control", "no-store")
+	res.WriteHeader(statusCode)
+	json.NewEncoder(res).Encode(report)
+}
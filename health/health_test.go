@@ -0,0 +1,150 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+const testService = "simplebank.SimpleBank"
+
+func newTestChecker(redisErr error) *Checker {
+	return NewChecker(
+		time.Second,
+		[]string{testService},
+		Check{Name: CheckPostgres, Run: func(ctx context.Context) error { return nil }},
+		Check{Name: "redis", Run: func(ctx context.Context) error { return redisErr }},
+	)
+}
+
+func grpcStatus(t *testing.T, checker *Checker, service string) healthpb.HealthCheckResponse_ServingStatus {
+	res, err := checker.GrpcServer().Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+	require.NoError(t, err)
+	return res.Status
+}
+
+func readiness(t *testing.T, checker *Checker) (int, Report) {
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+	checker.ReadinessHandler().ServeHTTP(recorder, request)
+
+	var report Report
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &report))
+	return recorder.Code, report
+}
+
+// runOnce runs the checks a single time, like the first iteration of Run
+func runOnce(checker *Checker) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	checker.Run(ctx, time.Minute)
+}
+
+func TestReadiness(t *testing.T) {
+	checker := newTestChecker(nil)
+
+	// the server isn't ready until the dependencies are checked
+	statusCode, report := readiness(t, checker)
+	require.Equal(t, http.StatusServiceUnavailable, statusCode)
+	require.Equal(t, StatusDown, report.Status)
+
+	runOnce(checker)
+	statusCode, report = readiness(t, checker)
+	require.Equal(t, http.StatusOK, statusCode)
+	require.Equal(t, Report{Status: StatusUp}, report)
+	require.Equal(t, map[string]CheckResult{
+		CheckPostgres: {Status: StatusUp},
+		"redis":       {Status: StatusUp},
+	}, checker.Report().Checks)
+}
+
+func TestReadinessDependencyDown(t *testing.T) {
+	checker := newTestChecker(errors.New("connection refused"))
+	runOnce(checker)
+
+	recorder := httptest.NewRecorder()
+	checker.ReadinessHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	require.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+	require.JSONEq(t, `{"status":"down"}`, recorder.Body.String())
+
+	// the error is kept for the logs only
+	require.Equal(t, CheckResult{Status: StatusDown, Error: "connection refused"}, checker.Report().Checks["redis"])
+}
+
+func TestReadinessCached(t *testing.T) {
+	runs := 0
+	checker := NewChecker(
+		time.Second,
+		nil,
+		Check{Name: CheckPostgres, Run: func(ctx context.Context) error {
+			runs++
+			return nil
+		}},
+	)
+	runOnce(checker)
+
+	// the probes are served from the last checks, they don't reach the dependencies
+	for i := 0; i < 3; i++ {
+		statusCode, _ := readiness(t, checker)
+		require.Equal(t, http.StatusOK, statusCode)
+	}
+	require.Equal(t, 1, runs)
+}
+
+func TestCheckTimeout(t *testing.T) {
+	checker := NewChecker(
+		10*time.Millisecond,
+		nil,
+		Check{Name: CheckPostgres, Run: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}},
+	)
+
+	report := checker.Check(context.Background())
+	require.Equal(t, StatusDown, report.Status)
+	require.Equal(t, context.DeadlineExceeded.Error(), report.Checks[CheckPostgres].Error)
+}
+
+func TestGrpcServingStatus(t *testing.T) {
+	checker := newTestChecker(errors.New("connection refused"))
+	require.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, grpcStatus(t, checker, ""))
+
+	runOnce(checker)
+
+	require.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, grpcStatus(t, checker, ""))
+	require.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, grpcStatus(t, checker, testService))
+	require.Equal(t, healthpb.HealthCheckResponse_SERVING, grpcStatus(t, checker, CheckPostgres))
+	require.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, grpcStatus(t, checker, "redis"))
+
+	checker = newTestChecker(nil)
+	runOnce(checker)
+	require.Equal(t, healthpb.HealthCheckResponse_SERVING, grpcStatus(t, checker, ""))
+	require.Equal(t, healthpb.HealthCheckResponse_SERVING, grpcStatus(t, checker, testService))
+}
+
+func TestDrain(t *testing.T) {
+	checker := newTestChecker(nil)
+	runOnce(checker)
+	checker.Drain()
+
+	statusCode, report := readiness(t, checker)
+	require.Equal(t, http.StatusServiceUnavailable, statusCode)
+	require.Equal(t, StatusDraining, report.Status)
+	require.Empty(t, report.Checks)
+
+	require.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, grpcStatus(t, checker, ""))
+	require.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, grpcStatus(t, checker, testService))
+
+	// the liveness probe still passes, the server is only stopping
+	recorder := httptest.NewRecorder()
+	checker.LivenessHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	require.Equal(t, http.StatusOK, recorder.Code)
+}
@@ -0,0 +1,73 @@
+package mail
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/juker1141/simplebank/util"
+)
+
+// FileSender writes emails as .eml files into a directory instead of sending them.
+// It is meant for development and tests, where there is no SMTP server
+type FileSender struct {
+	name             string
+	fromEmailAddress string
+	dir              string
+}
+
+func NewFileSender(name string, fromEmailAddress string, dir string) (EmailSender, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("missing email file directory")
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create email file directory: %w", err)
+	}
+
+	return &FileSender{
+		name: name,
+		fromEmailAddress: fromEmailAddress,
+		dir: dir,
+	}, nil
+}
+
+func (sender *FileSender) SendEmail(
+	subject string,
+	content string,
+	to []string,
+	cc []string,
+	bcc []string,
+	attchFiles []string,
+) error {
+	return sender.SendMessage(Message{
+		Subject: subject,
+		HTML: content,
+		To: to,
+		Cc: cc,
+		Bcc: bcc,
+		AttachFiles: attchFiles,
+	})
+}
+
+func (sender *FileSender) SendMessage(message Message) error {
+	e, err := newEmail(sender.name, sender.fromEmailAddress, message)
+	if err != nil {
+		return err
+	}
+
+	msg, err := e.Bytes()
+	if err != nil {
+		return fmt.Errorf("failed to build email: %w", err)
+	}
+
+	filename := fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405.000000000"), util.RandomString(6))
+	path := filepath.Join(sender.dir, filename)
+
+	if err = os.WriteFile(path, msg, 0o644); err != nil {
+		return fmt.Errorf("failed to write email file %s: %w", path, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,40 @@
+package mail
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/juker1141/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileSender(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mail")
+
+	sender, err := NewEmailSender(util.Config{
+		EmailBackend: BackendFile,
+		EmailFileDir: dir,
+		EmailSenderName: "Simple Bank",
+		EmailSenderAddress: "bank@example.com",
+	})
+	require.NoError(t, err)
+
+	err = sender.SendEmail("A test email", "<h1>Hello World</h1>", []string{"to@example.com"}, nil, nil, []string{"../README.md"})
+	require.NoError(t, err)
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	data, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	require.Contains(t, string(data), "Subject: A test email")
+	require.Contains(t, string(data), "to@example.com")
+	require.Contains(t, string(data), "README.md")
+}
+
+func TestNewEmailSenderUnsupportedBackend(t *testing.T) {
+	_, err := NewEmailSender(util.Config{EmailBackend: "pigeon"})
+	require.Error(t, err)
+}
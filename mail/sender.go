@@ -2,16 +2,34 @@ package mail
 
 import (
 	"fmt"
-	"net/smtp"
+	"time"
 
 	"github.com/jordan-wright/email"
+	"github.com/juker1141/simplebank/util"
 )
 
 const (
 	smtpAuthAddress = "smtp.gmail.com"
-	smtpServerAddress = "smtp.gmail.com:587"
+	smtpServerPort = 587
 )
 
+// Email backends that can be selected with EMAIL_BACKEND
+const (
+	BackendSMTP = "smtp"
+	BackendFile = "file"
+)
+
+// Message is an email with an HTML body and an optional plain-text alternative
+type Message struct {
+	Subject     string
+	HTML        string
+	Text        string
+	To          []string
+	Cc          []string
+	Bcc         []string
+	AttachFiles []string
+}
+
 type EmailSender interface {
 	SendEmail(
 		subject string,
@@ -21,45 +39,66 @@ type EmailSender interface {
 		bcc []string,
 		attchFiles []string,
 	) error
+	SendMessage(message Message) error
 }
 
-type GamilSender struct {
-	name string
-	fromEmailAddress string
-	fromEmailPassword string
+// NewEmailSender creates the email sender of the backend selected in the config
+func NewEmailSender(config util.Config) (EmailSender, error) {
+	switch config.EmailBackend {
+	case BackendSMTP, "":
+		username := config.SMTPUsername
+		if username == "" {
+			username = config.EmailSenderAddress
+		}
+
+		return NewSMTPSender(config.EmailSenderName, config.EmailSenderAddress, SMTPConfig{
+			Host: config.SMTPHost,
+			Port: config.SMTPPort,
+			Username: username,
+			Password: config.EmailSenderPassword,
+			Auth: config.SMTPAuth,
+			TLS: config.SMTPTLS,
+			Timeout: config.SMTPTimeout,
+		})
+	case BackendFile:
+		return NewFileSender(config.EmailSenderName, config.EmailSenderAddress, config.EmailFileDir)
+	default:
+		return nil, fmt.Errorf("unsupported email backend: %s", config.EmailBackend)
+	}
 }
 
+// NewGmailSender creates an SMTP sender for a Gmail account
 func NewGmailSender(name string, fromEmailAddress string, fromEmailPassword string) EmailSender {
-	return &GamilSender{
-		name: name,
-		fromEmailAddress: fromEmailAddress,
-		fromEmailPassword: fromEmailPassword,
-	}
+	sender, _ := NewSMTPSender(name, fromEmailAddress, SMTPConfig{
+		Host: smtpAuthAddress,
+		Port: smtpServerPort,
+		Username: fromEmailAddress,
+		Password: fromEmailPassword,
+		Auth: SMTPAuthPlain,
+		TLS: SMTPTLSStartTLS,
+		Timeout: 30 * time.Second,
+	})
+	return sender
 }
 
-func (sender *GamilSender) SendEmail(
-	subject string,
-	content string,
-	to []string,
-	cc []string,
-	bcc []string,
-	attchFiles []string,
-) error {
+func newEmail(name string, fromEmailAddress string, message Message) (*email.Email, error) {
 	e := email.NewEmail()
-	e.From = fmt.Sprintf("%s <%s>", sender.name, sender.fromEmailAddress)
-	e.Subject = subject
-	e.HTML = []byte(content)
-	e.To = to
-	e.Cc = cc
-	e.Bcc = bcc
+	e.From = fmt.Sprintf("%s <%s>", name, fromEmailAddress)
+	e.Subject = message.Subject
+	e.HTML = []byte(message.HTML)
+	if message.Text != "" {
+		e.Text = []byte(message.Text)
+	}
+	e.To = message.To
+	e.Cc = message.Cc
+	e.Bcc = message.Bcc
 
-	for _, f := range attchFiles {
+	for _, f := range message.AttachFiles {
 		_, err := e.AttachFile(f)
 		if err != nil {
-			return fmt.Errorf("failed to attach file %s: %w", f, err)
+			return nil, fmt.Errorf("failed to attach file %s: %w", f, err)
 		}
 	}
 
-	smtpAuth := smtp.PlainAuth("", sender.fromEmailAddress, sender.fromEmailPassword, smtpAuthAddress)
-	return e.Send(smtpServerAddress, smtpAuth)
-}
\ No newline at end of file
+	return e, nil
+}
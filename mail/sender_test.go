@@ -2,21 +2,29 @@ package mail
 
 import (
 	"testing"
+	"time"
 
 	"github.com/juker1141/simplebank/util"
 	"github.com/stretchr/testify/require"
 )
 
-func TestSendEmailWithGmail(t *testing.T) {
-	if testing.Short() {
-		t.Skip()
-	}
-
-	config, err := util.LoadConfig("..")
+func TestSendEmailWithSMTPBackend(t *testing.T) {
+	server := newFakeSMTPServer(t)
+
+	// without an SMTP username, the sender logs in with its own address like with Gmail
+	sender, err := NewEmailSender(util.Config{
+		EmailBackend: BackendSMTP,
+		EmailSenderName: "Simple Bank",
+		EmailSenderAddress: "bank@example.com",
+		EmailSenderPassword: "secret",
+		SMTPHost: "127.0.0.1",
+		SMTPPort: server.port(),
+		SMTPAuth: SMTPAuthPlain,
+		SMTPTLS: SMTPTLSNone,
+		SMTPTimeout: 5 * time.Second,
+	})
 	require.NoError(t, err)
 
-	sender := NewGmailSender(config.EmailSenderName, config.EmailSenderAddress, config.EmailSenderPassword)
-
 	subject := "A test email"
 	content := `
 	<h1>Hello World</h1>
@@ -28,4 +36,16 @@ func TestSendEmailWithGmail(t *testing.T) {
 
 	err = sender.SendEmail(subject, content, to, nil, nil, attachFiles)
 	require.NoError(t, err)
+
+	select {
+	case <-server.done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("smtp session didn't finish")
+	}
+
+	require.Equal(t, "bank@example.com", server.username)
+	require.Equal(t, "secret", server.password)
+	require.Equal(t, to, server.recipients)
+	require.Contains(t, server.data, "Subject: A test email")
+	require.Contains(t, server.data, "README.md")
 }
\ No newline at end of file
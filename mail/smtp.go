@@ -0,0 +1,250 @@
+package mail
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+// SMTP authentication mechanisms
+const (
+	SMTPAuthPlain   = "plain"
+	SMTPAuthLogin   = "login"
+	SMTPAuthCRAMMD5 = "cram-md5"
+	SMTPAuthNone    = "none"
+)
+
+// SMTP connection security
+const (
+	// SMTPTLSStartTLS upgrades a plain connection with the STARTTLS command, usually on port 587
+	SMTPTLSStartTLS = "starttls"
+	// SMTPTLSImplicit connects with TLS from the start, usually on port 465
+	SMTPTLSImplicit = "tls"
+	SMTPTLSNone     = "none"
+)
+
+const defaultSMTPTimeout = 30 * time.Second
+
+// SMTPConfig contains the settings to connect to an SMTP server
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	Auth     string
+	TLS      string
+	// Timeout bounds connecting to the server and sending a whole email
+	Timeout time.Duration
+}
+
+// SMTPSender sends emails through any SMTP server
+type SMTPSender struct {
+	name             string
+	fromEmailAddress string
+	config           SMTPConfig
+}
+
+func NewSMTPSender(name string, fromEmailAddress string, config SMTPConfig) (EmailSender, error) {
+	if config.Host == "" {
+		return nil, fmt.Errorf("missing smtp host")
+	}
+
+	if config.Port <= 0 {
+		return nil, fmt.Errorf("invalid smtp port: %d", config.Port)
+	}
+
+	switch config.Auth {
+	case "":
+		config.Auth = SMTPAuthPlain
+	case SMTPAuthPlain, SMTPAuthLogin, SMTPAuthCRAMMD5, SMTPAuthNone:
+	default:
+		return nil, fmt.Errorf("unsupported smtp auth mechanism: %s", config.Auth)
+	}
+
+	switch config.TLS {
+	case "":
+		config.TLS = SMTPTLSStartTLS
+	case SMTPTLSStartTLS, SMTPTLSImplicit, SMTPTLSNone:
+	default:
+		return nil, fmt.Errorf("unsupported smtp tls mode: %s", config.TLS)
+	}
+
+	if config.Timeout <= 0 {
+		config.Timeout = defaultSMTPTimeout
+	}
+
+	return &SMTPSender{
+		name: name,
+		fromEmailAddress: fromEmailAddress,
+		config: config,
+	}, nil
+}
+
+func (sender *SMTPSender) SendEmail(
+	subject string,
+	content string,
+	to []string,
+	cc []string,
+	bcc []string,
+	attchFiles []string,
+) error {
+	return sender.SendMessage(Message{
+		Subject: subject,
+		HTML: content,
+		To: to,
+		Cc: cc,
+		Bcc: bcc,
+		AttachFiles: attchFiles,
+	})
+}
+
+func (sender *SMTPSender) SendMessage(message Message) error {
+	e, err := newEmail(sender.name, sender.fromEmailAddress, message)
+	if err != nil {
+		return err
+	}
+
+	msg, err := e.Bytes()
+	if err != nil {
+		return fmt.Errorf("failed to build email: %w", err)
+	}
+
+	recipients := make([]string, 0, len(message.To)+len(message.Cc)+len(message.Bcc))
+	recipients = append(recipients, message.To...)
+	recipients = append(recipients, message.Cc...)
+	recipients = append(recipients, message.Bcc...)
+	if len(recipients) == 0 {
+		return errors.New("missing email recipients")
+	}
+
+	client, err := sender.dial()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if err = client.Mail(sender.fromEmailAddress); err != nil {
+		return fmt.Errorf("smtp MAIL command failed: %w", err)
+	}
+
+	for _, recipient := range recipients {
+		if err = client.Rcpt(recipient); err != nil {
+			return fmt.Errorf("smtp RCPT command failed for %s: %w", recipient, err)
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("smtp DATA command failed: %w", err)
+	}
+
+	if _, err = w.Write(msg); err != nil {
+		return fmt.Errorf("failed to write email: %w", err)
+	}
+
+	if err = w.Close(); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	return client.Quit()
+}
+
+// dial connects and authenticates to the SMTP server.
+// The deadline of the connection covers the whole conversation with the server
+func (sender *SMTPSender) dial() (*smtp.Client, error) {
+	config := sender.config
+	address := net.JoinHostPort(config.Host, strconv.Itoa(config.Port))
+	tlsConfig := &tls.Config{ServerName: config.Host}
+	dialer := &net.Dialer{Timeout: config.Timeout}
+
+	var conn net.Conn
+	var err error
+	if config.TLS == SMTPTLSImplicit {
+		conn, err = tls.DialWithDialer(dialer, "tcp", address, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", address)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to smtp server %s: %w", address, err)
+	}
+
+	if err = conn.SetDeadline(time.Now().Add(config.Timeout)); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	client, err := smtp.NewClient(conn, config.Host)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to create smtp client: %w", err)
+	}
+
+	if config.TLS == SMTPTLSStartTLS {
+		if err = client.StartTLS(tlsConfig); err != nil {
+			client.Close()
+			return nil, fmt.Errorf("smtp STARTTLS failed: %w", err)
+		}
+	}
+
+	if auth := sender.auth(); auth != nil {
+		if err = client.Auth(auth); err != nil {
+			client.Close()
+			return nil, fmt.Errorf("smtp authentication failed: %w", err)
+		}
+	}
+
+	return client, nil
+}
+
+func (sender *SMTPSender) auth() smtp.Auth {
+	config := sender.config
+	switch config.Auth {
+	case SMTPAuthPlain:
+		return smtp.PlainAuth("", config.Username, config.Password, config.Host)
+	case SMTPAuthLogin:
+		return &loginAuth{username: config.Username, password: config.Password, host: config.Host}
+	case SMTPAuthCRAMMD5:
+		return smtp.CRAMMD5Auth(config.Username, config.Password)
+	}
+	return nil
+}
+
+// loginAuth implements the LOGIN mechanism, which net/smtp doesn't provide
+// but many corporate relays still require
+type loginAuth struct {
+	username string
+	password string
+	host     string
+}
+
+func (a *loginAuth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	if !server.TLS && !isLocalhost(server.Name) {
+		return "", nil, errors.New("unencrypted connection")
+	}
+	if server.Name != a.host {
+		return "", nil, errors.New("wrong host name")
+	}
+	return "LOGIN", nil, nil
+}
+
+func (a *loginAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if !more {
+		return nil, nil
+	}
+
+	switch string(fromServer) {
+	case "Username:":
+		return []byte(a.username), nil
+	case "Password:":
+		return []byte(a.password), nil
+	}
+	return nil, fmt.Errorf("unexpected server challenge: %s", fromServer)
+}
+
+func isLocalhost(name string) bool {
+	return name == "localhost" || name == "127.0.0.1" || name == "::1"
+}
@@ -0,0 +1,178 @@
+package mail
+
+import (
+	"encoding/base64"
+	"net"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSMTPServer accepts a single SMTP session and records what the client sent
+type fakeSMTPServer struct {
+	listener   net.Listener
+	username   string
+	password   string
+	recipients []string
+	data       string
+	done       chan struct{}
+}
+
+func newFakeSMTPServer(t *testing.T) *fakeSMTPServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	server := &fakeSMTPServer{
+		listener: listener,
+		done: make(chan struct{}),
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go server.serve()
+	return server
+}
+
+func (server *fakeSMTPServer) port() int {
+	return server.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (server *fakeSMTPServer) serve() {
+	defer close(server.done)
+
+	conn, err := server.listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	tp := textproto.NewConn(conn)
+	tp.PrintfLine("220 localhost ESMTP")
+
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+
+		cmd := strings.ToUpper(strings.Fields(line)[0])
+		switch {
+		case cmd == "EHLO" || cmd == "HELO":
+			tp.PrintfLine("250-localhost")
+			tp.PrintfLine("250 AUTH PLAIN LOGIN")
+		case strings.HasPrefix(strings.ToUpper(line), "AUTH PLAIN "):
+			decoded, _ := base64.StdEncoding.DecodeString(strings.Fields(line)[2])
+			parts := strings.Split(string(decoded), "\x00")
+			server.username, server.password = parts[1], parts[2]
+			tp.PrintfLine("235 authenticated")
+		case strings.HasPrefix(strings.ToUpper(line), "AUTH LOGIN"):
+			tp.PrintfLine("334 %s", base64.StdEncoding.EncodeToString([]byte("Username:")))
+			username, _ := tp.ReadLine()
+			tp.PrintfLine("334 %s", base64.StdEncoding.EncodeToString([]byte("Password:")))
+			password, _ := tp.ReadLine()
+			decodedUsername, _ := base64.StdEncoding.DecodeString(username)
+			decodedPassword, _ := base64.StdEncoding.DecodeString(password)
+			server.username, server.password = string(decodedUsername), string(decodedPassword)
+			tp.PrintfLine("235 authenticated")
+		case cmd == "MAIL":
+			tp.PrintfLine("250 ok")
+		case cmd == "RCPT":
+			recipient := strings.TrimSuffix(strings.TrimPrefix(line[len("RCPT TO:"):], "<"), ">")
+			server.recipients = append(server.recipients, recipient)
+			tp.PrintfLine("250 ok")
+		case cmd == "DATA":
+			tp.PrintfLine("354 go ahead")
+			data, _ := tp.ReadDotBytes()
+			server.data = string(data)
+			tp.PrintfLine("250 queued")
+		case cmd == "QUIT":
+			tp.PrintfLine("221 bye")
+			return
+		default:
+			tp.PrintfLine("502 unknown command")
+		}
+	}
+}
+
+func TestSMTPSender(t *testing.T) {
+	for _, auth := range []string{SMTPAuthPlain, SMTPAuthLogin} {
+		t.Run(auth, func(t *testing.T) {
+			server := newFakeSMTPServer(t)
+
+			sender, err := NewSMTPSender("Simple Bank", "bank@example.com", SMTPConfig{
+				Host: "127.0.0.1",
+				Port: server.port(),
+				Username: "relay-user",
+				Password: "relay-secret",
+				Auth: auth,
+				TLS: SMTPTLSNone,
+				Timeout: 5 * time.Second,
+			})
+			require.NoError(t, err)
+
+			err = sender.SendEmail("A test email", "<h1>Hello World</h1>", []string{"to@example.com"}, []string{"cc@example.com"}, []string{"bcc@example.com"}, nil)
+			require.NoError(t, err)
+
+			select {
+			case <-server.done:
+			case <-time.After(5 * time.Second):
+				t.Fatal("smtp session didn't finish")
+			}
+
+			require.Equal(t, "relay-user", server.username)
+			require.Equal(t, "relay-secret", server.password)
+			require.Equal(t, []string{"to@example.com", "cc@example.com", "bcc@example.com"}, server.recipients)
+			require.Contains(t, server.data, "Subject: A test email")
+			require.Contains(t, server.data, "<h1>Hello World</h1>")
+			require.NotContains(t, server.data, "bcc@example.com")
+		})
+	}
+}
+
+func TestSMTPSenderTimeout(t *testing.T) {
+	// a server that accepts the connection but never greets the client
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	go func() {
+		conn, err := listener.Accept()
+		if err == nil {
+			defer conn.Close()
+			time.Sleep(2 * time.Second)
+		}
+	}()
+
+	sender, err := NewSMTPSender("Simple Bank", "bank@example.com", SMTPConfig{
+		Host: "127.0.0.1",
+		Port: listener.Addr().(*net.TCPAddr).Port,
+		Auth: SMTPAuthNone,
+		TLS: SMTPTLSNone,
+		Timeout: 200 * time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	start := time.Now()
+	err = sender.SendEmail("A test email", "content", []string{"to@example.com"}, nil, nil, nil)
+	require.Error(t, err)
+	require.Less(t, time.Since(start), time.Second)
+}
+
+func TestNewSMTPSenderInvalidConfig(t *testing.T) {
+	testCases := []SMTPConfig{
+		{Port: 587},
+		{Host: "smtp.example.com"},
+		{Host: "smtp.example.com", Port: 587, Auth: "kerberos"},
+		{Host: "smtp.example.com", Port: 587, TLS: "ssl3"},
+	}
+
+	for i, config := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			_, err := NewSMTPSender("Simple Bank", "bank@example.com", config)
+			require.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,188 @@
+package mail
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/juker1141/simplebank/util"
+)
+
+// Names of all email templates
+const (
+	TemplateVerifyEmail            = "verify_email"
+	TemplateBeneficiaryAdded       = "beneficiary_added"
+	TemplatePaymentRequestReceived = "payment_request_received"
+	TemplatePaymentRequestAccepted = "payment_request_accepted"
+	TemplatePaymentRequestDeclined = "payment_request_declined"
+	TemplateEmailChangeRequested   = "email_change_requested"
+	TemplateTransferDebit          = "transfer_debit"
+	TemplateTransferCredit         = "transfer_credit"
+)
+
+// DefaultLanguage is used when a template has no variant in the user's language
+const DefaultLanguage = util.English
+
+// Each language has its own directory with a <name>.html and a <name>.txt file per template.
+// The text file also defines the "subject" of the email.
+// Files starting with an underscore are partials shared by all templates of the directory
+//
+//go:embed templates/_layout.html templates/*/*
+var templateFS embed.FS
+
+const (
+	templateDir    = "templates"
+	layoutTemplate = "_layout.html"
+)
+
+type localizedTemplate struct {
+	html *htmltemplate.Template
+	text *texttemplate.Template
+}
+
+// Renderer renders emails from the embedded templates
+type Renderer struct {
+	baseURL   string
+	templates map[string]map[string]localizedTemplate
+}
+
+// NewRenderer parses all embedded templates.
+// baseURL is the public address of the server that links in the emails point to
+func NewRenderer(baseURL string) (*Renderer, error) {
+	renderer := &Renderer{
+		baseURL:   strings.TrimSuffix(baseURL, "/"),
+		templates: make(map[string]map[string]localizedTemplate),
+	}
+
+	funcs := map[string]any{
+		"baseURL":    func() string { return renderer.baseURL },
+		"formatTime": formatTime,
+	}
+
+	entries, err := fs.ReadDir(templateFS, templateDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read email templates: %w", err)
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		language := entry.Name()
+		dir := path.Join(templateDir, language)
+
+		files, err := fs.Glob(templateFS, path.Join(dir, "*.html"))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, file := range files {
+			name := strings.TrimSuffix(path.Base(file), ".html")
+			if strings.HasPrefix(name, "_") {
+				continue
+			}
+
+			html, err := htmltemplate.New(layoutTemplate).Funcs(funcs).ParseFS(
+				templateFS,
+				path.Join(templateDir, layoutTemplate),
+				path.Join(dir, "_*.html"),
+				file,
+			)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse email template %s: %w", file, err)
+			}
+
+			textFile := path.Join(dir, name+".txt")
+			text, err := texttemplate.New(path.Base(textFile)).Funcs(funcs).ParseFS(templateFS, textFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse email template %s: %w", textFile, err)
+			}
+
+			if text.Lookup("subject") == nil {
+				return nil, fmt.Errorf("email template %s doesn't define a subject", textFile)
+			}
+
+			if renderer.templates[name] == nil {
+				renderer.templates[name] = make(map[string]localizedTemplate)
+			}
+			renderer.templates[name][language] = localizedTemplate{
+				html: html,
+				text: text,
+			}
+		}
+	}
+
+	for name, variants := range renderer.templates {
+		if _, ok := variants[DefaultLanguage]; !ok {
+			return nil, fmt.Errorf("email template %s has no %s variant", name, DefaultLanguage)
+		}
+	}
+
+	return renderer, nil
+}
+
+// Render renders the template in the given language into a message without recipients.
+// It falls back to the default language if the template isn't translated
+func (renderer *Renderer) Render(name string, language string, data any) (Message, error) {
+	variants, ok := renderer.templates[name]
+	if !ok {
+		return Message{}, fmt.Errorf("unknown email template: %s", name)
+	}
+
+	tmpl, ok := variants[language]
+	if !ok {
+		tmpl = variants[DefaultLanguage]
+	}
+
+	var subject, text, html bytes.Buffer
+
+	if err := tmpl.text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return Message{}, fmt.Errorf("failed to render subject of %s: %w", name, err)
+	}
+
+	if err := tmpl.text.Execute(&text, data); err != nil {
+		return Message{}, fmt.Errorf("failed to render text of %s: %w", name, err)
+	}
+
+	if err := tmpl.html.Execute(&html, data); err != nil {
+		return Message{}, fmt.Errorf("failed to render html of %s: %w", name, err)
+	}
+
+	return Message{
+		Subject: strings.TrimSpace(subject.String()),
+		HTML:    html.String(),
+		Text:    strings.TrimSpace(text.String()) + "\n",
+	}, nil
+}
+
+// Templates returns the names of all templates
+func (renderer *Renderer) Templates() []string {
+	names := make([]string, 0, len(renderer.templates))
+	for name := range renderer.templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Languages returns the languages the template is translated into
+func (renderer *Renderer) Languages(name string) []string {
+	languages := make([]string, 0, len(renderer.templates[name]))
+	for language := range renderer.templates[name] {
+		languages = append(languages, language)
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02 15:04 MST")
+}
@@ -0,0 +1,87 @@
+package mail
+
+import "time"
+
+// VerifyEmailData is the data of the verify_email template
+type VerifyEmailData struct {
+	FullName   string
+	EmailID    int64
+	SecretCode string
+}
+
+// EmailChangeRequestedData is the data of the email_change_requested template
+type EmailChangeRequestedData struct {
+	FullName string
+	NewEmail string
+}
+
+// BeneficiaryAddedData is the data of the beneficiary_added template
+type BeneficiaryAddedData struct {
+	FullName string
+	Nickname string
+	Currency string
+}
+
+// PaymentRequestData is the data of the payment_request_* templates.
+// Counterparty is the payee for a received request, and the payer otherwise
+type PaymentRequestData struct {
+	FullName     string
+	Counterparty string
+	Amount       int64
+	Currency     string
+	Memo         string
+	ExpiredAt    time.Time
+}
+
+// TransferNotificationData is the data of the transfer_debit and transfer_credit templates.
+// AccountID is the account of the notified user
+type TransferNotificationData struct {
+	FullName              string
+	AccountID             int64
+	CounterpartyAccountID int64
+	Amount                int64
+	Currency              string
+	CreatedAt             time.Time
+}
+
+// SampleData returns example data of the template, used to preview it
+func SampleData(name string) any {
+	switch name {
+	case TemplateVerifyEmail:
+		return VerifyEmailData{
+			FullName:   "Alice Chen",
+			EmailID:    1,
+			SecretCode: "0123456789abcdefghijklmnopqrstuv",
+		}
+	case TemplateEmailChangeRequested:
+		return EmailChangeRequestedData{
+			FullName: "Alice Chen",
+			NewEmail: "alice.chen@example.com",
+		}
+	case TemplateBeneficiaryAdded:
+		return BeneficiaryAddedData{
+			FullName: "Alice Chen",
+			Nickname: "Landlord",
+			Currency: "USD",
+		}
+	case TemplatePaymentRequestReceived, TemplatePaymentRequestAccepted, TemplatePaymentRequestDeclined:
+		return PaymentRequestData{
+			FullName:     "Alice Chen",
+			Counterparty: "bob",
+			Amount:       120,
+			Currency:     "USD",
+			Memo:         "Dinner on Friday",
+			ExpiredAt:    time.Date(2024, time.January, 8, 12, 0, 0, 0, time.UTC),
+		}
+	case TemplateTransferDebit, TemplateTransferCredit:
+		return TransferNotificationData{
+			FullName:              "Alice Chen",
+			AccountID:             1,
+			CounterpartyAccountID: 2,
+			Amount:                250,
+			Currency:              "USD",
+			CreatedAt:             time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC),
+		}
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package mail
+
+import (
+	"testing"
+
+	"github.com/juker1141/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRenderer(t *testing.T) *Renderer {
+	renderer, err := NewRenderer("https://bank.example.com/")
+	require.NoError(t, err)
+	return renderer
+}
+
+func TestRenderAllTemplates(t *testing.T) {
+	renderer := newTestRenderer(t)
+
+	names := renderer.Templates()
+	require.ElementsMatch(t, []string{
+		TemplateVerifyEmail,
+		TemplateBeneficiaryAdded,
+		TemplatePaymentRequestReceived,
+		TemplatePaymentRequestAccepted,
+		TemplatePaymentRequestDeclined,
+		TemplateEmailChangeRequested,
+		TemplateTransferDebit,
+		TemplateTransferCredit,
+	}, names)
+
+	for _, name := range names {
+		require.Equal(t, []string{util.English, util.TraditionalChinese}, renderer.Languages(name))
+
+		for _, language := range renderer.Languages(name) {
+			message, err := renderer.Render(name, language, SampleData(name))
+			require.NoError(t, err, "%s.%s", name, language)
+			require.NotEmpty(t, message.Subject)
+			require.NotContains(t, message.Subject, "\n")
+			require.Contains(t, message.HTML, "Alice Chen")
+			require.Contains(t, message.Text, "Alice Chen")
+			require.NotContains(t, message.HTML, "<no value>")
+			require.NotContains(t, message.Text, "<no value>")
+		}
+	}
+}
+
+func TestRenderVerifyEmail(t *testing.T) {
+	renderer := newTestRenderer(t)
+
+	data := VerifyEmailData{
+		FullName:   "Alice Chen",
+		EmailID:    42,
+		SecretCode: "secret",
+	}
+
+	message, err := renderer.Render(TemplateVerifyEmail, util.English, data)
+	require.NoError(t, err)
+	require.Equal(t, "Welcome to Simple Bank", message.Subject)
+	require.Contains(t, message.HTML, `href="https://bank.example.com/v1/verify_email?email_id=42&secret_code=secret"`)
+	require.Contains(t, message.Text, "https://bank.example.com/v1/verify_email?email_id=42&secret_code=secret")
+
+	message, err = renderer.Render(TemplateVerifyEmail, util.TraditionalChinese, data)
+	require.NoError(t, err)
+	require.Equal(t, "歡迎加入 Simple Bank", message.Subject)
+}
+
+func TestRenderFallbackLanguage(t *testing.T) {
+	renderer := newTestRenderer(t)
+
+	name := TemplateBeneficiaryAdded
+	expected, err := renderer.Render(name, DefaultLanguage, SampleData(name))
+	require.NoError(t, err)
+
+	for _, language := range []string{"fr", ""} {
+		message, err := renderer.Render(name, language, SampleData(name))
+		require.NoError(t, err)
+		require.Equal(t, expected, message)
+	}
+}
+
+func TestRenderEscapesHTML(t *testing.T) {
+	renderer := newTestRenderer(t)
+
+	data := BeneficiaryAddedData{
+		FullName: `<script>alert("hi")</script>`,
+		Nickname: "Tom & Jerry",
+		Currency: util.USD,
+	}
+
+	message, err := renderer.Render(TemplateBeneficiaryAdded, util.English, data)
+	require.NoError(t, err)
+	require.NotContains(t, message.HTML, "<script>")
+	require.Contains(t, message.HTML, "&lt;script&gt;")
+	require.Contains(t, message.HTML, "Tom &amp; Jerry")
+
+	// the plain-text alternative is not escaped
+	require.Contains(t, message.Text, `<script>alert("hi")</script>`)
+	require.Contains(t, message.Text, "Tom & Jerry")
+}
+
+func TestRenderUnknownTemplate(t *testing.T) {
+	renderer := newTestRenderer(t)
+
+	_, err := renderer.Render("unknown", util.English, nil)
+	require.Error(t, err)
+}
@@ -2,116 +2,442 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
+	"flag"
+	"fmt"
 	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
-	"github.com/hibiken/asynq"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 
-	"github.com/golang-migrate/migrate/v4"
-	_ "github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 	"github.com/juker1141/simplebank/api"
+	"github.com/juker1141/simplebank/certs"
 	_ "github.com/juker1141/simplebank/doc/statik"
 	"github.com/juker1141/simplebank/gapi"
+	"github.com/juker1141/simplebank/health"
 	"github.com/juker1141/simplebank/mail"
+	"github.com/juker1141/simplebank/metrics"
 	"github.com/juker1141/simplebank/pb"
+	"github.com/juker1141/simplebank/ratelimit"
+	"github.com/juker1141/simplebank/tracing"
 	"github.com/juker1141/simplebank/util"
+	"github.com/juker1141/simplebank/webhook"
 	"github.com/juker1141/simplebank/worker"
 	"github.com/rakyll/statik/fs"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"golang.org/x/sync/errgroup"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
 	"google.golang.org/protobuf/encoding/protojson"
 
+	"github.com/juker1141/simplebank/db/migration"
 	db "github.com/juker1141/simplebank/db/sqlc"
 )
 
+var interruptSignals = []os.Signal{
+	os.Interrupt,
+	syscall.SIGTERM,
+	syscall.SIGINT,
+}
+
+// main runs the servers, or the administrative command given in the arguments.
+// The commands share the config and the setup of the servers
 func main() {
-	config, err := util.LoadConfig(".")
+	loader := util.ConfigLoader{
+		Path: ".",
+		Overrides: make(map[string]string),
+	}
+	flag.StringVar(&loader.File, "config", "", "read the config from a .env or .yaml `file`, over app.env and app.yaml")
+	flag.Var(configOverrides(loader.Overrides), "set", "set a config value as `KEY=VALUE` over all the other sources, can be repeated")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), commandUsage)
+	}
+	flag.Parse()
+
+	config, err := loader.Load()
 	if err != nil {
-		log.Fatal().Err(err).Msg("cannot load config:")
+		exitCommand(fmt.Errorf("cannot load config: %w", err))
 	}
 
 	if config.Environment == "development" {
 		log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
 	}
 
-	connPool, err := pgxpool.New(context.Background(), config.DBSource)
+	ctx, stop := signal.NotifyContext(context.Background(), interruptSignals...)
+	defer stop()
+
+	args := flag.Args()
+	if len(args) > 0 && args[0] != "serve" {
+		err := runCommand(ctx, config, args, os.Stdout)
+		if err != nil {
+			exitCommand(err)
+		}
+		return
+	}
+
+	runServer(ctx, loader, config)
+}
+
+// runServer migrates the database and runs the servers with the background components until ctx is done
+func runServer(ctx context.Context, loader util.ConfigLoader, config util.Config) {
+	shutdownTracing, err := tracing.Setup(ctx, config)
+	if err != nil {
+		log.Fatal().Err(err).Msg("cannot set up tracing")
+	}
+
+	connPool, err := pgxpool.New(ctx, config.DBSource)
 	if err != nil {
 		log.Fatal().Err(err).Msg("cannot connect to db.")
 	}
 
-	runDBMigration(config.MigrationURL, config.DBSource)
+	runDBMigration(config.DBSource)
 
 	store := db.NewStore(connPool)
+	metrics.MustRegister(
+		metrics.NewPoolCollector(connPool),
+		metrics.NewBusinessCollector(store, config.MetricsCacheDuration),
+	)
 
-	redisOpt := asynq.RedisClientOpt{
-		Addr: config.RedisAddress,
+	taskQueue, err := worker.NewTaskQueue(config)
+	if err != nil {
+		log.Fatal().Err(err).Msg("cannot create task queue")
 	}
 
-	taskDistributor := worker.NewRedisTaskDistribtor(redisOpt)
+	taskDistributor := taskQueue.NewDistributor()
+	taskInspector := taskQueue.NewInspector()
+
+	lastMigration, err := migration.LastVersion()
+	if err != nil {
+		log.Fatal().Err(err).Msg("cannot read migrations")
+	}
 
-	go runTaskProcessor(config, redisOpt, store)
-	go runGatewayServer(config, store,taskDistributor)
-	runGrpcServer(config, store,taskDistributor)
+	healthChecker := health.NewChecker(
+		config.HealthCheckTimeout,
+		[]string{pb.SimpleBank_ServiceDesc.ServiceName},
+		health.Postgres(connPool),
+		health.Migrations(connPool, lastMigration),
+		health.Check{Name: taskQueue.Backend(), Run: taskQueue.Ping},
+	)
+
+	limiter, err := ratelimit.New(config)
+	if err != nil {
+		log.Fatal().Err(err).Msg("cannot create rate limiter")
+	}
+
+	liveConfig := util.NewLiveConfig(config)
+	liveConfig.OnChange(func(config util.Config) {
+		policies, err := ratelimit.ParsePolicies(config.RateLimitPolicies)
+		if err != nil {
+			log.Error().Err(err).Msg("cannot reload rate limit policies, the previous ones are still used")
+			return
+		}
+		limiter.SetPolicies(policies)
+	})
+
+	// the components run until a signal is received or one of them fails,
+	// then they are all stopped before the connection pool is closed
+	ctx, stop := context.WithCancel(ctx)
+	defer stop()
+	waitGroup, ctx := errgroup.WithContext(ctx)
+
+	// a component that can't be set up stops the ones that are already started,
+	// so they shut down gracefully like on a signal
+	err = func() error {
+		if err := runTaskProcessor(ctx, waitGroup, config, taskQueue, store); err != nil {
+			return err
+		}
+		runOutboxRelay(ctx, waitGroup, config, store, taskDistributor)
+		runAuditChainer(ctx, waitGroup, config, store)
+		if err := runScheduler(ctx, waitGroup, config, store); err != nil {
+			return err
+		}
+		if err := runConfigReloader(ctx, waitGroup, config, loader, liveConfig); err != nil {
+			return err
+		}
+		serverCtx := runHealthChecker(ctx, waitGroup, config, healthChecker)
+		runMetricsServer(serverCtx, waitGroup, config)
+		tlsConfig, err := runCertReloader(serverCtx, waitGroup, config)
+		if err != nil {
+			return err
+		}
+		if err := runGatewayServer(serverCtx, waitGroup, config, liveConfig, store, taskDistributor, taskInspector, healthChecker, tlsConfig, limiter); err != nil {
+			return err
+		}
+		return runGrpcServer(serverCtx, waitGroup, config, liveConfig, store, taskDistributor, taskInspector, healthChecker, tlsConfig, limiter)
+	}()
 	// runGinServer(config, store)
-}
+	if err != nil {
+		log.Error().Err(err).Msg("cannot start server, stopping the started components")
+		stop()
+	}
 
-func runDBMigration(migrationURL string, dbSource string) {
-	migration, err := migrate.New(migrationURL, dbSource)
+	if waitErr := waitGroup.Wait(); err == nil {
+		err = waitErr
+	}
+	taskQueue.Close()
+	connPool.Close()
+	flushTracing(config, shutdownTracing)
 	if err != nil {
-		log.Fatal().Err(err).Msg("cannot create new migrate instance:")
+		log.Fatal().Err(err).Msg("error from wait group")
+	}
+
+	log.Info().Msg("server is stopped")
+}
+
+// flushTracing exports the spans that are still buffered, waiting for the exporter up to the shutdown timeout
+func flushTracing(config util.Config, shutdown func(context.Context) error) {
+	ctx, cancel := context.WithTimeout(context.Background(), config.ShutdownTimeout)
+	defer cancel()
+
+	if err := shutdown(ctx); err != nil {
+		log.Error().Err(err).Msg("failed to flush traces")
 	}
+}
 
-	if err := migration.Up(); err != nil && err != migrate.ErrNoChange {
-		log.Fatal().Err(err).Msg("failed to run migrate up:")
+func runDBMigration(dbSource string) {
+	if err := migration.Up(dbSource); err != nil {
+		log.Fatal().Err(err).Msg("failed to migrate db:")
 	}
 
 	log.Info().Msg("db migrated successfully")
 }
 
-func runTaskProcessor(config util.Config, redisOpt asynq.RedisClientOpt, store db.Store) {
-	mailer := mail.NewGmailSender(config.EmailSenderName, config.EmailSenderAddress, config.EmailSenderPassword)
-	taskProcessor := worker.NewRedisTaskProcessor(redisOpt, store, mailer)
+func runTaskProcessor(ctx context.Context, waitGroup *errgroup.Group, config util.Config, taskQueue *worker.TaskQueue, store db.Store) error {
+	mailer, err := mail.NewEmailSender(config)
+	if err != nil {
+		return fmt.Errorf("cannot create email sender: %w", err)
+	}
+
+	renderer, err := mail.NewRenderer(config.BaseURL)
+	if err != nil {
+		return fmt.Errorf("cannot load email templates: %w", err)
+	}
+
+	taskProcessor := taskQueue.NewProcessor(store, mailer, renderer, webhook.NewClient(0))
 
 	log.Info().Msg("start task processor")
-	err := taskProcessor.Start()
+	err = taskProcessor.Start()
 	if err != nil {
-		log.Fatal().Err(err).Msg("failed to start task processor")
+		return fmt.Errorf("failed to start task processor: %w", err)
 	}
+
+	waitGroup.Go(func() error {
+		<-ctx.Done()
+		log.Info().Msg("graceful shutdown task processor")
+
+		taskProcessor.Shutdown()
+		log.Info().Msg("task processor is stopped")
+
+		return nil
+	})
+
+	return nil
 }
 
-func runGrpcServer(config util.Config, store db.Store, taskDistributor worker.TaskDistribtor) {
-	server, err := gapi.NewServer(config, store, taskDistributor)
+func runOutboxRelay(ctx context.Context, waitGroup *errgroup.Group, config util.Config, store db.Store, taskDistributor worker.TaskDistribtor) {
+	relay := worker.NewOutboxRelay(store, taskDistributor, config.OutboxRelayInterval)
+
+	waitGroup.Go(func() error {
+		log.Info().Msg("start outbox relay")
+		relay.Run(ctx)
+		log.Info().Msg("outbox relay is stopped")
+
+		return nil
+	})
+}
+
+func runAuditChainer(ctx context.Context, waitGroup *errgroup.Group, config util.Config, store db.Store) {
+	chainer := worker.NewAuditChainer(store, config.AuditChainInterval)
+
+	waitGroup.Go(func() error {
+		log.Info().Msg("start audit chainer")
+		chainer.Run(ctx)
+		log.Info().Msg("audit chainer is stopped")
+
+		return nil
+	})
+}
+
+func runScheduler(ctx context.Context, waitGroup *errgroup.Group, config util.Config, store db.Store) error {
+	scheduler := worker.NewScheduler(store, config.SchedulerInterval)
+	for _, job := range worker.MaintenanceJobs(config, store) {
+		if err := scheduler.Register(job); err != nil {
+			return fmt.Errorf("cannot register scheduled job: %w", err)
+		}
+	}
+
+	waitGroup.Go(func() error {
+		log.Info().Msg("start scheduler")
+		err := scheduler.Run(ctx)
+		if err != nil {
+			log.Error().Err(err).Msg("failed to run scheduler")
+			return err
+		}
+
+		log.Info().Msg("scheduler is stopped")
+		return nil
+	})
+
+	return nil
+}
+
+// runConfigReloader updates the live config when the config files change,
+// unless the reload interval of the config is zero
+func runConfigReloader(ctx context.Context, waitGroup *errgroup.Group, config util.Config, loader util.ConfigLoader, liveConfig *util.LiveConfig) error {
+	if config.ConfigReloadInterval == 0 {
+		return nil
+	}
+
+	reloader, err := util.NewConfigReloader(loader, liveConfig)
 	if err != nil {
-		log.Fatal().Err(err).Msg("cannot create server:")
+		return fmt.Errorf("cannot create config reloader: %w", err)
 	}
 
-	grpcLogger := grpc.UnaryInterceptor(gapi.GrpcLogger)
-	grpcServer := grpc.NewServer(grpcLogger)
+	waitGroup.Go(func() error {
+		log.Info().Msg("start config reloader")
+		reloader.Run(ctx, config.ConfigReloadInterval)
+		log.Info().Msg("config reloader is stopped")
+
+		return nil
+	})
+
+	return nil
+}
+
+// runHealthChecker keeps the statuses of the gRPC health service up to date.
+// When the components are stopped, the returned context of the servers is only canceled
+// after the drain delay, during which the server reports it isn't ready
+func runHealthChecker(ctx context.Context, waitGroup *errgroup.Group, config util.Config, healthChecker *health.Checker) context.Context {
+	serverCtx, cancel := context.WithCancel(context.Background())
+
+	waitGroup.Go(func() error {
+		defer cancel()
+
+		log.Info().Msg("start health checker")
+		healthChecker.Run(ctx, config.HealthCheckInterval)
+
+		log.Info().Msgf("draining the servers for %s", config.ShutdownDrainDelay)
+		healthChecker.Drain()
+		time.Sleep(config.ShutdownDrainDelay)
+
+		return nil
+	})
+
+	return serverCtx
+}
+
+// runCertReloader returns the TLS config of the servers, which is nil if TLS isn't enabled in the config.
+// The certificates are reloaded when their files change
+func runCertReloader(ctx context.Context, waitGroup *errgroup.Group, config util.Config) (*tls.Config, error) {
+	if config.TLSCertFile == "" {
+		return nil, nil
+	}
+
+	reloader, err := certs.NewReloader(config.TLSCertFile, config.TLSKeyFile, config.TLSClientCAFile, config.TLSClientAuth)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load certificates: %w", err)
+	}
+
+	waitGroup.Go(func() error {
+		log.Info().Msg("start certificate reloader")
+		reloader.Run(ctx, config.TLSReloadInterval)
+		log.Info().Msg("certificate reloader is stopped")
+
+		return nil
+	})
+
+	return reloader.TLSConfig(), nil
+}
+
+func runGrpcServer(ctx context.Context, waitGroup *errgroup.Group, config util.Config, liveConfig *util.LiveConfig, store db.Store, taskDistributor worker.TaskDistribtor, taskInspector worker.TaskInspector, healthChecker *health.Checker, tlsConfig *tls.Config, limiter *ratelimit.Limiter) error {
+	server, err := gapi.NewServer(liveConfig, store, taskDistributor, taskInspector)
+	if err != nil {
+		return fmt.Errorf("cannot create server: %w", err)
+	}
+
+	rateLimiter, err := gapi.NewRateLimiter(config, limiter)
+	if err != nil {
+		return fmt.Errorf("cannot create rate limiter: %w", err)
+	}
+
+	grpcInterceptors := grpc.ChainUnaryInterceptor(
+		otelgrpc.UnaryServerInterceptor(otelgrpc.WithPropagators(tracing.PublicPropagator())),
+		gapi.GrpcRequestID,
+		gapi.GrpcServiceIdentity,
+		gapi.GrpcLogger,
+		rateLimiter.GrpcRateLimit,
+	)
+	serverOptions := []grpc.ServerOption{grpcInterceptors}
+	if tlsConfig != nil {
+		serverOptions = append(serverOptions, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+	grpcServer := grpc.NewServer(serverOptions...)
 	pb.RegisterSimpleBankServer(grpcServer, server)
+	healthpb.RegisterHealthServer(grpcServer, healthChecker.GrpcServer())
 	reflection.Register(grpcServer)
 
 	lintener, err := net.Listen("tcp", config.GRPCServerAddress)
 	if err != nil {
-		log.Fatal().Err(err).Msg("cannot create listener:")
+		return fmt.Errorf("cannot create listener: %w", err)
 	}
 
-	log.Info().Msgf("start gRPC server at %s", lintener.Addr().String())
-	err = grpcServer.Serve(lintener)
+	waitGroup.Go(func() error {
+		log.Info().Msgf("start gRPC server at %s", lintener.Addr().String())
+		err := grpcServer.Serve(lintener)
+		if err != nil {
+			log.Error().Err(err).Msg("gRPC server failed to serve")
+			return err
+		}
+
+		return nil
+	})
+
+	waitGroup.Go(func() error {
+		<-ctx.Done()
+		log.Info().Msg("graceful shutdown gRPC server")
+
+		// GracefulStop waits for the pending RPCs, the connections are closed
+		// if they don't finish before the timeout
+		stopped := make(chan struct{})
+		go func() {
+			grpcServer.GracefulStop()
+			close(stopped)
+		}()
+
+		select {
+		case <-stopped:
+		case <-time.After(config.ShutdownTimeout):
+			log.Warn().Msg("pending RPCs didn't finish before the shutdown timeout")
+			grpcServer.Stop()
+		}
+
+		log.Info().Msg("gRPC server is stopped")
+		return nil
+	})
+
+	return nil
+}
+
+func runGatewayServer(ctx context.Context, waitGroup *errgroup.Group, config util.Config, liveConfig *util.LiveConfig, store db.Store, taskDistributor worker.TaskDistribtor, taskInspector worker.TaskInspector, healthChecker *health.Checker, tlsConfig *tls.Config, limiter *ratelimit.Limiter) error {
+	server, err := gapi.NewServer(liveConfig, store, taskDistributor, taskInspector)
 	if err != nil {
-		log.Fatal().Err(err).Msg("cannot create gRPC server:")
+		return fmt.Errorf("cannot create server: %w", err)
 	}
-}
 
-func runGatewayServer(config util.Config, store db.Store, taskDistributor worker.TaskDistribtor) {
-	server, err := gapi.NewServer(config, store, taskDistributor)
+	rateLimiter, err := gapi.NewRateLimiter(config, limiter)
 	if err != nil {
-		log.Fatal().Err(err).Msg("cannot create server:")
+		return fmt.Errorf("cannot create rate limiter: %w", err)
 	}
 
 	jsonOption := runtime.WithMarshalerOption(runtime.MIMEWildcard, &runtime.JSONPb{
@@ -123,38 +449,127 @@ func runGatewayServer(config util.Config, store db.Store, taskDistributor worker
 		},
 	})
 	
-	grpcMux := runtime.NewServeMux(jsonOption)
-
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	grpcMux := runtime.NewServeMux(
+		jsonOption,
+		runtime.WithMetadata(gapi.HttpRouteAnnotator),
+		runtime.WithMetadata(gapi.HttpRequestIDAnnotator),
+		runtime.WithErrorHandler(gapi.HttpErrorHandler),
+	)
 
 	err = pb.RegisterSimpleBankHandlerServer(ctx, grpcMux, server)
 	if err != nil {
-		log.Fatal().Err(err).Msg("cannot register handler server:")
+		return fmt.Errorf("cannot register handler server: %w", err)
 	}
 	
 	mux := http.NewServeMux()
-	mux.Handle("/", grpcMux)
+	mux.Handle("/", rateLimiter.HttpRateLimit(grpcMux))
 
 	statikFS, err := fs.New()
 	if err != nil {
-		log.Fatal().Err(err).Msg("cannot create statik fs:")
+		return fmt.Errorf("cannot create statik fs: %w", err)
 	}
 
 	swaggerHandler := http.StripPrefix("/swagger/", http.FileServer(statikFS))
 	mux.Handle("/swagger/", swaggerHandler)
+	mux.Handle("/healthz", healthChecker.LivenessHandler())
+	mux.Handle("/readyz", healthChecker.ReadinessHandler())
 
-	listener, err := net.Listen("tcp", config.HTTPServerAddress)
-	if err != nil {
-		log.Fatal().Err(err).Msg("cannot create listener:")
+	httpServer := &http.Server{
+		// the trace context sent by the clients is only linked to the new trace of the request
+		Handler: otelhttp.NewHandler(gapi.HttpRequestID(gapi.HttpServiceIdentity(gapi.HttpLogger(mux))), "gateway",
+			otelhttp.WithPublicEndpoint(),
+			otelhttp.WithFilter(func(req *http.Request) bool {
+				switch req.URL.Path {
+				case "/healthz", "/readyz":
+					return false
+				}
+				return true
+			}),
+		),
+		Addr: config.HTTPServerAddress,
+		TLSConfig: tlsConfig,
 	}
 
-	log.Info().Msgf("start HTTP gateway server at %s", listener.Addr().String())
-	handler := gapi.HttpLogger(mux)
-	err = http.Serve(listener, handler)
-	if err != nil {
-		log.Fatal().Err(err).Msg("cannot create HTTP gateway server:")
+	waitGroup.Go(func() error {
+		log.Info().Msgf("start HTTP gateway server at %s", httpServer.Addr)
+		var err error
+		if tlsConfig != nil {
+			// the certificates are served by the TLS config
+			err = httpServer.ListenAndServeTLS("", "")
+		} else {
+			err = httpServer.ListenAndServe()
+		}
+		if err != nil {
+			if errors.Is(err, http.ErrServerClosed) {
+				return nil
+			}
+			log.Error().Err(err).Msg("HTTP gateway server failed to serve")
+			return err
+		}
+
+		return nil
+	})
+
+	waitGroup.Go(func() error {
+		<-ctx.Done()
+		log.Info().Msg("graceful shutdown HTTP gateway server")
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), config.ShutdownTimeout)
+		defer cancel()
+
+		err := httpServer.Shutdown(shutdownCtx)
+		if err != nil {
+			log.Warn().Err(err).Msg("pending HTTP requests didn't finish before the shutdown timeout")
+			httpServer.Close()
+		}
+
+		log.Info().Msg("HTTP gateway server is stopped")
+		return nil
+	})
+
+	return nil
+}
+
+// runMetricsServer serves /metrics on its own address, so that the metrics are only
+// reachable from inside the cluster and not through the public gateway
+func runMetricsServer(ctx context.Context, waitGroup *errgroup.Group, config util.Config) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+
+	httpServer := &http.Server{
+		Handler: mux,
+		Addr: config.MetricsServerAddress,
 	}
+
+	waitGroup.Go(func() error {
+		log.Info().Msgf("start metrics server at %s", httpServer.Addr)
+		err := httpServer.ListenAndServe()
+		if err != nil {
+			if errors.Is(err, http.ErrServerClosed) {
+				return nil
+			}
+			log.Error().Err(err).Msg("metrics server failed to serve")
+			return err
+		}
+
+		return nil
+	})
+
+	waitGroup.Go(func() error {
+		<-ctx.Done()
+		log.Info().Msg("graceful shutdown metrics server")
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), config.ShutdownTimeout)
+		defer cancel()
+
+		err := httpServer.Shutdown(shutdownCtx)
+		if err != nil {
+			httpServer.Close()
+		}
+
+		log.Info().Msg("metrics server is stopped")
+		return nil
+	})
 }
 
 func runGinServer(config util.Config, store db.Store) {
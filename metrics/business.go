@@ -0,0 +1,108 @@
+package metrics
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	db "github.com/juker1141/simplebank/db/sqlc"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog/log"
+)
+
+const businessQueryTimeout = 5 * time.Second
+
+// BusinessStore reads the totals exported by BusinessCollector, db.Store implements it
+type BusinessStore interface {
+	ListTransferVolumes(ctx context.Context) ([]db.ListTransferVolumesRow, error)
+	ListAccountTotals(ctx context.Context) ([]db.ListAccountTotalsRow, error)
+}
+
+// BusinessCollector exports the totals of the bank per currency. They are read from
+// the database, so every instance reports the same values. The queries scan whole tables,
+// so their results are cached for cacheDuration instead of being read on every scrape
+type BusinessCollector struct {
+	store         BusinessStore
+	cacheDuration time.Duration
+
+	mu          sync.Mutex
+	refreshedAt time.Time
+	volumes     []db.ListTransferVolumesRow
+	totals      []db.ListAccountTotalsRow
+
+	transfers      *prometheus.Desc
+	transferVolume *prometheus.Desc
+	accounts       *prometheus.Desc
+	balance        *prometheus.Desc
+	scrapeErrors   prometheus.Counter
+}
+
+func NewBusinessCollector(store BusinessStore, cacheDuration time.Duration) *BusinessCollector {
+	currency := []string{"currency"}
+
+	return &BusinessCollector{
+		store: store,
+		cacheDuration: cacheDuration,
+		transfers: prometheus.NewDesc(prometheus.BuildFQName(Namespace, "", "transfers"), "Number of transfers, by currency.", currency, nil),
+		transferVolume: prometheus.NewDesc(prometheus.BuildFQName(Namespace, "", "transfer_volume"), "Total amount transferred, by currency.", currency, nil),
+		accounts: prometheus.NewDesc(prometheus.BuildFQName(Namespace, "", "accounts"), "Number of accounts, by currency.", currency, nil),
+		balance: prometheus.NewDesc(prometheus.BuildFQName(Namespace, "", "account_balance"), "Total balance of the accounts, by currency.", currency, nil),
+		scrapeErrors: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name: "business_scrape_errors_total",
+			Help: "Number of failures to read the business metrics from the database.",
+		}),
+	}
+}
+
+func (collector *BusinessCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- collector.transfers
+	ch <- collector.transferVolume
+	ch <- collector.accounts
+	ch <- collector.balance
+	collector.scrapeErrors.Describe(ch)
+}
+
+// Collect skips the metrics that can't be read, so that a slow database doesn't fail the whole scrape
+func (collector *BusinessCollector) Collect(ch chan<- prometheus.Metric) {
+	collector.mu.Lock()
+	defer collector.mu.Unlock()
+
+	if collector.refreshedAt.IsZero() || time.Since(collector.refreshedAt) >= collector.cacheDuration {
+		collector.refresh()
+	}
+
+	for _, volume := range collector.volumes {
+		ch <- prometheus.MustNewConstMetric(collector.transfers, prometheus.GaugeValue, float64(volume.Transfers), volume.Currency)
+		ch <- prometheus.MustNewConstMetric(collector.transferVolume, prometheus.GaugeValue, float64(volume.Amount), volume.Currency)
+	}
+
+	for _, total := range collector.totals {
+		ch <- prometheus.MustNewConstMetric(collector.accounts, prometheus.GaugeValue, float64(total.Accounts), total.Currency)
+		ch <- prometheus.MustNewConstMetric(collector.balance, prometheus.GaugeValue, float64(total.Balance), total.Currency)
+	}
+
+	collector.scrapeErrors.Collect(ch)
+}
+
+// refresh reads the totals from the database. The failures are cached as well,
+// so that a slow database isn't queried again by every scrape
+func (collector *BusinessCollector) refresh() {
+	ctx, cancel := context.WithTimeout(context.Background(), businessQueryTimeout)
+	defer cancel()
+
+	var err error
+	collector.volumes, err = collector.store.ListTransferVolumes(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to list transfer volumes")
+		collector.scrapeErrors.Inc()
+	}
+
+	collector.totals, err = collector.store.ListAccountTotals(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to list account totals")
+		collector.scrapeErrors.Inc()
+	}
+
+	collector.refreshedAt = time.Now()
+}
@@ -0,0 +1,70 @@
+package metrics
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/juker1141/simplebank/db/mock"
+	db "github.com/juker1141/simplebank/db/sqlc"
+	"github.com/juker1141/simplebank/util"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBusinessCollector(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		ListTransferVolumes(gomock.Any()).
+		Times(1).
+		Return([]db.ListTransferVolumesRow{
+			{Currency: util.EUR, Transfers: 2, Amount: 150},
+			{Currency: util.USD, Transfers: 3, Amount: 700},
+		}, nil)
+	store.EXPECT().
+		ListAccountTotals(gomock.Any()).
+		Times(1).
+		Return(nil, errors.New("connection refused"))
+
+	expected := `
+# HELP simplebank_transfer_volume Total amount transferred, by currency.
+# TYPE simplebank_transfer_volume gauge
+simplebank_transfer_volume{currency="EUR"} 150
+simplebank_transfer_volume{currency="USD"} 700
+# HELP simplebank_business_scrape_errors_total Number of failures to read the business metrics from the database.
+# TYPE simplebank_business_scrape_errors_total counter
+simplebank_business_scrape_errors_total 1
+`
+	err := testutil.CollectAndCompare(NewBusinessCollector(store, time.Minute), strings.NewReader(expected),
+		"simplebank_transfer_volume", "simplebank_business_scrape_errors_total")
+	require.NoError(t, err)
+}
+
+func TestBusinessCollectorCache(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// the second scrape is served from the cache
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		ListTransferVolumes(gomock.Any()).
+		Times(1).
+		Return([]db.ListTransferVolumesRow{
+			{Currency: util.USD, Transfers: 3, Amount: 700},
+		}, nil)
+	store.EXPECT().
+		ListAccountTotals(gomock.Any()).
+		Times(1).
+		Return([]db.ListAccountTotalsRow{
+			{Currency: util.USD, Accounts: 2, Balance: 300},
+		}, nil)
+
+	collector := NewBusinessCollector(store, time.Minute)
+	require.Equal(t, 2, testutil.CollectAndCount(collector, "simplebank_transfers", "simplebank_account_balance"))
+	require.Equal(t, 2, testutil.CollectAndCount(collector, "simplebank_transfers", "simplebank_account_balance"))
+}
@@ -0,0 +1,21 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Namespace prefixes the names of all metrics of the application
+const Namespace = "simplebank"
+
+// Handler serves the metrics of the default registry, in which the packages register their metrics
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// MustRegister registers the collectors in the default registry
+func MustRegister(collectors ...prometheus.Collector) {
+	prometheus.MustRegister(collectors...)
+}
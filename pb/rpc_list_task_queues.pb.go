@@ -0,0 +1,202 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.30.0
+// 	protoc        v4.23.3
+// source: rpc_list_task_queues.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type ListTaskQueuesRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *ListTaskQueuesRequest) Reset() {
+	*x = ListTaskQueuesRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_rpc_list_task_queues_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListTaskQueuesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTaskQueuesRequest) ProtoMessage() {}
+
+func (x *ListTaskQueuesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_list_task_queues_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTaskQueuesRequest.ProtoReflect.Descriptor instead.
+func (*ListTaskQueuesRequest) Descriptor() ([]byte, []int) {
+	return file_rpc_list_task_queues_proto_rawDescGZIP(), []int{0}
+}
+
+type ListTaskQueuesResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Queues []*TaskQueue `protobuf:"bytes,1,rep,name=queues,proto3" json:"queues,omitempty"`
+}
+
+func (x *ListTaskQueuesResponse) Reset() {
+	*x = ListTaskQueuesResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_rpc_list_task_queues_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListTaskQueuesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTaskQueuesResponse) ProtoMessage() {}
+
+func (x *ListTaskQueuesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_list_task_queues_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTaskQueuesResponse.ProtoReflect.Descriptor instead.
+func (*ListTaskQueuesResponse) Descriptor() ([]byte, []int) {
+	return file_rpc_list_task_queues_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ListTaskQueuesResponse) GetQueues() []*TaskQueue {
+	if x != nil {
+		return x.Queues
+	}
+	return nil
+}
+
+var File_rpc_list_task_queues_proto protoreflect.FileDescriptor
+
+var file_rpc_list_task_queues_proto_rawDesc = []byte{
+	0x0a, 0x1a, 0x72, 0x70, 0x63, 0x5f, 0x6c, 0x69, 0x73, 0x74, 0x5f, 0x74, 0x61, 0x73, 0x6b, 0x5f,
+	0x71, 0x75, 0x65, 0x75, 0x65, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x02, 0x70, 0x62,
+	0x1a, 0x10, 0x74, 0x61, 0x73, 0x6b, 0x5f, 0x71, 0x75, 0x65, 0x75, 0x65, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x22, 0x17, 0x0a, 0x15, 0x4c, 0x69, 0x73, 0x74, 0x54, 0x61, 0x73, 0x6b, 0x51, 0x75,
+	0x65, 0x75, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x3f, 0x0a, 0x16, 0x4c,
+	0x69, 0x73, 0x74, 0x54, 0x61, 0x73, 0x6b, 0x51, 0x75, 0x65, 0x75, 0x65, 0x73, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x25, 0x0a, 0x06, 0x71, 0x75, 0x65, 0x75, 0x65, 0x73, 0x18,
+	0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0d, 0x2e, 0x70, 0x62, 0x2e, 0x54, 0x61, 0x73, 0x6b, 0x51,
+	0x75, 0x65, 0x75, 0x65, 0x52, 0x06, 0x71, 0x75, 0x65, 0x75, 0x65, 0x73, 0x42, 0x24, 0x5a, 0x22,
+	0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6a, 0x75, 0x6b, 0x65, 0x72,
+	0x31, 0x31, 0x34, 0x31, 0x2f, 0x73, 0x69, 0x6d, 0x70, 0x6c, 0x65, 0x62, 0x61, 0x6e, 0x6b, 0x2f,
+	0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_rpc_list_task_queues_proto_rawDescOnce sync.Once
+	file_rpc_list_task_queues_proto_rawDescData = file_rpc_list_task_queues_proto_rawDesc
+)
+
+func file_rpc_list_task_queues_proto_rawDescGZIP() []byte {
+	file_rpc_list_task_queues_proto_rawDescOnce.Do(func() {
+		file_rpc_list_task_queues_proto_rawDescData = protoimpl.X.CompressGZIP(file_rpc_list_task_queues_proto_rawDescData)
+	})
+	return file_rpc_list_task_queues_proto_rawDescData
+}
+
+var file_rpc_list_task_queues_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_rpc_list_task_queues_proto_goTypes = []interface{}{
+	(*ListTaskQueuesRequest)(nil),  // 0: pb.ListTaskQueuesRequest
+	(*ListTaskQueuesResponse)(nil), // 1: pb.ListTaskQueuesResponse
+	(*TaskQueue)(nil),              // 2: pb.TaskQueue
+}
+var file_rpc_list_task_queues_proto_depIdxs = []int32{
+	2, // 0: pb.ListTaskQueuesResponse.queues:type_name -> pb.TaskQueue
+	1, // [1:1] is the sub-list for method output_type
+	1, // [1:1] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_rpc_list_task_queues_proto_init() }
+func file_rpc_list_task_queues_proto_init() {
+	if File_rpc_list_task_queues_proto != nil {
+		return
+	}
+	file_task_queue_proto_init()
+	if !protoimpl.UnsafeEnabled {
+		file_rpc_list_task_queues_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListTaskQueuesRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_rpc_list_task_queues_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListTaskQueuesResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_rpc_list_task_queues_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_rpc_list_task_queues_proto_goTypes,
+		DependencyIndexes: file_rpc_list_task_queues_proto_depIdxs,
+		MessageInfos:      file_rpc_list_task_queues_proto_msgTypes,
+	}.Build()
+	File_rpc_list_task_queues_proto = out.File
+	file_rpc_list_task_queues_proto_rawDesc = nil
+	file_rpc_list_task_queues_proto_goTypes = nil
+	file_rpc_list_task_queues_proto_depIdxs = nil
+}
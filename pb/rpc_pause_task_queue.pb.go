@@ -0,0 +1,207 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.30.0
+// 	protoc        v4.23.3
+// source: rpc_pause_task_queue.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type PauseTaskQueueRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Queue    string `protobuf:"bytes,1,opt,name=queue,proto3" json:"queue,omitempty"`
+	IsPaused bool   `protobuf:"varint,2,opt,name=is_paused,json=isPaused,proto3" json:"is_paused,omitempty"`
+}
+
+func (x *PauseTaskQueueRequest) Reset() {
+	*x = PauseTaskQueueRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_rpc_pause_task_queue_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PauseTaskQueueRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PauseTaskQueueRequest) ProtoMessage() {}
+
+func (x *PauseTaskQueueRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_pause_task_queue_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PauseTaskQueueRequest.ProtoReflect.Descriptor instead.
+func (*PauseTaskQueueRequest) Descriptor() ([]byte, []int) {
+	return file_rpc_pause_task_queue_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *PauseTaskQueueRequest) GetQueue() string {
+	if x != nil {
+		return x.Queue
+	}
+	return ""
+}
+
+func (x *PauseTaskQueueRequest) GetIsPaused() bool {
+	if x != nil {
+		return x.IsPaused
+	}
+	return false
+}
+
+type PauseTaskQueueResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *PauseTaskQueueResponse) Reset() {
+	*x = PauseTaskQueueResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_rpc_pause_task_queue_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PauseTaskQueueResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PauseTaskQueueResponse) ProtoMessage() {}
+
+func (x *PauseTaskQueueResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_pause_task_queue_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PauseTaskQueueResponse.ProtoReflect.Descriptor instead.
+func (*PauseTaskQueueResponse) Descriptor() ([]byte, []int) {
+	return file_rpc_pause_task_queue_proto_rawDescGZIP(), []int{1}
+}
+
+var File_rpc_pause_task_queue_proto protoreflect.FileDescriptor
+
+var file_rpc_pause_task_queue_proto_rawDesc = []byte{
+	0x0a, 0x1a, 0x72, 0x70, 0x63, 0x5f, 0x70, 0x61, 0x75, 0x73, 0x65, 0x5f, 0x74, 0x61, 0x73, 0x6b,
+	0x5f, 0x71, 0x75, 0x65, 0x75, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x02, 0x70, 0x62,
+	0x22, 0x4a, 0x0a, 0x15, 0x50, 0x61, 0x75, 0x73, 0x65, 0x54, 0x61, 0x73, 0x6b, 0x51, 0x75, 0x65,
+	0x75, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x71, 0x75, 0x65,
+	0x75, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x71, 0x75, 0x65, 0x75, 0x65, 0x12,
+	0x1b, 0x0a, 0x09, 0x69, 0x73, 0x5f, 0x70, 0x61, 0x75, 0x73, 0x65, 0x64, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x08, 0x52, 0x08, 0x69, 0x73, 0x50, 0x61, 0x75, 0x73, 0x65, 0x64, 0x22, 0x18, 0x0a, 0x16,
+	0x50, 0x61, 0x75, 0x73, 0x65, 0x54, 0x61, 0x73, 0x6b, 0x51, 0x75, 0x65, 0x75, 0x65, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x42, 0x24, 0x5a, 0x22, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62,
+	0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6a, 0x75, 0x6b, 0x65, 0x72, 0x31, 0x31, 0x34, 0x31, 0x2f, 0x73,
+	0x69, 0x6d, 0x70, 0x6c, 0x65, 0x62, 0x61, 0x6e, 0x6b, 0x2f, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_rpc_pause_task_queue_proto_rawDescOnce sync.Once
+	file_rpc_pause_task_queue_proto_rawDescData = file_rpc_pause_task_queue_proto_rawDesc
+)
+
+func file_rpc_pause_task_queue_proto_rawDescGZIP() []byte {
+	file_rpc_pause_task_queue_proto_rawDescOnce.Do(func() {
+		file_rpc_pause_task_queue_proto_rawDescData = protoimpl.X.CompressGZIP(file_rpc_pause_task_queue_proto_rawDescData)
+	})
+	return file_rpc_pause_task_queue_proto_rawDescData
+}
+
+var file_rpc_pause_task_queue_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_rpc_pause_task_queue_proto_goTypes = []interface{}{
+	(*PauseTaskQueueRequest)(nil),  // 0: pb.PauseTaskQueueRequest
+	(*PauseTaskQueueResponse)(nil), // 1: pb.PauseTaskQueueResponse
+}
+var file_rpc_pause_task_queue_proto_depIdxs = []int32{
+	0, // [0:0] is the sub-list for method output_type
+	0, // [0:0] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_rpc_pause_task_queue_proto_init() }
+func file_rpc_pause_task_queue_proto_init() {
+	if File_rpc_pause_task_queue_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_rpc_pause_task_queue_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PauseTaskQueueRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_rpc_pause_task_queue_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PauseTaskQueueResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_rpc_pause_task_queue_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_rpc_pause_task_queue_proto_goTypes,
+		DependencyIndexes: file_rpc_pause_task_queue_proto_depIdxs,
+		MessageInfos:      file_rpc_pause_task_queue_proto_msgTypes,
+	}.Build()
+	File_rpc_pause_task_queue_proto = out.File
+	file_rpc_pause_task_queue_proto_rawDesc = nil
+	file_rpc_pause_task_queue_proto_goTypes = nil
+	file_rpc_pause_task_queue_proto_depIdxs = nil
+}
@@ -0,0 +1,63 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	db "github.com/juker1141/simplebank/db/sqlc"
+	"github.com/juker1141/simplebank/token"
+)
+
+// ReasonEmailNotVerified is the error code returned to clients
+// when an operation is rejected because the email of the user isn't verified
+const ReasonEmailNotVerified = "EMAIL_NOT_VERIFIED"
+
+// ErrEmailNotVerified is returned by VerifiedEmail when the email of the user isn't verified
+var ErrEmailNotVerified = errors.New("email address must be verified before moving money")
+
+// UserGetter looks up a user, db.Store implements it
+type UserGetter interface {
+	GetUser(ctx context.Context, username string) (db.User, error)
+}
+
+// VerifiedEmail is the policy that blocks account creation and outgoing transfers
+// of the users whose email isn't verified. It is shared by the HTTP and gRPC servers
+type VerifiedEmail struct {
+	required   bool
+	trustToken bool
+	users      UserGetter
+}
+
+// NewVerifiedEmail creates the policy. It doesn't block anything unless required is set.
+// If trustToken is set, the verified flag carried by the token is trusted, and the user is only
+// looked up when the token doesn't have it, for example when the email was verified after login
+func NewVerifiedEmail(required bool, trustToken bool, users UserGetter) *VerifiedEmail {
+	return &VerifiedEmail{
+		required: required,
+		trustToken: trustToken,
+		users: users,
+	}
+}
+
+// Check returns ErrEmailNotVerified if the policy is required and the email of the token's user isn't verified
+func (policy *VerifiedEmail) Check(ctx context.Context, payload *token.Payload) error {
+	if !policy.required {
+		return nil
+	}
+
+	if policy.trustToken && payload.EmailVerified {
+		return nil
+	}
+
+	user, err := policy.users.GetUser(ctx, payload.Username)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if !user.IsEmailVerified {
+		return ErrEmailNotVerified
+	}
+
+	return nil
+}
@@ -0,0 +1,132 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/juker1141/simplebank/util"
+)
+
+// Backends that can be selected with RATE_LIMIT_BACKEND
+const (
+	BackendMemory = "memory"
+	BackendRedis  = "redis"
+)
+
+// ReasonRateLimited is the reason of the errors returned to the clients over a limit
+const ReasonRateLimited = "RATE_LIMITED"
+
+// Store keeps the token buckets of the identities
+type Store interface {
+	// Take takes a token from the bucket of the key, and returns how long to wait
+	// for the next token if the bucket is empty
+	Take(ctx context.Context, key string, limit Limit) (allowed bool, retryAfter time.Duration, err error)
+}
+
+// Identity is who made a request, each policy only applies if its key is known
+type Identity struct {
+	IP       string
+	Username string
+	// APIKey is the hash of the API key, the keys themselves aren't stored in the buckets
+	APIKey string
+}
+
+func (identity Identity) value(key string) string {
+	switch key {
+	case KeyIP:
+		return identity.IP
+	case KeyUser:
+		return identity.Username
+	case KeyAPIKey:
+		return identity.APIKey
+	}
+	return ""
+}
+
+// Result is the decision of the limiter for a request
+type Result struct {
+	Allowed bool
+	// RetryAfter is how long to wait before the request can be allowed again
+	RetryAfter time.Duration
+	// Policy is the policy that rejected the request
+	Policy Policy
+}
+
+// Limiter applies the policies of the methods to the requests
+type Limiter struct {
+	policies atomic.Pointer[map[string][]Policy]
+	store    Store
+}
+
+func NewLimiter(policies []Policy, store Store) *Limiter {
+	limiter := &Limiter{
+		store: store,
+	}
+
+	limiter.SetPolicies(policies)
+	return limiter
+}
+
+// SetPolicies replaces the policies of the limiter, such as when the config is reloaded.
+// The buckets of the identities are kept
+func (limiter *Limiter) SetPolicies(policies []Policy) {
+	methods := make(map[string][]Policy)
+	for _, policy := range policies {
+		methods[policy.Method] = append(methods[policy.Method], policy)
+	}
+	limiter.policies.Store(&methods)
+}
+
+// New creates the limiter with the policies and the backend of the config
+func New(config util.Config) (*Limiter, error) {
+	policies, err := ParsePolicies(config.RateLimitPolicies)
+	if err != nil {
+		return nil, err
+	}
+
+	switch config.RateLimitBackend {
+	case BackendMemory, "":
+		return NewLimiter(policies, NewMemoryStore()), nil
+	case BackendRedis:
+		return NewLimiter(policies, NewRedisStore(config.RedisAddress)), nil
+	default:
+		return nil, fmt.Errorf("unsupported rate limit backend: %s", config.RateLimitBackend)
+	}
+}
+
+// Allow takes a token from the bucket of the identity for each policy of the method.
+// The method is limited by the policies of AnyMethod if it has no policy of its own,
+// their buckets are shared by all these methods. The request is rejected if one of the buckets is empty
+func (limiter *Limiter) Allow(ctx context.Context, method string, identity Identity) (Result, error) {
+	methods := *limiter.policies.Load()
+	policies, ok := methods[method]
+	if !ok {
+		policies = methods[AnyMethod]
+	}
+
+	result := Result{Allowed: true}
+	for _, policy := range policies {
+		value := identity.value(policy.Key)
+		if value == "" {
+			continue
+		}
+
+		key := fmt.Sprintf("%s:%s:%s", policy.Method, policy.Key, value)
+		allowed, retryAfter, err := limiter.store.Take(ctx, key, policy.Limit)
+		if err != nil {
+			return Result{}, fmt.Errorf("failed to take token for %s: %w", policy, err)
+		}
+
+		if !allowed && retryAfter >= result.RetryAfter {
+			result = Result{
+				Allowed:    false,
+				RetryAfter: retryAfter,
+				Policy:     policy,
+			}
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,132 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestMemoryStore() (*MemoryStore, *time.Time) {
+	now := time.Now()
+	store := NewMemoryStore()
+	store.now = func() time.Time {
+		return now
+	}
+	return store, &now
+}
+
+func TestMemoryStoreTake(t *testing.T) {
+	store, now := newTestMemoryStore()
+	limit := Limit{Rate: 2, Burst: 3}
+
+	for i := 0; i < 3; i++ {
+		allowed, _, err := store.Take(context.Background(), "key", limit)
+		require.NoError(t, err)
+		require.True(t, allowed)
+	}
+
+	allowed, retryAfter, err := store.Take(context.Background(), "key", limit)
+	require.NoError(t, err)
+	require.False(t, allowed)
+	require.Equal(t, 500*time.Millisecond, retryAfter)
+
+	// the other keys have their own bucket
+	allowed, _, err = store.Take(context.Background(), "other", limit)
+	require.NoError(t, err)
+	require.True(t, allowed)
+
+	*now = now.Add(retryAfter)
+	allowed, _, err = store.Take(context.Background(), "key", limit)
+	require.NoError(t, err)
+	require.True(t, allowed)
+
+	allowed, _, err = store.Take(context.Background(), "key", limit)
+	require.NoError(t, err)
+	require.False(t, allowed)
+}
+
+func TestMemoryStoreSweep(t *testing.T) {
+	store, now := newTestMemoryStore()
+	limit := Limit{Rate: 1, Burst: 1}
+
+	_, _, err := store.Take(context.Background(), "key", limit)
+	require.NoError(t, err)
+	require.Len(t, store.buckets, 1)
+
+	*now = now.Add(sweepInterval)
+	_, _, err = store.Take(context.Background(), "other", limit)
+	require.NoError(t, err)
+	require.Len(t, store.buckets, 1)
+	require.Contains(t, store.buckets, "other")
+}
+
+type errorStore struct{}
+
+func (errorStore) Take(ctx context.Context, key string, limit Limit) (bool, time.Duration, error) {
+	return false, 0, errors.New("connection refused")
+}
+
+func TestLimiterAllow(t *testing.T) {
+	loginPolicy := Policy{Method: "LoginUser", Key: KeyIP, Limit: Limit{Rate: 1, Burst: 1}}
+	userPolicy := Policy{Method: AnyMethod, Key: KeyUser, Limit: Limit{Rate: 0.5, Burst: 1}}
+	store, _ := newTestMemoryStore()
+	limiter := NewLimiter([]Policy{loginPolicy, userPolicy}, store)
+
+	ctx := context.Background()
+	identity := Identity{IP: "10.0.0.1", Username: "alice"}
+
+	result, err := limiter.Allow(ctx, "LoginUser", identity)
+	require.NoError(t, err)
+	require.True(t, result.Allowed)
+
+	result, err = limiter.Allow(ctx, "LoginUser", identity)
+	require.NoError(t, err)
+	require.False(t, result.Allowed)
+	require.Equal(t, loginPolicy, result.Policy)
+	require.Equal(t, time.Second, result.RetryAfter)
+
+	// the methods without policies share the buckets of the default policies
+	result, err = limiter.Allow(ctx, "Deposit", identity)
+	require.NoError(t, err)
+	require.True(t, result.Allowed)
+
+	result, err = limiter.Allow(ctx, "Withdraw", identity)
+	require.NoError(t, err)
+	require.False(t, result.Allowed)
+	require.Equal(t, userPolicy, result.Policy)
+	require.Equal(t, 2*time.Second, result.RetryAfter)
+
+	// the policies of the unknown identities don't apply
+	result, err = limiter.Allow(ctx, "Withdraw", Identity{IP: "10.0.0.1"})
+	require.NoError(t, err)
+	require.True(t, result.Allowed)
+
+	_, err = NewLimiter([]Policy{loginPolicy}, errorStore{}).Allow(ctx, "LoginUser", identity)
+	require.Error(t, err)
+}
+
+func TestLimiterSetPolicies(t *testing.T) {
+	store, _ := newTestMemoryStore()
+	limiter := NewLimiter([]Policy{{Method: "LoginUser", Key: KeyIP, Limit: Limit{Rate: 1, Burst: 1}}}, store)
+
+	ctx := context.Background()
+	identity := Identity{IP: "10.0.0.1"}
+
+	result, err := limiter.Allow(ctx, "LoginUser", identity)
+	require.NoError(t, err)
+	require.True(t, result.Allowed)
+
+	result, err = limiter.Allow(ctx, "LoginUser", identity)
+	require.NoError(t, err)
+	require.False(t, result.Allowed)
+
+	// the method isn't limited once its policy is removed
+	limiter.SetPolicies(nil)
+
+	result, err = limiter.Allow(ctx, "LoginUser", identity)
+	require.NoError(t, err)
+	require.True(t, result.Allowed)
+}
@@ -0,0 +1,108 @@
+package ratelimit
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Identities a policy can limit the requests of
+const (
+	KeyIP     = "ip"
+	KeyUser   = "user"
+	KeyAPIKey = "api_key"
+)
+
+// AnyMethod is the method of the policies applied to the methods without their own policies
+const AnyMethod = "*"
+
+// Limit is a token bucket that refills at Rate tokens per second, up to Burst tokens.
+// Each request takes a token
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Policy limits the requests of each identity of the key to a method
+type Policy struct {
+	Method string
+	Key    string
+	Limit  Limit
+}
+
+func (policy Policy) String() string {
+	return fmt.Sprintf("%s=%s:%g/s:%d", policy.Method, policy.Key, policy.Limit.Rate, policy.Limit.Burst)
+}
+
+var rateUnits = map[string]time.Duration{
+	"s": time.Second,
+	"m": time.Minute,
+	"h": time.Hour,
+}
+
+// ParsePolicy parses a policy in the format <method>=<key>:<count>/<unit>[:<burst>],
+// such as LoginUser=ip:5/m:10 for 5 logins per minute and per IP, with bursts of 10 logins.
+// The unit is s, m or h, and the burst defaults to the count
+func ParsePolicy(value string) (Policy, error) {
+	method, limit, ok := strings.Cut(strings.TrimSpace(value), "=")
+	if !ok || method == "" {
+		return Policy{}, fmt.Errorf("invalid rate limit policy %q: missing method", value)
+	}
+
+	fields := strings.Split(limit, ":")
+	if len(fields) < 2 || len(fields) > 3 {
+		return Policy{}, fmt.Errorf("invalid rate limit policy %q: expected <key>:<count>/<unit>[:<burst>]", value)
+	}
+
+	key := fields[0]
+	switch key {
+	case KeyIP, KeyUser, KeyAPIKey:
+	default:
+		return Policy{}, fmt.Errorf("invalid rate limit policy %q: unsupported key %s", value, key)
+	}
+
+	countValue, unitValue, ok := strings.Cut(fields[1], "/")
+	count, err := strconv.Atoi(countValue)
+	if !ok || err != nil || count <= 0 {
+		return Policy{}, fmt.Errorf("invalid rate limit policy %q: invalid rate %s", value, fields[1])
+	}
+	unit, ok := rateUnits[unitValue]
+	if !ok {
+		return Policy{}, fmt.Errorf("invalid rate limit policy %q: unsupported unit %s", value, unitValue)
+	}
+
+	burst := count
+	if len(fields) == 3 {
+		burst, err = strconv.Atoi(fields[2])
+		if err != nil || burst <= 0 {
+			return Policy{}, fmt.Errorf("invalid rate limit policy %q: invalid burst %s", value, fields[2])
+		}
+	}
+
+	return Policy{
+		Method: method,
+		Key:    key,
+		Limit: Limit{
+			Rate:  float64(count) / unit.Seconds(),
+			Burst: burst,
+		},
+	}, nil
+}
+
+// ParsePolicies parses the policies of the config, ignoring the empty values
+func ParsePolicies(values []string) ([]Policy, error) {
+	var policies []Policy
+	for _, value := range values {
+		if strings.TrimSpace(value) == "" {
+			continue
+		}
+
+		policy, err := ParsePolicy(value)
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, policy)
+	}
+	return policies, nil
+}
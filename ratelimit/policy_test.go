@@ -0,0 +1,65 @@
+package ratelimit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePolicy(t *testing.T) {
+	testCases := []struct {
+		value  string
+		policy Policy
+		valid  bool
+	}{
+		{
+			value:  "LoginUser=ip:5/m:10",
+			policy: Policy{Method: "LoginUser", Key: KeyIP, Limit: Limit{Rate: 5.0 / 60, Burst: 10}},
+			valid:  true,
+		},
+		{
+			value:  "*=user:20/s",
+			policy: Policy{Method: AnyMethod, Key: KeyUser, Limit: Limit{Rate: 20, Burst: 20}},
+			valid:  true,
+		},
+		{
+			value:  " ResendVerifyEmail=api_key:36/h ",
+			policy: Policy{Method: "ResendVerifyEmail", Key: KeyAPIKey, Limit: Limit{Rate: 0.01, Burst: 36}},
+			valid:  true,
+		},
+		{value: "ip:5/m"},
+		{value: "LoginUser=ip"},
+		{value: "LoginUser=email:5/m"},
+		{value: "LoginUser=ip:0/m"},
+		{value: "LoginUser=ip:5/d"},
+		{value: "LoginUser=ip:5/m:-1"},
+		{value: "LoginUser=ip:5/m:10:20"},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.value, func(t *testing.T) {
+			policy, err := ParsePolicy(tc.value)
+			if !tc.valid {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.policy.Method, policy.Method)
+			require.Equal(t, tc.policy.Key, policy.Key)
+			require.InDelta(t, tc.policy.Limit.Rate, policy.Limit.Rate, 1e-9)
+			require.Equal(t, tc.policy.Limit.Burst, policy.Limit.Burst)
+		})
+	}
+}
+
+func TestParsePolicies(t *testing.T) {
+	policies, err := ParsePolicies([]string{"LoginUser=ip:5/m", "", "*=user:20/s"})
+	require.NoError(t, err)
+	require.Len(t, policies, 2)
+
+	_, err = ParsePolicies([]string{"LoginUser=ip:5/m", "invalid"})
+	require.Error(t, err)
+}
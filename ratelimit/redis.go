@@ -0,0 +1,77 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// keyPrefix separates the buckets from the other data of Redis
+const keyPrefix = "ratelimit:"
+
+// takeScript refills and takes a token from a bucket atomically, with the clock of Redis
+// so that all the instances of the server agree on the time.
+// It returns whether the token is taken, and the seconds to wait for the next token otherwise
+var takeScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local time = redis.call('TIME')
+local now = tonumber(time[1]) + tonumber(time[2]) / 1000000
+
+local bucket = redis.call('HMGET', KEYS[1], 'tokens', 'updated_at')
+local tokens = tonumber(bucket[1]) or burst
+local updated_at = tonumber(bucket[2]) or now
+if now > updated_at then
+  tokens = math.min(burst, tokens + (now - updated_at) * rate)
+  updated_at = now
+end
+
+local allowed = 0
+local retry_after = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+else
+  retry_after = (1 - tokens) / rate
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'updated_at', tostring(updated_at))
+redis.call('PEXPIRE', KEYS[1], math.ceil(burst / rate * 1000) + 1000)
+return {allowed, tostring(retry_after)}
+`)
+
+// RedisStore keeps the buckets in Redis, they are shared by all the instances of the server
+type RedisStore struct {
+	client redis.UniversalClient
+}
+
+func NewRedisStore(address string) *RedisStore {
+	return &RedisStore{
+		client: redis.NewClient(&redis.Options{Addr: address}),
+	}
+}
+
+func (store *RedisStore) Take(ctx context.Context, key string, limit Limit) (bool, time.Duration, error) {
+	values, err := takeScript.Run(ctx, store.client, []string{keyPrefix + key}, limit.Rate, limit.Burst).Slice()
+	if err != nil {
+		return false, 0, err
+	}
+	if len(values) != 2 {
+		return false, 0, fmt.Errorf("unexpected result of the rate limit script: %v", values)
+	}
+
+	allowed, _ := values[0].(int64)
+	retryAfter, err := strconv.ParseFloat(fmt.Sprint(values[1]), 64)
+	if err != nil {
+		return false, 0, fmt.Errorf("invalid retry after: %w", err)
+	}
+
+	return allowed == 1, time.Duration(retryAfter * float64(time.Second)), nil
+}
+
+func (store *RedisStore) Close() error {
+	return store.client.Close()
+}
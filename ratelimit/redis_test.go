@@ -0,0 +1,33 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/juker1141/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedisStoreTake(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	store := NewRedisStore("localhost:6379")
+	defer store.Close()
+
+	key := "test:" + util.RandomString(12)
+	limit := Limit{Rate: 1, Burst: 2}
+
+	for i := 0; i < 2; i++ {
+		allowed, _, err := store.Take(context.Background(), key, limit)
+		require.NoError(t, err)
+		require.True(t, allowed)
+	}
+
+	allowed, retryAfter, err := store.Take(context.Background(), key, limit)
+	require.NoError(t, err)
+	require.False(t, allowed)
+	require.True(t, retryAfter > 0 && retryAfter <= time.Second)
+}
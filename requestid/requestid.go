@@ -0,0 +1,108 @@
+package requestid
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// Header is the HTTP header and the gRPC metadata key that carries the request ID
+const Header = "x-request-id"
+
+// payloadKey is the field of the task payloads that carries the ID of the request that enqueued the task
+const payloadKey = "request_id"
+
+// maxLength limits the size of the IDs sent by the clients, as they are written to every log line
+const maxLength = 128
+
+type contextKey struct{}
+
+// New generates a request ID
+func New() string {
+	return uuid.NewString()
+}
+
+// Resolve returns the request ID sent by the client, or a new ID if it is missing or invalid
+func Resolve(id string) string {
+	if !valid(id) {
+		return New()
+	}
+	return id
+}
+
+// valid only accepts short IDs made of letters, digits and a few separators
+func valid(id string) bool {
+	if len(id) == 0 || len(id) > maxLength {
+		return false
+	}
+
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// NewContext returns ctx with the request ID
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the request ID of ctx, or an empty string if ctx isn't part of a request
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
+
+// Logger returns the global logger, with the request ID of ctx on every line
+func Logger(ctx context.Context) *zerolog.Logger {
+	id := FromContext(ctx)
+	if id == "" {
+		return &log.Logger
+	}
+
+	logger := log.With().Str("request_id", id).Logger()
+	return &logger
+}
+
+// InjectPayload adds the request ID of ctx to a JSON object payload, unless it already carries one.
+// The payload is returned unchanged if ctx isn't part of a request, or if it isn't a JSON object
+func InjectPayload(ctx context.Context, payload []byte) []byte {
+	id := FromContext(ctx)
+	if id == "" {
+		return payload
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(payload, &fields); err != nil || fields == nil {
+		return payload
+	}
+	if _, ok := fields[payloadKey]; ok {
+		return payload
+	}
+
+	fields[payloadKey], _ = json.Marshal(id)
+	injected, err := json.Marshal(fields)
+	if err != nil {
+		return payload
+	}
+	return injected
+}
+
+// FromPayload returns the request ID carried by a task payload, or an empty string
+func FromPayload(payload []byte) string {
+	var fields struct {
+		RequestID string `json:"request_id"`
+	}
+	if err := json.Unmarshal(payload, &fields); err != nil {
+		return ""
+	}
+	return fields.RequestID
+}
@@ -0,0 +1,43 @@
+package requestid
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolve(t *testing.T) {
+	require.Equal(t, "req-1:abc_2.x", Resolve("req-1:abc_2.x"))
+
+	for _, id := range []string{"", "two words", "id\nwith newline", strings.Repeat("a", maxLength+1)} {
+		resolved := Resolve(id)
+		require.NotEqual(t, id, resolved)
+		require.True(t, valid(resolved))
+	}
+}
+
+func TestPayloadRequestID(t *testing.T) {
+	payload := []byte(`{"username":"alice"}`)
+	require.Equal(t, payload, InjectPayload(context.Background(), payload))
+	require.Empty(t, FromPayload(payload))
+
+	ctx := NewContext(context.Background(), "req-1")
+	require.Equal(t, "req-1", FromContext(ctx))
+
+	injected := InjectPayload(ctx, payload)
+	require.Equal(t, "req-1", FromPayload(injected))
+
+	var fields map[string]any
+	require.NoError(t, json.Unmarshal(injected, &fields))
+	require.Equal(t, "alice", fields["username"])
+
+	// the ID of the request that wrote the task isn't replaced when it is enqueued
+	require.Equal(t, injected, InjectPayload(NewContext(context.Background(), "req-2"), injected))
+
+	notObject := []byte(`"alice"`)
+	require.Equal(t, notObject, InjectPayload(ctx, notObject))
+	require.Empty(t, FromPayload(notObject))
+}
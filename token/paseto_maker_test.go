@@ -13,12 +13,13 @@ func TestPasetoMaker(t *testing.T) {
 	require.NoError(t, err)
 
 	username := util.RandomOwner()
+	role := util.DepositorRole
 	duration := time.Minute
 
 	issuedAt := time.Now()
 	expiredAt := issuedAt.Add(duration)
 
-	token, payload, err := maker.CreateToken(username, duration)
+	token, payload, err := maker.CreateToken(username, role, duration)
 	require.NoError(t, err)
 	require.NotEmpty(t, token)
 	require.NotEmpty(t, payload)
@@ -29,15 +30,35 @@ func TestPasetoMaker(t *testing.T) {
 
 	require.NotZero(t, payload.ID)
 	require.Equal(t, username, payload.Username)
+	require.Equal(t, role, payload.Role)
 	require.WithinDuration(t, issuedAt, payload.IssuedAt, time.Second)
 	require.WithinDuration(t, expiredAt, payload.ExpiredAt, time.Second)
 }
 
+func TestPasetoTokenEmailVerified(t *testing.T) {
+	maker, err := NewPasetoMaker(util.RandomString(32))
+	require.NoError(t, err)
+
+	token, _, err := maker.CreateToken(util.RandomOwner(), util.DepositorRole, time.Minute, WithEmailVerified(true))
+	require.NoError(t, err)
+
+	payload, err := maker.VerifyToken(token)
+	require.NoError(t, err)
+	require.True(t, payload.EmailVerified)
+
+	token, _, err = maker.CreateToken(util.RandomOwner(), util.DepositorRole, time.Minute)
+	require.NoError(t, err)
+
+	payload, err = maker.VerifyToken(token)
+	require.NoError(t, err)
+	require.False(t, payload.EmailVerified)
+}
+
 func TestExpiredPasetoToken(t *testing.T) {
 	maker, err := NewPasetoMaker(util.RandomString(32))
 	require.NoError(t, err)
 
-	token, payload, err := maker.CreateToken(util.RandomOwner(), -time.Minute)
+	token, payload, err := maker.CreateToken(util.RandomOwner(), util.DepositorRole, -time.Minute)
 	require.NoError(t, err)
 	require.NotEmpty(t, token)
 	require.NotEmpty(t, payload)
@@ -58,12 +79,12 @@ func TestInvalidPasetoToken(t *testing.T) {
 	maker2, err := NewPasetoMaker(util.RandomString(32))
 	require.NoError(t, err)
 
-	token1, payload, err := maker1.CreateToken(util.RandomOwner(), time.Minute)
+	token1, payload, err := maker1.CreateToken(util.RandomOwner(), util.DepositorRole, time.Minute)
 	require.NoError(t, err)
 	require.NotEmpty(t, token1)
 	require.NotEmpty(t, payload)
 
-	token2, payload, err := maker2.CreateToken(util.RandomOwner(), time.Minute)
+	token2, payload, err := maker2.CreateToken(util.RandomOwner(), util.DepositorRole, time.Minute)
 	require.NoError(t, err)
 	require.NotEmpty(t, token2)
 	require.NotEmpty(t, payload)
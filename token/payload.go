@@ -17,12 +17,26 @@ var (
 type Payload struct {
 	ID        uuid.UUID `json:"id"`
 	Username 	string 		`json:"username"`
+	Role 			string 		`json:"role"`
+	// EmailVerified is set when the email of the user was verified at the time the token was created
+	EmailVerified bool    `json:"email_verified,omitempty"`
 	IssuedAt 	time.Time `json:"issued_at"`
 	ExpiredAt time.Time `json:"expired_at"`
 }
 
-// NewPayload creates a new token payload with a specific username and duration
-func NewPayload(username string, duration time.Duration) (*Payload, error) {
+// PayloadOption sets an optional claim of the token payload
+type PayloadOption func(payload *Payload)
+
+// WithEmailVerified carries the email verification status of the user in the token,
+// so that it can be checked without looking up the user
+func WithEmailVerified(verified bool) PayloadOption {
+	return func(payload *Payload) {
+		payload.EmailVerified = verified
+	}
+}
+
+// NewPayload creates a new token payload with a specific username, role and duration
+func NewPayload(username string, role string, duration time.Duration, opts ...PayloadOption) (*Payload, error) {
 	tokenID, err := uuid.NewRandom()
 	if err != nil {
 		return nil, err
@@ -31,10 +45,15 @@ func NewPayload(username string, duration time.Duration) (*Payload, error) {
 	payload := &Payload{
 		ID: 			 tokenID,
 		Username:  username,
+		Role:      role,
 		IssuedAt:  time.Now(),
 		ExpiredAt: time.Now().Add(duration),
 	}
 
+	for _, opt := range opts {
+		opt(payload)
+	}
+
 	return payload, err
 }
 
@@ -0,0 +1,51 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// payloadKey is the field of the task payloads that carries the trace context.
+// The payloads are JSON objects, and the handlers ignore the fields they don't know
+const payloadKey = "trace_context"
+
+// InjectPayload adds the trace context of ctx to a JSON object payload, replacing the one it carries.
+// The payload is returned unchanged if ctx isn't part of a trace, or if it isn't a JSON object
+func InjectPayload(ctx context.Context, payload []byte) []byte {
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	if len(carrier) == 0 {
+		return payload
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(payload, &fields); err != nil || fields == nil {
+		return payload
+	}
+	traceContext, err := json.Marshal(carrier)
+	if err != nil {
+		return payload
+	}
+	fields[payloadKey] = traceContext
+
+	injected, err := json.Marshal(fields)
+	if err != nil {
+		return payload
+	}
+	return injected
+}
+
+// ExtractPayload returns ctx with the trace context carried by the payload, if any
+func ExtractPayload(ctx context.Context, payload []byte) context.Context {
+	var fields struct {
+		TraceContext propagation.MapCarrier `json:"trace_context"`
+	}
+	if err := json.Unmarshal(payload, &fields); err != nil || len(fields.TraceContext) == 0 {
+		return ctx
+	}
+
+	return otel.GetTextMapPropagator().Extract(ctx, fields.TraceContext)
+}
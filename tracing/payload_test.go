@@ -0,0 +1,61 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestPayloadTraceContext(t *testing.T) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	provider := sdktrace.NewTracerProvider()
+	ctx, span := provider.Tracer("test").Start(context.Background(), "request")
+	defer span.End()
+
+	payload := []byte(`{"username":"alice","amount":10}`)
+	injected := InjectPayload(ctx, payload)
+	require.NotEqual(t, payload, injected)
+
+	var fields map[string]any
+	require.NoError(t, json.Unmarshal(injected, &fields))
+	require.Equal(t, "alice", fields["username"])
+	require.Equal(t, float64(10), fields["amount"])
+	require.Contains(t, fields, payloadKey)
+
+	extracted := trace.SpanContextFromContext(ExtractPayload(context.Background(), injected))
+	require.True(t, extracted.IsRemote())
+	require.Equal(t, span.SpanContext().TraceID(), extracted.TraceID())
+	require.Equal(t, span.SpanContext().SpanID(), extracted.SpanID())
+
+	// the trace context of a later span replaces the one of the payload
+	childCtx, child := provider.Tracer("test").Start(ctx, "enqueue")
+	defer child.End()
+
+	reinjected := InjectPayload(childCtx, injected)
+	extracted = trace.SpanContextFromContext(ExtractPayload(context.Background(), reinjected))
+	require.Equal(t, child.SpanContext().SpanID(), extracted.SpanID())
+}
+
+func TestPayloadWithoutTrace(t *testing.T) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	payload := []byte(`{"username":"alice"}`)
+	require.Equal(t, payload, InjectPayload(context.Background(), payload))
+
+	ctx := ExtractPayload(context.Background(), payload)
+	require.False(t, trace.SpanContextFromContext(ctx).IsValid())
+
+	provider := sdktrace.NewTracerProvider()
+	ctx, span := provider.Tracer("test").Start(context.Background(), "request")
+	defer span.End()
+
+	notObject := []byte(`"alice"`)
+	require.Equal(t, notObject, InjectPayload(ctx, notObject))
+}
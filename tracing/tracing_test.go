@@ -0,0 +1,23 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestPublicPropagator(t *testing.T) {
+	carrier := propagation.MapCarrier{
+		"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+	}
+
+	// the trusted propagator reads the sampled flag of the caller
+	ctx := propagation.TraceContext{}.Extract(context.Background(), carrier)
+	require.True(t, trace.SpanContextFromContext(ctx).IsSampled())
+
+	ctx = PublicPropagator().Extract(context.Background(), carrier)
+	require.False(t, trace.SpanContextFromContext(ctx).IsValid())
+}
@@ -0,0 +1,26 @@
+package util
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+const apiKeySize = 32
+
+// NewAPIKey generates a new random API key
+func NewAPIKey() (string, error) {
+	key := make([]byte, apiKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return "", fmt.Errorf("failed to generate api key: %w", err)
+	}
+	return hex.EncodeToString(key), nil
+}
+
+// HashAPIKey returns the SHA-256 hash of the API key.
+// API keys are long random strings, so unlike passwords they don't need a slow hash
+func HashAPIKey(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
@@ -1,40 +1,350 @@
 package util
 
 import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/mitchellh/mapstructure"
 	"github.com/spf13/viper"
 )
 
 // Config stores all configuration of the application.
-// The values are read by viper from a config file or environment variables
+// The values are read by viper from the sources described in LoadConfig.
+//
+// The tags of the fields describe how they are loaded:
+//   - default is the value of the field when no source sets it
+//   - validate holds the rules checked by Validate
+//   - secret fields are redacted when the config is printed, and can be read from the file named by <KEY>_FILE.
+//     The "url" secrets only have the password of the URL redacted
+//   - reload fields are updated by the ConfigReloader while the servers run, the others need a restart
 type Config struct {
-	Environment          string 			 `mapstructure:"ENVIRONMENT"` 
-	DBSource 					 	 string 			 `mapstructure:"DB_SOURCE"`
-	MigrationURL 				 string 			 `mapstructure:"MIGRATION_URL"`
-	RedisAddress         string 			 `mapstructure:"REDIS_ADDRESS"`
-	HTTPServerAddress 	 string 			 `mapstructure:"HTTP_SERVER_ADDRESS"`
-	GRPCServerAddress 	 string 			 `mapstructure:"GRPC_SERVER_ADDRESS"`
-	TokenSymmetricKey 	 string 			 `mapstructure:"TOKEN_SYMMETRIC_KEY"`
-	AccessTokenDuration  time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
-	RefreshTokenDuration time.Duration `mapstructure:"REFRESH_TOKEN_DURATION"`
-	EmailSenderName    	 string 			 `mapstructure:"EMAIL_SENDER_NAME"`
-	EmailSenderAddress   string 			 `mapstructure:"EMAIL_SENDER_ADDRESS"`
-	EmailSenderPassword  string 			 `mapstructure:"EMAIL_SENDER_PASSWORD"`
-}
-
-// LoadConfig reads configuration from file or environment variables
-func LoadConfig(path string) (config Config, err error) {
-	viper.AddConfigPath(path)
-	viper.SetConfigName("app")
-	viper.SetConfigType("env")
-
-	viper.AutomaticEnv()
-	err = viper.ReadInConfig()
-	if err != nil {
-		return
-	}
-
-	err = viper.Unmarshal(&config)
-	return
+	Environment                   string        `mapstructure:"ENVIRONMENT" default:"production" validate:"required"`
+	DBSource                      string        `mapstructure:"DB_SOURCE" default:"" validate:"required,url" secret:"url"`
+	RedisAddress                  string        `mapstructure:"REDIS_ADDRESS" default:"0.0.0.0:6379" validate:"required,hostname_port"`
+	TaskQueueBackend              string        `mapstructure:"TASK_QUEUE_BACKEND" default:"redis" validate:"oneof=redis memory"`
+	HTTPServerAddress             string        `mapstructure:"HTTP_SERVER_ADDRESS" default:"0.0.0.0:8080" validate:"required,hostname_port"`
+	GRPCServerAddress             string        `mapstructure:"GRPC_SERVER_ADDRESS" default:"0.0.0.0:9090" validate:"required,hostname_port"`
+	MetricsServerAddress          string        `mapstructure:"METRICS_SERVER_ADDRESS" default:"0.0.0.0:9100" validate:"required,hostname_port"`
+	MetricsCacheDuration          time.Duration `mapstructure:"METRICS_CACHE_DURATION" default:"1m" validate:"gte=0"`
+	TokenSymmetricKey             string        `mapstructure:"TOKEN_SYMMETRIC_KEY" default:"" validate:"required,len=32" secret:"true"`
+	AccessTokenDuration           time.Duration `mapstructure:"ACCESS_TOKEN_DURATION" default:"15m" validate:"gt=0" reload:"true"`
+	RefreshTokenDuration          time.Duration `mapstructure:"REFRESH_TOKEN_DURATION" default:"24h" validate:"gtefield=AccessTokenDuration" reload:"true"`
+	EmailSenderName               string        `mapstructure:"EMAIL_SENDER_NAME" default:"Simple Bank" validate:"required"`
+	EmailSenderAddress            string        `mapstructure:"EMAIL_SENDER_ADDRESS" default:"" validate:"required,email"`
+	EmailSenderPassword           string        `mapstructure:"EMAIL_SENDER_PASSWORD" default:"" secret:"true"`
+	EmailBackend                  string        `mapstructure:"EMAIL_BACKEND" default:"smtp" validate:"oneof=smtp file"`
+	EmailFileDir                  string        `mapstructure:"EMAIL_FILE_DIR" default:"tmp/mail" validate:"required_if=EmailBackend file"`
+	SMTPHost                      string        `mapstructure:"SMTP_HOST" default:"smtp.gmail.com" validate:"required_if=EmailBackend smtp"`
+	SMTPPort                      int           `mapstructure:"SMTP_PORT" default:"587" validate:"min=1,max=65535"`
+	SMTPUsername                  string        `mapstructure:"SMTP_USERNAME" default:""`
+	SMTPAuth                      string        `mapstructure:"SMTP_AUTH" default:"plain" validate:"oneof=plain login cram-md5 none"`
+	SMTPTLS                       string        `mapstructure:"SMTP_TLS" default:"starttls" validate:"oneof=starttls tls none"`
+	SMTPTimeout                   time.Duration `mapstructure:"SMTP_TIMEOUT" default:"30s" validate:"gt=0"`
+	BeneficiaryCoolDown           time.Duration `mapstructure:"BENEFICIARY_COOL_DOWN" default:"1h" validate:"gte=0"`
+	BaseURL                       string        `mapstructure:"BASE_URL" default:"http://localhost:8080" validate:"required,url"`
+	OutboxRelayInterval           time.Duration `mapstructure:"OUTBOX_RELAY_INTERVAL" default:"1s" validate:"gt=0"`
+	AuditChainInterval            time.Duration `mapstructure:"AUDIT_CHAIN_INTERVAL" default:"1s" validate:"gt=0"`
+	VerifyEmailResendInterval     time.Duration `mapstructure:"VERIFY_EMAIL_RESEND_INTERVAL" default:"1m" validate:"gte=0" reload:"true"`
+	VerifyEmailHourlyLimit        int           `mapstructure:"VERIFY_EMAIL_HOURLY_LIMIT" default:"5" validate:"gte=0" reload:"true"`
+	RequireVerifiedEmail          bool          `mapstructure:"REQUIRE_VERIFIED_EMAIL" default:"true"`
+	TrustTokenEmailVerified       bool          `mapstructure:"TRUST_TOKEN_EMAIL_VERIFIED" default:"true"`
+	SchedulerInterval             time.Duration `mapstructure:"SCHEDULER_INTERVAL" default:"30s" validate:"gt=0"`
+	ScheduleCleanupSessions       string        `mapstructure:"SCHEDULE_CLEANUP_SESSIONS" default:"0 * * * *" validate:"omitempty,cron"`
+	SchedulePurgeVerifyEmails     string        `mapstructure:"SCHEDULE_PURGE_VERIFY_EMAILS" default:"30 3 * * *" validate:"omitempty,cron"`
+	ScheduleExpirePaymentRequests string        `mapstructure:"SCHEDULE_EXPIRE_PAYMENT_REQUESTS" default:"*/5 * * * *" validate:"omitempty,cron"`
+	ScheduleReconcileAccounts     string        `mapstructure:"SCHEDULE_RECONCILE_ACCOUNTS" default:"0 2 * * *" validate:"omitempty,cron"`
+	ScheduleVerifyAuditLog        string        `mapstructure:"SCHEDULE_VERIFY_AUDIT_LOG" default:"30 2 * * *" validate:"omitempty,cron"`
+	ShutdownTimeout               time.Duration `mapstructure:"SHUTDOWN_TIMEOUT" default:"20s" validate:"gt=0"`
+	ShutdownDrainDelay            time.Duration `mapstructure:"SHUTDOWN_DRAIN_DELAY" default:"15s" validate:"gte=0"`
+	HealthCheckInterval           time.Duration `mapstructure:"HEALTH_CHECK_INTERVAL" default:"5s" validate:"gt=0"`
+	HealthCheckTimeout            time.Duration `mapstructure:"HEALTH_CHECK_TIMEOUT" default:"2s" validate:"gt=0"`
+	TLSCertFile                   string        `mapstructure:"TLS_CERT_FILE" default:"" validate:"required_with=TLSKeyFile"`
+	TLSKeyFile                    string        `mapstructure:"TLS_KEY_FILE" default:"" validate:"required_with=TLSCertFile"`
+	TLSClientCAFile               string        `mapstructure:"TLS_CLIENT_CA_FILE" default:"" validate:"required_unless=TLSClientAuth none"`
+	TLSClientAuth                 string        `mapstructure:"TLS_CLIENT_AUTH" default:"none" validate:"oneof=none optional require"`
+	TLSReloadInterval             time.Duration `mapstructure:"TLS_RELOAD_INTERVAL" default:"1m" validate:"gt=0"`
+	OperatorServices              []string      `mapstructure:"OPERATOR_SERVICES" default:"" validate:"dive,required" reload:"true"`
+	TrustedProxies                []string      `mapstructure:"TRUSTED_PROXIES" default:"" validate:"dive,cidr|ip"`
+	RateLimitBackend              string        `mapstructure:"RATE_LIMIT_BACKEND" default:"memory" validate:"oneof=memory redis"`
+	RateLimitPolicies             []string      `mapstructure:"RATE_LIMIT_POLICIES" default:"LoginUser=ip:5/m:10,CreateUser=ip:5/m,VerifyEmail=ip:10/m,ResendVerifyEmail=user:5/h,*=ip:50/s:100,*=user:20/s:40,*=api_key:50/s:100" validate:"dive,required" reload:"true"`
+	TracingExporter               string        `mapstructure:"TRACING_EXPORTER" default:"none" validate:"oneof=none stdout file otlp"`
+	TracingFilePath               string        `mapstructure:"TRACING_FILE_PATH" default:"tmp/traces.json" validate:"required_if=TracingExporter file"`
+	TracingOTLPEndpoint           string        `mapstructure:"TRACING_OTLP_ENDPOINT" default:"localhost:4317" validate:"required_if=TracingExporter otlp"`
+	TracingOTLPInsecure           bool          `mapstructure:"TRACING_OTLP_INSECURE" default:"false"`
+	TracingOTLPCAFile             string        `mapstructure:"TRACING_OTLP_CA_FILE" default:""`
+	TracingSampleRatio            float64       `mapstructure:"TRACING_SAMPLE_RATIO" default:"1" validate:"min=0,max=1"`
+	ConfigReloadInterval          time.Duration `mapstructure:"CONFIG_RELOAD_INTERVAL" default:"30s" validate:"gte=0"`
+}
+
+// configName is the name of the config files, app.env and app.yaml
+const configName = "app"
+
+// secretFileSuffix is appended to the key of a secret to name the file the secret is read from
+const secretFileSuffix = "_FILE"
+
+// ConfigLoader reads the config from its sources. From the lowest to the highest precedence, they are:
+//  1. the default tags of the fields
+//  2. the app.env file of Path
+//  3. the app.yaml file of Path
+//  4. File, a .env or .yaml file given with the -config flag
+//  5. the environment variables
+//  6. the files named by the <KEY>_FILE values of the secrets, such as Kubernetes secret mounts
+//  7. Overrides, the values given with the -set flags
+//
+// All the files are optional, except File when it is set.
+// The YAML files use the same flat keys as app.env, in upper or lower case
+type ConfigLoader struct {
+	Path      string
+	File      string
+	Overrides map[string]string
+}
+
+// LoadConfig reads configuration from the files of the path and environment variables, and validates it
+func LoadConfig(path string) (Config, error) {
+	return ConfigLoader{Path: path}.Load()
+}
+
+// Load reads the config from the sources and validates it.
+// All the problems of the config are reported at once by a ConfigError
+func (loader ConfigLoader) Load() (Config, error) {
+	config, _, err := loader.load()
+	return config, err
+}
+
+// files returns the config files in the order they are read, they may not exist
+func (loader ConfigLoader) files() []string {
+	files := []string{
+		filepath.Join(loader.Path, configName+".env"),
+		filepath.Join(loader.Path, configName+".yaml"),
+	}
+	if loader.File != "" {
+		files = append(files, loader.File)
+	}
+	return files
+}
+
+// load also returns the files the config is read from, including the missing config files
+// and the secret files, so that the reloader can watch them
+func (loader ConfigLoader) load() (Config, []string, error) {
+	v := viper.New()
+	for _, field := range configFields() {
+		v.SetDefault(field.key, field.defaultValue)
+		if field.secret != "" {
+			v.SetDefault(field.key+secretFileSuffix, "")
+		}
+	}
+	v.AutomaticEnv()
+
+	files := loader.files()
+	for _, file := range files {
+		if _, err := os.Stat(file); errors.Is(err, fs.ErrNotExist) && file != loader.File {
+			continue
+		}
+
+		v.SetConfigFile(file)
+		if err := v.MergeInConfig(); err != nil {
+			return Config{}, nil, fmt.Errorf("cannot read config file %s: %w", file, err)
+		}
+	}
+
+	var config Config
+	var problems []string
+
+	keys := make([]string, 0, len(loader.Overrides))
+	for key := range loader.Overrides {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := loader.Overrides[key]
+		if !isConfigKey(key) {
+			problems = append(problems, fmt.Sprintf("%s: unknown key", key))
+			continue
+		}
+		v.Set(key, value)
+	}
+
+	// a value that can't be decoded is reported, and the field is left with its zero value
+	var decodeErr *mapstructure.Error
+	err := v.Unmarshal(&config)
+	if errors.As(err, &decodeErr) {
+		for _, problem := range decodeErr.Errors {
+			problems = append(problems, decodeProblem(problem))
+		}
+	} else if err != nil {
+		return Config{}, nil, fmt.Errorf("cannot decode config: %w", err)
+	}
+
+	secretFiles, secretProblems := loader.readSecretFiles(v, &config)
+	files = append(files, secretFiles...)
+	problems = append(problems, secretProblems...)
+
+	for _, problem := range validateConfig(config) {
+		if !hasProblemOf(problems, problem) {
+			problems = append(problems, problem)
+		}
+	}
+
+	if len(problems) > 0 {
+		return config, files, &ConfigError{Problems: problems}
+	}
+	return config, files, nil
+}
+
+// readSecretFiles replaces the secrets that have a <KEY>_FILE value with the content of the file.
+// The file wins over the value of the key, unless the key is overridden by a flag
+func (loader ConfigLoader) readSecretFiles(v *viper.Viper, config *Config) ([]string, []string) {
+	var files []string
+	var problems []string
+
+	value := reflect.ValueOf(config).Elem()
+	for _, field := range configFields() {
+		file := v.GetString(field.key + secretFileSuffix)
+		if field.secret == "" || file == "" {
+			continue
+		}
+		if _, ok := loader.Overrides[field.key]; ok {
+			continue
+		}
+
+		files = append(files, file)
+		data, err := os.ReadFile(file)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s%s: cannot read secret: %s", field.key, secretFileSuffix, err))
+			continue
+		}
+
+		// the editors and the secret stores usually end the files with a newline
+		value.Field(field.index).SetString(strings.TrimRight(string(data), "\r\n"))
+	}
+
+	return files, problems
+}
+
+// isConfigKey returns true if the key is the key of a field, or the <KEY>_FILE key of a secret
+func isConfigKey(key string) bool {
+	for _, field := range configFields() {
+		if key == field.key || (field.secret != "" && key == field.key+secretFileSuffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeProblem formats the errors of mapstructure like the other problems, "error decoding 'KEY': ..." becomes "KEY: ..."
+func decodeProblem(problem string) string {
+	rest, ok := strings.CutPrefix(problem, "error decoding '")
+	if !ok {
+		return problem
+	}
+
+	key, reason, ok := strings.Cut(rest, "': ")
+	if !ok {
+		return problem
+	}
+	return fmt.Sprintf("%s: %s", key, reason)
+}
+
+// hasProblemOf returns true if a problem is already reported for the key of the problem,
+// such as the decoding error of a value that is then zero
+func hasProblemOf(problems []string, problem string) bool {
+	key, _, _ := strings.Cut(problem, ":")
+	for _, reported := range problems {
+		if strings.HasPrefix(reported, key+":") {
+			return true
+		}
+	}
+	return false
+}
+
+// ConfigError lists all the problems of the config, so they can be fixed at once
+type ConfigError struct {
+	Problems []string
+}
+
+func (err *ConfigError) Error() string {
+	return fmt.Sprintf("invalid config:\n  %s", strings.Join(err.Problems, "\n  "))
+}
+
+// configField is a field of Config with the values of its tags
+type configField struct {
+	index        int
+	name         string
+	key          string
+	defaultValue string
+	secret       string
+	reload       bool
+}
+
+func configFields() []configField {
+	fields := reflect.TypeOf(Config{})
+
+	result := make([]configField, 0, fields.NumField())
+	for i := 0; i < fields.NumField(); i++ {
+		field := fields.Field(i)
+		result = append(result, configField{
+			index:        i,
+			name:         field.Name,
+			key:          field.Tag.Get("mapstructure"),
+			defaultValue: field.Tag.Get("default"),
+			secret:       field.Tag.Get("secret"),
+			reload:       field.Tag.Get("reload") == "true",
+		})
+	}
+	return result
+}
+
+// redactedValue replaces the secrets in the printed config
+const redactedValue = "REDACTED"
+
+// Redacted returns the config as KEY=value lines sorted by key, with the secrets redacted
+func (config Config) Redacted() []string {
+	value := reflect.ValueOf(config)
+
+	lines := make([]string, 0, value.NumField())
+	for _, field := range configFields() {
+		lines = append(lines, fmt.Sprintf("%s=%s", field.key, redactConfigValue(field.secret, value.Field(field.index).Interface())))
+	}
+
+	sort.Strings(lines)
+	return lines
+}
+
+func formatConfigValue(value any) string {
+	switch value := value.(type) {
+	case []string:
+		return strings.Join(value, ",")
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+func redactConfigValue(secret string, value any) string {
+	text := formatConfigValue(value)
+
+	switch {
+	case text == "":
+		return text
+	case secret == "url":
+		u, err := url.Parse(text)
+		if err != nil {
+			return redactedValue
+		}
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), redactedValue)
+		}
+		return u.String()
+	case secret != "":
+		return redactedValue
+	}
+	return text
 }
@@ -0,0 +1,183 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// LiveConfig holds the config of the running servers, whose reload fields can be changed
+// without a restart. The other fields keep the values the servers are started with
+type LiveConfig struct {
+	config atomic.Pointer[Config]
+
+	mutex     sync.Mutex
+	listeners []func(Config)
+}
+
+// NewLiveConfig creates a live config with the values of the config
+func NewLiveConfig(config Config) *LiveConfig {
+	live := &LiveConfig{}
+	live.config.Store(&config)
+	return live
+}
+
+// Get returns the current config
+func (live *LiveConfig) Get() Config {
+	return *live.config.Load()
+}
+
+// OnChange registers a listener called with the new config after each update,
+// for the values that are copied when the servers start
+func (live *LiveConfig) OnChange(listener func(Config)) {
+	live.mutex.Lock()
+	defer live.mutex.Unlock()
+
+	live.listeners = append(live.listeners, listener)
+}
+
+// Update applies the changed reload fields of the config, and returns their keys.
+// It also returns the keys of the changed fields that are ignored because they need a restart
+func (live *LiveConfig) Update(config Config) (reloaded []string, ignored []string) {
+	live.mutex.Lock()
+	defer live.mutex.Unlock()
+
+	current := live.Get()
+	next := current
+
+	currentValue := reflect.ValueOf(current)
+	newValue := reflect.ValueOf(config)
+	nextValue := reflect.ValueOf(&next).Elem()
+	for _, field := range configFields() {
+		value := newValue.Field(field.index)
+		if reflect.DeepEqual(currentValue.Field(field.index).Interface(), value.Interface()) {
+			continue
+		}
+
+		if !field.reload {
+			ignored = append(ignored, field.key)
+			continue
+		}
+
+		nextValue.Field(field.index).Set(value)
+		reloaded = append(reloaded, field.key)
+	}
+
+	if len(reloaded) == 0 {
+		return reloaded, ignored
+	}
+
+	live.config.Store(&next)
+	for _, listener := range live.listeners {
+		listener(next)
+	}
+	return reloaded, ignored
+}
+
+// ConfigReloader loads the config again when its files change, and updates the live config.
+// The environment variables and the flags can't change while the process runs, so only the files are watched
+type ConfigReloader struct {
+	loader ConfigLoader
+	live   *LiveConfig
+
+	mutex    sync.Mutex
+	files    []string
+	modTimes map[string]time.Time
+}
+
+// NewConfigReloader creates a reloader of the live config, which is loaded by the loader
+func NewConfigReloader(loader ConfigLoader, live *LiveConfig) (*ConfigReloader, error) {
+	reloader := &ConfigReloader{
+		loader: loader,
+		live:   live,
+		files:  loader.files(),
+	}
+
+	if _, err := reloader.Reload(); err != nil {
+		return nil, err
+	}
+	return reloader, nil
+}
+
+// Reload loads the config again if one of its files changed since it was loaded,
+// and returns the keys of the reloaded fields. The previous config is kept if the new one is invalid
+func (reloader *ConfigReloader) Reload() ([]string, error) {
+	reloader.mutex.Lock()
+	defer reloader.mutex.Unlock()
+
+	modTimes, err := configModTimes(reloader.files)
+	if err != nil {
+		return nil, err
+	}
+	if reloader.modTimes != nil && reflect.DeepEqual(reloader.modTimes, modTimes) {
+		return nil, nil
+	}
+
+	config, files, err := reloader.loader.load()
+	if err != nil {
+		return nil, err
+	}
+
+	// the secret files may change with the config files
+	modTimes, err = configModTimes(files)
+	if err != nil {
+		return nil, err
+	}
+
+	reloaded, ignored := reloader.live.Update(config)
+	if len(ignored) > 0 {
+		log.Warn().Strs("keys", ignored).Msg("config changes are ignored until the servers restart")
+	}
+
+	reloader.files = files
+	reloader.modTimes = modTimes
+	return reloaded, nil
+}
+
+// configModTimes returns the modification times of the files, zero for the missing ones
+func configModTimes(files []string) (map[string]time.Time, error) {
+	modTimes := make(map[string]time.Time, len(files))
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if errors.Is(err, fs.ErrNotExist) {
+			modTimes[file] = time.Time{}
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot read %s: %w", file, err)
+		}
+		modTimes[file] = info.ModTime()
+	}
+	return modTimes, nil
+}
+
+// Run checks the files at every interval until the context is canceled
+func (reloader *ConfigReloader) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		reloaded, err := reloader.Reload()
+		if err != nil {
+			log.Error().Err(err).Msg("failed to reload config, the previous one is still used")
+			continue
+		}
+		if len(reloaded) > 0 {
+			log.Info().Strs("keys", reloaded).Msg("config is reloaded")
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/robfig/cron/v3"
+)
+
+// configValidate checks the validate tags of the config fields
+var configValidate = newConfigValidate()
+
+func newConfigValidate() *validator.Validate {
+	validate := validator.New()
+
+	// the errors name the fields by their keys
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		return field.Tag.Get("mapstructure")
+	})
+
+	// the schedules of the maintenance jobs are parsed like the scheduler does
+	validate.RegisterValidation("cron", func(field validator.FieldLevel) bool {
+		_, err := cron.ParseStandard(field.Field().String())
+		return err == nil
+	})
+
+	return validate
+}
+
+// Validate checks the values of the config, and returns a ConfigError with all the problems found
+func (config Config) Validate() error {
+	problems := validateConfig(config)
+	if len(problems) > 0 {
+		return &ConfigError{Problems: problems}
+	}
+	return nil
+}
+
+func validateConfig(config Config) []string {
+	err := configValidate.Struct(config)
+
+	var fieldErrs validator.ValidationErrors
+	if !errors.As(err, &fieldErrs) {
+		if err != nil {
+			return []string{err.Error()}
+		}
+		return nil
+	}
+
+	problems := make([]string, 0, len(fieldErrs))
+	for _, fieldErr := range fieldErrs {
+		problems = append(problems, fmt.Sprintf("%s: %s", fieldErr.Field(), configProblem(fieldErr)))
+	}
+	return problems
+}
+
+// configProblem describes the rule that a value breaks
+func configProblem(fieldErr validator.FieldError) string {
+	param := fieldErr.Param()
+
+	switch fieldErr.Tag() {
+	case "required":
+		return "is required"
+	case "required_if", "required_unless":
+		name, value, _ := strings.Cut(param, " ")
+		condition := "is"
+		if fieldErr.Tag() == "required_unless" {
+			condition = "isn't"
+		}
+		return fmt.Sprintf("is required when %s %s %s", configKey(name), condition, value)
+	case "required_with":
+		return fmt.Sprintf("is required with %s", configKey(param))
+	case "oneof":
+		return fmt.Sprintf("must be one of %s, got %q", strings.ReplaceAll(param, " ", ", "), fieldErr.Value())
+	case "len":
+		return fmt.Sprintf("must be exactly %s characters", param)
+	case "gt":
+		return fmt.Sprintf("must be greater than %s", param)
+	case "gte", "min":
+		return fmt.Sprintf("must be at least %s", param)
+	case "lte", "max":
+		return fmt.Sprintf("must be at most %s", param)
+	case "gtefield":
+		return fmt.Sprintf("must be at least %s", configKey(param))
+	case "url":
+		return "must be a URL"
+	case "email":
+		return "must be an email address"
+	case "hostname_port":
+		return "must be a host:port address"
+	case "cron":
+		return "must be a cron expression"
+	}
+	return fmt.Sprintf("breaks the %s rule", fieldErr.Tag())
+}
+
+// configKey returns the key of the config field with the name
+func configKey(name string) string {
+	if field, ok := reflect.TypeOf(Config{}).FieldByName(name); ok {
+		return field.Tag.Get("mapstructure")
+	}
+	return name
+}
@@ -0,0 +1,16 @@
+package util
+
+// Constants for all supported user languages
+const (
+	English            = "en"
+	TraditionalChinese = "zh-TW"
+)
+
+// IsSupportedLanguage returns true if the language is supported
+func IsSupportedLanguage(language string) bool {
+	switch language {
+	case English, TraditionalChinese:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,40 @@
+package util
+
+// Constants for all events that users can be notified about
+const (
+	NotificationEventDebit  = "debit"
+	NotificationEventCredit = "credit"
+)
+
+// Constants for all channels that notifications are sent through
+const (
+	NotificationChannelEmail = "email"
+)
+
+// NotificationEvents returns all notification events
+func NotificationEvents() []string {
+	return []string{NotificationEventCredit, NotificationEventDebit}
+}
+
+// NotificationChannels returns all notification channels
+func NotificationChannels() []string {
+	return []string{NotificationChannelEmail}
+}
+
+// IsSupportedNotificationEvent returns true if the notification event is supported
+func IsSupportedNotificationEvent(event string) bool {
+	switch event {
+	case NotificationEventDebit, NotificationEventCredit:
+		return true
+	}
+	return false
+}
+
+// IsSupportedNotificationChannel returns true if the notification channel is supported
+func IsSupportedNotificationChannel(channel string) bool {
+	switch channel {
+	case NotificationChannelEmail:
+		return true
+	}
+	return false
+}
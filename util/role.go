@@ -0,0 +1,23 @@
+package util
+
+// Constants for all user roles
+const (
+	DepositorRole = "depositor"
+	BankerRole    = "banker"
+)
+
+// Constants for all account member roles
+const (
+	AccountOwnerRole       = "owner"
+	AccountCanTransferRole = "can_transfer"
+	AccountViewOnlyRole    = "view_only"
+)
+
+// IsSupportedAccountRole returns true if the account member role is supported
+func IsSupportedAccountRole(role string) bool {
+	switch role {
+	case AccountOwnerRole, AccountCanTransferRole, AccountViewOnlyRole:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,42 @@
+package util
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+)
+
+const webhookSecretSize = 32
+
+// Constants for all events that webhook endpoints can subscribe to
+const (
+	WebhookEventTransferCreated = "transfer.created"
+	WebhookEventAccountUpdated  = "account.updated"
+	WebhookEventUserVerified    = "user.verified"
+)
+
+// APIKeyScopeWebhooks lets the webhook endpoints of an API key receive the events of every user
+const APIKeyScopeWebhooks = "webhooks"
+
+// WebhookEvents returns all webhook events
+func WebhookEvents() []string {
+	return []string{WebhookEventAccountUpdated, WebhookEventTransferCreated, WebhookEventUserVerified}
+}
+
+// IsSupportedWebhookEvent returns true if the webhook event is supported
+func IsSupportedWebhookEvent(event string) bool {
+	switch event {
+	case WebhookEventTransferCreated, WebhookEventAccountUpdated, WebhookEventUserVerified:
+		return true
+	}
+	return false
+}
+
+// NewWebhookSecret generates a new random secret to sign the payloads sent to a webhook endpoint
+func NewWebhookSecret() (string, error) {
+	secret := make([]byte, webhookSecretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return "whsec_" + hex.EncodeToString(secret), nil
+}
@@ -3,12 +3,17 @@ package val
 import (
 	"fmt"
 	"net/mail"
+	"net/url"
 	"regexp"
+
+	"github.com/juker1141/simplebank/util"
+	"github.com/juker1141/simplebank/webhook"
 )
 
 var (
 	isValidUsername = regexp.MustCompile(`^[a-z0-9_]+$`).MatchString
 	isValidFullName = regexp.MustCompile(`^[a-zA-Z\s]+$`).MatchString
+	isValidNickname = regexp.MustCompile(`^[a-zA-Z0-9_\-\s]+$`).MatchString
 )
 
 func ValidateString(value string, minLength int, maxLength int) error {
@@ -62,4 +67,122 @@ func ValidateEmailId(value int64) error {
 
 func ValidateSecretCode(value string) error {
 	return ValidateString(value, 32, 128)
-}
\ No newline at end of file
+}
+
+func ValidateAccountId(value int64) error {
+	if value <= 0 {
+		return fmt.Errorf("must be a positive integer")
+	}
+	return nil
+}
+
+func ValidateAmount(value int64) error {
+	if value <= 0 {
+		return fmt.Errorf("must be a positive integer")
+	}
+	return nil
+}
+
+func ValidateCurrency(value string) error {
+	if !util.IsSupportCurrency(value) {
+		return fmt.Errorf("is not a supported currency")
+	}
+	return nil
+}
+
+func ValidateExternalReference(value string) error {
+	return ValidateString(value, 1, 100)
+}
+
+func ValidateNickname(value string) error {
+	if err := ValidateString(value, 1, 50); err != nil {
+		return err
+	}
+	if !isValidNickname(value) {
+		return fmt.Errorf("must contain only letters, digits, spaces, dashes or underscores")
+	}
+	return nil
+}
+
+func ValidateId(value int64) error {
+	if value <= 0 {
+		return fmt.Errorf("must be a positive integer")
+	}
+	return nil
+}
+
+func ValidatePageId(value int32) error {
+	if value < 1 {
+		return fmt.Errorf("must be at least 1")
+	}
+	return nil
+}
+
+func ValidatePageSize(value int32) error {
+	if value < 5 || value > 10 {
+		return fmt.Errorf("must be from 5 to 10")
+	}
+	return nil
+}
+
+func ValidateAccountRole(value string) error {
+	if !util.IsSupportedAccountRole(value) {
+		return fmt.Errorf("is not a supported account role")
+	}
+	return nil
+}
+
+func ValidateMemo(value string) error {
+	return ValidateString(value, 0, 200)
+}
+
+func ValidateLanguage(value string) error {
+	if !util.IsSupportedLanguage(value) {
+		return fmt.Errorf("is not a supported language")
+	}
+	return nil
+}
+
+func ValidateNotificationEvent(value string) error {
+	if !util.IsSupportedNotificationEvent(value) {
+		return fmt.Errorf("is not a supported notification event")
+	}
+	return nil
+}
+
+func ValidateNotificationChannel(value string) error {
+	if !util.IsSupportedNotificationChannel(value) {
+		return fmt.Errorf("is not a supported notification channel")
+	}
+	return nil
+}
+
+func ValidateMinAmount(value int64) error {
+	if value < 0 {
+		return fmt.Errorf("must not be negative")
+	}
+	return nil
+}
+
+func ValidateWebhookEvent(value string) error {
+	if !util.IsSupportedWebhookEvent(value) {
+		return fmt.Errorf("is not a supported webhook event")
+	}
+	return nil
+}
+
+func ValidateWebhookURL(value string) error {
+	if err := ValidateString(value, 1, 2048); err != nil {
+		return err
+	}
+
+	u, err := url.Parse(value)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return fmt.Errorf("must be an absolute http or https url")
+	}
+
+	if err := webhook.CheckHost(u.Hostname()); err != nil {
+		return fmt.Errorf("must not point to a loopback, private or link-local address")
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+const defaultTimeout = 10 * time.Second
+
+// maxResponseSize is how much of the response body is read, so the connection can be reused
+const maxResponseSize = 64 << 10
+
+// Request is a signed webhook request to an endpoint
+type Request struct {
+	URL       string
+	Secret    string
+	ID        string
+	Event     string
+	Body      []byte
+	Timestamp time.Time
+}
+
+// Response is the result of a delivery attempt
+type Response struct {
+	StatusCode int
+	Duration   time.Duration
+}
+
+// Success returns true if the endpoint accepted the webhook with a 2xx status
+func (rsp Response) Success() bool {
+	return rsp.StatusCode >= 200 && rsp.StatusCode < 300
+}
+
+// Client posts signed webhook requests
+type Client struct {
+	http            *http.Client
+	allowedNetworks []netip.Prefix
+}
+
+// NewClient creates a webhook client, requests time out after the timeout, 10 seconds by default.
+// The endpoints can't be reached on a loopback, private or link-local address,
+// unless it is in one of the allowed networks
+func NewClient(timeout time.Duration, allowedNetworks ...netip.Prefix) *Client {
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+
+	client := &Client{
+		allowedNetworks: allowedNetworks,
+	}
+
+	// the address is checked when it is dialed, after the name is resolved,
+	// so a name can't be changed to resolve to a forbidden address once the endpoint is saved
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: client.checkDialedAddress,
+	}
+
+	client.http = &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			// a proxy would dial the endpoint itself, without the check of the address
+			Proxy: nil,
+			DialContext: dialer.DialContext,
+			ForceAttemptHTTP2: true,
+			MaxIdleConns: 100,
+			IdleConnTimeout: 90 * time.Second,
+			TLSHandshakeTimeout: timeout,
+		},
+		// a redirect would send the signed payload to another url than the registered one
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	return client
+}
+
+func (client *Client) checkDialedAddress(network string, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("invalid webhook address %s: %w", address, err)
+	}
+
+	addr := addrPort.Addr().Unmap()
+	for _, allowed := range client.allowedNetworks {
+		if allowed.Contains(addr) {
+			return nil
+		}
+	}
+	return CheckAddr(addr)
+}
+
+// Send posts the request to the endpoint.
+// It only returns an error if no response was received, a non-2xx response isn't an error
+func (client *Client) Send(ctx context.Context, req Request) (Response, error) {
+	timestamp := req.Timestamp
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, req.URL, bytes.NewReader(req.Body))
+	if err != nil {
+		return Response{}, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("User-Agent", "SimpleBank-Webhook/1.0")
+	httpReq.Header.Set(HeaderID, req.ID)
+	httpReq.Header.Set(HeaderEvent, req.Event)
+	httpReq.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp.Unix(), 10))
+	httpReq.Header.Set(HeaderSignature, Sign(req.Secret, timestamp, req.Body))
+
+	start := time.Now()
+	httpRsp, err := client.http.Do(httpReq)
+	if err != nil {
+		return Response{Duration: time.Since(start)}, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer httpRsp.Body.Close()
+
+	_, _ = io.Copy(io.Discard, io.LimitReader(httpRsp.Body, maxResponseSize))
+
+	return Response{
+		StatusCode: httpRsp.StatusCode,
+		Duration: time.Since(start),
+	}, nil
+}
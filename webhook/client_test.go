@@ -0,0 +1,117 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// loopback is allowed for the test servers
+var loopback = netip.MustParsePrefix("127.0.0.0/8")
+
+func TestClientSend(t *testing.T) {
+	secret := "secret"
+	body := []byte(`{"id":"event","type":"transfer.created"}`)
+
+	var received *http.Request
+	var receivedBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		receivedBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client := NewClient(time.Second, loopback)
+	rsp, err := client.Send(context.Background(), Request{
+		URL: server.URL,
+		Secret: secret,
+		ID: "event",
+		Event: "transfer.created",
+		Body: body,
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNoContent, rsp.StatusCode)
+	require.True(t, rsp.Success())
+
+	require.NotNil(t, received)
+	require.Equal(t, http.MethodPost, received.Method)
+	require.Equal(t, "application/json", received.Header.Get("Content-Type"))
+	require.Equal(t, "event", received.Header.Get(HeaderID))
+	require.Equal(t, "transfer.created", received.Header.Get(HeaderEvent))
+	require.Equal(t, body, receivedBody)
+
+	err = Verify(secret, received.Header.Get(HeaderSignature), received.Header.Get(HeaderTimestamp), receivedBody, time.Minute)
+	require.NoError(t, err)
+
+	err = Verify("other", received.Header.Get(HeaderSignature), received.Header.Get(HeaderTimestamp), receivedBody, time.Minute)
+	require.ErrorIs(t, err, ErrInvalidSignature)
+}
+
+func TestClientSendFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "https://example.com", http.StatusFound)
+	}))
+	defer server.Close()
+
+	client := NewClient(time.Second, loopback)
+	rsp, err := client.Send(context.Background(), Request{URL: server.URL})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusFound, rsp.StatusCode)
+	require.False(t, rsp.Success())
+
+	server.Close()
+	_, err = client.Send(context.Background(), Request{URL: server.URL})
+	require.Error(t, err)
+}
+
+func TestClientSendForbiddenAddress(t *testing.T) {
+	received := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = true
+	}))
+	defer server.Close()
+
+	// the address is checked when it is dialed, even if the url doesn't look local
+	client := NewClient(time.Second)
+	_, err := client.Send(context.Background(), Request{URL: server.URL})
+	require.ErrorIs(t, err, ErrForbiddenAddress)
+	require.False(t, received)
+}
+
+func TestCheckHost(t *testing.T) {
+	for _, host := range []string{"localhost", "api.localhost", "127.0.0.1", "::1", "10.0.0.1", "172.16.0.1", "192.168.1.1", "169.254.169.254", "fe80::1", "fd00::1", "0.0.0.0", "::", "100.64.0.1", "::ffff:127.0.0.1"} {
+		require.ErrorIs(t, CheckHost(host), ErrForbiddenAddress, host)
+	}
+
+	for _, host := range []string{"example.com", "8.8.8.8", "2001:4860:4860::8888"} {
+		require.NoError(t, CheckHost(host), host)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	secret := "secret"
+	body := []byte(`{}`)
+	unix := func(t time.Time) string {
+		return strconv.FormatInt(t.Unix(), 10)
+	}
+
+	now := time.Now()
+	signature := Sign(secret, now, body)
+	require.NoError(t, Verify(secret, signature, unix(now), body, time.Minute))
+	require.NoError(t, Verify(secret, "v1=old,"+signature, unix(now), body, time.Minute))
+	require.ErrorIs(t, Verify(secret, signature, unix(now), []byte(`{"a":1}`), time.Minute), ErrInvalidSignature)
+	require.ErrorIs(t, Verify(secret, signature, "now", body, time.Minute), ErrInvalidTimestamp)
+
+	old := now.Add(-time.Hour)
+	oldSignature := Sign(secret, old, body)
+	require.ErrorIs(t, Verify(secret, oldSignature, unix(old), body, time.Minute), ErrExpiredTimestamp)
+	require.NoError(t, Verify(secret, oldSignature, unix(old), body, 0))
+}
@@ -0,0 +1,17 @@
+package webhook
+
+import "time"
+
+// Event is the body of a webhook request
+type Event struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"`
+}
+
+// UserData is the data of the user.verified event
+type UserData struct {
+	Username string `json:"username"`
+	Email    string `json:"email"`
+}
@@ -0,0 +1,60 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers sent with every webhook request
+const (
+	HeaderID        = "X-Webhook-ID"
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+const signatureVersion = "v1"
+
+var (
+	ErrInvalidSignature = errors.New("webhook signature is invalid")
+	ErrInvalidTimestamp = errors.New("webhook timestamp is invalid")
+	ErrExpiredTimestamp = errors.New("webhook timestamp is too old")
+)
+
+// Sign returns the signature of the body sent at the timestamp.
+// It is the HMAC-SHA256 of "<unix timestamp>.<body>" with the secret of the endpoint,
+// so a receiver can reject replayed requests by checking the timestamp
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signatureVersion + "=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the signature and timestamp headers of a webhook request.
+// A zero tolerance accepts any timestamp
+func Verify(secret string, signature string, timestamp string, body []byte, tolerance time.Duration) error {
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidTimestamp
+	}
+
+	signedAt := time.Unix(unix, 0)
+	if tolerance > 0 && time.Since(signedAt).Abs() > tolerance {
+		return ErrExpiredTimestamp
+	}
+
+	expected := Sign(secret, signedAt, body)
+	for _, value := range strings.Split(signature, ",") {
+		if hmac.Equal([]byte(strings.TrimSpace(value)), []byte(expected)) {
+			return nil
+		}
+	}
+	return ErrInvalidSignature
+}
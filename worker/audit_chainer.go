@@ -0,0 +1,60 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	db "github.com/juker1141/simplebank/db/sqlc"
+	"github.com/rs/zerolog/log"
+)
+
+const auditChainBatchSize = 500
+
+// AuditChainer chains the records written to the audit outbox by store transactions to the audit log.
+// Every instance runs a chainer, but the store lets only one of them chain at a time
+type AuditChainer struct {
+	store    db.Store
+	interval time.Duration
+}
+
+func NewAuditChainer(store db.Store, interval time.Duration) *AuditChainer {
+	return &AuditChainer{
+		store: store,
+		interval: interval,
+	}
+}
+
+// Run polls the audit outbox until the context is canceled
+func (chainer *AuditChainer) Run(ctx context.Context) {
+	ticker := time.NewTicker(chainer.interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := chainer.Chain(ctx); err != nil {
+			log.Error().Err(err).Msg("failed to chain audit events")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Chain chains all pending records and returns the number of chained events
+func (chainer *AuditChainer) Chain(ctx context.Context) (int, error) {
+	chained := 0
+
+	for {
+		n, err := chainer.store.ChainAuditEventsTx(ctx, auditChainBatchSize)
+		if err != nil {
+			return chained, err
+		}
+
+		chained += n
+		if n < auditChainBatchSize {
+			return chained, nil
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package worker_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/juker1141/simplebank/db/mock"
+	"github.com/juker1141/simplebank/worker"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditChainer(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// a full batch is followed by another one until the outbox is drained
+	store := mockdb.NewMockStore(ctrl)
+	gomock.InOrder(
+		store.EXPECT().ChainAuditEventsTx(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, limit int32) (int, error) {
+				return int(limit), nil
+			}),
+		store.EXPECT().ChainAuditEventsTx(gomock.Any(), gomock.Any()).
+			Return(3, nil),
+	)
+
+	chainer := worker.NewAuditChainer(store, 0)
+	chained, err := chainer.Chain(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 503, chained)
+
+	store.EXPECT().ChainAuditEventsTx(gomock.Any(), gomock.Any()).
+		Return(0, errors.New("connection refused"))
+
+	_, err = chainer.Chain(context.Background())
+	require.Error(t, err)
+}
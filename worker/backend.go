@@ -0,0 +1,106 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hibiken/asynq"
+	db "github.com/juker1141/simplebank/db/sqlc"
+	"github.com/juker1141/simplebank/mail"
+	"github.com/juker1141/simplebank/util"
+	"github.com/juker1141/simplebank/webhook"
+	"github.com/redis/go-redis/v9"
+)
+
+// Task queue backends that can be selected with TASK_QUEUE_BACKEND
+const (
+	BackendRedis  = "redis"
+	BackendMemory = "memory"
+)
+
+// TaskQueue creates the distributor and the processor of the backend selected in the config.
+// With the memory backend, both share the same in-process queue
+type TaskQueue struct {
+	backend         string
+	redisOpt        asynq.RedisClientOpt
+	redisClient     redis.UniversalClient
+	memory          *MemoryQueue
+	shutdownTimeout time.Duration
+}
+
+func NewTaskQueue(config util.Config) (*TaskQueue, error) {
+	switch config.TaskQueueBackend {
+	case BackendRedis, "":
+		redisOpt := asynq.RedisClientOpt{
+			Addr: config.RedisAddress,
+		}
+
+		redisClient, ok := redisOpt.MakeRedisClient().(redis.UniversalClient)
+		if !ok {
+			return nil, errors.New("unexpected redis client")
+		}
+
+		return &TaskQueue{
+			backend: BackendRedis,
+			redisOpt: redisOpt,
+			redisClient: redisClient,
+			shutdownTimeout: config.ShutdownTimeout,
+		}, nil
+	case BackendMemory:
+		return &TaskQueue{
+			backend: BackendMemory,
+			memory: NewMemoryQueue(MemoryQueueConfig{
+				ShutdownTimeout: config.ShutdownTimeout,
+			}),
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported task queue backend: %s", config.TaskQueueBackend)
+	}
+}
+
+func (queue *TaskQueue) NewDistributor() TaskDistribtor {
+	if queue.backend == BackendMemory {
+		return NewMemoryTaskDistribtor(queue.memory)
+	}
+	return NewRedisTaskDistribtor(queue.redisOpt)
+}
+
+func (queue *TaskQueue) NewProcessor(store db.Store, mailer mail.EmailSender, renderer *mail.Renderer, webhooks *webhook.Client) TaskProcessor {
+	if queue.backend == BackendMemory {
+		return NewMemoryTaskProcessor(queue.memory, store, mailer, renderer, webhooks)
+	}
+	return NewRedisTaskProcessor(queue.redisOpt, queue.shutdownTimeout, store, mailer, renderer, webhooks)
+}
+
+func (queue *TaskQueue) NewInspector() TaskInspector {
+	if queue.backend == BackendMemory {
+		return NewMemoryTaskInspector(queue.memory)
+	}
+	return NewRedisTaskInspector(queue.redisOpt)
+}
+
+
+// Backend returns the name of the backend selected in the config
+func (queue *TaskQueue) Backend() string {
+	return queue.backend
+}
+
+// Ping checks that the backend can be reached, the memory backend is always available.
+// The same connections are used by every check, so frequent checks don't open new ones
+func (queue *TaskQueue) Ping(ctx context.Context) error {
+	if queue.backend == BackendMemory {
+		return nil
+	}
+
+	return queue.redisClient.Ping(ctx).Err()
+}
+
+// Close closes the connections of the task queue, once the distributor, the processor and the health checks are stopped
+func (queue *TaskQueue) Close() error {
+	if queue.redisClient == nil {
+		return nil
+	}
+	return queue.redisClient.Close()
+}
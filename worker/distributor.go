@@ -2,16 +2,62 @@ package worker
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 
 	"github.com/hibiken/asynq"
+	"github.com/juker1141/simplebank/requestid"
+	"github.com/juker1141/simplebank/tracing"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type TaskDistribtor interface {
+	DistributeTask(
+		ctx context.Context,
+		taskType string,
+		payload []byte,
+		opts ...asynq.Option,
+	) error
 	DistributeTaskSendVerifyEmail(
 		ctx context.Context,
 		payload *PayloadSendVerifyEmail,
 		opts ...asynq.Option,
 	) error
+	DistributeTaskSendBeneficiaryAddedEmail(
+		ctx context.Context,
+		payload *PayloadSendBeneficiaryAddedEmail,
+		opts ...asynq.Option,
+	) error
+	DistributeTaskSendPaymentRequestEmail(
+		ctx context.Context,
+		payload *PayloadSendPaymentRequestEmail,
+		opts ...asynq.Option,
+	) error
+	DistributeTaskSendEmailChangeNotice(
+		ctx context.Context,
+		payload *PayloadSendEmailChangeNotice,
+		opts ...asynq.Option,
+	) error
+	DistributeTaskSendDebitNotification(
+		ctx context.Context,
+		payload *PayloadSendTransferNotification,
+		opts ...asynq.Option,
+	) error
+	DistributeTaskSendCreditNotification(
+		ctx context.Context,
+		payload *PayloadSendTransferNotification,
+		opts ...asynq.Option,
+	) error
+	DistributeTaskDispatchWebhookEvent(
+		ctx context.Context,
+		payload *PayloadDispatchWebhookEvent,
+		opts ...asynq.Option,
+	) error
+	DistributeTaskDeliverWebhook(
+		ctx context.Context,
+		payload *PayloadDeliverWebhook,
+		opts ...asynq.Option,
+	) error
 }
 
 type RedisTaskDistribtor struct {
@@ -23,4 +69,53 @@ func NewRedisTaskDistribtor(redisOpt asynq.RedisClientOpt) TaskDistribtor {
 	return &RedisTaskDistribtor{
 		client: client,
 	}
+}
+
+// DistributeTask enqueues a task with an already encoded payload
+func (distributor *RedisTaskDistribtor) DistributeTask(
+	ctx context.Context,
+	taskType string,
+	payload []byte,
+	opts ...asynq.Option,
+) (err error) {
+	payload = requestid.InjectPayload(ctx, payload)
+	ctx, span, payload := startEnqueueSpan(ctx, taskType, payload)
+	defer func() {
+		tracing.End(span, err)
+	}()
+
+	task := asynq.NewTask(taskType, payload, opts...)
+	info, err := distributor.client.EnqueueContext(ctx, task)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue task: %w", err)
+	}
+	span.SetAttributes(
+		attribute.String("messaging.destination.name", info.Queue),
+		attribute.String("task.id", info.ID),
+	)
+
+	requestid.Logger(ctx).Info().
+		Str("type", task.Type()).
+		Bytes("payload", task.Payload()).
+		Str("queue", info.Queue).
+		Int("max_retry", info.MaxRetry).
+		Msg("enqueued task")
+
+	return nil
+}
+
+// distributeTask encodes the payload and enqueues the task with the distributor
+func distributeTask(
+	ctx context.Context,
+	distributor TaskDistribtor,
+	taskType string,
+	payload any,
+	opts ...asynq.Option,
+) error {
+	jsonPayload, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal task payload: %w", err)
+	}
+
+	return distributor.DistributeTask(ctx, taskType, jsonPayload, opts...)
 }
\ No newline at end of file
@@ -0,0 +1,212 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/hibiken/asynq"
+)
+
+// States of the tasks waiting in a queue
+const (
+	TaskStatePending   = "pending"
+	TaskStateActive    = "active"
+	TaskStateScheduled = "scheduled"
+	TaskStateRetry     = "retry"
+	TaskStateArchived  = "archived"
+	TaskStateCompleted = "completed"
+)
+
+var (
+	ErrQueueNotFound    = errors.New("queue not found")
+	ErrTaskNotFound     = errors.New("task not found")
+	ErrInvalidTaskState = errors.New("task can't be changed in its current state")
+)
+
+type QueueInfo struct {
+	Queue     string
+	Paused    bool
+	Size      int
+	Pending   int
+	Active    int
+	Scheduled int
+	Retry     int
+	Archived  int
+}
+
+type TaskInfo struct {
+	ID        string
+	Queue     string
+	Type      string
+	Payload   []byte
+	State     string
+	MaxRetry  int
+	Retried   int
+	LastError string
+	// LastFailedAt is zero if the task never failed
+	LastFailedAt time.Time
+	// NextProcessAt is zero for the active and archived tasks
+	NextProcessAt time.Time
+}
+
+// TaskInspector lets the operators inspect the queues and fix the tasks that failed
+type TaskInspector interface {
+	ListQueues(ctx context.Context) ([]QueueInfo, error)
+	// ListTasks lists the tasks of the queue in a pending, scheduled, retry or archived state.
+	// The first page is 1
+	ListTasks(ctx context.Context, queue string, state string, page int, pageSize int) ([]TaskInfo, error)
+	// RunTask moves a scheduled, retry or archived task to the pending tasks, to be processed now
+	RunTask(ctx context.Context, queue string, id string) error
+	// DeleteTask deletes a task that isn't being processed.
+	// Deleting a completed task lets a task with the same ID be enqueued again
+	DeleteTask(ctx context.Context, queue string, id string) error
+	// PauseQueue stops processing the tasks of the queue, they can still be enqueued
+	PauseQueue(ctx context.Context, queue string) error
+	UnpauseQueue(ctx context.Context, queue string) error
+}
+
+// RedisTaskInspector inspects the queues of the Redis backend
+type RedisTaskInspector struct {
+	inspector *asynq.Inspector
+}
+
+func NewRedisTaskInspector(redisOpt asynq.RedisClientOpt) TaskInspector {
+	return &RedisTaskInspector{
+		inspector: asynq.NewInspector(redisOpt),
+	}
+}
+
+func (inspector *RedisTaskInspector) ListQueues(ctx context.Context) ([]QueueInfo, error) {
+	queues, err := inspector.inspector.Queues()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list queues: %w", err)
+	}
+	sort.Strings(queues)
+
+	infos := make([]QueueInfo, 0, len(queues))
+	for _, queue := range queues {
+		info, err := inspector.inspector.GetQueueInfo(queue)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get queue %s: %w", queue, inspectorError(err))
+		}
+
+		infos = append(infos, QueueInfo{
+			Queue: info.Queue,
+			Paused: info.Paused,
+			Size: info.Size,
+			Pending: info.Pending,
+			Active: info.Active,
+			Scheduled: info.Scheduled,
+			Retry: info.Retry,
+			Archived: info.Archived,
+		})
+	}
+
+	return infos, nil
+}
+
+func (inspector *RedisTaskInspector) ListTasks(ctx context.Context, queue string, state string, page int, pageSize int) ([]TaskInfo, error) {
+	opts := []asynq.ListOption{asynq.Page(page), asynq.PageSize(pageSize)}
+
+	var tasks []*asynq.TaskInfo
+	var err error
+	switch state {
+	case TaskStatePending:
+		tasks, err = inspector.inspector.ListPendingTasks(queue, opts...)
+	case TaskStateScheduled:
+		tasks, err = inspector.inspector.ListScheduledTasks(queue, opts...)
+	case TaskStateRetry:
+		tasks, err = inspector.inspector.ListRetryTasks(queue, opts...)
+	case TaskStateArchived:
+		tasks, err = inspector.inspector.ListArchivedTasks(queue, opts...)
+	default:
+		return nil, fmt.Errorf("unsupported task state: %s", state)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tasks: %w", inspectorError(err))
+	}
+
+	infos := make([]TaskInfo, 0, len(tasks))
+	for _, task := range tasks {
+		infos = append(infos, TaskInfo{
+			ID: task.ID,
+			Queue: task.Queue,
+			Type: task.Type,
+			Payload: task.Payload,
+			State: task.State.String(),
+			MaxRetry: task.MaxRetry,
+			Retried: task.Retried,
+			LastError: task.LastErr,
+			LastFailedAt: task.LastFailedAt,
+			NextProcessAt: task.NextProcessAt,
+		})
+	}
+
+	return infos, nil
+}
+
+func (inspector *RedisTaskInspector) RunTask(ctx context.Context, queue string, id string) error {
+	if err := inspector.checkTaskState(queue, id, TaskStateScheduled, TaskStateRetry, TaskStateArchived); err != nil {
+		return err
+	}
+
+	if err := inspector.inspector.RunTask(queue, id); err != nil {
+		return fmt.Errorf("failed to run task: %w", inspectorError(err))
+	}
+	return nil
+}
+
+func (inspector *RedisTaskInspector) DeleteTask(ctx context.Context, queue string, id string) error {
+	if err := inspector.checkTaskState(queue, id, TaskStatePending, TaskStateScheduled, TaskStateRetry, TaskStateArchived, TaskStateCompleted); err != nil {
+		return err
+	}
+
+	if err := inspector.inspector.DeleteTask(queue, id); err != nil {
+		return fmt.Errorf("failed to delete task: %w", inspectorError(err))
+	}
+	return nil
+}
+
+// checkTaskState returns ErrInvalidTaskState if the task isn't in one of the states,
+// asynq only reports it with an untyped error
+func (inspector *RedisTaskInspector) checkTaskState(queue string, id string, states ...string) error {
+	task, err := inspector.inspector.GetTaskInfo(queue, id)
+	if err != nil {
+		return fmt.Errorf("failed to get task: %w", inspectorError(err))
+	}
+
+	for _, state := range states {
+		if task.State.String() == state {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: task is %s", ErrInvalidTaskState, task.State)
+}
+
+func (inspector *RedisTaskInspector) PauseQueue(ctx context.Context, queue string) error {
+	if err := inspector.inspector.PauseQueue(queue); err != nil {
+		return fmt.Errorf("failed to pause queue: %w", inspectorError(err))
+	}
+	return nil
+}
+
+func (inspector *RedisTaskInspector) UnpauseQueue(ctx context.Context, queue string) error {
+	if err := inspector.inspector.UnpauseQueue(queue); err != nil {
+		return fmt.Errorf("failed to unpause queue: %w", inspectorError(err))
+	}
+	return nil
+}
+
+// inspectorError converts the errors of asynq to the errors of the package
+func inspectorError(err error) error {
+	switch {
+	case errors.Is(err, asynq.ErrQueueNotFound):
+		return ErrQueueNotFound
+	case errors.Is(err, asynq.ErrTaskNotFound):
+		return ErrTaskNotFound
+	}
+	return err
+}
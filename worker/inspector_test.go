@@ -0,0 +1,97 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryTaskInspectorRetryArchived(t *testing.T) {
+	handler := &recorder{
+		fail: func(task *asynq.Task, attempt int) error {
+			if attempt == 0 {
+				return errors.New("smtp server is down")
+			}
+			return nil
+		},
+	}
+	queue, distributor := startMemoryQueue(t, MemoryQueueConfig{}, handler)
+	inspector := NewMemoryTaskInspector(queue)
+
+	err := distributor.DistributeTask(context.Background(), "task:test", []byte(`{"username":"alice"}`), asynq.MaxRetry(0), asynq.TaskID("task-1"))
+	require.NoError(t, err)
+	waitMemoryQueue(t, queue)
+
+	tasks, err := inspector.ListTasks(context.Background(), QueueDefault, TaskStateArchived, 1, 10)
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	require.Equal(t, "task-1", tasks[0].ID)
+	require.Equal(t, "task:test", tasks[0].Type)
+	require.Equal(t, `{"username":"alice"}`, string(tasks[0].Payload))
+	require.Equal(t, TaskStateArchived, tasks[0].State)
+	require.Equal(t, "smtp server is down", tasks[0].LastError)
+	require.WithinDuration(t, time.Now(), tasks[0].LastFailedAt, time.Second)
+
+	// an archived task keeps its ID
+	err = distributor.DistributeTask(context.Background(), "task:test", []byte(`{}`), asynq.TaskID("task-1"))
+	require.ErrorIs(t, err, asynq.ErrTaskIDConflict)
+
+	require.NoError(t, inspector.RunTask(context.Background(), QueueDefault, "task-1"))
+	waitMemoryQueue(t, queue)
+	require.Len(t, handler.processed(), 2)
+
+	queues, err := inspector.ListQueues(context.Background())
+	require.NoError(t, err)
+	for _, info := range queues {
+		require.Zero(t, info.Size)
+	}
+
+	err = inspector.RunTask(context.Background(), QueueDefault, "task-1")
+	require.ErrorIs(t, err, ErrTaskNotFound)
+}
+
+func TestMemoryTaskInspectorPauseQueue(t *testing.T) {
+	handler := &recorder{}
+	queue, distributor := startMemoryQueue(t, MemoryQueueConfig{}, handler)
+	inspector := NewMemoryTaskInspector(queue)
+
+	require.NoError(t, inspector.PauseQueue(context.Background(), QueueCritical))
+	require.ErrorIs(t, inspector.PauseQueue(context.Background(), "unknown"), ErrQueueNotFound)
+
+	for _, id := range []string{"task-1", "task-2"} {
+		err := distributor.DistributeTask(context.Background(), "task:test", []byte(id), asynq.Queue(QueueCritical), asynq.TaskID(id))
+		require.NoError(t, err)
+	}
+	err := distributor.DistributeTask(context.Background(), "task:test", []byte("task-3"), asynq.Queue(QueueCritical), asynq.ProcessIn(time.Hour))
+	require.NoError(t, err)
+
+	time.Sleep(50 * time.Millisecond)
+	require.Empty(t, handler.processed())
+
+	queues, err := inspector.ListQueues(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []QueueInfo{
+		{Queue: QueueCritical, Paused: true, Size: 3, Pending: 2, Scheduled: 1},
+		{Queue: QueueDefault},
+	}, queues)
+
+	tasks, err := inspector.ListTasks(context.Background(), QueueCritical, TaskStatePending, 2, 1)
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	require.Equal(t, "task-2", tasks[0].ID)
+
+	// a pending task can't be retried, but it can be deleted
+	err = inspector.RunTask(context.Background(), QueueCritical, "task-1")
+	require.ErrorIs(t, err, ErrInvalidTaskState)
+	require.NoError(t, inspector.DeleteTask(context.Background(), QueueCritical, "task-1"))
+
+	require.NoError(t, inspector.UnpauseQueue(context.Background(), QueueCritical))
+	require.Eventually(t, func() bool {
+		return len(handler.processed()) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, []string{"task-2"}, handler.processed())
+}
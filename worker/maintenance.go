@@ -0,0 +1,106 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	db "github.com/juker1141/simplebank/db/sqlc"
+	"github.com/juker1141/simplebank/util"
+	"github.com/rs/zerolog/log"
+)
+
+// Names of the maintenance jobs
+const (
+	JobCleanupSessions       = "cleanup_sessions"
+	JobPurgeVerifyEmails     = "purge_verify_emails"
+	JobExpirePaymentRequests = "expire_payment_requests"
+	JobReconcileAccounts     = "reconcile_accounts"
+	JobVerifyAuditLog        = "verify_audit_log"
+)
+
+// verifyEmailRetention is how long the used and expired verification codes are kept,
+// longer than the window of the hourly limit of verification emails
+const verifyEmailRetention = 24 * time.Hour
+
+// MaintenanceJobs returns the periodic jobs that keep the database clean and consistent,
+// with their schedules from the config
+func MaintenanceJobs(config util.Config, store db.Store) []Job {
+	return []Job{
+		{
+			Name: JobCleanupSessions,
+			Schedule: config.ScheduleCleanupSessions,
+			Run: func(ctx context.Context) (string, error) {
+				deleted, err := store.DeleteExpiredSessions(ctx, time.Now())
+				if err != nil {
+					return "", fmt.Errorf("failed to delete expired sessions: %w", err)
+				}
+				return fmt.Sprintf("deleted %d sessions", deleted), nil
+			},
+		},
+		{
+			Name: JobPurgeVerifyEmails,
+			Schedule: config.SchedulePurgeVerifyEmails,
+			Run: func(ctx context.Context) (string, error) {
+				deleted, err := store.DeleteStaleVerifyEmails(ctx, time.Now().Add(-verifyEmailRetention))
+				if err != nil {
+					return "", fmt.Errorf("failed to delete verify emails: %w", err)
+				}
+				return fmt.Sprintf("deleted %d verify emails", deleted), nil
+			},
+		},
+		{
+			Name: JobExpirePaymentRequests,
+			Schedule: config.ScheduleExpirePaymentRequests,
+			Run: func(ctx context.Context) (string, error) {
+				expired, err := store.ExpirePaymentRequests(ctx)
+				if err != nil {
+					return "", fmt.Errorf("failed to expire payment requests: %w", err)
+				}
+				return fmt.Sprintf("expired %d payment requests", expired), nil
+			},
+		},
+		{
+			Name: JobReconcileAccounts,
+			Schedule: config.ScheduleReconcileAccounts,
+			Run: func(ctx context.Context) (string, error) {
+				return reconcileAccounts(ctx, store)
+			},
+		},
+		{
+			Name: JobVerifyAuditLog,
+			Schedule: config.ScheduleVerifyAuditLog,
+			Run: func(ctx context.Context) (string, error) {
+				verified, err := store.VerifyAuditLog(ctx)
+				if err != nil {
+					return "", fmt.Errorf("audit log is broken after %d events: %w", verified, err)
+				}
+				return fmt.Sprintf("verified %d audit events", verified), nil
+			},
+		},
+	}
+}
+
+// reconcileAccounts checks that the balance of every account is the sum of its entries.
+// It doesn't fix the balances, a mismatch must be investigated
+func reconcileAccounts(ctx context.Context, store db.Store) (string, error) {
+	accounts, err := store.ListUnbalancedAccounts(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to list unbalanced accounts: %w", err)
+	}
+
+	for _, account := range accounts {
+		log.Error().
+			Int64("account_id", account.ID).
+			Str("currency", account.Currency).
+			Int64("balance", account.Balance).
+			Int64("entries_total", account.EntriesTotal).
+			Msg("account balance doesn't match its entries")
+	}
+
+	if len(accounts) > 0 {
+		return fmt.Sprintf("%d accounts are unbalanced", len(accounts)), fmt.Errorf("%d accounts don't match their entries", len(accounts))
+	}
+
+	return "all accounts are balanced", nil
+}
@@ -0,0 +1,669 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	db "github.com/juker1141/simplebank/db/sqlc"
+	"github.com/juker1141/simplebank/mail"
+	"github.com/juker1141/simplebank/requestid"
+	"github.com/juker1141/simplebank/tracing"
+	"github.com/juker1141/simplebank/webhook"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Defaults of the options of a task and of the shutdown timeout, the same as asynq's
+const (
+	defaultMaxRetry        = 25
+	defaultTimeout         = 30 * time.Minute
+	defaultShutdownTimeout = 8 * time.Second
+)
+
+type MemoryQueueConfig struct {
+	// Concurrency is the number of tasks processed at the same time, 10 by default
+	Concurrency int
+	// RetryDelay returns how long to wait before retrying a failed task,
+	// the back-off of the Redis queue by default
+	RetryDelay asynq.RetryDelayFunc
+	// ShutdownTimeout is how long Shutdown waits for the active tasks to finish, 8 seconds by default
+	ShutdownTimeout time.Duration
+}
+
+type memoryTask struct {
+	id        string
+	taskType  string
+	payload   []byte
+	queue     string
+	maxRetry  int
+	retried   int
+	timeout   time.Duration
+	retention time.Duration
+	processAt time.Time
+	lastError string
+	failedAt  time.Time
+}
+
+// MemoryQueue is an in-process task queue, for development and tests without Redis.
+// It supports the queue priorities, delayed tasks, retries and task IDs of the Redis queue,
+// but the tasks are lost when the process exits
+type MemoryQueue struct {
+	config MemoryQueueConfig
+
+	mu       sync.Mutex
+	pending  map[string][]*memoryTask
+	// active is the number of tasks being processed in each queue
+	active   map[string]int
+	archived []*memoryTask
+	paused   map[string]bool
+	// ids holds the IDs of the unfinished tasks with a zero time,
+	// and of the finished tasks with the end of their retention
+	ids map[string]time.Time
+	// wake is closed when a task is enqueued
+	wake chan struct{}
+}
+
+func NewMemoryQueue(config MemoryQueueConfig) *MemoryQueue {
+	if config.Concurrency <= 0 {
+		config.Concurrency = 10
+	}
+	if config.RetryDelay == nil {
+		config.RetryDelay = retryDelay
+	}
+	if config.ShutdownTimeout <= 0 {
+		config.ShutdownTimeout = defaultShutdownTimeout
+	}
+
+	return &MemoryQueue{
+		config: config,
+		pending: make(map[string][]*memoryTask),
+		active: make(map[string]int),
+		ids: make(map[string]time.Time),
+		paused: make(map[string]bool),
+		wake: make(chan struct{}),
+	}
+}
+
+func newMemoryTask(taskType string, payload []byte, opts []asynq.Option) *memoryTask {
+	task := &memoryTask{
+		id: uuid.NewString(),
+		taskType: taskType,
+		payload: payload,
+		queue: QueueDefault,
+		maxRetry: defaultMaxRetry,
+		timeout: defaultTimeout,
+		processAt: time.Now(),
+	}
+
+	for _, opt := range opts {
+		switch opt.Type() {
+		case asynq.MaxRetryOpt:
+			task.maxRetry = opt.Value().(int)
+		case asynq.QueueOpt:
+			task.queue = opt.Value().(string)
+		case asynq.TimeoutOpt:
+			task.timeout = opt.Value().(time.Duration)
+		case asynq.ProcessAtOpt:
+			task.processAt = opt.Value().(time.Time)
+		case asynq.ProcessInOpt:
+			task.processAt = time.Now().Add(opt.Value().(time.Duration))
+		case asynq.TaskIDOpt:
+			task.id = opt.Value().(string)
+		case asynq.RetentionOpt:
+			task.retention = opt.Value().(time.Duration)
+		}
+	}
+
+	return task
+}
+
+func (queue *MemoryQueue) enqueue(task *memoryTask) error {
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+
+	now := time.Now()
+	for id, expiry := range queue.ids {
+		if !expiry.IsZero() && expiry.Before(now) {
+			delete(queue.ids, id)
+		}
+	}
+
+	if _, ok := queue.ids[task.id]; ok {
+		return asynq.ErrTaskIDConflict
+	}
+
+	queue.ids[task.id] = time.Time{}
+	queue.push(task)
+	return nil
+}
+
+// push adds the task to its queue and wakes up the waiting workers. The lock must be held
+func (queue *MemoryQueue) push(task *memoryTask) {
+	queue.pending[task.queue] = append(queue.pending[task.queue], task)
+	queue.notify()
+}
+
+// notify wakes up the waiting workers. The lock must be held
+func (queue *MemoryQueue) notify() {
+	close(queue.wake)
+	queue.wake = make(chan struct{})
+}
+
+// next blocks until a task is ready to be processed, or the context is canceled
+func (queue *MemoryQueue) next(ctx context.Context) (*memoryTask, error) {
+	for {
+		queue.mu.Lock()
+		task, wait := queue.dequeue(time.Now())
+		wake := queue.wake
+		queue.mu.Unlock()
+
+		if task != nil {
+			return task, nil
+		}
+
+		var timer *time.Timer
+		var timeout <-chan time.Time
+		if wait > 0 {
+			timer = time.NewTimer(wait)
+			timeout = timer.C
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-wake:
+		case <-timeout:
+		}
+
+		if timer != nil {
+			timer.Stop()
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// dequeue removes a ready task from a queue picked by priority, ignoring the paused queues.
+// If no task is ready, it returns how long to wait for the next one, or 0 if the queues are empty.
+// The lock must be held
+func (queue *MemoryQueue) dequeue(now time.Time) (*memoryTask, time.Duration) {
+	var wait time.Duration
+	ready := make(map[string]int)
+	total := 0
+
+	for name, tasks := range queue.pending {
+		if queue.paused[name] {
+			continue
+		}
+
+		for i, task := range tasks {
+			if !task.processAt.After(now) {
+				ready[name] = i
+				total += queuePriority(name)
+				break
+			}
+
+			if untilReady := task.processAt.Sub(now); wait == 0 || untilReady < wait {
+				wait = untilReady
+			}
+		}
+	}
+
+	if total == 0 {
+		return nil, wait
+	}
+
+	n := rand.Intn(total)
+	for name, i := range ready {
+		n -= queuePriority(name)
+		if n >= 0 {
+			continue
+		}
+
+		tasks := queue.pending[name]
+		task := tasks[i]
+		queue.pending[name] = append(tasks[:i:i], tasks[i+1:]...)
+		queue.active[name]++
+		return task, 0
+	}
+
+	return nil, wait
+}
+
+// done records the result of processing the task
+func (queue *MemoryQueue) done(task *memoryTask, err error) {
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+
+	queue.active[task.queue]--
+
+	if err == nil {
+		if task.retention > 0 {
+			queue.ids[task.id] = time.Now().Add(task.retention)
+		} else {
+			delete(queue.ids, task.id)
+		}
+		return
+	}
+
+	task.lastError = err.Error()
+	task.failedAt = time.Now()
+	if errors.Is(err, asynq.SkipRetry) || task.retried >= task.maxRetry {
+		queue.archived = append(queue.archived, task)
+		return
+	}
+
+	task.retried++
+	task.processAt = time.Now().Add(queue.config.RetryDelay(task.retried, err, asynq.NewTask(task.taskType, task.payload)))
+	queue.push(task)
+}
+
+// Wait blocks until all tasks, including the delayed ones, are processed or archived
+func (queue *MemoryQueue) Wait(ctx context.Context) error {
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		queue.mu.Lock()
+		idle := true
+		for _, n := range queue.active {
+			idle = idle && n == 0
+		}
+		for _, tasks := range queue.pending {
+			idle = idle && len(tasks) == 0
+		}
+		queue.mu.Unlock()
+
+		if idle {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func queuePriority(queue string) int {
+	if priority, ok := queuePriorities[queue]; ok {
+		return priority
+	}
+	return 1
+}
+
+// MemoryTaskDistribtor enqueues tasks to a MemoryQueue
+type MemoryTaskDistribtor struct {
+	queue *MemoryQueue
+}
+
+func NewMemoryTaskDistribtor(queue *MemoryQueue) TaskDistribtor {
+	return &MemoryTaskDistribtor{
+		queue: queue,
+	}
+}
+
+func (distributor *MemoryTaskDistribtor) DistributeTask(
+	ctx context.Context,
+	taskType string,
+	payload []byte,
+	opts ...asynq.Option,
+) (err error) {
+	payload = requestid.InjectPayload(ctx, payload)
+	ctx, span, payload := startEnqueueSpan(ctx, taskType, payload)
+	defer func() {
+		tracing.End(span, err)
+	}()
+
+	task := newMemoryTask(taskType, payload, opts)
+	if err = distributor.queue.enqueue(task); err != nil {
+		return fmt.Errorf("failed to enqueue task: %w", err)
+	}
+	span.SetAttributes(
+		attribute.String("messaging.destination.name", task.queue),
+		attribute.String("task.id", task.id),
+	)
+
+	requestid.Logger(ctx).Info().
+		Str("type", task.taskType).
+		Bytes("payload", task.payload).
+		Str("queue", task.queue).
+		Int("max_retry", task.maxRetry).
+		Msg("enqueued task")
+
+	return nil
+}
+
+// MemoryTaskProcessor processes the tasks of a MemoryQueue
+type MemoryTaskProcessor struct {
+	*taskHandler
+	queue  *MemoryQueue
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewMemoryTaskProcessor(queue *MemoryQueue, store db.Store, mailer mail.EmailSender, renderer *mail.Renderer, webhooks *webhook.Client) *MemoryTaskProcessor {
+	return &MemoryTaskProcessor{
+		taskHandler: newTaskHandler(store, mailer, renderer, webhooks),
+		queue: queue,
+	}
+}
+
+// Start starts the workers in the background
+func (processor *MemoryTaskProcessor) Start() error {
+	return processor.start(processor.mux())
+}
+
+func (processor *MemoryTaskProcessor) start(handler asynq.Handler) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	processor.cancel = cancel
+
+	for i := 0; i < processor.queue.config.Concurrency; i++ {
+		processor.wg.Add(1)
+		go func() {
+			defer processor.wg.Done()
+
+			for {
+				task, err := processor.queue.next(ctx)
+				if err != nil {
+					return
+				}
+				processor.process(handler, task)
+			}
+		}()
+	}
+
+	return nil
+}
+
+// process runs the handler of the task. It isn't canceled by Shutdown, which lets the active tasks finish
+func (processor *MemoryTaskProcessor) process(handler asynq.Handler, task *memoryTask) {
+	ctx, cancel := context.WithTimeout(context.Background(), task.timeout)
+	defer cancel()
+
+	asynqTask := asynq.NewTask(task.taskType, task.payload)
+	err := handler.ProcessTask(ctx, asynqTask)
+	if err != nil {
+		logTaskError(ctx, asynqTask, err)
+	}
+
+	processor.queue.done(task, err)
+}
+
+// Shutdown stops the workers and waits for the active tasks to finish, up to the shutdown timeout.
+// The tasks still active after the timeout are lost with the queue when the process exits
+func (processor *MemoryTaskProcessor) Shutdown() {
+	if processor.cancel != nil {
+		processor.cancel()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		processor.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(processor.queue.config.ShutdownTimeout):
+		log.Warn().Msg("active tasks didn't finish before the shutdown timeout")
+	}
+}
+
+// state returns the state of a task waiting in a queue, as reported by the Redis backend
+func (task *memoryTask) state(now time.Time) string {
+	if !task.processAt.After(now) {
+		return TaskStatePending
+	}
+	if task.retried > 0 {
+		return TaskStateRetry
+	}
+	return TaskStateScheduled
+}
+
+func (task *memoryTask) info(state string) TaskInfo {
+	info := TaskInfo{
+		ID: task.id,
+		Queue: task.queue,
+		Type: task.taskType,
+		Payload: task.payload,
+		State: state,
+		MaxRetry: task.maxRetry,
+		Retried: task.retried,
+		LastError: task.lastError,
+		LastFailedAt: task.failedAt,
+	}
+	if state != TaskStateArchived {
+		info.NextProcessAt = task.processAt
+	}
+	return info
+}
+
+// MemoryTaskInspector inspects the queues of a MemoryQueue
+type MemoryTaskInspector struct {
+	queue *MemoryQueue
+}
+
+func NewMemoryTaskInspector(queue *MemoryQueue) TaskInspector {
+	return &MemoryTaskInspector{
+		queue: queue,
+	}
+}
+
+// queues returns the names of the queues with a priority or with tasks. The lock must be held
+func (queue *MemoryQueue) queues() []string {
+	names := make(map[string]bool)
+	for name := range queuePriorities {
+		names[name] = true
+	}
+	for name := range queue.pending {
+		names[name] = true
+	}
+	for _, task := range queue.archived {
+		names[task.queue] = true
+	}
+
+	queues := make([]string, 0, len(names))
+	for name := range names {
+		queues = append(queues, name)
+	}
+	sort.Strings(queues)
+	return queues
+}
+
+// hasQueue returns ErrQueueNotFound if the queue doesn't exist. The lock must be held
+func (queue *MemoryQueue) hasQueue(name string) error {
+	for _, existing := range queue.queues() {
+		if existing == name {
+			return nil
+		}
+	}
+	return ErrQueueNotFound
+}
+
+func (inspector *MemoryTaskInspector) ListQueues(ctx context.Context) ([]QueueInfo, error) {
+	queue := inspector.queue
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+
+	now := time.Now()
+	var infos []QueueInfo
+	for _, name := range queue.queues() {
+		info := QueueInfo{
+			Queue: name,
+			Paused: queue.paused[name],
+			Active: queue.active[name],
+		}
+
+		for _, task := range queue.pending[name] {
+			switch task.state(now) {
+			case TaskStatePending:
+				info.Pending++
+			case TaskStateRetry:
+				info.Retry++
+			case TaskStateScheduled:
+				info.Scheduled++
+			}
+		}
+		for _, task := range queue.archived {
+			if task.queue == name {
+				info.Archived++
+			}
+		}
+
+		info.Size = info.Pending + info.Active + info.Scheduled + info.Retry + info.Archived
+		infos = append(infos, info)
+	}
+
+	return infos, nil
+}
+
+func (inspector *MemoryTaskInspector) ListTasks(ctx context.Context, queue string, state string, page int, pageSize int) ([]TaskInfo, error) {
+	inspector.queue.mu.Lock()
+	defer inspector.queue.mu.Unlock()
+
+	if err := inspector.queue.hasQueue(queue); err != nil {
+		return nil, fmt.Errorf("failed to list tasks: %w", err)
+	}
+
+	var tasks []TaskInfo
+	switch state {
+	case TaskStatePending, TaskStateScheduled, TaskStateRetry:
+		now := time.Now()
+		for _, task := range inspector.queue.pending[queue] {
+			if task.state(now) == state {
+				tasks = append(tasks, task.info(state))
+			}
+		}
+	case TaskStateArchived:
+		for _, task := range inspector.queue.archived {
+			if task.queue == queue {
+				tasks = append(tasks, task.info(state))
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unsupported task state: %s", state)
+	}
+
+	if page < 1 {
+		page = 1
+	}
+	start := (page - 1) * pageSize
+	if start >= len(tasks) {
+		return []TaskInfo{}, nil
+	}
+	end := start + pageSize
+	if end > len(tasks) {
+		end = len(tasks)
+	}
+
+	return tasks[start:end], nil
+}
+
+// find returns the task waiting in the queue, or the archived task, with its index.
+// The lock must be held
+func (queue *MemoryQueue) find(name string, id string) (task *memoryTask, index int, archived bool, err error) {
+	if err := queue.hasQueue(name); err != nil {
+		return nil, 0, false, err
+	}
+
+	for i, task := range queue.pending[name] {
+		if task.id == id {
+			return task, i, false, nil
+		}
+	}
+	for i, task := range queue.archived {
+		if task.queue == name && task.id == id {
+			return task, i, true, nil
+		}
+	}
+
+	// the task is known but in neither list while it is processed
+	if expiry, ok := queue.ids[id]; ok && expiry.IsZero() {
+		return nil, 0, false, fmt.Errorf("%w: task is %s", ErrInvalidTaskState, TaskStateActive)
+	}
+	return nil, 0, false, ErrTaskNotFound
+}
+
+func (inspector *MemoryTaskInspector) RunTask(ctx context.Context, queue string, id string) error {
+	inspector.queue.mu.Lock()
+	defer inspector.queue.mu.Unlock()
+
+	task, i, archived, err := inspector.queue.find(queue, id)
+	if err != nil {
+		return fmt.Errorf("failed to run task: %w", err)
+	}
+
+	if archived {
+		inspector.queue.archived = append(inspector.queue.archived[:i:i], inspector.queue.archived[i+1:]...)
+		task.processAt = time.Now()
+		inspector.queue.push(task)
+		return nil
+	}
+
+	if state := task.state(time.Now()); state == TaskStatePending {
+		return fmt.Errorf("failed to run task: %w: task is %s", ErrInvalidTaskState, state)
+	}
+
+	task.processAt = time.Now()
+	inspector.queue.notify()
+	return nil
+}
+
+func (inspector *MemoryTaskInspector) DeleteTask(ctx context.Context, queue string, id string) error {
+	inspector.queue.mu.Lock()
+	defer inspector.queue.mu.Unlock()
+
+	_, i, archived, err := inspector.queue.find(queue, id)
+	if errors.Is(err, ErrTaskNotFound) {
+		// a completed task is only remembered by its ID until the end of its retention
+		if expiry, ok := inspector.queue.ids[id]; ok && !expiry.IsZero() {
+			delete(inspector.queue.ids, id)
+			return nil
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete task: %w", err)
+	}
+
+	if archived {
+		inspector.queue.archived = append(inspector.queue.archived[:i:i], inspector.queue.archived[i+1:]...)
+	} else {
+		tasks := inspector.queue.pending[queue]
+		inspector.queue.pending[queue] = append(tasks[:i:i], tasks[i+1:]...)
+	}
+
+	delete(inspector.queue.ids, id)
+	return nil
+}
+
+func (inspector *MemoryTaskInspector) PauseQueue(ctx context.Context, queue string) error {
+	return inspector.setPaused(queue, true)
+}
+
+func (inspector *MemoryTaskInspector) UnpauseQueue(ctx context.Context, queue string) error {
+	return inspector.setPaused(queue, false)
+}
+
+func (inspector *MemoryTaskInspector) setPaused(queue string, paused bool) error {
+	inspector.queue.mu.Lock()
+	defer inspector.queue.mu.Unlock()
+
+	if err := inspector.queue.hasQueue(queue); err != nil {
+		return err
+	}
+
+	inspector.queue.paused[queue] = paused
+	if !paused {
+		inspector.queue.notify()
+	}
+	return nil
+}
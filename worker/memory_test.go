@@ -0,0 +1,244 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/stretchr/testify/require"
+)
+
+// recorder is a task handler that records the processed tasks
+type recorder struct {
+	mu    sync.Mutex
+	calls []string
+	fail  func(task *asynq.Task, attempt int) error
+}
+
+func (r *recorder) ProcessTask(ctx context.Context, task *asynq.Task) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	payload := string(task.Payload())
+	attempt := 0
+	for _, call := range r.calls {
+		if call == payload {
+			attempt++
+		}
+	}
+	r.calls = append(r.calls, payload)
+
+	if r.fail != nil {
+		return r.fail(task, attempt)
+	}
+	return nil
+}
+
+func (r *recorder) processed() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string{}, r.calls...)
+}
+
+func startMemoryQueue(t *testing.T, config MemoryQueueConfig, handler asynq.Handler) (*MemoryQueue, TaskDistribtor) {
+	queue := NewMemoryQueue(config)
+	processor := NewMemoryTaskProcessor(queue, nil, nil, nil, nil)
+	require.NoError(t, processor.start(handler))
+	t.Cleanup(processor.Shutdown)
+
+	return queue, NewMemoryTaskDistribtor(queue)
+}
+
+func waitMemoryQueue(t *testing.T, queue *MemoryQueue) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, queue.Wait(ctx))
+}
+
+func TestMemoryQueueProcess(t *testing.T) {
+	handler := &recorder{}
+	queue, distributor := startMemoryQueue(t, MemoryQueueConfig{}, handler)
+
+	for i := 0; i < 5; i++ {
+		err := distributor.DistributeTask(context.Background(), "task:test", []byte(fmt.Sprint(i)))
+		require.NoError(t, err)
+	}
+
+	waitMemoryQueue(t, queue)
+	require.ElementsMatch(t, []string{"0", "1", "2", "3", "4"}, handler.processed())
+}
+
+func TestMemoryQueueProcessIn(t *testing.T) {
+	handler := &recorder{}
+	queue, distributor := startMemoryQueue(t, MemoryQueueConfig{}, handler)
+
+	start := time.Now()
+	err := distributor.DistributeTask(context.Background(), "task:test", []byte("delayed"), asynq.ProcessIn(200*time.Millisecond))
+	require.NoError(t, err)
+
+	err = distributor.DistributeTask(context.Background(), "task:test", []byte("now"))
+	require.NoError(t, err)
+
+	waitMemoryQueue(t, queue)
+	require.Equal(t, []string{"now", "delayed"}, handler.processed())
+	require.GreaterOrEqual(t, time.Since(start), 200*time.Millisecond)
+}
+
+func TestMemoryQueueRetry(t *testing.T) {
+	handler := &recorder{
+		fail: func(task *asynq.Task, attempt int) error {
+			switch string(task.Payload()) {
+			case "flaky":
+				if attempt < 2 {
+					return errors.New("temporary error")
+				}
+				return nil
+			case "skip":
+				return fmt.Errorf("bad payload: %w", asynq.SkipRetry)
+			default:
+				return errors.New("permanent error")
+			}
+		},
+	}
+
+	var delays []int
+	var mu sync.Mutex
+	config := MemoryQueueConfig{
+		RetryDelay: func(n int, err error, task *asynq.Task) time.Duration {
+			mu.Lock()
+			defer mu.Unlock()
+			delays = append(delays, n)
+			return time.Millisecond
+		},
+	}
+	queue, distributor := startMemoryQueue(t, config, handler)
+
+	for _, payload := range []string{"flaky", "skip", "broken"} {
+		err := distributor.DistributeTask(context.Background(), "task:test", []byte(payload), asynq.MaxRetry(3))
+		require.NoError(t, err)
+	}
+
+	waitMemoryQueue(t, queue)
+
+	count := make(map[string]int)
+	for _, call := range handler.processed() {
+		count[call]++
+	}
+	require.Equal(t, 3, count["flaky"])
+	require.Equal(t, 1, count["skip"])
+	require.Equal(t, 4, count["broken"])
+
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+	require.Len(t, queue.archived, 2)
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, delays, 5)
+}
+
+func TestMemoryQueueTaskID(t *testing.T) {
+	handler := &recorder{}
+	queue, distributor := startMemoryQueue(t, MemoryQueueConfig{}, handler)
+
+	opts := []asynq.Option{asynq.TaskID("outbox:1"), asynq.Retention(time.Hour)}
+
+	err := distributor.DistributeTask(context.Background(), "task:test", []byte("first"), opts...)
+	require.NoError(t, err)
+	waitMemoryQueue(t, queue)
+
+	// a task with the same ID is rejected while it is retained
+	err = distributor.DistributeTask(context.Background(), "task:test", []byte("second"), opts...)
+	require.ErrorIs(t, err, asynq.ErrTaskIDConflict)
+
+	// deleting the completed task forgets its ID
+	inspector := NewMemoryTaskInspector(queue)
+	require.NoError(t, inspector.DeleteTask(context.Background(), QueueDefault, "outbox:1"))
+
+	err = distributor.DistributeTask(context.Background(), "task:test", []byte("third"), opts...)
+	require.NoError(t, err)
+
+	waitMemoryQueue(t, queue)
+	require.Equal(t, []string{"first", "third"}, handler.processed())
+}
+
+func TestMemoryTaskProcessorShutdown(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	finished := make(chan string, 2)
+	handler := asynq.HandlerFunc(func(ctx context.Context, task *asynq.Task) error {
+		if string(task.Payload()) == "slow" {
+			<-release
+		}
+		finished <- string(task.Payload())
+		return nil
+	})
+
+	queue := NewMemoryQueue(MemoryQueueConfig{ShutdownTimeout: 100 * time.Millisecond})
+	processor := NewMemoryTaskProcessor(queue, nil, nil, nil, nil)
+	require.NoError(t, processor.start(handler))
+
+	distributor := NewMemoryTaskDistribtor(queue)
+	require.NoError(t, distributor.DistributeTask(context.Background(), "task:test", []byte("fast")))
+	require.Equal(t, "fast", <-finished)
+	require.NoError(t, distributor.DistributeTask(context.Background(), "task:test", []byte("slow")))
+
+	// the task doesn't finish in time, Shutdown gives up waiting for it
+	require.Eventually(t, func() bool {
+		queue.mu.Lock()
+		defer queue.mu.Unlock()
+		return queue.active[QueueDefault] == 1
+	}, time.Second, 10*time.Millisecond)
+
+	start := time.Now()
+	processor.Shutdown()
+	require.WithinDuration(t, start.Add(100*time.Millisecond), time.Now(), 100*time.Millisecond)
+	require.Empty(t, finished)
+}
+
+func TestMemoryQueuePriority(t *testing.T) {
+	queue := NewMemoryQueue(MemoryQueueConfig{})
+	distributor := NewMemoryTaskDistribtor(queue)
+
+	for i := 0; i < 100; i++ {
+		require.NoError(t, distributor.DistributeTask(context.Background(), "task:test", nil, asynq.Queue(QueueCritical)))
+		require.NoError(t, distributor.DistributeTask(context.Background(), "task:test", nil, asynq.Queue(QueueDefault)))
+	}
+
+	// the critical queue is picked about twice as often as the default queue
+	critical := 0
+	for i := 0; i < 90; i++ {
+		task, _ := queue.dequeue(time.Now())
+		require.NotNil(t, task)
+		if task.queue == QueueCritical {
+			critical++
+		}
+	}
+	require.Greater(t, critical, 40)
+}
+
+func TestNewMemoryTask(t *testing.T) {
+	processAt := time.Now().Add(time.Hour)
+	task := newMemoryTask("task:test", nil, []asynq.Option{
+		asynq.Queue(QueueCritical),
+		asynq.MaxRetry(10),
+		asynq.ProcessAt(processAt),
+		asynq.TaskID("id"),
+		asynq.Timeout(time.Minute),
+	})
+
+	require.Equal(t, QueueCritical, task.queue)
+	require.Equal(t, 10, task.maxRetry)
+	require.Equal(t, processAt, task.processAt)
+	require.Equal(t, "id", task.id)
+	require.Equal(t, time.Minute, task.timeout)
+
+	task = newMemoryTask("task:test", nil, nil)
+	require.Equal(t, QueueDefault, task.queue)
+	require.Equal(t, defaultMaxRetry, task.maxRetry)
+	require.NotEmpty(t, task.id)
+}
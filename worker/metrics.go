@@ -0,0 +1,57 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/juker1141/simplebank/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	tasksProcessed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "worker",
+		Name: "tasks_processed_total",
+		Help: "Number of tasks processed, by type and outcome.",
+	}, []string{"type", "outcome"})
+
+	taskDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "worker",
+		Name: "task_duration_seconds",
+		Help: "Duration of the processing of the tasks, by type.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"type"})
+)
+
+// Outcomes of processing a task
+const (
+	taskOutcomeSucceeded = "succeeded"
+	taskOutcomeFailed    = "failed"
+	// taskOutcomeSkipped is a failure that isn't retried
+	taskOutcomeSkipped = "skipped"
+)
+
+// observeTask is a middleware that records the outcome and the duration of the tasks
+func observeTask(handler asynq.Handler) asynq.Handler {
+	return asynq.HandlerFunc(func(ctx context.Context, task *asynq.Task) error {
+		startTime := time.Now()
+		err := handler.ProcessTask(ctx, task)
+		taskDuration.WithLabelValues(task.Type()).Observe(time.Since(startTime).Seconds())
+
+		outcome := taskOutcomeSucceeded
+		if err != nil {
+			outcome = taskOutcomeFailed
+			if errors.Is(err, asynq.SkipRetry) {
+				outcome = taskOutcomeSkipped
+			}
+		}
+		tasksProcessed.WithLabelValues(task.Type(), outcome).Inc()
+
+		return err
+	})
+}
@@ -0,0 +1,41 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/hibiken/asynq"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestObserveTask(t *testing.T) {
+	testCases := []struct {
+		name    string
+		err     error
+		outcome string
+	}{
+		{name: "OK", outcome: taskOutcomeSucceeded},
+		{name: "Failed", err: errors.New("smtp server is down"), outcome: taskOutcomeFailed},
+		{name: "SkipRetry", err: fmt.Errorf("user not found: %w", asynq.SkipRetry), outcome: taskOutcomeSkipped},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			handler := observeTask(asynq.HandlerFunc(func(ctx context.Context, task *asynq.Task) error {
+				return tc.err
+			}))
+
+			processed := tasksProcessed.WithLabelValues("task:test", tc.outcome)
+			before := testutil.ToFloat64(processed)
+
+			err := handler.ProcessTask(context.Background(), asynq.NewTask("task:test", nil))
+			require.ErrorIs(t, err, tc.err)
+			require.Equal(t, before+1, testutil.ToFloat64(processed))
+		})
+	}
+}
@@ -36,6 +36,158 @@ func (m *MockTaskDistribtor) EXPECT() *MockTaskDistribtorMockRecorder {
 	return m.recorder
 }
 
+// DistributeTask mocks base method.
+func (m *MockTaskDistribtor) DistributeTask(arg0 context.Context, arg1 string, arg2 []byte, arg3 ...asynq.Option) error {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1, arg2}
+	for _, a := range arg3 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "DistributeTask", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DistributeTask indicates an expected call of DistributeTask.
+func (mr *MockTaskDistribtorMockRecorder) DistributeTask(arg0, arg1, arg2 interface{}, arg3 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1, arg2}, arg3...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DistributeTask", reflect.TypeOf((*MockTaskDistribtor)(nil).DistributeTask), varargs...)
+}
+
+// DistributeTaskDeliverWebhook mocks base method.
+func (m *MockTaskDistribtor) DistributeTaskDeliverWebhook(arg0 context.Context, arg1 *worker.PayloadDeliverWebhook, arg2 ...asynq.Option) error {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "DistributeTaskDeliverWebhook", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DistributeTaskDeliverWebhook indicates an expected call of DistributeTaskDeliverWebhook.
+func (mr *MockTaskDistribtorMockRecorder) DistributeTaskDeliverWebhook(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DistributeTaskDeliverWebhook", reflect.TypeOf((*MockTaskDistribtor)(nil).DistributeTaskDeliverWebhook), varargs...)
+}
+
+// DistributeTaskDispatchWebhookEvent mocks base method.
+func (m *MockTaskDistribtor) DistributeTaskDispatchWebhookEvent(arg0 context.Context, arg1 *worker.PayloadDispatchWebhookEvent, arg2 ...asynq.Option) error {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "DistributeTaskDispatchWebhookEvent", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DistributeTaskDispatchWebhookEvent indicates an expected call of DistributeTaskDispatchWebhookEvent.
+func (mr *MockTaskDistribtorMockRecorder) DistributeTaskDispatchWebhookEvent(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DistributeTaskDispatchWebhookEvent", reflect.TypeOf((*MockTaskDistribtor)(nil).DistributeTaskDispatchWebhookEvent), varargs...)
+}
+
+// DistributeTaskSendBeneficiaryAddedEmail mocks base method.
+func (m *MockTaskDistribtor) DistributeTaskSendBeneficiaryAddedEmail(arg0 context.Context, arg1 *worker.PayloadSendBeneficiaryAddedEmail, arg2 ...asynq.Option) error {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "DistributeTaskSendBeneficiaryAddedEmail", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DistributeTaskSendBeneficiaryAddedEmail indicates an expected call of DistributeTaskSendBeneficiaryAddedEmail.
+func (mr *MockTaskDistribtorMockRecorder) DistributeTaskSendBeneficiaryAddedEmail(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DistributeTaskSendBeneficiaryAddedEmail", reflect.TypeOf((*MockTaskDistribtor)(nil).DistributeTaskSendBeneficiaryAddedEmail), varargs...)
+}
+
+// DistributeTaskSendCreditNotification mocks base method.
+func (m *MockTaskDistribtor) DistributeTaskSendCreditNotification(arg0 context.Context, arg1 *worker.PayloadSendTransferNotification, arg2 ...asynq.Option) error {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "DistributeTaskSendCreditNotification", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DistributeTaskSendCreditNotification indicates an expected call of DistributeTaskSendCreditNotification.
+func (mr *MockTaskDistribtorMockRecorder) DistributeTaskSendCreditNotification(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DistributeTaskSendCreditNotification", reflect.TypeOf((*MockTaskDistribtor)(nil).DistributeTaskSendCreditNotification), varargs...)
+}
+
+// DistributeTaskSendDebitNotification mocks base method.
+func (m *MockTaskDistribtor) DistributeTaskSendDebitNotification(arg0 context.Context, arg1 *worker.PayloadSendTransferNotification, arg2 ...asynq.Option) error {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "DistributeTaskSendDebitNotification", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DistributeTaskSendDebitNotification indicates an expected call of DistributeTaskSendDebitNotification.
+func (mr *MockTaskDistribtorMockRecorder) DistributeTaskSendDebitNotification(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DistributeTaskSendDebitNotification", reflect.TypeOf((*MockTaskDistribtor)(nil).DistributeTaskSendDebitNotification), varargs...)
+}
+
+// DistributeTaskSendEmailChangeNotice mocks base method.
+func (m *MockTaskDistribtor) DistributeTaskSendEmailChangeNotice(arg0 context.Context, arg1 *worker.PayloadSendEmailChangeNotice, arg2 ...asynq.Option) error {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "DistributeTaskSendEmailChangeNotice", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DistributeTaskSendEmailChangeNotice indicates an expected call of DistributeTaskSendEmailChangeNotice.
+func (mr *MockTaskDistribtorMockRecorder) DistributeTaskSendEmailChangeNotice(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DistributeTaskSendEmailChangeNotice", reflect.TypeOf((*MockTaskDistribtor)(nil).DistributeTaskSendEmailChangeNotice), varargs...)
+}
+
+// DistributeTaskSendPaymentRequestEmail mocks base method.
+func (m *MockTaskDistribtor) DistributeTaskSendPaymentRequestEmail(arg0 context.Context, arg1 *worker.PayloadSendPaymentRequestEmail, arg2 ...asynq.Option) error {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "DistributeTaskSendPaymentRequestEmail", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DistributeTaskSendPaymentRequestEmail indicates an expected call of DistributeTaskSendPaymentRequestEmail.
+func (mr *MockTaskDistribtorMockRecorder) DistributeTaskSendPaymentRequestEmail(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DistributeTaskSendPaymentRequestEmail", reflect.TypeOf((*MockTaskDistribtor)(nil).DistributeTaskSendPaymentRequestEmail), varargs...)
+}
+
 // DistributeTaskSendVerifyEmail mocks base method.
 func (m *MockTaskDistribtor) DistributeTaskSendVerifyEmail(arg0 context.Context, arg1 *worker.PayloadSendVerifyEmail, arg2 ...asynq.Option) error {
 	m.ctrl.T.Helper()
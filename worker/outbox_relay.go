@@ -0,0 +1,146 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hibiken/asynq"
+	db "github.com/juker1141/simplebank/db/sqlc"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	outboxBatchSize = 100
+	// outboxDedupWindow is how long a published task is remembered by the queue,
+	// so that publishing it again in that window is a no-op
+	outboxDedupWindow = 24 * time.Hour
+	// outboxRetention is how long published events are kept in the outbox
+	outboxRetention = 7 * 24 * time.Hour
+	outboxPurgeInterval = time.Hour
+	// outboxPublishTimeout bounds the time spent enqueueing a task, so a slow queue doesn't stall the relay
+	outboxPublishTimeout = 5 * time.Second
+	// outboxClaimLease is how long a batch is reserved for the relay, and how long a failed event waits before it is retried
+	outboxClaimLease = 30 * time.Second
+	// outboxMaxAttempts is the number of failures after which an event is dead-lettered,
+	// about 25 minutes of failures with the claim lease between the attempts
+	outboxMaxAttempts = 50
+)
+
+// OutboxRelay publishes the tasks written to the outbox by store transactions.
+// Delivery is at-least-once: if the relay crashes after enqueueing a task but before
+// marking it published, the task is published again and dropped by the queue as a duplicate
+type OutboxRelay struct {
+	store       db.Store
+	distributor TaskDistribtor
+	interval    time.Duration
+	lastPurge   time.Time
+}
+
+func NewOutboxRelay(store db.Store, distributor TaskDistribtor, interval time.Duration) *OutboxRelay {
+	return &OutboxRelay{
+		store: store,
+		distributor: distributor,
+		interval: interval,
+	}
+}
+
+// Run polls the outbox until the context is canceled
+func (relay *OutboxRelay) Run(ctx context.Context) {
+	ticker := time.NewTicker(relay.interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := relay.Relay(ctx); err != nil {
+			log.Error().Err(err).Msg("failed to relay outbox")
+		}
+
+		if err := relay.purge(ctx); err != nil {
+			log.Error().Err(err).Msg("failed to purge outbox")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Relay publishes all pending events and returns the number of published events
+func (relay *OutboxRelay) Relay(ctx context.Context) (int, error) {
+	published := 0
+
+	for {
+		result, err := relay.store.PublishOutbox(ctx, db.PublishOutboxParams{
+			Limit: outboxBatchSize,
+			Lease: outboxClaimLease,
+			RetryIn: outboxClaimLease,
+			MaxAttempts: outboxMaxAttempts,
+			Publish: func(event db.OutboxEvent) error {
+				return relay.publish(ctx, event)
+			},
+		})
+		if err != nil {
+			return published, err
+		}
+
+		published += result.Published
+		if result.DeadLettered > 0 {
+			log.Error().
+				Int("count", result.DeadLettered).
+				Msg("outbox events are dead-lettered after too many failures, replay them with the outbox replay command")
+		}
+		if result.Failed > 0 {
+			return published, fmt.Errorf("failed to publish %d outbox events", result.Failed)
+		}
+		if result.Published < outboxBatchSize {
+			return published, nil
+		}
+	}
+}
+
+func (relay *OutboxRelay) publish(ctx context.Context, event db.OutboxEvent) error {
+	ctx, cancel := context.WithTimeout(ctx, outboxPublishTimeout)
+	defer cancel()
+
+	opts := []asynq.Option{
+		asynq.TaskID(OutboxTaskID(event.ID)),
+		asynq.Queue(event.Queue),
+		asynq.MaxRetry(int(event.MaxRetry)),
+		asynq.ProcessAt(event.ProcessAt),
+		asynq.Retention(outboxDedupWindow),
+	}
+
+	err := relay.distributor.DistributeTask(ctx, event.TaskType, event.Payload, opts...)
+	if errors.Is(err, asynq.ErrTaskIDConflict) {
+		log.Info().
+			Int64("outbox_event_id", event.ID).
+			Str("type", event.TaskType).
+			Msg("outbox event was already published")
+		return nil
+	}
+
+	return err
+}
+
+func (relay *OutboxRelay) purge(ctx context.Context) error {
+	if time.Since(relay.lastPurge) < outboxPurgeInterval {
+		return nil
+	}
+
+	_, err := relay.store.DeletePublishedOutboxEvents(ctx, time.Now().Add(-outboxRetention))
+	if err != nil {
+		return err
+	}
+
+	relay.lastPurge = time.Now()
+	return nil
+}
+
+// OutboxTaskID is the ID of the task published for an outbox event,
+// which lets the queue reject duplicates of the same event
+func OutboxTaskID(eventID int64) string {
+	return fmt.Sprintf("outbox:%d", eventID)
+}
@@ -0,0 +1,114 @@
+package worker_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/hibiken/asynq"
+	mockdb "github.com/juker1141/simplebank/db/mock"
+	db "github.com/juker1141/simplebank/db/sqlc"
+	"github.com/juker1141/simplebank/worker"
+	mockwk "github.com/juker1141/simplebank/worker/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// publishOutbox returns a fake PublishOutbox that publishes the events
+func publishOutbox(events []db.OutboxEvent) func(ctx context.Context, arg db.PublishOutboxParams) (db.PublishOutboxResult, error) {
+	return func(ctx context.Context, arg db.PublishOutboxParams) (db.PublishOutboxResult, error) {
+		var result db.PublishOutboxResult
+		for _, event := range events {
+			if err := arg.Publish(event); err != nil {
+				result.Failed++
+				continue
+			}
+			result.Published++
+		}
+		return result, nil
+	}
+}
+
+func TestOutboxRelay(t *testing.T) {
+	events := []db.OutboxEvent{
+		{ID: 1, TaskType: worker.TaskSendVerifyEmail, Payload: []byte(`{"username":"alice"}`), Queue: worker.QueueCritical, MaxRetry: 10},
+		{ID: 2, TaskType: worker.TaskSendBeneficiaryAddedEmail, Payload: []byte(`{"beneficiary_id":1}`), Queue: worker.QueueDefault},
+	}
+
+	testCases := []struct {
+		name          string
+		distributeErr func(event db.OutboxEvent) error
+		published     int
+		ok            bool
+	}{
+		{
+			name: "OK",
+			distributeErr: func(event db.OutboxEvent) error {
+				return nil
+			},
+			published: 2,
+			ok:        true,
+		},
+		{
+			name: "AlreadyPublished",
+			distributeErr: func(event db.OutboxEvent) error {
+				if event.ID == 1 {
+					return fmt.Errorf("failed to enqueue task: %w", asynq.ErrTaskIDConflict)
+				}
+				return nil
+			},
+			published: 2,
+			ok:        true,
+		},
+		{
+			name: "QueueError",
+			distributeErr: func(event db.OutboxEvent) error {
+				if event.ID == 2 {
+					return errors.New("connection refused")
+				}
+				return nil
+			},
+			published: 1,
+			ok:        false,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			distributor := mockwk.NewMockTaskDistribtor(ctrl)
+
+			store.EXPECT().
+				PublishOutbox(gomock.Any(), gomock.Any()).
+				Times(1).
+				DoAndReturn(publishOutbox(events))
+
+			for _, event := range events {
+				distributor.EXPECT().
+					DistributeTask(gomock.Any(), event.TaskType, event.Payload, gomock.Any()).
+					Times(1).
+					Return(tc.distributeErr(event))
+			}
+
+			relay := worker.NewOutboxRelay(store, distributor, 0)
+			published, err := relay.Relay(context.Background())
+			require.Equal(t, tc.published, published)
+			if tc.ok {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestOutboxTaskID(t *testing.T) {
+	require.Equal(t, "outbox:42", worker.OutboxTaskID(42))
+	require.NotEqual(t, worker.OutboxTaskID(1), worker.OutboxTaskID(2))
+}
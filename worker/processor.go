@@ -2,10 +2,14 @@ package worker
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/hibiken/asynq"
 	db "github.com/juker1141/simplebank/db/sqlc"
 	"github.com/juker1141/simplebank/mail"
+	"github.com/juker1141/simplebank/requestid"
+	"github.com/juker1141/simplebank/webhook"
 	"github.com/rs/zerolog/log"
 )
 
@@ -16,47 +20,148 @@ const (
 
 type TaskProcessor interface {
 	Start() error
+	// Shutdown stops processing new tasks and waits for the active tasks to finish,
+	// up to the shutdown timeout of the processor
+	Shutdown()
 	ProcessTaskSendVerifyEmail(
 		ctx context.Context,
 		task *asynq.Task,
 	) error
+	ProcessTaskSendBeneficiaryAddedEmail(
+		ctx context.Context,
+		task *asynq.Task,
+	) error
+	ProcessTaskSendPaymentRequestEmail(
+		ctx context.Context,
+		task *asynq.Task,
+	) error
+	ProcessTaskSendEmailChangeNotice(
+		ctx context.Context,
+		task *asynq.Task,
+	) error
+	ProcessTaskSendDebitNotification(
+		ctx context.Context,
+		task *asynq.Task,
+	) error
+	ProcessTaskSendCreditNotification(
+		ctx context.Context,
+		task *asynq.Task,
+	) error
+	ProcessTaskDispatchWebhookEvent(
+		ctx context.Context,
+		task *asynq.Task,
+	) error
+	ProcessTaskDeliverWebhook(
+		ctx context.Context,
+		task *asynq.Task,
+	) error
 }
 
-type RedisTaskProcessor struct {
-	server *asynq.Server
+// queuePriorities are the weights of the queues: tasks of a queue with a higher weight are processed more often
+var queuePriorities = map[string]int{
+	QueueCritical: 10,
+	QueueDefault: 5,
+}
+
+// taskHandler processes the tasks, independently of the queue backend they come from
+type taskHandler struct {
 	store  db.Store
 	mailer mail.EmailSender
+	renderer *mail.Renderer
+	webhooks *webhook.Client
 }
 
-func NewRedisTaskProcessor(redisOpt asynq.RedisClientOpt, store db.Store, mailer mail.EmailSender) TaskProcessor {
+type RedisTaskProcessor struct {
+	*taskHandler
+	server *asynq.Server
+}
+
+func NewRedisTaskProcessor(redisOpt asynq.RedisClientOpt, shutdownTimeout time.Duration, store db.Store, mailer mail.EmailSender, renderer *mail.Renderer, webhooks *webhook.Client) TaskProcessor {
 	server := asynq.NewServer(
 		redisOpt,
 		asynq.Config{
-			Queues: map[string]int{
-				QueueCritical: 10,
-				QueueDefault: 5,
-			},
-			ErrorHandler: asynq.ErrorHandlerFunc(func(ctx context.Context, task *asynq.Task, err error) {
-				log.Error().Err(err).
-					Str("type", task.Type()).
-					Bytes("payload", task.Payload()).
-					Msg("process task failed")
-			}),
+			Queues: queuePriorities,
+			RetryDelayFunc: retryDelay,
+			ErrorHandler: asynq.ErrorHandlerFunc(logTaskError),
 			Logger: NewLogger(),
+			// the tasks still active after the timeout are retried by another worker
+			ShutdownTimeout: shutdownTimeout,
 		},
 	)
 
 	return &RedisTaskProcessor{
+		taskHandler: newTaskHandler(store, mailer, renderer, webhooks),
 		server: server,
+	}
+}
+
+func (processor *RedisTaskProcessor) Start() error {
+	return processor.server.Start(processor.mux())
+}
+
+func (processor *RedisTaskProcessor) Shutdown() {
+	processor.server.Shutdown()
+}
+
+func newTaskHandler(store db.Store, mailer mail.EmailSender, renderer *mail.Renderer, webhooks *webhook.Client) *taskHandler {
+	return &taskHandler{
 		store: store,
 		mailer: mailer,
+		renderer: renderer,
+		webhooks: webhooks,
 	}
 }
 
-func (processor *RedisTaskProcessor) Start() error {
+// mux routes the tasks to their handlers
+func (processor *taskHandler) mux() *asynq.ServeMux {
 	mux := asynq.NewServeMux()
+	mux.Use(traceTask, withRequestID, observeTask)
 
 	mux.HandleFunc(TaskSendVerifyEmail, processor.ProcessTaskSendVerifyEmail)
+	mux.HandleFunc(TaskSendBeneficiaryAddedEmail, processor.ProcessTaskSendBeneficiaryAddedEmail)
+	mux.HandleFunc(TaskSendPaymentRequestEmail, processor.ProcessTaskSendPaymentRequestEmail)
+	mux.HandleFunc(TaskSendEmailChangeNotice, processor.ProcessTaskSendEmailChangeNotice)
+	mux.HandleFunc(TaskSendDebitNotification, processor.ProcessTaskSendDebitNotification)
+	mux.HandleFunc(TaskSendCreditNotification, processor.ProcessTaskSendCreditNotification)
+	mux.HandleFunc(TaskDispatchWebhookEvent, processor.ProcessTaskDispatchWebhookEvent)
+	mux.HandleFunc(TaskDeliverWebhook, processor.ProcessTaskDeliverWebhook)
+
+	return mux
+}
+
+// withRequestID is a middleware that tags the log lines of a task with the ID of the request that enqueued it
+func withRequestID(handler asynq.Handler) asynq.Handler {
+	return asynq.HandlerFunc(func(ctx context.Context, task *asynq.Task) error {
+		if id := requestid.FromPayload(task.Payload()); id != "" {
+			ctx = requestid.NewContext(ctx, id)
+		}
+		return handler.ProcessTask(ctx, task)
+	})
+}
+
+func logTaskError(ctx context.Context, task *asynq.Task, err error) {
+	logger := log.Error().Err(err)
+	if id := requestid.FromPayload(task.Payload()); id != "" {
+		logger = logger.Str("request_id", id)
+	}
+
+	logger.Str("type", task.Type()).
+		Bytes("payload", task.Payload()).
+		Msg("process task failed")
+}
+
+// sendEmail renders the email template in the user's language and sends it to the user
+func (processor *taskHandler) sendEmail(user db.User, template string, data any) error {
+	return processor.sendEmailTo(user, user.Email, template, data)
+}
+
+// sendEmailTo renders the email template in the user's language and sends it to the address
+func (processor *taskHandler) sendEmailTo(user db.User, to string, template string, data any) error {
+	message, err := processor.renderer.Render(template, user.Language, data)
+	if err != nil {
+		return fmt.Errorf("failed to render email: %w", err)
+	}
 
-	return processor.server.Start(mux)
+	message.To = []string{to}
+	return processor.mailer.SendMessage(message)
 }
\ No newline at end of file
@@ -0,0 +1,26 @@
+package worker
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hibiken/asynq"
+	"github.com/juker1141/simplebank/requestid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTaskRequestID(t *testing.T) {
+	ids := make(chan string, 1)
+	handler := withRequestID(asynq.HandlerFunc(func(ctx context.Context, task *asynq.Task) error {
+		ids <- requestid.FromContext(ctx)
+		return nil
+	}))
+	queue, distributor := startMemoryQueue(t, MemoryQueueConfig{}, handler)
+
+	ctx := requestid.NewContext(context.Background(), "req-1")
+	err := distributor.DistributeTask(ctx, "task:test", []byte(`{"username":"alice"}`))
+	require.NoError(t, err)
+	waitMemoryQueue(t, queue)
+
+	require.Equal(t, "req-1", <-ids)
+}
@@ -0,0 +1,39 @@
+package worker
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/hibiken/asynq"
+)
+
+const (
+	webhookRetryBaseDelay = 10 * time.Second
+	webhookRetryMaxDelay  = time.Hour
+)
+
+// retryDelay returns how long to wait before retrying a failed task.
+// Webhook deliveries back off exponentially, the other tasks use asynq's back-off
+func retryDelay(n int, err error, task *asynq.Task) time.Duration {
+	if task.Type() == TaskDeliverWebhook {
+		return webhookRetryDelay(n)
+	}
+	return asynq.DefaultRetryDelayFunc(n, err, task)
+}
+
+// webhookRetryDelay doubles the delay after each attempt, from 10 seconds up to an hour,
+// with up to 10% of jitter so the retries of an endpoint that was down don't all happen at once
+func webhookRetryDelay(n int) time.Duration {
+	delay := webhookRetryMaxDelay
+	if n < 1 {
+		n = 1
+	}
+	if n <= 20 {
+		delay = webhookRetryBaseDelay << (n - 1)
+		if delay > webhookRetryMaxDelay {
+			delay = webhookRetryMaxDelay
+		}
+	}
+
+	return delay + time.Duration(rand.Int63n(int64(delay/10)+1))
+}
@@ -0,0 +1,31 @@
+package worker
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookRetryDelay(t *testing.T) {
+	testCases := []struct {
+		retried int
+		delay   time.Duration
+	}{
+		{retried: 1, delay: 10 * time.Second},
+		{retried: 2, delay: 20 * time.Second},
+		{retried: 5, delay: 160 * time.Second},
+		{retried: 9, delay: 2560 * time.Second},
+		{retried: 10, delay: time.Hour},
+		{retried: 100, delay: time.Hour},
+	}
+
+	task := asynq.NewTask(TaskDeliverWebhook, nil)
+	for _, tc := range testCases {
+		delay := retryDelay(tc.retried, errors.New("status 500"), task)
+		require.GreaterOrEqual(t, delay, tc.delay)
+		require.LessOrEqual(t, delay, tc.delay+tc.delay/10)
+	}
+}
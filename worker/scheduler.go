@@ -0,0 +1,179 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	db "github.com/juker1141/simplebank/db/sqlc"
+	"github.com/robfig/cron/v3"
+	"github.com/rs/zerolog/log"
+)
+
+const defaultJobTimeout = 10 * time.Minute
+
+// Job is a periodic job run by the scheduler
+type Job struct {
+	Name string
+	// Schedule is a standard cron expression, or a descriptor such as "@hourly" or "@every 5m".
+	// The job is disabled when it is empty
+	Schedule string
+	// Timeout is how long the job may run, 10 minutes by default.
+	// Another worker can take over the job if it hasn't finished by then
+	Timeout time.Duration
+	// Run runs the job and returns a short summary of what it did
+	Run func(ctx context.Context) (string, error)
+}
+
+type scheduledJob struct {
+	Job
+	schedule cron.Schedule
+}
+
+// Scheduler runs periodic jobs. Several workers can run a scheduler with the same jobs:
+// the schedule of each job is stored in the database, and a worker must acquire a job
+// before running it, so each run of a job happens on a single worker
+type Scheduler struct {
+	store    db.Store
+	interval time.Duration
+	instance string
+	jobs     []scheduledJob
+}
+
+func NewScheduler(store db.Store, interval time.Duration) *Scheduler {
+	hostname, _ := os.Hostname()
+
+	return &Scheduler{
+		store: store,
+		interval: interval,
+		instance: fmt.Sprintf("%s:%s", hostname, uuid.NewString()),
+	}
+}
+
+// Register adds the job to the scheduler, unless its schedule is empty
+func (scheduler *Scheduler) Register(job Job) error {
+	if job.Schedule == "" {
+		log.Info().Str("job", job.Name).Msg("scheduled job is disabled")
+		return nil
+	}
+
+	schedule, err := cron.ParseStandard(job.Schedule)
+	if err != nil {
+		return fmt.Errorf("invalid schedule of job %s: %w", job.Name, err)
+	}
+
+	if job.Timeout <= 0 {
+		job.Timeout = defaultJobTimeout
+	}
+
+	scheduler.jobs = append(scheduler.jobs, scheduledJob{
+		Job: job,
+		schedule: schedule,
+	})
+	return nil
+}
+
+// Run runs the jobs when they are due, until the context is canceled
+func (scheduler *Scheduler) Run(ctx context.Context) error {
+	for _, job := range scheduler.jobs {
+		_, err := scheduler.store.UpsertScheduledJob(ctx, db.UpsertScheduledJobParams{
+			Name: job.Name,
+			Schedule: job.Schedule,
+			NextRunAt: job.schedule.Next(time.Now()),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to register job %s: %w", job.Name, err)
+		}
+	}
+
+	ticker := time.NewTicker(scheduler.interval)
+	defer ticker.Stop()
+
+	for {
+		scheduler.RunDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunDue runs the jobs that are due and not running on another worker,
+// and returns the names of the jobs that were run
+func (scheduler *Scheduler) RunDue(ctx context.Context) []string {
+	var ran []string
+
+	for _, job := range scheduler.jobs {
+		ok, err := scheduler.runJob(ctx, job)
+		if err != nil {
+			log.Error().Err(err).Str("job", job.Name).Msg("failed to run scheduled job")
+		}
+		if ok {
+			ran = append(ran, job.Name)
+		}
+	}
+
+	return ran
+}
+
+// runJob runs the job if this worker can acquire it, and records the result
+func (scheduler *Scheduler) runJob(ctx context.Context, job scheduledJob) (bool, error) {
+	_, err := scheduler.store.AcquireScheduledJob(ctx, db.AcquireScheduledJobParams{
+		Name: job.Name,
+		LockedBy: scheduler.instance,
+		LockedUntil: time.Now().Add(job.Timeout),
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			// the job isn't due, or another worker is running it
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to acquire job: %w", err)
+	}
+
+	jobCtx, cancel := context.WithTimeout(ctx, job.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	result, runErr := job.Run(jobCtx)
+	duration := time.Since(start)
+
+	arg := db.FinishScheduledJobParams{
+		Name: job.Name,
+		LockedBy: scheduler.instance,
+		NextRunAt: job.schedule.Next(time.Now()),
+		LastStatus: db.ScheduledJobStatusSucceeded,
+		LastResult: result,
+		LastDurationMs: duration.Milliseconds(),
+	}
+	if runErr != nil {
+		arg.LastStatus = db.ScheduledJobStatusFailed
+		arg.LastError = runErr.Error()
+	}
+
+	// the result is recorded even if the scheduler is stopping
+	finishCtx, finishCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer finishCancel()
+
+	_, err = scheduler.store.FinishScheduledJob(finishCtx, arg)
+	if err != nil {
+		return true, fmt.Errorf("failed to record job result: %w", err)
+	}
+
+	logger := log.Info()
+	if runErr != nil {
+		logger = log.Error().Err(runErr)
+	}
+	logger.
+		Str("job", job.Name).
+		Str("result", result).
+		Dur("duration", duration).
+		Msg("ran scheduled job")
+
+	return true, nil
+}
@@ -0,0 +1,155 @@
+package worker_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/juker1141/simplebank/db/mock"
+	db "github.com/juker1141/simplebank/db/sqlc"
+	"github.com/juker1141/simplebank/util"
+	"github.com/juker1141/simplebank/worker"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchedulerRegister(t *testing.T) {
+	scheduler := worker.NewScheduler(nil, time.Minute)
+
+	run := func(ctx context.Context) (string, error) {
+		return "", nil
+	}
+
+	require.NoError(t, scheduler.Register(worker.Job{Name: "hourly", Schedule: "@hourly", Run: run}))
+	require.NoError(t, scheduler.Register(worker.Job{Name: "disabled", Schedule: "", Run: run}))
+	require.Error(t, scheduler.Register(worker.Job{Name: "invalid", Schedule: "every minute", Run: run}))
+}
+
+func TestSchedulerRunDue(t *testing.T) {
+	jobErr := errors.New("job failed")
+
+	testCases := []struct {
+		name       string
+		acquireErr error
+		runErr     error
+		buildStubs func(store *mockdb.MockStore)
+		ran        []string
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					FinishScheduledJob(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, arg db.FinishScheduledJobParams) (db.ScheduledJob, error) {
+						require.Equal(t, "job", arg.Name)
+						require.Equal(t, db.ScheduledJobStatusSucceeded, arg.LastStatus)
+						require.Equal(t, "done", arg.LastResult)
+						require.Empty(t, arg.LastError)
+						require.WithinDuration(t, time.Now().Add(time.Hour), arg.NextRunAt, time.Hour)
+						return db.ScheduledJob{}, nil
+					})
+			},
+			ran: []string{"job"},
+		},
+		{
+			name:   "JobFailed",
+			runErr: jobErr,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					FinishScheduledJob(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, arg db.FinishScheduledJobParams) (db.ScheduledJob, error) {
+						require.Equal(t, db.ScheduledJobStatusFailed, arg.LastStatus)
+						require.Equal(t, jobErr.Error(), arg.LastError)
+						return db.ScheduledJob{}, nil
+					})
+			},
+			ran: []string{"job"},
+		},
+		{
+			name:       "NotAcquired",
+			acquireErr: db.ErrRecordNotFound,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					FinishScheduledJob(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+		},
+		{
+			name:       "AcquireError",
+			acquireErr: errors.New("connection refused"),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					FinishScheduledJob(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().
+				AcquireScheduledJob(gomock.Any(), gomock.Any()).
+				Times(1).
+				DoAndReturn(func(ctx context.Context, arg db.AcquireScheduledJobParams) (db.ScheduledJob, error) {
+					require.Equal(t, "job", arg.Name)
+					require.NotEmpty(t, arg.LockedBy)
+					require.True(t, arg.LockedUntil.After(time.Now()))
+					return db.ScheduledJob{Name: arg.Name}, tc.acquireErr
+				})
+			tc.buildStubs(store)
+
+			runs := 0
+			scheduler := worker.NewScheduler(store, time.Minute)
+			err := scheduler.Register(worker.Job{
+				Name:     "job",
+				Schedule: "@hourly",
+				Run: func(ctx context.Context) (string, error) {
+					runs++
+					if tc.runErr != nil {
+						return "", tc.runErr
+					}
+					return "done", nil
+				},
+			})
+			require.NoError(t, err)
+
+			ran := scheduler.RunDue(context.Background())
+			require.Equal(t, tc.ran, ran)
+			require.Equal(t, len(tc.ran), runs)
+		})
+	}
+}
+
+func TestReconcileAccountsJob(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		ListUnbalancedAccounts(gomock.Any()).
+		Times(1).
+		Return([]db.ListUnbalancedAccountsRow{
+			{ID: 1, Currency: util.USD, Balance: 100, EntriesTotal: 90},
+		}, nil)
+
+	var reconcile worker.Job
+	for _, job := range worker.MaintenanceJobs(util.Config{}, store) {
+		if job.Name == worker.JobReconcileAccounts {
+			reconcile = job
+		}
+	}
+	require.NotNil(t, reconcile.Run)
+
+	result, err := reconcile.Run(context.Background())
+	require.Error(t, err)
+	require.Equal(t, "1 accounts are unbalanced", result)
+}
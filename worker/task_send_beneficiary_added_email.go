@@ -0,0 +1,79 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/hibiken/asynq"
+	db "github.com/juker1141/simplebank/db/sqlc"
+	"github.com/juker1141/simplebank/mail"
+	"github.com/juker1141/simplebank/requestid"
+)
+
+const TaskSendBeneficiaryAddedEmail = "task:send_beneficiary_added_email"
+
+type PayloadSendBeneficiaryAddedEmail struct {
+	BeneficiaryID int64 `json:"beneficiary_id"`
+}
+
+func (distributor *RedisTaskDistribtor) DistributeTaskSendBeneficiaryAddedEmail(
+	ctx context.Context,
+	payload *PayloadSendBeneficiaryAddedEmail,
+	opts ...asynq.Option,
+) error {
+	return distributeTask(ctx, distributor, TaskSendBeneficiaryAddedEmail, payload, opts...)
+}
+
+func (distributor *MemoryTaskDistribtor) DistributeTaskSendBeneficiaryAddedEmail(
+	ctx context.Context,
+	payload *PayloadSendBeneficiaryAddedEmail,
+	opts ...asynq.Option,
+) error {
+	return distributeTask(ctx, distributor, TaskSendBeneficiaryAddedEmail, payload, opts...)
+}
+
+func (processor *taskHandler) ProcessTaskSendBeneficiaryAddedEmail(
+	ctx context.Context,
+	task *asynq.Task,
+) error {
+	var payload PayloadSendBeneficiaryAddedEmail
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal payload: %w", asynq.SkipRetry)
+	}
+
+	beneficiary, err := processor.store.GetBeneficiary(ctx, payload.BeneficiaryID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			// the payee was deleted before the email was sent
+			return fmt.Errorf("beneficiary doesn't exist: %w", asynq.SkipRetry)
+		}
+		return fmt.Errorf("failed to get beneficiary: %w", err)
+	}
+
+	user, err := processor.store.GetUser(ctx, beneficiary.Owner)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return fmt.Errorf("user doesn't exist: %w", asynq.SkipRetry)
+		}
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	err = processor.sendEmail(user, mail.TemplateBeneficiaryAdded, mail.BeneficiaryAddedData{
+		FullName: user.FullName,
+		Nickname: beneficiary.Nickname,
+		Currency: beneficiary.Currency,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send beneficiary added email: %w", err)
+	}
+
+	requestid.Logger(ctx).Info().
+		Str("type", task.Type()).
+		Bytes("payload", task.Payload()).
+		Str("email", user.Email).
+		Msg("processed task")
+
+	return nil
+}
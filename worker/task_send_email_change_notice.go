@@ -0,0 +1,71 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/hibiken/asynq"
+	db "github.com/juker1141/simplebank/db/sqlc"
+	"github.com/juker1141/simplebank/mail"
+	"github.com/juker1141/simplebank/requestid"
+)
+
+const TaskSendEmailChangeNotice = "task:send_email_change_notice"
+
+// PayloadSendEmailChangeNotice warns the current address of the user that a change to NewEmail was requested
+type PayloadSendEmailChangeNotice struct {
+	Username string `json:"username"`
+	NewEmail string `json:"new_email"`
+}
+
+func (distributor *RedisTaskDistribtor) DistributeTaskSendEmailChangeNotice(
+	ctx context.Context,
+	payload *PayloadSendEmailChangeNotice,
+	opts ...asynq.Option,
+) error {
+	return distributeTask(ctx, distributor, TaskSendEmailChangeNotice, payload, opts...)
+}
+
+func (distributor *MemoryTaskDistribtor) DistributeTaskSendEmailChangeNotice(
+	ctx context.Context,
+	payload *PayloadSendEmailChangeNotice,
+	opts ...asynq.Option,
+) error {
+	return distributeTask(ctx, distributor, TaskSendEmailChangeNotice, payload, opts...)
+}
+
+func (processor *taskHandler) ProcessTaskSendEmailChangeNotice(
+	ctx context.Context,
+	task *asynq.Task,
+) error {
+	var payload PayloadSendEmailChangeNotice
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal payload: %w", asynq.SkipRetry)
+	}
+
+	user, err := processor.store.GetUser(ctx, payload.Username)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return fmt.Errorf("user doesn't exist: %w", asynq.SkipRetry)
+		}
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	err = processor.sendEmail(user, mail.TemplateEmailChangeRequested, mail.EmailChangeRequestedData{
+		FullName: user.FullName,
+		NewEmail: payload.NewEmail,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send email change notice: %w", err)
+	}
+
+	requestid.Logger(ctx).Info().
+		Str("type", task.Type()).
+		Bytes("payload", task.Payload()).
+		Str("email", user.Email).
+		Msg("processed task")
+
+	return nil
+}
@@ -0,0 +1,104 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/hibiken/asynq"
+	db "github.com/juker1141/simplebank/db/sqlc"
+	"github.com/juker1141/simplebank/mail"
+	"github.com/juker1141/simplebank/requestid"
+)
+
+const TaskSendPaymentRequestEmail = "task:send_payment_request_email"
+
+// PayloadSendPaymentRequestEmail notifies the other side of a payment request
+// about its current status: the payer when it is created,
+// and the payee when it is accepted or declined
+type PayloadSendPaymentRequestEmail struct {
+	PaymentRequestID int64 `json:"payment_request_id"`
+}
+
+func (distributor *RedisTaskDistribtor) DistributeTaskSendPaymentRequestEmail(
+	ctx context.Context,
+	payload *PayloadSendPaymentRequestEmail,
+	opts ...asynq.Option,
+) error {
+	return distributeTask(ctx, distributor, TaskSendPaymentRequestEmail, payload, opts...)
+}
+
+func (distributor *MemoryTaskDistribtor) DistributeTaskSendPaymentRequestEmail(
+	ctx context.Context,
+	payload *PayloadSendPaymentRequestEmail,
+	opts ...asynq.Option,
+) error {
+	return distributeTask(ctx, distributor, TaskSendPaymentRequestEmail, payload, opts...)
+}
+
+func (processor *taskHandler) ProcessTaskSendPaymentRequestEmail(
+	ctx context.Context,
+	task *asynq.Task,
+) error {
+	var payload PayloadSendPaymentRequestEmail
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal payload: %w", asynq.SkipRetry)
+	}
+
+	paymentRequest, err := processor.store.GetPaymentRequest(ctx, payload.PaymentRequestID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return fmt.Errorf("payment request doesn't exist: %w", asynq.SkipRetry)
+		}
+		return fmt.Errorf("failed to get payment request: %w", err)
+	}
+
+	recipient := paymentRequest.Payee
+	if paymentRequest.Status == db.PaymentRequestStatusPending {
+		recipient = paymentRequest.Payer
+	}
+
+	user, err := processor.store.GetUser(ctx, recipient)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return fmt.Errorf("user doesn't exist: %w", asynq.SkipRetry)
+		}
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	data := mail.PaymentRequestData{
+		FullName: user.FullName,
+		Counterparty: paymentRequest.Payer,
+		Amount: paymentRequest.Amount,
+		Currency: paymentRequest.Currency,
+		Memo: paymentRequest.Memo,
+		ExpiredAt: paymentRequest.ExpiredAt,
+	}
+
+	var template string
+	switch paymentRequest.Status {
+	case db.PaymentRequestStatusPending:
+		template = mail.TemplatePaymentRequestReceived
+		data.Counterparty = paymentRequest.Payee
+	case db.PaymentRequestStatusAccepted:
+		template = mail.TemplatePaymentRequestAccepted
+	case db.PaymentRequestStatusDeclined:
+		template = mail.TemplatePaymentRequestDeclined
+	default:
+		return fmt.Errorf("unknown payment request status %s: %w", paymentRequest.Status, asynq.SkipRetry)
+	}
+
+	err = processor.sendEmail(user, template, data)
+	if err != nil {
+		return fmt.Errorf("failed to send payment request email: %w", err)
+	}
+
+	requestid.Logger(ctx).Info().
+		Str("type", task.Type()).
+		Bytes("payload", task.Payload()).
+		Str("email", user.Email).
+		Msg("processed task")
+
+	return nil
+}
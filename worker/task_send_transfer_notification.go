@@ -0,0 +1,234 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/hibiken/asynq"
+	db "github.com/juker1141/simplebank/db/sqlc"
+	"github.com/juker1141/simplebank/mail"
+	"github.com/juker1141/simplebank/requestid"
+	"github.com/juker1141/simplebank/util"
+)
+
+const (
+	TaskSendDebitNotification  = "task:send_debit_notification"
+	TaskSendCreditNotification = "task:send_credit_notification"
+)
+
+// PayloadSendTransferNotification notifies the members of an account involved in a transfer:
+// the sender for a debit notification, and the receiver for a credit notification.
+// Without a Username, the task is split into one task per member of a joint account
+type PayloadSendTransferNotification struct {
+	TransferID int64  `json:"transfer_id"`
+	Username   string `json:"username,omitempty"`
+}
+
+// TransferTasks notifies the members of both accounts and their webhook endpoints once the transfer is committed
+func TransferTasks(result db.TransferTxResult) []db.OutboxTask {
+	payload := &PayloadSendTransferNotification{
+		TransferID: result.Transfer.ID,
+	}
+
+	tasks := []db.OutboxTask{
+		transferNotificationTask(TaskSendDebitNotification, payload),
+		transferNotificationTask(TaskSendCreditNotification, payload),
+	}
+
+	return append(tasks, TransferWebhookTasks(result)...)
+}
+
+func transferNotificationTask(taskType string, payload *PayloadSendTransferNotification) db.OutboxTask {
+	return db.OutboxTask{
+		TaskType: taskType,
+		Payload: payload,
+		Queue: QueueDefault,
+		MaxRetry: 10,
+	}
+}
+
+func (distributor *RedisTaskDistribtor) DistributeTaskSendDebitNotification(
+	ctx context.Context,
+	payload *PayloadSendTransferNotification,
+	opts ...asynq.Option,
+) error {
+	return distributeTask(ctx, distributor, TaskSendDebitNotification, payload, opts...)
+}
+
+func (distributor *MemoryTaskDistribtor) DistributeTaskSendDebitNotification(
+	ctx context.Context,
+	payload *PayloadSendTransferNotification,
+	opts ...asynq.Option,
+) error {
+	return distributeTask(ctx, distributor, TaskSendDebitNotification, payload, opts...)
+}
+
+func (distributor *RedisTaskDistribtor) DistributeTaskSendCreditNotification(
+	ctx context.Context,
+	payload *PayloadSendTransferNotification,
+	opts ...asynq.Option,
+) error {
+	return distributeTask(ctx, distributor, TaskSendCreditNotification, payload, opts...)
+}
+
+func (distributor *MemoryTaskDistribtor) DistributeTaskSendCreditNotification(
+	ctx context.Context,
+	payload *PayloadSendTransferNotification,
+	opts ...asynq.Option,
+) error {
+	return distributeTask(ctx, distributor, TaskSendCreditNotification, payload, opts...)
+}
+
+func (processor *taskHandler) ProcessTaskSendDebitNotification(
+	ctx context.Context,
+	task *asynq.Task,
+) error {
+	return processor.processTransferNotification(ctx, task, util.NotificationEventDebit)
+}
+
+func (processor *taskHandler) ProcessTaskSendCreditNotification(
+	ctx context.Context,
+	task *asynq.Task,
+) error {
+	return processor.processTransferNotification(ctx, task, util.NotificationEventCredit)
+}
+
+// processTransferNotification emails the members of the debited or credited account,
+// unless the notification is disabled or the amount is below the threshold of the member's preference
+func (processor *taskHandler) processTransferNotification(
+	ctx context.Context,
+	task *asynq.Task,
+	event string,
+) error {
+	var payload PayloadSendTransferNotification
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal payload: %w", asynq.SkipRetry)
+	}
+
+	transfer, err := processor.store.GetTransfer(ctx, payload.TransferID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return fmt.Errorf("transfer doesn't exist: %w", asynq.SkipRetry)
+		}
+		return fmt.Errorf("failed to get transfer: %w", err)
+	}
+
+	accountID, counterpartyAccountID := transfer.FromAccountID, transfer.ToAccountID
+	template := mail.TemplateTransferDebit
+	if event == util.NotificationEventCredit {
+		accountID, counterpartyAccountID = transfer.ToAccountID, transfer.FromAccountID
+		template = mail.TemplateTransferCredit
+	}
+
+	account, err := processor.store.GetAccount(ctx, accountID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return fmt.Errorf("account doesn't exist: %w", asynq.SkipRetry)
+		}
+		return fmt.Errorf("failed to get account: %w", err)
+	}
+
+	username := payload.Username
+	if username == "" {
+		recipients, err := processor.transferNotificationRecipients(ctx, account)
+		if err != nil {
+			return err
+		}
+
+		// each member of a joint account is notified by its own task, so a failed email is retried
+		// without sending the others again
+		if len(recipients) > 1 {
+			tasks := make([]db.OutboxTask, 0, len(recipients))
+			for _, recipient := range recipients {
+				tasks = append(tasks, transferNotificationTask(task.Type(), &PayloadSendTransferNotification{
+					TransferID: transfer.ID,
+					Username: recipient,
+				}))
+			}
+
+			if err := processor.store.CreateOutboxEventsTx(ctx, tasks); err != nil {
+				return fmt.Errorf("failed to create member notifications: %w", err)
+			}
+
+			requestid.Logger(ctx).Info().
+				Str("type", task.Type()).
+				Bytes("payload", task.Payload()).
+				Int("members", len(recipients)).
+				Msg("processed task")
+			return nil
+		}
+		username = account.Owner
+	} else if username != account.Owner {
+		// the member may have left the account since the transfer
+		member, err := processor.store.GetAccountMember(ctx, db.GetAccountMemberParams{
+			AccountID: account.ID,
+			Username: username,
+		})
+		if err != nil && !errors.Is(err, db.ErrRecordNotFound) {
+			return fmt.Errorf("failed to get account member: %w", err)
+		}
+		if err != nil || !member.CanView() {
+			return fmt.Errorf("user is no longer a member of the account: %w", asynq.SkipRetry)
+		}
+	}
+
+	preference, err := db.GetEffectiveNotificationPreference(ctx, processor.store, username, event, util.NotificationChannelEmail)
+	if err != nil {
+		return fmt.Errorf("failed to get notification preference: %w", err)
+	}
+
+	if !preference.Allows(transfer.Amount) {
+		requestid.Logger(ctx).Info().
+			Str("type", task.Type()).
+			Bytes("payload", task.Payload()).
+			Str("username", username).
+			Msg("skipped task: notification disabled by user preference")
+		return nil
+	}
+
+	user, err := processor.store.GetUser(ctx, username)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return fmt.Errorf("user doesn't exist: %w", asynq.SkipRetry)
+		}
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	err = processor.sendEmail(user, template, mail.TransferNotificationData{
+		FullName: user.FullName,
+		AccountID: account.ID,
+		CounterpartyAccountID: counterpartyAccountID,
+		Amount: transfer.Amount,
+		Currency: account.Currency,
+		CreatedAt: transfer.CreatedAt,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send %s notification: %w", event, err)
+	}
+
+	requestid.Logger(ctx).Info().
+		Str("type", task.Type()).
+		Bytes("payload", task.Payload()).
+		Str("email", user.Email).
+		Msg("processed task")
+
+	return nil
+}
+
+// transferNotificationRecipients returns the owner of the account and the members who can view it
+func (processor *taskHandler) transferNotificationRecipients(ctx context.Context, account db.Account) ([]string, error) {
+	members, err := processor.store.ListAccountMembers(ctx, account.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list account members: %w", err)
+	}
+
+	recipients := []string{account.Owner}
+	for _, member := range members {
+		if member.Username != account.Owner && member.CanView() {
+			recipients = append(recipients, member.Username)
+		}
+	}
+	return recipients, nil
+}
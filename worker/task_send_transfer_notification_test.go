@@ -0,0 +1,193 @@
+package worker_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/hibiken/asynq"
+	mockdb "github.com/juker1141/simplebank/db/mock"
+	db "github.com/juker1141/simplebank/db/sqlc"
+	"github.com/juker1141/simplebank/mail"
+	"github.com/juker1141/simplebank/util"
+	"github.com/juker1141/simplebank/worker"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingSender keeps the sent messages instead of sending them
+type recordingSender struct {
+	messages []mail.Message
+}
+
+func (sender *recordingSender) SendEmail(subject string, content string, to []string, cc []string, bcc []string, attachFiles []string) error {
+	return sender.SendMessage(mail.Message{Subject: subject, HTML: content, To: to, Cc: cc, Bcc: bcc, AttachFiles: attachFiles})
+}
+
+func (sender *recordingSender) SendMessage(message mail.Message) error {
+	sender.messages = append(sender.messages, message)
+	return nil
+}
+
+func TestProcessTaskSendTransferNotification(t *testing.T) {
+	sender := db.User{Username: util.RandomOwner(), FullName: "Alice", Email: util.RandomEmail(), Language: util.English}
+	receiver := db.User{Username: util.RandomOwner(), FullName: "Bob", Email: util.RandomEmail(), Language: util.English}
+
+	fromAccount := db.Account{ID: 1, Owner: sender.Username, Currency: util.USD}
+	toAccount := db.Account{ID: 2, Owner: receiver.Username, Currency: util.USD}
+	transfer := db.Transfer{ID: 10, FromAccountID: fromAccount.ID, ToAccountID: toAccount.ID, Amount: 100, CreatedAt: time.Now()}
+
+	fromOwner := db.AccountMember{AccountID: fromAccount.ID, Username: sender.Username, Role: util.AccountOwnerRole, IsAccepted: true}
+	toOwner := db.AccountMember{AccountID: toAccount.ID, Username: receiver.Username, Role: util.AccountOwnerRole, IsAccepted: true}
+
+	// a joint member of the sending account, and a member who didn't accept the invitation yet
+	member := db.User{Username: util.RandomOwner(), FullName: "Carol", Email: util.RandomEmail(), Language: util.English}
+	fromMember := db.AccountMember{AccountID: fromAccount.ID, Username: member.Username, Role: util.AccountViewOnlyRole, IsAccepted: true}
+	invited := db.AccountMember{AccountID: fromAccount.ID, Username: util.RandomOwner(), Role: util.AccountViewOnlyRole}
+
+	testCases := []struct {
+		name       string
+		taskType   string
+		username   string
+		buildStubs func(store *mockdb.MockStore)
+		sentTo     []string
+	}{
+		{
+			name:     "Debit",
+			taskType: worker.TaskSendDebitNotification,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAccount.ID)).Times(1).Return(fromAccount, nil)
+				store.EXPECT().ListAccountMembers(gomock.Any(), gomock.Eq(fromAccount.ID)).Times(1).Return([]db.AccountMember{fromOwner}, nil)
+				store.EXPECT().
+					GetNotificationPreference(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.NotificationPreference{}, db.ErrRecordNotFound)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(sender.Username)).Times(1).Return(sender, nil)
+			},
+			sentTo: []string{sender.Email},
+		},
+		{
+			name:     "Credit",
+			taskType: worker.TaskSendCreditNotification,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount.ID)).Times(1).Return(toAccount, nil)
+				store.EXPECT().ListAccountMembers(gomock.Any(), gomock.Eq(toAccount.ID)).Times(1).Return([]db.AccountMember{toOwner}, nil)
+				store.EXPECT().
+					GetNotificationPreference(gomock.Any(), gomock.Eq(db.GetNotificationPreferenceParams{
+						Username:  receiver.Username,
+						EventType: util.NotificationEventCredit,
+						Channel:   util.NotificationChannelEmail,
+					})).
+					Times(1).
+					Return(db.NotificationPreference{IsEnabled: true, MinAmount: 100}, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(receiver.Username)).Times(1).Return(receiver, nil)
+			},
+			sentTo: []string{receiver.Email},
+		},
+		{
+			name:     "BelowMinAmount",
+			taskType: worker.TaskSendCreditNotification,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount.ID)).Times(1).Return(toAccount, nil)
+				store.EXPECT().ListAccountMembers(gomock.Any(), gomock.Eq(toAccount.ID)).Times(1).Return([]db.AccountMember{toOwner}, nil)
+				store.EXPECT().
+					GetNotificationPreference(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.NotificationPreference{IsEnabled: true, MinAmount: 101}, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+		},
+		{
+			name:     "Disabled",
+			taskType: worker.TaskSendDebitNotification,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAccount.ID)).Times(1).Return(fromAccount, nil)
+				store.EXPECT().ListAccountMembers(gomock.Any(), gomock.Eq(fromAccount.ID)).Times(1).Return([]db.AccountMember{fromOwner}, nil)
+				store.EXPECT().
+					GetNotificationPreference(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.NotificationPreference{IsEnabled: false}, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+		},
+		{
+			name:     "JointAccount",
+			taskType: worker.TaskSendDebitNotification,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAccount.ID)).Times(1).Return(fromAccount, nil)
+				store.EXPECT().
+					ListAccountMembers(gomock.Any(), gomock.Eq(fromAccount.ID)).
+					Times(1).
+					Return([]db.AccountMember{fromOwner, fromMember, invited}, nil)
+				store.EXPECT().
+					CreateOutboxEventsTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, tasks []db.OutboxTask) error {
+						// the accepted members are notified by their own tasks
+						require.Len(t, tasks, 2)
+						for i, username := range []string{sender.Username, member.Username} {
+							require.Equal(t, worker.TaskSendDebitNotification, tasks[i].TaskType)
+							require.Equal(t, &worker.PayloadSendTransferNotification{TransferID: transfer.ID, Username: username}, tasks[i].Payload)
+						}
+						return nil
+					})
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+		},
+		{
+			name:     "Member",
+			taskType: worker.TaskSendDebitNotification,
+			username: member.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAccount.ID)).Times(1).Return(fromAccount, nil)
+				store.EXPECT().
+					GetAccountMember(gomock.Any(), gomock.Eq(db.GetAccountMemberParams{AccountID: fromAccount.ID, Username: member.Username})).
+					Times(1).
+					Return(fromMember, nil)
+				store.EXPECT().
+					GetNotificationPreference(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.NotificationPreference{}, db.ErrRecordNotFound)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(member.Username)).Times(1).Return(member, nil)
+			},
+			sentTo: []string{member.Email},
+		},
+	}
+
+	renderer, err := mail.NewRenderer("http://localhost:8080")
+	require.NoError(t, err)
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().GetTransfer(gomock.Any(), gomock.Eq(transfer.ID)).Times(1).Return(transfer, nil)
+			tc.buildStubs(store)
+
+			mailer := &recordingSender{}
+			processor := worker.NewMemoryTaskProcessor(worker.NewMemoryQueue(worker.MemoryQueueConfig{}), store, mailer, renderer, nil)
+
+			payload, err := json.Marshal(&worker.PayloadSendTransferNotification{TransferID: transfer.ID, Username: tc.username})
+			require.NoError(t, err)
+
+			task := asynq.NewTask(tc.taskType, payload)
+			if tc.taskType == worker.TaskSendDebitNotification {
+				err = processor.ProcessTaskSendDebitNotification(context.Background(), task)
+			} else {
+				err = processor.ProcessTaskSendCreditNotification(context.Background(), task)
+			}
+			require.NoError(t, err)
+
+			require.Len(t, mailer.messages, len(tc.sentTo))
+			for i, message := range mailer.messages {
+				require.Equal(t, []string{tc.sentTo[i]}, message.To)
+				require.Contains(t, message.Text, "100 USD")
+			}
+		})
+	}
+}
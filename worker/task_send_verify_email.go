@@ -5,17 +5,21 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/hibiken/asynq"
 	db "github.com/juker1141/simplebank/db/sqlc"
-	"github.com/juker1141/simplebank/util"
-	"github.com/rs/zerolog/log"
+	"github.com/juker1141/simplebank/mail"
+	"github.com/juker1141/simplebank/requestid"
 )
 
 const TaskSendVerifyEmail = "task:send_verify_email"
 
+// PayloadSendVerifyEmail sends the verification code of the user,
+// which is created with the task
 type PayloadSendVerifyEmail struct {
-	Username string `json:"username"`
+	Username      string `json:"username"`
+	VerifyEmailID int64  `json:"verify_email_id"`
 }
 
 func (distributor *RedisTaskDistribtor) DistributeTaskSendVerifyEmail (
@@ -23,28 +27,18 @@ func (distributor *RedisTaskDistribtor) DistributeTaskSendVerifyEmail (
 	payload *PayloadSendVerifyEmail,
 	opts ...asynq.Option,
 ) error {
-	jsonPayload, err := json.Marshal(payload)
-	if err != nil {
-		return fmt.Errorf("failed to marshal task payload: %w", err)
-	}
-
-	task := asynq.NewTask(TaskSendVerifyEmail, jsonPayload, opts...)
-	info, err := distributor.client.EnqueueContext(ctx, task)
-	if err != nil {
-		return fmt.Errorf("failed to enqueue task: %w", err)
-	}
-
-	log.Info().
-		Str("type", task.Type()).
-		Bytes("payload", task.Payload()).
-		Str("queue", info.Queue).
-		Int("max_retry", info.MaxRetry).
-		Msg("enqueued task")
+	return distributeTask(ctx, distributor, TaskSendVerifyEmail, payload, opts...)
+}
 
-	return nil
+func (distributor *MemoryTaskDistribtor) DistributeTaskSendVerifyEmail(
+	ctx context.Context,
+	payload *PayloadSendVerifyEmail,
+	opts ...asynq.Option,
+) error {
+	return distributeTask(ctx, distributor, TaskSendVerifyEmail, payload, opts...)
 }
 
-func (processor *RedisTaskProcessor) ProcessTaskSendVerifyEmail(
+func (processor *taskHandler) ProcessTaskSendVerifyEmail(
 	ctx context.Context,
 	task *asynq.Task,
 ) error {
@@ -61,33 +55,37 @@ func (processor *RedisTaskProcessor) ProcessTaskSendVerifyEmail(
 		return fmt.Errorf("failed to get user: %w", err)
 	}
 
-	verifyEmail, err := processor.store.CreateVerifyEmail(ctx, db.CreateVerifyEmailParams{
-		Username: user.Username,
-		Email: user.Email,
-		SecretCode: util.RandomString(32),
-	})
+	// the code is created with the task, so that the retries send the same one
+	if payload.VerifyEmailID == 0 {
+		return fmt.Errorf("verify email is missing: %w", asynq.SkipRetry)
+	}
 
+	verifyEmail, err := processor.store.GetVerifyEmail(ctx, payload.VerifyEmailID)
 	if err != nil {
-		return fmt.Errorf("failed to create verify email: %w", err)
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return fmt.Errorf("verify email doesn't exist: %w", asynq.SkipRetry)
+		}
+		return fmt.Errorf("failed to get verify email: %w", err)
 	}
 
-	subject := "Welcome to Simple Bank"
-	verifyUrl := fmt.Sprintf("http://localhost:8080/v1/verify_email?email_id=%d&secret_code=%s", verifyEmail.ID, verifyEmail.SecretCode)
-	content := fmt.Sprintf(`Hello %s,<br/>
-	Thank you for registering with us!<br/>
-	Please <a href="%s">click here</a> to verify your email address.<br/>
-	`, user.FullName, verifyUrl)
-	to := []string{user.Email}
+	// the code was used, or replaced by a newer one
+	if verifyEmail.IsUsed || verifyEmail.ExpiredAt.Before(time.Now()) {
+		return fmt.Errorf("verify email is no longer valid: %w", asynq.SkipRetry)
+	}
 
-	err = processor.mailer.SendEmail(subject, content, to, nil, nil, nil)
+	err = processor.sendEmailTo(user, verifyEmail.Email, mail.TemplateVerifyEmail, mail.VerifyEmailData{
+		FullName: user.FullName,
+		EmailID: verifyEmail.ID,
+		SecretCode: verifyEmail.SecretCode,
+	})
 	if err != nil {
 		return fmt.Errorf("failed to send verify email: %w", err)
 	}
 
-	log.Info().
+	requestid.Logger(ctx).Info().
 		Str("type", task.Type()).
 		Bytes("payload", task.Payload()).
-		Str("email", user.Email).
+		Str("email", verifyEmail.Email).
 		Msg("processed task")
 	
 	return nil
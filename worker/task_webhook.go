@@ -0,0 +1,322 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/juker1141/simplebank/db/sqlc"
+	"github.com/juker1141/simplebank/requestid"
+	"github.com/juker1141/simplebank/util"
+	"github.com/juker1141/simplebank/webhook"
+)
+
+const (
+	TaskDispatchWebhookEvent = "task:dispatch_webhook_event"
+	TaskDeliverWebhook       = "task:deliver_webhook"
+)
+
+// WebhookMaxRetry is the number of retries of a webhook delivery,
+// with the back-off of webhookRetryDelay they span about eight hours
+const WebhookMaxRetry = 16
+
+// PayloadDispatchWebhookEvent creates the deliveries of the event to the endpoints subscribed to it.
+// The endpoints of the users and the endpoints without owner receive the event.
+// The members who can view the accounts receive it too, they are listed when the event is dispatched
+// so they don't have to be looked up in the transaction of the change
+type PayloadDispatchWebhookEvent struct {
+	Event      webhook.Event `json:"event"`
+	Usernames  []string      `json:"usernames"`
+	AccountIDs []int64       `json:"account_ids,omitempty"`
+}
+
+type PayloadDeliverWebhook struct {
+	DeliveryID int64 `json:"delivery_id"`
+}
+
+// WebhookEventTask returns the outbox task that dispatches a new event about the users
+func WebhookEventTask(eventType string, usernames []string, data any) db.OutboxTask {
+	return db.OutboxTask{
+		TaskType: TaskDispatchWebhookEvent,
+		Payload: &PayloadDispatchWebhookEvent{
+			Event: webhook.Event{
+				ID: uuid.NewString(),
+				Type: eventType,
+				CreatedAt: time.Now(),
+				Data: data,
+			},
+			Usernames: usernames,
+		},
+		Queue: QueueDefault,
+		MaxRetry: 10,
+	}
+}
+
+// accountWebhookEventTask returns the outbox task that dispatches a new event about the accounts,
+// to their owners and the members who can view them
+func accountWebhookEventTask(eventType string, accounts []db.Account, data any) db.OutboxTask {
+	var owners []string
+	var accountIDs []int64
+	for i, account := range accounts {
+		if i == 0 || account.Owner != accounts[0].Owner {
+			owners = append(owners, account.Owner)
+		}
+		accountIDs = append(accountIDs, account.ID)
+	}
+
+	task := WebhookEventTask(eventType, owners, data)
+	task.Payload.(*PayloadDispatchWebhookEvent).AccountIDs = accountIDs
+	return task
+}
+
+// TransferWebhookTasks returns the events of a transfer: the transfer itself,
+// and the balance change of both accounts
+func TransferWebhookTasks(result db.TransferTxResult) []db.OutboxTask {
+	accounts := []db.Account{result.FromAccount, result.ToAccount}
+
+	return []db.OutboxTask{
+		accountWebhookEventTask(util.WebhookEventTransferCreated, accounts, result.Transfer),
+		AccountUpdatedWebhookTask(result.FromAccount),
+		AccountUpdatedWebhookTask(result.ToAccount),
+	}
+}
+
+// AccountUpdatedWebhookTask returns the event of a change of the account
+func AccountUpdatedWebhookTask(account db.Account) db.OutboxTask {
+	return accountWebhookEventTask(util.WebhookEventAccountUpdated, []db.Account{account}, account)
+}
+
+// WebhookDeliveryTask returns the outbox task that delivers the webhook
+func WebhookDeliveryTask(delivery db.WebhookDelivery) db.OutboxTask {
+	return db.OutboxTask{
+		TaskType: TaskDeliverWebhook,
+		Payload: &PayloadDeliverWebhook{
+			DeliveryID: delivery.ID,
+		},
+		Queue: QueueDefault,
+		MaxRetry: WebhookMaxRetry,
+	}
+}
+
+func (distributor *RedisTaskDistribtor) DistributeTaskDispatchWebhookEvent(
+	ctx context.Context,
+	payload *PayloadDispatchWebhookEvent,
+	opts ...asynq.Option,
+) error {
+	return distributeTask(ctx, distributor, TaskDispatchWebhookEvent, payload, opts...)
+}
+
+func (distributor *MemoryTaskDistribtor) DistributeTaskDispatchWebhookEvent(
+	ctx context.Context,
+	payload *PayloadDispatchWebhookEvent,
+	opts ...asynq.Option,
+) error {
+	return distributeTask(ctx, distributor, TaskDispatchWebhookEvent, payload, opts...)
+}
+
+func (distributor *RedisTaskDistribtor) DistributeTaskDeliverWebhook(
+	ctx context.Context,
+	payload *PayloadDeliverWebhook,
+	opts ...asynq.Option,
+) error {
+	return distributeTask(ctx, distributor, TaskDeliverWebhook, payload, opts...)
+}
+
+func (distributor *MemoryTaskDistribtor) DistributeTaskDeliverWebhook(
+	ctx context.Context,
+	payload *PayloadDeliverWebhook,
+	opts ...asynq.Option,
+) error {
+	return distributeTask(ctx, distributor, TaskDeliverWebhook, payload, opts...)
+}
+
+func (processor *taskHandler) ProcessTaskDispatchWebhookEvent(
+	ctx context.Context,
+	task *asynq.Task,
+) error {
+	var payload PayloadDispatchWebhookEvent
+	// numbers are kept as they are, so the amounts of the event aren't rounded
+	decoder := json.NewDecoder(bytes.NewReader(task.Payload()))
+	decoder.UseNumber()
+	if err := decoder.Decode(&payload); err != nil {
+		return fmt.Errorf("failed to unmarshal payload: %w", asynq.SkipRetry)
+	}
+
+	usernames, err := processor.webhookEventRecipients(ctx, payload)
+	if err != nil {
+		return err
+	}
+
+	endpoints, err := processor.store.ListWebhookEndpointsForEvent(ctx, db.ListWebhookEndpointsForEventParams{
+		EventType: payload.Event.Type,
+		Usernames: usernames,
+		ApiKeyScope: util.APIKeyScopeWebhooks,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list webhook endpoints: %w", err)
+	}
+
+	if len(endpoints) == 0 {
+		return nil
+	}
+
+	body, err := json.Marshal(payload.Event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook event: %w", asynq.SkipRetry)
+	}
+
+	arg := db.CreateWebhookDeliveriesTxParams{
+		Tasks: func(delivery db.WebhookDelivery) []db.OutboxTask {
+			return []db.OutboxTask{WebhookDeliveryTask(delivery)}
+		},
+	}
+	for _, endpoint := range endpoints {
+		arg.Deliveries = append(arg.Deliveries, db.CreateWebhookDeliveryParams{
+			EndpointID: endpoint.ID,
+			EventID: payload.Event.ID,
+			EventType: payload.Event.Type,
+			Payload: body,
+		})
+	}
+
+	result, err := processor.store.CreateWebhookDeliveriesTx(ctx, arg)
+	if err != nil {
+		return fmt.Errorf("failed to create webhook deliveries: %w", err)
+	}
+
+	requestid.Logger(ctx).Info().
+		Str("type", task.Type()).
+		Str("event_id", payload.Event.ID).
+		Str("event_type", payload.Event.Type).
+		Int("deliveries", len(result.Deliveries)).
+		Msg("processed task")
+
+	return nil
+}
+
+// webhookEventRecipients returns the users of the event and the members who can view its accounts
+func (processor *taskHandler) webhookEventRecipients(ctx context.Context, payload PayloadDispatchWebhookEvent) ([]string, error) {
+	recipients := append([]string{}, payload.Usernames...)
+	seen := make(map[string]bool, len(recipients))
+	for _, username := range recipients {
+		seen[username] = true
+	}
+
+	for _, accountID := range payload.AccountIDs {
+		members, err := processor.store.ListAccountMembers(ctx, accountID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list account members: %w", err)
+		}
+
+		for _, member := range members {
+			if member.CanView() && !seen[member.Username] {
+				seen[member.Username] = true
+				recipients = append(recipients, member.Username)
+			}
+		}
+	}
+	return recipients, nil
+}
+
+// ProcessTaskDeliverWebhook posts the delivery to its endpoint and records the attempt.
+// It fails when the endpoint doesn't respond with a 2xx status, so the delivery is retried with a back-off
+func (processor *taskHandler) ProcessTaskDeliverWebhook(
+	ctx context.Context,
+	task *asynq.Task,
+) error {
+	var payload PayloadDeliverWebhook
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal payload: %w", asynq.SkipRetry)
+	}
+
+	delivery, err := processor.store.GetWebhookDelivery(ctx, payload.DeliveryID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return fmt.Errorf("webhook delivery doesn't exist: %w", asynq.SkipRetry)
+		}
+		return fmt.Errorf("failed to get webhook delivery: %w", err)
+	}
+
+	if delivery.Status == db.WebhookDeliveryStatusSucceeded {
+		return nil
+	}
+
+	endpoint, err := processor.store.GetWebhookEndpoint(ctx, delivery.EndpointID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return fmt.Errorf("webhook endpoint doesn't exist: %w", asynq.SkipRetry)
+		}
+		return fmt.Errorf("failed to get webhook endpoint: %w", err)
+	}
+
+	if !endpoint.IsActive {
+		_, err = processor.store.UpdateWebhookDeliveryStatus(ctx, db.UpdateWebhookDeliveryStatusParams{
+			ID: delivery.ID,
+			Status: db.WebhookDeliveryStatusFailed,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to update webhook delivery: %w", err)
+		}
+		return fmt.Errorf("webhook endpoint is deleted: %w", asynq.SkipRetry)
+	}
+
+	rsp, sendErr := processor.webhooks.Send(ctx, webhook.Request{
+		URL: endpoint.Url,
+		Secret: endpoint.Secret,
+		ID: delivery.EventID,
+		Event: delivery.EventType,
+		Body: delivery.Payload,
+	})
+	if sendErr == nil && !rsp.Success() {
+		sendErr = fmt.Errorf("endpoint responded with status %d", rsp.StatusCode)
+	}
+
+	attemptArg := db.CreateWebhookDeliveryAttemptParams{
+		DeliveryID: delivery.ID,
+		ResponseStatus: int32(rsp.StatusCode),
+		DurationMs: rsp.Duration.Milliseconds(),
+	}
+	statusArg := db.UpdateWebhookDeliveryStatusParams{
+		ID: delivery.ID,
+		Status: db.WebhookDeliveryStatusSucceeded,
+		Attempted: 1,
+	}
+	if sendErr != nil {
+		attemptArg.Error = sendErr.Error()
+		statusArg.Status = db.WebhookDeliveryStatusFailed
+	} else {
+		statusArg.DeliveredAt = pgtype.Timestamptz{
+			Time: time.Now(),
+			Valid: true,
+		}
+	}
+
+	_, err = processor.store.CreateWebhookDeliveryAttempt(ctx, attemptArg)
+	if err != nil {
+		return fmt.Errorf("failed to create webhook delivery attempt: %w", err)
+	}
+
+	_, err = processor.store.UpdateWebhookDeliveryStatus(ctx, statusArg)
+	if err != nil {
+		return fmt.Errorf("failed to update webhook delivery: %w", err)
+	}
+
+	if sendErr != nil {
+		return fmt.Errorf("failed to deliver webhook: %w", sendErr)
+	}
+
+	requestid.Logger(ctx).Info().
+		Str("type", task.Type()).
+		Bytes("payload", task.Payload()).
+		Str("url", endpoint.Url).
+		Int("status", rsp.StatusCode).
+		Msg("processed task")
+
+	return nil
+}
@@ -0,0 +1,261 @@
+package worker_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/hibiken/asynq"
+	mockdb "github.com/juker1141/simplebank/db/mock"
+	db "github.com/juker1141/simplebank/db/sqlc"
+	"github.com/juker1141/simplebank/mail"
+	"github.com/juker1141/simplebank/util"
+	"github.com/juker1141/simplebank/webhook"
+	"github.com/juker1141/simplebank/worker"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessTaskDispatchWebhookEvent(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	username := util.RandomOwner()
+	account := db.Account{ID: 1, Owner: username, Balance: 9007199254740993, Currency: util.USD}
+	task := worker.AccountUpdatedWebhookTask(account)
+	payload, err := json.Marshal(task.Payload)
+	require.NoError(t, err)
+
+	endpoints := []db.WebhookEndpoint{{ID: 1}, {ID: 2}}
+
+	// the members who can view the account receive the event with the owner, the pending invitations don't
+	viewer := db.AccountMember{AccountID: account.ID, Username: util.RandomOwner(), Role: util.AccountViewOnlyRole, IsAccepted: true}
+	invited := db.AccountMember{AccountID: account.ID, Username: util.RandomOwner(), Role: util.AccountViewOnlyRole}
+	members := []db.AccountMember{
+		{AccountID: account.ID, Username: username, Role: util.AccountOwnerRole, IsAccepted: true},
+		viewer,
+		invited,
+	}
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		ListAccountMembers(gomock.Any(), gomock.Eq(account.ID)).
+		Times(1).
+		Return(members, nil)
+	store.EXPECT().
+		ListWebhookEndpointsForEvent(gomock.Any(), gomock.Eq(db.ListWebhookEndpointsForEventParams{
+			EventType: util.WebhookEventAccountUpdated,
+			Usernames: []string{username, viewer.Username},
+			ApiKeyScope: util.APIKeyScopeWebhooks,
+		})).
+		Times(1).
+		Return(endpoints, nil)
+	store.EXPECT().
+		CreateWebhookDeliveriesTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(ctx context.Context, arg db.CreateWebhookDeliveriesTxParams) (db.CreateWebhookDeliveriesTxResult, error) {
+			require.Len(t, arg.Deliveries, len(endpoints))
+			for i, delivery := range arg.Deliveries {
+				require.Equal(t, endpoints[i].ID, delivery.EndpointID)
+				require.Equal(t, util.WebhookEventAccountUpdated, delivery.EventType)
+				require.NotEmpty(t, delivery.EventID)
+
+				// the amounts aren't rounded when the event is decoded and encoded again
+				require.Contains(t, string(delivery.Payload), `"balance":9007199254740993`)
+			}
+
+			tasks := arg.Tasks(db.WebhookDelivery{ID: 5})
+			require.Len(t, tasks, 1)
+			require.Equal(t, worker.TaskDeliverWebhook, tasks[0].TaskType)
+			require.Equal(t, &worker.PayloadDeliverWebhook{DeliveryID: 5}, tasks[0].Payload)
+
+			return db.CreateWebhookDeliveriesTxResult{}, nil
+		})
+
+	processor := newWebhookTestProcessor(t, store)
+	err = processor.ProcessTaskDispatchWebhookEvent(context.Background(), asynq.NewTask(worker.TaskDispatchWebhookEvent, payload))
+	require.NoError(t, err)
+}
+
+func TestTransferWebhookTasks(t *testing.T) {
+	result := db.TransferTxResult{
+		Transfer: db.Transfer{ID: 1, FromAccountID: 1, ToAccountID: 2, Amount: 10},
+		FromAccount: db.Account{ID: 1, Owner: util.RandomOwner()},
+		ToAccount: db.Account{ID: 2, Owner: util.RandomOwner()},
+	}
+
+	tasks := worker.TransferWebhookTasks(result)
+	require.Len(t, tasks, 3)
+
+	// the transfer is sent to both accounts, and the balance of each account to its own users only
+	expected := []struct {
+		eventType  string
+		usernames  []string
+		accountIDs []int64
+	}{
+		{util.WebhookEventTransferCreated, []string{result.FromAccount.Owner, result.ToAccount.Owner}, []int64{1, 2}},
+		{util.WebhookEventAccountUpdated, []string{result.FromAccount.Owner}, []int64{1}},
+		{util.WebhookEventAccountUpdated, []string{result.ToAccount.Owner}, []int64{2}},
+	}
+	for i, task := range tasks {
+		payload, ok := task.Payload.(*worker.PayloadDispatchWebhookEvent)
+		require.True(t, ok)
+		require.Equal(t, expected[i].eventType, payload.Event.Type)
+		require.Equal(t, expected[i].usernames, payload.Usernames)
+		require.Equal(t, expected[i].accountIDs, payload.AccountIDs)
+	}
+}
+
+func TestProcessTaskDeliverWebhook(t *testing.T) {
+	secret := "whsec_test"
+	body := []byte(`{"id":"event","type":"transfer.created","data":{}}`)
+
+	testCases := []struct {
+		name          string
+		status        int
+		isActive      bool
+		checkAttempt  func(t *testing.T, arg db.CreateWebhookDeliveryAttemptParams)
+		checkStatus   func(t *testing.T, arg db.UpdateWebhookDeliveryStatusParams)
+		checkResult   func(t *testing.T, received int, err error)
+	}{
+		{
+			name:     "OK",
+			status:   http.StatusOK,
+			isActive: true,
+			checkAttempt: func(t *testing.T, arg db.CreateWebhookDeliveryAttemptParams) {
+				require.Equal(t, int32(http.StatusOK), arg.ResponseStatus)
+				require.Empty(t, arg.Error)
+			},
+			checkStatus: func(t *testing.T, arg db.UpdateWebhookDeliveryStatusParams) {
+				require.Equal(t, db.WebhookDeliveryStatusSucceeded, arg.Status)
+				require.Equal(t, int32(1), arg.Attempted)
+				require.True(t, arg.DeliveredAt.Valid)
+			},
+			checkResult: func(t *testing.T, received int, err error) {
+				require.NoError(t, err)
+				require.Equal(t, 1, received)
+			},
+		},
+		{
+			name:     "ServerError",
+			status:   http.StatusInternalServerError,
+			isActive: true,
+			checkAttempt: func(t *testing.T, arg db.CreateWebhookDeliveryAttemptParams) {
+				require.Equal(t, int32(http.StatusInternalServerError), arg.ResponseStatus)
+				require.Contains(t, arg.Error, "500")
+			},
+			checkStatus: func(t *testing.T, arg db.UpdateWebhookDeliveryStatusParams) {
+				require.Equal(t, db.WebhookDeliveryStatusFailed, arg.Status)
+				require.Equal(t, int32(1), arg.Attempted)
+				require.False(t, arg.DeliveredAt.Valid)
+			},
+			checkResult: func(t *testing.T, received int, err error) {
+				// the task fails so it is retried
+				require.Error(t, err)
+				require.False(t, errors.Is(err, asynq.SkipRetry))
+				require.Equal(t, 1, received)
+			},
+		},
+		{
+			name:     "InactiveEndpoint",
+			isActive: false,
+			checkStatus: func(t *testing.T, arg db.UpdateWebhookDeliveryStatusParams) {
+				require.Equal(t, db.WebhookDeliveryStatusFailed, arg.Status)
+				require.Zero(t, arg.Attempted)
+			},
+			checkResult: func(t *testing.T, received int, err error) {
+				require.ErrorIs(t, err, asynq.SkipRetry)
+				require.Zero(t, received)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			received := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				received++
+
+				receivedBody, err := io.ReadAll(r.Body)
+				require.NoError(t, err)
+				require.Equal(t, body, receivedBody)
+				require.Equal(t, "event", r.Header.Get(webhook.HeaderID))
+				require.Equal(t, util.WebhookEventTransferCreated, r.Header.Get(webhook.HeaderEvent))
+
+				err = webhook.Verify(secret, r.Header.Get(webhook.HeaderSignature), r.Header.Get(webhook.HeaderTimestamp), receivedBody, time.Minute)
+				require.NoError(t, err)
+
+				w.WriteHeader(tc.status)
+			}))
+			defer server.Close()
+
+			delivery := db.WebhookDelivery{
+				ID: 3,
+				EndpointID: 9,
+				EventID: "event",
+				EventType: util.WebhookEventTransferCreated,
+				Payload: body,
+				Status: db.WebhookDeliveryStatusPending,
+			}
+			endpoint := db.WebhookEndpoint{
+				ID: delivery.EndpointID,
+				Url: server.URL,
+				Secret: secret,
+				IsActive: tc.isActive,
+			}
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().GetWebhookDelivery(gomock.Any(), gomock.Eq(delivery.ID)).Times(1).Return(delivery, nil)
+			store.EXPECT().GetWebhookEndpoint(gomock.Any(), gomock.Eq(endpoint.ID)).Times(1).Return(endpoint, nil)
+
+			if tc.checkAttempt != nil {
+				store.EXPECT().
+					CreateWebhookDeliveryAttempt(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, arg db.CreateWebhookDeliveryAttemptParams) (db.WebhookDeliveryAttempt, error) {
+						require.Equal(t, delivery.ID, arg.DeliveryID)
+						tc.checkAttempt(t, arg)
+						return db.WebhookDeliveryAttempt{}, nil
+					})
+			} else {
+				store.EXPECT().CreateWebhookDeliveryAttempt(gomock.Any(), gomock.Any()).Times(0)
+			}
+
+			store.EXPECT().
+				UpdateWebhookDeliveryStatus(gomock.Any(), gomock.Any()).
+				Times(1).
+				DoAndReturn(func(ctx context.Context, arg db.UpdateWebhookDeliveryStatusParams) (db.WebhookDelivery, error) {
+					require.Equal(t, delivery.ID, arg.ID)
+					tc.checkStatus(t, arg)
+					return delivery, nil
+				})
+
+			payload, err := json.Marshal(&worker.PayloadDeliverWebhook{DeliveryID: delivery.ID})
+			require.NoError(t, err)
+
+			processor := newWebhookTestProcessor(t, store)
+			err = processor.ProcessTaskDeliverWebhook(context.Background(), asynq.NewTask(worker.TaskDeliverWebhook, payload))
+			tc.checkResult(t, received, err)
+		})
+	}
+}
+
+func newWebhookTestProcessor(t *testing.T, store db.Store) *worker.MemoryTaskProcessor {
+	renderer, err := mail.NewRenderer("http://localhost:8080")
+	require.NoError(t, err)
+
+	// the test servers listen on the loopback address, which the endpoints can't use otherwise
+	webhooks := webhook.NewClient(time.Second, netip.MustParsePrefix("127.0.0.0/8"))
+	return worker.NewMemoryTaskProcessor(worker.NewMemoryQueue(worker.MemoryQueueConfig{}), store, &recordingSender{}, renderer, webhooks)
+}
@@ -0,0 +1,58 @@
+package worker
+
+import (
+	"context"
+
+	"github.com/hibiken/asynq"
+	"github.com/juker1141/simplebank/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const messagingSystem = "asynq"
+
+// startEnqueueSpan starts the span of enqueuing a task. The span continues the trace carried by the payload,
+// such as the trace of the request that wrote an outbox event, or else the trace of ctx.
+// It returns the payload with the trace context of the span, for the processor to continue the trace
+func startEnqueueSpan(ctx context.Context, taskType string, payload []byte) (context.Context, trace.Span, []byte) {
+	ctx = tracing.ExtractPayload(ctx, payload)
+	ctx, span := tracing.Tracer().Start(ctx, "enqueue "+taskType,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", messagingSystem),
+			attribute.String("messaging.operation", "publish"),
+			attribute.String("task.type", taskType),
+		),
+	)
+
+	return ctx, span, tracing.InjectPayload(ctx, payload)
+}
+
+// traceTask is a middleware that processes the tasks within a span,
+// which continues the trace of the span that enqueued the task
+func traceTask(handler asynq.Handler) asynq.Handler {
+	return asynq.HandlerFunc(func(ctx context.Context, task *asynq.Task) (err error) {
+		attributes := []attribute.KeyValue{
+			attribute.String("messaging.system", messagingSystem),
+			attribute.String("messaging.operation", "process"),
+			attribute.String("task.type", task.Type()),
+		}
+		if id, ok := asynq.GetTaskID(ctx); ok {
+			attributes = append(attributes, attribute.String("task.id", id))
+		}
+		if queue, ok := asynq.GetQueueName(ctx); ok {
+			attributes = append(attributes, attribute.String("messaging.destination.name", queue))
+		}
+
+		ctx = tracing.ExtractPayload(ctx, task.Payload())
+		ctx, span := tracing.Tracer().Start(ctx, "process "+task.Type(),
+			trace.WithSpanKind(trace.SpanKindConsumer),
+			trace.WithAttributes(attributes...),
+		)
+		defer func() {
+			tracing.End(span, err)
+		}()
+
+		return handler.ProcessTask(ctx, task)
+	})
+}
@@ -0,0 +1,63 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/hibiken/asynq"
+	"github.com/juker1141/simplebank/tracing"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTraceTask(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(trace.NewNoopTracerProvider())
+	})
+
+	// the request writes the task to the outbox with its trace context
+	requestCtx, request := tracing.Tracer().Start(context.Background(), "CreateUser")
+	payload := tracing.InjectPayload(requestCtx, []byte(`{"username":"alice"}`))
+	request.End()
+
+	// the relay enqueues the task without the context of the request
+	_, enqueue, payload := startEnqueueSpan(context.Background(), TaskSendVerifyEmail, payload)
+	enqueue.End()
+
+	taskErr := errors.New("smtp server is down")
+	var taskSpan trace.SpanContext
+	handler := traceTask(asynq.HandlerFunc(func(ctx context.Context, task *asynq.Task) error {
+		taskSpan = trace.SpanContextFromContext(ctx)
+		return taskErr
+	}))
+
+	err := handler.ProcessTask(context.Background(), asynq.NewTask(TaskSendVerifyEmail, payload))
+	require.ErrorIs(t, err, taskErr)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+	require.Equal(t, "CreateUser", spans[0].Name())
+	require.Equal(t, "enqueue "+TaskSendVerifyEmail, spans[1].Name())
+	require.Equal(t, "process "+TaskSendVerifyEmail, spans[2].Name())
+
+	traceID := spans[0].SpanContext().TraceID()
+	for _, span := range spans {
+		require.Equal(t, traceID, span.SpanContext().TraceID())
+	}
+	require.Equal(t, spans[0].SpanContext().SpanID(), spans[1].Parent().SpanID())
+	require.Equal(t, spans[1].SpanContext().SpanID(), spans[2].Parent().SpanID())
+	require.Equal(t, spans[2].SpanContext().SpanID(), taskSpan.SpanID())
+
+	require.Equal(t, trace.SpanKindConsumer, spans[2].SpanKind())
+	require.Equal(t, codes.Error, spans[2].Status().Code)
+	require.Equal(t, taskErr.Error(), spans[2].Status().Description)
+}